@@ -2,6 +2,7 @@ package main
 
 import (
 	"image"
+	"io"
 	"testing"
 	"time"
 
@@ -242,6 +243,55 @@ func BenchmarkFaceVerificationService_MemoryUsage(b *testing.B) {
 	}
 }
 
+// BenchmarkFaceVerificationService_FramePooling measures the frame buffers
+// recycled across requests: the RGBA conversions of YCbCr frames, as JPEG
+// decoding produces them, for liveness scoring, downscaling and the
+// recognizer. Once the pool is warm allocs/op and B/op drop by the
+// conversions a request would otherwise allocate.
+func BenchmarkFaceVerificationService_FramePooling(b *testing.B) {
+	frames := make([]image.Image, 5)
+	for i := range frames {
+		frames[i] = createBenchmarkYCbCrImage(640, 480)
+	}
+
+	run := func(b *testing.B, maxDimension int, decoder services.FrameDecoder) {
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(b), &config.Config{
+			LivenessThreshold:   0.85,
+			SimilarityThreshold: 0.75,
+			StoragePath:         b.TempDir(),
+			EncryptionKey:       "benchmark-encryption-key",
+			MaxFrameDimension:   maxDimension,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer service.Close()
+		if decoder != nil {
+			service.SetFrameDecoder(decoder)
+		}
+		videoData := createBenchmarkVideoData()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			req := &models.VerificationRequest{
+				VideoData: videoData,
+				SessionID: "pooling-session",
+			}
+			if _, err := service.VerifyVideo(req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("YCbCrFrames", func(b *testing.B) {
+		run(b, 0, &benchmarkDecoder{frames: frames})
+	})
+	b.Run("DownscaledFrames", func(b *testing.B) {
+		run(b, 320, nil)
+	})
+}
+
 // Helper functions for benchmarks
 
 func createBenchmarkVideoData() []byte {
@@ -277,6 +327,49 @@ func createBenchmarkImage(width, height int) image.Image {
 	return img
 }
 
+// benchmarkDecoder yields the same frames for any capture.
+type benchmarkDecoder struct {
+	frames []image.Image
+}
+
+type benchmarkFrames struct {
+	frames []image.Image
+}
+
+func (d *benchmarkDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	return &benchmarkFrames{frames: d.frames}, nil
+}
+
+func (f *benchmarkFrames) Next() (image.Image, error) {
+	if len(f.frames) == 0 {
+		return nil, io.EOF
+	}
+	frame := f.frames[0]
+	f.frames = f.frames[1:]
+	return frame, nil
+}
+
+func (f *benchmarkFrames) Close() error {
+	return nil
+}
+
+// createBenchmarkYCbCrImage is createBenchmarkImage in the 4:2:0 YCbCr
+// layout JPEG decoding produces, which analysis converts to RGBA.
+func createBenchmarkYCbCrImage(width, height int) image.Image {
+	src := createBenchmarkImage(width, height)
+	img := image.NewYCbCr(src.Bounds(), image.YCbCrSubsampleRatio420)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := src.At(x, y).RGBA()
+			yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+			img.Y[img.YOffset(x, y)] = yy
+			img.Cb[img.COffset(x, y)] = cb
+			img.Cr[img.COffset(x, y)] = cr
+		}
+	}
+	return img
+}
+
 func createBenchmarkVector(size int) []float32 {
 	vector := make([]float32, size)
 	for i := 0; i < size; i++ {
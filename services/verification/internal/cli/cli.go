@@ -0,0 +1,152 @@
+// Package cli is the verification binary's command line: the API server,
+// the queue worker and maintenance commands that work on the vector store
+// directly, for operators who need more than the HTTP API offers.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/logging"
+)
+
+// ServeFunc runs the API servers until the process is told to stop.
+type ServeFunc func(logger *zap.Logger, cfg *config.Config) error
+
+// env is what every command runs with. Configuration comes from the
+// environment and config file, exactly as for serve, so the commands find
+// the same store.
+type env struct {
+	logger *zap.Logger
+	cfg    *config.Config
+	serve  ServeFunc
+	stdout io.Writer
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(env *env, flags *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"serve", "Run the HTTP and gRPC APIs (the default)", runServe},
+	{"migrate", "Rewrite stored enrollments in the current storage format", runMigrate},
+	{"reindex", "Build the ANN index from stored enrollments and report any it cannot hold", runReindex},
+	{"export", "Write a user's stored enrollments as JSON", runExport},
+	{"migrate-descriptors", "Stamp legacy templates with the current recognizer models and list users to re-enroll", runMigrateDescriptors},
+	{"rotate-key", "Re-encrypt stored enrollments under ENCRYPTION_KEY", runRotateKey},
+	{"worker", "Run verification jobs from NATS JetStream instead of serving HTTP", runWorker},
+}
+
+// errUsage is returned for a malformed command line; the usage has been
+// printed already.
+var errUsage = errors.New("usage")
+
+// Run runs the command named by args[0], serve when there is none, and
+// returns the process exit code: 0 on success, 1 when the command failed
+// and 2 for a malformed command line.
+func Run(args []string, serve ServeFunc, stdout, stderr io.Writer) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage(stdout)
+		return 0
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("verification "+cmd.name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintln(stderr, "failed to create logger:", err)
+		return 1
+	}
+	defer logger.Sync()
+
+	// Every configuration problem is listed, one per line, so print them
+	// rather than log them
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "verification %s: %v\n", cmd.name, err)
+		return 1
+	}
+	redactor, err := logging.NewRedactor(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "verification %s: %v\n", cmd.name, err)
+		return 1
+	}
+	if redactor != nil {
+		logger = logger.WithOptions(zap.WrapCore(redactor.Wrap))
+	}
+
+	err = cmd.run(&env{logger: logger, cfg: cfg, serve: serve, stdout: stdout}, flags, args)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "verification %s: %v\n", cmd.name, err)
+		return 1
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: verification <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Configuration is read from the environment and CONFIG_FILE, as for serve.")
+	fmt.Fprintln(w, `Run "verification <command> -h" for the flags of a command.`)
+}
+
+// parse parses a command's flags, which take no positional arguments.
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func (e *env) print(v interface{}) error {
+	return printJSON(e.stdout, v)
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/queue"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrNoEnrollments = errors.New("user has no stored enrollments")
+
+func runServe(env *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	return env.serve(env.logger, env.cfg)
+}
+
+// runWorker consumes verification jobs until SIGINT or SIGTERM, finishing
+// the jobs in progress before it exits.
+func runWorker(env *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	faceService, err := services.NewFaceVerificationService(env.logger, env.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize face verification service: %w", err)
+	}
+	defer faceService.Close()
+
+	worker, err := queue.NewWorker(faceService, env.logger)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := worker.Run(ctx); err != nil {
+		return err
+	}
+
+	// Results of the last jobs may still be delivering to the webhook
+	drainCtx, cancel := context.WithTimeout(context.Background(), services.ShutdownDrainTimeout(env.cfg))
+	defer cancel()
+	return faceService.Drain(drainCtx)
+}
+
+func runMigrate(env *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	store, err := services.NewVectorStore(env.logger, env.cfg)
+	if err != nil {
+		return err
+	}
+	migrator, ok := store.(storage.Migrator)
+	if !ok {
+		return fmt.Errorf("storage type %q has no format to migrate", env.cfg.StorageType)
+	}
+
+	migration, err := migrator.Migrate()
+	if err != nil {
+		return err
+	}
+	migration.MigratedAt = time.Now().UTC()
+	return env.print(migration)
+}
+
+// runReindex fails when enrollments had to be skipped, after reporting
+// them, so scripted checks notice.
+func runReindex(env *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	store, err := services.NewVectorStore(env.logger, env.cfg)
+	if err != nil {
+		return err
+	}
+	gallery, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	rebuild := services.ReindexGallery(env.cfg, gallery)
+	if err := env.print(rebuild); err != nil {
+		return err
+	}
+	if len(rebuild.Skipped) > 0 {
+		return fmt.Errorf("%d enrollments cannot be indexed", len(rebuild.Skipped))
+	}
+	return nil
+}
+
+func runExport(env *env, flags *flag.FlagSet, args []string) error {
+	userID := flags.String("user", "", "ID of the user to export (required)")
+	tenantID := flags.String("tenant", "", "tenant of the user, empty for the default tenant")
+	output := flags.String("output", "", "file to write the export to instead of stdout")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		fmt.Fprintln(flags.Output(), "-user is required")
+		flags.Usage()
+		return errUsage
+	}
+	if *tenantID != "" && !tenant.ValidID(*tenantID) {
+		fmt.Fprintln(flags.Output(), "-tenant is not a valid tenant ID")
+		return errUsage
+	}
+
+	store, err := services.NewVectorStore(env.logger, env.cfg)
+	if err != nil {
+		return err
+	}
+	enrollments, err := store.Load(tenant.UserKey(*tenantID, *userID))
+	if err != nil {
+		return err
+	}
+	if len(enrollments) == 0 {
+		return ErrNoEnrollments
+	}
+	// Enrollments are stored under the tenant's user key; the subject
+	// knows only their own ID
+	for i := range enrollments {
+		enrollments[i].UserID = *userID
+	}
+	export := models.EnrollmentExport{
+		UserID:      *userID,
+		Tenant:      *tenantID,
+		Enrollments: enrollments,
+		ExportedAt:  time.Now().UTC(),
+	}
+
+	if *output == "" {
+		return env.print(export)
+	}
+	// The export holds biometric templates in the clear
+	file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = printJSON(file, export)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func runMigrateDescriptors(env *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if !services.ValidLegacyDescriptors(env.cfg.LegacyDescriptors) {
+		return fmt.Errorf("invalid LEGACY_DESCRIPTORS %q, expected current or stale", env.cfg.LegacyDescriptors)
+	}
+	modelVersion, err := services.DescriptorModelVersion(env.cfg)
+	if err != nil {
+		return err
+	}
+	store, err := services.NewVectorStore(env.logger, env.cfg)
+	if err != nil {
+		return err
+	}
+
+	migration, err := services.MigrateDescriptors(store, modelVersion, env.cfg.LegacyDescriptors)
+	if err != nil {
+		return err
+	}
+	return env.print(migration)
+}
+
+func runRotateKey(env *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	store, err := services.NewVectorStore(env.logger, env.cfg)
+	if err != nil {
+		return err
+	}
+	rotator, ok := store.(storage.KeyRotator)
+	if !ok {
+		return services.ErrKeyRotationUnsupported
+	}
+
+	rotation, err := rotator.RotateKey()
+	if err != nil {
+		return err
+	}
+	rotation.RotatedAt = time.Now().UTC()
+	return env.print(rotation)
+}
@@ -1,46 +1,756 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
+// ConfigFileEnv names the environment variable pointing at a YAML or JSON
+// config file. Without it, config.yaml, config.yml or config.json in the
+// working directory is read when present.
+const ConfigFileEnv = "CONFIG_FILE"
+
+var defaultConfigFiles = []string{"config.yaml", "config.yml", "config.json"}
+
 type Config struct {
 	Port        int    `mapstructure:"PORT"`
 	Environment string `mapstructure:"ENVIRONMENT"`
 	DatabaseURL string `mapstructure:"DATABASE_URL"`
 
+	// gRPC API for service-to-service calls, on its own port
+	GRPCEnabled bool `mapstructure:"GRPC_ENABLED"`
+	GRPCPort    int  `mapstructure:"GRPC_PORT"`
+
+	// HTTPS on PORT, from a certificate and key reloaded when they change,
+	// or from Let's Encrypt for the listed domains (comma-separated)
+	TLSCertFile        string `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile         string `mapstructure:"TLS_KEY_FILE"`
+	TLSAutocertDomains string `mapstructure:"TLS_AUTOCERT_DOMAINS"`
+	TLSAutocertEmail   string `mapstructure:"TLS_AUTOCERT_EMAIL"`
+	TLSAutocertCache   string `mapstructure:"TLS_AUTOCERT_CACHE"`
+	// Client certificates verified against a CA bundle, "optional" or
+	// "require"d, and the roles of their identities as identity=role pairs
+	TLSClientCAFile     string `mapstructure:"TLS_CLIENT_CA_FILE"`
+	TLSClientAuth       string `mapstructure:"TLS_CLIENT_AUTH"`
+	TLSClientIdentities string `mapstructure:"TLS_CLIENT_IDENTITIES"`
+
+	// Hash or mask personal data in logs ("on" or "off"), keyed with
+	// LOG_REDACTION_KEY, except for the allowlisted fields and the extra
+	// ones listed in LOG_REDACTION_ALLOWLIST (comma-separated)
+	LogRedaction          string `mapstructure:"LOG_REDACTION"`
+	LogRedactionKey       string `mapstructure:"LOG_REDACTION_KEY"`
+	LogRedactionAllowlist string `mapstructure:"LOG_REDACTION_ALLOWLIST"`
+
 	// Face recognition settings
-	FaceModelPath     string  `mapstructure:"FACE_MODEL_PATH"`
-	LivenessThreshold float64 `mapstructure:"LIVENESS_THRESHOLD"`
-	SimilarityThreshold float64 `mapstructure:"SIMILARITY_THRESHOLD"`
+	FaceModelPath string `mapstructure:"FACE_MODEL_PATH"`
+	// Retry recognizer initialization while the model mount comes up
+	RecognizerInitAttempts     int `mapstructure:"RECOGNIZER_INIT_ATTEMPTS"`
+	RecognizerInitRetryDelayMs int `mapstructure:"RECOGNIZER_INIT_RETRY_DELAY_MS"`
+	// Recognizer instances for parallel detection, loaded on demand, and
+	// how often idle ones are probed (seconds)
+	RecognizerPoolSize            int `mapstructure:"RECOGNIZER_POOL_SIZE"`
+	RecognizerHealthCheckInterval int `mapstructure:"RECOGNIZER_HEALTH_CHECK_INTERVAL"`
+	// Detect faces with dlib's CNN detector on the GPU (needs a build with
+	// -tags cuda), and how many frames of a capture are described together
+	// and averaged into its descriptor
+	RecognizerGPU    bool `mapstructure:"RECOGNIZER_GPU"`
+	DescriptorFrames int  `mapstructure:"DESCRIPTOR_FRAMES"`
+	// Where descriptors are computed: in process with dlib ("dlib") or by a
+	// KServe v2 inference server at EmbedderAddr ("remote"), which is sent
+	// square RGB face crops of EmbedderInputSize and returns the embedding
+	EmbedderBackend      string  `mapstructure:"EMBEDDER_BACKEND"`
+	EmbedderAddr         string  `mapstructure:"EMBEDDER_ADDR"`
+	EmbedderTLS          bool    `mapstructure:"EMBEDDER_TLS"`
+	EmbedderModel        string  `mapstructure:"EMBEDDER_MODEL"`
+	EmbedderModelVersion string  `mapstructure:"EMBEDDER_MODEL_VERSION"`
+	EmbedderInputName    string  `mapstructure:"EMBEDDER_INPUT_NAME"`
+	EmbedderOutputName   string  `mapstructure:"EMBEDDER_OUTPUT_NAME"`
+	EmbedderInputSize    int     `mapstructure:"EMBEDDER_INPUT_SIZE"`
+	EmbedderTimeoutMs    int     `mapstructure:"EMBEDDER_TIMEOUT_MS"`
+	LivenessThreshold    float64 `mapstructure:"LIVENESS_THRESHOLD"`
+	SimilarityThreshold  float64 `mapstructure:"SIMILARITY_THRESHOLD"`
+	// Quantize compact templates to int8 (~4x smaller than float32)
+	TemplateQuantization bool `mapstructure:"TEMPLATE_QUANTIZATION"`
+	// Early rejection of covered / no-signal cameras
+	CameraCheckEnabled  bool    `mapstructure:"CAMERA_CHECK_ENABLED"`
+	CameraMinBrightness float64 `mapstructure:"CAMERA_MIN_BRIGHTNESS"`
+	CameraMinVariance   float64 `mapstructure:"CAMERA_MIN_VARIANCE"`
+	// Abort extraction when any single frame takes longer than this to decode
+	FrameDecodeBudgetMs int `mapstructure:"FRAME_DECODE_BUDGET_MS"`
+	// Longest side, in pixels, frames are downscaled to before analysis;
+	// 0 analyzes them at full size
+	MaxFrameDimension int `mapstructure:"MAX_FRAME_DIMENSION"`
+	// Reject captures whose motion contradicts the declared action
+	ActionCheckEnabled bool    `mapstructure:"ACTION_CHECK_ENABLED"`
+	ActionMinMotion    float64 `mapstructure:"ACTION_MIN_MOTION"`
+	// Active challenge-response liveness: require a session from
+	// /liveness/session on every verification, and how long one is valid
+	LivenessChallengeRequired bool `mapstructure:"LIVENESS_CHALLENGE_REQUIRED"`
+	LivenessSessionTTL        int  `mapstructure:"LIVENESS_SESSION_TTL"`
+	// Bind session nonces into captures with a screen color sequence; every
+	// verification then needs a session
+	NonceBindingEnabled bool `mapstructure:"NONCE_BINDING_ENABLED"`
+	// Weight of the landmark blink signal in the liveness score (0 disables)
+	// and the eye aspect ratio below which eyes count as closed
+	BlinkWeight       float64 `mapstructure:"BLINK_WEIGHT"`
+	BlinkEARThreshold float64 `mapstructure:"BLINK_EAR_THRESHOLD"`
+	// Frequency-domain screen replay (moiré / refresh banding) detection:
+	// weight of 1 - replay_score in the liveness score, and the replay_score
+	// that fails liveness on its own
+	ReplayDetectionEnabled bool    `mapstructure:"REPLAY_DETECTION_ENABLED"`
+	ReplayWeight           float64 `mapstructure:"REPLAY_WEIGHT"`
+	ReplayThreshold        float64 `mapstructure:"REPLAY_THRESHOLD"`
+	// Virtual camera and stream injection detection: captures whose
+	// injection risk reaches InjectionThreshold are rejected; encoders are
+	// comma-separated markers whose presence in the container is suspicious
+	InjectionDetectionEnabled   bool    `mapstructure:"INJECTION_DETECTION_ENABLED"`
+	InjectionThreshold          float64 `mapstructure:"INJECTION_THRESHOLD"`
+	InjectionSuspiciousEncoders string  `mapstructure:"INJECTION_SUSPICIOUS_ENCODERS"`
+	// Liveness detector ensemble as "name:weight,..."; empty derives it from
+	// BLINK_WEIGHT and REPLAY_WEIGHT
+	LivenessDetectors string `mapstructure:"LIVENESS_DETECTORS"`
+	// Passive anti-spoofing CNN run through onnxruntime: model file, runtime
+	// shared library, square input side, index of the "live" output class
+	// and its share of the default liveness score
+	ONNXLivenessModelPath string  `mapstructure:"ONNX_LIVENESS_MODEL_PATH"`
+	ONNXRuntimeLibPath    string  `mapstructure:"ONNX_RUNTIME_LIB_PATH"`
+	ONNXInputSize         int     `mapstructure:"ONNX_INPUT_SIZE"`
+	ONNXLiveClass         int     `mapstructure:"ONNX_LIVE_CLASS"`
+	ONNXLivenessWeight    float64 `mapstructure:"ONNX_LIVENESS_WEIGHT"`
+	// Non-fatal warnings on results: scores within WARNING_MARGIN of their
+	// threshold and captures dimmer than WARNING_MIN_BRIGHTNESS
+	WarningsEnabled      bool    `mapstructure:"WARNINGS_ENABLED"`
+	WarningMargin        float64 `mapstructure:"WARNING_MARGIN"`
+	WarningMinBrightness float64 `mapstructure:"WARNING_MIN_BRIGHTNESS"`
+	// Piecewise-linear "raw:calibrated,..." curve applied to match confidence
+	ConfidenceCalibration string `mapstructure:"CONFIDENCE_CALIBRATION"`
+
+	// Serve the OpenAPI document at /openapi.json
+	OpenAPIEnabled bool `mapstructure:"OPENAPI_ENABLED"`
+
+	// Locale for reason guidance when Accept-Language has no supported match
+	DefaultLocale string `mapstructure:"DEFAULT_LOCALE"`
+
+	// Data residency: region this instance processes in, and the regions
+	// clients may declare (comma-separated, empty allows any)
+	Region         string `mapstructure:"REGION"`
+	AllowedRegions string `mapstructure:"ALLOWED_REGIONS"`
+
+	// HS256 secret for user bearer tokens (enables self-service endpoints)
+	JWTSecret string `mapstructure:"JWT_SECRET"`
+	// JWKS endpoint of an external token issuer, such as the connect-hub
+	// auth service; its RS/ES-signed tokens are accepted on every API call
+	// and must carry the issuer and audience when set. The tenant claim
+	// stands in for a tenant API key, and keys are refetched every
+	// JWT_JWKS_REFRESH seconds
+	JWTJWKSURL     string `mapstructure:"JWT_JWKS_URL"`
+	JWTIssuer      string `mapstructure:"JWT_ISSUER"`
+	JWTAudience    string `mapstructure:"JWT_AUDIENCE"`
+	JWTTenantClaim string `mapstructure:"JWT_TENANT_CLAIM"`
+	JWTJWKSRefresh int    `mapstructure:"JWT_JWKS_REFRESH"`
+	// Claim listing the roles of a bearer token (verify, enroll, reviewer,
+	// admin); tokens naming none get verify and enroll
+	JWTRolesClaim string `mapstructure:"JWT_ROLES_CLAIM"`
+
+	// Admin callers presenting this key via X-Admin-Key see unredacted results
+	AdminAPIKey string `mapstructure:"ADMIN_API_KEY"`
+
+	// Multi-tenancy (comma-separated tenant:value lists). With API keys
+	// assigned, every API caller must present one and sees only its
+	// tenant's gallery; thresholds and per-minute rate limits override the
+	// global ones for a tenant
+	TenantAPIKeys              string `mapstructure:"TENANT_API_KEYS"`
+	TenantSimilarityThresholds string `mapstructure:"TENANT_SIMILARITY_THRESHOLDS"`
+	TenantLivenessThresholds   string `mapstructure:"TENANT_LIVENESS_THRESHOLDS"`
+	TenantRateLimits           string `mapstructure:"TENANT_RATE_LIMITS"`
+	// File the overrides set through /api/v1/admin/tenants are kept in
+	TenantConfigPath string `mapstructure:"TENANT_CONFIG_PATH"`
+
+	// Compliance audit export: default format ("csv" or "json"), default
+	// comma-separated columns, and HMAC key signing the export manifest
+	AuditExportFormat string `mapstructure:"AUDIT_EXPORT_FORMAT"`
+	AuditExportFields string `mapstructure:"AUDIT_EXPORT_FIELDS"`
+	AuditSigningKey   string `mapstructure:"AUDIT_SIGNING_KEY"`
+	// Append-only trail of register/verify/identify/delete operations,
+	// written to STORAGE_PATH/audit.log unless a path is given
+	AuditLogEnabled bool   `mapstructure:"AUDIT_LOG_ENABLED"`
+	AuditLogPath    string `mapstructure:"AUDIT_LOG_PATH"`
+	// Monthly usage metering per API key, kept in STORAGE_PATH/usage.json
+	// unless a path is given, and quotas as "key:limit,..." pairs where the
+	// key "*" sets the limit of every other key
+	UsagePath   string `mapstructure:"USAGE_PATH"`
+	UsageQuotas string `mapstructure:"USAGE_QUOTAS"`
+
+	// Signed attestation tokens on verification results: PEM-encoded P-256
+	// private key (or a path to one), its JWKS key ID, the iss claim and
+	// token lifetime in seconds
+	AttestationEnabled    bool   `mapstructure:"ATTESTATION_ENABLED"`
+	AttestationSigningKey string `mapstructure:"ATTESTATION_SIGNING_KEY"`
+	AttestationKeyID      string `mapstructure:"ATTESTATION_KEY_ID"`
+	AttestationIssuer     string `mapstructure:"ATTESTATION_ISSUER"`
+	AttestationTTL        int    `mapstructure:"ATTESTATION_TTL"`
 
 	// Storage settings
-	StorageType      string `mapstructure:"STORAGE_TYPE"`
-	EncryptionKey    string `mapstructure:"ENCRYPTION_KEY"`
-	StoragePath      string `mapstructure:"STORAGE_PATH"`
+	StorageType   string `mapstructure:"STORAGE_TYPE"`
+	EncryptionKey string `mapstructure:"ENCRYPTION_KEY"`
+	StoragePath   string `mapstructure:"STORAGE_PATH"`
+	// How replicas sharing the vector store pick up each other's writes:
+	// "off" reloads the whole gallery after every local write, "redis"
+	// announces changed users over REDIS_URL pub/sub so every replica
+	// reloads just those
+	GallerySync string `mapstructure:"GALLERY_SYNC"`
+	// ID stored with data encrypted under ENCRYPTION_KEY, and retired
+	// "id:secret" keys still accepted for decryption during a rotation
+	EncryptionKeyID        string `mapstructure:"ENCRYPTION_KEY_ID"`
+	EncryptionPreviousKeys string `mapstructure:"ENCRYPTION_PREVIOUS_KEYS"`
+	// Where the data-encryption key comes from: "env" (ENCRYPTION_KEY),
+	// "aws-kms", "gcp-kms" or "vault", which unwrap ENCRYPTION_KEY_CIPHERTEXT.
+	// The unwrapped key is cached for STORAGE_KEY_CACHE_TTL seconds
+	StorageKeyProvider      string `mapstructure:"STORAGE_KEY_PROVIDER"`
+	EncryptionKeyCiphertext string `mapstructure:"ENCRYPTION_KEY_CIPHERTEXT"`
+	StorageKeyCacheTTL      int    `mapstructure:"STORAGE_KEY_CACHE_TTL"`
+	// GCP crypto key resource name or Vault transit key name, and an
+	// optional AWS/GCP KMS endpoint override
+	KMSKeyName         string `mapstructure:"KMS_KEY_NAME"`
+	KMSEndpoint        string `mapstructure:"KMS_ENDPOINT"`
+	AWSRegion          string `mapstructure:"AWS_REGION"`
+	AWSAccessKeyID     string `mapstructure:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey string `mapstructure:"AWS_SECRET_ACCESS_KEY"`
+	AWSSessionToken    string `mapstructure:"AWS_SESSION_TOKEN"`
+	// Bearer token for GCP KMS; the metadata server is used when unset
+	GCPAccessToken    string `mapstructure:"GCP_ACCESS_TOKEN"`
+	VaultAddr         string `mapstructure:"VAULT_ADDR"`
+	VaultToken        string `mapstructure:"VAULT_TOKEN"`
+	VaultTransitMount string `mapstructure:"VAULT_TRANSIT_MOUNT"`
+	// Seal each user's enrollments under their own key so erasure can
+	// crypto-shred copies of them
+	PerUserKeys bool `mapstructure:"PER_USER_KEYS"`
+	// Seconds to wait for the advisory lock on the shared vector file
+	StorageLockTimeout int `mapstructure:"STORAGE_LOCK_TIMEOUT"`
+	// Bytes of appended enrollments after which the log is compacted into
+	// the vector file
+	VectorLogCompactionSize int `mapstructure:"VECTOR_LOG_COMPACTION_SIZE"`
+
+	// Object store for captures uploaded via pre-signed URL. The s3 store
+	// signs with the AWS_* credentials, the gcs store with an HMAC key;
+	// ObjectStoreURL overrides their endpoint. Pre-signed uploads expire
+	// after ObjectUploadTTL seconds
+	ObjectStoreType   string `mapstructure:"OBJECT_STORE_TYPE"`
+	ObjectStorePath   string `mapstructure:"OBJECT_STORE_PATH"`
+	ObjectStoreURL    string `mapstructure:"OBJECT_STORE_URL"`
+	ObjectStoreBucket string `mapstructure:"OBJECT_STORE_BUCKET"`
+	ObjectKeyPrefix   string `mapstructure:"OBJECT_KEY_PREFIX"`
+	GCSHMACAccessID   string `mapstructure:"GCS_HMAC_ACCESS_ID"`
+	GCSHMACSecret     string `mapstructure:"GCS_HMAC_SECRET"`
+	ObjectUploadTTL   int    `mapstructure:"OBJECT_UPLOAD_TTL"`
+
+	// Idempotency-Key support on /verify and /register: first responses are
+	// kept for IdempotencyTTL seconds in memory or, shared by replicas, in
+	// Redis at RedisURL
+	IdempotencyEnabled bool   `mapstructure:"IDEMPOTENCY_ENABLED"`
+	IdempotencyStore   string `mapstructure:"IDEMPOTENCY_STORE"`
+	IdempotencyTTL     int    `mapstructure:"IDEMPOTENCY_TTL"`
+	RedisURL           string `mapstructure:"REDIS_URL"`
+
+	// Admin self-benchmark guard rails
+	SelfBenchAllowProduction bool `mapstructure:"SELFBENCH_ALLOW_PRODUCTION"`
+	SelfBenchMaxRequests     int  `mapstructure:"SELFBENCH_MAX_REQUESTS"`
+	SelfBenchCooldown        int  `mapstructure:"SELFBENCH_COOLDOWN"`
 
 	// Performance settings
 	MaxConcurrentRequests int `mapstructure:"MAX_CONCURRENT_REQUESTS"`
+	RequestQueueDepth     int `mapstructure:"REQUEST_QUEUE_DEPTH"`
 	ProcessingTimeout     int `mapstructure:"PROCESSING_TIMEOUT"`
+
+	// Shutdown: seconds /readyz reports draining before the listeners
+	// close, and seconds allowed for in-flight requests, queued async jobs
+	// and webhook deliveries to finish
+	ShutdownDelay        int `mapstructure:"SHUTDOWN_DELAY"`
+	ShutdownDrainTimeout int `mapstructure:"SHUTDOWN_DRAIN_TIMEOUT"`
+
+	// Startup warmup: every recognizer of the pool is loaded and synthetic
+	// verifications are run (one per recognizer when WarmupRequests is 0)
+	// before /readyz reports ready, within WarmupTimeout seconds
+	WarmupEnabled  bool `mapstructure:"WARMUP_ENABLED"`
+	WarmupRequests int  `mapstructure:"WARMUP_REQUESTS"`
+	WarmupTimeout  int  `mapstructure:"WARMUP_TIMEOUT"`
+
+	// Per-stage deadlines inside a verification, in milliseconds
+	FrameExtractionTimeoutMs int `mapstructure:"FRAME_EXTRACTION_TIMEOUT_MS"`
+	AnalysisTimeoutMs        int `mapstructure:"ANALYSIS_TIMEOUT_MS"`
+
+	// Per-client (API key or IP) rate limiting
+	RateLimitPerMinute  int `mapstructure:"RATE_LIMIT_PER_MINUTE"`
+	RateLimitBurst      int `mapstructure:"RATE_LIMIT_BURST"`
+	RateLimitMaxClients int `mapstructure:"RATE_LIMIT_MAX_CLIENTS"`
+
+	// Cross-origin access (comma-separated lists). Origins may be exact,
+	// wildcard subdomains (https://*.example.com) or *; with none listed
+	// any origin is allowed outside production and none in it
+	CORSAllowedOrigins   string `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods   string `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders   string `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSAllowCredentials bool   `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+
+	// Refuse enrollments outside a supervised onboarding window (admins can
+	// toggle this at runtime); verification is unaffected
+	EnrollmentDisabled bool `mapstructure:"ENROLLMENT_DISABLED"`
+	// Seconds an enrollment must age before it can be matched against
+	MinEnrollmentAge int `mapstructure:"MIN_ENROLLMENT_AGE"`
+	// Enrollment quality gate: minimum face width as a fraction of the
+	// frame, Laplacian variance (sharpness), face luminance range (0-1) and
+	// maximum head turn or tilt in degrees
+	EnrollmentQualityEnabled bool    `mapstructure:"ENROLLMENT_QUALITY_ENABLED"`
+	QualityMinFaceSize       float64 `mapstructure:"QUALITY_MIN_FACE_SIZE"`
+	QualityMinSharpness      float64 `mapstructure:"QUALITY_MIN_SHARPNESS"`
+	QualityMinBrightness     float64 `mapstructure:"QUALITY_MIN_BRIGHTNESS"`
+	QualityMaxBrightness     float64 `mapstructure:"QUALITY_MAX_BRIGHTNESS"`
+	QualityMaxPoseAngle      float64 `mapstructure:"QUALITY_MAX_POSE_ANGLE"`
+	// Cross-user duplicate face check on enrollment: "off", "flag" or
+	// "reject", and the raw similarity to another user's enrollment that
+	// counts as the same face
+	DuplicateIdentityCheck     string  `mapstructure:"DUPLICATE_IDENTITY_CHECK"`
+	DuplicateIdentityThreshold float64 `mapstructure:"DUPLICATE_IDENTITY_THRESHOLD"`
+	// How a user's enrolled descriptors are fused into templates: "all",
+	// "average" or "diverse", and how many templates "diverse" keeps
+	EnrollmentFusion       string `mapstructure:"ENROLLMENT_FUSION"`
+	EnrollmentMaxTemplates int    `mapstructure:"ENROLLMENT_MAX_TEMPLATES"`
+	// Refresh templates from verified captures: once a template is older
+	// than TemplateMaxAge days, or when a capture's quality score beats the
+	// best template's by TemplateQualityMargin. Captures must reach
+	// TemplateRefreshMinSimilarity raw similarity.
+	TemplateRefreshEnabled       bool    `mapstructure:"TEMPLATE_REFRESH_ENABLED"`
+	TemplateMaxAge               int     `mapstructure:"TEMPLATE_MAX_AGE"`
+	TemplateQualityMargin        float64 `mapstructure:"TEMPLATE_QUALITY_MARGIN"`
+	TemplateRefreshMinSimilarity float64 `mapstructure:"TEMPLATE_REFRESH_MIN_SIMILARITY"`
+	// Whether templates stored before descriptors recorded their model
+	// count as made by the current one ("current") or not ("stale")
+	LegacyDescriptors string `mapstructure:"LEGACY_DESCRIPTORS"`
+	// Encrypted watchlist of known fraudsters' faces every verification is
+	// screened against, and the raw similarity that counts as a hit
+	WatchlistPath      string  `mapstructure:"WATCHLIST_PATH"`
+	WatchlistThreshold float64 `mapstructure:"WATCHLIST_THRESHOLD"`
+	// Gray zones of the raw confidence and the liveness score, inclusive,
+	// in which verifications are held for manual review; a zero maximum
+	// disables a zone
+	ReviewConfidenceMin float64 `mapstructure:"REVIEW_CONFIDENCE_MIN"`
+	ReviewConfidenceMax float64 `mapstructure:"REVIEW_CONFIDENCE_MAX"`
+	ReviewLivenessMin   float64 `mapstructure:"REVIEW_LIVENESS_MIN"`
+	ReviewLivenessMax   float64 `mapstructure:"REVIEW_LIVENESS_MAX"`
+	// JSON risk policy weighing every verification's signals into a risk
+	// score and decision; empty disables risk scoring
+	RiskPolicyPath string `mapstructure:"RISK_POLICY_PATH"`
+	// Distinct accounts one face, device or client IP may verify as within
+	// sliding windows, as "window:accounts" pairs such as "1h:3,24h:5"
+	// (empty disables the check), counted in memory or, shared by replicas,
+	// in Redis at RedisURL. Captures are the same face when their raw
+	// similarity reaches VelocityFaceThreshold
+	VelocityLimits        string  `mapstructure:"VELOCITY_LIMITS"`
+	VelocityStore         string  `mapstructure:"VELOCITY_STORE"`
+	VelocityFaceThreshold float64 `mapstructure:"VELOCITY_FACE_THRESHOLD"`
+	// Consecutive failed verifications after which a user, or a client IP,
+	// is refused with 423 for LockoutCooldown seconds; 0 disables either
+	LockoutThreshold       int `mapstructure:"LOCKOUT_THRESHOLD"`
+	LockoutClientThreshold int `mapstructure:"LOCKOUT_CLIENT_THRESHOLD"`
+	LockoutCooldown        int `mapstructure:"LOCKOUT_COOLDOWN"`
+	// HNSW index over the gallery for 1:N searches; efSearch trades recall
+	// for latency
+	AnnIndexEnabled bool `mapstructure:"ANN_INDEX_ENABLED"`
+	AnnEfSearch     int  `mapstructure:"ANN_EF_SEARCH"`
+
+	// Worker pool for mode=async verifications (0 disables async mode) and
+	// the file job state is mirrored to so status survives restarts
+	AsyncWorkers      int    `mapstructure:"ASYNC_WORKERS"`
+	AsyncQueueSize    int    `mapstructure:"ASYNC_QUEUE_SIZE"`
+	AsyncJobStatePath string `mapstructure:"ASYNC_JOB_STATE_PATH"`
+
+	// Reject verifications reusing a session_id that is still in flight
+	EnforceUniqueSessions bool `mapstructure:"ENFORCE_UNIQUE_SESSIONS"`
+	SessionLockTTL        int  `mapstructure:"SESSION_LOCK_TTL"`
+
+	// Verification from client pre-extracted JPEG frames
+	FrameSubmissionEnabled bool `mapstructure:"FRAME_SUBMISSION_ENABLED"`
+	MinSubmittedFrames     int  `mapstructure:"MIN_SUBMITTED_FRAMES"`
+	MaxSubmittedFrames     int  `mapstructure:"MAX_SUBMITTED_FRAMES"`
+	MaxFrameSize           int  `mapstructure:"MAX_FRAME_SIZE"`
+
+	// Matching of a single selfie image without liveness, and the largest
+	// image in bytes
+	ImageVerificationEnabled bool `mapstructure:"IMAGE_VERIFICATION_ENABLED"`
+	MaxImageSize             int  `mapstructure:"MAX_IMAGE_SIZE"`
+
+	// 1:1 comparison of a selfie capture with a document photo: match
+	// threshold (0 uses SIMILARITY_THRESHOLD) and largest document in bytes
+	CompareSimilarityThreshold float64 `mapstructure:"COMPARE_SIMILARITY_THRESHOLD"`
+	CompareMaxDocumentSize     int     `mapstructure:"COMPARE_MAX_DOCUMENT_SIZE"`
+	// OCR of uploaded identity documents: "tesseract" or "none", the
+	// tesseract binary and its language (e.g. "mrz" or "eng")
+	DocumentOCREngine string `mapstructure:"DOCUMENT_OCR_ENGINE"`
+	TesseractPath     string `mapstructure:"TESSERACT_PATH"`
+	TesseractLanguage string `mapstructure:"TESSERACT_LANGUAGE"`
+
+	// Largest accepted capture in bytes; request bodies past it get 413
+	MaxUploadSize int64 `mapstructure:"MAX_UPLOAD_SIZE"`
+
+	// Live verification over a WebSocket at /api/v1/verify/live
+	LiveVerificationEnabled bool `mapstructure:"LIVE_VERIFICATION_ENABLED"`
+	LiveMaxFrames           int  `mapstructure:"LIVE_MAX_FRAMES"`
+	LiveIdleTimeout         int  `mapstructure:"LIVE_IDLE_TIMEOUT"`
+
+	// Two-phase verification: liveness first, matching later via a token
+	LivenessPrecheckEnabled bool `mapstructure:"LIVENESS_PRECHECK_ENABLED"`
+	ContinuationTTL         int  `mapstructure:"CONTINUATION_TTL"`
+
+	// Seconds identical verifications share one pipeline run (0 disables);
+	// DEDUP_KEY is "video" (content + user) or "user"
+	DedupWindow int    `mapstructure:"DEDUP_WINDOW"`
+	DedupKey    string `mapstructure:"DEDUP_KEY"`
+	// Seconds the liveness and descriptor found in a payload are reused for
+	// identical re-submissions (0 disables), kept in an in-memory LRU of
+	// DescriptorCacheSize entries or, shared by replicas, in Redis at RedisURL
+	DescriptorCacheTTL   int    `mapstructure:"DESCRIPTOR_CACHE_TTL"`
+	DescriptorCacheStore string `mapstructure:"DESCRIPTOR_CACHE_STORE"`
+	DescriptorCacheSize  int    `mapstructure:"DESCRIPTOR_CACHE_SIZE"`
+
+	// POST each verification result to this URL, signed with the secret
+	WebhookURL            string `mapstructure:"WEBHOOK_URL"`
+	WebhookSecret         string `mapstructure:"WEBHOOK_SECRET"`
+	WebhookMaxAttempts    int    `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookRetryBackoffMs int    `mapstructure:"WEBHOOK_RETRY_BACKOFF_MS"`
+	WebhookTimeout        int    `mapstructure:"WEBHOOK_TIMEOUT"`
+
+	// Publish verification and registration events to a Kafka topic,
+	// relayed from an outbox file (empty brokers disables publishing)
+	KafkaBrokers        string `mapstructure:"KAFKA_BROKERS"`
+	KafkaTopic          string `mapstructure:"KAFKA_TOPIC"`
+	KafkaOutboxPath     string `mapstructure:"KAFKA_OUTBOX_PATH"`
+	KafkaPollIntervalMs int    `mapstructure:"KAFKA_POLL_INTERVAL_MS"`
+
+	// The worker command takes verification jobs from a JetStream pull
+	// consumer and publishes results to NATSResultSubject
+	NATSURL           string `mapstructure:"NATS_URL"`
+	NATSStream        string `mapstructure:"NATS_STREAM"`
+	NATSConsumer      string `mapstructure:"NATS_CONSUMER"`
+	NATSResultSubject string `mapstructure:"NATS_RESULT_SUBJECT"`
+	WorkerConcurrency int    `mapstructure:"WORKER_CONCURRENCY"`
+
+	// HTTP caching of verify decisions via ETag / If-None-Match
+	ETagCachingEnabled bool `mapstructure:"ETAG_CACHING_ENABLED"`
+	ResultCacheTTL     int  `mapstructure:"RESULT_CACHE_TTL"`
+
+	// Shadow evaluation of a candidate pipeline on sampled traffic
+	CanaryFraction            float64 `mapstructure:"CANARY_FRACTION"`
+	CanaryLivenessThreshold   float64 `mapstructure:"CANARY_LIVENESS_THRESHOLD"`
+	CanarySimilarityThreshold float64 `mapstructure:"CANARY_SIMILARITY_THRESHOLD"`
+	// Candidate dlib models and ONNX liveness model scored in shadow mode
+	CanaryFaceModelPath         string `mapstructure:"CANARY_FACE_MODEL_PATH"`
+	CanaryONNXLivenessModelPath string `mapstructure:"CANARY_ONNX_LIVENESS_MODEL_PATH"`
+
+	// Model drift monitoring against operator-supplied baselines
+	DriftMonitorEnabled     bool    `mapstructure:"DRIFT_MONITOR_ENABLED"`
+	DriftBaselineConfidence float64 `mapstructure:"DRIFT_BASELINE_CONFIDENCE"`
+	DriftBaselineLiveness   float64 `mapstructure:"DRIFT_BASELINE_LIVENESS"`
+	DriftTolerance          float64 `mapstructure:"DRIFT_TOLERANCE"`
+	DriftWindowSize         int     `mapstructure:"DRIFT_WINDOW_SIZE"`
+	DriftCheckInterval      int     `mapstructure:"DRIFT_CHECK_INTERVAL"`
+
+	// Evaluation dataset export (scalar liveness features only)
+	DatasetExportEnabled bool   `mapstructure:"DATASET_EXPORT_ENABLED"`
+	DatasetExportPath    string `mapstructure:"DATASET_EXPORT_PATH"`
 }
 
+// Load reads the configuration from the environment and the config file, if
+// any, and validates it. Environment variables take precedence over the
+// file, which takes precedence over the defaults.
 func Load() (*Config, error) {
+	viper.Reset()
 	viper.SetDefault("PORT", 8080)
+	viper.SetDefault("GRPC_ENABLED", false)
+	viper.SetDefault("GRPC_PORT", 9090)
+	viper.SetDefault("TLS_CERT_FILE", "")
+	viper.SetDefault("TLS_KEY_FILE", "")
+	viper.SetDefault("TLS_AUTOCERT_DOMAINS", "")
+	viper.SetDefault("TLS_AUTOCERT_EMAIL", "")
+	viper.SetDefault("TLS_AUTOCERT_CACHE", "./storage/autocert")
+	viper.SetDefault("TLS_CLIENT_CA_FILE", "")
+	viper.SetDefault("TLS_CLIENT_AUTH", "optional")
+	viper.SetDefault("TLS_CLIENT_IDENTITIES", "")
+	viper.SetDefault("LOG_REDACTION", "off")
+	viper.SetDefault("LOG_REDACTION_KEY", "")
+	viper.SetDefault("LOG_REDACTION_ALLOWLIST", "")
 	viper.SetDefault("ENVIRONMENT", "development")
 	viper.SetDefault("FACE_MODEL_PATH", "./models")
+	viper.SetDefault("RECOGNIZER_INIT_ATTEMPTS", 1)
+	viper.SetDefault("RECOGNIZER_INIT_RETRY_DELAY_MS", 1000)
+	viper.SetDefault("RECOGNIZER_POOL_SIZE", 1)
+	viper.SetDefault("RECOGNIZER_HEALTH_CHECK_INTERVAL", 60)
+	viper.SetDefault("RECOGNIZER_GPU", false)
+	viper.SetDefault("DESCRIPTOR_FRAMES", 1)
+	viper.SetDefault("EMBEDDER_BACKEND", "dlib")
+	viper.SetDefault("EMBEDDER_ADDR", "")
+	viper.SetDefault("EMBEDDER_TLS", false)
+	viper.SetDefault("EMBEDDER_MODEL", "")
+	viper.SetDefault("EMBEDDER_MODEL_VERSION", "")
+	viper.SetDefault("EMBEDDER_INPUT_NAME", "input")
+	viper.SetDefault("EMBEDDER_OUTPUT_NAME", "embedding")
+	viper.SetDefault("EMBEDDER_INPUT_SIZE", 112)
+	viper.SetDefault("EMBEDDER_TIMEOUT_MS", 2000)
 	viper.SetDefault("LIVENESS_THRESHOLD", 0.85)
 	viper.SetDefault("SIMILARITY_THRESHOLD", 0.75)
+	viper.SetDefault("TEMPLATE_QUANTIZATION", true)
+	viper.SetDefault("CAMERA_CHECK_ENABLED", true)
+	viper.SetDefault("CAMERA_MIN_BRIGHTNESS", 0.04)
+	viper.SetDefault("CAMERA_MIN_VARIANCE", 0.0001)
+	viper.SetDefault("AUDIT_EXPORT_FORMAT", "csv")
+	viper.SetDefault("AUDIT_LOG_ENABLED", true)
+	viper.SetDefault("AUDIT_LOG_PATH", "")
+	viper.SetDefault("USAGE_PATH", "")
+	viper.SetDefault("USAGE_QUOTAS", "")
+	viper.SetDefault("ATTESTATION_ENABLED", false)
+	viper.SetDefault("ATTESTATION_SIGNING_KEY", "")
+	viper.SetDefault("ATTESTATION_KEY_ID", "")
+	viper.SetDefault("ATTESTATION_ISSUER", "connect-hub-verification")
+	viper.SetDefault("ATTESTATION_TTL", 300)
 	viper.SetDefault("STORAGE_TYPE", "encrypted_file")
+	viper.SetDefault("GALLERY_SYNC", "off")
 	viper.SetDefault("STORAGE_PATH", "./storage")
+	viper.SetDefault("STORAGE_LOCK_TIMEOUT", 10)
+	viper.SetDefault("VECTOR_LOG_COMPACTION_SIZE", 8388608)
+	viper.SetDefault("ENCRYPTION_KEY_ID", "1")
+	viper.SetDefault("ENCRYPTION_PREVIOUS_KEYS", "")
+	viper.SetDefault("STORAGE_KEY_PROVIDER", "env")
+	viper.SetDefault("PER_USER_KEYS", false)
+	viper.SetDefault("ENCRYPTION_KEY_CIPHERTEXT", "")
+	viper.SetDefault("STORAGE_KEY_CACHE_TTL", 300)
+	viper.SetDefault("KMS_KEY_NAME", "")
+	viper.SetDefault("KMS_ENDPOINT", "")
+	viper.SetDefault("AWS_REGION", "")
+	viper.SetDefault("AWS_ACCESS_KEY_ID", "")
+	viper.SetDefault("AWS_SECRET_ACCESS_KEY", "")
+	viper.SetDefault("AWS_SESSION_TOKEN", "")
+	viper.SetDefault("GCP_ACCESS_TOKEN", "")
+	viper.SetDefault("VAULT_ADDR", "")
+	viper.SetDefault("VAULT_TOKEN", "")
+	viper.SetDefault("VAULT_TRANSIT_MOUNT", "transit")
+	viper.SetDefault("OBJECT_STORE_BUCKET", "")
+	viper.SetDefault("OBJECT_KEY_PREFIX", "uploads/")
+	viper.SetDefault("GCS_HMAC_ACCESS_ID", "")
+	viper.SetDefault("GCS_HMAC_SECRET", "")
+	viper.SetDefault("OBJECT_UPLOAD_TTL", 900)
+	viper.SetDefault("IDEMPOTENCY_ENABLED", true)
+	viper.SetDefault("IDEMPOTENCY_STORE", "memory")
+	viper.SetDefault("IDEMPOTENCY_TTL", 86400)
+	viper.SetDefault("REDIS_URL", "")
 	viper.SetDefault("MAX_CONCURRENT_REQUESTS", 10)
+	viper.SetDefault("REQUEST_QUEUE_DEPTH", 20)
 	viper.SetDefault("PROCESSING_TIMEOUT", 30)
+	viper.SetDefault("FRAME_EXTRACTION_TIMEOUT_MS", 2000)
+	viper.SetDefault("SHUTDOWN_DELAY", 0)
+	viper.SetDefault("SHUTDOWN_DRAIN_TIMEOUT", 30)
+	viper.SetDefault("WARMUP_ENABLED", true)
+	viper.SetDefault("WARMUP_REQUESTS", 0)
+	viper.SetDefault("WARMUP_TIMEOUT", 60)
+	viper.SetDefault("ANALYSIS_TIMEOUT_MS", 1000)
+	viper.SetDefault("RATE_LIMIT_PER_MINUTE", 60)
+	viper.SetDefault("RATE_LIMIT_BURST", 60)
+	viper.SetDefault("RATE_LIMIT_MAX_CLIENTS", 10000)
+	viper.SetDefault("JWT_JWKS_URL", "")
+	viper.SetDefault("JWT_ISSUER", "")
+	viper.SetDefault("JWT_AUDIENCE", "")
+	viper.SetDefault("JWT_TENANT_CLAIM", "tenant")
+	viper.SetDefault("JWT_JWKS_REFRESH", 300)
+	viper.SetDefault("JWT_ROLES_CLAIM", "roles")
+	viper.SetDefault("TENANT_API_KEYS", "")
+	viper.SetDefault("TENANT_SIMILARITY_THRESHOLDS", "")
+	viper.SetDefault("TENANT_LIVENESS_THRESHOLDS", "")
+	viper.SetDefault("TENANT_RATE_LIMITS", "")
+	viper.SetDefault("TENANT_CONFIG_PATH", "./storage/tenant_config.json")
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", "")
+	viper.SetDefault("CORS_ALLOWED_METHODS", "GET, POST, PUT, DELETE, OPTIONS")
+	viper.SetDefault("CORS_ALLOWED_HEADERS", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-Admin-Key, X-Tenant-ID, Idempotency-Key, If-None-Match")
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", false)
+	viper.SetDefault("ENROLLMENT_DISABLED", false)
+	viper.SetDefault("MIN_ENROLLMENT_AGE", 0)
+	viper.SetDefault("ENROLLMENT_QUALITY_ENABLED", true)
+	viper.SetDefault("QUALITY_MIN_FACE_SIZE", 0.2)
+	viper.SetDefault("QUALITY_MIN_SHARPNESS", 50)
+	viper.SetDefault("QUALITY_MIN_BRIGHTNESS", 0.2)
+	viper.SetDefault("QUALITY_MAX_BRIGHTNESS", 0.9)
+	viper.SetDefault("QUALITY_MAX_POSE_ANGLE", 30)
+	viper.SetDefault("DUPLICATE_IDENTITY_CHECK", "off")
+	viper.SetDefault("DUPLICATE_IDENTITY_THRESHOLD", 0.9)
+	viper.SetDefault("ENROLLMENT_FUSION", "all")
+	viper.SetDefault("ENROLLMENT_MAX_TEMPLATES", 5)
+	viper.SetDefault("TEMPLATE_REFRESH_ENABLED", false)
+	viper.SetDefault("TEMPLATE_MAX_AGE", 365)
+	viper.SetDefault("TEMPLATE_QUALITY_MARGIN", 0.2)
+	viper.SetDefault("TEMPLATE_REFRESH_MIN_SIMILARITY", 0.85)
+	viper.SetDefault("LEGACY_DESCRIPTORS", "current")
+	viper.SetDefault("WATCHLIST_PATH", "")
+	viper.SetDefault("WATCHLIST_THRESHOLD", 0.9)
+	viper.SetDefault("REVIEW_CONFIDENCE_MIN", 0)
+	viper.SetDefault("REVIEW_CONFIDENCE_MAX", 0)
+	viper.SetDefault("REVIEW_LIVENESS_MIN", 0)
+	viper.SetDefault("REVIEW_LIVENESS_MAX", 0)
+	viper.SetDefault("RISK_POLICY_PATH", "")
+	viper.SetDefault("VELOCITY_LIMITS", "")
+	viper.SetDefault("VELOCITY_STORE", "memory")
+	viper.SetDefault("VELOCITY_FACE_THRESHOLD", 0.9)
+	viper.SetDefault("LOCKOUT_THRESHOLD", 0)
+	viper.SetDefault("LOCKOUT_CLIENT_THRESHOLD", 0)
+	viper.SetDefault("LOCKOUT_COOLDOWN", 900)
+	viper.SetDefault("ANN_INDEX_ENABLED", false)
+	viper.SetDefault("ANN_EF_SEARCH", 64)
+	viper.SetDefault("ASYNC_WORKERS", 4)
+	viper.SetDefault("ASYNC_QUEUE_SIZE", 100)
+	viper.SetDefault("ASYNC_JOB_STATE_PATH", "./storage/async_jobs.json")
+	viper.SetDefault("ENFORCE_UNIQUE_SESSIONS", false)
+	viper.SetDefault("SESSION_LOCK_TTL", 60)
+	viper.SetDefault("ETAG_CACHING_ENABLED", false)
+	viper.SetDefault("RESULT_CACHE_TTL", 300)
+	viper.SetDefault("CANARY_FRACTION", 0.0)
+	viper.SetDefault("CANARY_FACE_MODEL_PATH", "")
+	viper.SetDefault("CANARY_ONNX_LIVENESS_MODEL_PATH", "")
+	viper.SetDefault("DRIFT_MONITOR_ENABLED", false)
+	viper.SetDefault("DRIFT_TOLERANCE", 0.05)
+	viper.SetDefault("DRIFT_WINDOW_SIZE", 500)
+	viper.SetDefault("DRIFT_CHECK_INTERVAL", 60)
+	viper.SetDefault("DATASET_EXPORT_ENABLED", false)
+	viper.SetDefault("DATASET_EXPORT_PATH", "./storage/liveness_dataset.jsonl")
+	viper.SetDefault("DEFAULT_LOCALE", "en")
+	viper.SetDefault("OPENAPI_ENABLED", true)
+	viper.SetDefault("LIVENESS_PRECHECK_ENABLED", false)
+	viper.SetDefault("FRAME_SUBMISSION_ENABLED", true)
+	viper.SetDefault("MIN_SUBMITTED_FRAMES", 2)
+	viper.SetDefault("MAX_SUBMITTED_FRAMES", 10)
+	viper.SetDefault("MAX_FRAME_SIZE", 2*1024*1024)
+	viper.SetDefault("IMAGE_VERIFICATION_ENABLED", false)
+	viper.SetDefault("MAX_IMAGE_SIZE", 10*1024*1024)
+	viper.SetDefault("COMPARE_SIMILARITY_THRESHOLD", 0)
+	viper.SetDefault("COMPARE_MAX_DOCUMENT_SIZE", 10*1024*1024)
+	viper.SetDefault("DOCUMENT_OCR_ENGINE", "tesseract")
+	viper.SetDefault("TESSERACT_PATH", "tesseract")
+	viper.SetDefault("TESSERACT_LANGUAGE", "eng")
+	viper.SetDefault("MAX_UPLOAD_SIZE", 50*1024*1024)
+	viper.SetDefault("LIVE_VERIFICATION_ENABLED", false)
+	viper.SetDefault("LIVE_MAX_FRAMES", 30)
+	viper.SetDefault("LIVE_IDLE_TIMEOUT", 10)
+	viper.SetDefault("DEDUP_WINDOW", 0)
+	viper.SetDefault("DEDUP_KEY", "video")
+	viper.SetDefault("DESCRIPTOR_CACHE_TTL", 0)
+	viper.SetDefault("DESCRIPTOR_CACHE_STORE", "memory")
+	viper.SetDefault("DESCRIPTOR_CACHE_SIZE", 1024)
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 5)
+	viper.SetDefault("SELFBENCH_ALLOW_PRODUCTION", false)
+	viper.SetDefault("SELFBENCH_MAX_REQUESTS", 500)
+	viper.SetDefault("SELFBENCH_COOLDOWN", 60)
+	viper.SetDefault("WEBHOOK_RETRY_BACKOFF_MS", 1000)
+	viper.SetDefault("WEBHOOK_TIMEOUT", 10)
+	viper.SetDefault("KAFKA_BROKERS", "")
+	viper.SetDefault("KAFKA_TOPIC", "connect-hub.verification.events")
+	viper.SetDefault("KAFKA_OUTBOX_PATH", "")
+	viper.SetDefault("KAFKA_POLL_INTERVAL_MS", 1000)
+	viper.SetDefault("NATS_URL", "")
+	viper.SetDefault("NATS_STREAM", "VERIFICATION_JOBS")
+	viper.SetDefault("NATS_CONSUMER", "verification-worker")
+	viper.SetDefault("NATS_RESULT_SUBJECT", "verification.results")
+	viper.SetDefault("WORKER_CONCURRENCY", 4)
+	viper.SetDefault("FRAME_DECODE_BUDGET_MS", 500)
+	viper.SetDefault("MAX_FRAME_DIMENSION", 1280)
+	viper.SetDefault("ACTION_CHECK_ENABLED", true)
+	viper.SetDefault("WARNINGS_ENABLED", true)
+	viper.SetDefault("WARNING_MARGIN", 0.05)
+	viper.SetDefault("WARNING_MIN_BRIGHTNESS", 0.2)
+	viper.SetDefault("ACTION_MIN_MOTION", 0.02)
+	viper.SetDefault("LIVENESS_CHALLENGE_REQUIRED", false)
+	viper.SetDefault("LIVENESS_SESSION_TTL", 120)
+	viper.SetDefault("NONCE_BINDING_ENABLED", false)
+	viper.SetDefault("BLINK_WEIGHT", 0.2)
+	viper.SetDefault("BLINK_EAR_THRESHOLD", 0.21)
+	viper.SetDefault("REPLAY_DETECTION_ENABLED", true)
+	viper.SetDefault("REPLAY_WEIGHT", 0.2)
+	viper.SetDefault("REPLAY_THRESHOLD", 0.8)
+	viper.SetDefault("INJECTION_DETECTION_ENABLED", false)
+	viper.SetDefault("INJECTION_THRESHOLD", 0.8)
+	viper.SetDefault("INJECTION_SUSPICIOUS_ENCODERS", "Lavf,x264 - core,x265 (build,obs-studio,OBS Virtual Camera,ManyCam,SplitCam,XSplit,HandBrake")
+	viper.SetDefault("ONNX_INPUT_SIZE", 80)
+	viper.SetDefault("ONNX_LIVE_CLASS", 1)
+	viper.SetDefault("ONNX_LIVENESS_WEIGHT", 0.5)
+	viper.SetDefault("CONTINUATION_TTL", 120)
 
 	viper.AutomaticEnv()
 
+	// AutomaticEnv only covers keys viper already knows about, so settings
+	// without a default, such as ENCRYPTION_KEY, are bound explicitly
+	fields := reflect.TypeOf(Config{})
+	known := make(map[string]bool, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		if key := fields.Field(i).Tag.Get("mapstructure"); key != "" {
+			viper.BindEnv(key)
+			known[strings.ToLower(key)] = true
+		}
+	}
+
+	unknown, err := readConfigFile(known)
+	if err != nil {
+		return nil, err
+	}
+
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, err
 	}
 
+	// Misspelled keys in the file come first, as the likely cause of the
+	// problems after them
+	problems := unknown
+	var validation *ValidationError
+	if errors.As(config.Validate(), &validation) {
+		problems = append(problems, validation.Problems...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
 	return &config, nil
-}
\ No newline at end of file
+}
+
+// readConfigFile merges the config file into viper's configuration and
+// reports the settings in it that are not known keys.
+func readConfigFile(known map[string]bool) ([]string, error) {
+	path := os.Getenv(ConfigFileEnv)
+	if path == "" {
+		for _, candidate := range defaultConfigFiles {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	file := viper.New()
+	file.SetConfigFile(path)
+	if err := file.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := viper.MergeConfigMap(file.AllSettings()); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var unknown []string
+	for _, key := range file.AllKeys() {
+		if !known[key] {
+			unknown = append(unknown, fmt.Sprintf("unknown setting %q in %s", key, path))
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// minEncryptionKeyLength is the shortest ENCRYPTION_KEY accepted. Keys are
+// stretched before use, but a short one is still guessable.
+const minEncryptionKeyLength = 16
+
+// ValidationError lists every problem found in a configuration, so all of
+// them can be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration, %d problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks required settings and ranges: ports, thresholds and
+// fractions in [0,1], the encryption key for the configured key provider
+// and the model directory. It returns a *ValidationError listing every
+// problem, or nil.
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	validPort := func(name string, port int) {
+		if port < 1 || port > 65535 {
+			addf("%s must be a port between 1 and 65535, got %d", name, port)
+		}
+	}
+	validPort("PORT", c.Port)
+	if c.GRPCEnabled {
+		validPort("GRPC_PORT", c.GRPCPort)
+		if c.GRPCPort == c.Port {
+			addf("GRPC_PORT must differ from PORT, both are %d", c.Port)
+		}
+	}
+
+	switch {
+	case (c.TLSCertFile == "") != (c.TLSKeyFile == ""):
+		addf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case c.TLSCertFile != "" && c.TLSAutocertDomains != "":
+		addf("TLS_CERT_FILE and TLS_KEY_FILE are mutually exclusive with TLS_AUTOCERT_DOMAINS")
+	}
+	if c.TLSClientCAFile != "" {
+		switch {
+		case c.TLSCertFile == "" && c.TLSAutocertDomains == "":
+			addf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS")
+		case c.TLSClientAuth != "optional" && c.TLSClientAuth != "require":
+			addf("TLS_CLIENT_AUTH must be optional or require, got %q", c.TLSClientAuth)
+		case c.TLSClientAuth == "require" && c.TLSAutocertDomains != "":
+			// The ACME server presents no certificate when validating
+			addf("TLS_CLIENT_AUTH=require cannot be used with TLS_AUTOCERT_DOMAINS")
+		}
+	} else if c.TLSClientIdentities != "" {
+		addf("TLS_CLIENT_IDENTITIES requires TLS_CLIENT_CA_FILE")
+	}
+
+	if c.LogRedaction != "" && c.LogRedaction != "on" && c.LogRedaction != "off" {
+		addf("LOG_REDACTION must be on or off, got %q", c.LogRedaction)
+	}
+
+	if c.CORSAllowCredentials {
+		for _, origin := range strings.Split(c.CORSAllowedOrigins, ",") {
+			if strings.TrimSpace(origin) == "*" {
+				// Any site could then make requests with the user's credentials
+				addf("CORS_ALLOWED_ORIGINS cannot be * with CORS_ALLOW_CREDENTIALS, list the allowed origins")
+				break
+			}
+		}
+	}
+
+	if c.JWTJWKSURL != "" {
+		if u, err := url.Parse(c.JWTJWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			addf("JWT_JWKS_URL must be an http(s) URL, got %q", c.JWTJWKSURL)
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value float64
+	}{
+		{"LIVENESS_THRESHOLD", c.LivenessThreshold},
+		{"SIMILARITY_THRESHOLD", c.SimilarityThreshold},
+		{"COMPARE_SIMILARITY_THRESHOLD", c.CompareSimilarityThreshold},
+		{"REPLAY_THRESHOLD", c.ReplayThreshold},
+		{"INJECTION_THRESHOLD", c.InjectionThreshold},
+		{"DUPLICATE_IDENTITY_THRESHOLD", c.DuplicateIdentityThreshold},
+		{"WATCHLIST_THRESHOLD", c.WatchlistThreshold},
+		{"VELOCITY_FACE_THRESHOLD", c.VelocityFaceThreshold},
+		{"REVIEW_CONFIDENCE_MIN", c.ReviewConfidenceMin},
+		{"REVIEW_CONFIDENCE_MAX", c.ReviewConfidenceMax},
+		{"REVIEW_LIVENESS_MIN", c.ReviewLivenessMin},
+		{"REVIEW_LIVENESS_MAX", c.ReviewLivenessMax},
+		{"CANARY_FRACTION", c.CanaryFraction},
+		{"CANARY_LIVENESS_THRESHOLD", c.CanaryLivenessThreshold},
+		{"CANARY_SIMILARITY_THRESHOLD", c.CanarySimilarityThreshold},
+		{"CAMERA_MIN_BRIGHTNESS", c.CameraMinBrightness},
+		{"WARNING_MIN_BRIGHTNESS", c.WarningMinBrightness},
+		{"TEMPLATE_QUALITY_MARGIN", c.TemplateQualityMargin},
+		{"TEMPLATE_REFRESH_MIN_SIMILARITY", c.TemplateRefreshMinSimilarity},
+	} {
+		if setting.value < 0 || setting.value > 1 {
+			addf("%s must be between 0 and 1, got %g", setting.name, setting.value)
+		}
+	}
+	if c.ReviewConfidenceMin > c.ReviewConfidenceMax && c.ReviewConfidenceMax != 0 {
+		addf("REVIEW_CONFIDENCE_MIN (%g) must not exceed REVIEW_CONFIDENCE_MAX (%g)", c.ReviewConfidenceMin, c.ReviewConfidenceMax)
+	}
+	if c.ReviewLivenessMin > c.ReviewLivenessMax && c.ReviewLivenessMax != 0 {
+		addf("REVIEW_LIVENESS_MIN (%g) must not exceed REVIEW_LIVENESS_MAX (%g)", c.ReviewLivenessMin, c.ReviewLivenessMax)
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"MAX_CONCURRENT_REQUESTS", c.MaxConcurrentRequests},
+		{"PROCESSING_TIMEOUT", c.ProcessingTimeout},
+		{"ASYNC_WORKERS", c.AsyncWorkers},
+		{"MAX_FRAME_DIMENSION", c.MaxFrameDimension},
+		{"SHUTDOWN_DELAY", c.ShutdownDelay},
+		{"SHUTDOWN_DRAIN_TIMEOUT", c.ShutdownDrainTimeout},
+		{"WARMUP_REQUESTS", c.WarmupRequests},
+		{"WARMUP_TIMEOUT", c.WarmupTimeout},
+		{"JWT_JWKS_REFRESH", c.JWTJWKSRefresh},
+		{"LOCKOUT_THRESHOLD", c.LockoutThreshold},
+		{"LOCKOUT_CLIENT_THRESHOLD", c.LockoutClientThreshold},
+		{"LOCKOUT_COOLDOWN", c.LockoutCooldown},
+		{"ENROLLMENT_MAX_TEMPLATES", c.EnrollmentMaxTemplates},
+		{"TEMPLATE_MAX_AGE", c.TemplateMaxAge},
+		{"DESCRIPTOR_FRAMES", c.DescriptorFrames},
+		{"EMBEDDER_INPUT_SIZE", c.EmbedderInputSize},
+		{"EMBEDDER_TIMEOUT_MS", c.EmbedderTimeoutMs},
+		{"DESCRIPTOR_CACHE_TTL", c.DescriptorCacheTTL},
+		{"DESCRIPTOR_CACHE_SIZE", c.DescriptorCacheSize},
+	} {
+		if setting.value < 0 {
+			addf("%s must not be negative, got %d", setting.name, setting.value)
+		}
+	}
+
+	switch c.StorageKeyProvider {
+	case "", "env":
+		if c.EncryptionKey == "" {
+			addf("ENCRYPTION_KEY is required when STORAGE_KEY_PROVIDER is env")
+		} else if len(c.EncryptionKey) < minEncryptionKeyLength {
+			addf("ENCRYPTION_KEY must be at least %d characters, got %d", minEncryptionKeyLength, len(c.EncryptionKey))
+		}
+	case "aws-kms", "gcp-kms", "vault":
+		if c.EncryptionKeyCiphertext == "" {
+			addf("ENCRYPTION_KEY_CIPHERTEXT is required when STORAGE_KEY_PROVIDER is %s", c.StorageKeyProvider)
+		}
+	default:
+		addf("STORAGE_KEY_PROVIDER must be env, aws-kms, gcp-kms or vault, got %q", c.StorageKeyProvider)
+	}
+
+	switch c.EmbedderBackend {
+	case "", "dlib":
+	case "remote":
+		if c.EmbedderAddr == "" || c.EmbedderModel == "" {
+			addf("EMBEDDER_ADDR and EMBEDDER_MODEL are required when EMBEDDER_BACKEND is remote")
+		}
+	default:
+		addf("EMBEDDER_BACKEND must be dlib or remote, got %q", c.EmbedderBackend)
+	}
+
+	// With retries the model mount may still be coming up
+	if c.RecognizerInitAttempts <= 1 {
+		if info, err := os.Stat(c.FaceModelPath); err != nil {
+			addf("FACE_MODEL_PATH %q is not readable: %v", c.FaceModelPath, errors.Unwrap(err))
+		} else if !info.IsDir() {
+			addf("FACE_MODEL_PATH %q is not a directory", c.FaceModelPath)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
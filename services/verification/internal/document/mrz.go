@@ -0,0 +1,204 @@
+// Package document reads identity documents: the machine-readable zone
+// (MRZ) of passports and ID cards, and the OCR engine that transcribes it.
+package document
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrMRZNotFound = errors.New("no machine-readable zone found")
+
+// ICAO 9303 MRZ layouts: line count and characters per line
+const (
+	FormatTD1 = "TD1" // ID cards, 3 lines of 30
+	FormatTD2 = "TD2" // older ID cards and visas, 2 lines of 36
+	FormatTD3 = "TD3" // passports, 2 lines of 44
+)
+
+var mrzFormats = []struct {
+	name   string
+	lines  int
+	length int
+}{
+	{FormatTD3, 2, 44},
+	{FormatTD2, 2, 36},
+	{FormatTD1, 3, 30},
+}
+
+// FindMRZ picks the machine-readable zone out of OCR output. The zone is
+// the last run of lines made only of MRZ characters with one of the
+// standard lengths; lines missing up to two trailing fillers are padded.
+func FindMRZ(text []string) (string, []string, error) {
+	var candidates []string
+	for _, line := range text {
+		candidates = append(candidates, strings.ToUpper(strings.ReplaceAll(line, " ", "")))
+	}
+
+	for end := len(candidates); end > 0; end-- {
+		for _, format := range mrzFormats {
+			start := end - format.lines
+			if start < 0 {
+				continue
+			}
+			lines := make([]string, 0, format.lines)
+			for _, line := range candidates[start:end] {
+				padded, ok := mrzLine(line, format.length)
+				if !ok {
+					break
+				}
+				lines = append(lines, padded)
+			}
+			if len(lines) == format.lines {
+				return format.name, lines, nil
+			}
+		}
+	}
+	return "", nil, ErrMRZNotFound
+}
+
+func mrzLine(line string, length int) (string, bool) {
+	if len(line) > length || len(line) < length-2 {
+		return "", false
+	}
+	for _, r := range line {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '<') {
+			return "", false
+		}
+	}
+	if len(line) < length && !strings.HasSuffix(line, "<") {
+		return "", false
+	}
+	return line + strings.Repeat("<", length-len(line)), true
+}
+
+// ParseMRZ finds and decodes the machine-readable zone in OCR output. Dates
+// are returned as YYYY-MM-DD; ChecksValid reports whether every check digit
+// matched, which is what tells a clean read from a misread.
+func ParseMRZ(text []string, now time.Time) (*models.DocumentFields, error) {
+	format, lines, err := FindMRZ(text)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := &models.DocumentFields{Format: format, MRZ: lines}
+	var checks []bool
+	switch format {
+	case FormatTD1:
+		l1, l2, l3 := lines[0], lines[1], lines[2]
+		fields.DocumentType = filler(l1[0:2])
+		fields.IssuingCountry = filler(l1[2:5])
+		fields.DocumentNumber = filler(l1[5:14])
+		fields.OptionalData = filler(l1[15:30] + l2[18:29])
+		dob, expiry := digits(l2[0:6]), digits(l2[8:14])
+		fields.Sex = filler(l2[7:8])
+		fields.Nationality = filler(l2[15:18])
+		fields.Surname, fields.GivenNames = names(l3)
+		fields.DateOfBirth = birthDate(dob, now)
+		fields.ExpiryDate = expiryDate(expiry)
+		checks = []bool{
+			checkDigit(l1[5:14], l1[14]),
+			checkDigit(dob, l2[6]),
+			checkDigit(expiry, l2[14]),
+			checkDigit(l1[5:30]+dob+digits(l2[6:7])+expiry+digits(l2[14:15])+l2[18:29], l2[29]),
+		}
+	default:
+		// TD2 and TD3 share a layout and differ in the optional data width
+		l1, l2 := lines[0], lines[1]
+		width := len(l2)
+		fields.DocumentType = filler(l1[0:2])
+		fields.IssuingCountry = filler(l1[2:5])
+		fields.Surname, fields.GivenNames = names(l1[5:])
+		fields.DocumentNumber = filler(l2[0:9])
+		fields.Nationality = filler(l2[10:13])
+		dob, expiry := digits(l2[13:19]), digits(l2[21:27])
+		fields.Sex = filler(l2[20:21])
+		fields.DateOfBirth = birthDate(dob, now)
+		fields.ExpiryDate = expiryDate(expiry)
+		checks = []bool{
+			checkDigit(l2[0:9], l2[9]),
+			checkDigit(dob, l2[19]),
+			checkDigit(expiry, l2[27]),
+		}
+		if format == FormatTD3 {
+			fields.OptionalData = filler(l2[28:42])
+			// An all-filler personal number may use a filler check digit
+			if fields.OptionalData != "" || l2[42] != '<' {
+				checks = append(checks, checkDigit(l2[28:42], l2[42]))
+			}
+		} else {
+			fields.OptionalData = filler(l2[28:35])
+		}
+		composite := l2[0:10] + dob + digits(l2[19:20]) + expiry + digits(l2[27:28]) + l2[28:width-1]
+		checks = append(checks, checkDigit(composite, l2[width-1]))
+	}
+
+	fields.ChecksValid = true
+	for _, ok := range checks {
+		fields.ChecksValid = fields.ChecksValid && ok
+	}
+	return fields, nil
+}
+
+// checkDigit verifies the ICAO 9303 check digit of value: characters
+// weighted 7, 3, 1 in turn, digits at face value, letters A-Z as 10-35 and
+// fillers as 0, summed modulo 10.
+func checkDigit(value string, check byte) bool {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		var v int
+		switch {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c >= 'A' && c <= 'Z':
+			v = int(c-'A') + 10
+		}
+		sum += v * weights[i%3]
+	}
+	return digits(string(check)) == fmt.Sprint(sum%10)
+}
+
+// ocrDigits undoes the letter/digit confusions OCR makes in fields that
+// can only hold digits.
+var ocrDigits = strings.NewReplacer("O", "0", "Q", "0", "D", "0", "I", "1", "L", "1", "Z", "2", "S", "5", "G", "6", "B", "8")
+
+func digits(value string) string {
+	return ocrDigits.Replace(value)
+}
+
+func filler(value string) string {
+	return strings.TrimSpace(strings.ReplaceAll(value, "<", " "))
+}
+
+// names splits the "SURNAME<<GIVEN<NAMES" name field.
+func names(field string) (string, string) {
+	surname, given, _ := strings.Cut(strings.TrimRight(field, "<"), "<<")
+	return strings.Join(strings.Fields(filler(surname)), " "), strings.Join(strings.Fields(filler(given)), " ")
+}
+
+// birthDate expands a YYMMDD birth date, placing it in the past.
+func birthDate(yymmdd string, now time.Time) string {
+	date, err := time.Parse("060102", yymmdd)
+	if err != nil {
+		return ""
+	}
+	year := now.Year()/100*100 + date.Year()%100
+	if year > now.Year() {
+		year -= 100
+	}
+	return fmt.Sprintf("%04d-%s-%s", year, yymmdd[2:4], yymmdd[4:6])
+}
+
+// expiryDate expands a YYMMDD expiry date, which is always this century.
+func expiryDate(yymmdd string) string {
+	if _, err := time.Parse("060102", yymmdd); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("20%s-%s-%s", yymmdd[0:2], yymmdd[2:4], yymmdd[4:6])
+}
@@ -0,0 +1,60 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strings"
+)
+
+// OCREngine transcribes the text of a document image, one string per line
+// in reading order.
+type OCREngine interface {
+	Recognize(ctx context.Context, img image.Image) ([]string, error)
+}
+
+// TesseractOCR runs the tesseract command-line tool, feeding the image on
+// stdin. Installing the "mrz" traineddata and selecting it as the language
+// markedly improves reads of the machine-readable zone.
+type TesseractOCR struct {
+	path     string
+	language string
+}
+
+func NewTesseractOCR(path, language string) *TesseractOCR {
+	if path == "" {
+		path = "tesseract"
+	}
+	if language == "" {
+		language = "eng"
+	}
+	return &TesseractOCR{path: path, language: language}
+}
+
+func (t *TesseractOCR) Recognize(ctx context.Context, img image.Image) ([]string, error) {
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return nil, err
+	}
+
+	// Page segmentation mode 6: a single uniform block of text
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.language, "--psm", "6")
+	cmd.Stdin = &input
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,201 @@
+// Package errors is the API's error taxonomy: each failure a client can see
+// has one machine-readable code, one HTTP status and a message that is safe
+// to show. Handlers answer with these instead of building bodies by hand, so
+// the same failure reads the same on every endpoint and internal error text
+// stays in the logs.
+//
+// Import it as apperrors to keep the standard library's errors in scope.
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// Error is an API error. The catalogue values below are templates: derive
+// a variant with WithMessage or Wrap rather than modifying them.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	cause   error
+}
+
+// New defines an API error.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.Message + ": " + e.cause.Error()
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches API errors by code, so a variant from WithMessage or Wrap is
+// still its catalogue error.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of e with a more specific message. The
+// message is sent to the client as it is.
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// Wrap returns a copy of e carrying cause for logs and errors.Is; the cause
+// never reaches the response.
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// Body is the JSON response for e.
+func (e *Error) Body() map[string]interface{} {
+	return map[string]interface{}{
+		"error": e.Message,
+		"code":  e.Code,
+	}
+}
+
+// From returns the API error in err's chain, or ErrInternal wrapping err
+// when there is none.
+func From(err error) *Error {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+	return ErrInternal.Wrap(err)
+}
+
+var (
+	ErrInternal = New(http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+
+	// Authentication and authorization
+	ErrUnauthorized   = New(http.StatusUnauthorized, "UNAUTHORIZED", "Invalid bearer token")
+	ErrAdminRequired  = New(http.StatusUnauthorized, "ADMIN_REQUIRED", "Admin credentials required")
+	ErrForbidden      = New(http.StatusForbidden, "FORBIDDEN", "Not allowed to view this user's history")
+	ErrRoleRequired   = New(http.StatusForbidden, "ROLE_REQUIRED", "Credentials lack the role for this operation")
+	ErrTenantRequired = New(http.StatusUnauthorized, "TENANT_REQUIRED", "A tenant API key is required")
+	ErrUnknownTenant  = New(http.StatusBadRequest, "UNKNOWN_TENANT", "Unknown tenant")
+	ErrRateLimited    = New(http.StatusTooManyRequests, "RATE_LIMITED", "Rate limit exceeded")
+	ErrQuotaExceeded  = New(http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Monthly quota of verifications and registrations exceeded")
+
+	// Request validation
+	ErrInvalidRequest         = New(http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	ErrInvalidFormData        = New(http.StatusBadRequest, "INVALID_FORM_DATA", "Invalid form data")
+	ErrUploadTooLarge         = New(http.StatusRequestEntityTooLarge, "UPLOAD_TOO_LARGE", "Upload too large")
+	ErrMissingVideo           = New(http.StatusBadRequest, "MISSING_VIDEO_FILE", "Video file is required")
+	ErrInvalidVideo           = New(http.StatusBadRequest, "INVALID_VIDEO_FILE", "Invalid video file")
+	ErrMissingDocument        = New(http.StatusBadRequest, "MISSING_DOCUMENT", "Document image is required")
+	ErrInvalidDocument        = New(http.StatusBadRequest, "INVALID_DOCUMENT", "Document must be a JPEG or PNG image")
+	ErrDocumentTooLarge       = New(http.StatusBadRequest, "DOCUMENT_TOO_LARGE", "Document too large")
+	ErrMissingImage           = New(http.StatusBadRequest, "MISSING_IMAGE", "Image is required")
+	ErrInvalidImage           = New(http.StatusBadRequest, "INVALID_IMAGE", "Image must be a JPEG or PNG")
+	ErrImageTooLarge          = New(http.StatusBadRequest, "IMAGE_TOO_LARGE", "Image too large")
+	ErrInvalidFrameCount      = New(http.StatusBadRequest, "INVALID_FRAME_COUNT", "Wrong number of frames")
+	ErrFrameTooLarge          = New(http.StatusBadRequest, "FRAME_TOO_LARGE", "Frame too large")
+	ErrMissingUserID          = New(http.StatusBadRequest, "MISSING_USER_ID", "User ID is required for registration")
+	ErrInvalidUserID          = New(http.StatusBadRequest, "INVALID_USER_ID", "Invalid user ID format")
+	ErrMissingVerificationID  = New(http.StatusBadRequest, "MISSING_VERIFICATION_ID", "Verification ID is required")
+	ErrInvalidVerificationID  = New(http.StatusBadRequest, "INVALID_VERIFICATION_ID", "Invalid verification ID format")
+	ErrInvalidAction          = New(http.StatusBadRequest, "INVALID_ACTION", "Unknown action")
+	ErrInvalidMode            = New(http.StatusBadRequest, "INVALID_MODE", "mode must be sync or async")
+	ErrInvalidRegion          = New(http.StatusBadRequest, "INVALID_REGION", "Invalid region format")
+	ErrRegionNotAllowed       = New(http.StatusBadRequest, "REGION_NOT_ALLOWED", "Region is not allowed")
+	ErrInvalidTemplate        = New(http.StatusBadRequest, "INVALID_TEMPLATE", "Template is malformed or of an unsupported version")
+	ErrInvalidObjectKey       = New(http.StatusBadRequest, "INVALID_OBJECT_KEY", "Invalid object key")
+	ErrObjectKeyForbidden     = New(http.StatusForbidden, "OBJECT_KEY_FORBIDDEN", "Object key is not allowed")
+	ErrInvalidObjectURL       = New(http.StatusBadRequest, "INVALID_OBJECT_URL", "url must be an s3:// or gs:// object URL")
+	ErrInvalidUploadType      = New(http.StatusBadRequest, "INVALID_CONTENT_TYPE", "content_type must be video/webm, video/mp4 or video/quicktime")
+	ErrInvalidPagination      = New(http.StatusBadRequest, "INVALID_PAGINATION", "Invalid page")
+	ErrInvalidLimit           = New(http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 1000")
+	ErrInvalidDateRange       = New(http.StatusBadRequest, "INVALID_DATE_RANGE", "from must be before to")
+	ErrInvalidOperation       = New(http.StatusBadRequest, "INVALID_OPERATION", "operation must be register, verify, identify, delete, compare or template_refresh")
+	ErrInvalidFormat          = New(http.StatusBadRequest, "INVALID_FORMAT", "format must be csv or json")
+	ErrInvalidFields          = New(http.StatusBadRequest, "INVALID_FIELDS", "Field is not exportable")
+	ErrInvalidStatus          = New(http.StatusBadRequest, "INVALID_STATUS", "status must be pending, delivered or failed")
+	ErrLivenessSessionMissing = New(http.StatusBadRequest, "LIVENESS_SESSION_REQUIRED", "A liveness session is required; start one at /api/v1/liveness/session")
+	ErrLivenessSessionInvalid = New(http.StatusBadRequest, "LIVENESS_SESSION_INVALID", "Liveness session is unknown, expired or already used")
+	ErrInvalidMessage         = New(http.StatusBadRequest, "INVALID_MESSAGE", `Expected a JPEG frame or {"type":"finish"}`)
+	ErrInvalidIdempotencyKey  = New(http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be at most 255 characters")
+	ErrInvalidTenantConfig    = New(http.StatusBadRequest, "INVALID_TENANT_CONFIG", "Invalid tenant configuration")
+	ErrInvalidMonth           = New(http.StatusBadRequest, "INVALID_MONTH", "month must be YYYY-MM")
+	ErrInvalidMaxResults      = New(http.StatusBadRequest, "INVALID_MAX_RESULTS", "max_results must be between 1 and 100")
+
+	// Capture processing
+	ErrDecodeFailed         = New(http.StatusBadRequest, "INVALID_FRAME", "Frames must be JPEG images of the same size")
+	ErrDecodeBudgetExceeded = New(http.StatusUnprocessableEntity, "DECODE_BUDGET_EXCEEDED", "Capture took too long to decode")
+	ErrNoFaceDetected       = New(http.StatusUnprocessableEntity, "NO_FACE_IN_VIDEO", "No face detected in the video")
+	ErrNoFaceInDocument     = New(http.StatusUnprocessableEntity, "NO_FACE_IN_DOCUMENT", "No face detected in the document")
+	ErrCapturesMismatch     = New(http.StatusUnprocessableEntity, "CAPTURES_MISMATCH", "The enrollment videos do not show the same face")
+	ErrLivenessFailed       = New(http.StatusUnprocessableEntity, "LIVENESS_FAILED", "Liveness check failed")
+	ErrTimeout              = New(http.StatusRequestTimeout, "PROCESSING_TIMEOUT", "Processing timed out")
+	ErrServerBusy           = New(http.StatusServiceUnavailable, "SERVER_BUSY", "Too many verifications in progress, retry later")
+	ErrSessionInUse         = New(http.StatusConflict, "SESSION_IN_USE", "Session is already in use by another verification")
+	ErrIdempotencyKeyInUse  = New(http.StatusConflict, "IDEMPOTENCY_KEY_IN_USE", "A request with this Idempotency-Key is still in progress")
+	ErrLocked               = New(http.StatusLocked, "LOCKED", "Too many failed verifications, retry later")
+	ErrFileRead             = New(http.StatusInternalServerError, "FILE_READ_ERROR", "Failed to process video file")
+
+	// Lookups and state
+	ErrVerificationNotFound    = New(http.StatusNotFound, "VERIFICATION_NOT_FOUND", "Verification not found")
+	ErrUserNotEnrolled         = New(http.StatusNotFound, "USER_NOT_ENROLLED", "User has no enrolled face")
+	ErrEnrollmentNotYetActive  = New(http.StatusConflict, "ENROLLMENT_NOT_YET_ACTIVE", "Enrollment is not active yet")
+	ErrReenrollmentRequired    = New(http.StatusConflict, "REENROLLMENT_REQUIRED", "User must enroll again after a recognizer model upgrade")
+	ErrEnrollmentDisabled      = New(http.StatusForbidden, "ENROLLMENT_DISABLED", "Enrollment is currently disabled")
+	ErrDuplicateIdentity       = New(http.StatusConflict, "DUPLICATE_IDENTITY", "This face is already enrolled under another user")
+	ErrContinuationExpired     = New(http.StatusGone, "CONTINUATION_EXPIRED", "Continuation token is unknown or has expired; submit the capture again")
+	ErrObjectNotFound          = New(http.StatusNotFound, "OBJECT_NOT_FOUND", "Referenced object not found")
+	ErrObjectFetchFailed       = New(http.StatusBadGateway, "OBJECT_FETCH_FAILED", "Failed to fetch referenced object")
+	ErrAsyncQueueFull          = New(http.StatusServiceUnavailable, "ASYNC_QUEUE_FULL", "Verification queue is full, retry later")
+	ErrShuttingDown            = New(http.StatusServiceUnavailable, "SHUTTING_DOWN", "Service is shutting down, retry later")
+	ErrWebhookDeliveryNotFound = New(http.StatusNotFound, "WEBHOOK_DELIVERY_NOT_FOUND", "Webhook delivery not found")
+	ErrWebhookDeliveryInFlight = New(http.StatusConflict, "WEBHOOK_DELIVERY_IN_PROGRESS", "Webhook delivery is still in progress")
+	ErrWatchlistEntryNotFound  = New(http.StatusNotFound, "WATCHLIST_ENTRY_NOT_FOUND", "Watchlist entry not found")
+	ErrReviewNotFound          = New(http.StatusNotFound, "REVIEW_NOT_FOUND", "Review case not found")
+	ErrReviewDecided           = New(http.StatusConflict, "REVIEW_ALREADY_DECIDED", "Review case has already been decided")
+	ErrSelfBenchForbidden      = New(http.StatusForbidden, "SELFBENCH_FORBIDDEN", "Self-benchmark is disabled in production")
+	ErrSelfBenchRateLimited    = New(http.StatusTooManyRequests, "SELFBENCH_RATE_LIMITED", "Self-benchmark ran too recently or is already running")
+	ErrKeyRotationUnsupported  = New(http.StatusNotImplemented, "KEY_ROTATION_UNSUPPORTED", "Configured storage does not support key rotation")
+	ErrInvalidConfig           = New(http.StatusUnprocessableEntity, "INVALID_CONFIG", "Configuration is invalid, nothing was reloaded")
+
+	// Disabled features
+	ErrAsyncDisabled             = New(http.StatusNotImplemented, "ASYNC_DISABLED", "Async verification is not enabled")
+	ErrFrameSubmissionDisabled   = New(http.StatusNotImplemented, "FRAME_SUBMISSION_DISABLED", "Frame submission is not enabled")
+	ErrLiveVerificationDisabled  = New(http.StatusNotImplemented, "LIVE_VERIFICATION_DISABLED", "Live verification is not enabled")
+	ErrImageVerificationDisabled = New(http.StatusNotImplemented, "IMAGE_VERIFICATION_DISABLED", "Image verification is not enabled")
+	ErrObjectStoreDisabled       = New(http.StatusNotImplemented, "OBJECT_STORE_DISABLED", "Verification by reference is not enabled")
+	ErrUploadsUnsupported        = New(http.StatusNotImplemented, "UPLOADS_UNSUPPORTED", "Configured object store cannot issue pre-signed uploads")
+	ErrObjectDeleteUnsupported   = New(http.StatusNotImplemented, "OBJECT_DELETE_UNSUPPORTED", "Configured object store cannot delete objects")
+	ErrPrecheckDisabled          = New(http.StatusNotImplemented, "PRECHECK_DISABLED", "Liveness pre-check is not enabled")
+	ErrAttestationDisabled       = New(http.StatusNotImplemented, "ATTESTATION_DISABLED", "Result attestation is not enabled")
+	ErrAuditLogDisabled          = New(http.StatusNotImplemented, "AUDIT_LOG_DISABLED", "Audit log is not enabled")
+	ErrWebhooksDisabled          = New(http.StatusNotImplemented, "WEBHOOKS_DISABLED", "Webhooks are not configured")
+
+	// Internal failures; the cause is logged, never returned
+	ErrVerificationFailed      = New(http.StatusInternalServerError, "VERIFICATION_FAILED", "Verification processing failed")
+	ErrRegistrationFailed      = New(http.StatusInternalServerError, "REGISTRATION_FAILED", "Face registration failed")
+	ErrComparisonFailed        = New(http.StatusInternalServerError, "COMPARISON_FAILED", "Face comparison failed")
+	ErrTemplateExtraction      = New(http.StatusInternalServerError, "TEMPLATE_EXTRACTION_FAILED", "Template extraction failed")
+	ErrMatchFailed             = New(http.StatusInternalServerError, "MATCH_FAILED", "Template match failed")
+	ErrIdentificationFailed    = New(http.StatusInternalServerError, "IDENTIFICATION_FAILED", "Face identification failed")
+	ErrLivenessSessionFailed   = New(http.StatusInternalServerError, "LIVENESS_SESSION_FAILED", "Failed to start liveness session")
+	ErrAuditQueryFailed        = New(http.StatusInternalServerError, "AUDIT_QUERY_FAILED", "Audit log query failed")
+	ErrAuditExportFailed       = New(http.StatusInternalServerError, "AUDIT_EXPORT_FAILED", "Audit export failed")
+	ErrWebhookRedeliveryFailed = New(http.StatusInternalServerError, "WEBHOOK_REDELIVERY_FAILED", "Webhook redelivery failed")
+	ErrSelfBenchFailed         = New(http.StatusInternalServerError, "SELFBENCH_FAILED", "Self-benchmark failed")
+	ErrErasureFailed           = New(http.StatusInternalServerError, "ERASURE_FAILED", "User data could not be erased")
+	ErrKeyRotationFailed       = New(http.StatusInternalServerError, "KEY_ROTATION_FAILED", "Encryption key rotation failed")
+	ErrMigrationFailed         = New(http.StatusInternalServerError, "MIGRATION_FAILED", "Stored descriptors could not be migrated")
+	ErrUploadFailed            = New(http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to issue upload URL")
+)
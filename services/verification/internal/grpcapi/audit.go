@@ -0,0 +1,93 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"connect-hub/verification-service/internal/grpcapi/verificationpb"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// auditInterceptor records Verify, Register and Identify calls in the audit
+// log, like the audited REST routes.
+func auditInterceptor(faceService *services.FaceVerificationService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+
+		event := models.AuditEvent{Tenant: tenantFrom(ctx), Transport: "grpc"}
+		switch r := req.(type) {
+		case *verificationpb.VerifyRequest:
+			event.Operation = models.AuditVerify
+			event.UserID = r.UserId
+			if out, ok := resp.(*verificationpb.VerifyResponse); ok && err == nil && out.Result != nil {
+				event.VerificationID = out.Result.VerificationId
+				event.Result = models.AuditNotVerified
+				if out.Result.Verified {
+					event.Result = models.AuditVerified
+				}
+			}
+		case *verificationpb.RegisterRequest:
+			event.Operation = models.AuditRegister
+			event.UserID = r.UserId
+			if err == nil {
+				event.Result = models.AuditSuccess
+			}
+		case *verificationpb.IdentifyRequest:
+			event.Operation = models.AuditIdentify
+			if out, ok := resp.(*verificationpb.IdentifyResponse); ok && err == nil {
+				event.Result = models.AuditNoMatch
+				if len(out.Matches) > 0 {
+					event.Result = models.AuditMatch
+					event.UserID = out.Matches[0].UserId
+				}
+			}
+		default:
+			return resp, err
+		}
+
+		if event.Result == "" {
+			event.Result = models.AuditError
+			switch status.Code(err) {
+			case codes.InvalidArgument, codes.FailedPrecondition, codes.NotFound,
+				codes.Unavailable, codes.ResourceExhausted, codes.AlreadyExists:
+				event.Result = models.AuditRejected
+			}
+		}
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if keys := md.Get("x-api-key"); len(keys) > 0 {
+				event.ClientKey = services.ClientKeyFingerprint(keys[0])
+			}
+			for _, key := range md.Get("x-admin-key") {
+				if middleware.AdminKeyMatches(faceService.Config().AdminAPIKey, key) {
+					event.Admin = true
+				}
+			}
+		}
+		event.ClientIP = peerIP(ctx)
+
+		faceService.RecordAudit(event)
+		return resp, err
+	}
+}
+
+// peerIP returns the address of the caller, without its port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
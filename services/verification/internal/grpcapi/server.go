@@ -0,0 +1,383 @@
+// Package grpcapi serves the verification API over gRPC for other connect-hub
+// services, next to the REST API and on the same FaceVerificationService.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"connect-hub/verification-service/internal/grpcapi/verificationpb"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// errorDomain tags the ErrorInfo detail on every error status. Its reason
+// carries the same machine-stable code the REST API returns.
+const errorDomain = "verification.connect-hub"
+
+// maxMessageSize admits a full-size capture in one message; gRPC defaults to 4MB.
+const maxMessageSize = 64 * 1024 * 1024
+
+const maxVideoSize = 50 * 1024 * 1024
+
+// Server implements verificationpb.VerificationServiceServer.
+type Server struct {
+	verificationpb.UnimplementedVerificationServiceServer
+
+	faceService *services.FaceVerificationService
+	logger      *zap.Logger
+}
+
+func NewServer(faceService *services.FaceVerificationService, logger *zap.Logger) *Server {
+	return &Server{
+		faceService: faceService,
+		logger:      logger,
+	}
+}
+
+// NewGRPCServer returns a grpc.Server with the verification service
+// registered, request logging, tenant resolution, auditing, usage
+// metering and panic recovery.
+func NewGRPCServer(faceService *services.FaceVerificationService, logger *zap.Logger) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ChainUnaryInterceptor(recoveryInterceptor(logger), loggingInterceptor(logger),
+			tenantInterceptor(faceService), auditInterceptor(faceService), usageInterceptor(faceService)),
+	)
+	verificationpb.RegisterVerificationServiceServer(server, NewServer(faceService, logger))
+	return server
+}
+
+func (s *Server) Verify(ctx context.Context, req *verificationpb.VerifyRequest) (*verificationpb.VerifyResponse, error) {
+	cfg := s.faceService.Config()
+
+	switch {
+	case len(req.Video) == 0 && len(req.Frames) == 0:
+		return nil, statusError(codes.InvalidArgument, "MISSING_VIDEO_FILE", "video or frames is required")
+	case len(req.Video) > 0 && len(req.Frames) > 0:
+		return nil, statusError(codes.InvalidArgument, "INVALID_REQUEST", "send either video or frames, not both")
+	case len(req.Video) > maxVideoSize:
+		return nil, statusError(codes.InvalidArgument, "INVALID_VIDEO_FILE", "video is larger than 50MB")
+	}
+
+	if len(req.Frames) > 0 {
+		if !cfg.FrameSubmissionEnabled {
+			return nil, statusError(codes.Unimplemented, "FRAME_SUBMISSION_DISABLED", "frame submission is not enabled")
+		}
+		minFrames, maxFrames := cfg.MinSubmittedFrames, cfg.MaxSubmittedFrames
+		if minFrames <= 0 {
+			minFrames = 2
+		}
+		if maxFrames <= 0 {
+			maxFrames = 10
+		}
+		if len(req.Frames) < minFrames || len(req.Frames) > maxFrames {
+			return nil, statusError(codes.InvalidArgument, "INVALID_FRAME_COUNT",
+				fmt.Sprintf("between %d and %d frames are required", minFrames, maxFrames))
+		}
+		for _, frame := range req.Frames {
+			if !services.IsJPEG(frame) {
+				return nil, statusError(codes.InvalidArgument, "INVALID_FRAME", "frames must be JPEG images")
+			}
+		}
+	}
+
+	if req.UserId != "" && !validUserID(req.UserId) {
+		return nil, statusError(codes.InvalidArgument, "INVALID_USER_ID", "invalid user ID format")
+	}
+	if req.Action != "" && !services.ValidAction(req.Action) {
+		return nil, statusError(codes.InvalidArgument, "INVALID_ACTION", "unknown action")
+	}
+	if req.Region != "" {
+		if err := s.faceService.ValidateClientRegion(req.Region); errors.Is(err, services.ErrRegionNotAllowed) {
+			return nil, statusError(codes.InvalidArgument, "REGION_NOT_ALLOWED", "region is not allowed")
+		} else if err != nil {
+			return nil, statusError(codes.InvalidArgument, "INVALID_REGION", "invalid region format")
+		}
+	}
+	if req.LivenessSession == "" && (cfg.LivenessChallengeRequired || cfg.NonceBindingEnabled) {
+		return nil, statusError(codes.FailedPrecondition, "LIVENESS_SESSION_REQUIRED",
+			"a liveness session is required; start one at /api/v1/liveness/session")
+	}
+
+	sessionID := req.SessionId
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+	verification := &models.VerificationRequest{
+		VideoData:       req.Video,
+		FrameData:       req.Frames,
+		UserID:          req.UserId,
+		Tenant:          tenantFrom(ctx),
+		SessionID:       sessionID,
+		Device:          req.Device,
+		Action:          req.Action,
+		Region:          req.Region,
+		LivenessSession: req.LivenessSession,
+		LivenessNonce:   req.LivenessNonce,
+		ClientIP:        peerIP(ctx),
+	}
+
+	// Reserve the session so concurrent reuse of the same ID is rejected
+	releaseSession, err := s.faceService.AcquireSession(sessionID)
+	if err != nil {
+		return nil, statusError(codes.Aborted, "SESSION_IN_USE", "session is already in use by another verification")
+	}
+
+	type outcome struct {
+		result *models.VerificationResult
+		err    error
+	}
+	ctx, cancel := context.WithTimeout(ctx, services.ProcessingTimeout(cfg))
+	defer cancel()
+	done := make(chan outcome, 1)
+	go func() {
+		defer releaseSession()
+		result, _, err := s.faceService.VerifyVideoDeduplicatedContext(ctx, verification)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if out.err != nil {
+			return nil, s.verifyError(out.err, sessionID)
+		}
+		return &verificationpb.VerifyResponse{Result: toProtoResult(out.result)}, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (s *Server) verifyError(err error, sessionID string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidFrame):
+		return statusError(codes.InvalidArgument, "INVALID_FRAME", "frames must be JPEG images of the same size")
+	case errors.Is(err, services.ErrLivenessSessionInvalid):
+		return statusError(codes.InvalidArgument, "LIVENESS_SESSION_INVALID", "liveness session is unknown, expired or already used")
+	case errors.Is(err, services.ErrDecodeBudgetExceeded):
+		return statusError(codes.ResourceExhausted, "DECODE_BUDGET_EXCEEDED", "capture took too long to decode")
+	case errors.Is(err, services.ErrServerBusy):
+		return statusError(codes.Unavailable, "SERVER_BUSY", "too many verifications in progress, retry later")
+	case errors.Is(err, services.ErrLockedOut):
+		return statusError(codes.ResourceExhausted, "LOCKED", "too many failed verifications, retry later")
+	}
+	s.logger.Error("Video verification failed", zap.Error(err), zap.String("session_id", sessionID))
+	return statusError(codes.Internal, "VERIFICATION_FAILED", "verification processing failed")
+}
+
+func (s *Server) Register(ctx context.Context, req *verificationpb.RegisterRequest) (*verificationpb.RegisterResponse, error) {
+	if !s.faceService.EnrollmentEnabled() {
+		return nil, statusError(codes.FailedPrecondition, "ENROLLMENT_DISABLED", "enrollment is currently disabled")
+	}
+	if req.UserId == "" {
+		return nil, statusError(codes.InvalidArgument, "MISSING_USER_ID", "user_id is required")
+	}
+	if !validUserID(req.UserId) {
+		return nil, statusError(codes.InvalidArgument, "INVALID_USER_ID", "invalid user ID format")
+	}
+	if len(req.Video) == 0 {
+		return nil, statusError(codes.InvalidArgument, "MISSING_VIDEO_FILE", "video is required")
+	}
+	if len(req.Video) > maxVideoSize {
+		return nil, statusError(codes.InvalidArgument, "INVALID_VIDEO_FILE", "video is larger than 50MB")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, services.ProcessingTimeout(s.faceService.Config()))
+	defer cancel()
+	userKey := tenant.UserKey(tenantFrom(ctx), req.UserId)
+	if err := s.faceService.RegisterFaceVideoContext(ctx, userKey, services.BytesVideo(req.Video)); err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if errors.Is(err, services.ErrEnrollmentDisabled) {
+			return nil, statusError(codes.FailedPrecondition, "ENROLLMENT_DISABLED", "enrollment is currently disabled")
+		}
+		if errors.Is(err, services.ErrDuplicateIdentity) {
+			return nil, statusError(codes.AlreadyExists, "DUPLICATE_IDENTITY", "this face is already enrolled under another user")
+		}
+		if errors.Is(err, services.ErrServerBusy) {
+			return nil, statusError(codes.Unavailable, "SERVER_BUSY", "too many verifications in progress, retry later")
+		}
+		var qualityErr *services.QualityError
+		if errors.As(err, &qualityErr) {
+			return nil, statusError(codes.FailedPrecondition, qualityErr.Code, qualityErr.Error())
+		}
+		s.logger.Error("Face registration failed", zap.Error(err), zap.String("user_id", req.UserId))
+		return nil, statusError(codes.Internal, "REGISTRATION_FAILED", "face registration failed")
+	}
+
+	return &verificationpb.RegisterResponse{
+		UserId:        req.UserId,
+		TemplateCount: int32(s.faceService.TemplateCount(userKey)),
+	}, nil
+}
+
+func (s *Server) Identify(ctx context.Context, req *verificationpb.IdentifyRequest) (*verificationpb.IdentifyResponse, error) {
+	if len(req.Video) == 0 {
+		return nil, statusError(codes.InvalidArgument, "MISSING_VIDEO_FILE", "video is required")
+	}
+	if len(req.Video) > maxVideoSize {
+		return nil, statusError(codes.InvalidArgument, "INVALID_VIDEO_FILE", "video is larger than 50MB")
+	}
+	k := int(req.MaxResults)
+	if k <= 0 {
+		k = services.DefaultIdentifyResults
+	}
+	if k > services.MaxIdentifyResults {
+		return nil, statusError(codes.InvalidArgument, "INVALID_MAX_RESULTS", "max_results must be at most 100")
+	}
+
+	vector, err := s.faceService.ExtractTemplate(req.Video)
+	if err != nil {
+		if errors.Is(err, services.ErrNotLive) {
+			return nil, statusError(codes.FailedPrecondition, "LIVENESS_FAILED", "capture failed the liveness check")
+		}
+		s.logger.Error("Template extraction failed", zap.Error(err))
+		return nil, statusError(codes.Internal, "IDENTIFICATION_FAILED", "face identification failed")
+	}
+
+	matches := s.faceService.SearchTenantGallery(tenantFrom(ctx), vector, k)
+	response := &verificationpb.IdentifyResponse{
+		Matches: make([]*verificationpb.GalleryMatch, 0, len(matches)),
+	}
+	for _, match := range matches {
+		response.Matches = append(response.Matches, &verificationpb.GalleryMatch{
+			UserId:     match.UserID,
+			Similarity: match.Similarity,
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) GetStatus(ctx context.Context, req *verificationpb.GetStatusRequest) (*verificationpb.GetStatusResponse, error) {
+	if req.VerificationId == "" {
+		return nil, statusError(codes.InvalidArgument, "MISSING_VERIFICATION_ID", "verification_id is required")
+	}
+
+	record, found := s.faceService.GetVerificationRecord(req.VerificationId)
+	if !found || record.Tenant != tenantFrom(ctx) {
+		return nil, statusError(codes.NotFound, "VERIFICATION_NOT_FOUND", "verification not found")
+	}
+
+	response := &verificationpb.GetStatusResponse{
+		VerificationId: record.ID,
+		Status:         string(record.Status),
+		CreatedAt:      timestamppb.New(record.CreatedAt),
+		UpdatedAt:      timestamppb.New(record.UpdatedAt),
+	}
+	if record.Status == models.StatusFailed {
+		response.ErrorMessage = record.ErrorMessage
+	}
+	if record.Result != nil {
+		response.Verified = record.Result.Verified
+		// Scores are only disclosed to admin-authenticated callers
+		if s.isAdmin(ctx) {
+			response.Result = toProtoResult(record.Result)
+		}
+	}
+	return response, nil
+}
+
+// isAdmin reports whether the call carries the admin key in x-admin-key.
+func (s *Server) isAdmin(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, key := range md.Get("x-admin-key") {
+		if middleware.AdminKeyMatches(s.faceService.Config().AdminAPIKey, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func toProtoResult(result *models.VerificationResult) *verificationpb.VerificationResult {
+	out := &verificationpb.VerificationResult{
+		VerificationId: result.VerificationID,
+		UserId:         result.UserID,
+		Verified:       result.Verified,
+		Confidence:     result.Confidence,
+		RawConfidence:  result.RawConfidence,
+		LivenessScore:  result.LivenessScore,
+		ProcessingTime: result.ProcessingTime,
+		Timestamp:      timestamppb.New(result.Timestamp),
+		Reason:         result.Reason,
+	}
+	for _, warning := range result.Warnings {
+		out.Warnings = append(out.Warnings, &verificationpb.VerificationWarning{
+			Code:    warning.Code,
+			Message: warning.Message,
+		})
+	}
+	return out
+}
+
+// statusError builds a status whose ErrorInfo reason is the REST error code.
+func statusError(code codes.Code, reason, message string) error {
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}); err == nil {
+		return detailed.Err()
+	}
+	return st.Err()
+}
+
+// validUserID mirrors the REST rule: 1-64 alphanumerics, hyphens and underscores.
+func validUserID(userID string) bool {
+	if len(userID) < 1 || len(userID) > 64 {
+		return false
+	}
+	for _, char := range userID {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func loggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.Info("gRPC request",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("latency", time.Since(start)))
+		return resp, err
+	}
+}
+
+func recoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("gRPC handler panicked",
+					zap.String("method", info.FullMethod),
+					zap.Any("panic", r),
+					zap.ByteString("stack", debug.Stack()))
+				err = statusError(codes.Internal, "INTERNAL_ERROR", "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
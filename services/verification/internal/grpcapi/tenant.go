@@ -0,0 +1,73 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"connect-hub/verification-service/internal/grpcapi/verificationpb"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/rbac"
+	"connect-hub/verification-service/internal/services"
+)
+
+type tenantContextKey struct{}
+
+// methodRoles are the roles each method requires, as its REST route does.
+var methodRoles = map[string]rbac.Roles{
+	verificationpb.VerificationService_Verify_FullMethodName:    rbac.Verify,
+	verificationpb.VerificationService_Identify_FullMethodName:  rbac.Verify,
+	verificationpb.VerificationService_GetStatus_FullMethodName: rbac.Verify,
+	verificationpb.VerificationService_Register_FullMethodName:  rbac.Enroll,
+}
+
+// tenantInterceptor resolves the caller's tenant from x-api-key, as
+// middleware.Tenant does for REST: with tenancy enabled, calls without a
+// tenant key are refused unless they carry the admin key, and admins act on
+// the tenant named by x-tenant-id. Keys limited to roles are refused the
+// methods that need others, as middleware.RequireRole does.
+func tenantInterceptor(faceService *services.FaceVerificationService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		tenants := faceService.Tenants()
+		if !tenants.Enabled() {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		apiKey := firstValue(md, "x-api-key")
+		if tenantID, ok := tenants.Resolve(apiKey); ok {
+			if role, ok := methodRoles[info.FullMethod]; ok && !tenants.Roles(apiKey).Has(role) {
+				return nil, statusError(codes.PermissionDenied, "ROLE_REQUIRED", fmt.Sprintf("the %s role is required", role))
+			}
+			return handler(context.WithValue(ctx, tenantContextKey{}, tenantID), req)
+		}
+		admin := false
+		for _, key := range md.Get("x-admin-key") {
+			admin = admin || middleware.AdminKeyMatches(faceService.Config().AdminAPIKey, key)
+		}
+		if !admin {
+			return nil, statusError(codes.Unauthenticated, "TENANT_REQUIRED", "a tenant API key is required")
+		}
+		tenantID := firstValue(md, "x-tenant-id")
+		if !tenants.Known(tenantID) {
+			return nil, statusError(codes.InvalidArgument, "UNKNOWN_TENANT", "unknown tenant")
+		}
+		return handler(context.WithValue(ctx, tenantContextKey{}, tenantID), req)
+	}
+}
+
+// tenantFrom returns the tenant tenantInterceptor resolved for the call.
+func tenantFrom(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
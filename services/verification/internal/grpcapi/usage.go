@@ -0,0 +1,41 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"connect-hub/verification-service/internal/grpcapi/verificationpb"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// usageInterceptor meters Verify and Register calls against the caller's
+// monthly quota, like the metered REST routes. Failed calls are not counted.
+func usageInterceptor(faceService *services.FaceVerificationService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var operation models.UsageOperation
+		switch req.(type) {
+		case *verificationpb.VerifyRequest:
+			operation = models.UsageVerification
+		case *verificationpb.RegisterRequest:
+			operation = models.UsageRegistration
+		default:
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		release, err := faceService.ReserveUsage(firstValue(md, "x-api-key"), tenantFrom(ctx), operation)
+		if err != nil {
+			return nil, statusError(codes.ResourceExhausted, "QUOTA_EXCEEDED", "monthly quota of verifications and registrations exceeded")
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			release()
+		}
+		return resp, err
+	}
+}
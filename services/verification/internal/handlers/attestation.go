@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+)
+
+// JWKS serves the public keys verification attestations are signed with.
+func (h *VerificationHandler) JWKS(c *gin.Context) {
+	keys, err := h.faceService.AttestationKeys()
+	if err != nil {
+		respondError(c, apperrors.ErrAttestationDisabled)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys)
+}
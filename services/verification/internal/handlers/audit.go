@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// Context keys handlers use to tell the audit middleware what they learned
+// about the request.
+const (
+	auditUserKey         = "audit_user_id"
+	auditVerificationKey = "audit_verification_id"
+	auditResultKey       = "audit_result"
+)
+
+// audited records operation in the audit log once the handler has run. The
+// user and outcome come from auditUser and auditVerification when the
+// handler set them, otherwise from the route and the response status.
+func (h *VerificationHandler) audited(operation models.AuditOperation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		result := c.GetString(auditResultKey)
+		if result == "" {
+			switch status := c.Writer.Status(); {
+			case status >= http.StatusInternalServerError || status == http.StatusRequestTimeout:
+				result = models.AuditError
+			case status >= http.StatusBadRequest:
+				result = models.AuditRejected
+			default:
+				result = models.AuditSuccess
+			}
+		}
+
+		userID := c.GetString(auditUserKey)
+		if userID == "" {
+			userID = c.Param("user_id")
+		}
+
+		h.faceService.RecordAudit(models.AuditEvent{
+			Operation:      operation,
+			Tenant:         middleware.TenantOf(c),
+			UserID:         userID,
+			VerificationID: c.GetString(auditVerificationKey),
+			Result:         result,
+			ClientKey:      services.ClientKeyFingerprint(c.GetHeader("X-API-Key")),
+			ClientIdentity: c.GetString(middleware.ClientIdentityContextKey),
+			ClientIP:       c.ClientIP(),
+			Admin:          c.GetBool(middleware.AdminContextKey),
+			Transport:      "http",
+		})
+	}
+}
+
+func auditUser(c *gin.Context, userID string) {
+	if userID != "" {
+		c.Set(auditUserKey, userID)
+	}
+}
+
+// auditVerification records the decision of a completed verification.
+func auditVerification(c *gin.Context, result *models.VerificationResult) {
+	auditUser(c, result.UserID)
+	c.Set(auditVerificationKey, result.VerificationID)
+	switch {
+	case result.Error != "":
+		c.Set(auditResultKey, models.AuditError)
+	case result.Verified:
+		c.Set(auditResultKey, models.AuditVerified)
+	default:
+		c.Set(auditResultKey, models.AuditNotVerified)
+	}
+}
+
+// QueryAuditLog lists biometric audit events, newest first, filtered by
+// user_id, operation and an optional RFC 3339 from/to range.
+func (h *VerificationHandler) QueryAuditLog(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		respondError(c, apperrors.ErrInvalidLimit)
+		return
+	}
+
+	q := storage.AuditQuery{
+		UserID:    c.Query("user_id"),
+		Operation: models.AuditOperation(c.Query("operation")),
+		Limit:     limit,
+	}
+	switch q.Operation {
+	case "", models.AuditRegister, models.AuditVerify, models.AuditIdentify, models.AuditDelete, models.AuditCompare, models.AuditTemplateRefresh:
+	default:
+		respondError(c, apperrors.ErrInvalidOperation)
+		return
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, apperrors.ErrInvalidDateRange.WithMessage(bound.name+" must be an RFC 3339 timestamp"))
+			return
+		}
+		*bound.target = parsed
+	}
+
+	events, err := h.faceService.QueryAudit(q)
+	if err != nil {
+		if errors.Is(err, services.ErrAuditLogDisabled) {
+			respondError(c, apperrors.ErrAuditLogDisabled)
+			return
+		}
+		h.logger.Error("Audit log query failed", zap.Error(err))
+		respondError(c, apperrors.ErrAuditQueryFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": events,
+		"count": len(events),
+	})
+}
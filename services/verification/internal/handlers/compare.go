@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// CompareFaces compares a selfie capture with a document photo, for
+// onboarding users who have no enrollment yet.
+func (h *VerificationHandler) CompareFaces(c *gin.Context) {
+	video, document, ok := h.comparisonUploads(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), services.ProcessingTimeout(h.faceService.Config()))
+	defer cancel()
+
+	comparison, err := h.faceService.CompareFaces(ctx, video, document)
+	if err != nil {
+		h.comparisonFailed(c, ctx, err)
+		return
+	}
+
+	if comparison.Match {
+		c.Set(auditResultKey, models.AuditMatch)
+	} else {
+		c.Set(auditResultKey, models.AuditNoMatch)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    comparison,
+	})
+}
+
+// VerifyDocument verifies a selfie capture against an identity document,
+// returning the face comparison along with the portrait crop and the fields
+// read from the document's machine-readable zone.
+func (h *VerificationHandler) VerifyDocument(c *gin.Context) {
+	video, document, ok := h.comparisonUploads(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), services.ProcessingTimeout(h.faceService.Config()))
+	defer cancel()
+
+	verification, err := h.faceService.VerifyDocument(ctx, video, document)
+	if err != nil {
+		h.comparisonFailed(c, ctx, err)
+		return
+	}
+
+	if verification.Match {
+		c.Set(auditResultKey, models.AuditMatch)
+	} else {
+		c.Set(auditResultKey, models.AuditNoMatch)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    verification,
+	})
+}
+
+// comparisonUploads reads the "video" and "document" parts of a comparison
+// request, writing the error response and returning false when either is
+// missing or unusable.
+func (h *VerificationHandler) comparisonUploads(c *gin.Context) (*services.StreamedVideo, []byte, bool) {
+	form, ok := h.parseUploadForm(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	videos, documents := form.File["video"], form.File["document"]
+	if len(videos) == 0 {
+		respondError(c, apperrors.ErrMissingVideo)
+		return nil, nil, false
+	}
+	if len(documents) == 0 {
+		respondError(c, apperrors.ErrMissingDocument)
+		return nil, nil, false
+	}
+
+	if err := h.validateVideoFile(c, videos[0]); err != nil {
+		respondError(c, err)
+		return nil, nil, false
+	}
+
+	maxDocumentSize := int64(h.faceService.Config().CompareMaxDocumentSize)
+	if maxDocumentSize <= 0 {
+		maxDocumentSize = 10 * 1024 * 1024
+	}
+	if documents[0].Size > maxDocumentSize {
+		respondError(c, apperrors.ErrDocumentTooLarge.WithMessage(fmt.Sprintf("Document too large. Maximum size is %d bytes", maxDocumentSize)))
+		return nil, nil, false
+	}
+
+	document, err := h.readVideoFile(documents[0])
+	if err != nil {
+		h.logger.Error("Failed to read document", zap.Error(err), zap.String("filename", documents[0].Filename))
+		respondError(c, apperrors.ErrFileRead.WithMessage("Failed to process document"))
+		return nil, nil, false
+	}
+	video, err := h.openVideoFile(videos[0])
+	if err != nil {
+		h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", videos[0].Filename))
+		respondError(c, apperrors.ErrFileRead)
+		return nil, nil, false
+	}
+	return video, document, true
+}
+
+// comparisonFailed maps a CompareFaces or VerifyDocument error to its
+// response. A client that went away gets none.
+func (h *VerificationHandler) comparisonFailed(c *gin.Context, ctx context.Context, err error) {
+	switch {
+	case ctx.Err() != nil:
+		if errors.Is(ctx.Err(), context.Canceled) {
+			h.logger.Info("Client disconnected, comparison canceled")
+			return
+		}
+		respondError(c, apperrors.ErrTimeout)
+	case errors.Is(err, services.ErrInvalidDocument):
+		respondError(c, apperrors.ErrInvalidDocument)
+	case errors.Is(err, services.ErrNoFaceInCapture):
+		respondError(c, apperrors.ErrNoFaceDetected)
+	case errors.Is(err, services.ErrNoFaceInDocument):
+		respondError(c, apperrors.ErrNoFaceInDocument)
+	case errors.Is(err, services.ErrServerBusy):
+		h.serverBusy(c)
+	default:
+		h.logger.Error("Face comparison failed", zap.Error(err))
+		respondError(c, apperrors.ErrComparisonFailed)
+	}
+}
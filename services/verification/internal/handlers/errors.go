@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+)
+
+// respondError answers with err's API error. Errors outside the taxonomy
+// are answered as INTERNAL_ERROR, without their text.
+func respondError(c *gin.Context, err error) {
+	apiErr := apperrors.From(err)
+	c.JSON(apiErr.Status, apiErr.Body())
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz is the liveness probe: it answers as long as the process serves
+// requests, whatever the state of its dependencies.
+func (h *VerificationHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "healthy",
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// Readyz is the readiness probe: 200 when every dependency check passed,
+// 503 otherwise, with the outcome of each check either way.
+func (h *VerificationHandler) Readyz(c *gin.Context) {
+	readiness := h.faceService.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, readiness)
+}
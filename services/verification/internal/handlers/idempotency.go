@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/storage"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// replayedHeaders are kept with a stored response besides its body.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotent answers retries of a request carrying an Idempotency-Key with
+// the response to the first attempt instead of running it again. Keys are
+// scoped to the client (API key, else IP) and route. Only final answers are
+// kept: server errors, timeouts, conflicts and rate limiting release the
+// key so a retry runs afresh. When the store is unreachable the request
+// runs without the guarantee rather than failing.
+func (h *VerificationHandler) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || !h.faceService.IdempotencyEnabled() {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			respondError(c, apperrors.ErrInvalidIdempotencyKey)
+			c.Abort()
+			return
+		}
+
+		scoped := idempotencyScope(c, key)
+		ctx := c.Request.Context()
+		stored, err := h.faceService.ReserveIdempotencyKey(ctx, scoped)
+		switch {
+		case errors.Is(err, storage.ErrIdempotencyKeyInUse):
+			respondError(c, apperrors.ErrIdempotencyKeyInUse)
+			c.Abort()
+			return
+		case err != nil:
+			h.logger.Warn("Idempotency store unavailable, running request without it", zap.Error(err))
+			c.Next()
+			return
+		case stored != nil:
+			for name, value := range stored.Header {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Status(stored.Status)
+			c.Writer.Write(stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// The request context may be gone with the client
+		ctx = context.WithoutCancel(ctx)
+		if !recorder.Written() || !storableStatus(recorder.Status()) {
+			if err := h.faceService.ReleaseIdempotencyKey(ctx, scoped); err != nil {
+				h.logger.Warn("Failed to release idempotency key", zap.Error(err))
+			}
+			return
+		}
+
+		response := &storage.IdempotentResponse{
+			Status: recorder.Status(),
+			Header: make(map[string]string),
+			Body:   recorder.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+		if err := h.faceService.CompleteIdempotencyKey(ctx, scoped, response); err != nil {
+			h.logger.Warn("Failed to store idempotent response", zap.Error(err))
+		}
+	}
+}
+
+// idempotencyScope keys an Idempotency-Key by tenant, client and route,
+// hashed so neither API keys nor client-chosen keys reach the store.
+func idempotencyScope(c *gin.Context, key string) string {
+	client := "ip:" + c.ClientIP()
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		client = "key:" + apiKey
+	}
+	if tenantID := middleware.TenantOf(c); tenantID != "" {
+		client = "tenant:" + tenantID + "/" + client
+	}
+	sum := sha256.Sum256([]byte(client + "\n" + c.Request.Method + " " + c.FullPath() + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func storableStatus(status int) bool {
+	switch {
+	case status >= http.StatusInternalServerError,
+		status == http.StatusRequestTimeout,
+		status == http.StatusConflict,
+		status == http.StatusTooManyRequests,
+		status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// responseRecorder keeps a copy of the response body as it is written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
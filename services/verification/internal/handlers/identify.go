@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// Identify searches the caller's tenant gallery for the users closest to a
+// live capture, best first, like the gRPC Identify call.
+func (h *VerificationHandler) Identify(c *gin.Context) {
+	form, ok := h.parseUploadForm(c)
+	if !ok {
+		return
+	}
+
+	files := form.File["video"]
+	if len(files) == 0 {
+		respondError(c, apperrors.ErrMissingVideo)
+		return
+	}
+
+	k := services.DefaultIdentifyResults
+	if value := c.PostForm("max_results"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > services.MaxIdentifyResults {
+			respondError(c, apperrors.ErrInvalidMaxResults)
+			return
+		}
+		k = n
+	}
+
+	file := files[0]
+	if err := h.validateVideoFile(c, file); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	video, err := h.openVideoFile(file)
+	if err != nil {
+		h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", file.Filename))
+		respondError(c, apperrors.ErrFileRead)
+		return
+	}
+
+	vector, err := h.faceService.ExtractTemplateVideo(video)
+	if err != nil {
+		if errors.Is(err, services.ErrNotLive) {
+			respondError(c, apperrors.ErrLivenessFailed)
+			return
+		}
+		h.logger.Error("Identification failed", zap.Error(err))
+		respondError(c, apperrors.ErrIdentificationFailed)
+		return
+	}
+
+	matches := h.faceService.SearchTenantGallery(middleware.TenantOf(c), vector, k)
+	if matches == nil {
+		matches = []models.GalleryMatch{}
+	}
+	if len(matches) > 0 {
+		auditUser(c, matches[0].UserID)
+		c.Set(auditResultKey, models.AuditMatch)
+	} else {
+		c.Set(auditResultKey, models.AuditNoMatch)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"matches": matches},
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// VerifyImage matches a single selfie image against the user's enrollment,
+// for clients that cannot capture video. Liveness is not assessed.
+func (h *VerificationHandler) VerifyImage(c *gin.Context) {
+	cfg := h.faceService.Config()
+	if !cfg.ImageVerificationEnabled {
+		respondError(c, apperrors.ErrImageVerificationDisabled)
+		return
+	}
+	// A still image cannot answer an active liveness challenge
+	if cfg.LivenessChallengeRequired || cfg.NonceBindingEnabled {
+		respondError(c, apperrors.ErrLivenessSessionMissing)
+		return
+	}
+
+	form, ok := h.parseUploadForm(c)
+	if !ok {
+		return
+	}
+
+	files := form.File["image"]
+	if len(files) == 0 {
+		respondError(c, apperrors.ErrMissingImage)
+		return
+	}
+
+	userID := c.PostForm("user_id")
+	if userID == "" {
+		respondError(c, apperrors.ErrMissingUserID.WithMessage("User ID is required"))
+		return
+	}
+	if !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+	auditUser(c, userID)
+
+	region := c.PostForm("region")
+	if !h.validateRegion(c, region) {
+		return
+	}
+
+	maxImageSize := int64(cfg.MaxImageSize)
+	if maxImageSize <= 0 {
+		maxImageSize = 10 * 1024 * 1024
+	}
+	if files[0].Size > maxImageSize {
+		respondError(c, apperrors.ErrImageTooLarge.WithMessage(fmt.Sprintf("Image too large. Maximum size is %d bytes", maxImageSize)))
+		return
+	}
+
+	data, err := h.readVideoFile(files[0])
+	if err != nil {
+		h.logger.Error("Failed to read image", zap.Error(err), zap.String("filename", files[0].Filename))
+		respondError(c, apperrors.ErrFileRead.WithMessage("Failed to process image"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), services.ProcessingTimeout(cfg))
+	defer cancel()
+
+	result, err := h.faceService.VerifyImage(ctx, &models.VerificationRequest{
+		UserID:   userID,
+		Tenant:   middleware.TenantOf(c),
+		Device:   h.deviceLabel(c),
+		Region:   region,
+		ClientIP: c.ClientIP(),
+	}, data)
+	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+			h.verificationAborted(c, ctx.Err(), "")
+		case errors.Is(err, services.ErrInvalidImage):
+			respondError(c, apperrors.ErrInvalidImage)
+		case errors.Is(err, services.ErrNoFaceDetected):
+			respondError(c, apperrors.ErrNoFaceDetected.WithMessage("No face detected in the image"))
+		case errors.Is(err, services.ErrServerBusy):
+			h.serverBusy(c)
+		case errors.Is(err, services.ErrLockedOut):
+			h.lockedOut(c, err)
+		default:
+			h.logger.Error("Image verification failed", zap.Error(err))
+			respondError(c, apperrors.ErrVerificationFailed)
+		}
+		return
+	}
+
+	h.logger.Info("Image verification completed",
+		zap.String("verification_id", result.VerificationID),
+		zap.Bool("verified", result.Verified),
+		zap.Float64("confidence", result.Confidence),
+		zap.Float64("processing_time", result.ProcessingTime))
+
+	auditVerification(c, result)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.localizeResult(c, result),
+	})
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  64 * 1024,
+	WriteBufferSize: 16 * 1024,
+}
+
+// liveOriginCheck admits the WebSocket handshake from the origins CORS
+// allows, since browsers do not apply CORS to WebSockets. Same-host pages
+// and clients that send no Origin are admitted as well.
+func liveOriginCheck(cors middleware.CORSConfig) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || cors.AllowsOrigin(origin) {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// liveMessage is a control message sent by the client as text.
+type liveMessage struct {
+	Type string `json:"type"`
+}
+
+// VerifyLive verifies a capture streamed over a WebSocket instead of
+// uploaded. The client sends each JPEG frame as a binary message and
+// {"type":"finish"} once done; the server answers every frame with a
+// progress event and closes after the result or an error event.
+func (h *VerificationHandler) VerifyLive(c *gin.Context) {
+	cfg := h.faceService.Config()
+	if !cfg.LiveVerificationEnabled {
+		respondError(c, apperrors.ErrLiveVerificationDisabled)
+		return
+	}
+
+	// Parameters are validated before upgrading so failures are plain HTTP
+	userID := c.Query("user_id")
+	auditUser(c, userID)
+	if userID != "" && !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	action := c.Query("action")
+	if action != "" && !services.ValidAction(action) {
+		respondError(c, apperrors.ErrInvalidAction)
+		return
+	}
+
+	region := c.Query("region")
+	if !h.validateRegion(c, region) {
+		return
+	}
+
+	sessionID := c.Query("session_id")
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+
+	template := models.VerificationRequest{
+		SessionID:       sessionID,
+		Device:          h.deviceLabel(c),
+		Action:          action,
+		Region:          region,
+		LivenessSession: c.Query("liveness_session"),
+		LivenessNonce:   c.Query("liveness_nonce"),
+		ClientIP:        c.ClientIP(),
+	}
+	if !h.checkLivenessSession(c, &template) {
+		return
+	}
+	if err := h.faceService.CheckLockout(middleware.TenantOf(c), userID, template.ClientIP); err != nil {
+		h.lockedOut(c, err)
+		return
+	}
+
+	// The session is held for the whole connection
+	releaseSession, err := h.faceService.AcquireSession(sessionID)
+	if err != nil {
+		h.logger.Warn("Session already in use", zap.String("session_id", sessionID))
+		respondError(c, apperrors.ErrSessionInUse)
+		return
+	}
+	defer releaseSession()
+
+	upgrader := liveUpgrader
+	upgrader.CheckOrigin = liveOriginCheck(middleware.NewCORSConfig(cfg))
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered the handshake
+		h.logger.Warn("WebSocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	// Until a result is sent, the capture counts as failed
+	c.Set(auditResultKey, models.AuditError)
+
+	maxFrameSize := int64(cfg.MaxFrameSize)
+	if maxFrameSize <= 0 {
+		maxFrameSize = 2 * 1024 * 1024
+	}
+	conn.SetReadLimit(maxFrameSize)
+
+	idleTimeout := time.Duration(cfg.LiveIdleTimeout) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = 10 * time.Second
+	}
+
+	live := h.faceService.NewLiveVerification(middleware.TenantOf(c), userID)
+	for !live.Full() {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				h.closeLive(conn, apperrors.ErrFrameTooLarge)
+				return
+			}
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.Info("Live verification connection lost",
+					zap.Error(err),
+					zap.String("session_id", sessionID))
+			}
+			return
+		}
+
+		if messageType == websocket.TextMessage {
+			var message liveMessage
+			if json.Unmarshal(data, &message) != nil || message.Type != "finish" {
+				h.closeLive(conn, apperrors.ErrInvalidMessage)
+				return
+			}
+			break
+		}
+
+		progress, err := live.AddFrame(data)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidFrame) {
+				h.closeLive(conn, apperrors.ErrDecodeFailed)
+				return
+			}
+			h.logger.Error("Live frame processing failed", zap.Error(err), zap.String("session_id", sessionID))
+			h.closeLive(conn, apperrors.ErrVerificationFailed)
+			return
+		}
+		if err := conn.WriteJSON(gin.H{"type": "progress", "data": progress}); err != nil {
+			return
+		}
+	}
+
+	minFrames, _ := submittedFrameLimits(cfg.MinSubmittedFrames, cfg.MaxSubmittedFrames)
+	if live.Frames() < minFrames {
+		h.closeLive(conn, apperrors.ErrInvalidFrameCount.WithMessage("Not enough frames to verify"))
+		return
+	}
+
+	// The accumulated frames run the same pipeline as /verify/frames
+	ctx, cancel := context.WithTimeout(c.Request.Context(), services.ProcessingTimeout(cfg))
+	defer cancel()
+	result, _, err := h.faceService.VerifyVideoDeduplicatedContext(ctx, live.Request(template))
+	if err != nil {
+		if errors.Is(err, services.ErrLivenessSessionInvalid) {
+			h.closeLive(conn, apperrors.ErrLivenessSessionInvalid)
+			return
+		}
+		if errors.Is(err, services.ErrServerBusy) {
+			h.closeLive(conn, apperrors.ErrServerBusy)
+			return
+		}
+		if errors.Is(err, services.ErrLockedOut) {
+			h.closeLive(conn, apperrors.ErrLocked)
+			return
+		}
+		h.logger.Error("Live verification failed", zap.Error(err), zap.String("session_id", sessionID))
+		h.closeLive(conn, apperrors.ErrVerificationFailed)
+		return
+	}
+
+	h.logger.Info("Live verification completed",
+		zap.String("verification_id", result.VerificationID),
+		zap.String("session_id", sessionID),
+		zap.Int("frames", live.Frames()),
+		zap.Bool("verified", result.Verified),
+		zap.Float64("liveness_score", result.LivenessScore))
+
+	auditVerification(c, result)
+	if err := conn.WriteJSON(gin.H{"type": "result", "data": h.localizeResult(c, result)}); err != nil {
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+}
+
+// closeLive sends an error event, then closes the connection as a policy
+// violation.
+func (h *VerificationHandler) closeLive(conn *websocket.Conn, apiErr *apperrors.Error) {
+	event := apiErr.Body()
+	event["type"] = "error"
+	if err := conn.WriteJSON(event); err != nil {
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, apiErr.Code),
+		time.Now().Add(time.Second))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// ListReviews lists the caller's tenant's review cases, oldest first:
+// pending ones by default, those with another status, or all of them.
+func (h *VerificationHandler) ListReviews(c *gin.Context) {
+	status := models.ReviewStatus(c.DefaultQuery("status", string(models.ReviewPending)))
+	switch status {
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+	case "all":
+		status = ""
+	default:
+		respondError(c, apperrors.ErrInvalidStatus.WithMessage("status must be pending, approved, rejected or all"))
+		return
+	}
+
+	cases := h.faceService.ReviewCases(middleware.TenantOf(c), status)
+	c.JSON(http.StatusOK, gin.H{
+		"items": cases,
+		"count": len(cases),
+	})
+}
+
+// GetReview returns a review case with its evidence.
+func (h *VerificationHandler) GetReview(c *gin.Context) {
+	evidence, err := h.faceService.ReviewEvidence(middleware.TenantOf(c), c.Param("id"))
+	if err != nil {
+		respondError(c, apperrors.ErrReviewNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, evidence)
+}
+
+type reviewDecisionRequest struct {
+	Reviewer string `json:"reviewer"`
+	Note     string `json:"note"`
+}
+
+// ApproveReview completes a pending case as verified.
+func (h *VerificationHandler) ApproveReview(c *gin.Context) {
+	h.decideReview(c, true)
+}
+
+// RejectReview completes a pending case as not verified.
+func (h *VerificationHandler) RejectReview(c *gin.Context) {
+	h.decideReview(c, false)
+}
+
+func (h *VerificationHandler) decideReview(c *gin.Context, approve bool) {
+	var body reviewDecisionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest)
+		return
+	}
+	if body.Reviewer == "" {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("reviewer is required"))
+		return
+	}
+
+	result, err := h.faceService.DecideReview(middleware.TenantOf(c), c.Param("id"), approve, body.Reviewer, body.Note)
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		respondError(c, apperrors.ErrReviewNotFound)
+		return
+	case errors.Is(err, services.ErrReviewDecided):
+		respondError(c, apperrors.ErrReviewDecided)
+		return
+	case err != nil:
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
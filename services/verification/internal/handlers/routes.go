@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/openapi"
+	"connect-hub/verification-service/internal/rbac"
+)
+
+// RegisterRoutes mounts every endpoint of the service on router. Keep the
+// OpenAPI document in internal/openapi in sync when adding routes.
+func RegisterRoutes(router *gin.Engine, verificationHandler *VerificationHandler, cfg *config.Config) {
+	// Liveness and readiness probes; /health predates the split and stays
+	// as an alias of /healthz
+	router.GET("/health", verificationHandler.Healthz)
+	router.GET("/healthz", verificationHandler.Healthz)
+	router.GET("/readyz", verificationHandler.Readyz)
+
+	// Process metrics, which expose runtime internals, for admins only
+	router.GET("/debug/vars", middleware.RequireAdmin(cfg.AdminAPIKey), gin.WrapH(expvar.Handler()))
+
+	// Machine-readable API contract
+	if cfg.OpenAPIEnabled {
+		router.GET("/openapi.json", func(c *gin.Context) {
+			c.JSON(http.StatusOK, openapi.Spec())
+		})
+	}
+
+	// Keys for verifying result attestations
+	router.GET("/.well-known/jwks.json", verificationHandler.JWKS)
+
+	// API routes
+	// Every API call runs against the caller's tenant, which a bearer token
+	// may name
+	jwtConfig := middleware.NewJWTConfig(cfg)
+	bearer := middleware.NewJWTVerifier(jwtConfig)
+	v1 := router.Group("/api/v1")
+	if jwtConfig.Enabled() {
+		v1.Use(middleware.IdentifyBearer(bearer))
+	}
+	v1.Use(middleware.Tenant(verificationHandler.faceService.Tenants(), cfg.AdminAPIKey))
+	{
+		// Biometric operations are recorded in the audit log
+		verify := verificationHandler.audited(models.AuditVerify)
+		// Retries with the same Idempotency-Key get the first response
+		idempotent := verificationHandler.idempotent()
+		// Verifications and registrations count against monthly quotas
+		meterVerify := verificationHandler.metered(models.UsageVerification)
+		meterRegister := verificationHandler.metered(models.UsageRegistration)
+		// Credentials limited to other roles are refused
+		canVerify := middleware.RequireRole(cfg.AdminAPIKey, rbac.Verify)
+		canEnroll := middleware.RequireRole(cfg.AdminAPIKey, rbac.Enroll)
+		v1.POST("/verify", canVerify, idempotent, verify, meterVerify, verificationHandler.VerifyVideo)
+		v1.POST("/verify/ref", canVerify, verify, meterVerify, verificationHandler.VerifyReference)
+		v1.POST("/uploads", canVerify, verificationHandler.CreateUpload)
+		v1.POST("/verify/frames", canVerify, verify, meterVerify, verificationHandler.VerifyFrames)
+		v1.POST("/verify/image", canVerify, verify, meterVerify, verificationHandler.VerifyImage)
+		v1.GET("/verify/live", canVerify, verify, meterVerify, verificationHandler.VerifyLive)
+		v1.POST("/liveness/session", canVerify, verificationHandler.StartLivenessSession)
+		v1.POST("/verify/precheck", canVerify, verificationHandler.PrecheckLiveness)
+		v1.POST("/verify/continue", canVerify, verify, meterVerify, verificationHandler.ContinueVerification)
+		v1.GET("/status/:id", canVerify, middleware.IdentifyAdmin(cfg.AdminAPIKey), verificationHandler.GetVerificationStatus)
+		v1.GET("/verify/:id/events", canVerify, middleware.IdentifyAdmin(cfg.AdminAPIKey), verificationHandler.StreamVerificationEvents)
+		v1.POST("/register", canEnroll, idempotent, verificationHandler.audited(models.AuditRegister), meterRegister, verificationHandler.RegisterFace)
+		v1.POST("/template", canEnroll, verificationHandler.ExtractTemplate)
+		v1.POST("/match", canVerify, verify, meterVerify, verificationHandler.MatchTemplate)
+		v1.POST("/identify", canVerify, verificationHandler.audited(models.AuditIdentify), verificationHandler.Identify)
+		v1.POST("/compare", canVerify, verificationHandler.audited(models.AuditCompare), verificationHandler.CompareFaces)
+		v1.POST("/verify/document", canVerify, verificationHandler.audited(models.AuditCompare), verificationHandler.VerifyDocument)
+		v1.GET("/faces/:user_id", canEnroll, verificationHandler.GetFaceTemplates)
+		v1.DELETE("/faces/:user_id", middleware.RequireAdmin(cfg.AdminAPIKey),
+			verificationHandler.audited(models.AuditDelete), verificationHandler.EraseUser)
+
+		// The tenant's review queue is open to reviewers as well as admins
+		reviews := v1.Group("/admin/reviews", middleware.RequireRole(cfg.AdminAPIKey, rbac.Reviewer))
+		reviews.GET("", verificationHandler.ListReviews)
+		reviews.GET("/:id", verificationHandler.GetReview)
+		reviews.POST("/:id/approve", verificationHandler.ApproveReview)
+		reviews.POST("/:id/reject", verificationHandler.RejectReview)
+
+		// Admin-only queries
+		admin := v1.Group("/admin", middleware.RequireAdmin(cfg.AdminAPIKey))
+		admin.GET("/verifications", verificationHandler.ListVerifications)
+		admin.GET("/audit", verificationHandler.QueryAuditLog)
+		admin.GET("/audit/export", verificationHandler.ExportAudit)
+		admin.GET("/webhooks", verificationHandler.ListWebhookDeliveries)
+		admin.POST("/webhooks/:id/redeliver", verificationHandler.RedeliverWebhook)
+		admin.POST("/selfbench", verificationHandler.SelfBench)
+		admin.GET("/enrollment", verificationHandler.GetEnrollment)
+		admin.PUT("/enrollment", verificationHandler.SetEnrollment)
+		admin.POST("/keys/rotate", verificationHandler.RotateEncryptionKey)
+		admin.POST("/descriptors/migrate", verificationHandler.MigrateDescriptors)
+		admin.GET("/usage", verificationHandler.GetUsage)
+		admin.GET("/watchlist", verificationHandler.ListWatchlist)
+		admin.POST("/watchlist", verificationHandler.AddWatchlistEntry)
+		admin.DELETE("/watchlist/:id", verificationHandler.DeleteWatchlistEntry)
+		admin.GET("/tenants/:tenant_id/config", verificationHandler.GetTenantConfig)
+		admin.PUT("/tenants/:tenant_id/config", verificationHandler.SetTenantConfig)
+		admin.DELETE("/tenants/:tenant_id/config", verificationHandler.DeleteTenantConfig)
+		admin.GET("/config", verificationHandler.GetTuning)
+		admin.POST("/config/reload", verificationHandler.ReloadConfig)
+
+		// Self-service endpoints authenticated with user bearer tokens
+		if jwtConfig.Enabled() {
+			v1.GET("/users/:id/history", middleware.RequireBearer(bearer), verificationHandler.GetUserHistory)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// GetTenantConfig returns a tenant's overrides, null when it has none, and
+// the settings in effect.
+func (h *VerificationHandler) GetTenantConfig(c *gin.Context) {
+	tenantID, ok := h.tenantParam(c)
+	if !ok {
+		return
+	}
+	if overrides, ok := h.faceService.TenantConfig(tenantID); ok {
+		h.respondTenantConfig(c, tenantID, &overrides)
+		return
+	}
+	h.respondTenantConfig(c, tenantID, nil)
+}
+
+// SetTenantConfig replaces a tenant's overrides.
+func (h *VerificationHandler) SetTenantConfig(c *gin.Context) {
+	tenantID, ok := h.tenantParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.TenantConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest)
+		return
+	}
+
+	overrides, err := h.faceService.SetTenantConfig(tenantID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTenantConfig) {
+			message := strings.TrimPrefix(err.Error(), services.ErrInvalidTenantConfig.Error()+": ")
+			respondError(c, apperrors.ErrInvalidTenantConfig.WithMessage(message))
+			return
+		}
+		h.logger.Error("Failed to save tenant configuration", zap.Error(err))
+		respondError(c, err)
+		return
+	}
+	h.respondTenantConfig(c, tenantID, &overrides)
+}
+
+// DeleteTenantConfig drops a tenant's overrides, returning it to the
+// configured settings.
+func (h *VerificationHandler) DeleteTenantConfig(c *gin.Context) {
+	tenantID, ok := h.tenantParam(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.faceService.DeleteTenantConfig(tenantID); err != nil {
+		h.logger.Error("Failed to save tenant configuration", zap.Error(err))
+		respondError(c, err)
+		return
+	}
+	h.logger.Info("Tenant configuration reset", zap.String("tenant", tenantID))
+	h.respondTenantConfig(c, tenantID, nil)
+}
+
+// tenantParam returns the :tenant_id path parameter, which must name a
+// tenant with API keys.
+func (h *VerificationHandler) tenantParam(c *gin.Context) (string, bool) {
+	tenantID := c.Param("tenant_id")
+	if tenantID == "" || !h.faceService.Tenants().Known(tenantID) {
+		respondError(c, apperrors.ErrUnknownTenant)
+		return "", false
+	}
+	return tenantID, true
+}
+
+func (h *VerificationHandler) respondTenantConfig(c *gin.Context, tenantID string, overrides *models.TenantConfig) {
+	c.JSON(http.StatusOK, gin.H{
+		"tenant":    tenantID,
+		"overrides": overrides,
+		"effective": h.faceService.TenantSettings(tenantID),
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/services"
+)
+
+// GetTuning returns the thresholds, detector weights and rate limits in
+// effect.
+func (h *VerificationHandler) GetTuning(c *gin.Context) {
+	c.JSON(http.StatusOK, h.faceService.Tuning())
+}
+
+// ReloadConfig reads the configuration again and applies its tunable
+// settings, as SIGHUP does.
+func (h *VerificationHandler) ReloadConfig(c *gin.Context) {
+	tuned, err := h.faceService.ReloadConfig()
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTuning) {
+			h.logger.Warn("Configuration reload rejected", zap.Error(err))
+			message := strings.TrimPrefix(err.Error(), services.ErrInvalidTuning.Error()+": ")
+			respondError(c, apperrors.ErrInvalidConfig.WithMessage(message))
+			return
+		}
+		h.logger.Error("Failed to reload configuration", zap.Error(err))
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tuned)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// metered counts operation against the caller's monthly quota and rejects
+// the request with QUOTA_EXCEEDED once it is spent. Requests the handler
+// fails are not counted.
+func (h *VerificationHandler) metered(operation models.UsageOperation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		release, err := h.faceService.ReserveUsage(c.GetHeader("X-API-Key"), middleware.TenantOf(c), operation)
+		if err != nil {
+			respondError(c, apperrors.ErrQuotaExceeded)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			release()
+		}
+	}
+}
+
+// GetUsage reports verifications and registrations per API key for the
+// month given as YYYY-MM, the current one by default, optionally only for
+// the key with the client_key fingerprint.
+func (h *VerificationHandler) GetUsage(c *gin.Context) {
+	month := c.DefaultQuery("month", time.Now().UTC().Format(services.UsageMonthFormat))
+	if _, err := time.Parse(services.UsageMonthFormat, month); err != nil {
+		respondError(c, apperrors.ErrInvalidMonth)
+		return
+	}
+
+	records := h.faceService.Usage(month, c.Query("client_key"))
+	c.JSON(http.StatusOK, gin.H{
+		"month": month,
+		"items": records,
+		"count": len(records),
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+)
+
+// eventStreamHeartbeat is how often an idle event stream sends a comment,
+// so proxies do not time the connection out.
+const eventStreamHeartbeat = 15 * time.Second
+
+// StreamVerificationEvents streams the progress of a verification as
+// Server-Sent Events: a "progress" event each time it passes a stage or
+// changes status, then a "result" event once it is completed, failed or
+// held for review, after which the stream ends. Events carry the body of
+// GET /status/:id.
+func (h *VerificationHandler) StreamVerificationEvents(c *gin.Context) {
+	verificationID := c.Param("id")
+	if !h.isValidVerificationID(verificationID) {
+		respondError(c, apperrors.ErrInvalidVerificationID)
+		return
+	}
+
+	// Watching before the first read misses no change in between
+	changed, stop := h.faceService.WatchVerification(verificationID)
+	defer stop()
+
+	// Another tenant's verifications are reported as unknown
+	tenantID := middleware.TenantOf(c)
+	record, found := h.faceService.GetVerificationRecord(verificationID)
+	if !found || record.Tenant != tenantID {
+		respondError(c, apperrors.ErrVerificationNotFound)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Keeps nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	// The stream outlasts the server's WriteTimeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	var sent *models.VerificationRecord
+	c.Stream(func(w io.Writer) bool {
+		record, found := h.faceService.GetVerificationRecord(verificationID)
+		if !found || record.Tenant != tenantID {
+			// Evicted or erased while streaming
+			c.SSEvent("error", apperrors.ErrVerificationNotFound.Body())
+			return false
+		}
+
+		if finished(record.Status) {
+			c.SSEvent("result", statusBody(c, record))
+			return false
+		}
+		if sent == nil || record.Status != sent.Status || record.Stage != sent.Stage {
+			c.SSEvent("progress", statusBody(c, record))
+			sent = &record
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-heartbeat.C:
+			io.WriteString(w, ": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// finished reports whether a verification with status has an outcome.
+func finished(status models.VerificationStatus) bool {
+	switch status {
+	case models.StatusCompleted, models.StatusFailed, models.StatusNeedsReview:
+		return true
+	}
+	return false
+}
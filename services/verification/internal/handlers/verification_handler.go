@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,8 +16,25 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/i18n"
+	"connect-hub/verification-service/internal/middleware"
 	"connect-hub/verification-service/internal/models"
 	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+const (
+	// uploadMemory is how much of a multipart body is kept in memory; the
+	// rest is spilled to temp files.
+	uploadMemory = 1 << 20
+	// uploadFormOverhead leaves room for boundaries and text fields on top
+	// of the capture itself.
+	uploadFormOverhead = 1 << 20
+	// serverBusyRetryAfter is the Retry-After, in seconds, sent with
+	// SERVER_BUSY.
+	serverBusyRetryAfter = 1
 )
 
 type VerificationHandler struct {
@@ -29,46 +50,38 @@ func NewVerificationHandler(faceService *services.FaceVerificationService, logge
 }
 
 func (h *VerificationHandler) VerifyVideo(c *gin.Context) {
+	// A JSON body references a capture already uploaded to object storage
+	if c.ContentType() == "application/json" {
+		h.VerifyReference(c)
+		return
+	}
+
 	// Parse multipart form with validation
-	form, err := c.MultipartForm()
-	if err != nil {
-		h.logger.Error("Failed to parse multipart form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid form data",
-			"code": "INVALID_FORM_DATA",
-		})
+	form, ok := h.parseUploadForm(c)
+	if !ok {
 		return
 	}
 
 	files := form.File["video"]
 	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Video file is required",
-			"code": "MISSING_VIDEO_FILE",
-		})
+		respondError(c, apperrors.ErrMissingVideo)
 		return
 	}
 
 	file := files[0]
 
 	// Comprehensive file validation
-	if err := h.validateVideoFile(file); err != nil {
+	if err := h.validateVideoFile(c, file); err != nil {
 		h.logger.Warn("File validation failed", zap.Error(err), zap.String("filename", file.Filename))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-			"code": "INVALID_VIDEO_FILE",
-		})
+		respondError(c, err)
 		return
 	}
 
 	// Read file data with error handling
-	videoData, err := h.readVideoFile(file)
+	video, err := h.openVideoFile(file)
 	if err != nil {
 		h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", file.Filename))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process video file",
-			"code": "FILE_READ_ERROR",
-		})
+		respondError(c, apperrors.ErrFileRead)
 		return
 	}
 
@@ -81,301 +94,1498 @@ func (h *VerificationHandler) VerifyVideo(c *gin.Context) {
 
 	// Sanitize and validate user ID
 	if userID != "" && !h.isValidUserID(userID) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID format",
-			"code": "INVALID_USER_ID",
-		})
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	action := c.PostForm("action")
+	if action != "" && !services.ValidAction(action) {
+		respondError(c, apperrors.ErrInvalidAction)
+		return
+	}
+
+	region := c.PostForm("region")
+	if !h.validateRegion(c, region) {
 		return
 	}
 
-	// Create verification request
 	req := &models.VerificationRequest{
-		VideoData: videoData,
-		UserID:    userID,
-		SessionID: sessionID,
+		Video:           video,
+		UserID:          userID,
+		Tenant:          middleware.TenantOf(c),
+		SessionID:       sessionID,
+		Device:          h.deviceLabel(c),
+		Action:          action,
+		Region:          region,
+		LivenessSession: c.PostForm("liveness_session"),
+		LivenessNonce:   c.PostForm("liveness_nonce"),
+		ClientIP:        c.ClientIP(),
 	}
 
-	// Process verification with timeout protection
-	resultChan := make(chan *models.VerificationResult, 1)
-	errChan := make(chan error, 1)
+	switch mode := c.DefaultPostForm("mode", c.Query("mode")); mode {
+	case "", "sync":
+		h.processVerification(c, req)
+	case "async":
+		h.enqueueVerification(c, req)
+	default:
+		respondError(c, apperrors.ErrInvalidMode)
+	}
+}
 
-	go func() {
-		result, err := h.faceService.VerifyVideo(req)
+// enqueueVerification answers 202 with the verification ID while a
+// background worker runs the pipeline. Clients poll /status/:id or wait
+// for the result webhook.
+func (h *VerificationHandler) enqueueVerification(c *gin.Context, req *models.VerificationRequest) {
+	auditUser(c, req.UserID)
+	if !h.checkLivenessSession(c, req) {
+		return
+	}
+
+	// The session stays reserved until the background job finishes
+	releaseSession, err := h.faceService.AcquireSession(req.SessionID)
+	if err != nil {
+		h.logger.Warn("Session already in use", zap.String("session_id", req.SessionID))
+		respondError(c, apperrors.ErrSessionInUse)
+		return
+	}
+
+	// The upload's temp file is removed once this handler returns, so the
+	// job runs from its own copy
+	if req.Video != nil {
+		spooled, err := services.SpoolVideo(req.Video, "")
 		if err != nil {
-			errChan <- err
+			releaseSession()
+			h.logger.Error("Failed to spool video file", zap.Error(err), zap.String("session_id", req.SessionID))
+			respondError(c, apperrors.ErrFileRead)
 			return
 		}
-		resultChan <- result
-	}()
-
-	// Wait for result with timeout
-	select {
-	case result := <-resultChan:
-		h.logger.Info("Video verification completed",
-			zap.String("verification_id", result.VerificationID),
-			zap.String("session_id", sessionID),
-			zap.Bool("verified", result.Verified),
-			zap.Float64("confidence", result.Confidence),
-			zap.Float64("liveness_score", result.LivenessScore),
-			zap.Float64("processing_time", result.ProcessingTime))
-
-		// Check for performance issues
-		if result.ProcessingTime > 3.0 {
-			h.logger.Warn("Processing time exceeded target",
-				zap.Float64("processing_time", result.ProcessingTime),
-				zap.String("verification_id", result.VerificationID))
+		req.Video = spooled
+		release := releaseSession
+		releaseSession = func() {
+			release()
+			spooled.Remove()
 		}
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"data":    result,
-		})
+	verificationID, err := h.faceService.EnqueueVerification(req, releaseSession)
+	if err != nil {
+		releaseSession()
+		if errors.Is(err, services.ErrAsyncDisabled) {
+			respondError(c, apperrors.ErrAsyncDisabled)
+			return
+		}
+		if errors.Is(err, services.ErrShuttingDown) {
+			respondError(c, apperrors.ErrShuttingDown)
+			return
+		}
+		if errors.Is(err, services.ErrLockedOut) {
+			h.lockedOut(c, err)
+			return
+		}
+		h.logger.Warn("Async verification rejected", zap.Error(err), zap.String("session_id", req.SessionID))
+		respondError(c, apperrors.ErrAsyncQueueFull)
+		return
+	}
 
-	case err := <-errChan:
-		h.logger.Error("Video verification failed",
-			zap.Error(err),
-			zap.String("session_id", sessionID))
+	h.logger.Info("Verification queued",
+		zap.String("verification_id", verificationID),
+		zap.String("session_id", req.SessionID))
+	c.Set(auditVerificationKey, verificationID)
+	c.Set(auditResultKey, models.AuditAccepted)
 
-		// Return structured error response
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Verification processing failed",
-			"code": "VERIFICATION_FAILED",
-			"details": err.Error(),
-		})
+	statusURL := "/api/v1/status/" + verificationID
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data": gin.H{
+			"verification_id": verificationID,
+			"status":          models.StatusPending,
+			"status_url":      statusURL,
+		},
+	})
+}
 
-	case <-time.After(30 * time.Second):
-		h.logger.Error("Verification timeout", zap.String("session_id", sessionID))
-		c.JSON(http.StatusRequestTimeout, gin.H{
-			"error": "Verification processing timeout",
-			"code": "VERIFICATION_TIMEOUT",
-		})
+// VerifyFrames verifies a small set of JPEG frames the client already
+// extracted on-device, skipping video decoding entirely.
+func (h *VerificationHandler) VerifyFrames(c *gin.Context) {
+	cfg := h.faceService.Config()
+	if !cfg.FrameSubmissionEnabled {
+		respondError(c, apperrors.ErrFrameSubmissionDisabled)
+		return
 	}
-}
 
-func (h *VerificationHandler) RegisterFace(c *gin.Context) {
-	// Parse multipart form with validation
-	form, err := c.MultipartForm()
-	if err != nil {
-		h.logger.Error("Failed to parse multipart form", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid form data",
-			"code": "INVALID_FORM_DATA",
-		})
+	form, ok := h.parseUploadForm(c)
+	if !ok {
 		return
 	}
 
-	files := form.File["video"]
-	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Video file is required",
-			"code": "MISSING_VIDEO_FILE",
-		})
+	files := form.File["frame"]
+	minFrames, maxFrames := submittedFrameLimits(cfg.MinSubmittedFrames, cfg.MaxSubmittedFrames)
+	if len(files) < minFrames || len(files) > maxFrames {
+		respondError(c, apperrors.ErrInvalidFrameCount.WithMessage(fmt.Sprintf("Between %d and %d frames are required", minFrames, maxFrames)))
 		return
 	}
 
+	maxFrameSize := int64(cfg.MaxFrameSize)
+	if maxFrameSize <= 0 {
+		maxFrameSize = 2 * 1024 * 1024
+	}
+
+	frameData := make([][]byte, 0, len(files))
+	for _, file := range files {
+		if file.Size > maxFrameSize {
+			respondError(c, apperrors.ErrFrameTooLarge.WithMessage(fmt.Sprintf("Frame too large. Maximum size is %d bytes", maxFrameSize)))
+			return
+		}
+
+		data, err := h.readVideoFile(file)
+		if err != nil {
+			h.logger.Error("Failed to read frame", zap.Error(err), zap.String("filename", file.Filename))
+			respondError(c, apperrors.ErrFileRead.WithMessage("Failed to process frame"))
+			return
+		}
+		if !services.IsJPEG(data) {
+			respondError(c, apperrors.ErrDecodeFailed.WithMessage("Frames must be JPEG images"))
+			return
+		}
+		frameData = append(frameData, data)
+	}
+
 	userID := c.PostForm("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "User ID is required for registration",
-			"code": "MISSING_USER_ID",
-		})
+	if userID != "" && !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
 		return
 	}
 
-	// Validate user ID format
-	if !h.isValidUserID(userID) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID format",
-			"code": "INVALID_USER_ID",
-		})
+	action := c.PostForm("action")
+	if action != "" && !services.ValidAction(action) {
+		respondError(c, apperrors.ErrInvalidAction)
 		return
 	}
 
-	file := files[0]
-
-	// Comprehensive file validation
-	if err := h.validateVideoFile(file); err != nil {
-		h.logger.Warn("File validation failed", zap.Error(err), zap.String("filename", file.Filename))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-			"code": "INVALID_VIDEO_FILE",
-		})
+	region := c.PostForm("region")
+	if !h.validateRegion(c, region) {
 		return
 	}
 
-	// Read file data with error handling
-	videoData, err := h.readVideoFile(file)
-	if err != nil {
-		h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", file.Filename))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process video file",
-			"code": "FILE_READ_ERROR",
-		})
-		return
+	sessionID := c.PostForm("session_id")
+	if sessionID == "" {
+		sessionID = uuid.New().String()
 	}
 
-	// Register face with timeout protection
-	errChan := make(chan error, 1)
+	h.processVerification(c, &models.VerificationRequest{
+		FrameData:       frameData,
+		UserID:          userID,
+		Tenant:          middleware.TenantOf(c),
+		SessionID:       sessionID,
+		Device:          h.deviceLabel(c),
+		Action:          action,
+		Region:          region,
+		LivenessSession: c.PostForm("liveness_session"),
+		LivenessNonce:   c.PostForm("liveness_nonce"),
+		ClientIP:        c.ClientIP(),
+	})
+}
 
-	go func() {
-		errChan <- h.faceService.RegisterFace(userID, videoData)
-	}()
+func submittedFrameLimits(minFrames, maxFrames int) (int, int) {
+	if minFrames <= 0 {
+		minFrames = 2
+	}
+	if maxFrames <= 0 {
+		maxFrames = 10
+	}
+	return minFrames, maxFrames
+}
 
-	// Wait for registration with timeout
-	select {
-	case err := <-errChan:
-		if err != nil {
-			h.logger.Error("Face registration failed",
-				zap.Error(err),
-				zap.String("user_id", userID),
-				zap.String("filename", file.Filename))
+type verifyReferenceRequest struct {
+	ObjectKey string `json:"object_key"`
+	// s3:// or gs:// URL of the capture, in place of object_key
+	URL string `json:"url"`
+	// Delete the object once the verification has run
+	DeleteObject bool   `json:"delete_object"`
+	UserID       string `json:"user_id"`
+	SessionID    string `json:"session_id"`
+	Device       string `json:"device"`
+	Action       string `json:"action"`
+	Region       string `json:"region"`
+	// Active liveness session answered by the capture
+	LivenessSession string `json:"liveness_session"`
+	LivenessNonce   string `json:"liveness_nonce"`
+}
 
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Face registration failed",
-				"code": "REGISTRATION_FAILED",
-				"details": err.Error(),
-			})
+// VerifyReference verifies a capture the client uploaded directly to object
+// storage, identified by its object key or s3:// or gs:// URL.
+func (h *VerificationHandler) VerifyReference(c *gin.Context) {
+	var body verifyReferenceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest)
+		return
+	}
+
+	objectKey := body.ObjectKey
+	if body.URL != "" {
+		if body.ObjectKey != "" {
+			respondError(c, apperrors.ErrInvalidRequest.WithMessage("Send either object_key or url, not both"))
+			return
+		}
+		key, err := h.faceService.ObjectKeyFromURL(body.URL)
+		if err != nil {
+			h.objectFetchFailed(c, err, body.URL)
 			return
 		}
+		objectKey = key
+	}
 
-		h.logger.Info("Face registration completed",
-			zap.String("user_id", userID),
-			zap.String("filename", file.Filename))
+	if body.UserID != "" && !h.isValidUserID(body.UserID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "Face registered successfully",
-			"user_id": userID,
-			"timestamp": time.Now().UTC(),
-		})
+	if body.Action != "" && !services.ValidAction(body.Action) {
+		respondError(c, apperrors.ErrInvalidAction)
+		return
+	}
 
-	case <-time.After(30 * time.Second):
-		h.logger.Error("Face registration timeout", zap.String("user_id", userID))
-		c.JSON(http.StatusRequestTimeout, gin.H{
-			"error": "Face registration timeout",
-			"code": "REGISTRATION_TIMEOUT",
-		})
+	if !h.validateRegion(c, body.Region) {
+		return
 	}
-}
 
-func (h *VerificationHandler) GetVerificationStatus(c *gin.Context) {
-	verificationID := c.Param("id")
-	if verificationID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Verification ID is required",
-			"code": "MISSING_VERIFICATION_ID",
-		})
+	videoData, err := h.faceService.FetchReferencedVideo(c.Request.Context(), objectKey)
+	if err != nil {
+		h.objectFetchFailed(c, err, objectKey)
 		return
 	}
 
-	// Validate verification ID format
-	if !h.isValidVerificationID(verificationID) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid verification ID format",
-			"code": "INVALID_VERIFICATION_ID",
-		})
+	if body.DeleteObject && !h.faceService.CanDeleteObjects() {
+		respondError(c, apperrors.ErrObjectDeleteUnsupported)
 		return
 	}
 
-	// For now, return a mock response
-	// In a real implementation, you'd check a database/cache
-	h.logger.Info("Verification status requested", zap.String("verification_id", verificationID))
+	sessionID := body.SessionID
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"verification_id": verificationID,
-		"status": "completed",
-		"verified": true,
-		"timestamp": time.Now().UTC(),
+	device := body.Device
+	if device == "" {
+		device = h.deviceLabel(c)
+	}
+
+	h.processVerification(c, &models.VerificationRequest{
+		VideoData:       videoData,
+		UserID:          body.UserID,
+		Tenant:          middleware.TenantOf(c),
+		SessionID:       sessionID,
+		Device:          device,
+		Action:          body.Action,
+		Region:          body.Region,
+		LivenessSession: body.LivenessSession,
+		LivenessNonce:   body.LivenessNonce,
+		ClientIP:        c.ClientIP(),
 	})
+
+	// Captures refused before they ran stay, so the client can retry
+	if body.DeleteObject && storableStatus(c.Writer.Status()) {
+		// The request context may be gone with the client
+		if err := h.faceService.DeleteReferencedObject(context.WithoutCancel(c.Request.Context()), objectKey); err != nil {
+			h.logger.Warn("Failed to delete verified object", zap.Error(err), zap.String("object_key", objectKey))
+		}
+	}
 }
 
-// Helper functions for validation
+// objectFetchFailed answers a referenced capture that could not be
+// resolved or fetched; reference is the key or URL the client sent.
+func (h *VerificationHandler) objectFetchFailed(c *gin.Context, err error, reference string) {
+	switch {
+	case errors.Is(err, services.ErrObjectStoreDisabled):
+		respondError(c, apperrors.ErrObjectStoreDisabled)
+	case errors.Is(err, services.ErrInvalidObjectKey):
+		respondError(c, apperrors.ErrInvalidObjectKey)
+	case errors.Is(err, services.ErrInvalidObjectURL):
+		respondError(c, apperrors.ErrInvalidObjectURL)
+	case errors.Is(err, services.ErrObjectKeyOutOfScope):
+		h.logger.Warn("Object reference outside allowed bucket or prefix", zap.String("object", reference))
+		respondError(c, apperrors.ErrObjectKeyForbidden)
+	case errors.Is(err, storage.ErrObjectNotFound):
+		respondError(c, apperrors.ErrObjectNotFound)
+	case errors.Is(err, storage.ErrObjectTooLarge):
+		respondError(c, apperrors.ErrInvalidVideo.WithMessage("Referenced object too large. Maximum size is 50MB"))
+	default:
+		h.logger.Error("Failed to fetch referenced object", zap.Error(err), zap.String("object", reference))
+		respondError(c, apperrors.ErrObjectFetchFailed)
+	}
+}
 
-func (h *VerificationHandler) validateVideoFile(file *multipart.FileHeader) error {
-	// Size validation
-	if file.Size > 50*1024*1024 {
-		return fmt.Errorf("video file too large. Maximum size is 50MB, got %d bytes", file.Size)
+// CreateUpload issues a pre-signed URL the client uploads a capture to,
+// so large videos bypass the API server; the capture is then verified by
+// URL or object key.
+func (h *VerificationHandler) CreateUpload(c *gin.Context) {
+	var body struct {
+		ContentType string `json:"content_type"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest)
+		return
 	}
 
-	if file.Size < 1024 {
-		return fmt.Errorf("video file too small. Minimum size is 1KB, got %d bytes", file.Size)
+	upload, err := h.faceService.IssueUpload(body.ContentType)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrObjectStoreDisabled):
+		respondError(c, apperrors.ErrObjectStoreDisabled)
+		return
+	case errors.Is(err, services.ErrUploadsUnsupported):
+		respondError(c, apperrors.ErrUploadsUnsupported)
+		return
+	case errors.Is(err, services.ErrUnsupportedUploadType):
+		respondError(c, apperrors.ErrInvalidUploadType)
+		return
+	default:
+		h.logger.Error("Failed to issue upload URL", zap.Error(err))
+		respondError(c, apperrors.ErrUploadFailed)
+		return
 	}
 
-	// Content type validation
-	contentType := file.Header.Get("Content-Type")
-	validTypes := []string{
-		"video/webm",
-		"video/mp4",
-		"video/avi",
-		"video/mov",
-		"video/quicktime",
-		"image/jpeg",  // Allow images for testing
-		"image/png",
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    upload,
+	})
+}
+
+// PrecheckLiveness is phase one of a two-phase verification. It answers with
+// the liveness decision and, for live captures, a continuation token.
+func (h *VerificationHandler) PrecheckLiveness(c *gin.Context) {
+	// The two-phase flow does not carry challenges, so it would bypass them
+	if !h.checkLivenessSession(c, &models.VerificationRequest{}) {
+		return
 	}
 
-	for _, validType := range validTypes {
-		if contentType == validType {
-			return nil
-		}
+	form, ok := h.parseUploadForm(c)
+	if !ok {
+		return
 	}
 
-	return fmt.Errorf("invalid file type: %s. Supported types: video/webm, video/mp4, video/avi, video/mov", contentType)
-}
+	files := form.File["video"]
+	if len(files) == 0 {
+		respondError(c, apperrors.ErrMissingVideo)
+		return
+	}
 
-func (h *VerificationHandler) readVideoFile(file *multipart.FileHeader) ([]byte, error) {
-	src, err := file.Open()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+	file := files[0]
+	if err := h.validateVideoFile(c, file); err != nil {
+		respondError(c, err)
+		return
 	}
-	defer src.Close()
 
-	// Read with size limit to prevent memory exhaustion
-	data := make([]byte, file.Size)
-	_, err = io.ReadFull(src, data)
+	video, err := h.openVideoFile(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file data: %w", err)
+		h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", file.Filename))
+		respondError(c, apperrors.ErrFileRead)
+		return
 	}
 
-	return data, nil
-}
-
-func (h *VerificationHandler) isValidUserID(userID string) bool {
-	// Basic validation: alphanumeric, hyphens, underscores, 1-64 chars
-	if len(userID) < 1 || len(userID) > 64 {
-		return false
+	region := c.PostForm("region")
+	if !h.validateRegion(c, region) {
+		return
 	}
 
-	for _, char := range userID {
-		if !((char >= 'a' && char <= 'z') ||
-			 (char >= 'A' && char <= 'Z') ||
-			 (char >= '0' && char <= '9') ||
-			 char == '-' || char == '_') {
-			return false
+	result, err := h.faceService.PrecheckLiveness(&models.VerificationRequest{
+		Video:     video,
+		Tenant:    middleware.TenantOf(c),
+		SessionID: c.PostForm("session_id"),
+		Device:    h.deviceLabel(c),
+		Region:    region,
+		ClientIP:  c.ClientIP(),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrPrecheckDisabled) {
+			respondError(c, apperrors.ErrPrecheckDisabled)
+			return
 		}
+		h.logger.Error("Liveness pre-check failed", zap.Error(err))
+		respondError(c, apperrors.ErrVerificationFailed)
+		return
 	}
 
-	return true
+	h.logger.Info("Liveness pre-check completed",
+		zap.String("verification_id", result.VerificationID),
+		zap.Bool("is_live", result.IsLive),
+		zap.Float64("liveness_score", result.LivenessScore),
+		zap.Float64("processing_time", result.ProcessingTime))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
 }
 
-func (h *VerificationHandler) isValidVerificationID(verificationID string) bool {
-	// Verification IDs should be prefixed with "ver_"
-	if len(verificationID) < 4 || !strings.HasPrefix(verificationID, "ver_") {
-		return false
+type continueVerificationRequest struct {
+	ContinuationToken string `json:"continuation_token"`
+	UserID            string `json:"user_id"`
+}
+
+// ContinueVerification is phase two: matching on the frames cached by the
+// pre-check, without re-uploading the capture.
+func (h *VerificationHandler) ContinueVerification(c *gin.Context) {
+	var body continueVerificationRequest
+	if err := c.ShouldBindJSON(&body); err != nil || body.ContinuationToken == "" {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("continuation_token is required"))
+		return
 	}
 
-	// Check remaining characters are valid
-	suffix := verificationID[4:]
-	if len(suffix) < 10 || len(suffix) > 30 {
-		return false
+	if body.UserID != "" && !h.isValidUserID(body.UserID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
 	}
 
-	for _, char := range suffix {
-		if !((char >= 'a' && char <= 'z') ||
-			 (char >= 'A' && char <= 'Z') ||
-			 (char >= '0' && char <= '9')) {
-			return false
+	auditUser(c, body.UserID)
+	result, err := h.faceService.ContinueVerification(body.ContinuationToken, middleware.TenantOf(c), body.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrContinuationExpired) {
+			respondError(c, apperrors.ErrContinuationExpired)
+			return
 		}
+		if errors.Is(err, services.ErrLockedOut) {
+			h.lockedOut(c, err)
+			return
+		}
+		h.logger.Error("Continued verification failed", zap.Error(err))
+		respondError(c, apperrors.ErrVerificationFailed)
+		return
 	}
 
-	return true
-}
\ No newline at end of file
+	h.logger.Info("Continued verification completed",
+		zap.String("verification_id", result.VerificationID),
+		zap.Bool("verified", result.Verified),
+		zap.Float64("confidence", result.Confidence),
+		zap.Float64("processing_time", result.ProcessingTime))
+
+	auditVerification(c, result)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.localizeResult(c, result),
+	})
+}
+
+// StartLivenessSession issues an active liveness challenge. The client
+// shows it to the subject and sends the token and nonce with the capture.
+func (h *VerificationHandler) StartLivenessSession(c *gin.Context) {
+	session, err := h.faceService.StartLivenessSession()
+	if err != nil {
+		h.logger.Error("Failed to start liveness session", zap.Error(err))
+		respondError(c, apperrors.ErrLivenessSessionFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    session,
+	})
+}
+
+// checkLivenessSession enforces LIVENESS_CHALLENGE_REQUIRED and
+// NONCE_BINDING_ENABLED, answering 400 when the capture does not name a
+// liveness session.
+func (h *VerificationHandler) checkLivenessSession(c *gin.Context, req *models.VerificationRequest) bool {
+	cfg := h.faceService.Config()
+	if req.LivenessSession != "" || !(cfg.LivenessChallengeRequired || cfg.NonceBindingEnabled) {
+		return true
+	}
+	respondError(c, apperrors.ErrLivenessSessionMissing)
+	return false
+}
+
+// processVerification runs the pipeline for a validated request and writes
+// the response, shared by all verify entry points.
+func (h *VerificationHandler) processVerification(c *gin.Context, req *models.VerificationRequest) {
+	auditUser(c, req.UserID)
+	if !h.checkLivenessSession(c, req) {
+		return
+	}
+
+	// Identical re-submissions can be answered from the result cache
+	var contentKey, etag string
+	if h.faceService.Config().ETagCachingEnabled {
+		contentKey = services.RequestContentKey(req)
+		etag = fmt.Sprintf(`"%s"`, contentKey)
+		if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+			if cached, ok := h.faceService.CachedResult(contentKey); ok {
+				h.logger.Info("Serving cached verification decision",
+					zap.String("verification_id", cached.VerificationID),
+					zap.String("session_id", req.SessionID))
+				auditVerification(c, cached)
+				c.Header("ETag", etag)
+				c.Header("X-Verification-Id", cached.VerificationID)
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	// Reserve the session so concurrent reuse of the same ID is rejected
+	releaseSession, err := h.faceService.AcquireSession(req.SessionID)
+	if err != nil {
+		h.logger.Warn("Session already in use", zap.String("session_id", req.SessionID))
+		respondError(c, apperrors.ErrSessionInUse)
+		return
+	}
+
+	// The pipeline is canceled when the client disconnects or
+	// PROCESSING_TIMEOUT passes, whichever comes first
+	ctx, cancel := context.WithTimeout(c.Request.Context(), services.ProcessingTimeout(h.faceService.Config()))
+	defer cancel()
+	resultChan := make(chan *models.VerificationResult, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer releaseSession()
+		result, _, err := h.faceService.VerifyVideoDeduplicatedContext(ctx, req)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- result
+	}()
+
+	// Wait for result with timeout
+	select {
+	case result := <-resultChan:
+		h.logger.Info("Video verification completed",
+			zap.String("verification_id", result.VerificationID),
+			zap.String("session_id", req.SessionID),
+			zap.Bool("verified", result.Verified),
+			zap.Float64("confidence", result.Confidence),
+			zap.Float64("liveness_score", result.LivenessScore),
+			zap.Float64("processing_time", result.ProcessingTime))
+
+		// Check for performance issues
+		if result.ProcessingTime > 3.0 {
+			h.logger.Warn("Processing time exceeded target",
+				zap.Float64("processing_time", result.ProcessingTime),
+				zap.String("verification_id", result.VerificationID))
+		}
+
+		auditVerification(c, result)
+		if etag != "" {
+			h.faceService.CacheResult(contentKey, result)
+			c.Header("ETag", etag)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    h.localizeResult(c, result),
+		})
+
+	case err := <-errChan:
+		if ctx.Err() != nil {
+			h.verificationAborted(c, ctx.Err(), req.SessionID)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidFrame) {
+			respondError(c, apperrors.ErrDecodeFailed)
+			return
+		}
+		if errors.Is(err, services.ErrLivenessSessionInvalid) {
+			respondError(c, apperrors.ErrLivenessSessionInvalid)
+			return
+		}
+		if errors.Is(err, services.ErrServerBusy) {
+			h.serverBusy(c)
+			return
+		}
+		if errors.Is(err, services.ErrLockedOut) {
+			h.lockedOut(c, err)
+			return
+		}
+		if errors.Is(err, services.ErrDecodeBudgetExceeded) {
+			h.logger.Warn("Capture exceeded the frame decode budget", zap.String("session_id", req.SessionID))
+			respondError(c, apperrors.ErrDecodeBudgetExceeded)
+			return
+		}
+
+		h.logger.Error("Video verification failed",
+			zap.Error(err),
+			zap.String("session_id", req.SessionID))
+
+		// Return structured error response
+		respondError(c, apperrors.ErrVerificationFailed)
+
+	case <-ctx.Done():
+		h.verificationAborted(c, ctx.Err(), req.SessionID)
+	}
+}
+
+// verificationAborted answers a verification cut short by its context: a
+// timeout is reported, while a client that went away gets no response.
+func (h *VerificationHandler) verificationAborted(c *gin.Context, err error, sessionID string) {
+	if errors.Is(err, context.Canceled) {
+		h.logger.Info("Client disconnected, verification canceled", zap.String("session_id", sessionID))
+		return
+	}
+	h.logger.Error("Verification timeout", zap.String("session_id", sessionID))
+	respondError(c, apperrors.ErrTimeout)
+}
+
+// serverBusy answers a request the admission queue turned away. Slots free
+// up as verifications finish, so clients are told to retry shortly.
+func (h *VerificationHandler) serverBusy(c *gin.Context) {
+	c.Header("Retry-After", strconv.Itoa(serverBusyRetryAfter))
+	respondError(c, apperrors.ErrServerBusy)
+}
+
+// lockedOut answers a verification by a user or client locked out after
+// repeated failures, telling it when the lockout ends.
+func (h *VerificationHandler) lockedOut(c *gin.Context, err error) {
+	var lockout *services.LockedOutError
+	if errors.As(err, &lockout) {
+		seconds := int((lockout.RetryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+	respondError(c, apperrors.ErrLocked)
+}
+
+func (h *VerificationHandler) RegisterFace(c *gin.Context) {
+	if !h.faceService.EnrollmentEnabled() {
+		respondError(c, apperrors.ErrEnrollmentDisabled)
+		return
+	}
+
+	// Parse multipart form with validation
+	form, ok := h.parseUploadForm(c)
+	if !ok {
+		return
+	}
+
+	files := form.File["video"]
+	if len(files) == 0 {
+		respondError(c, apperrors.ErrMissingVideo)
+		return
+	}
+
+	userID := c.PostForm("user_id")
+	auditUser(c, userID)
+	if userID == "" {
+		respondError(c, apperrors.ErrMissingUserID)
+		return
+	}
+
+	// Validate user ID format
+	if !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	// Cross-user duplicate check for this enrollment, never more lenient
+	// than DUPLICATE_IDENTITY_CHECK
+	opts := services.RegistrationOptions{DuplicateCheck: c.PostForm("duplicate_check")}
+	if !services.ValidDuplicateCheck(opts.DuplicateCheck) {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("duplicate_check must be off, flag or reject"))
+		return
+	}
+
+	// Several videos enroll the same face from different angles
+	if len(files) > services.MaxEnrollmentCaptures {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage(fmt.Sprintf("at most %d videos can be enrolled at once", services.MaxEnrollmentCaptures)))
+		return
+	}
+	videos := make([]models.VideoSource, 0, len(files))
+	for _, file := range files {
+		// Comprehensive file validation
+		if err := h.validateVideoFile(c, file); err != nil {
+			h.logger.Warn("File validation failed", zap.Error(err), zap.String("filename", file.Filename))
+			respondError(c, err)
+			return
+		}
+
+		// Read file data with error handling
+		video, err := h.openVideoFile(file)
+		if err != nil {
+			h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", file.Filename))
+			respondError(c, apperrors.ErrFileRead)
+			return
+		}
+		videos = append(videos, video)
+	}
+
+	// Register face, canceled on disconnect or after PROCESSING_TIMEOUT
+	ctx, cancel := context.WithTimeout(c.Request.Context(), services.ProcessingTimeout(h.faceService.Config()))
+	defer cancel()
+	type outcome struct {
+		registration *services.Registration
+		err          error
+	}
+	outcomeChan := make(chan outcome, 1)
+
+	go func() {
+		registration, err := h.faceService.RegisterFaceVideos(ctx, tenant.UserKey(middleware.TenantOf(c), userID), videos, opts)
+		outcomeChan <- outcome{registration, err}
+	}()
+
+	// Wait for registration with timeout
+	select {
+	case result := <-outcomeChan:
+		err := result.err
+		if ctx.Err() != nil {
+			h.registrationAborted(c, ctx.Err(), userID)
+			return
+		}
+		if errors.Is(err, services.ErrEnrollmentDisabled) {
+			respondError(c, apperrors.ErrEnrollmentDisabled)
+			return
+		}
+		if errors.Is(err, services.ErrDuplicateIdentity) {
+			h.logger.Warn("Enrollment rejected as a duplicate identity", zap.String("user_id", userID))
+			respondError(c, apperrors.ErrDuplicateIdentity)
+			return
+		}
+		if errors.Is(err, services.ErrCapturesMismatch) {
+			respondError(c, apperrors.ErrCapturesMismatch)
+			return
+		}
+		if errors.Is(err, services.ErrServerBusy) {
+			h.serverBusy(c)
+			return
+		}
+		var qualityErr *services.QualityError
+		if errors.As(err, &qualityErr) {
+			h.logger.Info("Enrollment rejected by the quality gate",
+				zap.String("user_id", userID),
+				zap.String("code", qualityErr.Code))
+			locale := i18n.ResolveLocale(c.GetHeader("Accept-Language"), h.faceService.Config().DefaultLocale)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   qualityErr.Error(),
+				"code":    qualityErr.Code,
+				"message": i18n.Message(locale, qualityErr.Code),
+				"quality": qualityErr.Quality,
+			})
+			return
+		}
+		if err != nil {
+			h.logger.Error("Face registration failed",
+				zap.Error(err),
+				zap.String("user_id", userID),
+				zap.Int("videos", len(videos)))
+
+			respondError(c, apperrors.ErrRegistrationFailed)
+			return
+		}
+
+		h.logger.Info("Face registration completed",
+			zap.String("user_id", userID),
+			zap.Int("videos", len(videos)),
+			zap.Int("templates", result.registration.Templates))
+
+		response := gin.H{
+			"success":   true,
+			"message":   "Face registered successfully",
+			"user_id":   userID,
+			"templates": result.registration.Templates,
+			"timestamp": time.Now().UTC(),
+		}
+		if result.registration.DuplicateIdentity {
+			response["duplicate_identity"] = true
+		}
+		c.JSON(http.StatusOK, response)
+
+	case <-ctx.Done():
+		h.registrationAborted(c, ctx.Err(), userID)
+	}
+}
+
+func (h *VerificationHandler) registrationAborted(c *gin.Context, err error, userID string) {
+	if errors.Is(err, context.Canceled) {
+		h.logger.Info("Client disconnected, registration canceled", zap.String("user_id", userID))
+		return
+	}
+	h.logger.Error("Face registration timeout", zap.String("user_id", userID))
+	respondError(c, apperrors.ErrTimeout)
+}
+
+func (h *VerificationHandler) GetVerificationStatus(c *gin.Context) {
+	verificationID := c.Param("id")
+	if verificationID == "" {
+		respondError(c, apperrors.ErrMissingVerificationID)
+		return
+	}
+
+	// Validate verification ID format
+	if !h.isValidVerificationID(verificationID) {
+		respondError(c, apperrors.ErrInvalidVerificationID)
+		return
+	}
+
+	h.logger.Info("Verification status requested", zap.String("verification_id", verificationID))
+
+	// Another tenant's verifications are reported as unknown
+	record, found := h.faceService.GetVerificationRecord(verificationID)
+	if !found || record.Tenant != middleware.TenantOf(c) {
+		respondError(c, apperrors.ErrVerificationNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusBody(c, record))
+}
+
+// statusBody is the view of a verification record given by the status
+// endpoint and its event stream.
+func statusBody(c *gin.Context, record models.VerificationRecord) gin.H {
+	response := gin.H{
+		"verification_id": record.ID,
+		"status":          record.Status,
+		"timestamp":       record.CreatedAt,
+		"updated_at":      record.UpdatedAt,
+	}
+	if record.Stage != "" {
+		response["stage"] = record.Stage
+	}
+	if record.Result != nil {
+		response["verified"] = record.Result.Verified
+		response["timestamp"] = record.Result.Timestamp
+	}
+	if record.Status == models.StatusFailed && record.ErrorMessage != "" {
+		response["error_message"] = record.ErrorMessage
+	}
+
+	// Scores are only disclosed to admin-authenticated callers
+	if c.GetBool(middleware.AdminContextKey) && record.Result != nil {
+		response["result"] = record.Result
+	}
+	return response
+}
+
+// ExtractTemplate returns the compact binary face template of a live capture,
+// base64-encoded in JSON or raw with ?format=binary.
+func (h *VerificationHandler) ExtractTemplate(c *gin.Context) {
+	form, ok := h.parseUploadForm(c)
+	if !ok {
+		return
+	}
+
+	files := form.File["video"]
+	if len(files) == 0 {
+		respondError(c, apperrors.ErrMissingVideo)
+		return
+	}
+
+	file := files[0]
+	if err := h.validateVideoFile(c, file); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	video, err := h.openVideoFile(file)
+	if err != nil {
+		h.logger.Error("Failed to read video file", zap.Error(err), zap.String("filename", file.Filename))
+		respondError(c, apperrors.ErrFileRead)
+		return
+	}
+
+	vector, err := h.faceService.ExtractTemplateVideo(video)
+	if err != nil {
+		if errors.Is(err, services.ErrNotLive) {
+			respondError(c, apperrors.ErrLivenessFailed)
+			return
+		}
+		h.logger.Error("Template extraction failed", zap.Error(err))
+		respondError(c, apperrors.ErrTemplateExtraction)
+		return
+	}
+
+	template := services.EncodeTemplate(vector, h.faceService.Config().TemplateQuantization)
+
+	if c.Query("format") == "binary" {
+		c.Data(http.StatusOK, "application/octet-stream", template)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"template": base64.StdEncoding.EncodeToString(template),
+		"version":  services.TemplateVersion,
+		"size":     len(template),
+	})
+}
+
+type matchTemplateRequest struct {
+	UserID   string `json:"user_id"`
+	Template string `json:"template"`
+}
+
+// MatchTemplate matches a compact template against a user's enrollment.
+func (h *VerificationHandler) MatchTemplate(c *gin.Context) {
+	var body matchTemplateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest)
+		return
+	}
+
+	auditUser(c, body.UserID)
+	if !h.isValidUserID(body.UserID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(body.Template)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTemplate.WithMessage("Template must be base64 encoded"))
+		return
+	}
+
+	vector, err := services.DecodeTemplate(raw)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTemplate)
+		return
+	}
+
+	confidence, matched, err := h.faceService.MatchTemplateFrom(tenant.UserKey(middleware.TenantOf(c), body.UserID), vector, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrLockedOut) {
+			h.lockedOut(c, err)
+			return
+		}
+		if errors.Is(err, services.ErrNotEnrolled) {
+			respondError(c, apperrors.ErrUserNotEnrolled)
+			return
+		}
+		if errors.Is(err, services.ErrEnrollmentNotYetActive) {
+			respondError(c, apperrors.ErrEnrollmentNotYetActive)
+			return
+		}
+		if errors.Is(err, services.ErrReenrollmentRequired) {
+			respondError(c, apperrors.ErrReenrollmentRequired)
+			return
+		}
+		h.logger.Error("Template match failed", zap.Error(err), zap.String("user_id", body.UserID))
+		respondError(c, apperrors.ErrMatchFailed)
+		return
+	}
+
+	if matched {
+		c.Set(auditResultKey, models.AuditVerified)
+	} else {
+		c.Set(auditResultKey, models.AuditNotVerified)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"user_id":    body.UserID,
+		"matched":    matched,
+		"confidence": confidence,
+	})
+}
+
+func (h *VerificationHandler) GetUserHistory(c *gin.Context) {
+	userID := c.Param("id")
+	if !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	// Users may only read their own history
+	if c.GetString(middleware.SubjectContextKey) != userID {
+		h.logger.Warn("History requested for another user", zap.String("user_id", userID))
+		respondError(c, apperrors.ErrForbidden)
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		respondError(c, apperrors.ErrInvalidPagination)
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		respondError(c, apperrors.ErrInvalidPagination.WithMessage("page_size must be between 1 and 100"))
+		return
+	}
+
+	entries, total := h.faceService.GetUserHistory(tenant.UserKey(middleware.TenantOf(c), userID), page, pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":   userID,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"items":     entries,
+	})
+}
+
+// Helper functions for validation
+
+// deviceLabel describes the capture device from the optional "device" form
+// field, falling back to the User-Agent.
+func (h *VerificationHandler) deviceLabel(c *gin.Context) string {
+	device := c.PostForm("device")
+	if device == "" {
+		device = c.GetHeader("User-Agent")
+	}
+	if len(device) > 128 {
+		device = device[:128]
+	}
+	return device
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateVideoFile returns an INVALID_VIDEO_FILE error saying what is
+// wrong with the upload.
+func (h *VerificationHandler) validateVideoFile(c *gin.Context, file *multipart.FileHeader) error {
+	// Size validation
+	if limit := h.maxUploadSize(c); file.Size > limit {
+		return apperrors.ErrInvalidVideo.WithMessage(fmt.Sprintf("video file too large. Maximum size is %d bytes, got %d bytes", limit, file.Size))
+	}
+
+	if file.Size < 1024 {
+		return apperrors.ErrInvalidVideo.WithMessage(fmt.Sprintf("video file too small. Minimum size is 1KB, got %d bytes", file.Size))
+	}
+
+	// Content type validation
+	contentType := file.Header.Get("Content-Type")
+	validTypes := []string{
+		"video/webm",
+		"video/mp4",
+		"video/avi",
+		"video/mov",
+		"video/quicktime",
+		"image/jpeg", // Allow images for testing
+		"image/png",
+	}
+
+	for _, validType := range validTypes {
+		if contentType == validType {
+			return h.checkSniffedType(file, contentType)
+		}
+	}
+
+	return apperrors.ErrInvalidVideo.WithMessage(fmt.Sprintf("invalid file type: %s. Supported types: video/webm, video/mp4, video/avi, video/mov", contentType))
+}
+
+// checkSniffedType rejects uploads whose leading bytes are not of the
+// declared type; the Content-Type header alone is the client's word.
+func (h *VerificationHandler) checkSniffedType(file *multipart.FileHeader, contentType string) error {
+	src, err := file.Open()
+	if err != nil {
+		return apperrors.ErrFileRead
+	}
+	defer src.Close()
+
+	header := make([]byte, services.SniffLength)
+	n, err := io.ReadFull(src, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return apperrors.ErrFileRead
+	}
+
+	sniffed := services.SniffContentType(header[:n])
+	if sniffed == "" {
+		return apperrors.ErrInvalidVideo.WithMessage("unrecognized file format: content is not WebM, MP4, QuickTime, AVI, JPEG or PNG")
+	}
+	if !services.ContentTypeMatches(contentType, sniffed) {
+		return apperrors.ErrInvalidVideo.WithMessage(fmt.Sprintf("file content is %s but was declared as %s", sniffed, contentType))
+	}
+	return nil
+}
+
+func (h *VerificationHandler) readVideoFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	// Read with size limit to prevent memory exhaustion
+	data := make([]byte, file.Size)
+	_, err = io.ReadFull(src, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file data: %w", err)
+	}
+
+	return data, nil
+}
+
+// openVideoFile wraps an uploaded capture so the pipeline streams it from
+// the multipart temp file instead of reading it into memory.
+func (h *VerificationHandler) openVideoFile(file *multipart.FileHeader) (*services.StreamedVideo, error) {
+	return services.NewStreamedVideo(func() (io.ReadCloser, error) {
+		src, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		return src, nil
+	})
+}
+
+// parseUploadForm parses the multipart body behind a MaxBytesReader, so
+// oversized uploads are cut off while streaming rather than after. Parts
+// past uploadMemory are spilled to temp files by the multipart parser.
+func (h *VerificationHandler) parseUploadForm(c *gin.Context) (*multipart.Form, bool) {
+	limit := h.maxUploadSize(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+uploadFormOverhead)
+	if err := c.Request.ParseMultipartForm(uploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, apperrors.ErrUploadTooLarge.WithMessage(fmt.Sprintf("Upload too large. Maximum size is %d bytes", limit)))
+			return nil, false
+		}
+		h.logger.Error("Failed to parse multipart form", zap.Error(err))
+		respondError(c, apperrors.ErrInvalidFormData)
+		return nil, false
+	}
+	return c.Request.MultipartForm, true
+}
+
+// maxUploadSize is the upload limit of the caller's tenant.
+func (h *VerificationHandler) maxUploadSize(c *gin.Context) int64 {
+	if size := h.faceService.MaxUploadSize(middleware.TenantOf(c)); size > 0 {
+		return size
+	}
+	return 50 * 1024 * 1024
+}
+
+func (h *VerificationHandler) isValidUserID(userID string) bool {
+	// Basic validation: alphanumeric, hyphens, underscores, 1-64 chars
+	if len(userID) < 1 || len(userID) > 64 {
+		return false
+	}
+
+	for _, char := range userID {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *VerificationHandler) isValidVerificationID(verificationID string) bool {
+	// Verification IDs should be prefixed with "ver_"
+	if len(verificationID) < 4 || !strings.HasPrefix(verificationID, "ver_") {
+		return false
+	}
+
+	// Check remaining characters are valid
+	suffix := verificationID[4:]
+	if len(suffix) < 10 || len(suffix) > 30 {
+		return false
+	}
+
+	for _, char := range suffix {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateRegion checks an optional client-declared region and writes the
+// error response when it is rejected.
+func (h *VerificationHandler) validateRegion(c *gin.Context, region string) bool {
+	if region == "" {
+		return true
+	}
+
+	switch err := h.faceService.ValidateClientRegion(region); {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrRegionNotAllowed):
+		respondError(c, apperrors.ErrRegionNotAllowed)
+	default:
+		respondError(c, apperrors.ErrInvalidRegion)
+	}
+	return false
+}
+
+// localizeResult attaches guidance for the result's reason in the caller's
+// language. It returns a copy so stored and cached results stay neutral.
+func (h *VerificationHandler) localizeResult(c *gin.Context, result *models.VerificationResult) *models.VerificationResult {
+	if result.Reason == "" {
+		return result
+	}
+
+	locale := i18n.ResolveLocale(c.GetHeader("Accept-Language"), h.faceService.Config().DefaultLocale)
+	localized := *result
+	localized.ReasonMessage = i18n.Message(locale, result.Reason)
+	c.Header("Content-Language", locale)
+	return &localized
+}
+
+// ListVerifications is the admin residency audit query over recent records,
+// filtered by processing and/or client region.
+func (h *VerificationHandler) ListVerifications(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		respondError(c, apperrors.ErrInvalidLimit)
+		return
+	}
+
+	filter := services.RegionFilter{
+		ProcessingRegion: c.Query("processing_region"),
+		ClientRegion:     c.Query("client_region"),
+	}
+	records := h.faceService.FindVerifications(filter, limit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": records,
+		"count": len(records),
+	})
+}
+
+// ExportAudit returns verification records in a compliance format over an
+// optional RFC 3339 from/to range, with an integrity manifest.
+func (h *VerificationHandler) ExportAudit(c *gin.Context) {
+	opts := services.AuditExportOptions{Format: c.Query("format")}
+	if fields := c.Query("fields"); fields != "" {
+		opts.Fields = strings.Split(fields, ",")
+	}
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &opts.From}, {"to", &opts.To}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, apperrors.ErrInvalidDateRange.WithMessage(bound.name+" must be an RFC 3339 timestamp"))
+			return
+		}
+		*bound.target = parsed
+	}
+
+	export, err := h.faceService.ExportAudit(opts)
+	var fieldErr *services.InvalidAuditFieldError
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidAuditFormat):
+		respondError(c, apperrors.ErrInvalidFormat)
+		return
+	case errors.As(err, &fieldErr):
+		respondError(c, apperrors.ErrInvalidFields.WithMessage("Field is not exportable: "+fieldErr.Field))
+		return
+	case errors.Is(err, services.ErrInvalidAuditRange):
+		respondError(c, apperrors.ErrInvalidDateRange)
+		return
+	default:
+		h.logger.Error("Audit export failed", zap.Error(err))
+		respondError(c, apperrors.ErrAuditExportFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// ListWebhookDeliveries reports tracked result callbacks, optionally filtered
+// by status (pending, delivered, failed) and verification ID.
+func (h *VerificationHandler) ListWebhookDeliveries(c *gin.Context) {
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", models.WebhookPending, models.WebhookDelivered, models.WebhookFailed:
+	default:
+		respondError(c, apperrors.ErrInvalidStatus)
+		return
+	}
+
+	deliveries, err := h.faceService.WebhookDeliveries(status, c.Query("verification_id"))
+	if err != nil {
+		respondError(c, apperrors.ErrWebhooksDisabled)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": deliveries,
+		"count": len(deliveries),
+	})
+}
+
+// RedeliverWebhook re-sends a delivered or failed callback in the background.
+func (h *VerificationHandler) RedeliverWebhook(c *gin.Context) {
+	delivery, err := h.faceService.RedeliverWebhook(c.Param("id"))
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrWebhooksDisabled):
+		respondError(c, apperrors.ErrWebhooksDisabled)
+		return
+	case errors.Is(err, services.ErrDeliveryNotFound):
+		respondError(c, apperrors.ErrWebhookDeliveryNotFound)
+		return
+	case errors.Is(err, services.ErrDeliveryInProgress):
+		respondError(c, apperrors.ErrWebhookDeliveryInFlight)
+		return
+	default:
+		h.logger.Error("Webhook redelivery failed", zap.Error(err))
+		respondError(c, apperrors.ErrWebhookRedeliveryFailed)
+		return
+	}
+
+	h.logger.Info("Webhook redelivery requested", zap.String("delivery_id", delivery.ID))
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+// SelfBench runs a synthetic load test through the pipeline and reports
+// throughput and latency percentiles.
+func (h *VerificationHandler) SelfBench(c *gin.Context) {
+	opts := services.SelfBenchOptions{Requests: 50, Concurrency: 4}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			respondError(c, apperrors.ErrInvalidRequest)
+			return
+		}
+	}
+
+	report, err := h.faceService.RunSelfBench(c.Request.Context(), opts)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrSelfBenchForbidden):
+		respondError(c, apperrors.ErrSelfBenchForbidden)
+		return
+	case errors.Is(err, services.ErrSelfBenchRateLimited):
+		respondError(c, apperrors.ErrSelfBenchRateLimited)
+		return
+	case errors.Is(err, services.ErrSelfBenchInvalid):
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("requests or concurrency out of range"))
+		return
+	default:
+		h.logger.Error("Self-benchmark failed", zap.Error(err))
+		respondError(c, apperrors.ErrSelfBenchFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// EraseUser handles right-to-erasure requests, removing every enrollment,
+// verification record and audit trace of a user. Erasing a user with no
+// data succeeds with an empty receipt.
+func (h *VerificationHandler) EraseUser(c *gin.Context) {
+	userID := c.Param("user_id")
+	if !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	receipt, err := h.faceService.EraseUser(tenant.UserKey(middleware.TenantOf(c), userID))
+	if err != nil {
+		h.logger.Error("User erasure failed", zap.Error(err), zap.String("user_id", userID))
+		respondError(c, apperrors.ErrErasureFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    receipt,
+	})
+}
+
+// GetFaceTemplates reports how many templates a user of the caller's tenant
+// has enrolled, and whether they must enroll again because all of them were
+// made by other recognizer models.
+func (h *VerificationHandler) GetFaceTemplates(c *gin.Context) {
+	userID := c.Param("user_id")
+	if !h.isValidUserID(userID) {
+		respondError(c, apperrors.ErrInvalidUserID)
+		return
+	}
+
+	userKey := tenant.UserKey(middleware.TenantOf(c), userID)
+	templates := h.faceService.TemplateCount(userKey)
+	stale := h.faceService.StaleTemplateCount(userKey)
+	if templates == 0 && stale == 0 {
+		respondError(c, apperrors.ErrUserNotEnrolled)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"user_id":               userID,
+			"templates":             templates,
+			"reenrollment_required": templates == 0,
+		},
+	})
+}
+
+// RotateEncryptionKey re-encrypts stored enrollments under the current
+// encryption key so retired keys can be dropped from the configuration.
+func (h *VerificationHandler) RotateEncryptionKey(c *gin.Context) {
+	rotation, err := h.faceService.RotateEncryptionKey()
+	if err != nil {
+		if errors.Is(err, services.ErrKeyRotationUnsupported) {
+			respondError(c, apperrors.ErrKeyRotationUnsupported)
+			return
+		}
+		h.logger.Error("Encryption key rotation failed", zap.Error(err))
+		respondError(c, apperrors.ErrKeyRotationFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rotation,
+	})
+}
+
+// MigrateDescriptors stamps legacy templates with the recognizer models in
+// use and flags users whose templates were all made by other models for
+// re-enrollment.
+func (h *VerificationHandler) MigrateDescriptors(c *gin.Context) {
+	migration, err := h.faceService.MigrateDescriptors()
+	if err != nil {
+		h.logger.Error("Descriptor migration failed", zap.Error(err))
+		respondError(c, apperrors.ErrMigrationFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    migration,
+	})
+}
+
+type enrollmentRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetEnrollment reports whether enrollments are currently accepted.
+func (h *VerificationHandler) GetEnrollment(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": h.faceService.EnrollmentEnabled(),
+	})
+}
+
+// SetEnrollment opens or closes the supervised enrollment window.
+func (h *VerificationHandler) SetEnrollment(c *gin.Context) {
+	var req enrollmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("enabled is required"))
+		return
+	}
+
+	h.faceService.SetEnrollmentEnabled(*req.Enabled)
+	h.logger.Info("Enrollment toggled", zap.Bool("enabled", *req.Enabled))
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": h.faceService.EnrollmentEnabled(),
+	})
+}
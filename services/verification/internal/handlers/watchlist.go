@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/services"
+)
+
+type addWatchlistRequest struct {
+	// Exactly one of a compact template (as returned by /template) and an
+	// enrolled user
+	Template string `json:"template"`
+	UserID   string `json:"user_id"`
+	Label    string `json:"label"`
+	Reason   string `json:"reason"`
+}
+
+// AddWatchlistEntry puts a face on the caller's tenant's watchlist.
+func (h *VerificationHandler) AddWatchlistEntry(c *gin.Context) {
+	var body addWatchlistRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, apperrors.ErrInvalidRequest)
+		return
+	}
+	if body.Label == "" || len(body.Label) > 128 {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("label is required and at most 128 characters"))
+		return
+	}
+	if (body.Template == "") == (body.UserID == "") {
+		respondError(c, apperrors.ErrInvalidRequest.WithMessage("Exactly one of template and user_id is required"))
+		return
+	}
+
+	req := services.WatchlistRequest{Label: body.Label, Reason: body.Reason}
+	if body.UserID != "" {
+		if !h.isValidUserID(body.UserID) {
+			respondError(c, apperrors.ErrInvalidUserID)
+			return
+		}
+		req.UserID = body.UserID
+	} else {
+		raw, err := base64.StdEncoding.DecodeString(body.Template)
+		if err != nil {
+			respondError(c, apperrors.ErrInvalidTemplate.WithMessage("Template must be base64 encoded"))
+			return
+		}
+		if req.Vector, err = services.DecodeTemplate(raw); err != nil {
+			respondError(c, apperrors.ErrInvalidTemplate)
+			return
+		}
+	}
+
+	entry, err := h.faceService.AddToWatchlist(middleware.TenantOf(c), req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotEnrolled) {
+			respondError(c, apperrors.ErrUserNotEnrolled)
+			return
+		}
+		h.logger.Error("Failed to save watchlist", zap.Error(err))
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// ListWatchlist returns the caller's tenant's watchlist, oldest entry
+// first, without the faces themselves.
+func (h *VerificationHandler) ListWatchlist(c *gin.Context) {
+	entries := h.faceService.Watchlist(middleware.TenantOf(c))
+	c.JSON(http.StatusOK, gin.H{
+		"items": entries,
+		"count": len(entries),
+	})
+}
+
+// DeleteWatchlistEntry takes an entry off the caller's tenant's watchlist.
+func (h *VerificationHandler) DeleteWatchlistEntry(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.faceService.RemoveFromWatchlist(middleware.TenantOf(c), id); err != nil {
+		if errors.Is(err, services.ErrWatchlistEntryNotFound) {
+			respondError(c, apperrors.ErrWatchlistEntryNotFound)
+			return
+		}
+		h.logger.Error("Failed to save watchlist", zap.Error(err))
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "deleted": true})
+}
@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"strings"
+)
+
+const DefaultLocale = "en"
+
+// catalog maps locale -> stable code -> user-facing guidance. Codes never
+// change between locales; only the guidance text is translated.
+var catalog = map[string]map[string]string{
+	"en": {
+		"CAMERA_BLOCKED":            "Your camera seems to be covered or not sending a picture. Uncover it, improve the lighting and try again.",
+		"LIVENESS_FAILED":           "We couldn't confirm a live person. Face the camera in good light, move naturally and try again.",
+		"LOW_SIMILARITY":            "Your face didn't match the registered profile. Remove glasses or hats, face the camera directly and try again.",
+		"ACTION_MISMATCH":           "We couldn't see the movement we asked for. Follow the on-screen instruction while recording and try again.",
+		"ENROLLMENT_NOT_YET_ACTIVE": "Your registration is still being activated. Please try again later.",
+		"CHALLENGE_FAILED":          "We couldn't see the gesture we asked for. Start a new check, follow the on-screen instruction and try again.",
+		"NEEDS_REVIEW":              "Your check needs a closer look by our team. We will let you know the outcome shortly.",
+		"REVIEW_REJECTED":           "Our team reviewed your check and couldn't confirm your identity. Please try again in good light.",
+		"RISK_DENIED":               "We couldn't complete your check. Please contact support if you need help.",
+		"INJECTION_SUSPECTED":       "We couldn't verify that the video comes from your device's camera. Turn off any virtual camera or screen sharing app and try again.",
+		"NONCE_NOT_BOUND":           "We couldn't see the screen colors light up your face. Turn up the screen brightness, stay close to it and start a new check.",
+		"FACE_TOO_SMALL":            "Your face is too far from the camera. Move closer so it fills more of the frame and try again.",
+		"IMAGE_TOO_DARK":            "The picture is too dark. Move to a brighter place or face a light source and try again.",
+		"IMAGE_TOO_BRIGHT":          "The picture is too bright. Move away from direct light or a bright window and try again.",
+		"IMAGE_TOO_BLURRY":          "The picture is blurry. Hold the camera steady, wipe the lens and try again.",
+		"FACE_NOT_FRONTAL":          "Your head is turned or tilted. Look straight at the camera and try again.",
+	},
+	"es": {
+		"CAMERA_BLOCKED":            "Parece que tu cámara está tapada o no envía imagen. Destápala, mejora la iluminación e inténtalo de nuevo.",
+		"LIVENESS_FAILED":           "No pudimos confirmar que eres una persona real. Mira a la cámara con buena luz, muévete con naturalidad e inténtalo de nuevo.",
+		"LOW_SIMILARITY":            "Tu rostro no coincide con el perfil registrado. Quítate gafas o gorros, mira directamente a la cámara e inténtalo de nuevo.",
+		"ACTION_MISMATCH":           "No vimos el movimiento que te pedimos. Sigue la instrucción en pantalla mientras grabas e inténtalo de nuevo.",
+		"ENROLLMENT_NOT_YET_ACTIVE": "Tu registro todavía se está activando. Inténtalo de nuevo más tarde.",
+		"CHALLENGE_FAILED":          "No vimos el gesto que te pedimos. Inicia una nueva comprobación, sigue la instrucción en pantalla e inténtalo de nuevo.",
+		"NEEDS_REVIEW":              "Nuestro equipo tiene que revisar tu comprobación. Te comunicaremos el resultado en breve.",
+		"REVIEW_REJECTED":           "Nuestro equipo revisó tu comprobación y no pudo confirmar tu identidad. Inténtalo de nuevo con buena luz.",
+		"RISK_DENIED":               "No pudimos completar tu comprobación. Contacta con soporte si necesitas ayuda.",
+		"INJECTION_SUSPECTED":       "No pudimos comprobar que el vídeo viene de la cámara de tu dispositivo. Desactiva cualquier cámara virtual o aplicación para compartir pantalla e inténtalo de nuevo.",
+		"NONCE_NOT_BOUND":           "No vimos los colores de la pantalla iluminar tu rostro. Sube el brillo de la pantalla, acércate a ella e inicia una nueva comprobación.",
+		"FACE_TOO_SMALL":            "Tu rostro está demasiado lejos de la cámara. Acércate para que ocupe más del encuadre e inténtalo de nuevo.",
+		"IMAGE_TOO_DARK":            "La imagen está demasiado oscura. Busca un lugar más iluminado o mira hacia una fuente de luz e inténtalo de nuevo.",
+		"IMAGE_TOO_BRIGHT":          "La imagen tiene demasiada luz. Aléjate de la luz directa o de una ventana e inténtalo de nuevo.",
+		"IMAGE_TOO_BLURRY":          "La imagen está borrosa. Sujeta la cámara con firmeza, limpia la lente e inténtalo de nuevo.",
+		"FACE_NOT_FRONTAL":          "Tienes la cabeza girada o inclinada. Mira directamente a la cámara e inténtalo de nuevo.",
+	},
+	"pt": {
+		"CAMERA_BLOCKED":            "A sua câmera parece estar tapada ou sem imagem. Destape-a, melhore a iluminação e tente novamente.",
+		"LIVENESS_FAILED":           "Não foi possível confirmar uma pessoa real. Olhe para a câmera com boa luz, mova-se naturalmente e tente novamente.",
+		"LOW_SIMILARITY":            "O seu rosto não corresponde ao perfil registado. Retire óculos ou chapéus, olhe diretamente para a câmera e tente novamente.",
+		"ACTION_MISMATCH":           "Não vimos o movimento pedido. Siga a instrução no ecrã enquanto grava e tente novamente.",
+		"ENROLLMENT_NOT_YET_ACTIVE": "O seu registo ainda está a ser ativado. Tente novamente mais tarde.",
+		"CHALLENGE_FAILED":          "Não vimos o gesto pedido. Inicie uma nova verificação, siga a instrução no ecrã e tente novamente.",
+		"NEEDS_REVIEW":              "A sua verificação precisa de ser analisada pela nossa equipa. Informaremos o resultado em breve.",
+		"REVIEW_REJECTED":           "A nossa equipa analisou a sua verificação e não conseguiu confirmar a sua identidade. Tente novamente com boa luz.",
+		"RISK_DENIED":               "Não foi possível concluir a sua verificação. Contacte o suporte se precisar de ajuda.",
+		"INJECTION_SUSPECTED":       "Não foi possível confirmar que o vídeo vem da câmera do seu dispositivo. Desative qualquer câmera virtual ou aplicação de partilha de ecrã e tente novamente.",
+		"NONCE_NOT_BOUND":           "Não vimos as cores do ecrã iluminar o seu rosto. Aumente o brilho do ecrã, aproxime-se dele e inicie uma nova verificação.",
+		"FACE_TOO_SMALL":            "O seu rosto está demasiado longe da câmera. Aproxime-se para que ocupe mais do enquadramento e tente novamente.",
+		"IMAGE_TOO_DARK":            "A imagem está demasiado escura. Procure um local mais iluminado ou vire-se para uma fonte de luz e tente novamente.",
+		"IMAGE_TOO_BRIGHT":          "A imagem tem luz a mais. Afaste-se da luz direta ou de uma janela e tente novamente.",
+		"IMAGE_TOO_BLURRY":          "A imagem está desfocada. Segure a câmera com firmeza, limpe a lente e tente novamente.",
+		"FACE_NOT_FRONTAL":          "A sua cabeça está virada ou inclinada. Olhe diretamente para a câmera e tente novamente.",
+	},
+}
+
+// Message returns the guidance for code in locale, falling back to English.
+// Unknown codes yield an empty string.
+func Message(locale, code string) string {
+	if messages, ok := catalog[locale]; ok {
+		if message, ok := messages[code]; ok {
+			return message
+		}
+	}
+	return catalog[DefaultLocale][code]
+}
+
+// Supported reports whether the catalog has translations for locale.
+func Supported(locale string) bool {
+	_, ok := catalog[locale]
+	return ok
+}
+
+// ResolveLocale picks the first supported language from an Accept-Language
+// style list (e.g. "pt-BR,pt;q=0.9,en;q=0.8"), or fallback if none match.
+func ResolveLocale(acceptLanguage, fallback string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" {
+			continue
+		}
+		language := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if Supported(language) {
+			return language
+		}
+	}
+
+	if Supported(fallback) {
+		return fallback
+	}
+	return DefaultLocale
+}
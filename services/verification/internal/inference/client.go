@@ -0,0 +1,96 @@
+// Package inference is a minimal client for the KServe v2 gRPC inference
+// protocol served by Triton and compatible model servers. It covers what the
+// remote embedder needs and nothing more: one input, one output and raw
+// tensor contents. The messages are encoded with protowire rather than
+// generated bindings, so no copy of grpc_service.proto is vendored.
+package inference
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const modelInferMethod = "/inference.GRPCInferenceService/ModelInfer"
+
+// Tensor is a named input or output tensor. Data holds its raw contents,
+// row-major and little-endian, as FP32 produces and Float32s reads.
+type Tensor struct {
+	Name     string
+	Datatype string
+	Shape    []int64
+	Data     []byte
+}
+
+// Client calls ModelInfer on one inference server. It is safe for
+// concurrent use.
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// Dial connects to the inference server at addr, over TLS with the system
+// roots when useTLS is set. The connection is established lazily.
+func Dial(addr string, useTLS bool) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("inference: failed to dial %s: %w", addr, err)
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Infer runs version of model, or the server's choice of version when
+// empty, on input and returns the output tensor named output.
+func (c *Client) Infer(ctx context.Context, model, version string, input Tensor, output string) (Tensor, error) {
+	req := encodeInferRequest(model, version, input, output)
+	var resp []byte
+	if err := c.conn.Invoke(ctx, modelInferMethod, &req, &resp, grpc.ForceCodec(rawCodec{})); err != nil {
+		return Tensor{}, err
+	}
+	outputs, err := decodeInferResponse(resp)
+	if err != nil {
+		return Tensor{}, err
+	}
+	for _, tensor := range outputs {
+		if tensor.Name == output {
+			return tensor, nil
+		}
+	}
+	return Tensor{}, fmt.Errorf("inference: model %s returned no output %q", model, output)
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// rawCodec passes already encoded messages through as the "proto" codec.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("inference: cannot marshal %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("inference: cannot unmarshal into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
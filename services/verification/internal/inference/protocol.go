@@ -0,0 +1,223 @@
+package inference
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the KServe v2 messages, from grpc_service.proto
+const (
+	// ModelInferRequest
+	requestModelName    = 1
+	requestModelVersion = 2
+	requestInputs       = 5
+	requestOutputs      = 6
+	requestRawInputs    = 7
+
+	// ModelInferResponse
+	responseOutputs    = 5
+	responseRawOutputs = 6
+
+	// InferInputTensor and InferOutputTensor
+	tensorName     = 1
+	tensorDatatype = 2
+	tensorShape    = 3
+	tensorContents = 5
+
+	// InferRequestedOutputTensor
+	requestedOutputName = 1
+
+	// InferTensorContents
+	contentsFP32 = 6
+)
+
+const fp32Size = 4
+
+var errMalformed = errors.New("inference: malformed response")
+
+// encodeInferRequest encodes a ModelInferRequest carrying input as raw
+// contents and asking for the output named output.
+func encodeInferRequest(model, version string, input Tensor, output string) []byte {
+	var tensor []byte
+	tensor = protowire.AppendTag(tensor, tensorName, protowire.BytesType)
+	tensor = protowire.AppendString(tensor, input.Name)
+	tensor = protowire.AppendTag(tensor, tensorDatatype, protowire.BytesType)
+	tensor = protowire.AppendString(tensor, input.Datatype)
+	var shape []byte
+	for _, dim := range input.Shape {
+		shape = protowire.AppendVarint(shape, uint64(dim))
+	}
+	tensor = protowire.AppendTag(tensor, tensorShape, protowire.BytesType)
+	tensor = protowire.AppendBytes(tensor, shape)
+
+	var requested []byte
+	requested = protowire.AppendTag(requested, requestedOutputName, protowire.BytesType)
+	requested = protowire.AppendString(requested, output)
+
+	var b []byte
+	b = protowire.AppendTag(b, requestModelName, protowire.BytesType)
+	b = protowire.AppendString(b, model)
+	if version != "" {
+		b = protowire.AppendTag(b, requestModelVersion, protowire.BytesType)
+		b = protowire.AppendString(b, version)
+	}
+	b = protowire.AppendTag(b, requestInputs, protowire.BytesType)
+	b = protowire.AppendBytes(b, tensor)
+	b = protowire.AppendTag(b, requestOutputs, protowire.BytesType)
+	b = protowire.AppendBytes(b, requested)
+	b = protowire.AppendTag(b, requestRawInputs, protowire.BytesType)
+	b = protowire.AppendBytes(b, input.Data)
+	return b
+}
+
+// decodeInferResponse decodes the output tensors of a ModelInferResponse.
+// Raw output contents belong to the outputs in order; outputs sent as typed
+// contents only support FP32, which is converted to raw little-endian bytes.
+func decodeInferResponse(b []byte) ([]Tensor, error) {
+	var outputs []Tensor
+	var raw [][]byte
+	err := eachField(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case num == responseOutputs && typ == protowire.BytesType:
+			tensor, err := decodeOutputTensor(value)
+			if err != nil {
+				return err
+			}
+			outputs = append(outputs, tensor)
+		case num == responseRawOutputs && typ == protowire.BytesType:
+			raw = append(raw, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) > 0 {
+		if len(raw) != len(outputs) {
+			return nil, fmt.Errorf("%w: %d raw contents for %d outputs", errMalformed, len(raw), len(outputs))
+		}
+		for i := range outputs {
+			outputs[i].Data = raw[i]
+		}
+	}
+	return outputs, nil
+}
+
+func decodeOutputTensor(b []byte) (Tensor, error) {
+	var tensor Tensor
+	err := eachField(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch num {
+		case tensorName:
+			tensor.Name = string(value)
+		case tensorDatatype:
+			tensor.Datatype = string(value)
+		case tensorShape:
+			// Packed, or one varint per field
+			if typ != protowire.BytesType {
+				dim, _ := protowire.ConsumeVarint(value)
+				tensor.Shape = append(tensor.Shape, int64(dim))
+				break
+			}
+			for len(value) > 0 {
+				dim, n := protowire.ConsumeVarint(value)
+				if n < 0 {
+					return errMalformed
+				}
+				tensor.Shape = append(tensor.Shape, int64(dim))
+				value = value[n:]
+			}
+		case tensorContents:
+			data, err := decodeFP32Contents(value)
+			if err != nil {
+				return err
+			}
+			tensor.Data = data
+		}
+		return nil
+	})
+	return tensor, err
+}
+
+// decodeFP32Contents returns the fp32_contents of InferTensorContents as
+// raw little-endian bytes.
+func decodeFP32Contents(b []byte) ([]byte, error) {
+	var data []byte
+	err := eachField(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != contentsFP32 {
+			return nil
+		}
+		if typ == protowire.Fixed32Type {
+			data = append(data, value...)
+			return nil
+		}
+		if typ != protowire.BytesType || len(value)%fp32Size != 0 {
+			return errMalformed
+		}
+		data = append(data, value...)
+		return nil
+	})
+	return data, err
+}
+
+// eachField calls fn with every field of a message. Varint and fixed values
+// are passed in their wire encoding; length-delimited ones without their
+// length prefix.
+func eachField(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errMalformed
+		}
+		b = b[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, m := protowire.ConsumeBytes(b)
+			if m < 0 {
+				return errMalformed
+			}
+			value, n = v, m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return errMalformed
+			}
+			value = b[:n]
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// FP32 encodes values as the raw contents of an FP32 tensor.
+func FP32(values []float32) []byte {
+	data := make([]byte, len(values)*fp32Size)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*fp32Size:], math.Float32bits(v))
+	}
+	return data
+}
+
+// Float32s decodes the contents of an FP32 tensor.
+func (t Tensor) Float32s() ([]float32, error) {
+	if t.Datatype != "FP32" {
+		return nil, fmt.Errorf("inference: output %q is %s, expected FP32", t.Name, t.Datatype)
+	}
+	if len(t.Data)%fp32Size != 0 {
+		return nil, fmt.Errorf("%w: %d bytes of FP32 contents", errMalformed, len(t.Data))
+	}
+	values := make([]float32, len(t.Data)/fp32Size)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(t.Data[i*fp32Size:]))
+	}
+	return values, nil
+}
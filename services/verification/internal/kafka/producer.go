@@ -0,0 +1,236 @@
+// Package kafka is a minimal Kafka producer speaking the wire protocol
+// directly: it looks up partition leaders and produces uncompressed record
+// batches acknowledged by all in-sync replicas. It covers what the event
+// publisher needs and nothing more; there are no transactions, compression
+// or consumers.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	apiProduce  = 0
+	apiMetadata = 3
+
+	// acksAll waits for every in-sync replica
+	acksAll = -1
+)
+
+// Message is a record to produce. Messages with the same key land on the
+// same partition, as with the Java client's default partitioner.
+type Message struct {
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// Producer produces to a cluster reached through any of its brokers.
+type Producer struct {
+	brokers       []string
+	clientID      string
+	timeout       time.Duration
+	correlationID atomic.Int32
+}
+
+// NewProducer returns a producer bootstrapping from brokers (host:port).
+// timeout bounds each request, including the wait for replicas.
+func NewProducer(brokers []string, clientID string, timeout time.Duration) *Producer {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Producer{brokers: brokers, clientID: clientID, timeout: timeout}
+}
+
+type partitionMeta struct {
+	id     int32
+	leader int32
+}
+
+type topicMeta struct {
+	brokers    map[int32]string
+	partitions []partitionMeta
+}
+
+// Produce writes messages to topic and returns once every partition leader
+// has acknowledged its share. On error some partitions may have been
+// written, so delivery is at least once.
+func (p *Producer) Produce(ctx context.Context, topic string, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	meta, err := p.metadata(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	// Group the messages by partition, then the partitions by leader
+	byPartition := make(map[int32][]Message)
+	for _, message := range messages {
+		partition := meta.partitions[partitionFor(message.Key, len(meta.partitions))]
+		byPartition[partition.id] = append(byPartition[partition.id], message)
+	}
+	byLeader := make(map[int32][]int32)
+	for _, partition := range meta.partitions {
+		if len(byPartition[partition.id]) > 0 {
+			byLeader[partition.leader] = append(byLeader[partition.leader], partition.id)
+		}
+	}
+
+	for leader, partitions := range byLeader {
+		addr, ok := meta.brokers[leader]
+		if !ok {
+			return fmt.Errorf("kafka: leader %d of topic %s is unknown", leader, topic)
+		}
+		if err := p.produce(ctx, addr, topic, partitions, byPartition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckHealth fetches the topic's metadata.
+func (p *Producer) CheckHealth(ctx context.Context, topic string) error {
+	_, err := p.metadata(ctx, topic)
+	return err
+}
+
+// metadata asks the first reachable bootstrap broker for the partition
+// leaders of topic.
+func (p *Producer) metadata(ctx context.Context, topic string) (*topicMeta, error) {
+	req := &encoder{}
+	req.int32(1)
+	req.string(topic)
+
+	var lastErr error
+	for _, broker := range p.brokers {
+		resp, err := p.roundTrip(ctx, broker, apiMetadata, 1, req.bytes())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return parseMetadata(resp, topic)
+	}
+	if lastErr == nil {
+		lastErr = errors.New("kafka: no brokers configured")
+	}
+	return nil, lastErr
+}
+
+func parseMetadata(resp *decoder, topic string) (*topicMeta, error) {
+	meta := &topicMeta{brokers: make(map[int32]string)}
+	for i := resp.arrayLen(); i > 0; i-- {
+		nodeID := resp.int32()
+		host := resp.string()
+		port := resp.int32()
+		resp.string() // rack
+		meta.brokers[nodeID] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	resp.int32() // controller ID
+
+	for i := resp.arrayLen(); i > 0; i-- {
+		errorCode := resp.int16()
+		name := resp.string()
+		resp.int8() // is internal
+		var partitions []partitionMeta
+		for j := resp.arrayLen(); j > 0; j-- {
+			resp.int16() // partition error, e.g. an offline replica
+			partition := partitionMeta{id: resp.int32(), leader: resp.int32()}
+			resp.skipInt32Array() // replicas
+			resp.skipInt32Array() // in-sync replicas
+			partitions = append(partitions, partition)
+		}
+		if name != topic {
+			continue
+		}
+		if errorCode != 0 {
+			return nil, fmt.Errorf("kafka: metadata for topic %s failed with error code %d", topic, errorCode)
+		}
+		meta.partitions = partitions
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if len(meta.partitions) == 0 {
+		return nil, fmt.Errorf("kafka: topic %s has no partitions", topic)
+	}
+	return meta, nil
+}
+
+func (p *Producer) produce(ctx context.Context, addr, topic string, partitions []int32, byPartition map[int32][]Message) error {
+	req := &encoder{}
+	req.int16(-1) // no transactional ID
+	req.int16(acksAll)
+	req.int32(int32(p.timeout.Milliseconds()))
+	req.int32(1)
+	req.string(topic)
+	req.int32(int32(len(partitions)))
+	for _, partition := range partitions {
+		req.int32(partition)
+		req.bytesField(recordBatch(byPartition[partition]))
+	}
+
+	resp, err := p.roundTrip(ctx, addr, apiProduce, 3, req.bytes())
+	if err != nil {
+		return err
+	}
+	for i := resp.arrayLen(); i > 0; i-- {
+		resp.string()
+		for j := resp.arrayLen(); j > 0; j-- {
+			partition := resp.int32()
+			errorCode := resp.int16()
+			resp.int64() // base offset
+			resp.int64() // log append time
+			if errorCode != 0 && resp.err == nil {
+				return fmt.Errorf("kafka: produce to %s/%d failed with error code %d", topic, partition, errorCode)
+			}
+		}
+	}
+	return resp.err
+}
+
+// roundTrip sends one request on a fresh connection and returns the
+// response body.
+func (p *Producer) roundTrip(ctx context.Context, addr string, apiKey, apiVersion int16, body []byte) (*decoder, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout+5*time.Second)
+	defer cancel()
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	correlationID := p.correlationID.Add(1)
+	header := &encoder{}
+	header.int16(apiKey)
+	header.int16(apiVersion)
+	header.int32(correlationID)
+	header.string(p.clientID)
+
+	frame := &encoder{}
+	frame.int32(int32(len(header.bytes()) + len(body)))
+	frame.raw(header.bytes())
+	frame.raw(body)
+	if _, err := conn.Write(frame.bytes()); err != nil {
+		return nil, err
+	}
+
+	resp, err := readFrame(conn)
+	if err != nil {
+		return nil, err
+	}
+	if got := resp.int32(); got != correlationID {
+		return nil, fmt.Errorf("kafka: response for request %d, want %d", got, correlationID)
+	}
+	return resp, resp.err
+}
@@ -0,0 +1,238 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// maxResponseSize guards against reading a frame from something that is
+// not a Kafka broker.
+const maxResponseSize = 64 << 20
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var errShortResponse = errors.New("kafka: truncated response")
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) bytes() []byte { return e.buf }
+
+func (e *encoder) raw(b []byte) { e.buf = append(e.buf, b...) }
+
+func (e *encoder) int8(v int8) { e.buf = append(e.buf, byte(v)) }
+
+func (e *encoder) int16(v int16) { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+
+func (e *encoder) int32(v int32) { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+
+func (e *encoder) int64(v int64) { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+
+// varint writes a zigzag varint, as record fields use.
+func (e *encoder) varint(v int64) { e.buf = binary.AppendVarint(e.buf, v) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bytesField(b []byte) {
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// decoder reads a response; the first failure sticks in err and later
+// reads return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.err = errShortResponse
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a nullable string; null reads as "".
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// arrayLen reads an array length; null arrays read as empty.
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) skipInt32Array() {
+	d.take(4 * d.arrayLen())
+}
+
+func readFrame(r io.Reader) (*decoder, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxResponseSize {
+		return nil, fmt.Errorf("kafka: response of %d bytes is too large", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return &decoder{buf: buf}, nil
+}
+
+// recordBatch encodes messages as an uncompressed v2 record batch.
+func recordBatch(messages []Message) []byte {
+	first, last := messages[0].Time, messages[0].Time
+	for _, message := range messages {
+		if message.Time.Before(first) {
+			first = message.Time
+		}
+		if message.Time.After(last) {
+			last = message.Time
+		}
+	}
+
+	records := &encoder{}
+	for i, message := range messages {
+		record := &encoder{}
+		record.int8(0) // attributes
+		record.varint(message.Time.Sub(first).Milliseconds())
+		record.varint(int64(i))
+		if message.Key == nil {
+			record.varint(-1)
+		} else {
+			record.varint(int64(len(message.Key)))
+			record.raw(message.Key)
+		}
+		record.varint(int64(len(message.Value)))
+		record.raw(message.Value)
+		record.varint(0) // headers
+		records.varint(int64(len(record.bytes())))
+		records.raw(record.bytes())
+	}
+
+	// The CRC covers everything from the attributes on
+	body := &encoder{}
+	body.int16(0) // attributes: no compression, create time
+	body.int32(int32(len(messages) - 1))
+	body.int64(millis(first))
+	body.int64(millis(last))
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(messages)))
+	body.raw(records.bytes())
+
+	batch := &encoder{}
+	batch.int64(0) // base offset, assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + len(body.bytes())))
+	batch.int32(-1) // partition leader epoch
+	batch.int8(2)   // magic
+	batch.int32(int32(crc32.Checksum(body.bytes(), castagnoli)))
+	batch.raw(body.bytes())
+	return batch.bytes()
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// partitionFor picks a partition the way the Java client's default
+// partitioner does, so keys land where other producers put them. Keyless
+// messages go to partition 0.
+func partitionFor(key []byte, partitions int) int {
+	if key == nil || partitions <= 1 {
+		return 0
+	}
+	return int(murmur2(key)&0x7fffffff) % partitions
+}
+
+// murmur2 is the hash the Java client partitions keys by.
+func murmur2(data []byte) int32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
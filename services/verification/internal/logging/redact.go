@@ -0,0 +1,145 @@
+// Package logging keeps personal data out of the service's logs. With
+// LOG_REDACTION=on a Redactor wraps the zap core: string fields are hashed
+// unless their key is on the allowlist of fields known to hold no personal
+// data, and values that cannot be hashed are masked.
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+// Masked replaces values that are neither allowlisted nor hashable.
+const Masked = "[redacted]"
+
+// DefaultAllowlist names the string, list and object fields logged by the
+// service that hold no personal data: generated IDs, tenants, settings and
+// outcomes. Numbers, booleans, durations and times are never redacted, and
+// neither are errors logged under "error".
+var DefaultAllowlist = []string{
+	"action", "check", "code", "consumer", "delivery_id", "domains",
+	"entry_id", "error", "event", "format", "job_id", "key_id", "method",
+	"names", "operation", "pipeline", "policy", "previous_key_id",
+	"provider", "serial", "signal", "signals", "source", "status", "stream",
+	"tenant", "triggers", "type", "verification_id",
+}
+
+// processKey keys the hashes without LOG_REDACTION_KEY. Every Redactor of
+// the process shares it, so the access log and the service logs agree, but
+// hashes only correlate within one run.
+var (
+	processKey     []byte
+	processKeyErr  error
+	processKeyOnce sync.Once
+)
+
+// Redactor hashes or masks the log fields that are not allowlisted.
+type Redactor struct {
+	key   []byte
+	allow map[string]bool
+}
+
+// NewRedactor returns the Redactor cfg configures, or nil when
+// LOG_REDACTION is off.
+func NewRedactor(cfg *config.Config) (*Redactor, error) {
+	if cfg.LogRedaction != "on" {
+		return nil, nil
+	}
+	key := []byte(cfg.LogRedactionKey)
+	if len(key) == 0 {
+		processKeyOnce.Do(func() {
+			processKey = make([]byte, 32)
+			_, processKeyErr = rand.Read(processKey)
+		})
+		if processKeyErr != nil {
+			return nil, fmt.Errorf("failed to generate log redaction key: %w", processKeyErr)
+		}
+		key = processKey
+	}
+	r := &Redactor{key: key, allow: make(map[string]bool)}
+	for _, name := range DefaultAllowlist {
+		r.allow[name] = true
+	}
+	for _, name := range strings.Split(cfg.LogRedactionAllowlist, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			r.allow[name] = true
+		}
+	}
+	return r, nil
+}
+
+// Hash returns a keyed hash of value, so the same user ID or session ID can
+// be followed through the logs without being readable or guessable.
+func (r *Redactor) Hash(value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return "h:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// Wrap returns core with every field redacted, for zap.WrapCore.
+func (r *Redactor) Wrap(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core, redactor: r}
+}
+
+func (r *Redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = r.field(field)
+	}
+	return redacted
+}
+
+func (r *Redactor) field(field zapcore.Field) zapcore.Field {
+	if r.allow[field.Key] {
+		return field
+	}
+	switch field.Type {
+	case zapcore.StringType:
+		return zap.String(field.Key, r.Hash(field.String))
+	case zapcore.ByteStringType:
+		return zap.String(field.Key, r.Hash(string(field.Interface.([]byte))))
+	case zapcore.StringerType:
+		return zap.String(field.Key, r.Hash(field.Interface.(fmt.Stringer).String()))
+	case zapcore.BoolType, zapcore.DurationType, zapcore.TimeType, zapcore.TimeFullType,
+		zapcore.Float64Type, zapcore.Float32Type,
+		zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type,
+		zapcore.SkipType, zapcore.NamespaceType:
+		return field
+	}
+	return zap.String(field.Key, Masked)
+}
+
+// redactingCore redacts the fields of entries before the wrapped core
+// encodes them.
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.fields(fields)), redactor: c.redactor}
+}
+
+// Check defers to the wrapped core, so its sampling still applies, but has
+// the entry written through c.
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Check(entry, nil) == nil {
+		return checked
+	}
+	return checked.AddCore(entry, c)
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redactor.fields(fields))
+}
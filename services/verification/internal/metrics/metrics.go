@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"expvar"
+)
+
+// Process-wide counters and gauges, exposed at /debug/vars.
+var (
+	DriftAlerts            = expvar.NewInt("drift_alerts_total")
+	DriftRollingConfidence = expvar.NewFloat("drift_rolling_confidence")
+	DriftRollingLiveness   = expvar.NewFloat("drift_rolling_liveness")
+
+	CanaryRuns          = expvar.NewInt("canary_runs_total")
+	CanaryAgreements    = expvar.NewInt("canary_agreements_total")
+	CanaryDisagreements = expvar.NewInt("canary_disagreements_total")
+	CanaryErrors        = expvar.NewInt("canary_errors_total")
+
+	// Candidate minus stable scores of canary models, summed over the runs
+	// that scored them
+	CanaryLivenessDeltas     = expvar.NewInt("canary_liveness_deltas_total")
+	CanaryLivenessDeltaSum   = expvar.NewFloat("canary_liveness_delta_sum")
+	CanarySimilarityDeltas   = expvar.NewInt("canary_similarity_deltas_total")
+	CanarySimilarityDeltaSum = expvar.NewFloat("canary_similarity_delta_sum")
+
+	VideoDecodes         = expvar.NewInt("video_decodes_total")
+	DecodeBudgetExceeded = expvar.NewInt("decode_budget_exceeded_total")
+	DedupHits            = expvar.NewInt("dedup_hits_total")
+
+	DescriptorCacheHits   = expvar.NewInt("descriptor_cache_hits_total")
+	DescriptorCacheMisses = expvar.NewInt("descriptor_cache_misses_total")
+
+	RequestsQueued = expvar.NewInt("requests_queued")
+	RequestsShed   = expvar.NewInt("requests_shed_total")
+
+	RecognizersLoaded  = expvar.NewInt("recognizers_loaded")
+	RecognizersInUse   = expvar.NewInt("recognizers_in_use")
+	RecognizerDiscards = expvar.NewInt("recognizer_discards_total")
+
+	// How long the startup warmup took
+	WarmupSeconds = expvar.NewFloat("warmup_seconds")
+
+	RemoteEmbeddings      = expvar.NewInt("remote_embeddings_total")
+	RemoteEmbeddingErrors = expvar.NewInt("remote_embedding_errors_total")
+
+	VectorFileRecoveries = expvar.NewInt("vector_file_recoveries_total")
+
+	// Gallery changes announced by replicas, own ones included
+	GalleryInvalidations = expvar.NewInt("gallery_invalidations_total")
+
+	WebhookAttempts = expvar.NewInt("webhook_attempts_total")
+	WebhookFailures = expvar.NewInt("webhook_failures_total")
+
+	EventsPublished      = expvar.NewInt("events_published_total")
+	EventPublishFailures = expvar.NewInt("event_publish_failures_total")
+
+	QueueJobs       = expvar.NewInt("queue_jobs_total")
+	QueueJobRetries = expvar.NewInt("queue_job_retries_total")
+
+	QuotaRejections = expvar.NewInt("quota_rejections_total")
+
+	WatchlistHits = expvar.NewInt("watchlist_hits_total")
+
+	VelocityExceeded = expvar.NewInt("velocity_exceeded_total")
+	Lockouts         = expvar.NewInt("lockouts_total")
+
+	InjectionsSuspected = expvar.NewInt("injections_suspected_total")
+
+	ReviewsQueued = expvar.NewInt("reviews_queued_total")
+
+	TemplateRefreshes = expvar.NewInt("template_refreshes_total")
+
+	// Risk engine decisions, keyed by approve, review and deny
+	RiskDecisions = expvar.NewMap("risk_decisions_total")
+)
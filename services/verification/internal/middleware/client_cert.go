@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"connect-hub/verification-service/internal/tlsconfig"
+)
+
+const (
+	ClientIdentityContextKey = "client_identity"
+	clientTenantContextKey   = "client_certificate_tenant"
+)
+
+// ClientCertificate resolves the role of a verified client certificate and
+// stores its identity in the context under ClientIdentityContextKey. An
+// admin identity counts as presenting the admin key, and a tenant identity
+// as presenting one of the tenant's API keys, for the middleware that
+// follows. Certificates without a listed identity change nothing.
+func ClientCertificate(identities tlsconfig.ClientIdentities) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity, role, ok := identities.Resolve(c.Request.TLS); ok {
+			c.Set(ClientIdentityContextKey, identity)
+			if role.Admin {
+				c.Set(AdminContextKey, true)
+			}
+			if role.Tenant != "" {
+				c.Set(clientTenantContextKey, role.Tenant)
+			}
+		}
+		c.Next()
+	}
+}
+
+// isAdmin reports whether the request carries the admin key or an admin
+// client certificate.
+func isAdmin(c *gin.Context, adminKey string) bool {
+	return c.GetBool(AdminContextKey) || AdminKeyMatches(adminKey, c.GetHeader("X-Admin-Key"))
+}
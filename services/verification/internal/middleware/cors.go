@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+// CORSConfig says which browser origins may call the API.
+type CORSConfig struct {
+	// AllowedOrigins are exact origins (https://app.example.com), wildcard
+	// subdomains (https://*.example.com) or "*" for any origin
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization; the
+	// request's origin is echoed back, as browsers refuse "*" with them
+	AllowCredentials bool
+}
+
+// corsExposedHeaders are the response headers clients act on: ETag for
+// conditional requests, the rate limit and the ID of a verification.
+var corsExposedHeaders = []string{
+	"ETag",
+	"Retry-After",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"X-Verification-Id",
+}
+
+// NewCORSConfig reads the CORS_* settings. With no origins configured none
+// is allowed.
+func NewCORSConfig(cfg *config.Config) CORSConfig {
+	return CORSConfig{
+		AllowedOrigins:   splitList(cfg.CORSAllowedOrigins),
+		AllowedMethods:   splitList(cfg.CORSAllowedMethods),
+		AllowedHeaders:   splitList(cfg.CORSAllowedHeaders),
+		ExposedHeaders:   corsExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+	}
+}
+
+// AllowsOrigin reports whether origin matches one of the allowed origins.
+func (cfg CORSConfig) AllowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range cfg.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		// A wildcard stands for one or more subdomain labels
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if !ok || !strings.HasPrefix(suffix, ".") {
+			continue
+		}
+		if len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+			!strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:@") {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) allowsAnyOrigin() bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS answers cross-origin requests from allowed origins. Requests from
+// other origins are served without CORS headers, so browsers withhold the
+// response, and their preflights are refused with 403.
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions
+
+		if origin != "" {
+			if !cfg.AllowsOrigin(origin) {
+				if preflight {
+					c.AbortWithStatus(http.StatusForbidden)
+					return
+				}
+				c.Next()
+				return
+			}
+
+			if cfg.allowsAnyOrigin() && !cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Origin", "*")
+			} else {
+				c.Header("Access-Control-Allow-Origin", origin)
+				c.Header("Vary", "Origin")
+			}
+			if cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			if exposed != "" {
+				c.Header("Access-Control-Expose-Headers", exposed)
+			}
+		}
+
+		if preflight {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,344 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"connect-hub/verification-service/internal/config"
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/rbac"
+)
+
+const SubjectContextKey = "jwt_subject"
+
+// tokenTenantContextKey and tokenRolesContextKey hold the tenant and roles
+// of a verified bearer token.
+const (
+	tokenTenantContextKey = "jwt_tenant"
+	tokenRolesContextKey  = "jwt_roles"
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	// Tenant and Roles are read from the configured claims
+	Tenant string     `json:"-"`
+	Roles  rbac.Roles `json:"-"`
+}
+
+// audience is the aud claim, a single string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// JWTConfig selects how bearer tokens are verified: HS256 with Secret, for
+// tokens this deployment issues itself, and RS256/384/512 or ES256/384 with
+// the keys published at JWKSURL, for tokens of an external issuer.
+type JWTConfig struct {
+	Secret  string
+	JWKSURL string
+	// How long fetched keys are used before fetching them again; a token
+	// signed with an unknown key ID triggers an early fetch
+	JWKSRefresh time.Duration
+	// Required iss and aud claims of JWKS-verified tokens, when set
+	Issuer   string
+	Audience string
+	// Claim naming the caller's tenant
+	TenantClaim string
+	// Claim listing the caller's roles, as a space-separated string like
+	// OAuth scopes or as an array
+	RolesClaim string
+}
+
+// NewJWTConfig reads the bearer token settings from cfg.
+func NewJWTConfig(cfg *config.Config) JWTConfig {
+	return JWTConfig{
+		Secret:      cfg.JWTSecret,
+		JWKSURL:     cfg.JWTJWKSURL,
+		JWKSRefresh: time.Duration(cfg.JWTJWKSRefresh) * time.Second,
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		TenantClaim: cfg.JWTTenantClaim,
+		RolesClaim:  cfg.JWTRolesClaim,
+	}
+}
+
+// Enabled reports whether any way of verifying tokens is configured.
+func (cfg JWTConfig) Enabled() bool {
+	return cfg.Secret != "" || cfg.JWKSURL != ""
+}
+
+// JWTVerifier checks bearer tokens against a JWTConfig. It is safe for
+// concurrent use and caches the JWKS between requests.
+type JWTVerifier struct {
+	cfg  JWTConfig
+	keys *keySet
+}
+
+func NewJWTVerifier(cfg JWTConfig) *JWTVerifier {
+	v := &JWTVerifier{cfg: cfg}
+	if cfg.JWKSURL != "" {
+		v.keys = newKeySet(cfg.JWKSURL, cfg.JWKSRefresh)
+	}
+	return v
+}
+
+// JWTAuth requires an HS256 bearer token signed with secret and stores its
+// subject claim in the context under SubjectContextKey.
+func JWTAuth(secret string) gin.HandlerFunc {
+	return RequireBearer(NewJWTVerifier(JWTConfig{Secret: secret}))
+}
+
+// IdentifyBearer verifies the bearer token of requests that carry one,
+// storing its subject under SubjectContextKey and its tenant and roles for
+// Tenant, which then accepts the token in place of a tenant API key. A token
+// with the admin role counts as presenting the admin key. Requests without
+// a token pass through; an invalid token is rejected.
+func IdentifyBearer(verifier *JWTVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if !identify(c, verifier, token) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireBearer rejects requests without a valid bearer token, unless
+// IdentifyBearer already accepted one.
+func RequireBearer(verifier *JWTVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(SubjectContextKey) != "" {
+			c.Next()
+			return
+		}
+		token, ok := bearerToken(c)
+		if !ok {
+			abortWithError(c, apperrors.ErrUnauthorized.WithMessage("Bearer token is required"))
+			return
+		}
+		if !identify(c, verifier, token) {
+			return
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	authorization := c.GetHeader("Authorization")
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	return token, token != "" && token != authorization
+}
+
+func identify(c *gin.Context, verifier *JWTVerifier, token string) bool {
+	claims, err := verifier.Verify(token, time.Now())
+	if err != nil {
+		abortWithError(c, apperrors.ErrUnauthorized)
+		return false
+	}
+	c.Set(SubjectContextKey, claims.Subject)
+	if claims.Tenant != "" {
+		c.Set(tokenTenantContextKey, claims.Tenant)
+	}
+	c.Set(tokenRolesContextKey, claims.Roles)
+	if claims.Roles.Has(rbac.Admin) {
+		c.Set(AdminContextKey, true)
+	}
+	return true
+}
+
+// Verify checks the token's signature and its exp and nbf claims, and the
+// iss and aud claims of tokens signed by the external issuer, which must
+// expire, and returns its claims. The admin role spans tenants, so a token
+// naming both is refused.
+func (v *JWTVerifier) Verify(token string, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], signature, now); err != nil {
+		return nil, err
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(claimsJSON, &all); err != nil {
+		return nil, err
+	}
+	claims.Tenant, _ = all[v.cfg.TenantClaim].(string)
+	claims.Roles = rbac.FromScopes(scopes(all[v.cfg.RolesClaim]))
+
+	if claims.Subject == "" {
+		return nil, errors.New("missing subject claim")
+	}
+	if claims.Tenant != "" && claims.Roles.Has(rbac.Admin) {
+		return nil, errors.New("admin tokens cannot be scoped to a tenant")
+	}
+	if claims.ExpiresAt != 0 && now.Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return nil, errors.New("token not yet valid")
+	}
+	if header.Alg == "HS256" {
+		// Our own tokens carry neither claim
+		return &claims, nil
+	}
+	if claims.ExpiresAt == 0 {
+		return nil, errors.New("missing expiry claim")
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if v.cfg.Audience != "" && !claims.Audience.contains(v.cfg.Audience) {
+		return nil, errors.New("token is not for this audience")
+	}
+	return &claims, nil
+}
+
+// scopes reads a roles claim given as a space-separated string or an array.
+func scopes(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []interface{}:
+		var scopes []string
+		for _, scope := range claim {
+			if scope, ok := scope.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+func (a audience) contains(want string) bool {
+	for _, aud := range a {
+		if aud == want {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature checks signature over signed with the key the header
+// selects. HS256 is only accepted with the shared secret and the other
+// algorithms only with a JWKS key of the matching type, so a token cannot
+// pass off a public key as an HMAC secret.
+func (v *JWTVerifier) verifySignature(header jwtHeader, signed string, signature []byte, now time.Time) error {
+	if header.Alg == "HS256" {
+		if v.cfg.Secret == "" {
+			return errors.New("no signing secret configured")
+		}
+		mac := hmac.New(sha256.New, []byte(v.cfg.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	hash, ok := jwtHashes[header.Alg]
+	if !ok {
+		return errors.New("unsupported signing algorithm")
+	}
+	if v.keys == nil {
+		return errors.New("no JWKS configured")
+	}
+	key, err := v.keys.key(header.Kid, now)
+	if err != nil {
+		return err
+	}
+	digest := hash.New()
+	digest.Write([]byte(signed))
+	sum := digest.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(header.Alg, "RS") {
+			return errors.New("algorithm does not match the key")
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, sum, signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(header.Alg, "ES") || len(signature) != 2*size || hash.Size() != size {
+			return errors.New("algorithm does not match the key")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, sum, r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return errors.New("unsupported key type")
+	}
+	return nil
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
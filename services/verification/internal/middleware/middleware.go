@@ -1,12 +1,14 @@
 package middleware
 
 import (
-	"net/http"
-	"time"
+	"crypto/subtle"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/logging"
 )
 
 func Logger(logger *zap.Logger) gin.HandlerFunc {
@@ -15,18 +17,33 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 	})
 }
 
-func CORS() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
+// routeContextKey holds the route template a request matched.
+const routeContextKey = "route"
 
-		c.Next()
+// RedactedLogger is Logger for LOG_REDACTION=on. Requests are logged by the
+// route they matched, such as /api/v1/status/:id, instead of their path,
+// which can carry user IDs, and the client IP is hashed.
+func RedactedLogger(redactor *logging.Redactor) gin.HandlerFunc {
+	log := gin.LoggerWithConfig(gin.LoggerConfig{
+		SkipPaths: []string{"/health"},
+		Formatter: func(param gin.LogFormatterParams) string {
+			route, _ := param.Keys[routeContextKey].(string)
+			if route == "" {
+				route = "-"
+			}
+			return fmt.Sprintf("[GIN] %v | %3d | %13v | %s | %-7s %s\n",
+				param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+				param.StatusCode,
+				param.Latency,
+				redactor.Hash(param.ClientIP),
+				param.Method,
+				route,
+			)
+		},
+	})
+	return func(c *gin.Context) {
+		c.Set(routeContextKey, c.FullPath())
+		log(c)
 	}
 }
 
@@ -37,22 +54,45 @@ func Recovery(logger *zap.Logger) gin.HandlerFunc {
 		} else {
 			logger.Error("Panic recovered", zap.Any("error", recovered))
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
-		})
+		abortWithError(c, apperrors.ErrInternal)
 	})
 }
 
-func RateLimit() gin.HandlerFunc {
-	limiter := rate.NewLimiter(rate.Every(time.Minute/60), 60) // 60 requests per minute
+// abortWithError rejects the request with an API error.
+func abortWithError(c *gin.Context, err *apperrors.Error) {
+	c.AbortWithStatusJSON(err.Status, err.Body())
+}
+
+const AdminContextKey = "is_admin"
+
+// IdentifyAdmin marks the request as admin-authenticated when it carries the
+// configured admin key. It never rejects; handlers decide what to expose.
+func IdentifyAdmin(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAdmin(c, adminKey) {
+			c.Set(AdminContextKey, true)
+		}
+		c.Next()
+	}
+}
 
+// RequireAdmin rejects requests that don't carry the configured admin key
+// or an admin client certificate. With neither configured, admin-only
+// routes are unreachable.
+func RequireAdmin(adminKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-			})
+		if !isAdmin(c, adminKey) {
+			abortWithError(c, apperrors.ErrAdminRequired)
 			return
 		}
+		c.Set(AdminContextKey, true)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// AdminKeyMatches reports whether provided is the configured admin key, for
+// transports that authenticate outside gin.
+func AdminKeyMatches(adminKey, provided string) bool {
+	return adminKey != "" && provided != "" &&
+		subtle.ConstantTimeCompare([]byte(provided), []byte(adminKey)) == 1
+}
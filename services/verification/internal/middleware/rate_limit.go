@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"container/list"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// RateLimitConfig sizes the per-client token buckets.
+type RateLimitConfig struct {
+	// RequestsPerMinute is the sustained rate each client is allowed
+	RequestsPerMinute int
+	// Burst is how many requests a client may make back to back
+	Burst int
+	// MaxClients bounds how many limiters are kept; the least recently
+	// seen client is evicted first
+	MaxClients int
+	// Tenants with a rate limit of their own share one bucket across all
+	// of their API keys, refilled at that rate
+	Tenants *tenant.Registry
+	// Limits, when set, is asked for RequestsPerMinute and Burst on every
+	// request, so they can change without a restart. Buckets already
+	// handed out follow the change.
+	Limits func() (requestsPerMinute, burst int)
+}
+
+// RateLimit gives every client its own token bucket, keyed by X-API-Key
+// when present, then by client certificate identity (see
+// ClientCertificate) and by client IP otherwise, so one noisy caller cannot
+// starve the rest. Responses carry X-RateLimit-Limit, X-RateLimit-Remaining
+// and X-RateLimit-Reset (seconds until the bucket is full again).
+func RateLimit(cfg RateLimitConfig) gin.HandlerFunc {
+	if cfg.RequestsPerMinute <= 0 {
+		cfg.RequestsPerMinute = 60
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.RequestsPerMinute
+	}
+	if cfg.MaxClients <= 0 {
+		cfg.MaxClients = 10000
+	}
+
+	limiters := newClientLimiters(cfg)
+
+	return func(c *gin.Context) {
+		key, perMinute, burst := rateLimitKey(c), cfg.RequestsPerMinute, cfg.Burst
+		if cfg.Limits != nil {
+			if limit, limitBurst := cfg.Limits(); limit > 0 {
+				perMinute, burst = limit, limitBurst
+				if burst <= 0 {
+					burst = limit
+				}
+			}
+		}
+		tenantID, ok := cfg.Tenants.Resolve(c.GetHeader("X-API-Key"))
+		if !ok {
+			tenantID = c.GetString(clientTenantContextKey)
+			ok = tenantID != "" && cfg.Tenants.Known(tenantID)
+		}
+		if ok {
+			if limit := cfg.Tenants.RateLimit(tenantID); limit > 0 {
+				key, perMinute, burst = "tenant:"+tenantID, limit, limit
+			}
+		}
+		limiter := limiters.get(key, perMinute, burst)
+		perToken := time.Minute / time.Duration(perMinute)
+
+		now := time.Now()
+		allowed := limiter.AllowN(now, 1)
+		tokens := math.Max(limiter.TokensAt(now), 0)
+		reset := time.Duration((float64(burst) - tokens) * float64(perToken))
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(perMinute))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(tokens)))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+
+		if !allowed {
+			retryAfter := time.Duration((1 - tokens) * float64(perToken))
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			abortWithError(c, apperrors.ErrRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return "key:" + apiKey
+	}
+	if identity := c.GetString(ClientIdentityContextKey); identity != "" {
+		return "cert:" + identity
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// clientLimiters is an LRU of per-client limiters.
+type clientLimiters struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+type clientLimiter struct {
+	key       string
+	limiter   *rate.Limiter
+	perMinute int
+	burst     int
+}
+
+func newClientLimiters(cfg RateLimitConfig) *clientLimiters {
+	return &clientLimiters{
+		capacity: cfg.MaxClients,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the limiter of key, creating one that allows perMinute
+// requests with the given burst if there is none. An existing limiter is
+// adjusted when the limits have changed, keeping the tokens it holds.
+func (l *clientLimiters) get(key string, perMinute, burst int) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		l.order.MoveToFront(elem)
+		entry := elem.Value.(*clientLimiter)
+		if entry.perMinute != perMinute || entry.burst != burst {
+			now := time.Now()
+			entry.limiter.SetLimitAt(now, rate.Every(time.Minute/time.Duration(perMinute)))
+			entry.limiter.SetBurstAt(now, burst)
+			entry.perMinute, entry.burst = perMinute, burst
+		}
+		return entry.limiter
+	}
+
+	// An evicted client starts again with a full bucket, so capacity should
+	// comfortably exceed the number of concurrently active clients
+	if l.order.Len() >= l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*clientLimiter).key)
+	}
+
+	limiter := rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), burst)
+	l.entries[key] = l.order.PushFront(&clientLimiter{key: key, limiter: limiter, perMinute: perMinute, burst: burst})
+	return limiter
+}
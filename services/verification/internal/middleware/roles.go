@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/rbac"
+)
+
+const RolesContextKey = "roles"
+
+// grant stores the roles of the credential the caller's tenant was resolved
+// from. Admins hold every role.
+func grant(c *gin.Context, adminKey string, roles rbac.Roles) {
+	if isAdmin(c, adminKey) {
+		roles = rbac.All
+	}
+	c.Set(RolesContextKey, roles)
+}
+
+// tokenRoles returns the roles of the request's bearer token, or the
+// default roles without one.
+func tokenRoles(c *gin.Context) rbac.Roles {
+	if roles, ok := c.Get(tokenRolesContextKey); ok {
+		return roles.(rbac.Roles)
+	}
+	return rbac.Default
+}
+
+// RolesOf returns the roles Tenant granted the caller, or the default
+// roles on routes without it.
+func RolesOf(c *gin.Context) rbac.Roles {
+	if roles, ok := c.Get(RolesContextKey); ok {
+		return roles.(rbac.Roles)
+	}
+	return rbac.Default
+}
+
+// RequireRole rejects callers without role, which admins always hold.
+func RequireRole(adminKey string, role rbac.Roles) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c, adminKey) && !RolesOf(c).Has(role) {
+			abortWithError(c, apperrors.ErrRoleRequired.WithMessage(fmt.Sprintf("The %s role is required", role)))
+			return
+		}
+		c.Next()
+	}
+}
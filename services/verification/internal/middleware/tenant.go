@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/rbac"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+const TenantContextKey = "tenant_id"
+
+// Tenant resolves the caller's tenant from X-API-Key, a tenant client
+// certificate or the tenant claim of a bearer token, and stores it in the
+// context under TenantContextKey. With tenancy enabled a request without
+// any of them is rejected unless it carries
+// the admin key or an admin certificate; admins act on the tenant named by
+// X-Tenant-ID, or on the default tenant without one. With tenancy disabled
+// every caller belongs to the default tenant. The roles of the credential
+// used are stored for RequireRole.
+func Tenant(tenants *tenant.Registry, adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tenants.Enabled() {
+			c.Set(TenantContextKey, tenant.Default)
+			grant(c, adminKey, tokenRoles(c))
+			c.Next()
+			return
+		}
+
+		apiKey := c.GetHeader("X-API-Key")
+		if tenantID, ok := tenants.Resolve(apiKey); ok {
+			c.Set(TenantContextKey, tenantID)
+			grant(c, adminKey, tenants.Roles(apiKey))
+			c.Next()
+			return
+		}
+		if tenantID := c.GetString(clientTenantContextKey); tenantID != "" && tenants.Known(tenantID) {
+			c.Set(TenantContextKey, tenantID)
+			grant(c, adminKey, rbac.Default)
+			c.Next()
+			return
+		}
+		if tenantID := c.GetString(tokenTenantContextKey); tenantID != "" && tenants.Known(tenantID) {
+			c.Set(TenantContextKey, tenantID)
+			grant(c, adminKey, tokenRoles(c))
+			c.Next()
+			return
+		}
+		if !isAdmin(c, adminKey) {
+			abortWithError(c, apperrors.ErrTenantRequired)
+			return
+		}
+		tenantID := c.GetHeader("X-Tenant-ID")
+		if !tenants.Known(tenantID) {
+			abortWithError(c, apperrors.ErrUnknownTenant)
+			return
+		}
+		c.Set(TenantContextKey, tenantID)
+		grant(c, adminKey, rbac.All)
+		c.Next()
+	}
+}
+
+// TenantOf returns the tenant Tenant resolved for the request.
+func TenantOf(c *gin.Context) string {
+	return c.GetString(TenantContextKey)
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"io"
 	"time"
 )
 
@@ -8,31 +10,666 @@ type VerificationRequest struct {
 	VideoData []byte `json:"video_data"`
 	UserID    string `json:"user_id,omitempty"`
 	SessionID string `json:"session_id"`
+	Device    string `json:"device,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Region    string `json:"region,omitempty"`
+
+	// Tenant whose gallery and thresholds apply, from the caller's API key
+	Tenant string `json:"-"`
+	// Streamed capture, read instead of VideoData when set
+	Video VideoSource `json:"-"`
+	// Pre-extracted JPEG frames submitted instead of a video
+	FrameData [][]byte `json:"-"`
+	// Synthetic requests (self-benchmarks) are never recorded or exported
+	Synthetic bool `json:"-"`
+	// Checks run by an enrollment are decided at once, never held for
+	// manual review
+	Enrollment bool `json:"-"`
+	// Active liveness session the capture answers, with its nonce
+	LivenessSession string `json:"-"`
+	LivenessNonce   string `json:"-"`
+	// Address the capture was submitted from, for IP reputation and velocity
+	ClientIP string `json:"-"`
+}
+
+// VideoSource is a capture held outside the request, such as an upload
+// spilled to a temp file. Open returns a new reader on every call, so the
+// capture can be decoded more than once.
+type VideoSource interface {
+	Open() (io.ReadCloser, error)
+	Size() int64
+	// Hex SHA-256 of the content
+	Digest() string
+}
+
+// LivenessSession is an issued challenge the next capture must perform.
+type LivenessSession struct {
+	SessionToken  string    `json:"session_token"`
+	Challenge     string    `json:"challenge"`
+	Nonce         string    `json:"nonce"`
+	ColorSequence []string  `json:"color_sequence,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// ObjectUpload is a pre-signed URL the client uploads a capture to before
+// verifying it by URL or object key.
+type ObjectUpload struct {
+	ObjectKey string            `json:"object_key"`
+	URL       string            `json:"url"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Resolution is a frame size in pixels.
+type Resolution struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
 
 type VerificationResult struct {
+	VerificationID   string    `json:"verification_id"`
+	UserID           string    `json:"user_id,omitempty"`
+	Tenant           string    `json:"tenant,omitempty"`
+	Verified         bool      `json:"verified"`
+	Confidence       float64   `json:"confidence"`
+	RawConfidence    float64   `json:"raw_confidence"`
+	LivenessScore    float64   `json:"liveness_score"`
+	ProcessingTime   float64   `json:"processing_time"`
+	Timestamp        time.Time `json:"timestamp"`
+	Reason           string    `json:"reason,omitempty"`
+	ReasonMessage    string    `json:"reason_message,omitempty"`
+	Device           string    `json:"device,omitempty"`
+	ProcessingRegion string    `json:"processing_region,omitempty"`
+	ClientRegion     string    `json:"client_region,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	// How liveness was assessed; "none" for single-image verifications,
+	// which are matched only
+	LivenessMethod string `json:"liveness_method,omitempty"`
+	// Frame size of the upload and the size it was analyzed at, after
+	// downscaling to MAX_FRAME_DIMENSION
+	OriginalResolution  *Resolution `json:"original_resolution,omitempty"`
+	ProcessedResolution *Resolution `json:"processed_resolution,omitempty"`
+	// Likelihood (0-1) the feed was injected rather than filmed, and the
+	// signs found, when injection detection is enabled
+	InjectionRisk    *float64 `json:"injection_risk,omitempty"`
+	InjectionSignals []string `json:"injection_signals,omitempty"`
+	// Non-fatal advisories, returned even when verification succeeds
+	Warnings []VerificationWarning `json:"warnings,omitempty"`
+	// Set when liveness and the descriptor were those of an identical
+	// earlier upload, from the descriptor cache, rather than computed anew
+	AnalysisCached bool `json:"analysis_cached,omitempty"`
+	// ES256 JWT over the outcome, verifiable with the keys served at
+	// /.well-known/jwks.json
+	Attestation string `json:"attestation,omitempty"`
+	// Set when scores fell in a gray zone and a reviewer decides the outcome
+	Review *Review `json:"review,omitempty"`
+	// Combined risk of the attempt, when a risk policy is configured
+	Risk      *RiskAssessment `json:"risk,omitempty"`
+	Synthetic bool            `json:"-"`
+}
+
+// RiskDecision is what the risk engine recommends for a verification.
+type RiskDecision string
+
+const (
+	RiskApprove RiskDecision = "approve"
+	RiskReview  RiskDecision = "review"
+	RiskDeny    RiskDecision = "deny"
+)
+
+// RiskAssessment is the risk score of a verification, from 0 (no risk) to
+// 1, with the contribution of every signal that went into it.
+type RiskAssessment struct {
+	Score    float64            `json:"score"`
+	Decision RiskDecision       `json:"decision"`
+	Signals  map[string]float64 `json:"signals,omitempty"`
+}
+
+// ReviewStatus is where a held verification is in manual review.
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// Review is the manual review of a verification whose confidence or
+// liveness score fell in its gray zone.
+type Review struct {
+	Status ReviewStatus `json:"status"`
+	// Scores that fell in their gray zone: "confidence", "liveness"
+	Triggers  []string   `json:"triggers"`
+	Reviewer  string     `json:"reviewer,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+// ReviewCase is a verification in the manual review queue.
+type ReviewCase struct {
 	VerificationID string    `json:"verification_id"`
+	Tenant         string    `json:"tenant,omitempty"`
 	UserID         string    `json:"user_id,omitempty"`
-	Verified       bool      `json:"verified"`
-	Confidence     float64   `json:"confidence"`
-	LivenessScore  float64   `json:"liveness_score"`
-	ProcessingTime float64   `json:"processing_time"`
+	CreatedAt      time.Time `json:"created_at"`
+	Review
+}
+
+// ReviewEvidence is what a reviewer decides a case on: the held result,
+// the liveness analysis and, until the case is decided, the JPEG of the
+// frame the face was matched on.
+type ReviewEvidence struct {
+	Case     ReviewCase          `json:"case"`
+	Result   *VerificationResult `json:"result"`
+	Liveness *LivenessResult     `json:"liveness,omitempty"`
+	Frame    []byte              `json:"frame,omitempty"`
+}
+
+// AttestationClaims is the payload of a VerificationResult attestation.
+type AttestationClaims struct {
+	Issuer         string  `json:"iss"`
+	Subject        string  `json:"sub,omitempty"`
+	IssuedAt       int64   `json:"iat"`
+	ExpiresAt      int64   `json:"exp"`
+	VerificationID string  `json:"verification_id"`
+	UserID         string  `json:"user_id,omitempty"`
+	Tenant         string  `json:"tenant,omitempty"`
+	Verified       bool    `json:"verified"`
+	Confidence     float64 `json:"confidence"`
+	LivenessScore  float64 `json:"liveness_score"`
+}
+
+// JSONWebKey is the public half of an attestation signing key (RFC 7517).
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// VerificationWarning is a caveat clients may surface without treating the
+// verification as failed.
+type VerificationWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PrecheckResult is the phase-one answer of a two-phase verification. A live
+// capture comes with a token that lets phase two match the cached frames.
+type PrecheckResult struct {
+	VerificationID    string     `json:"verification_id"`
+	IsLive            bool       `json:"is_live"`
+	LivenessScore     float64    `json:"liveness_score"`
+	InjectionRisk     *float64   `json:"injection_risk,omitempty"`
+	InjectionSignals  []string   `json:"injection_signals,omitempty"`
+	Reason            string     `json:"reason,omitempty"`
+	ContinuationToken string     `json:"continuation_token,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	ProcessingTime    float64    `json:"processing_time"`
+}
+
+// LiveProgress is the running assessment of a live verification after each
+// streamed frame. Confidence is only set when a user is being matched.
+type LiveProgress struct {
+	Frames        int     `json:"frames"`
+	LivenessScore float64 `json:"liveness_score"`
+	IsLive        bool    `json:"is_live"`
+	FaceDetected  bool    `json:"face_detected"`
+	Confidence    float64 `json:"confidence,omitempty"`
+}
+
+// KeyRotation reports a re-encryption of stored enrollments under the
+// current key.
+type KeyRotation struct {
+	KeyID         string    `json:"key_id"`
+	PreviousKeyID string    `json:"previous_key_id,omitempty"`
+	Enrollments   int       `json:"enrollments"`
+	RotatedAt     time.Time `json:"rotated_at"`
+}
+
+// StorageMigration reports a rewrite of stored enrollments in the current
+// storage format.
+type StorageMigration struct {
+	Users       int       `json:"users"`
+	Enrollments int       `json:"enrollments"`
+	MigratedAt  time.Time `json:"migrated_at"`
+}
+
+// LegacyDescriptorVersion is the version of templates stored before
+// descriptors recorded the recognizer model that computed them.
+const LegacyDescriptorVersion = "1.0"
+
+// DescriptorMigration reports a pass over stored enrollments after a
+// recognizer model change. Legacy templates are stamped with the current
+// model unless LEGACY_DESCRIPTORS=stale; users left without a template of
+// the current model are listed by user key for re-enrollment.
+type DescriptorMigration struct {
+	ModelVersion         string    `json:"model_version"`
+	Users                int       `json:"users"`
+	Stamped              int       `json:"stamped"`
+	StaleTemplates       int       `json:"stale_templates"`
+	ReenrollmentRequired []string  `json:"reenrollment_required,omitempty"`
+	MigratedAt           time.Time `json:"migrated_at"`
+}
+
+// IndexRebuild reports an ANN index built from the stored gallery.
+// Enrollments whose vector cannot be indexed, because it is empty, zero,
+// not finite or of a different length than the rest, are skipped and
+// listed as user ID and creation time.
+type IndexRebuild struct {
+	Users       int      `json:"users"`
+	Enrollments int      `json:"enrollments"`
+	Dimensions  int      `json:"dimensions"`
+	Skipped     []string `json:"skipped,omitempty"`
+	BuildTimeMs int64    `json:"build_time_ms"`
+}
+
+// EnrollmentExport is everything stored for one user, as handed out in
+// answer to a data subject access request.
+type EnrollmentExport struct {
+	UserID      string       `json:"user_id"`
+	Tenant      string       `json:"tenant,omitempty"`
+	Enrollments []FaceVector `json:"enrollments"`
+	ExportedAt  time.Time    `json:"exported_at"`
+}
+
+// TenantConfig holds the settings an admin has overridden for one tenant.
+// Unset fields fall back to the tenant's TENANT_* settings and then to the
+// global configuration.
+type TenantConfig struct {
+	LivenessThreshold   *float64 `json:"liveness_threshold,omitempty"`
+	SimilarityThreshold *float64 `json:"similarity_threshold,omitempty"`
+	MaxUploadSize       *int64   `json:"max_upload_size,omitempty"`
+	// LivenessDetectors restricts the liveness pipeline to these detectors;
+	// the weights of the others are redistributed among them
+	LivenessDetectors []string  `json:"liveness_detectors,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TenantSettings are the settings in effect for a tenant.
+type TenantSettings struct {
+	LivenessThreshold   float64  `json:"liveness_threshold"`
+	SimilarityThreshold float64  `json:"similarity_threshold"`
+	MaxUploadSize       int64    `json:"max_upload_size"`
+	LivenessDetectors   []string `json:"liveness_detectors"`
+}
+
+// Tuning is the configuration that can be reloaded without a restart, as
+// in effect.
+type Tuning struct {
+	LivenessThreshold   float64 `json:"liveness_threshold"`
+	SimilarityThreshold float64 `json:"similarity_threshold"`
+	// Relative weight of every liveness detector in the score
+	LivenessWeights    map[string]float64 `json:"liveness_weights"`
+	RateLimitPerMinute int                `json:"rate_limit_per_minute"`
+	RateLimitBurst     int                `json:"rate_limit_burst"`
+	// When the settings were last reloaded; unset while the startup
+	// configuration is in effect
+	ReloadedAt *time.Time `json:"reloaded_at,omitempty"`
+}
+
+// AuditOperation is a biometric operation recorded in the audit log.
+type AuditOperation string
+
+const (
+	AuditRegister AuditOperation = "register"
+	AuditVerify   AuditOperation = "verify"
+	AuditIdentify AuditOperation = "identify"
+	AuditDelete   AuditOperation = "delete"
+	AuditCompare  AuditOperation = "compare"
+	// A verified capture refreshed the user's templates
+	AuditTemplateRefresh AuditOperation = "template_refresh"
+)
+
+// Audit event results. Verifications and identifications report their
+// decision; requests refused before processing are "rejected".
+const (
+	AuditSuccess     = "success"
+	AuditVerified    = "verified"
+	AuditNotVerified = "not_verified"
+	AuditMatch       = "match"
+	AuditNoMatch     = "no_match"
+	AuditAccepted    = "accepted"
+	AuditRejected    = "rejected"
+	AuditError       = "error"
+)
+
+// AuditEvent is one entry of the biometric audit trail: who did what to
+// whom, when, and how it ended.
+type AuditEvent struct {
+	ID             string         `json:"id"`
+	Timestamp      time.Time      `json:"timestamp"`
+	Operation      AuditOperation `json:"operation"`
+	Tenant         string         `json:"tenant,omitempty"`
+	UserID         string         `json:"user_id,omitempty"`
+	VerificationID string         `json:"verification_id,omitempty"`
+	Result         string         `json:"result"`
+	// Fingerprint of the caller's API key, never the key itself
+	ClientKey string `json:"client_key,omitempty"`
+	// Identity of the caller's client certificate, when it has a role
+	ClientIdentity string `json:"client_identity,omitempty"`
+	ClientIP       string `json:"client_ip,omitempty"`
+	Admin          bool   `json:"admin,omitempty"`
+	Transport      string `json:"transport"`
+}
+
+// UsageOperation is an operation metered for billing.
+type UsageOperation string
+
+const (
+	UsageVerification UsageOperation = "verification"
+	UsageRegistration UsageOperation = "registration"
+)
+
+// WatchlistEntry is a face on a tenant's watchlist, such as a known
+// fraudster or chargeback abuser. Its descriptor is never returned.
+type WatchlistEntry struct {
+	ID     string `json:"id"`
+	Tenant string `json:"tenant,omitempty"`
+	// Short name shown to the fraud team, e.g. a case number
+	Label     string    `json:"label"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UsageRecord counts the metered operations of one API key in one month.
+type UsageRecord struct {
+	// Calendar month in UTC, as YYYY-MM
+	Month string `json:"month"`
+	// Fingerprint of the API key, empty for callers without one
+	ClientKey     string `json:"client_key"`
+	Tenant        string `json:"tenant,omitempty"`
+	Verifications int64  `json:"verifications"`
+	Registrations int64  `json:"registrations"`
+	// Monthly limit on verifications and registrations together, 0 when
+	// unlimited
+	Quota int64 `json:"quota"`
+}
+
+// ErasureReceipt confirms that everything held about a user was erased.
+type ErasureReceipt struct {
+	UserID            string    `json:"user_id"`
+	Tenant            string    `json:"tenant,omitempty"`
+	ErasedAt          time.Time `json:"erased_at"`
+	Records           int       `json:"records"`
+	Enrollments       int       `json:"enrollments"`
+	Verifications     int       `json:"verifications"`
+	WebhookDeliveries int       `json:"webhook_deliveries"`
+	OutboxEvents      int       `json:"outbox_events"`
+	CryptoShredded    bool      `json:"crypto_shredded"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery tracks the callback for one verification result.
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	VerificationID string                `json:"verification_id"`
+	URL            string                `json:"url"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// Event types published to Kafka.
+const (
+	EventVerificationCompleted = "verification.completed"
+	EventFaceRegistered        = "face.registered"
+	EventUserErased            = "user.erased"
+	EventDuplicateIdentity     = "face.duplicate_identity"
+	EventWatchlistHit          = "watchlist.hit"
+	EventLockout               = "security.lockout"
+	EventTemplateRefreshed     = "face.template_refreshed"
+	EventReenrollmentRequired  = "face.reenrollment_required"
+)
+
+// DuplicateIdentityEvent is the payload of a face.duplicate_identity event:
+// the face being enrolled was found enrolled under another user.
+type DuplicateIdentityEvent struct {
+	MatchedUserID string  `json:"matched_user_id"`
+	Similarity    float64 `json:"similarity"`
+	// Whether the enrollment was rejected rather than only flagged
+	Rejected bool `json:"rejected"`
+}
+
+// WatchlistHitEvent is the payload of a watchlist.hit event: a verified
+// capture matched a face on the watchlist.
+type WatchlistHitEvent struct {
+	EntryID    string  `json:"entry_id"`
+	Label      string  `json:"label"`
+	Similarity float64 `json:"similarity"`
+}
+
+// LockoutEvent is the payload of a security.lockout event: a user or a
+// client IP failed verification too often in a row and is refused until
+// LockedUntil. The event's user and verification are the attempt that
+// locked it out.
+type LockoutEvent struct {
+	// "user" or "client"
+	Subject     string    `json:"subject"`
+	ClientIP    string    `json:"client_ip,omitempty"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// TemplateRefreshEvent is the payload of a face.template_refreshed event: a
+// verified capture was stored as a template of the user, replacing those
+// that had aged or because it was of better quality. The event's
+// verification is the one the capture came from.
+type TemplateRefreshEvent struct {
+	// "aged" or "quality"
+	Reason string `json:"reason"`
+	// Templates dropped for their age
+	Replaced int `json:"replaced"`
+	// Templates the user has now
+	Templates int     `json:"templates"`
+	Quality   float64 `json:"quality"`
+}
+
+// ReenrollmentEvent is the payload of a face.reenrollment_required event: a
+// descriptor migration found none of the user's templates made by the
+// current recognizer model, so the user cannot verify until enrolled again.
+type ReenrollmentEvent struct {
+	ModelVersion   string `json:"model_version"`
+	StaleTemplates int    `json:"stale_templates"`
+}
+
+// OutboxEvent is a domain event as published to Kafka, held in the outbox
+// until the broker has acknowledged it.
+type OutboxEvent struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Tenant         string          `json:"tenant,omitempty"`
+	UserID         string          `json:"user_id,omitempty"`
+	VerificationID string          `json:"verification_id,omitempty"`
+	Data           json.RawMessage `json:"data,omitempty"`
+}
+
+// AuditManifest describes an audit export so auditors can check its
+// integrity: ContentSHA256 covers the exported content and Signature, when
+// present, is an HMAC-SHA256 over the manifest with Signature empty.
+type AuditManifest struct {
+	Format        string     `json:"format"`
+	Fields        []string   `json:"fields"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	RecordCount   int        `json:"record_count"`
+	GeneratedAt   time.Time  `json:"generated_at"`
+	ContentSHA256 string     `json:"content_sha256"`
+	Signature     string     `json:"signature,omitempty"`
+}
+
+// AuditExport is a compliance export of verification records (CSV or JSON)
+// with its integrity manifest. It never contains biometric scores.
+type AuditExport struct {
+	Manifest AuditManifest `json:"manifest"`
+	Content  string        `json:"content"`
+}
+
+// HistoryEntry is the user-facing, redacted view of a past verification.
+type HistoryEntry struct {
+	VerificationID string    `json:"verification_id"`
 	Timestamp      time.Time `json:"timestamp"`
-	Error          string    `json:"error,omitempty"`
+	Verified       bool      `json:"verified"`
+	Reason         string    `json:"reason,omitempty"`
+	Device         string    `json:"device,omitempty"`
 }
 
+// Machine-stable reasons explaining a negative verification outcome
+const (
+	ReasonCameraBlocked  = "CAMERA_BLOCKED"
+	ReasonLivenessFailed = "LIVENESS_FAILED"
+	ReasonLowSimilarity  = "LOW_SIMILARITY"
+	ReasonActionMismatch = "ACTION_MISMATCH"
+	// Every enrollment of the user is younger than MIN_ENROLLMENT_AGE
+	ReasonEnrollmentNotYetActive = "ENROLLMENT_NOT_YET_ACTIVE"
+	// The capture does not show the liveness session's challenge
+	ReasonChallengeFailed = "CHALLENGE_FAILED"
+	// Held for manual review; the outcome follows once a reviewer decides
+	ReasonNeedsReview = "NEEDS_REVIEW"
+	// A reviewer rejected a verification held for review
+	ReasonReviewRejected = "REVIEW_REJECTED"
+	// The risk policy denied an otherwise successful verification
+	ReasonRiskDenied = "RISK_DENIED"
+	// The feed looks injected by a virtual camera or a tampered client
+	ReasonInjectionSuspected = "INJECTION_SUSPECTED"
+	// The capture does not show the liveness session's color sequence
+	ReasonNonceNotBound = "NONCE_NOT_BOUND"
+	// Every enrollment of the user was made by another recognizer model
+	ReasonReenrollmentRequired = "REENROLLMENT_REQUIRED"
+)
+
 type FaceVector struct {
 	UserID    string    `json:"user_id"`
 	Vector    []float32 `json:"vector"`
 	CreatedAt time.Time `json:"created_at"`
-	Version   string    `json:"version"`
+	// Recognizer model the descriptor was computed with, see
+	// LegacyDescriptorVersion
+	Version string `json:"version"`
+	// Quality score (0-1) of the capture the template was made from, 0 when
+	// it was not measured
+	Quality float64 `json:"quality,omitempty"`
+	// TemplateSourceRefresh for templates refreshed from a verification,
+	// empty for enrollments
+	Source string `json:"source,omitempty"`
+}
+
+// TemplateSourceRefresh marks a template taken from a verified capture.
+const TemplateSourceRefresh = "refresh"
+
+// FaceQuality is the assessment of the face an enrollment would store.
+type FaceQuality struct {
+	// Face width as a fraction of the frame width
+	FaceSize float64 `json:"face_size"`
+	// Variance of the Laplacian over the face; low values mean blur
+	Sharpness float64 `json:"sharpness"`
+	// Mean luminance (0-1) of the face
+	Brightness float64 `json:"brightness"`
+	// Estimated head turn or tilt away from frontal, in degrees
+	PoseAngle float64 `json:"pose_angle"`
+}
+
+// FaceComparison is the outcome of comparing the face in a selfie capture
+// with the face in a document photo. Match requires a live selfie.
+type FaceComparison struct {
+	Match          bool    `json:"match"`
+	Similarity     float64 `json:"similarity"`
+	Confidence     float64 `json:"confidence"`
+	IsLive         bool    `json:"is_live"`
+	LivenessScore  float64 `json:"liveness_score"`
+	Reason         string  `json:"reason,omitempty"`
+	ProcessingTime float64 `json:"processing_time"`
+}
+
+// DocumentFields are read from the machine-readable zone (ICAO 9303) of a
+// passport or ID card. Dates are YYYY-MM-DD.
+type DocumentFields struct {
+	Format         string `json:"format"`
+	DocumentType   string `json:"document_type"`
+	IssuingCountry string `json:"issuing_country"`
+	DocumentNumber string `json:"document_number"`
+	Surname        string `json:"surname"`
+	GivenNames     string `json:"given_names"`
+	Nationality    string `json:"nationality"`
+	DateOfBirth    string `json:"date_of_birth"`
+	Sex            string `json:"sex"`
+	ExpiryDate     string `json:"expiry_date"`
+	OptionalData   string `json:"optional_data,omitempty"`
+	// Every check digit matched, so the fields were read correctly
+	ChecksValid bool     `json:"checks_valid"`
+	MRZ         []string `json:"mrz"`
+}
+
+// PortraitCrop is the holder's portrait as located on a document, with the
+// crop as a base64-encoded JPEG.
+type PortraitCrop struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Image  string `json:"image"`
 }
 
+// DocumentVerification is the outcome of verifying a selfie capture against
+// an identity document: the face comparison with the document portrait and
+// what could be read from the document. Match reflects the faces only;
+// problems with the document itself are reported as warnings.
+type DocumentVerification struct {
+	FaceComparison
+	Portrait *PortraitCrop         `json:"portrait,omitempty"`
+	Fields   *DocumentFields       `json:"fields,omitempty"`
+	Text     []string              `json:"text,omitempty"`
+	Warnings []VerificationWarning `json:"warnings,omitempty"`
+}
+
+// GalleryMatch is one enrolled user returned by a 1:N gallery search.
+type GalleryMatch struct {
+	UserID     string  `json:"user_id"`
+	Similarity float64 `json:"similarity"`
+}
+
+// LivenessMethodNone marks a verification whose liveness was not assessed.
+const LivenessMethodNone = "none"
+
 type LivenessResult struct {
-	IsLive      bool    `json:"is_live"`
-	Confidence  float64 `json:"confidence"`
-	Method      string  `json:"method"`
-	Score       float64 `json:"score"`
+	IsLive     bool    `json:"is_live"`
+	Confidence float64 `json:"confidence"`
+	Method     string  `json:"method"`
+	Score      float64 `json:"score"`
+	// Per-detector scores, for the dataset export; never sent to clients,
+	// as they show which detector a spoof has to beat
+	Features map[string]float64 `json:"-"`
+	// Weight of each scored feature in Score
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// Likelihood (0-1) the capture is a replay shown on a screen
+	ReplayScore float64 `json:"replay_score,omitempty"`
+}
+
+// DatasetSample is a single anonymized evaluation row. It must only ever hold
+// scalar liveness features and outcome labels, never images or descriptors.
+type DatasetSample struct {
+	Features map[string]float64 `json:"features"`
+	IsLive   bool               `json:"is_live"`
+	Verified bool               `json:"verified"`
 }
 
 type VerificationStatus string
@@ -42,15 +679,50 @@ const (
 	StatusProcessing VerificationStatus = "processing"
 	StatusCompleted  VerificationStatus = "completed"
 	StatusFailed     VerificationStatus = "failed"
+	// Held until a reviewer approves or rejects it
+	StatusNeedsReview VerificationStatus = "needs_review"
+)
+
+// VerificationStage is the last pipeline stage a verification passed.
+type VerificationStage string
+
+const (
+	StageUploadReceived  VerificationStage = "upload_received"
+	StageFramesExtracted VerificationStage = "frames_extracted"
+	StageLivenessDone    VerificationStage = "liveness_done"
+	StageMatched         VerificationStage = "matched"
 )
 
 type VerificationRecord struct {
-	ID             string             `json:"id"`
-	UserID         string             `json:"user_id,omitempty"`
-	SessionID      string             `json:"session_id"`
-	Status         VerificationStatus `json:"status"`
-	Result         *VerificationResult `json:"result,omitempty"`
-	CreatedAt      time.Time          `json:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at"`
-	ErrorMessage   string             `json:"error_message,omitempty"`
-}
\ No newline at end of file
+	ID           string              `json:"id"`
+	Tenant       string              `json:"tenant,omitempty"`
+	UserID       string              `json:"user_id,omitempty"`
+	SessionID    string              `json:"session_id"`
+	Status       VerificationStatus  `json:"status"`
+	Stage        VerificationStage   `json:"stage,omitempty"`
+	Result       *VerificationResult `json:"result,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	ErrorMessage string              `json:"error_message,omitempty"`
+}
+
+// Readiness is the outcome of the dependency checks behind /readyz. The
+// service is ready when every check passed.
+type Readiness struct {
+	Ready bool `json:"ready"`
+	// Set once shutdown began; dependencies are not checked then
+	Draining bool `json:"draining,omitempty"`
+	// Set until the startup warmup finished; dependencies are not checked
+	// then either
+	WarmingUp bool              `json:"warming_up,omitempty"`
+	Checks    []DependencyCheck `json:"checks"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// DependencyCheck is one readiness probe; Error is set when it failed.
+type DependencyCheck struct {
+	Name       string `json:"name"`
+	Healthy    bool   `json:"healthy"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
@@ -0,0 +1,389 @@
+// Package nats is a minimal NATS client speaking the core text protocol:
+// publish, subscribe and JetStream pull consumers. It covers what the queue
+// worker needs and nothing more; there is no TLS, reconnection or
+// key-value support, so callers dial again when a connection fails.
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned once the connection has failed or been closed.
+var ErrClosed = errors.New("nats: connection closed")
+
+// subscriptionBuffer is how many messages a subscription holds before
+// further ones are dropped rather than stalling the connection.
+const subscriptionBuffer = 64
+
+// Msg is a message received on a subscription. Status is the status code
+// of a JetStream control message, such as 408 for an expired pull request,
+// and 0 for ordinary messages.
+type Msg struct {
+	Subject string
+	Reply   string
+	Status  int
+	Data    []byte
+
+	conn *Conn
+}
+
+// Conn is a connection to one NATS server. It is safe for concurrent use.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+	writer  *bufio.Writer
+
+	mu      sync.Mutex
+	subs    map[int]chan *Msg
+	nextSID int
+	pongs   []chan struct{}
+	err     error
+	done    chan struct{}
+}
+
+type serverInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	Headers     bool `json:"headers"`
+}
+
+type connectOptions struct {
+	Verbose      bool   `json:"verbose"`
+	Pedantic     bool   `json:"pedantic"`
+	Name         string `json:"name,omitempty"`
+	Lang         string `json:"lang"`
+	Version      string `json:"version"`
+	Protocol     int    `json:"protocol"`
+	Headers      bool   `json:"headers"`
+	NoResponders bool   `json:"no_responders"`
+	User         string `json:"user,omitempty"`
+	Pass         string `json:"pass,omitempty"`
+	AuthToken    string `json:"auth_token,omitempty"`
+}
+
+// Connect dials a nats://[user:password@|token@]host[:port] URL and
+// completes the handshake within timeout.
+func Connect(ctx context.Context, rawURL, name string, timeout time.Duration) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q", rawURL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	options := connectOptions{Name: name, Lang: "go", Version: "0.1.0", Protocol: 1, Headers: true, NoResponders: true}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options.User, options.Pass = u.User.Username(), password
+		} else {
+			options.AuthToken = u.User.Username()
+		}
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{
+		conn:   netConn,
+		reader: bufio.NewReader(netConn),
+		writer: bufio.NewWriter(netConn),
+		subs:   make(map[int]chan *Msg),
+		done:   make(chan struct{}),
+	}
+	if err := c.handshake(options, timeout); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *Conn) handshake(options connectOptions, timeout time.Duration) error {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("nats: unexpected greeting %q", strings.TrimSpace(line))
+	}
+	var info serverInfo
+	if err := json.Unmarshal([]byte(line[5:]), &info); err != nil {
+		return fmt.Errorf("nats: invalid server info: %w", err)
+	}
+	if info.TLSRequired {
+		return errors.New("nats: server requires TLS, which is not supported")
+	}
+	if !info.Headers {
+		return errors.New("nats: server does not support headers, which JetStream needs")
+	}
+
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+
+	// The server answers PONG once CONNECT was accepted
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch op := strings.TrimSpace(line); {
+		case op == "PONG":
+			return nil
+		case strings.HasPrefix(op, "-ERR"):
+			return serverError(op)
+		}
+	}
+}
+
+func serverError(op string) error {
+	return fmt.Errorf("nats: %s", strings.Trim(strings.TrimSpace(strings.TrimPrefix(op, "-ERR")), "'"))
+}
+
+// Close closes the connection; subscriptions stop delivering.
+func (c *Conn) Close() error {
+	c.fail(ErrClosed)
+	return nil
+}
+
+// Done is closed once the connection has failed or been closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection stopped, or nil while it is up.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+	c.conn.Close()
+}
+
+// Publish sends data to subject; reply, when set, is where responders
+// answer. It returns once the message is written, not when the server has
+// processed it; see Flush.
+func (c *Conn) Publish(subject, reply string, data []byte) error {
+	header := "PUB " + subject + " "
+	if reply != "" {
+		header += reply + " "
+	}
+	header += strconv.Itoa(len(data)) + "\r\n"
+	return c.write(header, data, []byte("\r\n"))
+}
+
+// Flush returns once the server has processed everything sent before it.
+func (c *Conn) Flush(ctx context.Context) error {
+	pong := make(chan struct{})
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.pongs = append(c.pongs, pong)
+	c.mu.Unlock()
+
+	if err := c.write("PING\r\n"); err != nil {
+		return err
+	}
+	select {
+	case <-pong:
+		return nil
+	case <-c.done:
+		return c.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscription receives the messages published to its subject.
+type Subscription struct {
+	conn    *Conn
+	sid     int
+	subject string
+	Msgs    <-chan *Msg
+}
+
+// Subscribe starts delivering messages published to subject. Msgs is
+// closed when the connection stops.
+func (c *Conn) Subscribe(subject string) (*Subscription, error) {
+	msgs := make(chan *Msg, subscriptionBuffer)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextSID++
+	sid := c.nextSID
+	c.subs[sid] = msgs
+	c.mu.Unlock()
+
+	if err := c.write(fmt.Sprintf("SUB %s %d\r\n", subject, sid)); err != nil {
+		return nil, err
+	}
+	return &Subscription{conn: c, sid: sid, subject: subject, Msgs: msgs}, nil
+}
+
+// Unsubscribe stops delivery to the subscription.
+func (s *Subscription) Unsubscribe() error {
+	s.conn.mu.Lock()
+	delete(s.conn.subs, s.sid)
+	s.conn.mu.Unlock()
+	return s.conn.write(fmt.Sprintf("UNSUB %d\r\n", s.sid))
+}
+
+func (c *Conn) write(parts ...interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		switch p := part.(type) {
+		case string:
+			c.writer.WriteString(p)
+		case []byte:
+			c.writer.Write(p)
+		}
+	}
+	if err := c.writer.Flush(); err != nil {
+		c.fail(err)
+		return err
+	}
+	return nil
+}
+
+func (c *Conn) readLoop() {
+	err := c.read()
+	c.fail(err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for sid, msgs := range c.subs {
+		close(msgs)
+		delete(c.subs, sid)
+	}
+}
+
+func (c *Conn) read() error {
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "MSG", "HMSG":
+			if err := c.deliver(strings.ToUpper(op) == "HMSG", strings.Fields(args)); err != nil {
+				return err
+			}
+		case "PING":
+			if err := c.write("PONG\r\n"); err != nil {
+				return err
+			}
+		case "PONG":
+			c.mu.Lock()
+			if len(c.pongs) > 0 {
+				close(c.pongs[0])
+				c.pongs = c.pongs[1:]
+			}
+			c.mu.Unlock()
+		case "-ERR":
+			return serverError(line)
+		}
+	}
+}
+
+// deliver reads the payload of a MSG (subject sid [reply] size) or HMSG
+// (subject sid [reply] header-size total-size) and hands it to its
+// subscription.
+func (c *Conn) deliver(headers bool, fields []string) error {
+	sizes := 1
+	if headers {
+		sizes = 2
+	}
+	if len(fields) < 2+sizes || len(fields) > 3+sizes {
+		return fmt.Errorf("nats: malformed message header %q", strings.Join(fields, " "))
+	}
+	msg := &Msg{Subject: fields[0], conn: c}
+	if len(fields) == 3+sizes {
+		msg.Reply = fields[2]
+	}
+	sid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("nats: malformed subscription ID %q", fields[1])
+	}
+	total, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || total < 0 {
+		return fmt.Errorf("nats: malformed message size %q", fields[len(fields)-1])
+	}
+	headerSize := 0
+	if headers {
+		if headerSize, err = strconv.Atoi(fields[len(fields)-2]); err != nil || headerSize > total {
+			return fmt.Errorf("nats: malformed header size %q", fields[len(fields)-2])
+		}
+	}
+
+	payload := make([]byte, total+2)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return err
+	}
+	msg.Status = parseStatus(payload[:headerSize])
+	msg.Data = payload[headerSize:total]
+
+	c.mu.Lock()
+	msgs, ok := c.subs[sid]
+	if ok {
+		select {
+		case msgs <- msg:
+		default:
+		}
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// parseStatus reads the status code from a "NATS/1.0 408 Request Timeout"
+// header block.
+func parseStatus(header []byte) int {
+	line, _, _ := strings.Cut(string(header), "\r\n")
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "NATS/") {
+		return 0
+	}
+	status, _ := strconv.Atoi(fields[1])
+	return status
+}
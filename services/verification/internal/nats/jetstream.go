@@ -0,0 +1,112 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// JetStream status codes sent in place of a message.
+const (
+	statusIdleHeartbeat = 100
+	statusNoMessages    = 404
+	statusRequestExpire = 408
+)
+
+var inboxCounter atomic.Int64
+
+// PullConsumer fetches messages one at a time from a durable JetStream
+// pull consumer. The stream and consumer are created by the operator, who
+// chooses their ack wait, redelivery and retention.
+type PullConsumer struct {
+	conn    *Conn
+	subject string
+	inbox   *Subscription
+}
+
+// PullConsumer returns a consumer for consumer on stream, with an inbox of
+// its own so several can share the connection.
+func (c *Conn) PullConsumer(stream, consumer string) (*PullConsumer, error) {
+	inbox, err := c.Subscribe(fmt.Sprintf("_INBOX.%d.%d", time.Now().UnixNano(), inboxCounter.Add(1)))
+	if err != nil {
+		return nil, err
+	}
+	return &PullConsumer{
+		conn:    c,
+		subject: "$JS.API.CONSUMER.MSG.NEXT." + stream + "." + consumer,
+		inbox:   inbox,
+	}, nil
+}
+
+type pullRequest struct {
+	Batch   int   `json:"batch"`
+	Expires int64 `json:"expires"`
+}
+
+// Next waits up to wait for the next message. It returns nil, nil when none
+// arrived in time. Messages must be acknowledged with Ack, Nak or Term, or
+// they are redelivered after the consumer's ack wait.
+func (p *PullConsumer) Next(ctx context.Context, wait time.Duration) (*Msg, error) {
+	request, err := json.Marshal(pullRequest{Batch: 1, Expires: wait.Nanoseconds()})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.conn.Publish(p.subject, p.inbox.subject, request); err != nil {
+		return nil, err
+	}
+
+	// Allow the server a moment past expiry to say the request expired
+	timer := time.NewTimer(wait + 5*time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-p.inbox.Msgs:
+			if !ok {
+				return nil, p.conn.Err()
+			}
+			switch {
+			case msg.Status == 0:
+				return msg, nil
+			case msg.Status == statusIdleHeartbeat:
+				continue
+			case msg.Status == statusNoMessages, msg.Status == statusRequestExpire:
+				return nil, nil
+			default:
+				return nil, fmt.Errorf("nats: pull from %s failed with status %d", p.subject, msg.Status)
+			}
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close stops the consumer's inbox.
+func (p *PullConsumer) Close() error {
+	return p.inbox.Unsubscribe()
+}
+
+// Ack tells JetStream the message was processed.
+func (m *Msg) Ack() error {
+	return m.conn.Publish(m.Reply, "", []byte("+ACK"))
+}
+
+// Nak asks JetStream to redeliver the message after delay.
+func (m *Msg) Nak(delay time.Duration) error {
+	return m.conn.Publish(m.Reply, "", []byte(`-NAK {"delay": `+strconv.FormatInt(delay.Nanoseconds(), 10)+`}`))
+}
+
+// Term tells JetStream never to redeliver the message.
+func (m *Msg) Term() error {
+	return m.conn.Publish(m.Reply, "", []byte("+TERM"))
+}
+
+// InProgress restarts the message's ack wait while it is still being
+// processed.
+func (m *Msg) InProgress() error {
+	return m.conn.Publish(m.Reply, "", []byte("+WPI"))
+}
@@ -0,0 +1,1368 @@
+// Package openapi holds the hand-maintained OpenAPI 3 contract of the
+// service. A test walks the registered gin routes to keep it in sync.
+package openapi
+
+const Version = "3.0.3"
+
+type object = map[string]interface{}
+
+var spec = build()
+
+// Spec returns the OpenAPI document served at /openapi.json.
+func Spec() map[string]interface{} {
+	return spec
+}
+
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+func schema(typ string, description string) object {
+	s := object{"type": typ}
+	if description != "" {
+		s["description"] = description
+	}
+	return s
+}
+
+func objectSchema(properties object, required ...string) object {
+	s := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func jsonContent(s object) object {
+	return object{"application/json": object{"schema": s}}
+}
+
+func multipartBody(properties object, required ...string) object {
+	return object{
+		"required": true,
+		"content": object{
+			"multipart/form-data": object{"schema": objectSchema(properties, required...)},
+		},
+	}
+}
+
+func jsonBody(s object) object {
+	return object{"required": true, "content": jsonContent(s)}
+}
+
+// withJSONBody lets a request body be sent as JSON as well.
+func withJSONBody(body object, s object) object {
+	body["content"].(object)["application/json"] = object{"schema": s}
+	return body
+}
+
+func response(description string, s object) object {
+	return object{"description": description, "content": jsonContent(s)}
+}
+
+func errorResponse(description string) object {
+	return response(description, ref("Error"))
+}
+
+func pathParam(name, description string) object {
+	return object{"name": name, "in": "path", "required": true, "description": description, "schema": schema("string", "")}
+}
+
+func queryParam(name, typ, description string) object {
+	return object{"name": name, "in": "query", "description": description, "schema": schema(typ, "")}
+}
+
+// idempotencyKey documents the Idempotency-Key request header.
+var idempotencyKey = header("Idempotency-Key", "Client-chosen key (at most 255 characters); retries with it get the first response, marked Idempotent-Replayed")
+
+func header(name, description string) object {
+	return object{"name": name, "in": "header", "description": description, "schema": schema("string", "")}
+}
+
+// reviewDecisionOperation describes the approve and reject endpoints.
+func reviewDecisionOperation(operationID, summary string) object {
+	return object{
+		"operationId": operationID,
+		"summary":     summary,
+		"security":    []object{{"adminKey": []string{}}},
+		"parameters":  []object{pathParam("id", "Verification ID")},
+		"requestBody": jsonBody(objectSchema(object{
+			"reviewer": schema("string", ""),
+			"note":     schema("string", ""),
+		}, "reviewer")),
+		"responses": object{
+			"200": response("Decided verification", objectSchema(object{
+				"success": schema("boolean", ""),
+				"data":    ref("VerificationResult"),
+			})),
+			"400": errorResponse("reviewer missing"),
+			"401": errorResponse("Admin key missing or wrong"),
+			"403": errorResponse("Credentials lack the reviewer role (ROLE_REQUIRED)"),
+			"404": errorResponse("Case not found (REVIEW_NOT_FOUND)"),
+			"409": errorResponse("Case already decided (REVIEW_ALREADY_DECIDED)"),
+		},
+	}
+}
+
+func build() object {
+	video := object{"type": "string", "format": "binary", "description": "Capture (max MAX_UPLOAD_SIZE bytes, 50MB by default)"}
+	verifyResponse := response("Verification decision", objectSchema(object{
+		"success": schema("boolean", ""),
+		"data":    ref("VerificationResult"),
+	}, "success", "data"))
+	verificationStatus := objectSchema(object{
+		"verification_id": schema("string", ""),
+		"status":          object{"type": "string", "enum": []string{"pending", "processing", "completed", "failed", "needs_review"}},
+		"stage":           object{"type": "string", "enum": []string{"upload_received", "frames_extracted", "liveness_done", "matched"}, "description": "Last pipeline stage passed"},
+		"verified":        schema("boolean", "Present once completed"),
+		"timestamp":       object{"type": "string", "format": "date-time"},
+		"updated_at":      object{"type": "string", "format": "date-time"},
+		"error_message":   schema("string", "Why a failed verification failed"),
+		"result":          ref("VerificationResult"),
+	})
+	lockedResponse := errorResponse("User or client locked out after repeated failed verifications (LOCKED); Retry-After gives the seconds left")
+	injectionSignals := object{"type": "array", "items": object{
+		"type": "string",
+		"enum": []string{"missing_sensor_noise", "static_noise_floor", "encoder_metadata", "frame_timing"},
+	}}
+
+	return object{
+		"openapi": Version,
+		"info": object{
+			"title":       "ConnectHub Video Verification Service",
+			"version":     "1.0.0",
+			"description": "Liveness detection and face matching for short video captures.",
+		},
+		"paths": object{
+			"/health": object{
+				"get": object{
+					"operationId": "health",
+					"summary":     "Liveness probe (alias of /healthz)",
+					"deprecated":  true,
+					"responses": object{
+						"200": response("Service is up", ref("Liveness")),
+					},
+				},
+			},
+			"/healthz": object{
+				"get": object{
+					"operationId": "healthz",
+					"summary":     "Liveness probe",
+					"responses": object{
+						"200": response("Service is up", ref("Liveness")),
+					},
+				},
+			},
+			"/readyz": object{
+				"get": object{
+					"operationId": "readyz",
+					"summary":     "Readiness probe checking the recognizer, storage and object store",
+					"responses": object{
+						"200": response("Every dependency check passed", ref("Readiness")),
+						"503": response("A dependency check failed, or the service is shutting down", ref("Readiness")),
+					},
+				},
+			},
+			"/debug/vars": object{
+				"get": object{
+					"operationId": "metrics",
+					"summary":     "Process metrics (expvar)",
+					"parameters": []object{
+						header("X-Admin-Key", "Admin key"),
+					},
+					"responses": object{
+						"200": response("Metric values", schema("object", "")),
+						"401": errorResponse("Missing or invalid admin key"),
+					},
+				},
+			},
+			"/openapi.json": object{
+				"get": object{
+					"operationId": "openapi",
+					"summary":     "This document",
+					"responses": object{
+						"200": response("OpenAPI document", schema("object", "")),
+					},
+				},
+			},
+			"/.well-known/jwks.json": object{
+				"get": object{
+					"operationId": "jwks",
+					"summary":     "Public keys for verifying result attestations",
+					"responses": object{
+						"200": response("JSON Web Key Set", ref("JSONWebKeySet")),
+						"501": errorResponse("Result attestation disabled"),
+					},
+				},
+			},
+			"/api/v1/verify": object{
+				"post": object{
+					"operationId": "verifyVideo",
+					"summary":     "Verify a capture for liveness and, with user_id, face match",
+					"parameters": []object{
+						header("Accept-Language", "Language for reason_message"),
+						header("If-None-Match", "ETag of an earlier identical submission"),
+						idempotencyKey,
+					},
+					"requestBody": withJSONBody(multipartBody(object{
+						"video":            video,
+						"user_id":          schema("string", "User to match against"),
+						"session_id":       schema("string", "Client session identifier"),
+						"device":           schema("string", "Device label"),
+						"action":           object{"type": "string", "enum": []string{"turn_left", "turn_right", "look_up", "look_down"}},
+						"region":           schema("string", "Client-declared region, e.g. eu-west-1"),
+						"mode":             object{"type": "string", "enum": []string{"sync", "async"}},
+						"liveness_session": schema("string", "session_token from /api/v1/liveness/session"),
+						"liveness_nonce":   schema("string", "nonce issued with the liveness session"),
+					}, "video"), ref("ObjectReference")),
+					"responses": object{
+						"200": verifyResponse,
+						"202": object{"description": "Queued (mode=async); poll the Location header for the result"},
+						"304": object{"description": "Unchanged decision for an identical submission"},
+						"400": errorResponse("Invalid input"),
+						"403": errorResponse("Referenced object outside the allowed bucket or prefix, or credentials lack the verify role (ROLE_REQUIRED)"),
+						"404": errorResponse("Referenced object not found"),
+						"408": errorResponse("Processing timeout"),
+						"409": errorResponse("Session in use, or a request with the same Idempotency-Key in progress (IDEMPOTENCY_KEY_IN_USE)"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": errorResponse("Frame decode budget exceeded (DECODE_BUDGET_EXCEEDED)"),
+						"423": lockedResponse,
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+						"500": errorResponse("Processing failed"),
+						"501": errorResponse("Async mode disabled (ASYNC_DISABLED), or verification by reference disabled or unable to delete objects"),
+						"502": errorResponse("Object store failure"),
+						"503": errorResponse("Async queue full (ASYNC_QUEUE_FULL), service shutting down (SHUTTING_DOWN) or too many verifications in progress (SERVER_BUSY)"),
+					},
+				},
+			},
+			"/api/v1/verify/ref": object{
+				"post": object{
+					"operationId": "verifyReference",
+					"summary":     "Verify a capture uploaded to object storage",
+					"requestBody": jsonBody(ref("ObjectReference")),
+					"responses": object{
+						"200": verifyResponse,
+						"400": errorResponse("Invalid input"),
+						"403": errorResponse("Object outside the allowed bucket or prefix"),
+						"404": errorResponse("Object not found"),
+						"422": errorResponse("Frame decode budget exceeded (DECODE_BUDGET_EXCEEDED)"),
+						"423": lockedResponse,
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+						"501": errorResponse("Verification by reference disabled, or delete_object with a store that cannot delete"),
+						"503": errorResponse("Too many verifications in progress (SERVER_BUSY)"),
+						"502": errorResponse("Object store failure"),
+					},
+				},
+			},
+			"/api/v1/uploads": object{
+				"post": object{
+					"operationId": "createUpload",
+					"summary":     "Issue a pre-signed URL to upload a capture to object storage",
+					"requestBody": jsonBody(objectSchema(object{
+						"content_type": object{"type": "string", "enum": []string{"video/webm", "video/mp4", "video/quicktime"}},
+					}, "content_type")),
+					"responses": object{
+						"201": response("Upload URL issued", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("ObjectUpload"),
+						})),
+						"400": errorResponse("Unsupported content type (INVALID_CONTENT_TYPE)"),
+						"501": errorResponse("Object store disabled or unable to pre-sign uploads (UPLOADS_UNSUPPORTED)"),
+					},
+				},
+			},
+			"/api/v1/verify/frames": object{
+				"post": object{
+					"operationId": "verifyFrames",
+					"summary":     "Verify client pre-extracted JPEG frames without video decoding",
+					"parameters": []object{
+						header("Accept-Language", "Language for reason_message"),
+					},
+					"requestBody": multipartBody(object{
+						"frame": object{
+							"type":        "array",
+							"items":       object{"type": "string", "format": "binary"},
+							"description": "JPEG frames of equal size (MIN_SUBMITTED_FRAMES to MAX_SUBMITTED_FRAMES, each at most MAX_FRAME_SIZE bytes)",
+						},
+						"user_id":          schema("string", ""),
+						"session_id":       schema("string", ""),
+						"action":           schema("string", ""),
+						"region":           schema("string", ""),
+						"liveness_session": schema("string", ""),
+						"liveness_nonce":   schema("string", ""),
+					}, "frame"),
+					"responses": object{
+						"200": verifyResponse,
+						"304": object{"description": "Unchanged decision for an identical submission"},
+						"400": errorResponse("Invalid frame count, size or encoding"),
+						"409": errorResponse("Session in use"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"423": lockedResponse,
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+						"501": errorResponse("Frame submission disabled"),
+						"503": errorResponse("Too many verifications in progress (SERVER_BUSY)"),
+					},
+				},
+			},
+			"/api/v1/verify/image": object{
+				"post": object{
+					"operationId": "verifyImage",
+					"summary":     "Match a single selfie image without liveness",
+					"description": "Liveness is not assessed: results carry liveness_method \"none\". Risk scoring and manual review do not apply.",
+					"parameters": []object{
+						header("Accept-Language", "Language for reason_message"),
+					},
+					"requestBody": multipartBody(object{
+						"image": object{
+							"type":        "string",
+							"format":      "binary",
+							"description": "JPEG or PNG selfie, at most MAX_IMAGE_SIZE bytes",
+						},
+						"user_id": schema("string", ""),
+						"region":  schema("string", ""),
+					}, "image", "user_id"),
+					"responses": object{
+						"200": verifyResponse,
+						"400": errorResponse("Missing or invalid image or user ID, or a liveness session is required (LIVENESS_SESSION_REQUIRED)"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": errorResponse("No face in the image (NO_FACE_IN_VIDEO)"),
+						"423": lockedResponse,
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+						"501": errorResponse("Image verification disabled (IMAGE_VERIFICATION_DISABLED)"),
+						"503": errorResponse("Too many verifications in progress (SERVER_BUSY)"),
+					},
+				},
+			},
+			"/api/v1/verify/live": object{
+				"get": object{
+					"operationId": "verifyLive",
+					"summary":     "Verify frames streamed over a WebSocket with live progress",
+					"description": "Upgrades to a WebSocket. Send each JPEG frame as a binary message and {\"type\":\"finish\"} as text when done. " +
+						"Each frame is answered with {\"type\":\"progress\",\"data\":LiveProgress}; the connection closes after " +
+						"{\"type\":\"result\",\"data\":VerificationResult} or {\"type\":\"error\",\"error\":...,\"code\":...}.",
+					"parameters": []object{
+						header("Accept-Language", "Language for reason_message"),
+						queryParam("user_id", "string", "User to match against"),
+						queryParam("session_id", "string", "Client session identifier"),
+						queryParam("action", "string", "Declared head movement"),
+						queryParam("region", "string", "Client region"),
+						queryParam("liveness_session", "string", "Active liveness session token"),
+						queryParam("liveness_nonce", "string", "Nonce of the liveness session"),
+					},
+					"responses": object{
+						"101": object{"description": "Switching to the WebSocket protocol"},
+						"400": errorResponse("Invalid input"),
+						"409": errorResponse("Session in use"),
+						"423": lockedResponse,
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+						"501": errorResponse("Live verification disabled (LIVE_VERIFICATION_DISABLED)"),
+					},
+				},
+			},
+			"/api/v1/liveness/session": object{
+				"post": object{
+					"operationId": "startLivenessSession",
+					"summary":     "Issue an active liveness challenge for the next capture",
+					"responses": object{
+						"200": response("Challenge issued", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("LivenessSession"),
+						})),
+					},
+				},
+			},
+			"/api/v1/verify/precheck": object{
+				"post": object{
+					"operationId": "precheckLiveness",
+					"summary":     "Phase one: liveness only, returns a continuation token",
+					"requestBody": multipartBody(object{
+						"video":      video,
+						"session_id": schema("string", ""),
+						"region":     schema("string", ""),
+					}, "video"),
+					"responses": object{
+						"200": response("Liveness decision", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("PrecheckResult"),
+						})),
+						"400": errorResponse("Invalid input"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"501": errorResponse("Pre-check disabled"),
+					},
+				},
+			},
+			"/api/v1/verify/continue": object{
+				"post": object{
+					"operationId": "continueVerification",
+					"summary":     "Phase two: match the frames cached by the pre-check",
+					"requestBody": jsonBody(objectSchema(object{
+						"continuation_token": schema("string", ""),
+						"user_id":            schema("string", ""),
+					}, "continuation_token")),
+					"responses": object{
+						"200": verifyResponse,
+						"400": errorResponse("Invalid input"),
+						"410": errorResponse("Continuation token unknown or expired"),
+						"423": lockedResponse,
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+					},
+				},
+			},
+			"/api/v1/status/{id}": object{
+				"get": object{
+					"operationId": "getVerificationStatus",
+					"summary":     "Status of a verification; scores only for admins",
+					"parameters": []object{
+						pathParam("id", "Verification ID"),
+						header("X-Admin-Key", "Admin key for unredacted results"),
+					},
+					"responses": object{
+						"200": response("Verification status", verificationStatus),
+						"400": errorResponse("Invalid verification ID"),
+						"404": errorResponse("Unknown verification ID"),
+					},
+				},
+			},
+			"/api/v1/verify/{id}/events": object{
+				"get": object{
+					"operationId": "streamVerificationEvents",
+					"summary":     "Stream the progress of a verification as Server-Sent Events",
+					"description": "Sends a \"progress\" event each time the verification passes a stage or changes status, then a \"result\" event once it is " +
+						"completed, failed or held for review, and ends. Each event's data is the status body of /api/v1/status/{id}. " +
+						"Idle streams carry a comment every 15 seconds.",
+					"parameters": []object{
+						pathParam("id", "Verification ID"),
+						header("X-Admin-Key", "Admin key for unredacted results"),
+					},
+					"responses": object{
+						"200": object{
+							"description": "Event stream",
+							"content": object{
+								"text/event-stream": object{"schema": verificationStatus},
+							},
+						},
+						"400": errorResponse("Invalid verification ID"),
+						"404": errorResponse("Unknown verification ID"),
+					},
+				},
+			},
+			"/api/v1/register": object{
+				"post": object{
+					"operationId": "registerFace",
+					"summary":     "Enroll a user's face",
+					"parameters":  []object{idempotencyKey},
+					"requestBody": multipartBody(object{
+						"video": object{
+							"type":        "array",
+							"items":       object{"type": "string", "format": "binary"},
+							"maxItems":    5,
+							"description": "One to five captures of the same face, e.g. from different angles, together at most MAX_UPLOAD_SIZE bytes",
+						},
+						"user_id": schema("string", ""),
+						"duplicate_check": object{
+							"type":        "string",
+							"enum":        []string{"off", "flag", "reject"},
+							"description": "Look for the face under other user IDs; never more lenient than DUPLICATE_IDENTITY_CHECK",
+						},
+					}, "video", "user_id"),
+					"responses": object{
+						"200": response("Face registered", objectSchema(object{
+							"success":            schema("boolean", ""),
+							"message":            schema("string", ""),
+							"user_id":            schema("string", ""),
+							"templates":          schema("integer", "Templates the user has after fusion (ENROLLMENT_FUSION)"),
+							"timestamp":          object{"type": "string", "format": "date-time"},
+							"duplicate_identity": schema("boolean", "Set when the face is enrolled under another user and duplicates are only flagged"),
+						})),
+						"400": errorResponse("Invalid input"),
+						"403": errorResponse("Enrollment disabled (ENROLLMENT_DISABLED)"),
+						"409": errorResponse("Face enrolled under another user (DUPLICATE_IDENTITY), or a request with the same Idempotency-Key in progress (IDEMPOTENCY_KEY_IN_USE)"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": response("Face quality too low to enroll, or videos of different faces (CAPTURES_MISMATCH, without quality)", objectSchema(object{
+							"error": schema("string", ""),
+							"code": object{
+								"type": "string",
+								"enum": []string{"FACE_TOO_SMALL", "IMAGE_TOO_DARK", "IMAGE_TOO_BRIGHT", "IMAGE_TOO_BLURRY", "FACE_NOT_FRONTAL", "CAPTURES_MISMATCH"},
+							},
+							"message": schema("string", "Localized guidance for code"),
+							"quality": ref("FaceQuality"),
+						}, "error", "code")),
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+						"500": errorResponse("Registration failed"),
+						"503": errorResponse("Too many verifications in progress (SERVER_BUSY)"),
+					},
+				},
+			},
+			"/api/v1/template": object{
+				"post": object{
+					"operationId": "extractTemplate",
+					"summary":     "Extract a compact face template from a live capture",
+					"parameters": []object{
+						queryParam("format", "string", "binary for a raw application/octet-stream body"),
+					},
+					"requestBody": multipartBody(object{"video": video}, "video"),
+					"responses": object{
+						"200": response("Base64 template", objectSchema(object{
+							"success":  schema("boolean", ""),
+							"template": schema("string", "Base64-encoded template"),
+							"version":  schema("integer", ""),
+							"size":     schema("integer", ""),
+						})),
+						"400": errorResponse("Invalid input"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": errorResponse("Liveness check failed"),
+					},
+				},
+			},
+			"/api/v1/match": object{
+				"post": object{
+					"operationId": "matchTemplate",
+					"summary":     "Match a compact template against a user's enrollment",
+					"requestBody": jsonBody(objectSchema(object{
+						"user_id":  schema("string", ""),
+						"template": schema("string", "Base64-encoded template"),
+					}, "user_id", "template")),
+					"responses": object{
+						"200": response("Match decision", objectSchema(object{
+							"success":    schema("boolean", ""),
+							"user_id":    schema("string", ""),
+							"matched":    schema("boolean", ""),
+							"confidence": schema("number", ""),
+						})),
+						"400": errorResponse("Invalid template"),
+						"404": errorResponse("User not enrolled"),
+						"409": errorResponse("Enrollment not active yet (ENROLLMENT_NOT_YET_ACTIVE) or made by other recognizer models (REENROLLMENT_REQUIRED)"),
+						"429": errorResponse("Monthly quota exceeded (QUOTA_EXCEEDED)"),
+					},
+				},
+			},
+			"/api/v1/identify": object{
+				"post": object{
+					"operationId": "identify",
+					"summary":     "Find the enrolled users closest to a live capture (1:N)",
+					"requestBody": multipartBody(object{
+						"video":       video,
+						"max_results": object{"type": "integer", "minimum": 1, "maximum": 100, "default": 5},
+					}, "video"),
+					"responses": object{
+						"200": response("Closest users, best first", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data": objectSchema(object{
+								"matches": object{"type": "array", "items": ref("GalleryMatch")},
+							}, "matches"),
+						}, "success", "data")),
+						"400": errorResponse("Invalid input (INVALID_MAX_RESULTS)"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": errorResponse("Liveness check failed"),
+						"500": errorResponse("Identification failed"),
+					},
+				},
+			},
+			"/api/v1/compare": object{
+				"post": object{
+					"operationId": "compareFaces",
+					"summary":     "Compare a selfie capture with a document photo (1:1, nothing stored)",
+					"requestBody": multipartBody(object{
+						"video":    video,
+						"document": object{"type": "string", "format": "binary", "description": "JPEG or PNG photo (max COMPARE_MAX_DOCUMENT_SIZE bytes)"},
+					}, "video", "document"),
+					"responses": object{
+						"200": response("Comparison decision", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("FaceComparison"),
+						}, "success", "data")),
+						"400": errorResponse("Missing or invalid upload"),
+						"408": errorResponse("Processing timeout (PROCESSING_TIMEOUT)"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": errorResponse("No face found (NO_FACE_IN_VIDEO, NO_FACE_IN_DOCUMENT)"),
+						"500": errorResponse("Comparison failed"),
+						"503": errorResponse("Too many verifications in progress (SERVER_BUSY)"),
+					},
+				},
+			},
+			"/api/v1/verify/document": object{
+				"post": object{
+					"operationId": "verifyDocument",
+					"summary":     "Verify a selfie capture against an ID card or passport, reading its machine-readable zone",
+					"requestBody": multipartBody(object{
+						"video":    video,
+						"document": object{"type": "string", "format": "binary", "description": "JPEG or PNG photo of the document's data page (max COMPARE_MAX_DOCUMENT_SIZE bytes)"},
+					}, "video", "document"),
+					"responses": object{
+						"200": response("Comparison decision and document contents", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("DocumentVerification"),
+						}, "success", "data")),
+						"400": errorResponse("Missing or invalid upload"),
+						"408": errorResponse("Processing timeout (PROCESSING_TIMEOUT)"),
+						"413": errorResponse("Upload larger than MAX_UPLOAD_SIZE (UPLOAD_TOO_LARGE)"),
+						"422": errorResponse("No face found (NO_FACE_IN_VIDEO, NO_FACE_IN_DOCUMENT)"),
+						"500": errorResponse("Verification failed"),
+						"503": errorResponse("Too many verifications in progress (SERVER_BUSY)"),
+					},
+				},
+			},
+			"/api/v1/faces/{user_id}": object{
+				"get": object{
+					"operationId": "getFaceTemplates",
+					"summary":     "Number of face templates enrolled for a user of the current recognizer models",
+					"parameters": []object{
+						pathParam("user_id", "Enrolled user"),
+					},
+					"responses": object{
+						"200": response("Enrolled templates", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data": objectSchema(object{
+								"user_id":               schema("string", ""),
+								"templates":             schema("integer", ""),
+								"reenrollment_required": schema("boolean", "Every template was made by other recognizer models"),
+							}),
+						})),
+						"400": errorResponse("Invalid user ID"),
+						"404": errorResponse("User has no enrolled face (USER_NOT_ENROLLED)"),
+					},
+				},
+				"delete": object{
+					"operationId": "eraseUser",
+					"summary":     "Erase all enrollments, records and audit traces of a user",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						pathParam("user_id", "User whose data is erased"),
+					},
+					"responses": object{
+						"200": response("Deletion receipt", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("ErasureReceipt"),
+						})),
+						"400": errorResponse("Invalid user ID"),
+						"401": errorResponse("Admin key missing or wrong"),
+						"500": errorResponse("Erasure failed"),
+					},
+				},
+			},
+			"/api/v1/admin/verifications": object{
+				"get": object{
+					"operationId": "listVerifications",
+					"summary":     "Residency audit: recent records filtered by region",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						queryParam("processing_region", "string", ""),
+						queryParam("client_region", "string", ""),
+						queryParam("limit", "integer", "1-1000, default 100"),
+					},
+					"responses": object{
+						"200": response("Matching records, newest first", objectSchema(object{
+							"items": object{"type": "array", "items": ref("VerificationResult")},
+							"count": schema("integer", ""),
+						})),
+						"400": errorResponse("Invalid limit"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+			},
+			"/api/v1/admin/usage": object{
+				"get": object{
+					"operationId": "getUsage",
+					"summary":     "Metered verifications and registrations per API key for a month",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						queryParam("month", "string", "YYYY-MM in UTC, default the current month"),
+						queryParam("client_key", "string", "Only the API key with this fingerprint"),
+					},
+					"responses": object{
+						"200": response("Usage per API key", objectSchema(object{
+							"month": schema("string", ""),
+							"items": object{"type": "array", "items": ref("UsageRecord")},
+							"count": schema("integer", ""),
+						})),
+						"400": errorResponse("Invalid month (INVALID_MONTH)"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+			},
+			"/api/v1/admin/reviews": object{
+				"get": object{
+					"operationId": "listReviews",
+					"summary":     "Verifications held for manual review, oldest first",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						queryParam("status", "string", "pending (default), approved, rejected or all"),
+					},
+					"responses": object{
+						"200": response("Review cases", objectSchema(object{
+							"items": object{"type": "array", "items": ref("ReviewCase")},
+							"count": schema("integer", ""),
+						})),
+						"400": errorResponse("Invalid status"),
+						"401": errorResponse("Admin key missing or wrong"),
+						"403": errorResponse("Credentials lack the reviewer role (ROLE_REQUIRED)"),
+					},
+				},
+			},
+			"/api/v1/admin/reviews/{id}": object{
+				"get": object{
+					"operationId": "getReview",
+					"summary":     "A review case with its evidence",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters":  []object{pathParam("id", "Verification ID")},
+					"responses": object{
+						"200": response("Review case", ref("ReviewEvidence")),
+						"401": errorResponse("Admin key missing or wrong"),
+						"403": errorResponse("Credentials lack the reviewer role (ROLE_REQUIRED)"),
+						"404": errorResponse("Case not found (REVIEW_NOT_FOUND)"),
+					},
+				},
+			},
+			"/api/v1/admin/reviews/{id}/approve": object{
+				"post": reviewDecisionOperation("approveReview", "Complete a pending review case as verified"),
+			},
+			"/api/v1/admin/reviews/{id}/reject": object{
+				"post": reviewDecisionOperation("rejectReview", "Complete a pending review case as not verified (REVIEW_REJECTED)"),
+			},
+			"/api/v1/admin/watchlist": object{
+				"get": object{
+					"operationId": "listWatchlist",
+					"summary":     "The tenant's watchlist, oldest entry first",
+					"security":    []object{{"adminKey": []string{}}},
+					"responses": object{
+						"200": response("Watchlist entries", objectSchema(object{
+							"items": object{"type": "array", "items": ref("WatchlistEntry")},
+							"count": schema("integer", ""),
+						})),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+				"post": object{
+					"operationId": "addWatchlistEntry",
+					"summary":     "Put a face on the tenant's watchlist",
+					"security":    []object{{"adminKey": []string{}}},
+					"requestBody": jsonBody(objectSchema(object{
+						"template": schema("string", "Base64 compact template, as returned by /template"),
+						"user_id":  schema("string", "Enrolled user whose latest enrollment is added instead"),
+						"label":    schema("string", "At most 128 characters"),
+						"reason":   schema("string", ""),
+					}, "label")),
+					"responses": object{
+						"201": response("Entry added", ref("WatchlistEntry")),
+						"400": errorResponse("Label missing, not exactly one of template and user_id, or invalid template"),
+						"401": errorResponse("Admin key missing or wrong"),
+						"404": errorResponse("User has no enrolled face"),
+					},
+				},
+			},
+			"/api/v1/admin/watchlist/{id}": object{
+				"delete": object{
+					"operationId": "deleteWatchlistEntry",
+					"summary":     "Take an entry off the tenant's watchlist",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters":  []object{pathParam("id", "Watchlist entry ID")},
+					"responses": object{
+						"200": response("Entry removed", objectSchema(object{
+							"id":      schema("string", ""),
+							"deleted": schema("boolean", ""),
+						})),
+						"401": errorResponse("Admin key missing or wrong"),
+						"404": errorResponse("Entry not found (WATCHLIST_ENTRY_NOT_FOUND)"),
+					},
+				},
+			},
+			"/api/v1/admin/webhooks": object{
+				"get": object{
+					"operationId": "listWebhookDeliveries",
+					"summary":     "Tracked result webhook deliveries, newest first",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						queryParam("status", "string", "pending, delivered or failed"),
+						queryParam("verification_id", "string", ""),
+					},
+					"responses": object{
+						"200": response("Deliveries", objectSchema(object{
+							"items": object{"type": "array", "items": ref("WebhookDelivery")},
+							"count": schema("integer", ""),
+						})),
+						"400": errorResponse("Invalid status"),
+						"401": errorResponse("Admin key missing or wrong"),
+						"501": errorResponse("Webhooks not configured"),
+					},
+				},
+			},
+			"/api/v1/admin/webhooks/{id}/redeliver": object{
+				"post": object{
+					"operationId": "redeliverWebhook",
+					"summary":     "Re-send a delivered or failed webhook",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						pathParam("id", "Delivery ID"),
+					},
+					"responses": object{
+						"202": response("Redelivery started", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("WebhookDelivery"),
+						})),
+						"401": errorResponse("Admin key missing or wrong"),
+						"404": errorResponse("Delivery not found"),
+						"409": errorResponse("Delivery still in progress"),
+						"501": errorResponse("Webhooks not configured"),
+					},
+				},
+			},
+			"/api/v1/admin/audit": object{
+				"get": object{
+					"operationId": "queryAuditLog",
+					"summary":     "Biometric audit trail of register, verify, identify, compare and delete operations",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						queryParam("user_id", "string", ""),
+						queryParam("operation", "string", "register, verify, identify, delete, compare or template_refresh"),
+						queryParam("from", "string", "Inclusive RFC 3339 start"),
+						queryParam("to", "string", "Exclusive RFC 3339 end"),
+						queryParam("limit", "integer", "Default 100, max 1000"),
+					},
+					"responses": object{
+						"200": response("Matching events, newest first", objectSchema(object{
+							"items": object{"type": "array", "items": ref("AuditEvent")},
+							"count": schema("integer", ""),
+						})),
+						"400": errorResponse("Invalid range, operation or limit"),
+						"401": errorResponse("Admin key missing or wrong"),
+						"501": errorResponse("Audit log disabled"),
+					},
+				},
+			},
+			"/api/v1/admin/audit/export": object{
+				"get": object{
+					"operationId": "exportAudit",
+					"summary":     "Compliance export of verification records",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters": []object{
+						queryParam("from", "string", "Inclusive RFC 3339 start"),
+						queryParam("to", "string", "Exclusive RFC 3339 end"),
+						queryParam("format", "string", "csv or json (default AUDIT_EXPORT_FORMAT)"),
+						queryParam("fields", "string", "Comma-separated non-biometric columns (default AUDIT_EXPORT_FIELDS)"),
+					},
+					"responses": object{
+						"200": response("Export with integrity manifest", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("AuditExport"),
+						})),
+						"400": errorResponse("Invalid range, format or field"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+			},
+			"/api/v1/admin/selfbench": object{
+				"post": object{
+					"operationId": "selfBench",
+					"summary":     "Synthetic load test of this instance",
+					"security":    []object{{"adminKey": []string{}}},
+					"requestBody": object{
+						"required": false,
+						"content": jsonContent(objectSchema(object{
+							"requests":    schema("integer", "Default 50, at most SELFBENCH_MAX_REQUESTS"),
+							"concurrency": schema("integer", "Default 4, at most 32"),
+						})),
+					},
+					"responses": object{
+						"200": response("Benchmark report", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("SelfBenchReport"),
+						})),
+						"400": errorResponse("Parameters out of range"),
+						"401": errorResponse("Admin key missing or wrong"),
+						"403": errorResponse("Disabled in production"),
+						"429": errorResponse("Cooldown active or already running"),
+					},
+				},
+			},
+			"/api/v1/admin/keys/rotate": object{
+				"post": object{
+					"operationId": "rotateEncryptionKey",
+					"summary":     "Re-encrypt stored enrollments under the current key",
+					"security":    []object{{"adminKey": []string{}}},
+					"responses": object{
+						"200": response("Rotation report", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("KeyRotation"),
+						})),
+						"401": errorResponse("Admin key missing or wrong"),
+						"500": errorResponse("Stored data could not be re-encrypted"),
+						"501": errorResponse("Storage does not encrypt at rest"),
+					},
+				},
+			},
+			"/api/v1/admin/descriptors/migrate": object{
+				"post": object{
+					"operationId": "migrateDescriptors",
+					"summary":     "Stamp legacy templates with the current recognizer models and flag users to re-enroll",
+					"security":    []object{{"adminKey": []string{}}},
+					"responses": object{
+						"200": response("Migration report", objectSchema(object{
+							"success": schema("boolean", ""),
+							"data":    ref("DescriptorMigration"),
+						})),
+						"401": errorResponse("Admin key missing or wrong"),
+						"500": errorResponse("Stored templates could not be migrated"),
+					},
+				},
+			},
+			"/api/v1/admin/enrollment": object{
+				"get": object{
+					"operationId": "getEnrollment",
+					"summary":     "Whether enrollments are accepted",
+					"security":    []object{{"adminKey": []string{}}},
+					"responses": object{
+						"200": response("Enrollment state", ref("EnrollmentState")),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+				"put": object{
+					"operationId": "setEnrollment",
+					"summary":     "Open or close the enrollment window",
+					"security":    []object{{"adminKey": []string{}}},
+					"requestBody": jsonBody(ref("EnrollmentState")),
+					"responses": object{
+						"200": response("Enrollment state", ref("EnrollmentState")),
+						"400": errorResponse("enabled missing"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+			},
+			"/api/v1/admin/tenants/{tenant_id}/config": object{
+				"get": object{
+					"operationId": "getTenantConfig",
+					"summary":     "A tenant's configuration overrides and effective settings",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters":  []object{pathParam("tenant_id", "Tenant ID")},
+					"responses": object{
+						"200": response("Tenant configuration", ref("TenantConfigState")),
+						"400": errorResponse("Tenant has no API keys (UNKNOWN_TENANT)"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+				"put": object{
+					"operationId": "setTenantConfig",
+					"summary":     "Replace a tenant's configuration overrides",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters":  []object{pathParam("tenant_id", "Tenant ID")},
+					"requestBody": jsonBody(ref("TenantConfig")),
+					"responses": object{
+						"200": response("Tenant configuration", ref("TenantConfigState")),
+						"400": errorResponse("Invalid overrides (INVALID_TENANT_CONFIG) or unknown tenant"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+				"delete": object{
+					"operationId": "deleteTenantConfig",
+					"summary":     "Return a tenant to the configured settings",
+					"security":    []object{{"adminKey": []string{}}},
+					"parameters":  []object{pathParam("tenant_id", "Tenant ID")},
+					"responses": object{
+						"200": response("Tenant configuration", ref("TenantConfigState")),
+						"400": errorResponse("Tenant has no API keys (UNKNOWN_TENANT)"),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+			},
+			"/api/v1/admin/config": object{
+				"get": object{
+					"operationId": "getTuning",
+					"summary":     "Thresholds, detector weights and rate limits in effect",
+					"security":    []object{{"adminKey": []string{}}},
+					"responses": object{
+						"200": response("Tunable settings", ref("Tuning")),
+						"401": errorResponse("Admin key missing or wrong"),
+					},
+				},
+			},
+			"/api/v1/admin/config/reload": object{
+				"post": object{
+					"operationId": "reloadConfig",
+					"summary":     "Read the configuration again and apply its tunable settings",
+					"description": "Applies LIVENESS_THRESHOLD, SIMILARITY_THRESHOLD, LIVENESS_DETECTORS weights, RATE_LIMIT_PER_MINUTE and RATE_LIMIT_BURST, as SIGHUP does. Other settings need a restart.",
+					"security":    []object{{"adminKey": []string{}}},
+					"responses": object{
+						"200": response("Tunable settings now in effect", ref("Tuning")),
+						"401": errorResponse("Admin key missing or wrong"),
+						"422": errorResponse("Configuration is invalid and nothing was reloaded (INVALID_CONFIG)"),
+					},
+				},
+			},
+			"/api/v1/users/{id}/history": object{
+				"get": object{
+					"operationId": "getUserHistory",
+					"summary":     "A user's own verification history",
+					"security":    []object{{"bearerAuth": []string{}}},
+					"parameters": []object{
+						pathParam("id", "User ID (must match the token subject)"),
+						queryParam("page", "integer", ""),
+						queryParam("page_size", "integer", "At most 100"),
+					},
+					"responses": object{
+						"200": response("History page", objectSchema(object{
+							"user_id":   schema("string", ""),
+							"page":      schema("integer", ""),
+							"page_size": schema("integer", ""),
+							"total":     schema("integer", ""),
+							"items":     object{"type": "array", "items": ref("HistoryEntry")},
+						})),
+						"401": errorResponse("Missing or invalid token"),
+						"403": errorResponse("Token subject does not match the user"),
+					},
+				},
+			},
+		},
+		"components": object{
+			"securitySchemes": object{
+				"bearerAuth": object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"adminKey":   object{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+			},
+			"schemas": object{
+				"Error": objectSchema(object{
+					"error": schema("string", "Human-readable message"),
+					"code":  schema("string", "Machine-stable error code"),
+				}, "error", "code"),
+				"VerificationResult": objectSchema(object{
+					"verification_id": schema("string", ""),
+					"user_id":         schema("string", ""),
+					"tenant":          schema("string", "Tenant of the caller's API key, when tenancy is enabled"),
+					"verified":        schema("boolean", ""),
+					"confidence":      schema("number", "Calibrated match confidence"),
+					"raw_confidence":  schema("number", ""),
+					"liveness_score":  schema("number", ""),
+					"liveness_method": schema("string", "How liveness was assessed; none for /verify/image"),
+					"processing_time": schema("number", "Seconds"),
+					"timestamp":       object{"type": "string", "format": "date-time"},
+					"reason": object{
+						"type": "string",
+						"enum": []string{"CAMERA_BLOCKED", "ACTION_MISMATCH", "LIVENESS_FAILED", "LOW_SIMILARITY", "ENROLLMENT_NOT_YET_ACTIVE", "CHALLENGE_FAILED", "NEEDS_REVIEW", "REVIEW_REJECTED", "RISK_DENIED", "INJECTION_SUSPECTED", "NONCE_NOT_BOUND", "REENROLLMENT_REQUIRED"},
+					},
+					"reason_message":       schema("string", "Localized guidance for reason"),
+					"device":               schema("string", ""),
+					"processing_region":    schema("string", "Region that processed the capture"),
+					"client_region":        schema("string", "Region declared by the client"),
+					"error":                schema("string", ""),
+					"injection_risk":       schema("number", "Likelihood (0-1) the feed was injected rather than filmed, when injection detection is enabled"),
+					"injection_signals":    injectionSignals,
+					"warnings":             object{"type": "array", "items": ref("VerificationWarning")},
+					"attestation":          schema("string", "ES256 JWT over verification_id, user_id, tenant, verified, confidence and liveness_score; keys at /.well-known/jwks.json"),
+					"review":               ref("Review"),
+					"risk":                 ref("RiskAssessment"),
+					"original_resolution":  ref("Resolution"),
+					"processed_resolution": ref("Resolution"),
+					"analysis_cached":      schema("boolean", "Liveness and descriptor reused from an identical earlier upload"),
+				}, "verification_id", "verified", "confidence", "liveness_score", "processing_time", "timestamp"),
+				"Resolution": objectSchema(object{
+					"width":  schema("integer", "Pixels"),
+					"height": schema("integer", "Pixels"),
+				}, "width", "height"),
+				"Review": objectSchema(object{
+					"status":     object{"type": "string", "enum": []string{"pending", "approved", "rejected"}},
+					"triggers":   object{"type": "array", "items": object{"type": "string", "enum": []string{"confidence", "liveness", "risk"}}},
+					"reviewer":   schema("string", ""),
+					"note":       schema("string", ""),
+					"decided_at": object{"type": "string", "format": "date-time"},
+				}, "status", "triggers"),
+				"RiskAssessment": objectSchema(object{
+					"score":    schema("number", "Weighted sum of the signals, from 0 (no risk) to 1"),
+					"decision": object{"type": "string", "enum": []string{"approve", "review", "deny"}},
+					"signals": object{
+						"type":                 "object",
+						"description":          "Contribution of each signal (liveness, match, device, ip_reputation, velocity, watchlist) to the score",
+						"additionalProperties": schema("number", ""),
+					},
+				}, "score", "decision"),
+				"ReviewCase": object{
+					"allOf": []object{
+						objectSchema(object{
+							"verification_id": schema("string", ""),
+							"tenant":          schema("string", ""),
+							"user_id":         schema("string", ""),
+							"created_at":      object{"type": "string", "format": "date-time"},
+						}, "verification_id", "created_at"),
+						ref("Review"),
+					},
+				},
+				"ReviewEvidence": objectSchema(object{
+					"case":   ref("ReviewCase"),
+					"result": ref("VerificationResult"),
+					"liveness": objectSchema(object{
+						"is_live":      schema("boolean", ""),
+						"score":        schema("number", ""),
+						"features":     object{"type": "object", "additionalProperties": schema("number", "")},
+						"weights":      object{"type": "object", "additionalProperties": schema("number", "")},
+						"replay_score": schema("number", ""),
+					}),
+					"frame": object{"type": "string", "format": "byte", "description": "Base64 JPEG of the matched frame; dropped once the case is decided"},
+				}, "case", "result"),
+				"VerificationWarning": objectSchema(object{
+					"code": object{
+						"type": "string",
+						"enum": []string{"LOW_LIGHT", "FEW_FRAMES", "BORDERLINE_LIVENESS", "BORDERLINE_SIMILARITY", "WATCHLIST_HIT", "VELOCITY_EXCEEDED"},
+					},
+					"message": schema("string", ""),
+				}, "code", "message"),
+				"LivenessSession": objectSchema(object{
+					"session_token": schema("string", "Send as liveness_session with the capture"),
+					"challenge":     object{"type": "string", "enum": []string{"blink", "smile", "turn_left", "turn_right"}},
+					"nonce":         schema("string", "Send as liveness_nonce with the capture"),
+					"color_sequence": object{
+						"type":        "array",
+						"items":       object{"type": "string", "enum": []string{"red", "green", "blue"}},
+						"description": "With NONCE_BINDING_ENABLED, screen colors to flash in order while recording",
+					},
+					"expires_at": object{"type": "string", "format": "date-time"},
+				}, "session_token", "challenge", "nonce", "expires_at"),
+				"ObjectReference": objectSchema(object{
+					"object_key":       schema("string", "Key of the capture; must start with OBJECT_KEY_PREFIX"),
+					"url":              schema("string", "s3://bucket/key or gs://bucket/key of the capture, in place of object_key"),
+					"delete_object":    schema("boolean", "Delete the object once the verification has run"),
+					"user_id":          schema("string", ""),
+					"session_id":       schema("string", ""),
+					"device":           schema("string", ""),
+					"action":           schema("string", ""),
+					"region":           schema("string", ""),
+					"liveness_session": schema("string", ""),
+					"liveness_nonce":   schema("string", ""),
+				}),
+				"ObjectUpload": objectSchema(object{
+					"object_key": schema("string", ""),
+					"url":        schema("string", "s3:// or gs:// URL to verify once uploaded"),
+					"upload_url": schema("string", "Pre-signed URL to send the capture to"),
+					"method":     schema("string", "Always PUT"),
+					"headers":    object{"type": "object", "additionalProperties": schema("string", ""), "description": "Headers the upload must carry"},
+					"expires_at": object{"type": "string", "format": "date-time"},
+				}, "object_key", "url", "upload_url", "method", "headers", "expires_at"),
+				"PrecheckResult": objectSchema(object{
+					"verification_id":    schema("string", ""),
+					"is_live":            schema("boolean", ""),
+					"liveness_score":     schema("number", ""),
+					"injection_risk":     schema("number", ""),
+					"injection_signals":  injectionSignals,
+					"reason":             schema("string", ""),
+					"continuation_token": schema("string", ""),
+					"expires_at":         object{"type": "string", "format": "date-time"},
+					"processing_time":    schema("number", ""),
+				}, "verification_id", "is_live", "liveness_score"),
+				"LiveProgress": objectSchema(object{
+					"frames":         schema("integer", "Frames received so far"),
+					"liveness_score": schema("number", "Liveness over every frame so far"),
+					"is_live":        schema("boolean", ""),
+					"face_detected":  schema("boolean", "Whether the latest frame shows a face"),
+					"confidence":     schema("number", "Match confidence of the latest frame, with user_id"),
+				}, "frames", "liveness_score", "is_live", "face_detected"),
+				"WebhookDelivery": objectSchema(object{
+					"id":               schema("string", ""),
+					"verification_id":  schema("string", ""),
+					"url":              schema("string", ""),
+					"status":           object{"type": "string", "enum": []string{"pending", "delivered", "failed"}},
+					"attempts":         schema("integer", ""),
+					"last_status_code": schema("integer", ""),
+					"last_error":       schema("string", ""),
+					"created_at":       object{"type": "string", "format": "date-time"},
+					"delivered_at":     object{"type": "string", "format": "date-time"},
+				}, "id", "verification_id", "status", "attempts", "created_at"),
+				"SelfBenchReport": objectSchema(object{
+					"requests":         schema("integer", ""),
+					"concurrency":      schema("integer", ""),
+					"errors":           schema("integer", ""),
+					"error_rate":       schema("number", ""),
+					"duration_seconds": schema("number", ""),
+					"throughput_rps":   schema("number", ""),
+					"latency_min_ms":   schema("number", ""),
+					"latency_p50_ms":   schema("number", ""),
+					"latency_p95_ms":   schema("number", ""),
+					"latency_p99_ms":   schema("number", ""),
+					"latency_max_ms":   schema("number", ""),
+				}),
+				"AuditExport": objectSchema(object{
+					"manifest": objectSchema(object{
+						"format":         schema("string", ""),
+						"fields":         object{"type": "array", "items": schema("string", "")},
+						"from":           object{"type": "string", "format": "date-time"},
+						"to":             object{"type": "string", "format": "date-time"},
+						"record_count":   schema("integer", ""),
+						"generated_at":   object{"type": "string", "format": "date-time"},
+						"content_sha256": schema("string", "Hex SHA-256 of content"),
+						"signature":      schema("string", "Hex HMAC-SHA256 of the manifest with signature empty"),
+					}, "format", "fields", "record_count", "content_sha256"),
+					"content": schema("string", "CSV or JSON export"),
+				}, "manifest", "content"),
+				"AuditEvent": objectSchema(object{
+					"id":              schema("string", ""),
+					"timestamp":       object{"type": "string", "format": "date-time"},
+					"operation":       object{"type": "string", "enum": []string{"register", "verify", "identify", "delete", "compare", "template_refresh"}},
+					"user_id":         schema("string", ""),
+					"verification_id": schema("string", ""),
+					"result":          schema("string", "success, verified, not_verified, match, no_match, accepted, rejected or error"),
+					"client_key":      schema("string", "Fingerprint of the caller's X-API-Key"),
+					"client_identity": schema("string", "Identity of the caller's client certificate"),
+					"client_ip":       schema("string", ""),
+					"admin":           schema("boolean", ""),
+					"transport":       schema("string", "http, grpc, queue or internal"),
+				}, "id", "timestamp", "operation", "result", "transport"),
+				"ErasureReceipt": objectSchema(object{
+					"user_id":            schema("string", ""),
+					"erased_at":          object{"type": "string", "format": "date-time"},
+					"records":            schema("integer", "Total of enrollments, verifications, webhook deliveries and outbox events erased"),
+					"enrollments":        schema("integer", ""),
+					"verifications":      schema("integer", ""),
+					"webhook_deliveries": schema("integer", ""),
+					"outbox_events":      schema("integer", "Events dropped before being published to Kafka"),
+					"crypto_shredded":    schema("boolean", "The user's per-user key was destroyed"),
+				}, "user_id", "erased_at", "records"),
+				"GalleryMatch": objectSchema(object{
+					"user_id":    schema("string", ""),
+					"similarity": schema("number", "Similarity of the user's closest enrollment"),
+				}, "user_id", "similarity"),
+				"FaceComparison": objectSchema(object{
+					"match":           schema("boolean", "Live selfie whose face matches the document"),
+					"similarity":      schema("number", "Raw cosine similarity"),
+					"confidence":      schema("number", "Calibrated similarity"),
+					"is_live":         schema("boolean", ""),
+					"liveness_score":  schema("number", ""),
+					"reason":          object{"type": "string", "enum": []string{"LIVENESS_FAILED", "LOW_SIMILARITY"}},
+					"processing_time": schema("number", "Seconds"),
+				}, "match", "similarity", "confidence", "is_live", "liveness_score", "processing_time"),
+				"DocumentVerification": objectSchema(object{
+					"match":           schema("boolean", "Live selfie whose face matches the document portrait"),
+					"similarity":      schema("number", "Raw cosine similarity"),
+					"confidence":      schema("number", "Calibrated similarity"),
+					"is_live":         schema("boolean", ""),
+					"liveness_score":  schema("number", ""),
+					"reason":          object{"type": "string", "enum": []string{"LIVENESS_FAILED", "LOW_SIMILARITY"}},
+					"processing_time": schema("number", "Seconds"),
+					"portrait":        ref("PortraitCrop"),
+					"fields":          ref("DocumentFields"),
+					"text":            object{"type": "array", "items": schema("string", ""), "description": "OCR output, one entry per line"},
+					"warnings":        object{"type": "array", "items": ref("VerificationWarning"), "description": "OCR_FAILED, MRZ_NOT_FOUND, MRZ_CHECK_FAILED or DOCUMENT_EXPIRED"},
+				}, "match", "similarity", "confidence", "is_live", "liveness_score", "processing_time"),
+				"DocumentFields": objectSchema(object{
+					"format":          object{"type": "string", "enum": []string{"TD1", "TD2", "TD3"}},
+					"document_type":   schema("string", "P for passports, I, A or C for ID cards"),
+					"issuing_country": schema("string", "ICAO country code"),
+					"document_number": schema("string", ""),
+					"surname":         schema("string", ""),
+					"given_names":     schema("string", ""),
+					"nationality":     schema("string", "ICAO country code"),
+					"date_of_birth":   object{"type": "string", "format": "date"},
+					"sex":             schema("string", "M, F or empty"),
+					"expiry_date":     object{"type": "string", "format": "date"},
+					"optional_data":   schema("string", ""),
+					"checks_valid":    schema("boolean", "Every check digit matched"),
+					"mrz":             object{"type": "array", "items": schema("string", "")},
+				}, "format", "document_type", "issuing_country", "document_number", "surname", "given_names",
+					"nationality", "date_of_birth", "sex", "expiry_date", "checks_valid", "mrz"),
+				"PortraitCrop": objectSchema(object{
+					"x":      schema("integer", ""),
+					"y":      schema("integer", ""),
+					"width":  schema("integer", ""),
+					"height": schema("integer", ""),
+					"image":  object{"type": "string", "format": "byte", "description": "Base64-encoded JPEG"},
+				}, "x", "y", "width", "height", "image"),
+				"FaceQuality": objectSchema(object{
+					"face_size":  schema("number", "Face width as a fraction of the frame width"),
+					"sharpness":  schema("number", "Variance of the Laplacian over the face"),
+					"brightness": schema("number", "Mean luminance (0-1) of the face"),
+					"pose_angle": schema("number", "Estimated head turn or tilt in degrees"),
+				}, "face_size", "sharpness", "brightness", "pose_angle"),
+				"JSONWebKeySet": objectSchema(object{
+					"keys": object{"type": "array", "items": objectSchema(object{
+						"kty": schema("string", ""),
+						"crv": schema("string", ""),
+						"x":   schema("string", ""),
+						"y":   schema("string", ""),
+						"kid": schema("string", "Matches the kid header of attestation tokens"),
+						"use": schema("string", ""),
+						"alg": schema("string", ""),
+					}, "kty", "crv", "x", "y", "kid")},
+				}, "keys"),
+				"Liveness": objectSchema(object{
+					"status":    schema("string", ""),
+					"timestamp": object{"type": "string", "format": "date-time"},
+				}, "status", "timestamp"),
+				"Readiness": objectSchema(object{
+					"ready":      schema("boolean", ""),
+					"draining":   schema("boolean", "Set once shutdown began; no dependency is checked then"),
+					"warming_up": schema("boolean", "Set until the startup warmup finished; no dependency is checked then"),
+					"checks":     object{"type": "array", "items": ref("DependencyCheck")},
+					"timestamp":  object{"type": "string", "format": "date-time"},
+				}, "ready", "checks", "timestamp"),
+				"DependencyCheck": objectSchema(object{
+					"name":        schema("string", "recognizer, vector_store or object_store"),
+					"healthy":     schema("boolean", ""),
+					"error":       schema("string", "Why the check failed"),
+					"duration_ms": schema("integer", ""),
+				}, "name", "healthy", "duration_ms"),
+				"KeyRotation": objectSchema(object{
+					"key_id":          schema("string", "Key the data is now encrypted under"),
+					"previous_key_id": schema("string", "Key the data was encrypted under, \"legacy\" for data written before key IDs"),
+					"enrollments":     schema("integer", ""),
+					"rotated_at":      object{"type": "string", "format": "date-time"},
+				}, "key_id", "enrollments", "rotated_at"),
+				"DescriptorMigration": objectSchema(object{
+					"model_version":   schema("string", "Version of the recognizer models in use"),
+					"users":           schema("integer", ""),
+					"stamped":         schema("integer", "Legacy templates stamped with model_version"),
+					"stale_templates": schema("integer", "Templates of other recognizer models"),
+					"reenrollment_required": object{
+						"type":        "array",
+						"description": "Keys of users left without a template of model_version",
+						"items":       schema("string", ""),
+					},
+					"migrated_at": object{"type": "string", "format": "date-time"},
+				}, "model_version", "users", "stamped", "stale_templates", "migrated_at"),
+				"EnrollmentState": objectSchema(object{
+					"enabled": schema("boolean", ""),
+				}, "enabled"),
+				"UsageRecord": objectSchema(object{
+					"month":         schema("string", "YYYY-MM in UTC"),
+					"client_key":    schema("string", "Fingerprint of the API key; empty for callers without one"),
+					"tenant":        schema("string", ""),
+					"verifications": schema("integer", ""),
+					"registrations": schema("integer", ""),
+					"quota":         schema("integer", "Monthly limit on verifications and registrations together; 0 is unlimited"),
+				}, "month", "client_key", "verifications", "registrations", "quota"),
+				"WatchlistEntry": objectSchema(object{
+					"id":         schema("string", ""),
+					"tenant":     schema("string", ""),
+					"label":      schema("string", ""),
+					"reason":     schema("string", ""),
+					"created_at": object{"type": "string", "format": "date-time"},
+				}, "id", "label", "created_at"),
+				"TenantConfig": objectSchema(object{
+					"liveness_threshold":   schema("number", "Overrides LIVENESS_THRESHOLD"),
+					"similarity_threshold": schema("number", "Overrides SIMILARITY_THRESHOLD"),
+					"max_upload_size":      schema("integer", "Overrides MAX_UPLOAD_SIZE, in bytes"),
+					"liveness_detectors":   object{"type": "array", "items": schema("string", ""), "description": "Detectors of the liveness pipeline to score with; the others are skipped"},
+					"updated_at":           object{"type": "string", "format": "date-time", "readOnly": true},
+				}),
+				"TenantSettings": objectSchema(object{
+					"liveness_threshold":   schema("number", ""),
+					"similarity_threshold": schema("number", ""),
+					"max_upload_size":      schema("integer", "0 for the built-in 50 MB limit"),
+					"liveness_detectors":   object{"type": "array", "items": schema("string", "")},
+				}, "liveness_threshold", "similarity_threshold", "max_upload_size", "liveness_detectors"),
+				"TenantConfigState": objectSchema(object{
+					"tenant":    schema("string", ""),
+					"overrides": object{"allOf": []object{ref("TenantConfig")}, "nullable": true},
+					"effective": ref("TenantSettings"),
+				}, "tenant", "overrides", "effective"),
+				"Tuning": objectSchema(object{
+					"liveness_threshold":    schema("number", ""),
+					"similarity_threshold":  schema("number", ""),
+					"liveness_weights":      object{"type": "object", "additionalProperties": schema("number", ""), "description": "Weight of every liveness detector"},
+					"rate_limit_per_minute": schema("integer", "Default per-client rate limit"),
+					"rate_limit_burst":      schema("integer", ""),
+					"reloaded_at":           object{"type": "string", "format": "date-time", "description": "Unset until the first reload"},
+				}, "liveness_threshold", "similarity_threshold", "liveness_weights", "rate_limit_per_minute", "rate_limit_burst"),
+				"HistoryEntry": objectSchema(object{
+					"verification_id": schema("string", ""),
+					"timestamp":       object{"type": "string", "format": "date-time"},
+					"verified":        schema("boolean", ""),
+					"reason":          schema("string", ""),
+					"device":          schema("string", ""),
+				}, "verification_id", "timestamp", "verified"),
+			},
+		},
+	}
+}
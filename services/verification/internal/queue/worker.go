@@ -0,0 +1,428 @@
+// Package queue runs verifications taken from a job queue instead of HTTP
+// requests, so workers scale apart from the API tier. Jobs come from a NATS
+// JetStream pull consumer and name a capture in the object store; every
+// job's result is published to its reply subject.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/nats"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+const (
+	// pullWait is how long one pull request waits for a job
+	pullWait = 30 * time.Second
+	// inProgressInterval keeps a job from being redelivered while it runs;
+	// it must stay below the consumer's ack wait
+	inProgressInterval = 10 * time.Second
+	// retryDelay is how long a job that could not run yet waits
+	retryDelay          = 5 * time.Second
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// Job asks for the verification of a capture uploaded to the object store,
+// with the fields of POST /api/v1/verify/ref. Publishers are trusted to name
+// the tenant the job runs for; empty is the default tenant.
+type Job struct {
+	JobID           string `json:"job_id"`
+	ObjectKey       string `json:"object_key"`
+	URL             string `json:"url"`
+	Tenant          string `json:"tenant"`
+	UserID          string `json:"user_id"`
+	SessionID       string `json:"session_id"`
+	Device          string `json:"device"`
+	Action          string `json:"action"`
+	Region          string `json:"region"`
+	LivenessSession string `json:"liveness_session"`
+	LivenessNonce   string `json:"liveness_nonce"`
+	// Where the result goes; NATS_RESULT_SUBJECT when empty
+	ReplySubject string `json:"reply_subject"`
+}
+
+// Result is published for every job, in the shape of the REST response.
+type Result struct {
+	JobID   string                     `json:"job_id,omitempty"`
+	Success bool                       `json:"success"`
+	Data    *models.VerificationResult `json:"data,omitempty"`
+	Error   string                     `json:"error,omitempty"`
+	Code    string                     `json:"code,omitempty"`
+
+	// Set for jobs that could not run yet and go back on the queue
+	retry bool
+}
+
+// Worker consumes verification jobs on the same FaceVerificationService the
+// APIs use.
+type Worker struct {
+	faceService *services.FaceVerificationService
+	logger      *zap.Logger
+	cfg         *config.Config
+}
+
+// NewWorker checks the configuration the worker needs: a NATS server and an
+// object store to fetch captures from.
+func NewWorker(faceService *services.FaceVerificationService, logger *zap.Logger) (*Worker, error) {
+	cfg := faceService.Config()
+	if cfg.NATSURL == "" {
+		return nil, errors.New("NATS_URL is required for the worker")
+	}
+	if cfg.ObjectStoreType == "" {
+		return nil, errors.New("OBJECT_STORE_TYPE is required for the worker, which fetches captures by reference")
+	}
+	return &Worker{faceService: faceService, logger: logger, cfg: cfg}, nil
+}
+
+func concurrency(cfg *config.Config) int {
+	if cfg.WorkerConcurrency > 0 {
+		return cfg.WorkerConcurrency
+	}
+	return 4
+}
+
+// Run consumes jobs until ctx is done, reconnecting with backoff whenever
+// the connection to NATS fails. Jobs in progress are finished first.
+func (w *Worker) Run(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		connected, err := w.consume(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = time.Second
+		}
+		w.logger.Error("NATS connection failed, reconnecting", zap.Error(err), zap.Duration("backoff", backoff))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// consume runs the pull loops on one connection until it fails or ctx is
+// done, and reports whether it connected at all.
+func (w *Worker) consume(ctx context.Context) (bool, error) {
+	conn, err := nats.Connect(ctx, w.cfg.NATSURL, "verification-worker", 10*time.Second)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	stream, consumer := w.cfg.NATSStream, w.cfg.NATSConsumer
+	w.logger.Info("Consuming verification jobs",
+		zap.String("stream", stream),
+		zap.String("consumer", consumer),
+		zap.Int("concurrency", concurrency(w.cfg)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency(w.cfg); i++ {
+		pull, err := conn.PullConsumer(stream, consumer)
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.pull(ctx, conn, pull)
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-conn.Done():
+	}
+	wg.Wait()
+	return true, conn.Err()
+}
+
+func (w *Worker) pull(ctx context.Context, conn *nats.Conn, pull *nats.PullConsumer) {
+	for ctx.Err() == nil {
+		msg, err := pull.Next(ctx, pullWait)
+		if err != nil {
+			if ctx.Err() == nil && conn.Err() == nil {
+				// The consumer is misconfigured or gone; start over
+				w.logger.Error("Failed to pull verification job", zap.Error(err))
+				conn.Close()
+			}
+			return
+		}
+		if msg != nil {
+			w.handle(conn, msg)
+		}
+	}
+}
+
+// handle runs one job and settles it: the result is published and the job
+// acknowledged, or the job goes back on the queue when it could not run
+// yet. A job whose result cannot be published is redelivered.
+func (w *Worker) handle(conn *nats.Conn, msg *nats.Msg) {
+	metrics.QueueJobs.Add(1)
+
+	// Keep the job ours while it runs
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(inProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				msg.InProgress()
+			}
+		}
+	}()
+
+	var job Job
+	var result Result
+	if err := json.Unmarshal(msg.Data, &job); err != nil {
+		result = failure(apperrors.ErrInvalidRequest.WithMessage("Job is not valid JSON"))
+	} else {
+		result = w.run(&job)
+	}
+	result.JobID = job.JobID
+
+	if result.retry {
+		metrics.QueueJobRetries.Add(1)
+		msg.Nak(retryDelay)
+		return
+	}
+
+	subject := job.ReplySubject
+	if subject == "" || !validSubject(subject) {
+		subject = w.cfg.NATSResultSubject
+	}
+	if subject != "" {
+		if err := w.publish(conn, subject, &result); err != nil {
+			w.logger.Error("Failed to publish job result",
+				zap.Error(err),
+				zap.String("job_id", job.JobID),
+				zap.String("subject", subject))
+			msg.Nak(retryDelay)
+			return
+		}
+	}
+	if err := msg.Ack(); err != nil {
+		w.logger.Warn("Failed to acknowledge job", zap.Error(err), zap.String("job_id", job.JobID))
+	}
+}
+
+func (w *Worker) publish(conn *nats.Conn, subject string, result *Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := conn.Publish(subject, "", data); err != nil {
+		return err
+	}
+	// Acknowledge the job only once the server has the result
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return conn.Flush(ctx)
+}
+
+func failure(err *apperrors.Error) Result {
+	return Result{Success: false, Error: err.Message, Code: err.Code}
+}
+
+// run validates and verifies a job like POST /api/v1/verify/ref, and
+// records it in the audit log.
+func (w *Worker) run(job *Job) Result {
+	if result, ok := w.validate(job); !ok {
+		w.audit(job, nil, models.AuditRejected)
+		return result
+	}
+
+	// No client waits on the job, so only PROCESSING_TIMEOUT bounds it
+	ctx, cancel := context.WithTimeout(context.Background(), services.ProcessingTimeout(w.cfg))
+	defer cancel()
+
+	videoData, err := w.faceService.FetchReferencedVideo(ctx, job.ObjectKey)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvalidObjectKey):
+		return w.rejected(job, apperrors.ErrInvalidObjectKey)
+	case errors.Is(err, services.ErrObjectKeyOutOfScope):
+		w.logger.Warn("Object key outside allowed prefix", zap.String("object_key", job.ObjectKey))
+		return w.rejected(job, apperrors.ErrObjectKeyForbidden)
+	case errors.Is(err, storage.ErrObjectNotFound):
+		return w.rejected(job, apperrors.ErrObjectNotFound)
+	case errors.Is(err, storage.ErrObjectTooLarge):
+		return w.rejected(job, apperrors.ErrInvalidVideo.WithMessage("Referenced object too large. Maximum size is 50MB"))
+	default:
+		w.logger.Warn("Failed to fetch referenced object, retrying job", zap.Error(err), zap.String("object_key", job.ObjectKey))
+		return Result{retry: true}
+	}
+
+	sessionID := job.SessionID
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+	device := job.Device
+	if device == "" {
+		device = "queue"
+	}
+
+	// A redelivered job may still be running elsewhere under its session
+	releaseSession, err := w.faceService.AcquireSession(sessionID)
+	if err != nil {
+		return Result{retry: true}
+	}
+	defer releaseSession()
+
+	result, _, err := w.faceService.VerifyVideoDeduplicatedContext(ctx, &models.VerificationRequest{
+		VideoData:       videoData,
+		UserID:          job.UserID,
+		Tenant:          job.Tenant,
+		SessionID:       sessionID,
+		Device:          device,
+		Action:          job.Action,
+		Region:          job.Region,
+		LivenessSession: job.LivenessSession,
+		LivenessNonce:   job.LivenessNonce,
+	})
+	if err != nil {
+		return w.verifyError(ctx, job, err)
+	}
+
+	outcome := models.AuditNotVerified
+	if result.Verified {
+		outcome = models.AuditVerified
+	}
+	w.audit(job, result, outcome)
+	w.logger.Info("Queued verification completed",
+		zap.String("job_id", job.JobID),
+		zap.String("verification_id", result.VerificationID),
+		zap.Bool("verified", result.Verified))
+	return Result{Success: true, Data: result}
+}
+
+func (w *Worker) validate(job *Job) (Result, bool) {
+	if job.URL != "" {
+		if job.ObjectKey != "" {
+			return failure(apperrors.ErrInvalidRequest.WithMessage("Send either object_key or url, not both")), false
+		}
+		key, err := w.faceService.ObjectKeyFromURL(job.URL)
+		switch {
+		case errors.Is(err, services.ErrObjectKeyOutOfScope):
+			w.logger.Warn("Object URL outside allowed bucket", zap.String("url", job.URL))
+			return failure(apperrors.ErrObjectKeyForbidden), false
+		case err != nil:
+			return failure(apperrors.ErrInvalidObjectURL), false
+		}
+		job.ObjectKey = key
+	}
+
+	switch {
+	case job.ObjectKey == "":
+		return failure(apperrors.ErrInvalidObjectKey), false
+	case !w.faceService.Tenants().Known(job.Tenant):
+		return failure(apperrors.ErrUnknownTenant), false
+	case job.UserID != "" && !validUserID(job.UserID):
+		return failure(apperrors.ErrInvalidUserID), false
+	case job.Action != "" && !services.ValidAction(job.Action):
+		return failure(apperrors.ErrInvalidAction), false
+	case job.LivenessSession == "" && (w.cfg.LivenessChallengeRequired || w.cfg.NonceBindingEnabled):
+		return failure(apperrors.ErrLivenessSessionMissing), false
+	}
+	if job.Region != "" {
+		if err := w.faceService.ValidateClientRegion(job.Region); errors.Is(err, services.ErrRegionNotAllowed) {
+			return failure(apperrors.ErrRegionNotAllowed), false
+		} else if err != nil {
+			return failure(apperrors.ErrInvalidRegion), false
+		}
+	}
+	return Result{}, true
+}
+
+func (w *Worker) verifyError(ctx context.Context, job *Job, err error) Result {
+	switch {
+	case ctx.Err() != nil:
+		w.logger.Error("Verification timeout", zap.String("job_id", job.JobID))
+		w.audit(job, nil, models.AuditError)
+		return failure(apperrors.ErrTimeout)
+	case errors.Is(err, services.ErrServerBusy):
+		return Result{retry: true}
+	case errors.Is(err, services.ErrInvalidFrame):
+		return w.rejected(job, apperrors.ErrDecodeFailed)
+	case errors.Is(err, services.ErrLivenessSessionInvalid):
+		return w.rejected(job, apperrors.ErrLivenessSessionInvalid)
+	case errors.Is(err, services.ErrDecodeBudgetExceeded):
+		return w.rejected(job, apperrors.ErrDecodeBudgetExceeded)
+	case errors.Is(err, services.ErrLockedOut):
+		return w.rejected(job, apperrors.ErrLocked)
+	}
+	w.logger.Error("Video verification failed", zap.Error(err), zap.String("job_id", job.JobID))
+	w.audit(job, nil, models.AuditError)
+	return failure(apperrors.ErrVerificationFailed)
+}
+
+func (w *Worker) rejected(job *Job, err *apperrors.Error) Result {
+	w.audit(job, nil, models.AuditRejected)
+	return failure(err)
+}
+
+func (w *Worker) audit(job *Job, result *models.VerificationResult, outcome string) {
+	event := models.AuditEvent{
+		Operation: models.AuditVerify,
+		Tenant:    job.Tenant,
+		UserID:    job.UserID,
+		Result:    outcome,
+		Transport: "queue",
+	}
+	if result != nil {
+		event.VerificationID = result.VerificationID
+	}
+	w.faceService.RecordAudit(event)
+}
+
+// validUserID mirrors the REST rule: 1-64 alphanumerics, hyphens and underscores.
+func validUserID(userID string) bool {
+	if len(userID) < 1 || len(userID) > 64 {
+		return false
+	}
+	for _, char := range userID {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validSubject rejects reply subjects with wildcards, whitespace or empty
+// tokens, which cannot be published to.
+func validSubject(subject string) bool {
+	if strings.ContainsAny(subject, " \t\r\n*>") {
+		return false
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return false
+		}
+	}
+	return true
+}
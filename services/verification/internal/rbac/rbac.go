@@ -0,0 +1,91 @@
+// Package rbac defines the roles API credentials carry. Tenant API keys and
+// bearer tokens name theirs; keys and tenant client certificates that name
+// none act as ordinary clients with the Default roles, while a token naming
+// none holds no role. The admin key and admin client certificates hold
+// every role.
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Roles is a set of roles.
+type Roles uint8
+
+const (
+	// Verify allows verifications and the calls that prepare them
+	Verify Roles = 1 << iota
+	// Enroll allows registering faces and extracting templates
+	Enroll
+	// Reviewer allows deciding the manual review queue of the tenant
+	Reviewer
+	// Admin allows the admin endpoints, which span tenants, and implies
+	// every other role
+	Admin
+)
+
+// Default are the roles of credentials that name none.
+const Default = Verify | Enroll
+
+// All holds every role.
+const All = Verify | Enroll | Reviewer | Admin
+
+var names = []struct {
+	name string
+	role Roles
+}{
+	{"verify", Verify},
+	{"enroll", Enroll},
+	{"reviewer", Reviewer},
+	{"admin", Admin},
+}
+
+// Has reports whether r includes role, which Admin always does.
+func (r Roles) Has(role Roles) bool {
+	return r&Admin != 0 || r&role == role
+}
+
+func (r Roles) String() string {
+	var held []string
+	for _, n := range names {
+		if r&n.role != 0 {
+			held = append(held, n.name)
+		}
+	}
+	return strings.Join(held, "|")
+}
+
+// Parse parses a "|"-separated list of role names such as "verify|enroll".
+func Parse(spec string) (Roles, error) {
+	var roles Roles
+	for _, name := range strings.Split(spec, "|") {
+		role, ok := lookup(strings.TrimSpace(name))
+		if !ok {
+			return 0, fmt.Errorf("unknown role %q", name)
+		}
+		roles |= role
+	}
+	return roles, nil
+}
+
+// FromScopes returns the roles named among the scopes of a token, ignoring
+// the scopes that name none. A token naming no role holds none.
+func FromScopes(scopes []string) Roles {
+	var roles Roles
+	for _, scope := range scopes {
+		if role, ok := lookup(scope); ok {
+			roles |= role
+		}
+	}
+	return roles
+}
+
+func lookup(name string) (Roles, bool) {
+	for _, n := range names {
+		if n.name == name {
+			return n.role, true
+		}
+	}
+	return 0, false
+}
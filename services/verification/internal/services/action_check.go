@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+// Declared actions a client can claim for a capture. Directions are in image
+// coordinates as the camera recorded them (not a mirrored preview).
+const (
+	ActionTurnLeft  = "turn_left"
+	ActionTurnRight = "turn_right"
+	ActionLookUp    = "look_up"
+	ActionLookDown  = "look_down"
+)
+
+var ErrUnknownAction = errors.New("unknown declared action")
+var ErrActionMismatch = errors.New("observed motion does not match declared action")
+
+// motionGridWidth is the width frames are downsampled to before matching.
+const motionGridWidth = 64
+
+// ValidAction reports whether action is one the consistency check understands.
+func ValidAction(action string) bool {
+	switch action {
+	case ActionTurnLeft, ActionTurnRight, ActionLookUp, ActionLookDown:
+		return true
+	}
+	return false
+}
+
+// MeasureMotion estimates the dominant motion across the frames as a
+// displacement in fractions of the frame width and height. Consecutive frames
+// are block-matched on a coarse luminance grid and the shifts are summed.
+func MeasureMotion(frames []image.Image) (float64, float64) {
+	if len(frames) < 2 {
+		return 0.0, 0.0
+	}
+
+	prev, w, h := luminanceGrid(frames[0])
+	if w == 0 || h == 0 {
+		return 0.0, 0.0
+	}
+
+	totalDX, totalDY := 0, 0
+	for _, frame := range frames[1:] {
+		next, nw, nh := luminanceGrid(frame)
+		if nw != w || nh != h {
+			continue
+		}
+		dx, dy := bestShift(prev, next, w, h)
+		totalDX += dx
+		totalDY += dy
+		prev = next
+	}
+
+	return float64(totalDX) / float64(w), float64(totalDY) / float64(h)
+}
+
+// CheckDeclaredAction returns ErrActionMismatch unless the frames show motion
+// of at least minMotion in the declared direction, dominating the other axis.
+func CheckDeclaredAction(action string, frames []image.Image, minMotion float64) error {
+	if !ValidAction(action) {
+		return ErrUnknownAction
+	}
+
+	dx, dy := MeasureMotion(frames)
+
+	var along, across float64
+	switch action {
+	case ActionTurnLeft:
+		along, across = -dx, dy
+	case ActionTurnRight:
+		along, across = dx, dy
+	case ActionLookUp:
+		along, across = -dy, dx
+	case ActionLookDown:
+		along, across = dy, dx
+	}
+
+	if along < minMotion || along < math.Abs(across) {
+		return ErrActionMismatch
+	}
+	return nil
+}
+
+func actionMinMotion(cfg *config.Config) float64 {
+	if cfg.ActionMinMotion > 0 {
+		return cfg.ActionMinMotion
+	}
+	return 0.02
+}
+
+func (s *FaceVerificationService) actionMatches(action string, frames []image.Image) bool {
+	minMotion := actionMinMotion(s.config)
+
+	if err := CheckDeclaredAction(action, frames, minMotion); err != nil {
+		dx, dy := MeasureMotion(frames)
+		s.logger.Info("Declared action not supported by observed motion",
+			zap.String("action", action),
+			zap.Float64("motion_x", dx),
+			zap.Float64("motion_y", dy),
+			zap.Float64("min_motion", minMotion))
+		return false
+	}
+	return true
+}
+
+// luminanceGrid downsamples a frame to a motionGridWidth-wide luminance grid.
+func luminanceGrid(img image.Image) ([]float64, int, int) {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, 0, 0
+	}
+
+	w := motionGridWidth
+	if bounds.Dx() < w {
+		w = bounds.Dx()
+	}
+	h := bounds.Dy() * w / bounds.Dx()
+	if h == 0 {
+		h = 1
+	}
+
+	grid := make([]float64, w*h)
+	for gy := 0; gy < h; gy++ {
+		y := bounds.Min.Y + gy*bounds.Dy()/h
+		for gx := 0; gx < w; gx++ {
+			x := bounds.Min.X + gx*bounds.Dx()/w
+			r, g, b, _ := img.At(x, y).RGBA()
+			grid[gy*w+gx] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 65535.0
+		}
+	}
+	return grid, w, h
+}
+
+// bestShift finds the (dx, dy) for which next(x+dx, y+dy) best matches
+// prev(x, y), searching up to a quarter of the grid in each direction. Ties
+// keep the smaller shift so static content reports no motion.
+func bestShift(prev, next []float64, w, h int) (int, int) {
+	maxDX, maxDY := w/4, h/4
+
+	cost := func(dx, dy int) float64 {
+		sum, n := 0.0, 0
+		for y := 0; y < h; y++ {
+			ny := y + dy
+			if ny < 0 || ny >= h {
+				continue
+			}
+			for x := 0; x < w; x++ {
+				nx := x + dx
+				if nx < 0 || nx >= w {
+					continue
+				}
+				sum += math.Abs(prev[y*w+x] - next[ny*w+nx])
+				n++
+			}
+		}
+		if n == 0 {
+			return math.Inf(1)
+		}
+		return sum / float64(n)
+	}
+
+	bestDX, bestDY := 0, 0
+	best := cost(0, 0)
+	for dy := -maxDY; dy <= maxDY; dy++ {
+		for dx := -maxDX; dx <= maxDX; dx++ {
+			if c := cost(dx, dy); c < best-1e-9 ||
+				(math.Abs(c-best) <= 1e-9 && absInt(dx)+absInt(dy) < absInt(bestDX)+absInt(bestDY)) {
+				best, bestDX, bestDY = c, dx, dy
+			}
+		}
+	}
+	return bestDX, bestDY
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
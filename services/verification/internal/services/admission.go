@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+)
+
+var ErrServerBusy = errors.New("too many verifications in progress")
+
+// admission bounds how many verifications and enrollments run at once. Up to
+// depth more callers wait for a slot; beyond that they are turned away with
+// ErrServerBusy so excess load is shed instead of piling up.
+type admission struct {
+	slots    chan struct{}
+	admitted chan struct{}
+}
+
+// newAdmission returns nil, admitting everything, when MAX_CONCURRENT_REQUESTS
+// is not set.
+func newAdmission(cfg *config.Config) *admission {
+	if cfg.MaxConcurrentRequests <= 0 {
+		return nil
+	}
+	depth := cfg.RequestQueueDepth
+	if depth < 0 {
+		depth = 0
+	}
+	return &admission{
+		slots:    make(chan struct{}, cfg.MaxConcurrentRequests),
+		admitted: make(chan struct{}, cfg.MaxConcurrentRequests+depth),
+	}
+}
+
+// acquire waits for a slot and returns its release func, or ErrServerBusy
+// right away when the queue is already full. A caller whose ctx is done
+// while queued gives up its place with ctx's error.
+func (a *admission) acquire(ctx context.Context) (func(), error) {
+	if a == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case a.admitted <- struct{}{}:
+	default:
+		metrics.RequestsShed.Add(1)
+		return nil, ErrServerBusy
+	}
+
+	metrics.RequestsQueued.Add(1)
+	defer metrics.RequestsQueued.Add(-1)
+	select {
+	case a.slots <- struct{}{}:
+	case <-ctx.Done():
+		<-a.admitted
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-a.slots
+		<-a.admitted
+	}, nil
+}
@@ -0,0 +1,609 @@
+package services
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// HNSW graph parameters. m bounds the links per node above layer 0 (twice
+// that on layer 0); efConstruction is the candidate list size used while
+// inserting.
+const (
+	annM              = 16
+	annEfConstruction = 200
+)
+
+func annEfSearch(cfg *config.Config) int {
+	if cfg.AnnEfSearch > 0 {
+		return cfg.AnnEfSearch
+	}
+	return 64
+}
+
+// annIndex is a hierarchical navigable small world graph over the enrolled
+// gallery, giving approximate cosine nearest neighbours in roughly
+// logarithmic time. Nodes are only ever added; enrollments that disappear
+// from the store are tombstoned and the graph is rebuilt once tombstones
+// outnumber live nodes.
+type annIndex struct {
+	mu        sync.RWMutex
+	efSearch  int
+	levelMult float64
+	rng       *rand.Rand
+
+	nodes    []*annNode
+	byKey    map[string]int32
+	byUser   map[string][]int32
+	deleted  int
+	entry    int32
+	maxLevel int
+}
+
+type annNode struct {
+	key       string
+	userID    string
+	createdAt time.Time
+	source    string
+	version   string
+	vector    []float32 // unit length
+	links     [][]int32 // per layer, 0 up to the node's level
+	deleted   bool
+}
+
+type annCandidate struct {
+	id   int32
+	dist float64
+}
+
+func newANNIndex(efSearch int) *annIndex {
+	return &annIndex{
+		efSearch:  efSearch,
+		levelMult: 1 / math.Log(annM),
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		byKey:     make(map[string]int32),
+		byUser:    make(map[string][]int32),
+		entry:     -1,
+	}
+}
+
+func annKey(vector models.FaceVector) string {
+	return fmt.Sprintf("%s/%d/%s", vector.UserID, vector.CreatedAt.UnixNano(), vector.Version)
+}
+
+// annIndexes keeps a separate index per tenant, so a search never walks
+// another tenant's enrollments and recall does not depend on the size of
+// the other galleries.
+type annIndexes struct {
+	mu       sync.Mutex
+	efSearch int
+	indexes  map[string]*annIndex
+}
+
+func newANNIndexes(efSearch int) *annIndexes {
+	return &annIndexes{
+		efSearch: efSearch,
+		indexes:  make(map[string]*annIndex),
+	}
+}
+
+// sync partitions the gallery by tenant and syncs each tenant's index,
+// dropping the indexes of tenants left without enrollments.
+func (x *annIndexes) sync(gallery map[string][]models.FaceVector) {
+	partitions := make(map[string]map[string][]models.FaceVector)
+	for userKey, vectors := range gallery {
+		tenantID, _ := tenant.SplitUserKey(userKey)
+		if partitions[tenantID] == nil {
+			partitions[tenantID] = make(map[string][]models.FaceVector)
+		}
+		partitions[tenantID][userKey] = vectors
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for tenantID := range x.indexes {
+		if _, ok := partitions[tenantID]; !ok {
+			delete(x.indexes, tenantID)
+		}
+	}
+	for tenantID, partition := range partitions {
+		index, ok := x.indexes[tenantID]
+		if !ok {
+			index = newANNIndex(x.efSearch)
+			x.indexes[tenantID] = index
+		}
+		index.sync(partition)
+	}
+}
+
+// update replaces userKey's enrollments in their tenant's index with
+// vectors, leaving every other user's as they are.
+func (x *annIndexes) update(userKey string, vectors []models.FaceVector) {
+	tenantID, _ := tenant.SplitUserKey(userKey)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	index, ok := x.indexes[tenantID]
+	if !ok {
+		if len(vectors) == 0 {
+			return
+		}
+		index = newANNIndex(x.efSearch)
+		x.indexes[tenantID] = index
+	}
+	index.update(userKey, vectors)
+	if index.size() == 0 {
+		delete(x.indexes, tenantID)
+	}
+}
+
+// search returns up to n live nodes of tenantID's index nearest to query.
+func (x *annIndexes) search(tenantID string, query []float32, n int) []*annNode {
+	x.mu.Lock()
+	index := x.indexes[tenantID]
+	x.mu.Unlock()
+
+	if index == nil {
+		return nil
+	}
+	return index.search(query, n)
+}
+
+// sync brings the index in line with the gallery: new enrollments are
+// inserted and removed ones tombstoned, so a reload only pays for the
+// enrollments that changed.
+func (x *annIndex) sync(gallery map[string][]models.FaceVector) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	present := make(map[string]struct{}, len(x.byKey))
+	for _, vectors := range gallery {
+		for _, vector := range vectors {
+			present[x.keepLocked(vector)] = struct{}{}
+		}
+	}
+
+	for key, id := range x.byKey {
+		if _, ok := present[key]; !ok {
+			x.dropLocked(id)
+		}
+	}
+	x.compactLocked()
+}
+
+// update is sync for the enrollments of userKey alone, so an enrollment
+// costs the same however large the gallery is.
+func (x *annIndex) update(userKey string, vectors []models.FaceVector) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	present := make(map[string]struct{}, len(vectors))
+	for _, vector := range vectors {
+		present[x.keepLocked(vector)] = struct{}{}
+	}
+
+	for _, id := range x.byUser[userKey] {
+		if _, ok := present[x.nodes[id].key]; !ok {
+			x.dropLocked(id)
+		}
+	}
+	x.compactLocked()
+}
+
+// keepLocked inserts vector unless it is indexed already, reviving it if it
+// was tombstoned, and returns its key.
+func (x *annIndex) keepLocked(vector models.FaceVector) string {
+	key := annKey(vector)
+	id, exists := x.byKey[key]
+	if !exists {
+		x.insertLocked(key, vector)
+	} else if x.nodes[id].deleted {
+		x.nodes[id].deleted = false
+		x.deleted--
+	}
+	return key
+}
+
+func (x *annIndex) dropLocked(id int32) {
+	if !x.nodes[id].deleted {
+		x.nodes[id].deleted = true
+		x.deleted++
+	}
+}
+
+// compactLocked rebuilds the graph from its live nodes once tombstones
+// outnumber them.
+func (x *annIndex) compactLocked() {
+	if x.deleted == 0 || x.deleted*2 <= len(x.nodes) {
+		return
+	}
+
+	nodes := x.nodes
+	x.nodes = nil
+	x.byKey = make(map[string]int32)
+	x.byUser = make(map[string][]int32)
+	x.deleted = 0
+	x.entry = -1
+	x.maxLevel = 0
+	for _, node := range nodes {
+		if node.deleted {
+			continue
+		}
+		x.insertLocked(node.key, models.FaceVector{
+			UserID:    node.userID,
+			Vector:    node.vector,
+			CreatedAt: node.createdAt,
+			Version:   node.version,
+			Source:    node.source,
+		})
+	}
+}
+
+func (x *annIndex) insertLocked(key string, vector models.FaceVector) {
+	level := int(-math.Log(1-x.rng.Float64()) * x.levelMult)
+	node := &annNode{
+		key:       key,
+		userID:    vector.UserID,
+		createdAt: vector.CreatedAt,
+		source:    vector.Source,
+		version:   vector.Version,
+		vector:    normalizeVector(vector.Vector),
+		links:     make([][]int32, level+1),
+	}
+	id := int32(len(x.nodes))
+	x.nodes = append(x.nodes, node)
+	x.byKey[key] = id
+	x.byUser[vector.UserID] = append(x.byUser[vector.UserID], id)
+
+	if x.entry < 0 {
+		x.entry = id
+		x.maxLevel = level
+		return
+	}
+
+	ep := x.entry
+	for l := x.maxLevel; l > level; l-- {
+		ep = x.greedyLocked(node.vector, ep, l)
+	}
+
+	entries := []int32{ep}
+	for l := min(level, x.maxLevel); l >= 0; l-- {
+		candidates := x.searchLayerLocked(node.vector, entries, annEfConstruction, l)
+		neighbours := candidates
+		if len(neighbours) > annM {
+			neighbours = neighbours[:annM]
+		}
+
+		maxLinks := annM
+		if l == 0 {
+			maxLinks = 2 * annM
+		}
+		for _, c := range neighbours {
+			node.links[l] = append(node.links[l], c.id)
+			peer := x.nodes[c.id]
+			peer.links[l] = append(peer.links[l], id)
+			if len(peer.links[l]) > maxLinks {
+				peer.links[l] = x.closestLocked(peer.vector, peer.links[l], maxLinks)
+			}
+		}
+
+		entries = entries[:0]
+		for _, c := range candidates {
+			entries = append(entries, c.id)
+		}
+	}
+
+	if level > x.maxLevel {
+		x.entry = id
+		x.maxLevel = level
+	}
+}
+
+// search returns up to n live nodes nearest to query, closest first.
+func (x *annIndex) search(query []float32, n int) []*annNode {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if x.entry < 0 || n <= 0 {
+		return nil
+	}
+	q := normalizeVector(query)
+
+	ep := x.entry
+	for l := x.maxLevel; l > 0; l-- {
+		ep = x.greedyLocked(q, ep, l)
+	}
+
+	// Tombstoned nodes still route the search, so widen the beam in
+	// proportion to keep enough live results. Rebuilds cap this at 2x.
+	ef := max(x.efSearch, n)
+	if x.deleted > 0 {
+		ef = ef * len(x.nodes) / (len(x.nodes) - x.deleted)
+	}
+	candidates := x.searchLayerLocked(q, []int32{ep}, ef, 0)
+
+	nodes := make([]*annNode, 0, n)
+	for _, c := range candidates {
+		node := x.nodes[c.id]
+		if node.deleted {
+			continue
+		}
+		nodes = append(nodes, node)
+		if len(nodes) == n {
+			break
+		}
+	}
+	return nodes
+}
+
+func (x *annIndex) size() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.nodes) - x.deleted
+}
+
+// greedyLocked walks layer l towards q, one neighbour at a time.
+func (x *annIndex) greedyLocked(q []float32, ep int32, l int) int32 {
+	best := annDistance(q, x.nodes[ep].vector)
+	for changed := true; changed; {
+		changed = false
+		for _, peer := range x.nodes[ep].links[l] {
+			if d := annDistance(q, x.nodes[peer].vector); d < best {
+				best, ep, changed = d, peer, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayerLocked is the HNSW beam search on one layer, returning up to
+// ef candidates closest first.
+func (x *annIndex) searchLayerLocked(q []float32, entries []int32, ef, l int) []annCandidate {
+	visited := make(map[int32]struct{}, ef*4)
+	candidates := &annMinHeap{}
+	results := &annMaxHeap{}
+
+	for _, id := range entries {
+		visited[id] = struct{}{}
+		c := annCandidate{id: id, dist: annDistance(q, x.nodes[id].vector)}
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(annCandidate)
+		if results.Len() >= ef && current.dist > (*results)[0].dist {
+			break
+		}
+		for _, peer := range x.nodes[current.id].links[l] {
+			if _, seen := visited[peer]; seen {
+				continue
+			}
+			visited[peer] = struct{}{}
+
+			d := annDistance(q, x.nodes[peer].vector)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, annCandidate{id: peer, dist: d})
+				heap.Push(results, annCandidate{id: peer, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]annCandidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(annCandidate)
+	}
+	return sorted
+}
+
+// closestLocked trims links to the n closest to vector.
+func (x *annIndex) closestLocked(vector []float32, links []int32, n int) []int32 {
+	scored := make([]annCandidate, len(links))
+	for i, id := range links {
+		scored[i] = annCandidate{id: id, dist: annDistance(vector, x.nodes[id].vector)}
+	}
+	sort.Slice(scored, func(i, j int) bool { return scored[i].dist < scored[j].dist })
+
+	trimmed := make([]int32, n)
+	for i := range trimmed {
+		trimmed[i] = scored[i].id
+	}
+	return trimmed
+}
+
+// annDistance is cosine distance between unit vectors.
+func annDistance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 2
+	}
+	return 1 - dot(a, b)
+}
+
+func normalizeVector(v []float32) []float32 {
+	out := make([]float32, len(v))
+	normalizeInto(out, v)
+	return out
+}
+
+// ReindexGallery builds an ANN index over gallery from scratch, as a
+// serving process does when it starts, and reports what went into it.
+// Vectors the index cannot hold are skipped rather than inserted, since a
+// vector of the wrong length would break every search that reaches it.
+func ReindexGallery(cfg *config.Config, gallery map[string][]models.FaceVector) *models.IndexRebuild {
+	start := time.Now()
+	rebuild := &models.IndexRebuild{}
+
+	// The gallery's dimension is the most common vector length
+	lengths := make(map[int]int)
+	userIDs := make([]string, 0, len(gallery))
+	for userID, vectors := range gallery {
+		userIDs = append(userIDs, userID)
+		for _, vector := range vectors {
+			if len(vector.Vector) > 0 {
+				lengths[len(vector.Vector)]++
+			}
+		}
+	}
+	for length, count := range lengths {
+		best := lengths[rebuild.Dimensions]
+		if count > best || (count == best && length > rebuild.Dimensions) {
+			rebuild.Dimensions = length
+		}
+	}
+	sort.Strings(userIDs)
+
+	index := newANNIndex(annEfSearch(cfg))
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	for _, userID := range userIDs {
+		indexed := false
+		for _, vector := range gallery[userID] {
+			if !indexable(vector.Vector, rebuild.Dimensions) {
+				rebuild.Skipped = append(rebuild.Skipped,
+					fmt.Sprintf("%s@%s", userID, vector.CreatedAt.UTC().Format(time.RFC3339Nano)))
+				continue
+			}
+			index.insertLocked(annKey(vector), vector)
+			rebuild.Enrollments++
+			indexed = true
+		}
+		if indexed {
+			rebuild.Users++
+		}
+	}
+
+	rebuild.BuildTimeMs = time.Since(start).Milliseconds()
+	return rebuild
+}
+
+func indexable(v []float32, dimensions int) bool {
+	if len(v) == 0 || len(v) != dimensions {
+		return false
+	}
+	nonZero := false
+	for _, f := range v {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return false
+		}
+		nonZero = nonZero || f != 0
+	}
+	return nonZero
+}
+
+type annMinHeap []annCandidate
+
+func (h annMinHeap) Len() int            { return len(h) }
+func (h annMinHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h annMinHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *annMinHeap) Push(v interface{}) { *h = append(*h, v.(annCandidate)) }
+func (h *annMinHeap) Pop() interface{} {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+type annMaxHeap []annCandidate
+
+func (h annMaxHeap) Len() int            { return len(h) }
+func (h annMaxHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h annMaxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *annMaxHeap) Push(v interface{}) { *h = append(*h, v.(annCandidate)) }
+func (h *annMaxHeap) Pop() interface{} {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+// SearchGallery is SearchTenantGallery over the default tenant's gallery.
+func (s *FaceVerificationService) SearchGallery(vector []float32, k int) []models.GalleryMatch {
+	return s.SearchTenantGallery(tenant.Default, vector, k)
+}
+
+// Matches an identification returns unless asked for fewer or more, and
+// the most it may ask for
+const (
+	DefaultIdentifyResults = 5
+	MaxIdentifyResults     = 100
+)
+
+// SearchTenantGallery is the 1:N lookup: the k users of tenantID most
+// similar to vector, best first, each with the similarity of their closest
+// enrollment. Enrollments younger than MIN_ENROLLMENT_AGE or of other
+// recognizer models are ignored. It
+// uses the ANN index when ANN_INDEX_ENABLED is set and an exact scan
+// otherwise. Matches carry user IDs without the tenant.
+func (s *FaceVerificationService) SearchTenantGallery(tenantID string, vector []float32, k int) []models.GalleryMatch {
+	return s.searchGallery(tenantID, vector, k, time.Duration(s.config.MinEnrollmentAge)*time.Second)
+}
+
+// searchGallery is SearchTenantGallery ignoring only enrollments younger
+// than minAge.
+func (s *FaceVerificationService) searchGallery(tenantID string, vector []float32, k int, minAge time.Duration) []models.GalleryMatch {
+	if k <= 0 {
+		return nil
+	}
+	best := make(map[string]float64)
+	consider := func(userID string, createdAt time.Time, source, version string, similarity float64) {
+		if settling(createdAt, source, minAge) || !s.currentDescriptor(version) {
+			return
+		}
+		if current, ok := best[userID]; !ok || similarity > current {
+			best[userID] = similarity
+		}
+	}
+
+	// Stored templates are unit vectors already, so normalizing the query
+	// once turns every cosine into a dot product
+	query := normalizeVector(vector)
+	if s.annIndexes != nil {
+		// Users can hold several enrollments, so over-fetch before
+		// collapsing to one match per user
+		for _, node := range s.annIndexes.search(tenantID, vector, k*4) {
+			_, userID := tenant.SplitUserKey(node.userID)
+			consider(userID, node.createdAt, node.source, node.version, unitSimilarity(query, node.vector))
+		}
+	} else {
+		s.storageMutex.RLock()
+		for userKey, vectors := range s.faceVectors {
+			owner, userID := tenant.SplitUserKey(userKey)
+			if owner != tenantID {
+				continue
+			}
+			similarities := unitSimilarities(query, s.unitVectors[userKey])
+			for i, stored := range vectors {
+				consider(userID, stored.CreatedAt, stored.Source, stored.Version, similarities[i])
+			}
+		}
+		s.storageMutex.RUnlock()
+	}
+
+	matches := make([]models.GalleryMatch, 0, len(best))
+	for userID, similarity := range best {
+		matches = append(matches, models.GalleryMatch{UserID: userID, Similarity: similarity})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].UserID < matches[j].UserID
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/atomicfile"
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrAsyncDisabled = errors.New("async verification is not enabled")
+var ErrAsyncQueueFull = errors.New("async verification queue is full")
+
+// interruptedJobMessage marks async jobs a restart cut short.
+const interruptedJobMessage = "interrupted by service restart"
+
+type asyncJob struct {
+	verificationID string
+	req            *models.VerificationRequest
+	done           func()
+}
+
+// asyncJobs is the queue and worker pool behind mode=async verifications.
+// Job state lives in the verification records and is mirrored to a JSON
+// file so status lookups survive a restart.
+type asyncJobs struct {
+	queue chan asyncJob
+
+	mu    sync.Mutex
+	path  string
+	state map[string]models.VerificationRecord
+	order []string
+}
+
+func newAsyncJobs(cfg *config.Config) *asyncJobs {
+	size := cfg.AsyncQueueSize
+	if size <= 0 {
+		size = 100
+	}
+	return &asyncJobs{
+		queue: make(chan asyncJob, size),
+		path:  cfg.AsyncJobStatePath,
+		state: make(map[string]models.VerificationRecord),
+	}
+}
+
+// startAsyncWorkers restores persisted job state and starts the pool.
+func (s *FaceVerificationService) startAsyncWorkers() {
+	s.restoreAsyncJobs()
+	for i := 0; i < s.config.AsyncWorkers; i++ {
+		go s.runAsyncWorker()
+	}
+}
+
+func (s *FaceVerificationService) runAsyncWorker() {
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case job := <-s.asyncJobs.queue:
+			s.records.setStatus(job.verificationID, models.StatusProcessing, "")
+			s.persistAsyncJob(job.verificationID)
+			// No client waits on the job, so only PROCESSING_TIMEOUT bounds it
+			ctx, cancel := context.WithTimeout(context.Background(), ProcessingTimeout(s.config))
+			s.runVerification(ctx, job.verificationID, job.req)
+			cancel()
+			if job.done != nil {
+				job.done()
+			}
+			s.persistAsyncJob(job.verificationID)
+			s.background.done()
+		}
+	}
+}
+
+// EnqueueVerification registers a pending verification and hands it to the
+// worker pool, returning its ID immediately. done, if set, runs once the
+// job has finished. Results reach clients through GetVerificationRecord and
+// the result webhook. Once the service is draining jobs are refused with
+// ErrShuttingDown, and locked out users and clients with a LockedOutError.
+func (s *FaceVerificationService) EnqueueVerification(req *models.VerificationRequest, done func()) (string, error) {
+	if s.asyncJobs == nil {
+		return "", ErrAsyncDisabled
+	}
+	if s.Draining() {
+		return "", ErrShuttingDown
+	}
+	if err := s.CheckLockout(req.Tenant, req.UserID, req.ClientIP); err != nil {
+		return "", err
+	}
+
+	verificationID := newVerificationID()
+	s.records.begin(verificationID, req.Tenant, req.UserID, req.SessionID)
+
+	// Counted from the moment it is queued, so a drain waits for it
+	s.background.add()
+	select {
+	case s.asyncJobs.queue <- asyncJob{verificationID: verificationID, req: req, done: done}:
+	default:
+		s.background.done()
+		s.records.setStatus(verificationID, models.StatusFailed, ErrAsyncQueueFull.Error())
+		return "", ErrAsyncQueueFull
+	}
+
+	s.persistAsyncJob(verificationID)
+	return verificationID, nil
+}
+
+// persistAsyncJob mirrors the current record of an async job to disk.
+func (s *FaceVerificationService) persistAsyncJob(verificationID string) {
+	record, ok := s.records.get(verificationID)
+	if !ok || s.asyncJobs.path == "" {
+		return
+	}
+
+	jobs := s.asyncJobs
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+
+	if _, exists := jobs.state[verificationID]; !exists {
+		jobs.order = append(jobs.order, verificationID)
+	}
+	jobs.state[verificationID] = record
+	for len(jobs.order) > maxRecentResults {
+		delete(jobs.state, jobs.order[0])
+		jobs.order = jobs.order[1:]
+	}
+
+	if err := jobs.writeLocked(); err != nil {
+		s.logger.Warn("Failed to persist async job state",
+			zap.String("verification_id", verificationID),
+			zap.Error(err))
+	}
+}
+
+// restoreAsyncJobs reloads persisted job records. Jobs that were still
+// pending or processing cannot resume, since their spooled captures are not
+// kept across restarts, so they are reported as failed.
+func (s *FaceVerificationService) restoreAsyncJobs() {
+	jobs := s.asyncJobs
+	if jobs.path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(jobs.path)
+	if os.IsNotExist(err) {
+		return
+	}
+	var records []models.VerificationRecord
+	if err == nil {
+		err = json.Unmarshal(data, &records)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to load async job state", zap.Error(err))
+		return
+	}
+
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+
+	for _, record := range records {
+		if record.Status == models.StatusPending || record.Status == models.StatusProcessing {
+			record.Status = models.StatusFailed
+			record.ErrorMessage = interruptedJobMessage
+		}
+		s.records.restore(record)
+		jobs.order = append(jobs.order, record.ID)
+		jobs.state[record.ID] = record
+	}
+
+	if err := jobs.writeLocked(); err != nil {
+		s.logger.Warn("Failed to persist async job state", zap.Error(err))
+	}
+}
+
+// erase drops the persisted state of the given verifications.
+func (j *asyncJobs) erase(verificationIDs map[string]bool) error {
+	if j.path == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	order := j.order[:0]
+	for _, id := range j.order {
+		if verificationIDs[id] {
+			delete(j.state, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	j.order = order
+	return j.writeLocked()
+}
+
+func (j *asyncJobs) writeLocked() error {
+	records := make([]models.VerificationRecord, 0, len(j.order))
+	for _, id := range j.order {
+		records = append(records, j.state[id])
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	return atomicfile.Write(j.path, data)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrAttestationDisabled = errors.New("result attestation is not enabled")
+
+// attestationSigner issues ES256 JWTs over verification outcomes so other
+// connect-hub services can trust a result relayed by the client.
+type attestationSigner struct {
+	key    *ecdsa.PrivateKey
+	keyID  string
+	issuer string
+	ttl    time.Duration
+}
+
+func newAttestationSigner(logger *zap.Logger, cfg *config.Config) (*attestationSigner, error) {
+	var key *ecdsa.PrivateKey
+	if cfg.AttestationSigningKey != "" {
+		parsed, err := parseAttestationKey(cfg.AttestationSigningKey)
+		if err != nil {
+			return nil, err
+		}
+		key = parsed
+	} else {
+		// Tokens from other replicas or before a restart won't verify
+		logger.Warn("ATTESTATION_SIGNING_KEY not set, signing attestations with an ephemeral key")
+		generated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key = generated
+	}
+
+	signer := &attestationSigner{
+		key:    key,
+		keyID:  cfg.AttestationKeyID,
+		issuer: cfg.AttestationIssuer,
+		ttl:    attestationTTL(cfg),
+	}
+	if signer.keyID == "" {
+		signer.keyID = signer.thumbprint()
+	}
+	return signer, nil
+}
+
+func attestationTTL(cfg *config.Config) time.Duration {
+	if cfg.AttestationTTL > 0 {
+		return time.Duration(cfg.AttestationTTL) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// parseAttestationKey accepts a PEM-encoded P-256 key in SEC 1 or PKCS #8
+// form, or the path of a file holding one.
+func parseAttestationKey(value string) (*ecdsa.PrivateKey, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		contents, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attestation signing key: %w", err)
+		}
+		data = contents
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("attestation signing key is not PEM-encoded")
+	}
+
+	var key *ecdsa.PrivateKey
+	if parsed, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		key = parsed
+	} else {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attestation signing key: %w", err)
+		}
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("attestation signing key must be an ECDSA key")
+		}
+		key = ecKey
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("attestation signing key must use the P-256 curve")
+	}
+	return key, nil
+}
+
+func (a *attestationSigner) coordinates() (string, string) {
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	return base64.RawURLEncoding.EncodeToString(x), base64.RawURLEncoding.EncodeToString(y)
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint of the public key.
+func (a *attestationSigner) thumbprint() string {
+	x, y := a.coordinates()
+	canonical := fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":%q,"y":%q}`, x, y)
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (a *attestationSigner) jwk() models.JSONWebKey {
+	x, y := a.coordinates()
+	return models.JSONWebKey{Kty: "EC", Crv: "P-256", X: x, Y: y, Kid: a.keyID, Use: "sig", Alg: "ES256"}
+}
+
+func (a *attestationSigner) sign(result *models.VerificationResult, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "typ": "JWT", "kid": a.keyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(models.AttestationClaims{
+		Issuer:         a.issuer,
+		Subject:        tenant.UserKey(result.Tenant, result.UserID),
+		IssuedAt:       now.Unix(),
+		ExpiresAt:      now.Add(a.ttl).Unix(),
+		VerificationID: result.VerificationID,
+		UserID:         result.UserID,
+		Tenant:         result.Tenant,
+		Verified:       result.Verified,
+		Confidence:     result.Confidence,
+		LivenessScore:  result.LivenessScore,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// JWS wants the fixed-size r || s form rather than ASN.1
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// attest signs the outcome of a final result in place.
+func (s *FaceVerificationService) attest(result *models.VerificationResult) {
+	if s.attestation == nil {
+		return
+	}
+	token, err := s.attestation.sign(result, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to sign verification attestation",
+			zap.String("verification_id", result.VerificationID),
+			zap.Error(err))
+		return
+	}
+	result.Attestation = token
+}
+
+// AttestationKeys returns the public keys attestation tokens are signed with.
+func (s *FaceVerificationService) AttestationKeys() (models.JSONWebKeySet, error) {
+	if s.attestation == nil {
+		return models.JSONWebKeySet{}, ErrAttestationDisabled
+	}
+	return models.JSONWebKeySet{Keys: []models.JSONWebKey{s.attestation.jwk()}}, nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrInvalidAuditFormat = errors.New("unsupported audit export format")
+var ErrInvalidAuditField = errors.New("field is not exportable")
+var ErrInvalidAuditRange = errors.New("invalid audit export date range")
+
+// InvalidAuditFieldError names the field an export was refused for. It
+// matches ErrInvalidAuditField.
+type InvalidAuditFieldError struct {
+	Field string
+}
+
+func (e *InvalidAuditFieldError) Error() string {
+	return ErrInvalidAuditField.Error() + ": " + e.Field
+}
+
+func (e *InvalidAuditFieldError) Unwrap() error {
+	return ErrInvalidAuditField
+}
+
+// auditColumns maps every exportable column to its value. Biometric scores
+// (confidence, raw_confidence, liveness_score) are deliberately absent so they
+// can never be selected.
+var auditColumns = map[string]func(*models.VerificationResult) string{
+	"verification_id":   func(r *models.VerificationResult) string { return r.VerificationID },
+	"timestamp":         func(r *models.VerificationResult) string { return r.Timestamp.UTC().Format(time.RFC3339Nano) },
+	"user_id":           func(r *models.VerificationResult) string { return r.UserID },
+	"verified":          func(r *models.VerificationResult) string { return strconv.FormatBool(r.Verified) },
+	"reason":            func(r *models.VerificationResult) string { return r.Reason },
+	"device":            func(r *models.VerificationResult) string { return r.Device },
+	"processing_region": func(r *models.VerificationResult) string { return r.ProcessingRegion },
+	"client_region":     func(r *models.VerificationResult) string { return r.ClientRegion },
+	"processing_time":   func(r *models.VerificationResult) string { return strconv.FormatFloat(r.ProcessingTime, 'f', -1, 64) },
+	"error":             func(r *models.VerificationResult) string { return r.Error },
+}
+
+// DefaultAuditFields is the column set used when neither the request nor
+// AUDIT_EXPORT_FIELDS names one.
+var DefaultAuditFields = []string{
+	"verification_id", "timestamp", "user_id", "verified", "reason",
+	"processing_region", "client_region",
+}
+
+// AuditExportOptions selects the records and columns of an audit export.
+// From is inclusive, To exclusive; zero values leave that side open.
+type AuditExportOptions struct {
+	From   time.Time
+	To     time.Time
+	Format string
+	Fields []string
+}
+
+// ExportAudit renders recorded verifications in From..To as CSV or JSON with
+// only non-biometric columns, plus a manifest carrying the SHA-256 of the
+// content and, when AUDIT_SIGNING_KEY is set, an HMAC over the manifest.
+func (s *FaceVerificationService) ExportAudit(opts AuditExportOptions) (*models.AuditExport, error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = strings.ToLower(s.config.AuditExportFormat)
+	}
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return nil, ErrInvalidAuditFormat
+	}
+
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = splitFields(s.config.AuditExportFields)
+	}
+	if len(fields) == 0 {
+		fields = DefaultAuditFields
+	}
+	for _, field := range fields {
+		if _, ok := auditColumns[field]; !ok {
+			return nil, &InvalidAuditFieldError{Field: field}
+		}
+	}
+
+	if !opts.From.IsZero() && !opts.To.IsZero() && !opts.From.Before(opts.To) {
+		return nil, ErrInvalidAuditRange
+	}
+
+	records := s.recentResults.find(func(r *models.VerificationResult) bool {
+		if !opts.From.IsZero() && r.Timestamp.Before(opts.From) {
+			return false
+		}
+		return opts.To.IsZero() || r.Timestamp.Before(opts.To)
+	}, maxRecentResults)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	var content []byte
+	var err error
+	if format == "csv" {
+		content, err = auditCSV(records, fields)
+	} else {
+		content, err = auditJSON(records, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	manifest := models.AuditManifest{
+		Format:        format,
+		Fields:        fields,
+		RecordCount:   len(records),
+		GeneratedAt:   time.Now().UTC(),
+		ContentSHA256: hex.EncodeToString(sum[:]),
+	}
+	if !opts.From.IsZero() {
+		from := opts.From.UTC()
+		manifest.From = &from
+	}
+	if !opts.To.IsZero() {
+		to := opts.To.UTC()
+		manifest.To = &to
+	}
+	if s.config.AuditSigningKey != "" {
+		signature, err := signAuditManifest(manifest, s.config.AuditSigningKey)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Signature = signature
+	}
+
+	return &models.AuditExport{Manifest: manifest, Content: string(content)}, nil
+}
+
+// VerifyAuditExport checks that content matches the manifest hash and, when
+// key is non-empty, that the manifest signature was made with key.
+func VerifyAuditExport(export *models.AuditExport, key string) bool {
+	sum := sha256.Sum256([]byte(export.Content))
+	if hex.EncodeToString(sum[:]) != export.Manifest.ContentSHA256 {
+		return false
+	}
+	if key == "" {
+		return true
+	}
+
+	expected, err := signAuditManifest(export.Manifest, key)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(export.Manifest.Signature))
+}
+
+// signAuditManifest returns the hex HMAC-SHA256 of the manifest's JSON
+// encoding with the signature field left empty.
+func signAuditManifest(manifest models.AuditManifest, key string) (string, error) {
+	manifest.Signature = ""
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func auditCSV(records []*models.VerificationResult, fields []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(fields); err != nil {
+		return nil, err
+	}
+	row := make([]string, len(fields))
+	for _, record := range records {
+		for i, field := range fields {
+			row[i] = auditColumns[field](record)
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func auditJSON(records []*models.VerificationResult, fields []string) ([]byte, error) {
+	rows := make([]map[string]string, 0, len(records))
+	for _, record := range records {
+		row := make(map[string]string, len(fields))
+		for _, field := range fields {
+			row[field] = auditColumns[field](record)
+		}
+		rows = append(rows, row)
+	}
+	return json.Marshal(rows)
+}
+
+// splitFields parses a comma-separated field list, dropping blanks.
+func splitFields(list string) []string {
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+var ErrAuditLogDisabled = errors.New("audit log is not enabled")
+
+func auditLogPath(cfg *config.Config) string {
+	if cfg.AuditLogPath != "" {
+		return cfg.AuditLogPath
+	}
+	return filepath.Join(cfg.StoragePath, "audit.log")
+}
+
+// ClientKeyFingerprint identifies an API key in the audit log without
+// storing the key itself.
+func ClientKeyFingerprint(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// RecordAudit appends event to the audit log, stamping its ID and time.
+// A failed append is logged rather than failing the operation it records.
+func (s *FaceVerificationService) RecordAudit(event models.AuditEvent) {
+	if s.auditLog == nil {
+		return
+	}
+	event.ID = uuid.New().String()
+	event.Timestamp = time.Now().UTC()
+
+	if err := s.auditLog.Append(event); err != nil {
+		s.logger.Error("Failed to write audit event",
+			zap.String("operation", string(event.Operation)),
+			zap.String("user_id", event.UserID),
+			zap.Error(err))
+	}
+}
+
+// QueryAudit returns audit events matching q, newest first.
+func (s *FaceVerificationService) QueryAudit(q storage.AuditQuery) ([]models.AuditEvent, error) {
+	if s.auditLog == nil {
+		return nil, ErrAuditLogDisabled
+	}
+	return s.auditLog.Query(q)
+}
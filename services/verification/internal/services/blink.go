@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sync/atomic"
+
+	"github.com/Kagami/go-face"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+var ErrNoEyeLandmarks = errors.New("landmark model does not provide eye contours")
+
+// LandmarkDetector locates facial landmarks in a frame using the 68-point
+// iBUG 300-W layout (eyes are points 36-41 and 42-47). It returns nil when
+// no face is found.
+type LandmarkDetector interface {
+	Landmarks(frame image.Image) ([]image.Point, error)
+}
+
+// SetLandmarkDetector replaces the detector blink scoring uses. A nil
+// detector makes the blink detector abstain.
+func (s *FaceVerificationService) SetLandmarkDetector(detector LandmarkDetector) {
+	s.landmarkDetector = detector
+}
+
+const landmarkCount = 68
+
+// recognizerLandmarks reads the shape go-face fits while detecting faces.
+// The stock 5-point shape predictor has no eyelids, so blink scoring only
+// runs when a 68-point predictor is installed in FACE_MODEL_PATH.
+type recognizerLandmarks struct {
+	service *FaceVerificationService
+	// Set once the model proved to lack eye points, to skip detection
+	unsupported atomic.Bool
+}
+
+func (d *recognizerLandmarks) Landmarks(frame image.Image) ([]image.Point, error) {
+	if d.unsupported.Load() {
+		return nil, ErrNoEyeLandmarks
+	}
+	rgba, width, height, release := toRGBA(frame)
+	defer release()
+	var faces []face.Face
+	err := d.service.recognizers.with(func(rec *face.Recognizer) error {
+		var err error
+		faces, err = recognize(rec, rgba, width, height, d.service.recognizers.cnn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(faces) == 0 {
+		return nil, nil
+	}
+	if len(faces[0].Shapes) < landmarkCount {
+		d.unsupported.Store(true)
+		return nil, ErrNoEyeLandmarks
+	}
+	return faces[0].Shapes, nil
+}
+
+func blinkWeight(cfg *config.Config) float64 {
+	return math.Max(0, math.Min(cfg.BlinkWeight, 1))
+}
+
+func blinkEARThreshold(cfg *config.Config) float64 {
+	if cfg.BlinkEARThreshold > 0 {
+		return cfg.BlinkEARThreshold
+	}
+	return 0.21
+}
+
+// eyeAspectRatio is the EAR of Soukupová and Čech: the mean of both eyes'
+// vertical lid distances over their horizontal width. Open eyes sit around
+// 0.3 and the ratio drops towards 0 as the lids close.
+func eyeAspectRatio(landmarks []image.Point) float64 {
+	eye := func(p []image.Point) float64 {
+		width := pointDistance(p[0], p[3])
+		if width == 0 {
+			return 0
+		}
+		return (pointDistance(p[1], p[5]) + pointDistance(p[2], p[4])) / (2 * width)
+	}
+	return (eye(landmarks[36:42]) + eye(landmarks[42:48])) / 2
+}
+
+func pointDistance(a, b image.Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// measureBlink computes the EAR of every frame with a detectable face and
+// reports whether the eyes were open, closed below threshold and open again,
+// along with the lowest EAR seen. ok is false when too few frames had
+// landmarks to judge.
+func (s *FaceVerificationService) measureBlink(frames []image.Image) (blinked bool, minEAR float64, ok bool) {
+	if s.landmarkDetector == nil {
+		return false, 0, false
+	}
+
+	ears := make([]float64, 0, len(frames))
+	for _, frame := range frames {
+		landmarks, err := s.landmarkDetector.Landmarks(frame)
+		if errors.Is(err, ErrNoEyeLandmarks) {
+			return false, 0, false
+		}
+		if err != nil || len(landmarks) < landmarkCount {
+			continue
+		}
+		ears = append(ears, eyeAspectRatio(landmarks))
+	}
+	if len(ears) < 3 {
+		return false, 0, false
+	}
+
+	threshold := blinkEARThreshold(s.config)
+	closedAt := -1
+	minEAR = math.Inf(1)
+	for i, ear := range ears {
+		if ear < minEAR {
+			minEAR, closedAt = ear, i
+		}
+	}
+	if minEAR >= threshold {
+		return false, minEAR, true
+	}
+
+	openBefore, openAfter := false, false
+	for _, ear := range ears[:closedAt] {
+		openBefore = openBefore || ear >= threshold
+	}
+	for _, ear := range ears[closedAt+1:] {
+		openAfter = openAfter || ear >= threshold
+	}
+	return openBefore && openAfter, minEAR, true
+}
+
+// detectBlink is the blink liveness detector: 1 when the eyes were seen to
+// blink, 0 when they stayed open. Captures without usable landmarks abstain.
+func (s *FaceVerificationService) detectBlink(frames []image.Image) (float64, map[string]float64, bool) {
+	blinked, minEAR, ok := s.measureBlink(frames)
+	if !ok {
+		return 0, nil, false
+	}
+
+	blinkScore := 0.0
+	if blinked {
+		blinkScore = 1.0
+	}
+	return blinkScore, map[string]float64{
+		"blink_score":          blinkScore,
+		"min_eye_aspect_ratio": minEAR,
+	}, true
+}
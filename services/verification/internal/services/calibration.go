@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type calibrationPoint struct {
+	raw        float64
+	calibrated float64
+}
+
+// CalibrationMap is a monotonic piecewise-linear map from raw similarity
+// scores to calibrated confidences.
+type CalibrationMap struct {
+	points []calibrationPoint
+}
+
+// ParseCalibrationMap parses a curve of the form "raw:calibrated,..." such as
+// "0.5:0.05,0.7:0.40,0.85:0.90,1.0:0.99". An empty spec returns nil, which
+// disables calibration.
+func ParseCalibrationMap(spec string) (*CalibrationMap, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var points []calibrationPoint
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid calibration point %q", pair)
+		}
+
+		raw, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw score in %q: %w", pair, err)
+		}
+		calibrated, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibrated score in %q: %w", pair, err)
+		}
+		if calibrated < 0 || calibrated > 1 {
+			return nil, fmt.Errorf("calibrated score out of range [0,1] in %q", pair)
+		}
+
+		points = append(points, calibrationPoint{raw: raw, calibrated: calibrated})
+	}
+
+	if len(points) < 2 {
+		return nil, fmt.Errorf("calibration map needs at least 2 points, got %d", len(points))
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].raw < points[j].raw })
+
+	// Calibration must be isotonic: a higher raw score can never produce a
+	// lower calibrated confidence.
+	for i := 1; i < len(points); i++ {
+		if points[i].raw == points[i-1].raw {
+			return nil, fmt.Errorf("duplicate raw score %.4f in calibration map", points[i].raw)
+		}
+		if points[i].calibrated < points[i-1].calibrated {
+			return nil, fmt.Errorf("calibration map is not monotonic at raw score %.4f", points[i].raw)
+		}
+	}
+
+	return &CalibrationMap{points: points}, nil
+}
+
+// Apply maps a raw score onto the curve, clamping outside the covered range.
+func (m *CalibrationMap) Apply(raw float64) float64 {
+	if m == nil || len(m.points) == 0 {
+		return raw
+	}
+
+	first, last := m.points[0], m.points[len(m.points)-1]
+	if raw <= first.raw {
+		return first.calibrated
+	}
+	if raw >= last.raw {
+		return last.calibrated
+	}
+
+	i := sort.Search(len(m.points), func(i int) bool { return m.points[i].raw >= raw })
+	lo, hi := m.points[i-1], m.points[i]
+	t := (raw - lo.raw) / (hi.raw - lo.raw)
+
+	return lo.calibrated + t*(hi.calibrated-lo.calibrated)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"image"
+
+	"go.uber.org/zap"
+)
+
+// isCameraBlocked reports whether every frame is either almost black or a
+// single flat value, which points to a covered lens or a dead camera feed
+// rather than a spoof attempt.
+func (s *FaceVerificationService) isCameraBlocked(frames []image.Image) bool {
+	if len(frames) == 0 {
+		return false
+	}
+
+	minBrightness := s.config.CameraMinBrightness
+	minVariance := s.config.CameraMinVariance
+
+	for _, frame := range frames {
+		brightness, variance := s.calculateLuminanceStats(frame)
+		if brightness >= minBrightness && variance >= minVariance {
+			return false
+		}
+	}
+
+	s.logger.Debug("All frames look blocked or signal-less",
+		zap.Int("frames", len(frames)),
+		zap.Float64("min_brightness", minBrightness),
+		zap.Float64("min_variance", minVariance))
+
+	return true
+}
+
+// calculateLuminanceStats returns the mean and variance of luminance in [0,1].
+func (s *FaceVerificationService) calculateLuminanceStats(img image.Image) (float64, float64) {
+	bounds := img.Bounds()
+	rgba := asRGBA(img)
+	sum, sumSquares := 0.0, 0.0
+	pixelCount := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 4 { // Sample every 4th pixel
+		i := rgba.PixOffset(bounds.Min.X, y)
+		for x := bounds.Min.X; x < bounds.Max.X; x += 4 {
+			luma := (0.299*float64(rgba.Pix[i]) + 0.587*float64(rgba.Pix[i+1]) + 0.114*float64(rgba.Pix[i+2])) / 255.0
+			sum += luma
+			sumSquares += luma * luma
+			pixelCount++
+			i += 16
+		}
+	}
+
+	if pixelCount == 0 {
+		return 0.0, 0.0
+	}
+
+	mean := sum / float64(pixelCount)
+	variance := sumSquares/float64(pixelCount) - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+
+	return mean, variance
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"image"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+)
+
+// CanaryInput carries everything the stable pipeline already computed so a
+// candidate pipeline can be evaluated without re-decoding the capture.
+type CanaryInput struct {
+	Frames        []image.Image
+	FaceVector    []float32
+	UserID        string
+	Tenant        string
+	Liveness      *models.LivenessResult
+	LivenessScore float64
+	RawConfidence float64
+	// The stable pipeline's decision
+	Verified bool
+}
+
+// CanaryPipeline is a candidate decision path evaluated in shadow mode. Its
+// decision is only compared and recorded, never returned to clients.
+type CanaryPipeline interface {
+	Name() string
+	Evaluate(input CanaryInput) (bool, error)
+}
+
+// CanaryScorer is a CanaryPipeline that scores captures with candidate
+// models, so its scores can be compared with the stable pipeline's. Score
+// is called in place of Evaluate.
+type CanaryScorer interface {
+	CanaryPipeline
+	Score(input CanaryInput) (CanaryScores, error)
+}
+
+// CanaryScores is a candidate's decision on a capture and how its scores
+// differ from the stable pipeline's, candidate minus stable.
+type CanaryScores struct {
+	Verified         bool
+	LivenessDelta    float64
+	LivenessScored   bool
+	SimilarityDelta  float64
+	SimilarityScored bool
+}
+
+// CanaryStats summarizes shadow evaluations since startup.
+type CanaryStats struct {
+	Runs          int64 `json:"runs"`
+	Agreements    int64 `json:"agreements"`
+	Disagreements int64 `json:"disagreements"`
+	Errors        int64 `json:"errors"`
+	// Runs a CanaryScorer scored, and the mean of its score deltas
+	LivenessDeltas      int64   `json:"liveness_deltas"`
+	MeanLivenessDelta   float64 `json:"mean_liveness_delta"`
+	SimilarityDeltas    int64   `json:"similarity_deltas"`
+	MeanSimilarityDelta float64 `json:"mean_similarity_delta"`
+}
+
+type canaryCounters struct {
+	runs          atomic.Int64
+	agreements    atomic.Int64
+	disagreements atomic.Int64
+	errors        atomic.Int64
+
+	deltaMutex       sync.Mutex
+	livenessDeltas   deltaSum
+	similarityDeltas deltaSum
+}
+
+type deltaSum struct {
+	n   int64
+	sum float64
+}
+
+func (d *deltaSum) add(delta float64) {
+	d.n++
+	d.sum += delta
+}
+
+func (d deltaSum) mean() float64 {
+	if d.n == 0 {
+		return 0
+	}
+	return d.sum / float64(d.n)
+}
+
+// thresholdCanary re-decides with candidate thresholds, which is how
+// threshold changes are validated before being rolled out.
+type thresholdCanary struct {
+	livenessThreshold   float64
+	similarityThreshold float64
+}
+
+func (c *thresholdCanary) Name() string {
+	return "thresholds"
+}
+
+func (c *thresholdCanary) Evaluate(input CanaryInput) (bool, error) {
+	if input.LivenessScore < c.livenessThreshold {
+		return false, nil
+	}
+	if input.UserID == "" {
+		return true, nil
+	}
+	return input.RawConfidence >= c.similarityThreshold, nil
+}
+
+// SetCanaryPipeline replaces the candidate pipeline run for sampled traffic.
+// A replaced pipeline holding models is closed.
+func (s *FaceVerificationService) SetCanaryPipeline(pipeline CanaryPipeline) {
+	s.canaryMutex.Lock()
+	replaced := s.canary
+	s.canary = pipeline
+	s.canaryMutex.Unlock()
+	closeCanary(replaced)
+}
+
+func closeCanary(pipeline CanaryPipeline) {
+	if closer, ok := pipeline.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+func (s *FaceVerificationService) CanaryStats() CanaryStats {
+	s.canaryCounters.deltaMutex.Lock()
+	livenessDeltas := s.canaryCounters.livenessDeltas
+	similarityDeltas := s.canaryCounters.similarityDeltas
+	s.canaryCounters.deltaMutex.Unlock()
+
+	return CanaryStats{
+		Runs:                s.canaryCounters.runs.Load(),
+		Agreements:          s.canaryCounters.agreements.Load(),
+		Disagreements:       s.canaryCounters.disagreements.Load(),
+		Errors:              s.canaryCounters.errors.Load(),
+		LivenessDeltas:      livenessDeltas.n,
+		MeanLivenessDelta:   livenessDeltas.mean(),
+		SimilarityDeltas:    similarityDeltas.n,
+		MeanSimilarityDelta: similarityDeltas.mean(),
+	}
+}
+
+// maybeRunCanary evaluates the candidate pipeline for a sampled fraction of
+// traffic in the background. The stable result is never modified.
+func (s *FaceVerificationService) maybeRunCanary(input CanaryInput, stable *models.VerificationResult) {
+	s.canaryMutex.RLock()
+	pipeline := s.canary
+	s.canaryMutex.RUnlock()
+
+	if pipeline == nil || stable.Synthetic || s.config.CanaryFraction <= 0 || rand.Float64() >= s.config.CanaryFraction {
+		return
+	}
+
+	verificationID := stable.VerificationID
+	stableVerified := stable.Verified
+	input.Tenant = stable.Tenant
+	input.Verified = stable.Verified
+
+	go func() {
+		s.canaryCounters.runs.Add(1)
+		metrics.CanaryRuns.Add(1)
+
+		var verified bool
+		var err error
+		scorer, scoring := pipeline.(CanaryScorer)
+		if scoring {
+			var scores CanaryScores
+			if scores, err = scorer.Score(input); err == nil {
+				verified = scores.Verified
+				s.recordCanaryScores(pipeline.Name(), verificationID, scores)
+			}
+		} else {
+			verified, err = pipeline.Evaluate(input)
+		}
+		if err != nil {
+			s.canaryCounters.errors.Add(1)
+			metrics.CanaryErrors.Add(1)
+			s.logger.Warn("Canary evaluation failed",
+				zap.String("pipeline", pipeline.Name()),
+				zap.String("verification_id", verificationID),
+				zap.Error(err))
+			return
+		}
+
+		if verified == stableVerified {
+			s.canaryCounters.agreements.Add(1)
+			metrics.CanaryAgreements.Add(1)
+			return
+		}
+
+		s.canaryCounters.disagreements.Add(1)
+		metrics.CanaryDisagreements.Add(1)
+		s.logger.Info("Canary disagreed with stable pipeline",
+			zap.String("pipeline", pipeline.Name()),
+			zap.String("verification_id", verificationID),
+			zap.Bool("stable_verified", stableVerified),
+			zap.Bool("canary_verified", verified))
+	}()
+}
+
+// recordCanaryScores logs a candidate's score deltas and adds them to the
+// canary statistics.
+func (s *FaceVerificationService) recordCanaryScores(name, verificationID string, scores CanaryScores) {
+	if !scores.LivenessScored && !scores.SimilarityScored {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("pipeline", name),
+		zap.String("verification_id", verificationID),
+	}
+
+	s.canaryCounters.deltaMutex.Lock()
+	if scores.LivenessScored {
+		s.canaryCounters.livenessDeltas.add(scores.LivenessDelta)
+		fields = append(fields, zap.Float64("liveness_delta", scores.LivenessDelta))
+	}
+	if scores.SimilarityScored {
+		s.canaryCounters.similarityDeltas.add(scores.SimilarityDelta)
+		fields = append(fields, zap.Float64("similarity_delta", scores.SimilarityDelta))
+	}
+	s.canaryCounters.deltaMutex.Unlock()
+
+	if scores.LivenessScored {
+		metrics.CanaryLivenessDeltas.Add(1)
+		metrics.CanaryLivenessDeltaSum.Add(scores.LivenessDelta)
+	}
+	if scores.SimilarityScored {
+		metrics.CanarySimilarityDeltas.Add(1)
+		metrics.CanarySimilarityDeltaSum.Add(scores.SimilarityDelta)
+	}
+	s.logger.Info("Canary models scored capture", fields...)
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Kagami/go-face"
+
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var errCanaryClosed = errors.New("canary models closed")
+
+// canaryPairCapacity is how many users' last captures modelCanary keeps to
+// pair with their next one.
+const canaryPairCapacity = 10000
+
+// modelCanary scores sampled captures with candidate models: the dlib
+// models in CANARY_FACE_MODEL_PATH and the ONNX liveness model at
+// CANARY_ONNX_LIVENESS_MODEL_PATH. Candidate descriptors cannot be compared
+// with templates of the stable models, so the candidate recognizer is
+// judged on pairs of verified captures of the same user instead: the
+// similarity delta is how much closer the candidate finds the pair than the
+// stable models do. Decisions use the candidate liveness score with the
+// stable similarity, against the CANARY_* thresholds where set.
+type modelCanary struct {
+	service             *FaceVerificationService
+	livenessThreshold   float64
+	similarityThreshold float64
+
+	// Candidate models run one capture at a time; nil once closed
+	mu         sync.Mutex
+	recognizer *face.Recognizer
+	liveness   *onnxLiveness
+	pairs      *canaryPairs
+}
+
+// newModelCanary loads the candidate models, returning nil when none is
+// configured.
+func newModelCanary(s *FaceVerificationService) (*modelCanary, error) {
+	cfg := s.config
+	if cfg.CanaryFaceModelPath == "" && cfg.CanaryONNXLivenessModelPath == "" {
+		return nil, nil
+	}
+
+	c := &modelCanary{
+		service:             s,
+		livenessThreshold:   cfg.CanaryLivenessThreshold,
+		similarityThreshold: cfg.CanarySimilarityThreshold,
+		pairs:               newCanaryPairs(canaryPairCapacity),
+	}
+	if cfg.CanaryFaceModelPath != "" {
+		rec, err := face.NewRecognizer(cfg.CanaryFaceModelPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load candidate recognizer: %w", err)
+		}
+		c.recognizer = rec
+	}
+	if cfg.CanaryONNXLivenessModelPath != "" {
+		candidate := *cfg
+		candidate.ONNXLivenessModelPath = cfg.CanaryONNXLivenessModelPath
+		model, err := newONNXLiveness(&candidate)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to load candidate liveness model: %w", err)
+		}
+		c.liveness = model
+	}
+	return c, nil
+}
+
+func (c *modelCanary) Name() string {
+	return "models"
+}
+
+func (c *modelCanary) Evaluate(input CanaryInput) (bool, error) {
+	scores, err := c.Score(input)
+	return scores.Verified, err
+}
+
+func (c *modelCanary) Score(input CanaryInput) (CanaryScores, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.recognizer == nil && c.liveness == nil {
+		return CanaryScores{}, errCanaryClosed
+	}
+
+	var scores CanaryScores
+	livenessScore := input.LivenessScore
+	if c.liveness != nil {
+		if candidate, ok := c.candidateLiveness(input); ok {
+			scores.LivenessDelta = candidate - input.LivenessScore
+			scores.LivenessScored = true
+			livenessScore = candidate
+		}
+	}
+
+	if c.recognizer != nil && input.UserID != "" && len(input.FaceVector) > 0 && len(input.Frames) > 0 {
+		rgba, width, height, release := toRGBA(input.Frames[0])
+		descriptor, err := describeFace(c.recognizer, rgba, width, height, c.service.recognizers.cnn, nil)
+		release()
+		if err != nil {
+			return CanaryScores{}, err
+		}
+
+		userKey := tenant.UserKey(input.Tenant, input.UserID)
+		if previous, ok := c.pairs.get(userKey); ok {
+			stable := c.service.cosineSimilarity(input.FaceVector, previous.stable)
+			candidate := c.service.cosineSimilarity(descriptor, previous.candidate)
+			scores.SimilarityDelta = candidate - stable
+			scores.SimilarityScored = true
+		}
+		// Only captures the stable pipeline verified pair up as the same face
+		if input.Verified {
+			c.pairs.put(userKey, canaryPair{stable: input.FaceVector, candidate: descriptor})
+		}
+	}
+
+	scores.Verified = c.decide(input, livenessScore)
+	return scores, nil
+}
+
+// candidateLiveness is the stable liveness score with the candidate model's
+// score in place of the stable ONNX detector's, or mixed in at
+// ONNX_LIVENESS_WEIGHT when the stable pipeline did not run one.
+func (c *modelCanary) candidateLiveness(input CanaryInput) (float64, bool) {
+	score, _, ok := c.liveness.Detect(input.Frames)
+	if !ok {
+		return 0, false
+	}
+	if input.Liveness != nil {
+		if weight, ok := input.Liveness.Weights[DetectorONNX]; ok {
+			return input.LivenessScore + weight*(score-input.Liveness.Features["onnx_score"]), true
+		}
+	}
+	weight := onnxLivenessWeight(c.service.config)
+	return input.LivenessScore*(1-weight) + score*weight, true
+}
+
+func (c *modelCanary) decide(input CanaryInput, livenessScore float64) bool {
+	livenessThreshold := c.livenessThreshold
+	if livenessThreshold <= 0 {
+		livenessThreshold = c.service.livenessThreshold(input.Tenant)
+	}
+	if livenessScore < livenessThreshold {
+		return false
+	}
+	if input.UserID == "" {
+		return true
+	}
+	similarityThreshold := c.similarityThreshold
+	if similarityThreshold <= 0 {
+		similarityThreshold = c.service.similarityThreshold(input.Tenant)
+	}
+	return input.RawConfidence >= similarityThreshold
+}
+
+// Close releases the candidate models; later captures fail to score.
+func (c *modelCanary) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.recognizer != nil {
+		c.recognizer.Close()
+		c.recognizer = nil
+	}
+	if c.liveness != nil {
+		c.liveness.Close()
+		c.liveness = nil
+	}
+	return nil
+}
+
+// canaryPair is a capture's descriptor under the stable and the candidate
+// models.
+type canaryPair struct {
+	stable    []float32
+	candidate []float32
+}
+
+// canaryPairs keeps the last verified capture of up to capacity users,
+// forgetting the users added first. It is guarded by the canary's mutex.
+type canaryPairs struct {
+	capacity int
+	pairs    map[string]canaryPair
+	order    []string
+}
+
+func newCanaryPairs(capacity int) *canaryPairs {
+	return &canaryPairs{capacity: capacity, pairs: make(map[string]canaryPair)}
+}
+
+func (p *canaryPairs) get(userKey string) (canaryPair, bool) {
+	pair, ok := p.pairs[userKey]
+	return pair, ok
+}
+
+func (p *canaryPairs) put(userKey string, pair canaryPair) {
+	if _, ok := p.pairs[userKey]; !ok {
+		p.order = append(p.order, userKey)
+		if len(p.order) > p.capacity {
+			delete(p.pairs, p.order[0])
+			p.order = p.order[1:]
+		}
+	}
+	p.pairs[userKey] = pair
+}
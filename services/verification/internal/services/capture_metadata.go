@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// How much of a capture is scanned for encoder markers: its head, the
+// start of its media data and all of its MP4 movie header up to a cap
+const (
+	metadataScanBytes = 64 << 10
+	maxMoovBytes      = 16 << 20
+)
+
+// captureMetadata is what a capture's container says about how it was
+// made.
+type captureMetadata struct {
+	// Bytes encoder markers are searched in
+	scanned [][]byte
+	// Sample durations of the video track, in 1/timescale seconds
+	frameDeltas []uint32
+	timescale   uint32
+	// Clockwise rotation the video track is displayed with, in degrees
+	rotation int
+}
+
+// readCaptureMetadata reads the container of a capture. Only ISO BMFF
+// (MP4, MOV) is parsed; any other format yields just its head to scan.
+func readCaptureMetadata(r io.Reader) captureMetadata {
+	head := make([]byte, metadataScanBytes)
+	n, _ := io.ReadFull(r, head)
+	head = head[:n]
+	meta := captureMetadata{scanned: [][]byte{head}}
+	if len(head) < 8 || string(head[4:8]) != "ftyp" {
+		return meta
+	}
+
+	stream := io.MultiReader(bytes.NewReader(head), r)
+	var moov, mdat []byte
+	for moov == nil || mdat == nil {
+		boxType, size, err := readBoxHeader(stream)
+		if err != nil {
+			break
+		}
+		if boxType == "moov" && size >= 0 && size <= maxMoovBytes {
+			moov = make([]byte, size)
+			if _, err := io.ReadFull(stream, moov); err != nil {
+				moov = nil
+				break
+			}
+			continue
+		}
+		if boxType == "mdat" && mdat == nil {
+			// Encoders such as x264 sign the first frame they write
+			limit := int64(metadataScanBytes)
+			if size >= 0 && size < limit {
+				limit = size
+			}
+			mdat, _ = io.ReadAll(io.LimitReader(stream, limit))
+			if size < 0 {
+				// The media data runs to the end of the file
+				break
+			}
+			size -= int64(len(mdat))
+		}
+		if size < 0 {
+			break
+		}
+		if _, err := io.CopyN(io.Discard, stream, size); err != nil {
+			break
+		}
+	}
+
+	meta.scanned = append(meta.scanned, moov, mdat)
+	meta.timescale, meta.frameDeltas = videoTrackTiming(moov)
+	meta.rotation = videoTrackRotation(moov)
+	return meta
+}
+
+// readBoxHeader reads an ISO BMFF box header and returns the box type and
+// the size of its payload, -1 when the box runs to the end of the file.
+func readBoxHeader(r io.Reader) (string, int64, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", 0, err
+	}
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	boxType := string(header[4:8])
+	switch size {
+	case 0:
+		return boxType, -1, nil
+	case 1:
+		var large [8]byte
+		if _, err := io.ReadFull(r, large[:]); err != nil {
+			return "", 0, err
+		}
+		size = int64(binary.BigEndian.Uint64(large[:])) - 16
+	default:
+		size -= 8
+	}
+	if size < 0 {
+		return "", 0, io.ErrUnexpectedEOF
+	}
+	return boxType, size, nil
+}
+
+// childBoxes calls visit with the type and payload of every box in payload,
+// stopping at the first malformed one.
+func childBoxes(payload []byte, visit func(boxType string, body []byte)) {
+	for len(payload) >= 8 {
+		size := int(binary.BigEndian.Uint32(payload[:4]))
+		boxType := string(payload[4:8])
+		headerSize := 8
+		if size == 1 && len(payload) >= 16 {
+			size = int(binary.BigEndian.Uint64(payload[8:16]))
+			headerSize = 16
+		} else if size == 0 {
+			size = len(payload)
+		}
+		if size < headerSize || size > len(payload) {
+			return
+		}
+		visit(boxType, payload[headerSize:size])
+		payload = payload[size:]
+	}
+}
+
+func firstChild(payload []byte, want string) []byte {
+	var found []byte
+	childBoxes(payload, func(boxType string, body []byte) {
+		if found == nil && boxType == want {
+			found = body
+		}
+	})
+	return found
+}
+
+// videoTrackTiming returns the media timescale and the sample durations of
+// the first video track in a moov payload.
+func videoTrackTiming(moov []byte) (uint32, []uint32) {
+	var timescale uint32
+	var deltas []uint32
+	childBoxes(moov, func(boxType string, trak []byte) {
+		if boxType != "trak" || deltas != nil {
+			return
+		}
+		mdia := firstChild(trak, "mdia")
+		hdlr := firstChild(mdia, "hdlr")
+		if len(hdlr) < 12 || string(hdlr[8:12]) != "vide" {
+			return
+		}
+
+		mdhd := firstChild(mdia, "mdhd")
+		switch {
+		case len(mdhd) >= 24 && mdhd[0] == 1:
+			timescale = binary.BigEndian.Uint32(mdhd[20:24])
+		case len(mdhd) >= 16:
+			timescale = binary.BigEndian.Uint32(mdhd[12:16])
+		default:
+			return
+		}
+
+		stts := firstChild(firstChild(firstChild(mdia, "minf"), "stbl"), "stts")
+		if len(stts) < 8 {
+			return
+		}
+		entries := binary.BigEndian.Uint32(stts[4:8])
+		deltas = []uint32{}
+		for i := uint32(0); i < entries && 8+int(i+1)*8 <= len(stts); i++ {
+			entry := stts[8+i*8:]
+			count := binary.BigEndian.Uint32(entry[:4])
+			delta := binary.BigEndian.Uint32(entry[4:8])
+			// Runs are kept as one delta each; the count adds no evidence
+			if count > 0 {
+				deltas = append(deltas, delta)
+			}
+		}
+	})
+	return timescale, deltas
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"time"
+
+	"github.com/Kagami/go-face"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrInvalidDocument = errors.New("invalid document image")
+var ErrNoFaceInCapture = errors.New("no face detected in the capture")
+var ErrNoFaceInDocument = errors.New("no face detected in the document")
+
+// maxImagePixels bounds the decoded size of an uploaded photo, so a small
+// file declaring huge dimensions cannot exhaust memory.
+const maxImagePixels = 40_000_000
+
+func compareSimilarityThreshold(threshold, fallback float64) float64 {
+	if threshold > 0 {
+		return threshold
+	}
+	return fallback
+}
+
+// decodeDocument decodes a JPEG or PNG document photo, upright, along
+// with the EXIF orientation applied.
+func decodeDocument(data []byte) (image.Image, int, error) {
+	img, orientation, err := decodePhoto(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return img, orientation, nil
+}
+
+// decodePhoto decodes a JPEG or PNG photo of at most maxImagePixels and
+// turns it upright by its EXIF orientation, which it also returns.
+func decodePhoto(data []byte) (image.Image, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, 0, fmt.Errorf("%dx%d is too large", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+	orientation := jpegOrientation(data)
+	return orientImage(img, orientation), orientation, nil
+}
+
+// CompareFaces compares the face in a selfie capture with the face in a
+// document photo, for onboarding without a prior enrollment. Nothing is
+// stored. The selfie must pass liveness for the faces to match.
+func (s *FaceVerificationService) CompareFaces(ctx context.Context, video models.VideoSource, document []byte) (*models.FaceComparison, error) {
+	startTime := time.Now()
+
+	documentImage, orientation, err := decodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	s.logOrientation("document", orientation)
+
+	comparison, _, err := s.compareWithDocument(ctx, video, documentImage)
+	if err != nil {
+		return nil, err
+	}
+	comparison.ProcessingTime = time.Since(startTime).Seconds()
+	return comparison, nil
+}
+
+// compareWithDocument runs the comparison on a decoded document, also
+// returning where the document's face was found.
+func (s *FaceVerificationService) compareWithDocument(ctx context.Context, video models.VideoSource, documentImage image.Image) (*models.FaceComparison, image.Rectangle, error) {
+	release, err := s.admission.acquire(ctx)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+	defer release()
+
+	frames, err := s.extractFramesFromVideo(ctx, video)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+	if len(frames) == 0 {
+		return nil, image.Rectangle{}, fmt.Errorf("no frames extracted")
+	}
+
+	liveness, err := s.detectLiveness(tenant.Default, frames)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+
+	selfieVector, err := s.captureFaceVector(frames, nil)
+	if err != nil {
+		if errors.Is(err, ErrNoFaceDetected) {
+			return nil, image.Rectangle{}, ErrNoFaceInCapture
+		}
+		return nil, image.Rectangle{}, err
+	}
+
+	var portrait image.Rectangle
+	documentVector, err := s.generateCheckedFaceVector(documentImage, func(detected face.Face) error {
+		portrait = detected.Rectangle
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoFaceDetected) {
+			return nil, image.Rectangle{}, ErrNoFaceInDocument
+		}
+		return nil, image.Rectangle{}, err
+	}
+
+	similarity := s.cosineSimilarity(selfieVector, documentVector)
+	threshold := compareSimilarityThreshold(s.config.CompareSimilarityThreshold, s.currentTuning().similarityThreshold)
+
+	comparison := &models.FaceComparison{
+		Similarity:    similarity,
+		Confidence:    s.calibration.Apply(similarity),
+		IsLive:        liveness.IsLive,
+		LivenessScore: liveness.Score,
+	}
+	switch {
+	case !liveness.IsLive:
+		comparison.Reason = models.ReasonLivenessFailed
+	case similarity < threshold:
+		comparison.Reason = models.ReasonLowSimilarity
+	default:
+		comparison.Match = true
+	}
+	return comparison, portrait, nil
+}
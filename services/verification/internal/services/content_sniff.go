@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bytes"
+)
+
+// Capture formats SniffContentType tells apart by their leading bytes
+const (
+	ContentTypeWebM      = "video/webm"
+	ContentTypeMP4       = "video/mp4"
+	ContentTypeQuickTime = "video/quicktime"
+	ContentTypeAVI       = "video/avi"
+	ContentTypeJPEG      = "image/jpeg"
+	ContentTypePNG       = "image/png"
+)
+
+// SniffLength is how many leading bytes SniffContentType needs.
+const SniffLength = 512
+
+var (
+	ebmlMagic = []byte{0x1A, 0x45, 0xDF, 0xA3}
+	pngMagic  = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+)
+
+// quickTimeAtoms are top-level atoms that open QuickTime files predating
+// the ftyp box.
+var quickTimeAtoms = []string{"moov", "mdat", "wide", "free", "skip", "pnot"}
+
+// SniffContentType identifies a capture from its first bytes, ignoring what
+// the client declared. It returns "" for formats it does not know.
+func SniffContentType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, ebmlMagic):
+		// Matroska's EBML header; browsers record the WebM profile of it
+		return ContentTypeWebM
+	case IsJPEG(header):
+		return ContentTypeJPEG
+	case bytes.HasPrefix(header, pngMagic):
+		return ContentTypePNG
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "AVI ":
+		return ContentTypeAVI
+	case len(header) >= 12 && string(header[4:8]) == "ftyp":
+		// The major brand tells QuickTime movies from other ISO media files
+		if string(header[8:12]) == "qt  " {
+			return ContentTypeQuickTime
+		}
+		return ContentTypeMP4
+	case len(header) >= 8:
+		for _, atom := range quickTimeAtoms {
+			if string(header[4:8]) == atom {
+				return ContentTypeQuickTime
+			}
+		}
+	}
+	return ""
+}
+
+// ContentTypeMatches reports whether content sniffed as sniffed may be sent
+// as declared. MP4 and QuickTime share a container and clients label them
+// interchangeably, so either is accepted for the other.
+func ContentTypeMatches(declared, sniffed string) bool {
+	family := func(contentType string) string {
+		switch contentType {
+		case "video/mov", ContentTypeQuickTime, ContentTypeMP4:
+			return "isobmff"
+		}
+		return contentType
+	}
+	return sniffed != "" && family(declared) == family(sniffed)
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrPrecheckDisabled = errors.New("liveness pre-check is disabled")
+var ErrContinuationExpired = errors.New("continuation token is unknown or expired")
+
+// continuation holds the decoded frames of a capture that passed the
+// liveness pre-check, so matching can run later without re-decoding.
+type continuation struct {
+	verificationID string
+	tenant         string
+	frames         []image.Image
+	liveness       *models.LivenessResult
+	device         string
+	region         string
+	clientIP       string
+	// Injection detection's verdict on the capture, when it ran
+	injectionRisk    *float64
+	injectionSignals []string
+	expiresAt        time.Time
+}
+
+type continuations struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]continuation
+}
+
+func newContinuations(ttl time.Duration) *continuations {
+	return &continuations{
+		ttl:     ttl,
+		entries: make(map[string]continuation),
+	}
+}
+
+func (c *continuations) put(token string, entry continuation) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entry.expiresAt = now.Add(c.ttl)
+	c.entries[token] = entry
+
+	// Opportunistically drop expired entries so abandoned captures are freed
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	return entry.expiresAt
+}
+
+// take returns and removes the entry; tokens are single use. Another
+// tenant's token is left in place, as if it did not exist.
+func (c *continuations) take(token, tenantID string) (continuation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[token]
+	if !ok || entry.tenant != tenantID {
+		return continuation{}, false
+	}
+	delete(c.entries, token)
+	if time.Now().After(entry.expiresAt) {
+		return continuation{}, false
+	}
+	return entry, true
+}
+
+func newContinuationToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// PrecheckLiveness is phase one of a two-phase verification: it only decodes
+// the capture and runs liveness. Live captures are cached under a short-lived
+// continuation token for ContinueVerification.
+func (s *FaceVerificationService) PrecheckLiveness(req *models.VerificationRequest) (*models.PrecheckResult, error) {
+	if !s.config.LivenessPrecheckEnabled {
+		return nil, ErrPrecheckDisabled
+	}
+
+	startTime := time.Now()
+	result := &models.PrecheckResult{
+		VerificationID: fmt.Sprintf("ver_%d", startTime.UnixNano()),
+	}
+
+	frames, err := s.extractFramesFromVideo(context.Background(), requestVideo(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract frames: %w", err)
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames extracted")
+	}
+
+	if s.config.CameraCheckEnabled && s.isCameraBlocked(frames) {
+		result.Reason = models.ReasonCameraBlocked
+		result.ProcessingTime = time.Since(startTime).Seconds()
+		return result, nil
+	}
+
+	if s.config.InjectionDetectionEnabled {
+		risk, signals, suspected := s.injectionSuspected(req, frames)
+		result.InjectionRisk = &risk
+		result.InjectionSignals = signals
+		if suspected {
+			result.Reason = models.ReasonInjectionSuspected
+			result.ProcessingTime = time.Since(startTime).Seconds()
+			return result, nil
+		}
+	}
+
+	liveness, err := s.detectLiveness(req.Tenant, frames)
+	if err != nil {
+		return nil, fmt.Errorf("liveness detection failed: %w", err)
+	}
+
+	result.IsLive = liveness.IsLive
+	result.LivenessScore = liveness.Score
+	if !liveness.IsLive {
+		result.Reason = models.ReasonLivenessFailed
+		result.ProcessingTime = time.Since(startTime).Seconds()
+		return result, nil
+	}
+
+	token, err := newContinuationToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate continuation token: %w", err)
+	}
+
+	expiresAt := s.continuations.put(token, continuation{
+		verificationID: result.VerificationID,
+		tenant:         req.Tenant,
+		frames:         frames,
+		liveness:       liveness,
+		device:         req.Device,
+		region:         req.Region,
+		clientIP:       req.ClientIP,
+
+		injectionRisk:    result.InjectionRisk,
+		injectionSignals: result.InjectionSignals,
+	})
+	result.ContinuationToken = token
+	result.ExpiresAt = &expiresAt
+	result.ProcessingTime = time.Since(startTime).Seconds()
+
+	return result, nil
+}
+
+// ContinueVerification is phase two: it runs matching on the frames cached by
+// PrecheckLiveness. The token is consumed whether or not matching succeeds,
+// even by a locked out user or client; only the tenant that ran the
+// pre-check can redeem it.
+func (s *FaceVerificationService) ContinueVerification(token, tenantID, userID string) (*models.VerificationResult, error) {
+	entry, ok := s.continuations.take(token, tenantID)
+	if !ok {
+		return nil, ErrContinuationExpired
+	}
+	if err := s.CheckLockout(tenantID, userID, entry.clientIP); err != nil {
+		return nil, err
+	}
+
+	var userKey string
+	if userID != "" {
+		userKey = tenant.UserKey(tenantID, userID)
+	}
+
+	startTime := time.Now()
+	result := &models.VerificationResult{
+		VerificationID:   entry.verificationID,
+		UserID:           userID,
+		Tenant:           tenantID,
+		Device:           entry.device,
+		LivenessScore:    entry.liveness.Score,
+		Timestamp:        startTime,
+		ProcessingRegion: NormalizeRegion(s.config.Region),
+		ClientRegion:     NormalizeRegion(entry.region),
+		InjectionRisk:    entry.injectionRisk,
+		InjectionSignals: entry.injectionSignals,
+	}
+
+	faceVector, err := s.captureFaceVector(entry.frames, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("Face vector generation failed: %v", err)
+		return result, err
+	}
+
+	s.screenWatchlist(result, faceVector)
+	s.checkVelocity(context.Background(), result, faceVector, entry.clientIP)
+	s.decideMatch(result, userKey, faceVector, entry.liveness.Score)
+
+	s.exportDatasetSample(entry.liveness, result)
+	s.maybeRunCanary(CanaryInput{
+		Frames:        entry.frames,
+		FaceVector:    faceVector,
+		UserID:        userID,
+		Liveness:      entry.liveness,
+		LivenessScore: entry.liveness.Score,
+		RawConfidence: result.RawConfidence,
+	}, result)
+	s.assessRisk(result, entry.clientIP)
+	s.holdForReview(result, entry.frames[0], entry.liveness)
+
+	result.ProcessingTime = time.Since(startTime).Seconds()
+	s.recordResult(result)
+	s.countAttempt(result, entry.clientIP)
+
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// DatasetSink receives anonymized evaluation samples.
+type DatasetSink interface {
+	Write(sample models.DatasetSample) error
+	Close() error
+}
+
+// fileDatasetSink appends samples as JSON lines to a local file.
+type fileDatasetSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileDatasetSink(path string) (DatasetSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileDatasetSink{file: file}, nil
+}
+
+func (f *fileDatasetSink) Write(sample models.DatasetSample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+func (f *fileDatasetSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+func (s *FaceVerificationService) exportDatasetSample(liveness *models.LivenessResult, result *models.VerificationResult) {
+	if s.datasetSink == nil || liveness == nil || result.Synthetic {
+		return
+	}
+
+	// Copy only the scalar features; nothing derived from pixels or
+	// descriptors beyond these aggregate scores leaves the service.
+	features := make(map[string]float64, len(liveness.Features)+1)
+	for name, value := range liveness.Features {
+		features[name] = value
+	}
+	features["liveness_score"] = liveness.Score
+
+	sample := models.DatasetSample{
+		Features: features,
+		IsLive:   liveness.IsLive,
+		Verified: result.Verified,
+	}
+
+	if err := s.datasetSink.Write(sample); err != nil {
+		s.logger.Warn("Failed to export dataset sample", zap.Error(err))
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+)
+
+const (
+	DedupKeyUser  = "user"
+	DedupKeyVideo = "video"
+)
+
+type flightCall struct {
+	done   chan struct{}
+	result *models.VerificationResult
+	err    error
+}
+
+// verifyFlights is a single-flight group for verifications: callers with the
+// same key while a call is in flight, or within the window after it finished,
+// share its result instead of running the pipeline again.
+type verifyFlights struct {
+	mu     sync.Mutex
+	window time.Duration
+	calls  map[string]*flightCall
+}
+
+func newVerifyFlights(window time.Duration) *verifyFlights {
+	return &verifyFlights{
+		window: window,
+		calls:  make(map[string]*flightCall),
+	}
+}
+
+func (f *verifyFlights) do(key string, fn func() (*models.VerificationResult, error)) (*models.VerificationResult, bool, error) {
+	f.mu.Lock()
+	if call, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		<-call.done
+		return call.result, true, call.err
+	}
+
+	call := &flightCall{done: make(chan struct{})}
+	f.calls[key] = call
+	f.mu.Unlock()
+
+	call.result, call.err = fn()
+	close(call.done)
+
+	// Failures are not worth replaying; successes stay shareable for the window
+	if call.err != nil {
+		f.forget(key, call)
+	} else {
+		time.AfterFunc(f.window, func() { f.forget(key, call) })
+	}
+
+	return call.result, false, call.err
+}
+
+func (f *verifyFlights) forget(key string, call *flightCall) {
+	f.mu.Lock()
+	if f.calls[key] == call {
+		delete(f.calls, key)
+	}
+	f.mu.Unlock()
+}
+
+// dedupKey returns the single-flight key for a request, or "" when the
+// request should not be deduplicated.
+func (s *FaceVerificationService) dedupKey(req *models.VerificationRequest) string {
+	switch s.config.DedupKey {
+	case DedupKeyUser:
+		if req.UserID == "" {
+			return ""
+		}
+		return "user:" + galleryKey(req)
+	default:
+		return "video:" + RequestContentKey(req)
+	}
+}
+
+// VerifyVideoDeduplicated runs VerifyVideo, sharing the outcome between
+// identical requests that arrive within the dedup window (e.g. a double-tap).
+// The boolean reports whether the result was shared from another call.
+func (s *FaceVerificationService) VerifyVideoDeduplicated(req *models.VerificationRequest) (*models.VerificationResult, bool, error) {
+	return s.VerifyVideoDeduplicatedContext(context.Background(), req)
+}
+
+// VerifyVideoDeduplicatedContext is VerifyVideoDeduplicated bounded by ctx.
+// A shared run serves every caller, so it is not canceled with the first
+// caller's ctx; only PROCESSING_TIMEOUT bounds it.
+func (s *FaceVerificationService) VerifyVideoDeduplicatedContext(ctx context.Context, req *models.VerificationRequest) (*models.VerificationResult, bool, error) {
+	if s.dedup == nil {
+		result, err := s.VerifyVideoContext(ctx, req)
+		return result, false, err
+	}
+
+	key := s.dedupKey(req)
+	if key == "" {
+		result, err := s.VerifyVideoContext(ctx, req)
+		return result, false, err
+	}
+
+	result, shared, err := s.dedup.do(key, func() (*models.VerificationResult, error) {
+		flightCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ProcessingTimeout(s.config))
+		defer cancel()
+		return s.VerifyVideoContext(flightCtx, req)
+	})
+	if shared {
+		metrics.DedupHits.Add(1)
+		s.logger.Info("Shared in-flight verification result",
+			zap.String("session_id", req.SessionID),
+			zap.String("dedup_key", s.config.DedupKey))
+	}
+	return result, shared, err
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// descriptorCacheTimeout bounds a cache lookup or store, so a slow Redis
+// costs a retry no more than recomputing would.
+const descriptorCacheTimeout = 200 * time.Millisecond
+
+func newDescriptorCache(cfg *config.Config) (storage.DescriptorCache, error) {
+	if cfg.DescriptorCacheTTL <= 0 {
+		return nil, nil
+	}
+	switch cfg.DescriptorCacheStore {
+	case "", "memory":
+		return storage.NewMemoryDescriptorCache(cfg.DescriptorCacheSize), nil
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis descriptor cache")
+		}
+		return storage.NewRedisDescriptorCache(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unknown descriptor cache store %q", cfg.DescriptorCacheStore)
+	}
+}
+
+// SetDescriptorCache replaces the cache capture analyses are kept in; nil
+// turns descriptor caching off.
+func (s *FaceVerificationService) SetDescriptorCache(cache storage.DescriptorCache) {
+	s.descriptorCache = cache
+}
+
+// descriptorCacheKey identifies req's payload for the descriptor cache: its
+// SHA-256 within the tenant, whose settings the liveness decision follows,
+// and the recognizer model its descriptor is of. Unlike ContentKey it
+// leaves the user out, since the analysis does not depend on the gallery.
+// Requests whose checks depend on more than the payload, an active
+// liveness session or a declared action, are not cached.
+func (s *FaceVerificationService) descriptorCacheKey(req *models.VerificationRequest) (string, bool) {
+	if s.descriptorCache == nil || req.Synthetic || req.LivenessSession != "" {
+		return "", false
+	}
+	if s.config.ActionCheckEnabled && req.Action != "" {
+		return "", false
+	}
+	return contentKeyFromDigest(payloadDigest(req), req.Tenant+"\x00"+s.descriptorVersion), true
+}
+
+// cachedAnalysis returns the analysis cached for key. One without a face
+// quality does not serve requests that refresh templates. Cache errors are
+// logged and count as misses.
+func (s *FaceVerificationService) cachedAnalysis(ctx context.Context, key string, needQuality bool) *storage.CachedAnalysis {
+	ctx, cancel := context.WithTimeout(ctx, descriptorCacheTimeout)
+	defer cancel()
+
+	analysis, err := s.descriptorCache.Get(ctx, key)
+	if err != nil {
+		s.logger.Warn("Descriptor cache lookup failed", zap.Error(err))
+	}
+	if analysis == nil || analysis.Liveness == nil || (needQuality && !analysis.QualityAssessed) {
+		metrics.DescriptorCacheMisses.Add(1)
+		return nil
+	}
+	metrics.DescriptorCacheHits.Add(1)
+	if analysis.Liveness.Features == nil {
+		analysis.Liveness.Features = analysis.LivenessFeatures
+	}
+	return analysis
+}
+
+// cacheAnalysis stores what the pipeline found in a capture for
+// DESCRIPTOR_CACHE_TTL.
+func (s *FaceVerificationService) cacheAnalysis(ctx context.Context, key string, analysis *storage.CachedAnalysis) {
+	ctx, cancel := context.WithTimeout(ctx, descriptorCacheTimeout)
+	defer cancel()
+
+	ttl := time.Duration(s.config.DescriptorCacheTTL) * time.Second
+	if err := s.descriptorCache.Put(ctx, key, analysis, ttl); err != nil {
+		s.logger.Warn("Failed to cache capture analysis", zap.Error(err))
+	}
+}
+
+// captureAnalysis is the part of result the capture alone determined,
+// along with its liveness and descriptor.
+func captureAnalysis(result *models.VerificationResult, liveness *models.LivenessResult, faceVector []float32, quality float64, qualityAssessed bool) *storage.CachedAnalysis {
+	return &storage.CachedAnalysis{
+		Liveness:            liveness,
+		LivenessFeatures:    liveness.Features,
+		FaceVector:          faceVector,
+		Quality:             quality,
+		QualityAssessed:     qualityAssessed,
+		OriginalResolution:  result.OriginalResolution,
+		ProcessedResolution: result.ProcessedResolution,
+		InjectionRisk:       result.InjectionRisk,
+		InjectionSignals:    result.InjectionSignals,
+		Warnings:            append([]models.VerificationWarning(nil), result.Warnings...),
+	}
+}
+
+// applyAnalysis fills in result what a cached analysis found in the
+// capture, flagging it as cached.
+func applyAnalysis(result *models.VerificationResult, analysis *storage.CachedAnalysis) {
+	result.OriginalResolution = analysis.OriginalResolution
+	result.ProcessedResolution = analysis.ProcessedResolution
+	result.InjectionRisk = analysis.InjectionRisk
+	result.InjectionSignals = analysis.InjectionSignals
+	result.Warnings = append([]models.VerificationWarning(nil), analysis.Warnings...)
+	result.AnalysisCached = true
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+var ErrReenrollmentRequired = errors.New("no template of the current recognizer model")
+
+// How templates from before descriptor versioning are treated, for
+// LEGACY_DESCRIPTORS
+const (
+	// LegacyDescriptorsCurrent takes them as made by the current models
+	LegacyDescriptorsCurrent = "current"
+	// LegacyDescriptorsStale requires their users to enroll again
+	LegacyDescriptorsStale = "stale"
+)
+
+// ValidLegacyDescriptors reports whether legacy is a LEGACY_DESCRIPTORS
+// setting.
+func ValidLegacyDescriptors(legacy string) bool {
+	switch legacy {
+	case "", LegacyDescriptorsCurrent, LegacyDescriptorsStale:
+		return true
+	}
+	return false
+}
+
+// The dlib models a descriptor depends on: the landmarks that align the
+// face and the network that embeds it
+var descriptorModelFiles = []string{
+	"shape_predictor_5_face_landmarks.dat",
+	"dlib_face_recognition_resnet_model_v1.dat",
+}
+
+// DescriptorModelVersion identifies the recognizer models in
+// FACE_MODEL_PATH by a hash of their files, so descriptors computed by
+// different models are told apart. With the remote embedder it is the
+// served model and, when pinned, its version.
+func DescriptorModelVersion(cfg *config.Config) (string, error) {
+	if cfg.EmbedderBackend == EmbedderRemote {
+		if cfg.EmbedderModelVersion == "" {
+			return "remote:" + cfg.EmbedderModel, nil
+		}
+		return "remote:" + cfg.EmbedderModel + "@" + cfg.EmbedderModelVersion, nil
+	}
+
+	hash := sha256.New()
+	for _, name := range descriptorModelFiles {
+		file, err := os.Open(filepath.Join(cfg.FaceModelPath, name))
+		if err != nil {
+			return "", fmt.Errorf("failed to hash recognizer model: %w", err)
+		}
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to hash recognizer model %s: %w", name, err)
+		}
+	}
+	return "dlib:" + hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+func legacyDescriptor(version string) bool {
+	return version == "" || version == models.LegacyDescriptorVersion
+}
+
+// currentDescriptor reports whether a template of version was computed by
+// the models of modelVersion and can be compared with their descriptors.
+func currentDescriptor(version, modelVersion, legacy string) bool {
+	if legacyDescriptor(version) {
+		return legacy != LegacyDescriptorsStale
+	}
+	return version == modelVersion
+}
+
+func (s *FaceVerificationService) currentDescriptor(version string) bool {
+	return currentDescriptor(version, s.descriptorVersion, s.config.LegacyDescriptors)
+}
+
+// currentTemplates returns the templates made by the current models.
+func (s *FaceVerificationService) currentTemplates(templates []models.FaceVector) []models.FaceVector {
+	current := make([]models.FaceVector, 0, len(templates))
+	for _, template := range templates {
+		if s.currentDescriptor(template.Version) {
+			current = append(current, template)
+		}
+	}
+	return current
+}
+
+// StaleTemplateCount returns how many of userID's templates were made by
+// other recognizer models and are no longer matched. userID is the user's
+// key; see tenant.UserKey.
+func (s *FaceVerificationService) StaleTemplateCount(userID string) int {
+	s.storageMutex.RLock()
+	defer s.storageMutex.RUnlock()
+	templates := s.faceVectors[userID]
+	return len(templates) - len(s.currentTemplates(templates))
+}
+
+// MigrateDescriptors goes over the enrollments in store after a recognizer
+// model change. Unless legacy is LegacyDescriptorsStale, templates from
+// before descriptor versioning are stamped with modelVersion. Users left
+// without a template of modelVersion are listed for re-enrollment, since
+// capture videos are not kept to compute their descriptors again.
+func MigrateDescriptors(store storage.VectorStore, modelVersion, legacy string) (*models.DescriptorMigration, error) {
+	return migrateDescriptors(store, modelVersion, legacy, nil)
+}
+
+func migrateDescriptors(store storage.VectorStore, modelVersion, legacy string, flag func(userKey string, stale int)) (*models.DescriptorMigration, error) {
+	gallery, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	userKeys := make([]string, 0, len(gallery))
+	for userKey := range gallery {
+		userKeys = append(userKeys, userKey)
+	}
+	sort.Strings(userKeys)
+
+	migration := &models.DescriptorMigration{ModelVersion: modelVersion, Users: len(userKeys)}
+	for _, userKey := range userKeys {
+		templates := gallery[userKey]
+		if legacy != LegacyDescriptorsStale {
+			if templates, err = stampLegacyDescriptors(store, userKey, modelVersion, migration); err != nil {
+				return nil, err
+			}
+		}
+
+		current := 0
+		for _, template := range templates {
+			if currentDescriptor(template.Version, modelVersion, legacy) {
+				current++
+			}
+		}
+		stale := len(templates) - current
+		migration.StaleTemplates += stale
+		if current == 0 && stale > 0 {
+			migration.ReenrollmentRequired = append(migration.ReenrollmentRequired, userKey)
+			if flag != nil {
+				flag(userKey, stale)
+			}
+		}
+	}
+	migration.MigratedAt = time.Now().UTC()
+	return migration, nil
+}
+
+// stampLegacyDescriptors rewrites userKey's legacy templates with
+// modelVersion and returns the user's templates.
+func stampLegacyDescriptors(store storage.VectorStore, userKey, modelVersion string, migration *models.DescriptorMigration) ([]models.FaceVector, error) {
+	templates, err := store.Load(userKey)
+	if err != nil {
+		return nil, err
+	}
+	stamped := 0
+	for i := range templates {
+		if legacyDescriptor(templates[i].Version) {
+			templates[i].Version = modelVersion
+			stamped++
+		}
+	}
+	if stamped == 0 {
+		return templates, nil
+	}
+
+	if err := store.Delete(userKey); err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		if err := store.Save(template); err != nil {
+			return nil, fmt.Errorf("failed to store templates: %w", err)
+		}
+	}
+	migration.Stamped += stamped
+	return templates, nil
+}
+
+// MigrateDescriptors is the package MigrateDescriptors over the service's
+// vector store with the models in use. Enrollments wait for it to finish.
+// Each user to re-enroll is published as a face.reenrollment_required
+// event.
+func (s *FaceVerificationService) MigrateDescriptors() (*models.DescriptorMigration, error) {
+	stale := make(map[string]int)
+	s.templateMutex.Lock()
+	migration, err := migrateDescriptors(s.vectorStore, s.descriptorVersion, s.config.LegacyDescriptors, func(userKey string, n int) {
+		stale[userKey] = n
+	})
+	s.templateMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.galleryChanged(); err != nil {
+		return nil, err
+	}
+
+	for _, userKey := range migration.ReenrollmentRequired {
+		s.publishEvent(models.EventReenrollmentRequired, userKey, "", models.ReenrollmentEvent{
+			ModelVersion:   migration.ModelVersion,
+			StaleTemplates: stale[userKey],
+		})
+	}
+	s.logger.Info("Stored descriptors migrated",
+		zap.String("model_version", migration.ModelVersion),
+		zap.Int("users", migration.Users),
+		zap.Int("stamped", migration.Stamped),
+		zap.Int("reenrollment_required", len(migration.ReenrollmentRequired)))
+	return migration, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/document"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Stable codes for problems with the document itself, which leave the face
+// comparison standing.
+const (
+	WarningOCRFailed       = "OCR_FAILED"
+	WarningMRZNotFound     = "MRZ_NOT_FOUND"
+	WarningMRZCheckFailed  = "MRZ_CHECK_FAILED"
+	WarningDocumentExpired = "DOCUMENT_EXPIRED"
+)
+
+// portraitMargin widens the detected face box on each side, as a fraction
+// of its size, so the crop shows the whole document portrait.
+const portraitMargin = 0.25
+
+func newOCREngine(cfg *config.Config) document.OCREngine {
+	if cfg.DocumentOCREngine == "none" {
+		return nil
+	}
+	return document.NewTesseractOCR(cfg.TesseractPath, cfg.TesseractLanguage)
+}
+
+// SetOCREngine replaces the engine document text is read with. A nil engine
+// skips text extraction.
+func (s *FaceVerificationService) SetOCREngine(engine document.OCREngine) {
+	s.ocrEngine = engine
+}
+
+func addDocumentWarning(result *models.DocumentVerification, code, message string) {
+	result.Warnings = append(result.Warnings, models.VerificationWarning{Code: code, Message: message})
+}
+
+// VerifyDocument compares a selfie capture with the portrait on an identity
+// document and reads the document's machine-readable zone. Nothing is
+// stored. An unreadable or expired document is reported as a warning; only
+// a document without a face fails the request.
+func (s *FaceVerificationService) VerifyDocument(ctx context.Context, video models.VideoSource, data []byte) (*models.DocumentVerification, error) {
+	startTime := time.Now()
+
+	documentImage, orientation, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	s.logOrientation("document", orientation)
+
+	comparison, portrait, err := s.compareWithDocument(ctx, video, documentImage)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.DocumentVerification{FaceComparison: *comparison}
+
+	crop, err := cropPortrait(documentImage, portrait)
+	if err != nil {
+		s.logger.Warn("Failed to encode document portrait", zap.Error(err))
+	} else {
+		result.Portrait = crop
+	}
+
+	s.readDocumentText(ctx, documentImage, result)
+
+	result.ProcessingTime = time.Since(startTime).Seconds()
+	return result, nil
+}
+
+// readDocumentText runs OCR over the document and parses its MRZ into
+// result, recording why when it cannot.
+func (s *FaceVerificationService) readDocumentText(ctx context.Context, documentImage image.Image, result *models.DocumentVerification) {
+	if s.ocrEngine == nil {
+		return
+	}
+
+	text, err := s.ocrEngine.Recognize(ctx, documentImage)
+	if err != nil {
+		s.logger.Warn("Document OCR failed", zap.Error(err))
+		addDocumentWarning(result, WarningOCRFailed, "Text could not be read from the document")
+		return
+	}
+	result.Text = text
+
+	now := time.Now().UTC()
+	fields, err := document.ParseMRZ(text, now)
+	if err != nil {
+		if !errors.Is(err, document.ErrMRZNotFound) {
+			s.logger.Warn("Failed to parse document MRZ", zap.Error(err))
+		}
+		addDocumentWarning(result, WarningMRZNotFound, "No machine-readable zone was found on the document")
+		return
+	}
+	result.Fields = fields
+
+	if !fields.ChecksValid {
+		addDocumentWarning(result, WarningMRZCheckFailed, "Machine-readable zone check digits do not match; fields may be misread")
+	}
+	if fields.ExpiryDate != "" && fields.ExpiryDate < now.Format("2006-01-02") {
+		addDocumentWarning(result, WarningDocumentExpired, "Document has expired")
+	}
+}
+
+// cropPortrait cuts the face at rect, plus a margin, out of the document.
+func cropPortrait(img image.Image, rect image.Rectangle) (*models.PortraitCrop, error) {
+	marginX := int(float64(rect.Dx()) * portraitMargin)
+	marginY := int(float64(rect.Dy()) * portraitMargin)
+	region := image.Rect(
+		rect.Min.X-marginX, rect.Min.Y-marginY,
+		rect.Max.X+marginX, rect.Max.Y+marginY,
+	).Intersect(img.Bounds())
+	if region.Empty() {
+		return nil, errors.New("portrait lies outside the document")
+	}
+
+	crop := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(crop, crop.Bounds(), img, region.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, crop, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return &models.PortraitCrop{
+		X:      region.Min.X,
+		Y:      region.Min.Y,
+		Width:  region.Dx(),
+		Height: region.Dy(),
+		Image:  base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
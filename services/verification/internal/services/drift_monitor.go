@@ -0,0 +1,155 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+)
+
+// DriftAlert describes a rolling mean that moved past the tolerated distance
+// from its operator-supplied baseline.
+type DriftAlert struct {
+	Signal   string  `json:"signal"`
+	Baseline float64 `json:"baseline"`
+	Observed float64 `json:"observed"`
+	Delta    float64 `json:"delta"`
+}
+
+// DriftMonitor tracks rolling confidence and liveness scores of successful
+// verifications and alerts when they drift away from the baseline.
+type DriftMonitor struct {
+	logger             *zap.Logger
+	baselineConfidence float64
+	baselineLiveness   float64
+	tolerance          float64
+	window             int
+
+	mu          sync.Mutex
+	confidences []float64
+	liveness    []float64
+	alerting    map[string]bool
+}
+
+func NewDriftMonitor(logger *zap.Logger, cfg *config.Config) *DriftMonitor {
+	window := cfg.DriftWindowSize
+	if window <= 0 {
+		window = 500
+	}
+
+	return &DriftMonitor{
+		logger:             logger,
+		baselineConfidence: cfg.DriftBaselineConfidence,
+		baselineLiveness:   cfg.DriftBaselineLiveness,
+		tolerance:          cfg.DriftTolerance,
+		window:             window,
+		alerting:           make(map[string]bool),
+	}
+}
+
+// Observe records the scores of a successful verification.
+func (m *DriftMonitor) Observe(confidence, livenessScore float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.confidences = appendWindow(m.confidences, confidence, m.window)
+	m.liveness = appendWindow(m.liveness, livenessScore, m.window)
+}
+
+// Check compares the rolling means against the baseline. An alert is emitted
+// once when a signal leaves the tolerated band and re-armed when it returns.
+func (m *DriftMonitor) Check() []DriftAlert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Wait for a full window so a handful of early samples can't alert
+	if len(m.confidences) < m.window {
+		return nil
+	}
+
+	confidence := mean(m.confidences)
+	liveness := mean(m.liveness)
+	metrics.DriftRollingConfidence.Set(confidence)
+	metrics.DriftRollingLiveness.Set(liveness)
+
+	var alerts []DriftAlert
+	for _, signal := range []struct {
+		name     string
+		baseline float64
+		observed float64
+	}{
+		{"confidence", m.baselineConfidence, confidence},
+		{"liveness", m.baselineLiveness, liveness},
+	} {
+		if signal.baseline <= 0 {
+			continue
+		}
+
+		delta := signal.observed - signal.baseline
+		if math.Abs(delta) <= m.tolerance {
+			m.alerting[signal.name] = false
+			continue
+		}
+		if m.alerting[signal.name] {
+			continue
+		}
+
+		m.alerting[signal.name] = true
+		alert := DriftAlert{
+			Signal:   signal.name,
+			Baseline: signal.baseline,
+			Observed: signal.observed,
+			Delta:    delta,
+		}
+		alerts = append(alerts, alert)
+
+		metrics.DriftAlerts.Add(1)
+		m.logger.Warn("Verification score drift detected",
+			zap.String("signal", alert.Signal),
+			zap.Float64("baseline", alert.Baseline),
+			zap.Float64("observed", alert.Observed),
+			zap.Float64("delta", alert.Delta),
+			zap.Float64("tolerance", m.tolerance))
+	}
+
+	return alerts
+}
+
+// Run checks for drift on every tick until stop is closed.
+func (m *DriftMonitor) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func appendWindow(values []float64, value float64, size int) []float64 {
+	values = append(values, value)
+	if len(values) > size {
+		values = values[len(values)-size:]
+	}
+	return values
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
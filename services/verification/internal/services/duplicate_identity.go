@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// Cross-user duplicate checks DUPLICATE_IDENTITY_CHECK and
+// RegistrationOptions accept, from the most to the least lenient.
+const (
+	DuplicateCheckOff    = "off"
+	DuplicateCheckFlag   = "flag"
+	DuplicateCheckReject = "reject"
+)
+
+var ErrDuplicateIdentity = errors.New("face is already enrolled under another user")
+
+// RegistrationOptions adjusts a single enrollment.
+type RegistrationOptions struct {
+	// DuplicateCheck asks for a stricter cross-user duplicate check than
+	// DUPLICATE_IDENTITY_CHECK; a more lenient one is ignored
+	DuplicateCheck string
+}
+
+// Registration reports how an enrollment went.
+type Registration struct {
+	// DuplicateIdentity is set when the face was found enrolled under
+	// another user and the check only flags duplicates
+	DuplicateIdentity bool
+	// Templates is how many templates the user has after the enrollment
+	Templates int
+}
+
+// duplicateCheckRank orders the duplicate checks by strictness.
+var duplicateCheckRank = map[string]int{
+	"":                   0,
+	DuplicateCheckOff:    0,
+	DuplicateCheckFlag:   1,
+	DuplicateCheckReject: 2,
+}
+
+// ValidDuplicateCheck reports whether check names a duplicate check.
+func ValidDuplicateCheck(check string) bool {
+	_, ok := duplicateCheckRank[check]
+	return ok
+}
+
+// duplicateCheck is the stricter of the configured check and requested.
+func duplicateCheck(cfg *config.Config, requested string) string {
+	check := cfg.DuplicateIdentityCheck
+	if duplicateCheckRank[requested] > duplicateCheckRank[check] {
+		check = requested
+	}
+	return check
+}
+
+func duplicateIdentityThreshold(cfg *config.Config) float64 {
+	if cfg.DuplicateIdentityThreshold > 0 {
+		return cfg.DuplicateIdentityThreshold
+	}
+	return 0.9
+}
+
+// checkDuplicateIdentity looks for faceVector among the enrollments of the
+// other users of the same tenant, including enrollments too young to be
+// matched against. A duplicate is reported to the fraud team as an event,
+// and rejected with ErrDuplicateIdentity under DuplicateCheckReject.
+func (s *FaceVerificationService) checkDuplicateIdentity(userKey string, faceVector []float32, check string) (bool, error) {
+	if duplicateCheckRank[check] == 0 {
+		return false, nil
+	}
+
+	tenantID, userID := tenant.SplitUserKey(userKey)
+	threshold := duplicateIdentityThreshold(s.config)
+	for _, match := range s.searchGallery(tenantID, faceVector, 2, 0) {
+		if match.UserID == userID || match.Similarity < threshold {
+			continue
+		}
+
+		rejected := check == DuplicateCheckReject
+		s.logger.Warn("Face already enrolled under another user",
+			zap.String("user_id", userID),
+			zap.String("tenant", tenantID),
+			zap.Float64("similarity", match.Similarity),
+			zap.Bool("rejected", rejected))
+		s.publishEvent(models.EventDuplicateIdentity, userKey, "", models.DuplicateIdentityEvent{
+			MatchedUserID: match.UserID,
+			Similarity:    match.Similarity,
+			Rejected:      rejected,
+		})
+		if rejected {
+			return true, fmt.Errorf("%w (similarity %.2f)", ErrDuplicateIdentity, match.Similarity)
+		}
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/Kagami/go-face"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/inference"
+	"connect-hub/verification-service/internal/metrics"
+)
+
+// Descriptor backends, for EMBEDDER_BACKEND
+const (
+	// EmbedderDlib computes descriptors in process with the dlib recognizer
+	EmbedderDlib = "dlib"
+	// EmbedderRemote has an inference server compute them from face crops
+	EmbedderRemote = "remote"
+)
+
+// ValidEmbedderBackend reports whether backend is an EMBEDDER_BACKEND
+// setting.
+func ValidEmbedderBackend(backend string) bool {
+	switch backend {
+	case "", EmbedderDlib, EmbedderRemote:
+		return true
+	}
+	return false
+}
+
+// remoteCropMargin widens the detected face rectangle on each side, as a
+// share of its size, so the crop holds the whole face as embedding models
+// are trained on.
+const remoteCropMargin = 0.25
+
+// Embedder computes face descriptors.
+type Embedder interface {
+	// Embed returns the descriptor of the first (largest) face detected in
+	// frame, after running check on it when given.
+	Embed(frame image.Image, check func(face.Face) error) ([]float32, error)
+	// EmbedFrames returns the descriptors of the first face in each of
+	// frames, computed in one go. check runs on the face of the first frame,
+	// which must have one; later frames without a face are left out.
+	EmbedFrames(frames []image.Image, check func(face.Face) error) ([][]float32, error)
+	Close()
+}
+
+// newEmbedder returns the EMBEDDER_BACKEND embedder. Faces are detected by
+// the dlib recognizers either way.
+func newEmbedder(cfg *config.Config, recognizers *recognizerPool) (Embedder, error) {
+	switch cfg.EmbedderBackend {
+	case "", EmbedderDlib:
+		return &dlibEmbedder{recognizers: recognizers}, nil
+	case EmbedderRemote:
+		return newRemoteEmbedder(cfg, recognizers)
+	}
+	return nil, fmt.Errorf("invalid EMBEDDER_BACKEND %q, expected dlib or remote", cfg.EmbedderBackend)
+}
+
+// dlibEmbedder computes descriptors with the dlib recognizer that detects
+// the face.
+type dlibEmbedder struct {
+	recognizers *recognizerPool
+}
+
+func (e *dlibEmbedder) Embed(frame image.Image, check func(face.Face) error) ([]float32, error) {
+	rgba, width, height, release := toRGBA(frame)
+	defer release()
+	var descriptor []float32
+	err := e.recognizers.with(func(rec *face.Recognizer) error {
+		var err error
+		descriptor, err = describeFace(rec, rgba, width, height, e.recognizers.cnn, check)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return descriptor, nil
+}
+
+func (e *dlibEmbedder) EmbedFrames(frames []image.Image, check func(face.Face) error) ([][]float32, error) {
+	var descriptors [][]float32
+	err := e.recognizers.with(func(rec *face.Recognizer) error {
+		var err error
+		descriptors, err = describeFrames(rec, frames, e.recognizers.cnn, check)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return descriptors, nil
+}
+
+// Close leaves the recognizers to the service, which detects faces with
+// them for other purposes too.
+func (e *dlibEmbedder) Close() {}
+
+// remoteEmbedder detects the face with dlib and sends a square crop around
+// it to EMBEDDER_MODEL on a KServe v2 inference server: one NCHW float32 RGB
+// image of EMBEDDER_INPUT_SIZE scaled to [0, 1], returning the embedding as
+// an FP32 output.
+type remoteEmbedder struct {
+	recognizers  *recognizerPool
+	client       *inference.Client
+	model        string
+	modelVersion string
+	inputName    string
+	outputName   string
+	inputSize    int
+	timeout      time.Duration
+}
+
+func newRemoteEmbedder(cfg *config.Config, recognizers *recognizerPool) (*remoteEmbedder, error) {
+	if cfg.EmbedderAddr == "" || cfg.EmbedderModel == "" {
+		return nil, fmt.Errorf("EMBEDDER_ADDR and EMBEDDER_MODEL are required when EMBEDDER_BACKEND is remote")
+	}
+	client, err := inference.Dial(cfg.EmbedderAddr, cfg.EmbedderTLS)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &remoteEmbedder{
+		recognizers:  recognizers,
+		client:       client,
+		model:        cfg.EmbedderModel,
+		modelVersion: cfg.EmbedderModelVersion,
+		inputName:    cfg.EmbedderInputName,
+		outputName:   cfg.EmbedderOutputName,
+		inputSize:    cfg.EmbedderInputSize,
+		timeout:      time.Duration(cfg.EmbedderTimeoutMs) * time.Millisecond,
+	}
+	if e.inputName == "" {
+		e.inputName = "input"
+	}
+	if e.outputName == "" {
+		e.outputName = "embedding"
+	}
+	if e.inputSize <= 0 {
+		e.inputSize = 112
+	}
+	if e.timeout <= 0 {
+		e.timeout = 2 * time.Second
+	}
+	return e, nil
+}
+
+func (e *remoteEmbedder) Embed(frame image.Image, check func(face.Face) error) ([]float32, error) {
+	descriptors, err := e.EmbedFrames([]image.Image{frame}, check)
+	if err != nil {
+		return nil, err
+	}
+	return descriptors[0], nil
+}
+
+// EmbedFrames sends the face crops of all frames in one request, as a batch
+// of that many images.
+func (e *remoteEmbedder) EmbedFrames(frames []image.Image, check func(face.Face) error) ([][]float32, error) {
+	images := make([]*image.RGBA, 0, len(frames))
+	crops := make([]image.Rectangle, 0, len(frames))
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	err := e.recognizers.with(func(rec *face.Recognizer) error {
+		for i, frame := range frames {
+			rgba, width, height, release := toRGBA(frame)
+			releases = append(releases, release)
+			faces, err := recognize(rec, rgba, width, height, e.recognizers.cnn)
+			if err != nil {
+				return fmt.Errorf("face detection failed: %w", err)
+			}
+			if len(faces) == 0 {
+				if i == 0 {
+					return ErrNoFaceDetected
+				}
+				continue
+			}
+			if i == 0 && check != nil {
+				if err := check(faces[0]); err != nil {
+					return err
+				}
+			}
+			images = append(images, rgba)
+			crops = append(crops, remoteFaceCrop(faces[0].Rectangle))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := e.inputSize
+	data := make([]float32, 0, len(images)*3*n*n)
+	for i, rgba := range images {
+		data = append(data, squareTensor(rgba, crops[i], n)...)
+	}
+	input := inference.Tensor{
+		Name:     e.inputName,
+		Datatype: "FP32",
+		Shape:    []int64{int64(len(images)), 3, int64(n), int64(n)},
+		Data:     inference.FP32(data),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	metrics.RemoteEmbeddings.Add(1)
+	output, err := e.client.Infer(ctx, e.model, e.modelVersion, input, e.outputName)
+	if err != nil {
+		metrics.RemoteEmbeddingErrors.Add(1)
+		return nil, fmt.Errorf("face descriptor generation failed: %w", err)
+	}
+	values, err := output.Float32s()
+	if err == nil && (len(values) == 0 || len(values)%len(images) != 0) {
+		err = fmt.Errorf("model %s returned %d values for %d faces", e.model, len(values), len(images))
+	}
+	if err != nil {
+		metrics.RemoteEmbeddingErrors.Add(1)
+		return nil, fmt.Errorf("face descriptor generation failed: %w", err)
+	}
+
+	size := len(values) / len(images)
+	descriptors := make([][]float32, len(images))
+	for i := range descriptors {
+		descriptors[i] = values[i*size : (i+1)*size]
+	}
+	return descriptors, nil
+}
+
+func (e *remoteEmbedder) Close() {
+	e.client.Close()
+}
+
+// remoteFaceCrop is the square around a detected face, widened by
+// remoteCropMargin. It may reach past the frame, which is then padded.
+func remoteFaceCrop(rect image.Rectangle) image.Rectangle {
+	side := max(rect.Dx(), rect.Dy())
+	side += int(float64(side) * 2 * remoteCropMargin)
+	center := rect.Min.Add(rect.Max).Div(2)
+	corner := center.Sub(image.Pt(side/2, side/2))
+	return image.Rectangle{Min: corner, Max: corner.Add(image.Pt(side, side))}
+}
+
+// squareTensor resamples the square region of frame into n by n planar RGB
+// scaled to [0, 1]. Pixels outside the frame are black.
+func squareTensor(frame image.Image, region image.Rectangle, n int) []float32 {
+	bounds := frame.Bounds()
+	side := region.Dx()
+	plane := n * n
+	data := make([]float32, 3*plane)
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			p := image.Pt(region.Min.X+x*side/n, region.Min.Y+y*side/n)
+			if !p.In(bounds) {
+				continue
+			}
+			r, g, b, _ := frame.At(p.X, p.Y).RGBA()
+			data[y*n+x] = float32(r) / 65535
+			data[plane+y*n+x] = float32(g) / 65535
+			data[2*plane+y*n+x] = float32(b) / 65535
+		}
+	}
+	return data
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrEnrollmentDisabled = errors.New("enrollment is disabled")
+var ErrEnrollmentNotYetActive = errors.New("enrollment is younger than the minimum enrollment age")
+
+// EnrollmentEnabled reports whether RegisterFace currently accepts
+// enrollments. Verification is unaffected either way.
+func (s *FaceVerificationService) EnrollmentEnabled() bool {
+	return !s.enrollmentDisabled.Load()
+}
+
+// SetEnrollmentEnabled opens or closes the supervised enrollment window at
+// runtime, overriding ENROLLMENT_DISABLED until the next restart.
+func (s *FaceVerificationService) SetEnrollmentEnabled(enabled bool) {
+	s.enrollmentDisabled.Store(!enabled)
+}
+
+// settling reports whether a template created at createdAt is younger than
+// minAge and cannot be matched against yet. Refreshed templates come from
+// captures matched against settled ones, so they are settled at once.
+func settling(createdAt time.Time, source string, minAge time.Duration) bool {
+	return minAge > 0 && source != models.TemplateSourceRefresh && time.Since(createdAt) < minAge
+}
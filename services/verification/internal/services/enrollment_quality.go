@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/Kagami/go-face"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Stable codes for enrollments rejected by the quality gate.
+const (
+	QualityFaceTooSmall   = "FACE_TOO_SMALL"
+	QualityImageTooDark   = "IMAGE_TOO_DARK"
+	QualityImageTooBright = "IMAGE_TOO_BRIGHT"
+	QualityImageTooBlurry = "IMAGE_TOO_BLURRY"
+	QualityFaceNotFrontal = "FACE_NOT_FRONTAL"
+)
+
+// QualityError rejects an enrollment whose face would make a weak template.
+// Code is one of the Quality* codes.
+type QualityError struct {
+	Code    string
+	Quality models.FaceQuality
+	detail  string
+}
+
+func (e *QualityError) Error() string {
+	return fmt.Sprintf("enrollment quality too low (%s): %s", e.Code, e.detail)
+}
+
+// QualityThresholds bound an acceptable enrollment face.
+type QualityThresholds struct {
+	MinFaceSize   float64
+	MinSharpness  float64
+	MinBrightness float64
+	MaxBrightness float64
+	MaxPoseAngle  float64
+}
+
+func qualityThresholds(cfg *config.Config) QualityThresholds {
+	t := QualityThresholds{
+		MinFaceSize:   cfg.QualityMinFaceSize,
+		MinSharpness:  cfg.QualityMinSharpness,
+		MinBrightness: cfg.QualityMinBrightness,
+		MaxBrightness: cfg.QualityMaxBrightness,
+		MaxPoseAngle:  cfg.QualityMaxPoseAngle,
+	}
+	if t.MinFaceSize <= 0 {
+		t.MinFaceSize = 0.2
+	}
+	if t.MinSharpness <= 0 {
+		t.MinSharpness = 50
+	}
+	if t.MinBrightness <= 0 {
+		t.MinBrightness = 0.2
+	}
+	if t.MaxBrightness <= 0 {
+		t.MaxBrightness = 0.9
+	}
+	if t.MaxPoseAngle <= 0 {
+		t.MaxPoseAngle = 30
+	}
+	return t
+}
+
+// Check returns a *QualityError for the first threshold q violates. Size is
+// checked first since blur and lighting are unreliable on a tiny face.
+func (t QualityThresholds) Check(q models.FaceQuality) error {
+	reject := func(code, format string, args ...interface{}) error {
+		return &QualityError{Code: code, Quality: q, detail: fmt.Sprintf(format, args...)}
+	}
+	switch {
+	case q.FaceSize < t.MinFaceSize:
+		return reject(QualityFaceTooSmall, "face width %.2f of the frame is below %.2f", q.FaceSize, t.MinFaceSize)
+	case q.Brightness < t.MinBrightness:
+		return reject(QualityImageTooDark, "face brightness %.2f is below %.2f", q.Brightness, t.MinBrightness)
+	case q.Brightness > t.MaxBrightness:
+		return reject(QualityImageTooBright, "face brightness %.2f is above %.2f", q.Brightness, t.MaxBrightness)
+	case q.Sharpness < t.MinSharpness:
+		return reject(QualityImageTooBlurry, "sharpness %.1f is below %.1f", q.Sharpness, t.MinSharpness)
+	case q.PoseAngle > t.MaxPoseAngle:
+		return reject(QualityFaceNotFrontal, "pose angle %.0f° is above %.0f°", q.PoseAngle, t.MaxPoseAngle)
+	}
+	return nil
+}
+
+// Score rates q from 0 to 1 against the thresholds, so templates can be
+// compared: the mean of how far the face is from too small (full marks at
+// twice the minimum size), too blurry (at four times the minimum
+// sharpness), the edges of the brightness range and the pose limit.
+func (t QualityThresholds) Score(q models.FaceQuality) float64 {
+	clamp := func(v float64) float64 {
+		return math.Max(0, math.Min(1, v))
+	}
+	size := clamp(q.FaceSize / (2 * t.MinFaceSize))
+	sharpness := clamp(q.Sharpness / (4 * t.MinSharpness))
+	mid, halfRange := (t.MinBrightness+t.MaxBrightness)/2, (t.MaxBrightness-t.MinBrightness)/2
+	brightness := 0.0
+	if halfRange > 0 {
+		brightness = clamp(1 - math.Abs(q.Brightness-mid)/halfRange)
+	}
+	pose := clamp(1 - q.PoseAngle/t.MaxPoseAngle)
+	return (size + sharpness + brightness + pose) / 4
+}
+
+// enrollmentFaceVector computes the descriptor to enroll from a capture's
+// frames and the quality score of its face in the first, running the
+// quality gate on that face first when it is enabled.
+func (s *FaceVerificationService) enrollmentFaceVector(frames []image.Image) ([]float32, float64, error) {
+	thresholds := qualityThresholds(s.config)
+	var score float64
+	descriptor, err := s.captureFaceVector(frames, func(detected face.Face) error {
+		quality := AssessFaceQuality(frames[0], detected.Rectangle, detected.Shapes)
+		score = thresholds.Score(quality)
+		if !s.config.EnrollmentQualityEnabled {
+			return nil
+		}
+		return thresholds.Check(quality)
+	})
+	return descriptor, score, err
+}
+
+// assessedFaceVector is captureFaceVector that also returns the quality
+// score of the face in the first frame.
+func (s *FaceVerificationService) assessedFaceVector(frames []image.Image) ([]float32, float64, error) {
+	thresholds := qualityThresholds(s.config)
+	var score float64
+	descriptor, err := s.captureFaceVector(frames, func(detected face.Face) error {
+		score = thresholds.Score(AssessFaceQuality(frames[0], detected.Rectangle, detected.Shapes))
+		return nil
+	})
+	return descriptor, score, err
+}
+
+// AssessFaceQuality measures the face at rect in frame. landmarks are the
+// detector's shape points in the 5- or 68-point layout; with neither the
+// pose is taken as frontal.
+func AssessFaceQuality(frame image.Image, rect image.Rectangle, landmarks []image.Point) models.FaceQuality {
+	bounds := frame.Bounds()
+	region := rect.Intersect(bounds)
+
+	quality := models.FaceQuality{PoseAngle: poseAngle(landmarks)}
+	if bounds.Dx() > 0 {
+		quality.FaceSize = float64(rect.Dx()) / float64(bounds.Dx())
+	}
+	if region.Empty() {
+		return quality
+	}
+
+	// 8-bit grey levels, so sharpness thresholds match the usual
+	// variance-of-Laplacian scale
+	width, height := region.Dx(), region.Dy()
+	grey := make([]float64, width*height)
+	sum := 0.0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := frame.At(region.Min.X+x, region.Min.Y+y).RGBA()
+			luma := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			grey[y*width+x] = luma
+			sum += luma
+		}
+	}
+	quality.Brightness = sum / float64(len(grey)) / 255
+	quality.Sharpness = laplacianVariance(grey, width, height)
+	return quality
+}
+
+// laplacianVariance is the variance of the 4-neighbour Laplacian over the
+// interior of a greyscale image. Blur removes the edges the Laplacian
+// responds to, so a low variance means a soft image.
+func laplacianVariance(grey []float64, width, height int) float64 {
+	if width < 3 || height < 3 {
+		return 0
+	}
+	sum, sumSquares := 0.0, 0.0
+	n := 0
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			lap := grey[i-width] + grey[i+width] + grey[i-1] + grey[i+1] - 4*grey[i]
+			sum += lap
+			sumSquares += lap * lap
+			n++
+		}
+	}
+	mean := sum / float64(n)
+	return math.Max(0, sumSquares/float64(n)-mean*mean)
+}
+
+// noseDepth is how far the nose sits in front of the eyes, as a fraction of
+// the distance between them; it turns the nose's sideways shift into yaw.
+const noseDepth = 0.5
+
+// poseAngle estimates how far the head is turned (yaw, from the nose's
+// offset along the eye line) or tilted (roll, from the eye line's slope),
+// returning the larger in degrees.
+func poseAngle(landmarks []image.Point) float64 {
+	var eyeA, eyeB [2]float64
+	var nose image.Point
+	switch {
+	case len(landmarks) >= landmarkCount:
+		eyeA, eyeB, nose = centroid(landmarks[36:42]), centroid(landmarks[42:48]), landmarks[30]
+	case len(landmarks) >= 5:
+		// dlib's 5-point layout: two corners per eye, then the nose base
+		eyeA, eyeB, nose = centroid(landmarks[0:2]), centroid(landmarks[2:4]), landmarks[4]
+	default:
+		return 0
+	}
+
+	dx, dy := eyeB[0]-eyeA[0], eyeB[1]-eyeA[1]
+	interocular := math.Hypot(dx, dy)
+	if interocular == 0 {
+		return 0
+	}
+
+	// Eye order differs between layouts, so fold the slope into 0-90°
+	roll := math.Abs(math.Atan2(dy, dx)) * 180 / math.Pi
+	if roll > 90 {
+		roll = 180 - roll
+	}
+
+	midX, midY := (eyeA[0]+eyeB[0])/2, (eyeA[1]+eyeB[1])/2
+	offset := ((float64(nose.X)-midX)*dx + (float64(nose.Y)-midY)*dy) / (interocular * interocular)
+	yaw := math.Abs(math.Asin(math.Max(-1, math.Min(1, offset/noseDepth)))) * 180 / math.Pi
+
+	return math.Max(roll, yaw)
+}
+
+func centroid(points []image.Point) [2]float64 {
+	var c [2]float64
+	for _, p := range points {
+		c[0] += float64(p.X)
+		c[1] += float64(p.Y)
+	}
+	c[0] /= float64(len(points))
+	c[1] /= float64(len(points))
+	return c
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// EraseUser removes everything held about userID: enrollments, verification
+// records and results, review cases, cached decisions, persisted async job state, webhook
+// payloads and events not yet published to Kafka. Audit exports are built from the same records, so the
+// user no longer appears in them either. With per-user keys the user's key
+// is destroyed as well, which makes any copy of their enrollments, such as
+// one in a backup, unreadable. userID is the user's key; see
+// tenant.UserKey.
+func (s *FaceVerificationService) EraseUser(userID string) (*models.ErasureReceipt, error) {
+	receipt := &models.ErasureReceipt{}
+	receipt.Tenant, receipt.UserID = tenant.SplitUserKey(userID)
+
+	enrollments, err := s.vectorStore.Load(userID)
+	if err != nil {
+		return nil, err
+	}
+	if shredder, ok := s.vectorStore.(storage.UserShredder); ok {
+		receipt.CryptoShredded, err = shredder.ShredUser(userID)
+	} else {
+		err = s.vectorStore.Delete(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	receipt.Enrollments = len(enrollments)
+	s.setUserTemplates(userID, nil)
+	if err := s.galleryChanged(userID); err != nil {
+		return nil, err
+	}
+
+	verificationIDs := make(map[string]bool)
+	for _, id := range s.recentResults.eraseUser(userID) {
+		verificationIDs[id] = true
+	}
+	for _, id := range s.records.eraseUser(userID) {
+		verificationIDs[id] = true
+	}
+	for _, id := range s.reviews.eraseUser(userID) {
+		verificationIDs[id] = true
+	}
+	receipt.Verifications = len(verificationIDs)
+	s.resultCache.eraseUser(userID)
+	s.riskHistory.eraseUser(userID)
+
+	if s.asyncJobs != nil {
+		if err := s.asyncJobs.erase(verificationIDs); err != nil {
+			return nil, err
+		}
+	}
+	if s.webhooks != nil {
+		receipt.WebhookDeliveries = s.webhooks.erase(verificationIDs)
+	}
+
+	if s.events != nil {
+		receipt.OutboxEvents, err = s.events.outbox.Erase(userID, verificationIDs)
+		if err != nil {
+			return nil, err
+		}
+		// Consumers holding published events erase their copies on this
+		s.publishEvent(models.EventUserErased, userID, "", nil)
+	}
+
+	receipt.Records = receipt.Enrollments + receipt.Verifications + receipt.WebhookDeliveries + receipt.OutboxEvents
+	receipt.ErasedAt = time.Now().UTC()
+
+	s.logger.Info("User data erased",
+		zap.String("user_id", userID),
+		zap.Int("records", receipt.Records),
+		zap.Bool("crypto_shredded", receipt.CryptoShredded))
+	return receipt, nil
+}
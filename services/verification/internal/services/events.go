@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/kafka"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// eventBatchSize caps the events published in one produce request.
+const eventBatchSize = 100
+
+// eventPublisher relays events from the outbox to Kafka. Events are written
+// to the outbox with the operation they describe and published in the
+// background, so a broker outage neither fails requests nor loses events.
+type eventPublisher struct {
+	logger   *zap.Logger
+	outbox   storage.EventOutbox
+	producer *kafka.Producer
+	topic    string
+	interval time.Duration
+	wake     chan struct{}
+}
+
+func newEventPublisher(logger *zap.Logger, cfg *config.Config) (*eventPublisher, error) {
+	outbox, err := storage.NewFileEventOutbox(eventOutboxPath(cfg), storageLockTimeout(cfg))
+	if err != nil {
+		return nil, err
+	}
+	var brokers []string
+	for _, broker := range strings.Split(cfg.KafkaBrokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	interval := time.Duration(cfg.KafkaPollIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	topic := cfg.KafkaTopic
+	if topic == "" {
+		topic = "connect-hub.verification.events"
+	}
+
+	return &eventPublisher{
+		logger:   logger,
+		outbox:   outbox,
+		producer: kafka.NewProducer(brokers, "verification-service", 10*time.Second),
+		topic:    topic,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}, nil
+}
+
+func eventOutboxPath(cfg *config.Config) string {
+	if cfg.KafkaOutboxPath != "" {
+		return cfg.KafkaOutboxPath
+	}
+	return filepath.Join(cfg.StoragePath, "event_outbox.log")
+}
+
+// run publishes outbox events as they are added, and retries every poll
+// interval while the broker is unreachable, until stopCh is closed.
+func (p *eventPublisher) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+		p.drain()
+	}
+}
+
+func (p *eventPublisher) drain() {
+	for {
+		published, err := p.outbox.Drain(eventBatchSize, p.publish)
+		if errors.Is(err, storage.ErrStorageLockTimeout) {
+			// Another replica is relaying the shared outbox
+			return
+		}
+		if err != nil {
+			metrics.EventPublishFailures.Add(1)
+			p.logger.Warn("Failed to publish outbox events", zap.Error(err))
+			return
+		}
+		if published < eventBatchSize {
+			return
+		}
+	}
+}
+
+func (p *eventPublisher) publish(events []models.OutboxEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		// Keying by user keeps each user's events in order
+		key := tenant.UserKey(event.Tenant, event.UserID)
+		if event.UserID == "" {
+			key = event.VerificationID
+		}
+		messages = append(messages, kafka.Message{Key: []byte(key), Value: value, Time: event.OccurredAt})
+	}
+	if err := p.producer.Produce(context.Background(), p.topic, messages); err != nil {
+		return err
+	}
+	metrics.EventsPublished.Add(int64(len(events)))
+	return nil
+}
+
+// add writes an event to the outbox and wakes the relay.
+func (p *eventPublisher) add(event models.OutboxEvent) error {
+	if err := p.outbox.Add(event); err != nil {
+		return err
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// publishEvent queues a domain event for Kafka when publishing is
+// configured. userKey names the tenant and user (see tenant.UserKey) and
+// data is marshalled as the event's payload.
+func (s *FaceVerificationService) publishEvent(eventType, userKey, verificationID string, data interface{}) {
+	if s.events == nil {
+		return
+	}
+	event := models.OutboxEvent{
+		ID:             uuid.New().String(),
+		Type:           eventType,
+		OccurredAt:     time.Now().UTC(),
+		VerificationID: verificationID,
+	}
+	event.Tenant, event.UserID = tenant.SplitUserKey(userKey)
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			s.logger.Error("Failed to encode event", zap.String("type", eventType), zap.Error(err))
+			return
+		}
+		event.Data = payload
+	}
+	if err := s.events.add(event); err != nil {
+		metrics.EventPublishFailures.Add(1)
+		s.logger.Error("Failed to queue event", zap.String("type", eventType), zap.Error(err))
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+var ErrDecodeBudgetExceeded = errors.New("frame decode exceeded its time budget")
+
+// FrameDecoder turns a capture into frames. It is the seam where real video
+// decoding (ffmpeg) plugs in. The capture is streamed; decoders should read
+// only as much of it as they need rather than buffering it whole.
+type FrameDecoder interface {
+	Open(video io.Reader) (FrameIterator, error)
+}
+
+// FrameIterator yields decoded frames one at a time. Next returns io.EOF
+// after the last frame. Close may be called while a Next call is still
+// running when the decode budget is exceeded, and should stop that work.
+type FrameIterator interface {
+	Next() (image.Image, error)
+	Close() error
+}
+
+// SetFrameDecoder replaces the decoder captures are extracted with, for
+// formats without a decoder of their own.
+func (s *FaceVerificationService) SetFrameDecoder(decoder FrameDecoder) {
+	s.frameDecoder = decoder
+}
+
+// SetFormatDecoder extracts captures whose content sniffs as contentType,
+// such as ContentTypeMP4, with decoder.
+func (s *FaceVerificationService) SetFormatDecoder(contentType string, decoder FrameDecoder) {
+	s.formatDecoders[contentType] = decoder
+}
+
+// decoderFor picks the decoder of a capture from its sniffed format.
+func (s *FaceVerificationService) decoderFor(contentType string) FrameDecoder {
+	if decoder, ok := s.formatDecoders[contentType]; ok {
+		return decoder
+	}
+	return s.frameDecoder
+}
+
+func frameDecodeBudget(cfg *config.Config) time.Duration {
+	if cfg.FrameDecodeBudgetMs > 0 {
+		return time.Duration(cfg.FrameDecodeBudgetMs) * time.Millisecond
+	}
+	return 500 * time.Millisecond
+}
+
+// decodeWithBudget drains the decoder, aborting with ErrDecodeBudgetExceeded
+// as soon as any single frame takes longer than budget. This bounds the
+// damage a crafted clip can do by making the decoder spin on one frame.
+// Decoding also stops with ctx's error once ctx is done.
+func decodeWithBudget(ctx context.Context, decoder FrameDecoder, video io.Reader, budget time.Duration) ([]image.Image, error) {
+	iter, err := decoder.Open(video)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	type decoded struct {
+		frame image.Image
+		err   error
+	}
+
+	var frames []image.Image
+	for {
+		ch := make(chan decoded, 1)
+		go func() {
+			frame, err := iter.Next()
+			ch <- decoded{frame, err}
+		}()
+
+		timer := time.NewTimer(budget)
+		select {
+		case d := <-ch:
+			timer.Stop()
+			if d.err == io.EOF {
+				return frames, nil
+			}
+			if d.err != nil {
+				return nil, d.err
+			}
+			frames = append(frames, d.frame)
+		case <-timer.C:
+			return nil, ErrDecodeBudgetExceeded
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// placeholderDecoder stands in for video decoding until ffmpeg lands: data
+// that decodes as an image is used as the first frame (a gradient otherwise)
+// and four slightly shifted copies simulate motion.
+type placeholderDecoder struct {
+	logger *zap.Logger
+}
+
+const placeholderFrameCount = 5
+
+type placeholderFrames struct {
+	base  image.Image
+	index int
+}
+
+func (d *placeholderDecoder) Open(video io.Reader) (FrameIterator, error) {
+	// Try to decode as image first (for demo/test videos that are actually images)
+	img, format, err := image.Decode(video)
+	if err != nil {
+		// If not an image, create a placeholder for video processing
+		// In production, this would be replaced with actual video frame extraction
+		d.logger.Debug("Video data not decodable as image, using placeholder")
+
+		// Create a realistic placeholder image
+		gradient := image.NewRGBA(image.Rect(0, 0, 640, 480))
+
+		// Fill with a gradient to simulate a real face image
+		for y := 0; y < 480; y++ {
+			for x := 0; x < 640; x++ {
+				r := uint8((x * 255) / 640)
+				g := uint8((y * 255) / 480)
+				b := uint8(128)
+				gradient.SetRGBA(x, y, r, g, b, 255)
+			}
+		}
+		img = gradient
+	} else {
+		d.logger.Debug("Successfully decoded image", zap.String("format", format))
+	}
+
+	return &placeholderFrames{base: img}, nil
+}
+
+func (f *placeholderFrames) Next() (image.Image, error) {
+	i := f.index
+	if i >= placeholderFrameCount {
+		return nil, io.EOF
+	}
+	f.index++
+	if i == 0 {
+		return f.base, nil
+	}
+
+	// Create slightly modified copies for motion analysis
+	img := f.base
+	frameCopy := newFrame(img.Bounds())
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			// Add small random variations to simulate motion
+			noise := int32(i * 2)
+			r = (r + uint32(noise)) % 65535
+			g = (g + uint32(noise)) % 65535
+			b = (b + uint32(noise)) % 65535
+			frameCopy.SetRGBA(x, y, uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8))
+		}
+	}
+	return frameCopy, nil
+}
+
+func (f *placeholderFrames) Close() error {
+	return nil
+}
+
+// stillImageDecoder handles captures that are a single JPEG or PNG: the
+// image is the first frame, followed by the same shifted copies as
+// placeholderDecoder's.
+type stillImageDecoder struct{}
+
+func (stillImageDecoder) Open(video io.Reader) (FrameIterator, error) {
+	img, _, err := image.Decode(video)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return &placeholderFrames{base: img}, nil
+}
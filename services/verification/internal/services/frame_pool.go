@@ -0,0 +1,67 @@
+package services
+
+import (
+	"image"
+	"image/draw"
+	"sync"
+)
+
+// framePool recycles the pixel buffers of RGBA frames. A 640x480 frame is
+// 1.2 MB, and a capture goes through several of them: decoded frames,
+// their downscaled or rotated copies and the conversions made for the
+// recognizer and liveness scoring. Only the conversions made here are
+// recycled, once their caller returns. Decoded frames belong to the decoder,
+// which may hand out the same frame twice or keep it, and frames handed on
+// to the pipeline are held by canary evaluation and continuations after the
+// request, so both are left to the garbage collector.
+var framePool sync.Pool
+
+// newFrame returns an RGBA image of bounds, backed by a recycled buffer
+// when one is large enough. Its pixels are left as they were, so callers
+// must write every one of them.
+func newFrame(bounds image.Rectangle) *image.RGBA {
+	n := 4 * bounds.Dx() * bounds.Dy()
+	if frame, ok := framePool.Get().(*image.RGBA); ok && cap(frame.Pix) >= n {
+		frame.Pix = frame.Pix[:n]
+		frame.Stride = 4 * bounds.Dx()
+		frame.Rect = bounds
+		return frame
+	}
+	return image.NewRGBA(bounds)
+}
+
+// recycleFrame hands frame's buffer back for reuse. Nothing may use frame
+// afterwards.
+func recycleFrame(frame *image.RGBA) {
+	framePool.Put(frame)
+}
+
+// recycleReplaced recycles the RGBA frames of original that kept does not
+// hold, such as the conversions made of kept. original must not be used
+// afterwards.
+func recycleReplaced(original, kept []image.Image) {
+	live := make(map[*image.RGBA]bool, len(original)+len(kept))
+	for _, frame := range kept {
+		if rgba, ok := frame.(*image.RGBA); ok {
+			live[rgba] = true
+		}
+	}
+	for _, frame := range original {
+		// Recycle each buffer once, even if a frame repeats
+		if rgba, ok := frame.(*image.RGBA); ok && !live[rgba] {
+			live[rgba] = true
+			recycleFrame(rgba)
+		}
+	}
+}
+
+// asPooledRGBA is asRGBA with the conversion, if one is needed, made in a
+// recycled buffer. release recycles it once the caller is done.
+func asPooledRGBA(img image.Image) (*image.RGBA, func()) {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba, func() {}
+	}
+	rgba := newFrame(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, func() { recycleFrame(rgba) }
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// galleryResyncAll is announced instead of a user ID when changes touched
+// more users than are worth naming, such as a descriptor migration.
+const galleryResyncAll = "*"
+
+// galleryPublishTimeout bounds announcing a change; a replica that misses
+// it catches up on its next resync.
+const galleryPublishTimeout = 2 * time.Second
+
+// gallerySubscribeBackoff caps the delay between resubscription attempts.
+const gallerySubscribeBackoff = 30 * time.Second
+
+func newGalleryNotifier(cfg *config.Config) (storage.GalleryNotifier, error) {
+	switch cfg.GallerySync {
+	case "", "off":
+		return nil, nil
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for redis gallery sync")
+		}
+		return storage.NewRedisGalleryNotifier(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unknown gallery sync %q, expected off or redis", cfg.GallerySync)
+	}
+}
+
+// SetGalleryNotifier replaces how gallery changes are announced to other
+// replicas, and subscribes to theirs until the service is closed; nil turns
+// gallery sync off.
+func (s *FaceVerificationService) SetGalleryNotifier(notifier storage.GalleryNotifier) {
+	s.galleryNotifier = notifier
+	if notifier != nil {
+		go s.syncGallery(notifier, s.stopCh)
+	}
+}
+
+// galleryChanged is called after this replica wrote the enrollments of
+// userKeys, which its gallery already holds, or of any user when none are
+// given, in which case the whole gallery is reloaded. With gallery sync the
+// other replicas are told to reload the same.
+func (s *FaceVerificationService) galleryChanged(userKeys ...string) error {
+	if len(userKeys) == 0 {
+		if err := s.loadFaceVectors(); err != nil {
+			return err
+		}
+		userKeys = []string{galleryResyncAll}
+	}
+	if s.galleryNotifier == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), galleryPublishTimeout)
+	defer cancel()
+	for _, userKey := range userKeys {
+		if err := s.galleryNotifier.Publish(ctx, userKey); err != nil {
+			s.logger.Warn("Failed to announce gallery change", zap.Error(err))
+			break
+		}
+	}
+	return nil
+}
+
+// reloadUsers replaces the gallery entries of userKeys with what the store
+// holds now, leaving every other user's as they are.
+func (s *FaceVerificationService) reloadUsers(userKeys ...string) error {
+	for _, userKey := range userKeys {
+		templates, err := s.vectorStore.Load(userKey)
+		if err != nil {
+			return err
+		}
+		s.setUserTemplates(userKey, templates)
+	}
+	return nil
+}
+
+// readThrough loads userKey into the gallery from the store when gallery
+// sync is on and the gallery has no enrollments for them, in case the
+// announcement of their enrollment was missed. It reports whether any were
+// found.
+func (s *FaceVerificationService) readThrough(userKey string) bool {
+	if s.galleryNotifier == nil {
+		return false
+	}
+	if err := s.reloadUsers(userKey); err != nil {
+		s.logger.Warn("Failed to read enrollments through", zap.Error(err))
+		return false
+	}
+	s.storageMutex.RLock()
+	defer s.storageMutex.RUnlock()
+	return len(s.faceVectors[userKey]) > 0
+}
+
+// syncGallery applies the changes other replicas announce until stop is
+// closed. Announcements made while the subscription is down are lost, so
+// the whole gallery is reloaded every time it is established.
+func (s *FaceVerificationService) syncGallery(notifier storage.GalleryNotifier, stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	delay := time.Second
+	for {
+		err := notifier.Subscribe(ctx, func() {
+			delay = time.Second
+			if err := s.loadFaceVectors(); err != nil {
+				s.logger.Warn("Failed to resync gallery", zap.Error(err))
+			}
+		}, func(userKey string) {
+			metrics.GalleryInvalidations.Add(1)
+			var err error
+			if userKey == galleryResyncAll {
+				err = s.loadFaceVectors()
+			} else {
+				err = s.reloadUsers(userKey)
+			}
+			if err != nil {
+				s.logger.Warn("Failed to apply gallery change", zap.Error(err))
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Warn("Gallery sync subscription lost, resubscribing",
+			zap.Duration("retry_in", delay),
+			zap.Error(err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if delay *= 2; delay > gallerySubscribeBackoff {
+			delay = gallerySubscribeBackoff
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+
+	"github.com/Kagami/go-face"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+var ErrGPUUnavailable = errors.New("RECOGNIZER_GPU requires a binary built with -tags cuda")
+
+// cnnDetectorModel is dlib's MMOD face detector. It runs on the GPU where
+// the default HOG detector cannot, and is too slow to use without one.
+const cnnDetectorModel = "mmod_human_face_detector.dat"
+
+// checkRecognizerGPU makes sure RECOGNIZER_GPU can be honoured: the binary
+// links a CUDA-enabled dlib and the CNN detector is in FACE_MODEL_PATH.
+func checkRecognizerGPU(cfg *config.Config) error {
+	if !cfg.RecognizerGPU {
+		return nil
+	}
+	if !cudaBuild {
+		return ErrGPUUnavailable
+	}
+	if _, err := os.Stat(filepath.Join(cfg.FaceModelPath, cnnDetectorModel)); err != nil {
+		return fmt.Errorf("RECOGNIZER_GPU needs %s in FACE_MODEL_PATH: %w", cnnDetectorModel, err)
+	}
+	return nil
+}
+
+// cnnJPEGQuality is the quality frames are encoded with for the CNN
+// detector, high enough not to cost detections.
+const cnnJPEGQuality = 95
+
+// recognize detects the faces in rgba, largest first, with the CNN detector
+// when cnn is set. go-face runs that detector only on encoded images, so
+// the frame is handed to it as a JPEG; the faces it finds come with their
+// descriptors.
+func recognize(rec *face.Recognizer, rgba *image.RGBA, width, height int, cnn bool) ([]face.Face, error) {
+	if !cnn {
+		return rec.RecognizeRGBA(rgba.Pix, width, height, width*4)
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, rgba, &jpeg.Options{Quality: cnnJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return rec.RecognizeCNN(encoded.Bytes())
+}
+
+// faceDescriptor is the descriptor of detected, a face recognize found in
+// rgba. Faces of the CNN detector already carry theirs.
+func faceDescriptor(rec *face.Recognizer, rgba *image.RGBA, width, height int, detected face.Face, cnn bool) ([]float32, error) {
+	if cnn {
+		return detected.Descriptor[:], nil
+	}
+	return rec.GetDescriptor(rgba.Pix, width, height, width*4, detected.Rectangle)
+}
+
+// describeFrames computes the descriptors of the first (largest) face in
+// each frame, all on rec so the pool is visited once. dlib describes one
+// face at a time; with the CNN detector on a CUDA-enabled dlib each frame
+// takes a single pass on the GPU, detection and descriptor together. check
+// runs on the face of the first frame, which must have one; later frames
+// without a face are left out.
+func describeFrames(rec *face.Recognizer, frames []image.Image, cnn bool, check func(face.Face) error) ([][]float32, error) {
+	descriptors := make([][]float32, 0, len(frames))
+	for i, frame := range frames {
+		rgba, width, height, release := toRGBA(frame)
+		defer release()
+		faces, err := recognize(rec, rgba, width, height, cnn)
+		if err != nil {
+			return nil, fmt.Errorf("face detection failed: %w", err)
+		}
+		if len(faces) == 0 {
+			if i == 0 {
+				return nil, ErrNoFaceDetected
+			}
+			continue
+		}
+		if i == 0 && check != nil {
+			if err := check(faces[0]); err != nil {
+				return nil, err
+			}
+		}
+
+		descriptor, err := faceDescriptor(rec, rgba, width, height, faces[0], cnn)
+		if err != nil {
+			return nil, fmt.Errorf("face descriptor generation failed: %w", err)
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	return descriptors, nil
+}
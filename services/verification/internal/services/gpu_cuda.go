@@ -0,0 +1,13 @@
+//go:build cuda
+
+package services
+
+// A dlib built with DLIB_USE_CUDA calls into the CUDA libraries, which the
+// go-face link flags do not name.
+
+// #cgo LDFLAGS: -lcudnn -lcublas -lcurand -lcusolver -lcudart
+import "C"
+
+// cudaBuild reports whether the binary was built with -tags cuda against a
+// CUDA-enabled dlib.
+const cudaBuild = true
@@ -0,0 +1,5 @@
+//go:build !cuda
+
+package services
+
+const cudaBuild = false
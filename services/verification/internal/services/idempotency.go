@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func newIdempotencyStore(cfg *config.Config) (storage.IdempotencyStore, error) {
+	if !cfg.IdempotencyEnabled {
+		return nil, nil
+	}
+	switch cfg.IdempotencyStore {
+	case "", "memory":
+		return storage.NewMemoryIdempotencyStore(), nil
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis idempotency store")
+		}
+		return storage.NewRedisIdempotencyStore(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unknown idempotency store %q", cfg.IdempotencyStore)
+	}
+}
+
+// SetIdempotencyStore replaces the store idempotent responses are kept in;
+// nil turns Idempotency-Key support off.
+func (s *FaceVerificationService) SetIdempotencyStore(store storage.IdempotencyStore) {
+	s.idempotency = store
+}
+
+func (s *FaceVerificationService) IdempotencyEnabled() bool {
+	return s.idempotency != nil
+}
+
+func idempotencyTTL(cfg *config.Config) time.Duration {
+	if cfg.IdempotencyTTL > 0 {
+		return time.Duration(cfg.IdempotencyTTL) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// ReserveIdempotencyKey claims key for a request about to run, or returns
+// the response stored for it. The claim outlives PROCESSING_TIMEOUT so it
+// covers the whole request, yet lapses if the instance dies holding it.
+func (s *FaceVerificationService) ReserveIdempotencyKey(ctx context.Context, key string) (*storage.IdempotentResponse, error) {
+	return s.idempotency.Reserve(ctx, key, ProcessingTimeout(s.config)+time.Minute)
+}
+
+// CompleteIdempotencyKey stores the response to replay for key for
+// IDEMPOTENCY_TTL.
+func (s *FaceVerificationService) CompleteIdempotencyKey(ctx context.Context, key string, response *storage.IdempotentResponse) error {
+	return s.idempotency.Complete(ctx, key, response, idempotencyTTL(s.config))
+}
+
+// ReleaseIdempotencyKey drops the claim on key so a retry runs again.
+func (s *FaceVerificationService) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return s.idempotency.Release(ctx, key)
+}
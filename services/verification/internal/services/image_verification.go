@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// VerifyImage matches the face in a single JPEG or PNG selfie against
+// req.UserID's enrollment. One image carries no motion, so liveness is not
+// assessed: the result has liveness_method "none" and callers must treat it
+// as a weaker assurance than a video verification. Risk scoring and manual
+// review, which weigh the liveness score, do not apply.
+func (s *FaceVerificationService) VerifyImage(ctx context.Context, req *models.VerificationRequest, data []byte) (*models.VerificationResult, error) {
+	startTime := time.Now()
+
+	img, orientation, err := decodePhoto(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	s.logOrientation("image", orientation)
+
+	if err := s.CheckLockout(req.Tenant, req.UserID, req.ClientIP); err != nil {
+		return nil, err
+	}
+	release, err := s.admission.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result := &models.VerificationResult{
+		VerificationID:   newVerificationID(),
+		UserID:           req.UserID,
+		Tenant:           req.Tenant,
+		Device:           req.Device,
+		Timestamp:        startTime,
+		ProcessingRegion: NormalizeRegion(s.config.Region),
+		ClientRegion:     NormalizeRegion(req.Region),
+		LivenessMethod:   models.LivenessMethodNone,
+	}
+
+	frames := s.downscaleCapture(result, []image.Image{img})
+	img = frames[0]
+	if s.config.CameraCheckEnabled && s.isCameraBlocked(frames) {
+		result.Reason = models.ReasonCameraBlocked
+		result.Error = "Image is too dark or flat to show a face. Improve the lighting and try again."
+		result.ProcessingTime = time.Since(startTime).Seconds()
+		s.recordResult(result)
+		s.countAttempt(result, req.ClientIP)
+		return result, nil
+	}
+
+	faceVector, err := s.generateFaceVector(img)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.addCaptureWarnings(result, frames)
+	s.screenWatchlist(result, faceVector)
+	s.checkVelocity(ctx, result, faceVector, req.ClientIP)
+	s.decideMatch(result, galleryKey(req), faceVector, 0)
+
+	result.ProcessingTime = time.Since(startTime).Seconds()
+	s.recordResult(result)
+	s.countAttempt(result, req.ClientIP)
+	return result, nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Signs of an injected camera feed, as reported in injection_signals
+const (
+	InjectionSignalSensorNoise = "missing_sensor_noise"
+	InjectionSignalStaticNoise = "static_noise_floor"
+	InjectionSignalEncoder     = "encoder_metadata"
+	InjectionSignalFrameTiming = "frame_timing"
+)
+
+// Sensor noise (Immerkær's estimate, in 8-bit grey levels) mapped to a risk
+// between 0 and 1. Real sensors stay above the ceiling even after
+// compression; rendered frames and stills fed to a virtual camera fall
+// below the floor.
+const (
+	sensorNoiseFloor   = 0.3
+	sensorNoiseCeiling = 1.0
+)
+
+// Share of pixels left unchanged between consecutive frames, beyond one
+// global brightness shift, mapped to a risk. Sensor noise alone changes far
+// more of them, even where the scene holds still.
+const (
+	frozenShareFloor   = 0.9
+	frozenShareCeiling = 0.98
+)
+
+// maxPlausibleFPS is a frame rate no camera a user verifies with records at.
+const maxPlausibleFPS = 240
+
+func injectionThreshold(cfg *config.Config) float64 {
+	if cfg.InjectionThreshold > 0 {
+		return cfg.InjectionThreshold
+	}
+	return 0.8
+}
+
+func suspiciousEncoders(cfg *config.Config) []string {
+	var markers []string
+	for _, marker := range strings.Split(cfg.InjectionSuspiciousEncoders, ",") {
+		if marker = strings.TrimSpace(marker); marker != "" {
+			markers = append(markers, marker)
+		}
+	}
+	return markers
+}
+
+// InjectionRisk estimates how likely a capture was injected instead of
+// filmed by a camera, from 0 (no sign) to 1, and names the signals that
+// contributed. Frames are checked for the sensor noise every camera leaves
+// and for a noise floor frozen between frames; the capture's container,
+// when given, for the markers of encoders in encoders and for frame timing
+// no camera produces. The strongest signal sets the risk.
+func InjectionRisk(frames []image.Image, capture io.Reader, encoders []string) (float64, []string) {
+	type signal struct {
+		name string
+		risk float64
+	}
+	signals := []signal{
+		{InjectionSignalSensorNoise, sensorNoiseRisk(frames)},
+		{InjectionSignalStaticNoise, staticNoiseRisk(frames)},
+	}
+	if capture != nil {
+		meta := readCaptureMetadata(capture)
+		signals = append(signals,
+			signal{InjectionSignalEncoder, meta.encoderRisk(encoders)},
+			signal{InjectionSignalFrameTiming, meta.timingRisk()})
+	}
+
+	risk := 0.0
+	var fired []string
+	for _, s := range signals {
+		risk = math.Max(risk, s.risk)
+		if s.risk >= 0.5 {
+			fired = append(fired, s.name)
+		}
+	}
+	return roundScore(risk), fired
+}
+
+// sensorNoiseRisk scores the median noise level of the frames.
+func sensorNoiseRisk(frames []image.Image) float64 {
+	if len(frames) == 0 {
+		return 0
+	}
+	levels := make([]float64, len(frames))
+	for i, frame := range frames {
+		levels[i] = sensorNoise(frame)
+	}
+	sort.Float64s(levels)
+	noise := levels[len(levels)/2]
+	return clamp01((sensorNoiseCeiling - noise) / (sensorNoiseCeiling - sensorNoiseFloor))
+}
+
+// sensorNoise estimates the standard deviation of the noise in a frame's
+// luminance with Immerkær's Laplacian-difference operator, which cancels
+// out smooth image content. Every other row is sampled.
+func sensorNoise(img image.Image) float64 {
+	rgba := asRGBA(img)
+	bounds := rgba.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 3 || height < 3 {
+		return 0
+	}
+	luma := frameLuma(rgba)
+
+	sum, n := 0.0, 0
+	for y := 1; y < height-1; y += 2 {
+		above, row, below := luma[(y-1)*width:], luma[y*width:], luma[(y+1)*width:]
+		for x := 1; x < width-1; x++ {
+			v := above[x-1] - 2*above[x] + above[x+1] -
+				2*row[x-1] + 4*row[x] - 2*row[x+1] +
+				below[x-1] - 2*below[x] + below[x+1]
+			sum += math.Abs(float64(v))
+			n++
+		}
+	}
+	return math.Sqrt(math.Pi/2) * sum / (6 * float64(n))
+}
+
+// staticNoiseRisk scores the median share of pixels consecutive frames
+// leave unchanged, once the most common brightness shift between them is
+// taken out.
+func staticNoiseRisk(frames []image.Image) float64 {
+	var shares []float64
+	for i := 1; i < len(frames); i++ {
+		a, b := asRGBA(frames[i-1]), asRGBA(frames[i])
+		if a.Bounds().Size() != b.Bounds().Size() {
+			continue
+		}
+		shares = append(shares, frozenShare(frameLuma(a), frameLuma(b)))
+	}
+	if len(shares) == 0 {
+		return 0
+	}
+	sort.Float64s(shares)
+	share := shares[len(shares)/2]
+	return clamp01((share - frozenShareFloor) / (frozenShareCeiling - frozenShareFloor))
+}
+
+func frozenShare(a, b []int32) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+	var histogram [511]int
+	for i := range a {
+		histogram[b[i]-a[i]+255]++
+	}
+	mode := 0
+	for _, count := range histogram {
+		if count > mode {
+			mode = count
+		}
+	}
+	return float64(mode) / float64(len(a))
+}
+
+// frameLuma returns the 8-bit luminance of every pixel, row by row.
+func frameLuma(rgba *image.RGBA) []int32 {
+	bounds := rgba.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	luma := make([]int32, width*height)
+	for y := 0; y < height; y++ {
+		i := rgba.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+		for x := 0; x < width; x++ {
+			luma[y*width+x] = (299*int32(rgba.Pix[i]) + 587*int32(rgba.Pix[i+1]) + 114*int32(rgba.Pix[i+2]) + 500) / 1000
+			i += 4
+		}
+	}
+	return luma
+}
+
+// encoderRisk is 1 when the container carries the marker of a suspicious
+// encoder, such as the x264 banner ffmpeg and OBS write into the stream.
+// Markers are matched case-sensitively, so they rarely occur by chance in
+// compressed data.
+func (m captureMetadata) encoderRisk(encoders []string) float64 {
+	for _, marker := range encoders {
+		for _, scanned := range m.scanned {
+			if bytes.Contains(scanned, []byte(marker)) {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// timingRisk is 1 when the video track's frame timing is impossible for a
+// camera: frames without duration or a frame rate above maxPlausibleFPS.
+func (m captureMetadata) timingRisk() float64 {
+	if len(m.frameDeltas) == 0 {
+		return 0
+	}
+	if m.timescale == 0 {
+		return 1
+	}
+	for _, delta := range m.frameDeltas {
+		if delta == 0 || float64(m.timescale)/float64(delta) > maxPlausibleFPS {
+			return 1
+		}
+	}
+	return 0
+}
+
+// injectionSuspected scores a capture with InjectionRisk, the container
+// being read again from the request's video when there is one, and reports
+// whether the risk reaches INJECTION_THRESHOLD.
+func (s *FaceVerificationService) injectionSuspected(req *models.VerificationRequest, frames []image.Image) (float64, []string, bool) {
+	var capture io.Reader
+	if len(req.FrameData) == 0 {
+		video, err := requestVideo(req).Open()
+		if err != nil {
+			s.logger.Warn("Failed to reopen capture for injection detection", zap.Error(err))
+		} else {
+			defer video.Close()
+			capture = video
+		}
+	}
+
+	risk, signals := InjectionRisk(frames, capture, suspiciousEncoders(s.config))
+	suspected := risk >= injectionThreshold(s.config)
+	if suspected {
+		metrics.InjectionsSuspected.Add(1)
+		s.logger.Warn("Capture looks injected",
+			zap.Float64("injection_risk", risk),
+			zap.Strings("signals", signals))
+	}
+	return risk, signals, suspected
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrLiveFrameLimit = errors.New("live verification frame limit reached")
+
+// LiveVerification accumulates the frames of a live verification as they
+// are streamed in, scoring the capture so far after each one. Request turns
+// the accumulated frames into a regular frame submission. It is not safe for
+// concurrent use; each connection owns one.
+type LiveVerification struct {
+	service   *FaceVerificationService
+	tenant    string
+	userID    string
+	maxFrames int
+
+	frameData [][]byte
+	frames    []image.Image
+}
+
+// NewLiveVerification starts a live verification for userID of tenantID
+// (empty when only liveness is checked).
+func (s *FaceVerificationService) NewLiveVerification(tenantID, userID string) *LiveVerification {
+	return &LiveVerification{
+		service:   s,
+		tenant:    tenantID,
+		userID:    userID,
+		maxFrames: liveMaxFrames(s.config),
+	}
+}
+
+func liveMaxFrames(cfg *config.Config) int {
+	if cfg.LiveMaxFrames > 0 {
+		return cfg.LiveMaxFrames
+	}
+	return 30
+}
+
+// Frames is the number of frames accepted so far.
+func (l *LiveVerification) Frames() int {
+	return len(l.frames)
+}
+
+// Full reports whether no more frames are accepted.
+func (l *LiveVerification) Full() bool {
+	return len(l.frames) >= l.maxFrames
+}
+
+// AddFrame decodes one JPEG frame and returns the running liveness score
+// over every frame so far, plus the match confidence of this frame when a
+// user is being verified.
+func (l *LiveVerification) AddFrame(data []byte) (*models.LiveProgress, error) {
+	if l.Full() {
+		return nil, ErrLiveFrameLimit
+	}
+	frame, err := decodeSubmittedFrame(l.frames, len(l.frames), data)
+	if err != nil {
+		return nil, err
+	}
+	l.frameData = append(l.frameData, data)
+	l.frames = append(l.frames, frame)
+
+	progress := &models.LiveProgress{Frames: len(l.frames)}
+	liveness, err := l.service.detectLiveness(l.tenant, l.frames)
+	if err != nil {
+		return nil, fmt.Errorf("liveness detection failed: %w", err)
+	}
+	progress.LivenessScore = liveness.Score
+	progress.IsLive = liveness.IsLive
+
+	// A frame without a detectable face is progress, not an error; the
+	// client is told to keep the face in view
+	vector, err := l.service.generateFaceVector(frame)
+	if err != nil {
+		return progress, nil
+	}
+	progress.FaceDetected = true
+	if l.userID != "" {
+		if similarity, err := l.service.checkForDuplicates(tenant.UserKey(l.tenant, l.userID), vector); err == nil {
+			progress.Confidence = l.service.calibration.Apply(similarity)
+		}
+	}
+	return progress, nil
+}
+
+// Request turns the accumulated frames into a frame submission, to be run
+// through the regular pipeline. req supplies everything but the frames.
+func (l *LiveVerification) Request(req models.VerificationRequest) *models.VerificationRequest {
+	req.FrameData = l.frameData
+	req.Tenant = l.tenant
+	req.UserID = l.userID
+	return &req
+}
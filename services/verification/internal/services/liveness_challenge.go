@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"image"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Challenges a liveness session can ask the subject to perform. Turns reuse
+// the declared action directions.
+const (
+	ChallengeBlink     = "blink"
+	ChallengeSmile     = "smile"
+	ChallengeTurnLeft  = ActionTurnLeft
+	ChallengeTurnRight = ActionTurnRight
+)
+
+var livenessChallenges = []string{ChallengeBlink, ChallengeSmile, ChallengeTurnLeft, ChallengeTurnRight}
+
+var ErrLivenessSessionInvalid = errors.New("liveness session is unknown, expired or already used")
+
+// ChallengeDetector decides whether frames show the subject performing a
+// challenge. The default works on coarse luminance; a landmark based
+// detector can be plugged in with SetChallengeDetector.
+type ChallengeDetector interface {
+	Performed(challenge string, frames []image.Image) bool
+}
+
+type livenessSession struct {
+	challenge string
+	nonce     string
+	// Screen colors binding the nonce into the capture, when enabled
+	colors    []string
+	expiresAt time.Time
+}
+
+// livenessSessions holds issued challenges until a capture redeems them.
+type livenessSessions struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]livenessSession
+}
+
+func newLivenessSessions(ttl time.Duration) *livenessSessions {
+	return &livenessSessions{
+		ttl:     ttl,
+		entries: make(map[string]livenessSession),
+	}
+}
+
+func livenessSessionTTL(cfg *config.Config) time.Duration {
+	if cfg.LivenessSessionTTL > 0 {
+		return time.Duration(cfg.LivenessSessionTTL) * time.Second
+	}
+	return 2 * time.Minute
+}
+
+// StartLivenessSession issues a random challenge with a session token and a
+// nonce. The capture answering it must carry both and, with nonce binding
+// enabled, show the session's color sequence.
+func (s *FaceVerificationService) StartLivenessSession() (*models.LivenessSession, error) {
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(livenessChallenges))))
+	if err != nil {
+		return nil, err
+	}
+	token, err := newContinuationToken()
+	if err != nil {
+		return nil, err
+	}
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return nil, err
+	}
+
+	session := livenessSession{
+		challenge: livenessChallenges[index.Int64()],
+		nonce:     hex.EncodeToString(nonceBytes),
+	}
+	if s.config.NonceBindingEnabled {
+		if session.colors, err = newColorSequence(); err != nil {
+			return nil, err
+		}
+	}
+
+	sessions := s.livenessSessions
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	now := time.Now()
+	session.expiresAt = now.Add(sessions.ttl)
+	sessions.entries[token] = session
+
+	// Opportunistically drop expired sessions so abandoned ones are freed
+	for k, e := range sessions.entries {
+		if now.After(e.expiresAt) {
+			delete(sessions.entries, k)
+		}
+	}
+
+	return &models.LivenessSession{
+		SessionToken:  token,
+		Challenge:     session.challenge,
+		Nonce:         session.nonce,
+		ColorSequence: session.colors,
+		ExpiresAt:     session.expiresAt,
+	}, nil
+}
+
+// redeemLivenessSession returns the session issued for token. Sessions are
+// single use; a wrong nonce burns the session as well.
+func (s *FaceVerificationService) redeemLivenessSession(token, nonce string) (livenessSession, error) {
+	sessions := s.livenessSessions
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	session, ok := sessions.entries[token]
+	if !ok {
+		return livenessSession{}, ErrLivenessSessionInvalid
+	}
+	delete(sessions.entries, token)
+
+	if time.Now().After(session.expiresAt) ||
+		subtle.ConstantTimeCompare([]byte(nonce), []byte(session.nonce)) != 1 {
+		return livenessSession{}, ErrLivenessSessionInvalid
+	}
+	return session, nil
+}
+
+// SetChallengeDetector replaces the detector liveness challenges are checked with.
+func (s *FaceVerificationService) SetChallengeDetector(detector ChallengeDetector) {
+	s.challengeDetector = detector
+}
+
+// Face bands, as fractions of the frame height, the default detector watches
+// for blinks and smiles.
+const (
+	eyeBandTop       = 0.25
+	eyeBandBottom    = 0.45
+	mouthBandTop     = 0.60
+	mouthBandBottom  = 0.85
+	expressionChange = 0.02
+)
+
+// motionChallengeDetector checks turns with the declared action motion check.
+// A blink is a brief change in the eye band that settles back; a smile is a
+// lasting change in the mouth band with the head otherwise still.
+type motionChallengeDetector struct {
+	minMotion float64
+}
+
+func (d *motionChallengeDetector) Performed(challenge string, frames []image.Image) bool {
+	switch challenge {
+	case ChallengeTurnLeft, ChallengeTurnRight:
+		return CheckDeclaredAction(challenge, frames, d.minMotion) == nil
+	case ChallengeBlink:
+		levels := bandLevels(frames, eyeBandTop, eyeBandBottom)
+		if len(levels) < 3 {
+			return false
+		}
+		first, last := levels[0], levels[len(levels)-1]
+		baseline := (first + last) / 2
+		peak := 0.0
+		for _, level := range levels[1 : len(levels)-1] {
+			peak = math.Max(peak, math.Abs(level-baseline))
+		}
+		return peak >= expressionChange && math.Abs(first-last) < expressionChange
+	case ChallengeSmile:
+		levels := bandLevels(frames, mouthBandTop, mouthBandBottom)
+		if len(levels) < 2 {
+			return false
+		}
+		dx, dy := MeasureMotion(frames)
+		if math.Abs(dx) >= d.minMotion || math.Abs(dy) >= d.minMotion {
+			return false
+		}
+		return math.Abs(levels[len(levels)-1]-levels[0]) >= expressionChange
+	}
+	return false
+}
+
+// bandLevels is the mean luminance of a horizontal band of every frame.
+func bandLevels(frames []image.Image, top, bottom float64) []float64 {
+	levels := make([]float64, 0, len(frames))
+	for _, frame := range frames {
+		grid, w, h := luminanceGrid(frame)
+		if w == 0 || h == 0 {
+			continue
+		}
+		from, to := int(top*float64(h)), min(int(math.Ceil(bottom*float64(h))), h)
+		if to <= from {
+			from, to = max(to-1, 0), max(to, 1)
+		}
+		sum := 0.0
+		for y := from; y < to; y++ {
+			for x := 0; x < w; x++ {
+				sum += grid[y*w+x]
+			}
+		}
+		levels = append(levels, sum/float64((to-from)*w))
+	}
+	return levels
+}
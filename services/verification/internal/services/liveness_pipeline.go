@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Built-in liveness detectors that LIVENESS_DETECTORS can name.
+const (
+	DetectorMotion  = "motion"
+	DetectorTexture = "texture"
+	DetectorColor   = "color"
+	DetectorBlink   = "blink"
+	DetectorReplay  = "replay"
+	DetectorONNX    = "onnx"
+)
+
+// LivenessDetector scores one liveness signal of a capture from 0 (spoof) to
+// 1 (live), with the features behind the score. ok is false when the detector
+// cannot judge the capture; it then drops out and the weights of the others
+// are renormalized.
+type LivenessDetector interface {
+	Detect(frames []image.Image) (score float64, features map[string]float64, ok bool)
+}
+
+// LivenessDetectorFunc adapts a function to LivenessDetector.
+type LivenessDetectorFunc func(frames []image.Image) (float64, map[string]float64, bool)
+
+func (f LivenessDetectorFunc) Detect(frames []image.Image) (float64, map[string]float64, bool) {
+	return f(frames)
+}
+
+// LivenessWeight is one "name:weight" entry of LIVENESS_DETECTORS.
+type LivenessWeight struct {
+	Name   string
+	Weight float64
+}
+
+// ParseLivenessDetectors parses a detector list of the form
+// "name:weight,..." such as "motion:0.3,texture:0.3,blink:0.4". Weights are
+// relative and need not sum to one. An empty spec returns nil.
+func ParseLivenessDetectors(spec string) ([]LivenessWeight, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var weights []LivenessWeight
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid liveness detector %q", pair)
+		}
+
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight in %q: %w", pair, err)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("negative weight in %q", pair)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate liveness detector %q", name)
+		}
+		seen[name] = true
+
+		weights = append(weights, LivenessWeight{Name: name, Weight: weight})
+	}
+	return weights, nil
+}
+
+// defaultLivenessWeights is the pipeline used when LIVENESS_DETECTORS is
+// unset: the passive motion/texture/color mix, with ONNX_LIVENESS_WEIGHT (when
+// a model is loaded), REPLAY_WEIGHT and BLINK_WEIGHT as their share of the
+// final score.
+func defaultLivenessWeights(cfg *config.Config, withONNX bool) []LivenessWeight {
+	onnx := 0.0
+	if withONNX {
+		onnx = onnxLivenessWeight(cfg)
+	}
+	replay := 0.0
+	if cfg.ReplayDetectionEnabled {
+		replay = replayWeight(cfg)
+	}
+	blink := blinkWeight(cfg)
+	passive := (1 - onnx) * (1 - replay) * (1 - blink)
+
+	weights := []LivenessWeight{
+		{Name: DetectorMotion, Weight: 0.4 * passive},
+		{Name: DetectorTexture, Weight: 0.4 * passive},
+		{Name: DetectorColor, Weight: 0.2 * passive},
+	}
+	if blink > 0 {
+		weights = append(weights, LivenessWeight{Name: DetectorBlink, Weight: blink * (1 - replay) * (1 - onnx)})
+	}
+	if replay > 0 {
+		weights = append(weights, LivenessWeight{Name: DetectorReplay, Weight: replay * (1 - onnx)})
+	}
+	if onnx > 0 {
+		weights = append(weights, LivenessWeight{Name: DetectorONNX, Weight: onnx})
+	}
+	return weights
+}
+
+// builtinLivenessDetectors returns the detectors shipped with the service,
+// keyed by the names LIVENESS_DETECTORS accepts.
+func (s *FaceVerificationService) builtinLivenessDetectors() map[string]LivenessDetector {
+	return map[string]LivenessDetector{
+		DetectorMotion: LivenessDetectorFunc(func(frames []image.Image) (float64, map[string]float64, bool) {
+			score := s.calculateMotionScore(frames)
+			return score, map[string]float64{"motion_score": score}, true
+		}),
+		DetectorTexture: LivenessDetectorFunc(func(frames []image.Image) (float64, map[string]float64, bool) {
+			score := s.calculateTextureConsistency(frames)
+			return score, map[string]float64{"texture_score": score}, true
+		}),
+		DetectorColor: LivenessDetectorFunc(func(frames []image.Image) (float64, map[string]float64, bool) {
+			score := s.calculateColorConsistency(frames)
+			return score, map[string]float64{"color_score": score}, true
+		}),
+		DetectorBlink: LivenessDetectorFunc(s.detectBlink),
+		// Scores 1 - replay_score, the "not a replay" evidence
+		DetectorReplay: LivenessDetectorFunc(func(frames []image.Image) (float64, map[string]float64, bool) {
+			score := ReplayScore(frames)
+			return 1 - score, map[string]float64{"replay_score": score}, true
+		}),
+		// Abstains unless a model was loaded
+		DetectorONNX: LivenessDetectorFunc(func(frames []image.Image) (float64, map[string]float64, bool) {
+			if s.onnxLiveness == nil {
+				return 0, nil, false
+			}
+			return s.onnxLiveness.Detect(frames)
+		}),
+	}
+}
+
+// newLivenessPipeline resolves the configured detector list against the
+// built-in detectors.
+func (s *FaceVerificationService) newLivenessPipeline() error {
+	s.livenessDetectors = s.builtinLivenessDetectors()
+	weights, err := s.livenessWeightsFor(s.config)
+	if err != nil {
+		return err
+	}
+	s.tuning.Store(newTuning(s.config, weights))
+	return nil
+}
+
+// livenessWeightsFor returns the detector weights cfg configures, which
+// must name detectors of the pipeline.
+func (s *FaceVerificationService) livenessWeightsFor(cfg *config.Config) ([]LivenessWeight, error) {
+	weights, err := ParseLivenessDetectors(cfg.LivenessDetectors)
+	if err != nil {
+		return nil, err
+	}
+	if weights == nil {
+		weights = defaultLivenessWeights(cfg, s.onnxLiveness != nil)
+	}
+	for _, w := range weights {
+		if _, ok := s.livenessDetectors[w.Name]; !ok {
+			return nil, fmt.Errorf("unknown liveness detector %q", w.Name)
+		}
+	}
+	return weights, nil
+}
+
+// SetLivenessDetector replaces the detector behind a name in the pipeline.
+// A nil detector makes that name abstain from every capture.
+func (s *FaceVerificationService) SetLivenessDetector(name string, detector LivenessDetector) {
+	s.livenessDetectors[name] = detector
+}
+
+// runLivenessPipeline runs every weighted detector over the frames, records
+// their features and normalized weights on result and returns the weighted
+// mean score of the detectors that could judge the capture. A non-nil
+// enabled set skips the detectors outside it.
+func (s *FaceVerificationService) runLivenessPipeline(result *models.LivenessResult, frames []image.Image, enabled map[string]bool) float64 {
+	result.Features = make(map[string]float64)
+	result.Weights = make(map[string]float64)
+
+	weighted, totalWeight := 0.0, 0.0
+	for _, w := range s.currentTuning().livenessWeights {
+		detector := s.livenessDetectors[w.Name]
+		if detector == nil || w.Weight == 0 || (enabled != nil && !enabled[w.Name]) {
+			continue
+		}
+		score, features, ok := detector.Detect(frames)
+		if !ok {
+			continue
+		}
+		for name, value := range features {
+			result.Features[name] = value
+		}
+		result.Weights[w.Name] = w.Weight
+		weighted += score * w.Weight
+		totalWeight += w.Weight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+
+	for name, weight := range result.Weights {
+		result.Weights[name] = weight / totalWeight
+	}
+	return weighted / totalWeight
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrLockedOut = errors.New("too many failed verifications")
+
+// Subjects a lockout applies to
+const (
+	LockoutSubjectUser   = "user"
+	LockoutSubjectClient = "client"
+)
+
+// LockedOutError rejects a verification for a user or client IP locked out
+// after repeated failures. It wraps ErrLockedOut.
+type LockedOutError struct {
+	Subject    string
+	RetryAfter time.Duration
+}
+
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("%s locked out after repeated failed verifications, retry in %s", e.Subject, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedOutError) Unwrap() error {
+	return ErrLockedOut
+}
+
+// Failure counters kept before idle ones are pruned
+const maxLockoutCounters = 10000
+
+// lockouts counts consecutive failed verifications per user and per client
+// IP, locking either out for the cooldown once it reaches its threshold.
+// Counts are kept by each instance.
+type lockouts struct {
+	mu              sync.Mutex
+	userThreshold   int
+	clientThreshold int
+	cooldown        time.Duration
+	counters        map[string]*lockoutCounter
+}
+
+type lockoutCounter struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func newLockouts(cfg *config.Config) *lockouts {
+	if cfg.LockoutThreshold <= 0 && cfg.LockoutClientThreshold <= 0 {
+		return nil
+	}
+	cooldown := time.Duration(cfg.LockoutCooldown) * time.Second
+	if cooldown <= 0 {
+		cooldown = 15 * time.Minute
+	}
+	return &lockouts{
+		userThreshold:   cfg.LockoutThreshold,
+		clientThreshold: cfg.LockoutClientThreshold,
+		cooldown:        cooldown,
+		counters:        make(map[string]*lockoutCounter),
+	}
+}
+
+// remaining returns how much longer key is locked out, if at all.
+func (l *lockouts) remaining(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if counter, ok := l.counters[key]; ok && now.Before(counter.lockedUntil) {
+		return counter.lockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// fail counts a failure against key and reports whether it locked key out.
+// Failures are forgotten after a cooldown without any, and a lockout starts
+// the count afresh.
+func (l *lockouts) fail(key string, threshold int, now time.Time) (failures int, locked bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	counter, ok := l.counters[key]
+	if !ok {
+		if len(l.counters) >= maxLockoutCounters {
+			l.prune(now)
+		}
+		counter = &lockoutCounter{}
+		l.counters[key] = counter
+	}
+	if now.Sub(counter.lastFailure) >= l.cooldown {
+		counter.failures = 0
+	}
+	counter.failures++
+	counter.lastFailure = now
+	if counter.failures < threshold {
+		return counter.failures, false
+	}
+	failures = counter.failures
+	counter.failures = 0
+	counter.lockedUntil = now.Add(l.cooldown)
+	return failures, true
+}
+
+func (l *lockouts) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if counter, ok := l.counters[key]; ok {
+		counter.failures = 0
+	}
+}
+
+// prune drops the counters that are neither locked nor counting.
+func (l *lockouts) prune(now time.Time) {
+	for key, counter := range l.counters {
+		if !now.Before(counter.lockedUntil) && now.Sub(counter.lastFailure) >= l.cooldown {
+			delete(l.counters, key)
+		}
+	}
+}
+
+// lockoutSubject is a user or client IP whose failures are counted.
+type lockoutSubject struct {
+	kind      string
+	key       string
+	threshold int
+}
+
+// subjects returns the subjects tracked for an attempt by userID of
+// tenantID from clientIP. Like velocity counters, keys are hashed.
+func (l *lockouts) subjects(tenantID, userID, clientIP string) []lockoutSubject {
+	var subjects []lockoutSubject
+	if userID != "" && l.userThreshold > 0 {
+		subjects = append(subjects, lockoutSubject{LockoutSubjectUser, velocityKey("lockout", LockoutSubjectUser, tenantID, userID), l.userThreshold})
+	}
+	if clientIP != "" && l.clientThreshold > 0 {
+		subjects = append(subjects, lockoutSubject{LockoutSubjectClient, velocityKey("lockout", LockoutSubjectClient, tenantID, clientIP), l.clientThreshold})
+	}
+	return subjects
+}
+
+// CheckLockout returns a LockedOutError when userID of tenantID, or the
+// client at clientIP, is locked out. Verifications check it themselves;
+// callers check it early to refuse a client before it sends a capture.
+func (s *FaceVerificationService) CheckLockout(tenantID, userID, clientIP string) error {
+	l := s.lockouts
+	if l == nil {
+		return nil
+	}
+	now := time.Now()
+	for _, subject := range l.subjects(tenantID, userID, clientIP) {
+		if wait := l.remaining(subject.key, now); wait > 0 {
+			return &LockedOutError{Subject: subject.kind, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// countAttempt counts a completed verification towards the lockouts. A
+// failure counts against both the user and the client IP and may lock them
+// out, which is reported as a security.lockout event. A success clears the
+// user's count only: a client that controls one account must not be able to
+// clear its own count with it. Results held for manual review are not
+// counted either way.
+func (s *FaceVerificationService) countAttempt(result *models.VerificationResult, clientIP string) {
+	l := s.lockouts
+	if l == nil || result.Synthetic || result.Reason == models.ReasonNeedsReview {
+		return
+	}
+
+	now := time.Now()
+	for _, subject := range l.subjects(result.Tenant, result.UserID, clientIP) {
+		if result.Verified {
+			if subject.kind == LockoutSubjectUser {
+				l.reset(subject.key)
+			}
+			continue
+		}
+		failures, locked := l.fail(subject.key, subject.threshold, now)
+		if !locked {
+			continue
+		}
+
+		metrics.Lockouts.Add(1)
+		s.logger.Warn("Locked out after repeated failed verifications",
+			zap.String("verification_id", result.VerificationID),
+			zap.String("tenant", result.Tenant),
+			zap.String("subject", subject.kind),
+			zap.Int("failures", failures),
+			zap.Duration("cooldown", l.cooldown))
+
+		event := models.LockoutEvent{
+			Subject:     subject.kind,
+			Failures:    failures,
+			LockedUntil: now.Add(l.cooldown).UTC(),
+		}
+		if subject.kind == LockoutSubjectClient {
+			event.ClientIP = clientIP
+		}
+		s.publishEvent(models.EventLockout, tenant.UserKey(result.Tenant, result.UserID), result.VerificationID, event)
+	}
+}
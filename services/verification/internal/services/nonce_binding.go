@@ -0,0 +1,109 @@
+package services
+
+import (
+	"crypto/rand"
+	"image"
+	"math/big"
+)
+
+// Colors a liveness session can ask the client to flash on screen while
+// recording. They are the primaries, so the light they throw on the face
+// can be told apart in any frame.
+const (
+	ScreenColorRed   = "red"
+	ScreenColorGreen = "green"
+	ScreenColorBlue  = "blue"
+)
+
+var screenColors = []string{ScreenColorRed, ScreenColorGreen, ScreenColorBlue}
+
+// colorSequenceLength is how many flashes a session asks for. With no color
+// repeated back to back that makes 24 sequences, on top of the challenge.
+const colorSequenceLength = 4
+
+// minColorTint is the shift in a frame's chromaticity, away from the
+// capture's average, that counts as lit by a flash.
+const minColorTint = 0.01
+
+// newColorSequence draws the flashes binding a session's nonce into the
+// capture.
+func newColorSequence() ([]string, error) {
+	sequence := make([]string, 0, colorSequenceLength)
+	for len(sequence) < colorSequenceLength {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(screenColors))))
+		if err != nil {
+			return nil, err
+		}
+		color := screenColors[index.Int64()]
+		if len(sequence) > 0 && sequence[len(sequence)-1] == color {
+			continue
+		}
+		sequence = append(sequence, color)
+	}
+	return sequence, nil
+}
+
+// ColorSequenceShown reports whether frames are lit by the screen colors of
+// sequence, in order. Each frame is labelled with the primary its
+// chromaticity leans to most against the capture's average; frames that
+// barely lean are skipped and runs of one color count once.
+func ColorSequenceShown(sequence []string, frames []image.Image) bool {
+	if len(frames) == 0 {
+		return false
+	}
+	tints := make([][3]float64, len(frames))
+	var mean [3]float64
+	for i, frame := range frames {
+		tints[i] = chromaticity(frame)
+		for c := range mean {
+			mean[c] += tints[i][c] / float64(len(frames))
+		}
+	}
+
+	var shown []string
+	for _, tint := range tints {
+		strongest, lean := -1, minColorTint
+		for c := range tint {
+			if d := tint[c] - mean[c]; d >= lean {
+				strongest, lean = c, d
+			}
+		}
+		if strongest < 0 {
+			continue
+		}
+		if color := screenColors[strongest]; len(shown) == 0 || shown[len(shown)-1] != color {
+			shown = append(shown, color)
+		}
+	}
+
+	if len(shown) != len(sequence) {
+		return false
+	}
+	for i := range sequence {
+		if shown[i] != sequence[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// chromaticity is the share of red, green and blue in a frame's light,
+// sampled every fourth pixel of each row.
+func chromaticity(img image.Image) [3]float64 {
+	rgba := asRGBA(img)
+	bounds := rgba.Bounds()
+	var sums [3]float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x += 4 {
+			i := rgba.PixOffset(x, y)
+			sums[0] += float64(rgba.Pix[i])
+			sums[1] += float64(rgba.Pix[i+1])
+			sums[2] += float64(rgba.Pix[i+2])
+		}
+	}
+	total := sums[0] + sums[1] + sums[2]
+	if total == 0 {
+		return [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	}
+	return [3]float64{sums[0] / total, sums[1] / total, sums[2] / total}
+}
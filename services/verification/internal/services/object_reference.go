@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+const maxReferencedVideoSize = 50 * 1024 * 1024
+
+var (
+	ErrObjectStoreDisabled     = errors.New("object store is not configured")
+	ErrInvalidObjectKey        = errors.New("invalid object key")
+	ErrInvalidObjectURL        = errors.New("invalid object URL")
+	ErrObjectKeyOutOfScope     = errors.New("object key is outside the allowed prefix")
+	ErrUploadsUnsupported      = errors.New("object store cannot issue pre-signed uploads")
+	ErrObjectDeleteUnsupported = errors.New("object store cannot delete objects")
+	ErrUnsupportedUploadType   = errors.New("unsupported upload content type")
+)
+
+// uploadExtensions maps the content types accepted for pre-signed uploads
+// to the extension of the issued object key.
+var uploadExtensions = map[string]string{
+	"video/webm":      ".webm",
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+}
+
+func newObjectStore(cfg *config.Config) (storage.ObjectStore, error) {
+	switch cfg.ObjectStoreType {
+	case "":
+		return nil, nil
+	case "file":
+		return storage.NewFileObjectStore(cfg.ObjectStorePath), nil
+	case "http":
+		if cfg.ObjectStoreURL == "" {
+			return nil, fmt.Errorf("OBJECT_STORE_URL is required for the http object store")
+		}
+		return storage.NewHTTPObjectStore(cfg.ObjectStoreURL, nil), nil
+	case "s3":
+		return storage.NewS3ObjectStore(cfg.ObjectStoreURL, cfg.ObjectStoreBucket, cfg.AWSRegion, storage.AWSCredentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}, nil)
+	case "gcs":
+		return storage.NewGCSObjectStore(cfg.ObjectStoreURL, cfg.ObjectStoreBucket, cfg.GCSHMACAccessID, cfg.GCSHMACSecret, nil)
+	default:
+		return nil, fmt.Errorf("unknown object store type %q", cfg.ObjectStoreType)
+	}
+}
+
+// SetObjectStore replaces the store referenced captures are fetched from.
+func (s *FaceVerificationService) SetObjectStore(store storage.ObjectStore) {
+	s.objectStore = store
+}
+
+// ValidateObjectKey rejects keys that could escape the upload area, such as
+// absolute paths, traversal segments or keys outside the configured prefix.
+func (s *FaceVerificationService) ValidateObjectKey(key string) error {
+	if key == "" || len(key) > 512 || strings.HasPrefix(key, "/") {
+		return ErrInvalidObjectKey
+	}
+
+	for _, char := range key {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_' || char == '.' || char == '/') {
+			return ErrInvalidObjectKey
+		}
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidObjectKey
+		}
+	}
+
+	prefix := s.config.ObjectKeyPrefix
+	if prefix == "" || !strings.HasPrefix(key, prefix) {
+		return ErrObjectKeyOutOfScope
+	}
+
+	return nil
+}
+
+// FetchReferencedVideo loads a client-uploaded capture from the object store.
+func (s *FaceVerificationService) FetchReferencedVideo(ctx context.Context, key string) ([]byte, error) {
+	if s.objectStore == nil {
+		return nil, ErrObjectStoreDisabled
+	}
+
+	if err := s.ValidateObjectKey(key); err != nil {
+		return nil, err
+	}
+
+	return s.objectStore.Get(ctx, key, maxReferencedVideoSize)
+}
+
+// objectURLScheme is the URL scheme naming objects of the configured store:
+// s3:// for s3 and gs:// for gcs. Other stores are addressed by key only.
+func (s *FaceVerificationService) objectURLScheme() string {
+	switch s.config.ObjectStoreType {
+	case "s3":
+		return "s3"
+	case "gcs":
+		return "gs"
+	}
+	return ""
+}
+
+// ObjectKeyFromURL resolves an s3://bucket/key or gs://bucket/key URL to the
+// object key it names. URLs into any bucket but the configured one are out
+// of scope; the key itself is checked by FetchReferencedVideo.
+func (s *FaceVerificationService) ObjectKeyFromURL(rawURL string) (string, error) {
+	if s.objectStore == nil {
+		return "", ErrObjectStoreDisabled
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" ||
+		u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", ErrInvalidObjectURL
+	}
+	if u.Scheme != s.objectURLScheme() || u.Host != s.config.ObjectStoreBucket {
+		return "", ErrObjectKeyOutOfScope
+	}
+
+	return strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// IssueUpload creates a fresh object key under the allowed prefix and a
+// pre-signed URL the client PUTs the capture to.
+func (s *FaceVerificationService) IssueUpload(contentType string) (*models.ObjectUpload, error) {
+	if s.objectStore == nil {
+		return nil, ErrObjectStoreDisabled
+	}
+	signer, ok := s.objectStore.(storage.UploadSigner)
+	if !ok {
+		return nil, ErrUploadsUnsupported
+	}
+	extension, ok := uploadExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedUploadType
+	}
+
+	ttl := objectUploadTTL(s.config)
+	key := s.config.ObjectKeyPrefix + uuid.New().String() + extension
+	uploadURL, err := signer.PresignPut(key, contentType, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ObjectUpload{
+		ObjectKey: key,
+		URL:       s.objectURLScheme() + "://" + s.config.ObjectStoreBucket + "/" + key,
+		UploadURL: uploadURL,
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}, nil
+}
+
+func objectUploadTTL(cfg *config.Config) time.Duration {
+	if cfg.ObjectUploadTTL <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(cfg.ObjectUploadTTL) * time.Second
+}
+
+// CanDeleteObjects reports whether referenced captures can be deleted once
+// verified.
+func (s *FaceVerificationService) CanDeleteObjects() bool {
+	_, ok := s.objectStore.(storage.ObjectDeleter)
+	return ok
+}
+
+// DeleteReferencedObject removes a verified capture from the object store.
+func (s *FaceVerificationService) DeleteReferencedObject(ctx context.Context, key string) error {
+	deleter, ok := s.objectStore.(storage.ObjectDeleter)
+	if !ok {
+		return ErrObjectDeleteUnsupported
+	}
+	if err := s.ValidateObjectKey(key); err != nil {
+		return err
+	}
+	return deleter.Delete(ctx, key)
+}
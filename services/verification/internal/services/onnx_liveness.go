@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+
+	ort "github.com/yalue/onnxruntime_go"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+var errONNXModelUnavailable = errors.New("onnx liveness model not configured")
+
+// onnxMaxFrames caps the frames run through the model per capture; the
+// first, middle and last frames are enough for a passive CNN.
+const onnxMaxFrames = 3
+
+func onnxInputSize(cfg *config.Config) int {
+	if cfg.ONNXInputSize > 0 {
+		return cfg.ONNXInputSize
+	}
+	return 80
+}
+
+func onnxLivenessWeight(cfg *config.Config) float64 {
+	return math.Max(0, math.Min(cfg.ONNXLivenessWeight, 1))
+}
+
+// onnxLiveness runs a passive anti-spoofing CNN exported to ONNX. The model
+// takes one NCHW float32 RGB face crop scaled to [0, 1] and outputs class
+// logits (or probabilities); the softmax probability of ONNX_LIVE_CLASS is
+// the liveness score.
+type onnxLiveness struct {
+	session    *ort.DynamicAdvancedSession
+	inputSize  int
+	outputSize int
+	liveClass  int
+}
+
+// newONNXLiveness loads the model at ONNX_LIVENESS_MODEL_PATH. It returns
+// errONNXModelUnavailable when no model is configured or present.
+func newONNXLiveness(cfg *config.Config) (*onnxLiveness, error) {
+	if cfg.ONNXLivenessModelPath == "" {
+		return nil, errONNXModelUnavailable
+	}
+	if _, err := os.Stat(cfg.ONNXLivenessModelPath); err != nil {
+		return nil, fmt.Errorf("%w: %v", errONNXModelUnavailable, err)
+	}
+
+	if !ort.IsInitialized() {
+		if cfg.ONNXRuntimeLibPath != "" {
+			ort.SetSharedLibraryPath(cfg.ONNXRuntimeLibPath)
+		}
+		if err := ort.InitializeEnvironment(); err != nil {
+			return nil, fmt.Errorf("failed to initialize onnxruntime: %w", err)
+		}
+	}
+
+	inputs, outputs, err := ort.GetInputOutputInfo(cfg.ONNXLivenessModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read onnx model: %w", err)
+	}
+	if len(inputs) != 1 || len(outputs) == 0 {
+		return nil, fmt.Errorf("onnx model needs one input and an output, has %d and %d", len(inputs), len(outputs))
+	}
+	outputShape := outputs[0].Dimensions
+	if len(outputShape) == 0 || outputShape[len(outputShape)-1] <= 0 {
+		return nil, fmt.Errorf("onnx model output %q has no fixed class dimension", outputs[0].Name)
+	}
+	outputSize := int(outputShape[len(outputShape)-1])
+	if cfg.ONNXLiveClass < 0 || cfg.ONNXLiveClass >= outputSize {
+		return nil, fmt.Errorf("live class %d out of range for %d model outputs", cfg.ONNXLiveClass, outputSize)
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(cfg.ONNXLivenessModelPath,
+		[]string{inputs[0].Name}, []string{outputs[0].Name}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create onnx session: %w", err)
+	}
+
+	return &onnxLiveness{
+		session:    session,
+		inputSize:  onnxInputSize(cfg),
+		outputSize: outputSize,
+		liveClass:  cfg.ONNXLiveClass,
+	}, nil
+}
+
+// loadONNXLiveness installs the ONNX model detector when one is configured.
+// A missing or broken model only logs: the detector then abstains and the
+// heuristic detectors carry the liveness score.
+func (s *FaceVerificationService) loadONNXLiveness() {
+	model, err := newONNXLiveness(s.config)
+	if err != nil {
+		if s.config.ONNXLivenessModelPath != "" {
+			s.logger.Warn("ONNX liveness model unavailable, falling back to heuristic detectors",
+				zap.String("path", s.config.ONNXLivenessModelPath),
+				zap.Error(err))
+		}
+		return
+	}
+	s.onnxLiveness = model
+	s.logger.Info("ONNX liveness model loaded", zap.String("path", s.config.ONNXLivenessModelPath))
+}
+
+// Detect averages the live-class probability over a few frames.
+func (m *onnxLiveness) Detect(frames []image.Image) (float64, map[string]float64, bool) {
+	sampled := frames
+	if len(frames) > onnxMaxFrames {
+		sampled = []image.Image{frames[0], frames[len(frames)/2], frames[len(frames)-1]}
+	}
+
+	total, scored := 0.0, 0
+	for _, frame := range sampled {
+		probability, err := m.liveProbability(frame)
+		if err != nil {
+			continue
+		}
+		total += probability
+		scored++
+	}
+	if scored == 0 {
+		return 0, nil, false
+	}
+
+	score := total / float64(scored)
+	return score, map[string]float64{"onnx_score": score}, true
+}
+
+func (m *onnxLiveness) liveProbability(frame image.Image) (float64, error) {
+	input, err := ort.NewTensor(ort.NewShape(1, 3, int64(m.inputSize), int64(m.inputSize)), m.faceTensor(frame))
+	if err != nil {
+		return 0, err
+	}
+	defer input.Destroy()
+
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(1, int64(m.outputSize)))
+	if err != nil {
+		return 0, err
+	}
+	defer output.Destroy()
+
+	if err := m.session.Run([]ort.Value{input}, []ort.Value{output}); err != nil {
+		return 0, err
+	}
+	return softmaxAt(output.GetData(), m.liveClass), nil
+}
+
+// faceTensor resamples the central square of the frame, where the face sits
+// in a framed selfie capture, into planar RGB.
+func (m *onnxLiveness) faceTensor(frame image.Image) []float32 {
+	bounds := frame.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	corner := bounds.Min.Add(image.Pt((bounds.Dx()-side)/2, (bounds.Dy()-side)/2))
+	return squareTensor(frame, image.Rectangle{Min: corner, Max: corner.Add(image.Pt(side, side))}, m.inputSize)
+}
+
+// softmaxAt is the softmax probability of class i. Outputs that already are
+// probabilities keep their order, so the score stays monotonic either way.
+func softmaxAt(logits []float32, i int) float64 {
+	peak := math.Inf(-1)
+	for _, v := range logits {
+		peak = math.Max(peak, float64(v))
+	}
+	sum := 0.0
+	for _, v := range logits {
+		sum += math.Exp(float64(v) - peak)
+	}
+	return math.Exp(float64(logits[i])-peak) / sum
+}
+
+func (m *onnxLiveness) Close() {
+	m.session.Destroy()
+}
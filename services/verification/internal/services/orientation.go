@@ -0,0 +1,252 @@
+package services
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// EXIF orientations (tag 0x0112): how the stored pixels must be transformed
+// to display upright. Phones store selfies sideways and set the tag rather
+// than rotating the pixels.
+const (
+	orientationNormal     = 1
+	orientationFlipH      = 2
+	orientationRotate180  = 3
+	orientationFlipV      = 4
+	orientationTranspose  = 5
+	orientationRotate90   = 6
+	orientationTransverse = 7
+	orientationRotate270  = 8
+)
+
+const exifOrientationTag = 0x0112
+
+// orientationDegrees is the clockwise rotation an orientation applies, for
+// reporting.
+var orientationDegrees = map[int]int{
+	orientationRotate90:  90,
+	orientationRotate180: 180,
+	orientationRotate270: 270,
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, orientationNormal
+// when it has none or it cannot be read.
+func jpegOrientation(data []byte) int {
+	if !IsJPEG(data) {
+		return orientationNormal
+	}
+	// Walk the marker segments up to the start of the scan
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return orientationNormal
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return orientationNormal
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return orientationNormal
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && len(segment) >= 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return orientationNormal
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF
+// structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return orientationNormal
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:entry+2]) == exifOrientationTag {
+			// A SHORT, stored in the first bytes of the value field
+			if orientation := int(order.Uint16(tiff[entry+8 : entry+10])); orientation >= orientationNormal && orientation <= orientationRotate270 {
+				return orientation
+			}
+			break
+		}
+	}
+	return orientationNormal
+}
+
+// videoTrackRotation returns the clockwise display rotation of the first
+// video track in a moov payload, from its track header's transformation
+// matrix: 0, 90, 180 or 270.
+func videoTrackRotation(moov []byte) int {
+	rotation := -1
+	childBoxes(moov, func(boxType string, trak []byte) {
+		if boxType != "trak" || rotation >= 0 {
+			return
+		}
+		hdlr := firstChild(firstChild(trak, "mdia"), "hdlr")
+		if len(hdlr) < 12 || string(hdlr[8:12]) != "vide" {
+			return
+		}
+		rotation = 0
+
+		tkhd := firstChild(trak, "tkhd")
+		matrix := 40
+		if len(tkhd) > 0 && tkhd[0] == 1 {
+			matrix = 52
+		}
+		if len(tkhd) < matrix+36 {
+			return
+		}
+		// The 2x2 rotation part, in 16.16 fixed point: a b / c d
+		a := int32(binary.BigEndian.Uint32(tkhd[matrix:]))
+		b := int32(binary.BigEndian.Uint32(tkhd[matrix+4:]))
+		c := int32(binary.BigEndian.Uint32(tkhd[matrix+12:]))
+		d := int32(binary.BigEndian.Uint32(tkhd[matrix+16:]))
+		const one = 1 << 16
+		switch {
+		case a == 0 && b == one && c == -one && d == 0:
+			rotation = 90
+		case a == -one && b == 0 && c == 0 && d == -one:
+			rotation = 180
+		case a == 0 && b == -one && c == one && d == 0:
+			rotation = 270
+		}
+	})
+	if rotation < 0 {
+		return 0
+	}
+	return rotation
+}
+
+// rotationOrientation is the EXIF orientation that rotates clockwise by
+// degrees.
+func rotationOrientation(degrees int) int {
+	switch degrees {
+	case 90:
+		return orientationRotate90
+	case 180:
+		return orientationRotate180
+	case 270:
+		return orientationRotate270
+	}
+	return orientationNormal
+}
+
+// orientImage returns img transformed to display upright under orientation.
+// Normal or unknown orientations return img itself.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= orientationNormal || orientation > orientationRotate270 {
+		return img
+	}
+	src, release := asPooledRGBA(img)
+	defer release()
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= orientationTranspose {
+		dw, dh = h, w
+	}
+	dst := newFrame(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// The source pixel shown at (x, y)
+			var sx, sy int
+			switch orientation {
+			case orientationFlipH:
+				sx, sy = w-1-x, y
+			case orientationRotate180:
+				sx, sy = w-1-x, h-1-y
+			case orientationFlipV:
+				sx, sy = x, h-1-y
+			case orientationTranspose:
+				sx, sy = y, x
+			case orientationRotate90:
+				sx, sy = y, h-1-x
+			case orientationTransverse:
+				sx, sy = w-1-y, h-1-x
+			case orientationRotate270:
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(b.Min.X+sx, b.Min.Y+sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// orientCapture turns the decoded frames of a capture upright: JPEGs by
+// their EXIF orientation, MP4 and QuickTime videos by the rotation of their
+// video track. Phones record sideways and only flag the rotation.
+func (s *FaceVerificationService) orientCapture(video models.VideoSource, contentType string, frames []image.Image) []image.Image {
+	if contentType != ContentTypeJPEG && contentType != ContentTypeMP4 && contentType != ContentTypeQuickTime {
+		return frames
+	}
+	r, err := video.Open()
+	if err != nil {
+		s.logger.Warn("Failed to reopen capture for orientation", zap.Error(err))
+		return frames
+	}
+	defer r.Close()
+
+	orientation := orientationNormal
+	if contentType == ContentTypeJPEG {
+		head := make([]byte, metadataScanBytes)
+		n, _ := io.ReadFull(r, head)
+		orientation = jpegOrientation(head[:n])
+	} else {
+		orientation = rotationOrientation(readCaptureMetadata(r).rotation)
+	}
+	if orientation == orientationNormal {
+		return frames
+	}
+
+	oriented := make([]image.Image, len(frames))
+	for i, frame := range frames {
+		oriented[i] = orientImage(frame, orientation)
+	}
+	s.logOrientation(contentType, orientation)
+	return oriented
+}
+
+// logOrientation reports the orientation a capture or photo was turned
+// upright with.
+func (s *FaceVerificationService) logOrientation(source string, orientation int) {
+	if orientation <= orientationNormal {
+		return
+	}
+	s.logger.Debug("Turned image upright",
+		zap.String("source", source),
+		zap.Int("orientation", orientation),
+		zap.Int("rotation_degrees", orientationDegrees[orientation]))
+}
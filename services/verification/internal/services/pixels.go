@@ -0,0 +1,49 @@
+package services
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Scoring reads 8-bit samples straight from an *image.RGBA's Pix slice,
+// which is an order of magnitude faster than a color.Color per pixel
+// through At. Scores keep the 16-bit scale At reported: an 8-bit sample s
+// is s*257 there.
+const rgba16 = 257.0
+
+// asRGBA returns img as an *image.RGBA, converting it when it is of another
+// type. The result keeps img's bounds.
+func asRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// asRGBAFrames converts a capture's frames once, so the liveness detectors
+// that each walk every frame do not convert them again. The conversions are
+// made in recycled buffers, which release hands back.
+func asRGBAFrames(frames []image.Image) (converted []image.Image, release func()) {
+	converted = make([]image.Image, len(frames))
+	for i, frame := range frames {
+		converted[i], _ = asPooledRGBA(frame)
+	}
+	return converted, func() { recycleReplaced(converted, frames) }
+}
+
+// toRGBA returns img in the packed RGBA layout go-face expects, copying it
+// into a recycled buffer only when it is not already packed. release
+// recycles the copy once the caller is done with it.
+func toRGBA(img image.Image) (rgba *image.RGBA, width, height int, release func()) {
+	bounds := img.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Stride == width*4 {
+		return rgba, width, height, func() {}
+	}
+	rgba = newFrame(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	return rgba, width, height, func() { recycleFrame(rgba) }
+}
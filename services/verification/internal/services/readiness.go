@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// readinessTimeout bounds each readiness check, so a hung dependency fails
+// its check instead of the probe.
+const readinessTimeout = 5 * time.Second
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// Readiness runs the dependency checks concurrently: a recognizer probe,
+// the vector store, the usage meter and, when configured, the object store,
+// shared idempotency and velocity stores, the descriptor cache and gallery
+// sync. Stores that cannot check themselves pass. A draining service, or
+// one still warming up, is not ready, whatever its dependencies.
+func (s *FaceVerificationService) Readiness(ctx context.Context) *models.Readiness {
+	if s.Draining() {
+		return &models.Readiness{
+			Draining:  true,
+			Checks:    []models.DependencyCheck{},
+			Timestamp: time.Now().UTC(),
+		}
+	}
+	if s.WarmingUp() {
+		return &models.Readiness{
+			WarmingUp: true,
+			Checks:    []models.DependencyCheck{},
+			Timestamp: time.Now().UTC(),
+		}
+	}
+
+	checks := []readinessCheck{
+		{"recognizer", func(ctx context.Context) error { return s.recognizers.ready() }},
+		{"vector_store", storeCheck(s.vectorStore)},
+		{"usage_meter", storeCheck(s.usage.meter)},
+	}
+	if s.objectStore != nil {
+		checks = append(checks, readinessCheck{"object_store", storeCheck(s.objectStore)})
+	}
+	if _, ok := s.idempotency.(storage.HealthChecker); ok {
+		checks = append(checks, readinessCheck{"idempotency_store", storeCheck(s.idempotency)})
+	}
+	if s.velocity != nil {
+		if _, ok := s.velocity.store.(storage.HealthChecker); ok {
+			checks = append(checks, readinessCheck{"velocity_store", storeCheck(s.velocity.store)})
+		}
+	}
+	if _, ok := s.descriptorCache.(storage.HealthChecker); ok {
+		checks = append(checks, readinessCheck{"descriptor_cache", storeCheck(s.descriptorCache)})
+	}
+	if _, ok := s.galleryNotifier.(storage.HealthChecker); ok {
+		checks = append(checks, readinessCheck{"gallery_sync", storeCheck(s.galleryNotifier)})
+	}
+
+	readiness := &models.Readiness{
+		Ready:     true,
+		Checks:    make([]models.DependencyCheck, len(checks)),
+		Timestamp: time.Now().UTC(),
+	}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			readiness.Checks[i] = runReadinessCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, check := range readiness.Checks {
+		if !check.Healthy {
+			readiness.Ready = false
+			s.logger.Warn("Readiness check failed", zap.String("check", check.Name), zap.String("error", check.Error))
+		}
+	}
+	return readiness
+}
+
+func storeCheck(store interface{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if checker, ok := store.(storage.HealthChecker); ok {
+			return checker.CheckHealth(ctx)
+		}
+		return nil
+	}
+}
+
+// runReadinessCheck runs check under readinessTimeout, giving up on it
+// rather than waiting when it overruns.
+func runReadinessCheck(ctx context.Context, check readinessCheck) models.DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := models.DependencyCheck{
+		Name:       check.name,
+		Healthy:    err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Kagami/go-face"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+// newRecognizerWithRetry loads the dlib models, retrying with a doubling
+// delay so a model mount that is briefly unavailable at startup does not
+// crash-loop the process. The last error is returned once attempts run out.
+func newRecognizerWithRetry(logger *zap.Logger, cfg *config.Config) (*face.Recognizer, error) {
+	attempts := cfg.RecognizerInitAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	delay := time.Duration(cfg.RecognizerInitRetryDelayMs) * time.Millisecond
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var rec *face.Recognizer
+		rec, err = face.NewRecognizer(cfg.FaceModelPath)
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("Face recognizer initialized after retry", zap.Int("attempt", attempt))
+			}
+			return rec, nil
+		}
+		if attempt == attempts {
+			break
+		}
+		logger.Warn("Face recognizer initialization failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("retry_in", delay),
+			zap.Error(err))
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return nil, err
+}
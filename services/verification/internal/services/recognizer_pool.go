@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/Kagami/go-face"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+)
+
+var ErrRecognizerPoolClosed = errors.New("face recognizer pool closed")
+
+// recognizerPool hands out dlib recognizers one caller at a time, since a
+// face.Recognizer is not safe for concurrent use. Each slot holds a loaded
+// recognizer or nil; nil slots are loaded when first checked out, so only
+// as many instances as the peak concurrency ever occupy memory. A
+// recognizer that fails a health probe is closed and its slot emptied.
+type recognizerPool struct {
+	logger *zap.Logger
+	load   func() (*face.Recognizer, error)
+	slots  chan *face.Recognizer
+	size   int
+	// Detect faces with the CNN detector, for RECOGNIZER_GPU
+	cnn bool
+
+	mu     sync.Mutex
+	loaded int
+	inUse  int
+	closed bool
+}
+
+// RecognizerPoolStats reports the recognizer pool's occupancy.
+type RecognizerPoolStats struct {
+	Size   int
+	Loaded int
+	InUse  int
+}
+
+func recognizerPoolSize(cfg *config.Config) int {
+	if cfg.RecognizerPoolSize > 0 {
+		return cfg.RecognizerPoolSize
+	}
+	return 1
+}
+
+func recognizerHealthInterval(cfg *config.Config) time.Duration {
+	if cfg.RecognizerHealthCheckInterval > 0 {
+		return time.Duration(cfg.RecognizerHealthCheckInterval) * time.Second
+	}
+	return time.Minute
+}
+
+// newRecognizerPool seeds the pool with first, the recognizer loaded at
+// startup, leaving the other slots to be filled on demand.
+func newRecognizerPool(logger *zap.Logger, cfg *config.Config, first *face.Recognizer) *recognizerPool {
+	size := recognizerPoolSize(cfg)
+	p := &recognizerPool{
+		logger: logger,
+		load:   func() (*face.Recognizer, error) { return face.NewRecognizer(cfg.FaceModelPath) },
+		slots:  make(chan *face.Recognizer, size),
+		size:   size,
+		cnn:    cfg.RecognizerGPU,
+		loaded: 1,
+	}
+	p.slots <- first
+	for i := 1; i < size; i++ {
+		p.slots <- nil
+	}
+	metrics.RecognizersLoaded.Set(1)
+	return p
+}
+
+// get checks out a recognizer, waiting for a free slot and loading one into
+// it if it is empty.
+func (p *recognizerPool) get() (*face.Recognizer, error) {
+	rec := <-p.slots
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		if rec != nil {
+			rec.Close()
+		}
+		p.slots <- nil
+		return nil, ErrRecognizerPoolClosed
+	}
+
+	if rec == nil {
+		var err error
+		if rec, err = p.load(); err != nil {
+			p.slots <- nil
+			return nil, err
+		}
+		p.mu.Lock()
+		p.loaded++
+		metrics.RecognizersLoaded.Set(int64(p.loaded))
+		p.mu.Unlock()
+		p.logger.Info("Loaded additional face recognizer", zap.Int("loaded", p.loaded), zap.Int("pool_size", p.size))
+	}
+
+	p.mu.Lock()
+	p.inUse++
+	metrics.RecognizersInUse.Set(int64(p.inUse))
+	p.mu.Unlock()
+	return rec, nil
+}
+
+// put returns a checked-out recognizer. When the work done with it failed,
+// it is probed first so a recognizer in a bad state is not reused.
+func (p *recognizerPool) put(rec *face.Recognizer, failed bool) {
+	p.mu.Lock()
+	p.inUse--
+	metrics.RecognizersInUse.Set(int64(p.inUse))
+	closed := p.closed
+	p.mu.Unlock()
+
+	if closed {
+		rec.Close()
+		p.slots <- nil
+		return
+	}
+	if failed && !p.probe(rec) {
+		p.discard(rec)
+		p.slots <- nil
+		return
+	}
+	p.slots <- rec
+}
+
+// with runs fn on a checked-out recognizer.
+func (p *recognizerPool) with(fn func(rec *face.Recognizer) error) error {
+	rec, err := p.get()
+	if err != nil {
+		return err
+	}
+	err = fn(rec)
+	p.put(rec, err != nil)
+	return err
+}
+
+func (p *recognizerPool) discard(rec *face.Recognizer) {
+	rec.Close()
+	metrics.RecognizerDiscards.Add(1)
+	p.mu.Lock()
+	p.loaded--
+	metrics.RecognizersLoaded.Set(int64(p.loaded))
+	p.mu.Unlock()
+	p.logger.Warn("Discarded unhealthy face recognizer")
+}
+
+// probeImage is a blank frame; detection on it must succeed and find nothing.
+var probeImage = image.NewRGBA(image.Rect(0, 0, 64, 64))
+
+func (p *recognizerPool) probe(rec *face.Recognizer) bool {
+	_, err := recognize(rec, probeImage, 64, 64, p.cnn)
+	return err == nil
+}
+
+// checkHealth probes the idle recognizers, discarding any that fail.
+// Recognizers in use are checked when they are returned.
+func (p *recognizerPool) checkHealth() {
+	var idle []*face.Recognizer
+drain:
+	for i := 0; i < p.size; i++ {
+		select {
+		case rec := <-p.slots:
+			idle = append(idle, rec)
+		default:
+			break drain
+		}
+	}
+
+	for _, rec := range idle {
+		if rec != nil && !p.probe(rec) {
+			p.discard(rec)
+			rec = nil
+		}
+		p.slots <- rec
+	}
+}
+
+// preload loads a recognizer into every idle empty slot, so no request
+// pays for loading one. It stops at the first recognizer that fails to load.
+func (p *recognizerPool) preload() error {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrRecognizerPoolClosed
+	}
+
+	var idle []*face.Recognizer
+drain:
+	for i := 0; i < p.size; i++ {
+		select {
+		case rec := <-p.slots:
+			idle = append(idle, rec)
+		default:
+			break drain
+		}
+	}
+
+	var err error
+	for _, rec := range idle {
+		if rec == nil && err == nil {
+			if rec, err = p.load(); err == nil {
+				p.mu.Lock()
+				p.loaded++
+				metrics.RecognizersLoaded.Set(int64(p.loaded))
+				p.mu.Unlock()
+			}
+		}
+		p.slots <- rec
+	}
+	return err
+}
+
+// ready probes an idle recognizer for readiness checks, loading one when
+// none is loaded. With every recognizer checked out the pool is busy rather
+// than broken, so that counts as ready.
+func (p *recognizerPool) ready() error {
+	p.mu.Lock()
+	closed, loaded := p.closed, p.loaded
+	p.mu.Unlock()
+	if closed {
+		return ErrRecognizerPoolClosed
+	}
+
+	var rec *face.Recognizer
+	select {
+	case rec = <-p.slots:
+	default:
+		return nil
+	}
+	if rec == nil {
+		if loaded > 0 {
+			p.slots <- nil
+			return nil
+		}
+		var err error
+		if rec, err = p.load(); err != nil {
+			p.slots <- nil
+			return err
+		}
+		p.mu.Lock()
+		p.loaded++
+		metrics.RecognizersLoaded.Set(int64(p.loaded))
+		p.mu.Unlock()
+	}
+
+	if !p.probe(rec) {
+		p.discard(rec)
+		p.slots <- nil
+		return errors.New("face recognizer failed its probe")
+	}
+	p.slots <- rec
+	return nil
+}
+
+func (p *recognizerPool) runHealthChecks(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (p *recognizerPool) stats() RecognizerPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return RecognizerPoolStats{Size: p.size, Loaded: p.loaded, InUse: p.inUse}
+}
+
+// close releases the idle recognizers; those still checked out are closed
+// when they are returned. Later checkouts fail with ErrRecognizerPoolClosed.
+func (p *recognizerPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	emptied := 0
+drain:
+	for emptied < p.size {
+		select {
+		case rec := <-p.slots:
+			if rec != nil {
+				rec.Close()
+			}
+			emptied++
+		default:
+			break drain
+		}
+	}
+	for ; emptied > 0; emptied-- {
+		p.slots <- nil
+	}
+}
+
+// RecognizerPoolStats reports how many recognizers are loaded and checked
+// out of the RECOGNIZER_POOL_SIZE slots.
+func (s *FaceVerificationService) RecognizerPoolStats() RecognizerPoolStats {
+	return s.recognizers.stats()
+}
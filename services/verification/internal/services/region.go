@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrInvalidRegion = errors.New("invalid region")
+var ErrRegionNotAllowed = errors.New("region is not allowed")
+
+var regionPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// NormalizeRegion lowercases and trims a region code such as "eu-west-1".
+func NormalizeRegion(region string) string {
+	return strings.ToLower(strings.TrimSpace(region))
+}
+
+// ValidateClientRegion checks a client-declared region against the configured
+// allow-list. An empty allow-list accepts any well-formed region.
+func (s *FaceVerificationService) ValidateClientRegion(region string) error {
+	region = NormalizeRegion(region)
+	if len(region) > 32 || !regionPattern.MatchString(region) {
+		return ErrInvalidRegion
+	}
+
+	if s.config.AllowedRegions == "" {
+		return nil
+	}
+	for _, allowed := range strings.Split(s.config.AllowedRegions, ",") {
+		if NormalizeRegion(allowed) == region {
+			return nil
+		}
+	}
+	return ErrRegionNotAllowed
+}
+
+// RegionFilter selects records for residency audits; empty fields match all.
+type RegionFilter struct {
+	ProcessingRegion string
+	ClientRegion     string
+}
+
+func (f RegionFilter) matches(result *models.VerificationResult) bool {
+	if f.ProcessingRegion != "" && result.ProcessingRegion != NormalizeRegion(f.ProcessingRegion) {
+		return false
+	}
+	if f.ClientRegion != "" && result.ClientRegion != NormalizeRegion(f.ClientRegion) {
+		return false
+	}
+	return true
+}
+
+// FindVerifications returns up to limit recent records matching filter,
+// newest first.
+func (s *FaceVerificationService) FindVerifications(filter RegionFilter, limit int) []*models.VerificationResult {
+	return s.recentResults.find(filter.matches, limit)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"image"
+	"math"
+	"math/cmplx"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+// replayCropSize is the side of the square face crop that is transformed;
+// it must be a power of two for the FFT.
+const replayCropSize = 64
+
+// Spectral peakiness (strongest bin over the mean of its frequency ring)
+// mapped to a replay score between 0 and 1. Camera noise and skin texture stay
+// near 3; moiré and refresh banding put single bins far above their ring.
+const (
+	replayPeakFloor   = 6.0
+	replayPeakCeiling = 20.0
+)
+
+func replayWeight(cfg *config.Config) float64 {
+	return math.Max(0, math.Min(cfg.ReplayWeight, 1))
+}
+
+func replayThreshold(cfg *config.Config) float64 {
+	if cfg.ReplayThreshold > 0 {
+		return cfg.ReplayThreshold
+	}
+	return 0.8
+}
+
+// scoreReplay sets result.ReplayScore, reusing the replay detector's score
+// when it ran in the pipeline, so the REPLAY_THRESHOLD veto applies even
+// when replay carries no weight.
+func (s *FaceVerificationService) scoreReplay(result *models.LivenessResult, frames []image.Image) {
+	score, ok := result.Features["replay_score"]
+	if !ok {
+		if !s.config.ReplayDetectionEnabled {
+			return
+		}
+		score = ReplayScore(frames)
+		result.Features["replay_score"] = score
+	}
+	result.ReplayScore = score
+}
+
+// replayRejected reports whether the replay score alone condemns a capture.
+func (s *FaceVerificationService) replayRejected(result *models.LivenessResult) bool {
+	return s.config.ReplayDetectionEnabled && result.ReplayScore >= replayThreshold(s.config)
+}
+
+// ReplayScore estimates how likely the frames are a recording of a screen,
+// from 0 (no sign) to 1. Each face crop is checked for the isolated
+// high-frequency peaks moiré produces, and each difference between
+// consecutive crops for the bands a refreshing display leaves behind.
+func ReplayScore(frames []image.Image) float64 {
+	if len(frames) == 0 {
+		return 0
+	}
+
+	var crops [][]float64
+	for _, frame := range frames {
+		if crop := faceCrop(frame); crop != nil {
+			crops = append(crops, crop)
+		}
+	}
+	if len(crops) == 0 {
+		return 0
+	}
+
+	peak := 0.0
+	for i, crop := range crops {
+		peak = math.Max(peak, spectralPeakiness(crop, false))
+		if i > 0 {
+			diff := make([]float64, len(crop))
+			for j := range crop {
+				diff[j] = crop[j] - crops[i-1][j]
+			}
+			peak = math.Max(peak, spectralPeakiness(diff, true))
+		}
+	}
+
+	score := (peak - replayPeakFloor) / (replayPeakCeiling - replayPeakFloor)
+	return math.Max(0, math.Min(score, 1))
+}
+
+// faceCrop samples the central half of the frame, where the face sits in a
+// framed selfie capture, into a replayCropSize square of luminance.
+func faceCrop(img image.Image) []float64 {
+	bounds := img.Bounds()
+	w, h := bounds.Dx()/2, bounds.Dy()/2
+	if w < replayCropSize/2 || h < replayCropSize/2 {
+		return nil
+	}
+	x0, y0 := bounds.Min.X+bounds.Dx()/4, bounds.Min.Y+bounds.Dy()/4
+
+	crop := make([]float64, replayCropSize*replayCropSize)
+	for cy := 0; cy < replayCropSize; cy++ {
+		y := y0 + cy*h/replayCropSize
+		for cx := 0; cx < replayCropSize; cx++ {
+			x := x0 + cx*w/replayCropSize
+			r, g, b, _ := img.At(x, y).RGBA()
+			crop[cy*replayCropSize+cx] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257.0
+		}
+	}
+	return crop
+}
+
+// spectralPeakiness windows the crop, takes its 2D spectrum and returns the
+// largest ratio of a bin's magnitude to the mean magnitude of its frequency
+// ring, over the mid and high frequencies. Flat crops score 0. Unless
+// withAxes is set, bins on the horizontal and vertical axes are skipped:
+// ordinary edges and gradients pile up there, while refresh banding in
+// frame differences shows up exactly there.
+func spectralPeakiness(crop []float64, withAxes bool) float64 {
+	n := replayCropSize
+
+	mean := 0.0
+	for _, v := range crop {
+		mean += v
+	}
+	mean /= float64(len(crop))
+
+	// Hann window against edge leakage
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+	data := make([]complex128, n*n)
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			data[y*n+x] = complex((crop[y*n+x]-mean)*window[x]*window[y], 0)
+		}
+	}
+	fft2D(data, n)
+
+	// Rings from n/8 up to Nyquist; lower frequencies hold the face itself
+	minRadius, maxRadius := n/8, n/2
+	ringSum := make([]float64, maxRadius+1)
+	ringCount := make([]int, maxRadius+1)
+	radius := func(x, y int) int {
+		fx, fy := x, y
+		if fx > n/2 {
+			fx -= n
+		}
+		if fy > n/2 {
+			fy -= n
+		}
+		if !withAxes && (absInt(fx) <= 1 || absInt(fy) <= 1) {
+			return -1
+		}
+		return int(math.Round(math.Hypot(float64(fx), float64(fy))))
+	}
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if r := radius(x, y); r >= minRadius && r <= maxRadius {
+				ringSum[r] += cmplx.Abs(data[y*n+x])
+				ringCount[r]++
+			}
+		}
+	}
+
+	peak := 0.0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			r := radius(x, y)
+			if r < minRadius || r > maxRadius || ringSum[r] == 0 {
+				continue
+			}
+			ringMean := ringSum[r] / float64(ringCount[r])
+			peak = math.Max(peak, cmplx.Abs(data[y*n+x])/ringMean)
+		}
+	}
+	return peak
+}
+
+// fft2D transforms a row-major n x n grid in place, n a power of two.
+func fft2D(data []complex128, n int) {
+	line := make([]complex128, n)
+	for y := 0; y < n; y++ {
+		fft(data[y*n : (y+1)*n])
+	}
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			line[y] = data[y*n+x]
+		}
+		fft(line)
+		for y := 0; y < n; y++ {
+			data[y*n+x] = line[y]
+		}
+	}
+}
+
+// fft is an in-place iterative radix-2 Cooley-Tukey transform.
+func fft(a []complex128) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u, v := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = u+v, u-v
+				w *= step
+			}
+		}
+	}
+}
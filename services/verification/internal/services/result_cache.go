@@ -0,0 +1,114 @@
+package services
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// maxCachedResults bounds the decisions held for re-submissions; the least
+// recently used go first.
+const maxCachedResults = 10000
+
+type cachedResult struct {
+	key       string
+	result    *models.VerificationResult
+	expiresAt time.Time
+}
+
+// resultCache holds recent decisions keyed by capture content so identical
+// re-submissions can be answered without reprocessing. Expired decisions
+// are dropped when looked up or pushed out.
+type resultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	order   *list.List // of *cachedResult, most recently used first
+	entries map[string]*list.Element
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *resultCache) get(key string) (*models.VerificationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cachedResult)
+	if time.Now().After(entry.expiresAt) {
+		c.removeLocked(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.result, true
+}
+
+func (c *resultCache) put(key string, result *models.VerificationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cachedResult{key: key, result: result, expiresAt: time.Now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > maxCachedResults {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+func (c *resultCache) removeLocked(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cachedResult).key)
+}
+
+// eraseUser drops the cached decisions for the captures of the user with
+// key userKey.
+func (c *resultCache) eraseUser(userKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, element := range c.entries {
+		if resultBelongsTo(element.Value.(*cachedResult).result, userKey) {
+			c.removeLocked(element)
+		}
+	}
+}
+
+// ContentKey identifies a capture for caching. The user ID is part of the key
+// because the same clip yields a different decision against another gallery.
+func ContentKey(videoData []byte, userID string) string {
+	return contentKeyFromDigest(BytesVideo(videoData).Digest(), userID)
+}
+
+// contentKeyFromDigest is ContentKey for a capture known by its SHA-256, so
+// streamed uploads key the same as identical in-memory captures.
+func contentKeyFromDigest(digest, userID string) string {
+	hash := sha256.New()
+	hash.Write([]byte(digest))
+	hash.Write([]byte{0})
+	hash.Write([]byte(userID))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (s *FaceVerificationService) CachedResult(key string) (*models.VerificationResult, bool) {
+	return s.resultCache.get(key)
+}
+
+func (s *FaceVerificationService) CacheResult(key string, result *models.VerificationResult) {
+	s.resultCache.put(key, result)
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+const maxRecentResults = 10000
+
+// recentResults keeps the latest verification results in memory so status
+// lookups can report real outcomes. Oldest entries are evicted first.
+type recentResults struct {
+	mu      sync.RWMutex
+	results map[string]*models.VerificationResult
+	order   []string
+}
+
+func newRecentResults() *recentResults {
+	return &recentResults{
+		results: make(map[string]*models.VerificationResult),
+	}
+}
+
+func (r *recentResults) put(result *models.VerificationResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.results[result.VerificationID]; !exists {
+		r.order = append(r.order, result.VerificationID)
+	}
+	r.results[result.VerificationID] = result
+
+	for len(r.order) > maxRecentResults {
+		delete(r.results, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+func (r *recentResults) get(verificationID string) (*models.VerificationResult, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result, ok := r.results[verificationID]
+	return result, ok
+}
+
+// forUser returns the results of the user with key userKey.
+func (r *recentResults) forUser(userKey string) []*models.VerificationResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []*models.VerificationResult
+	for _, id := range r.order {
+		if result := r.results[id]; resultBelongsTo(result, userKey) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// eraseUser drops every result of the user with key userKey and returns
+// their verification IDs.
+func (r *recentResults) eraseUser(userKey string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var erased []string
+	order := r.order[:0]
+	for _, id := range r.order {
+		if resultBelongsTo(r.results[id], userKey) {
+			erased = append(erased, id)
+			delete(r.results, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	r.order = order
+	return erased
+}
+
+// resultBelongsTo reports whether result verified the user with key userKey.
+func resultBelongsTo(result *models.VerificationResult, userKey string) bool {
+	return result.UserID != "" && tenant.UserKey(result.Tenant, result.UserID) == userKey
+}
+
+// find returns up to limit results accepted by match, newest first.
+func (r *recentResults) find(match func(*models.VerificationResult) bool, limit int) []*models.VerificationResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := []*models.VerificationResult{}
+	for i := len(r.order) - 1; i >= 0 && len(results) < limit; i-- {
+		if result := r.results[r.order[i]]; match(result) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// verificationRecords tracks the lifecycle of every verification, from
+// pending through processing to completed or failed, so status lookups
+// reflect real outcomes. Oldest entries are evicted first. Watchers are
+// signalled whenever a record changes.
+type verificationRecords struct {
+	mu       sync.RWMutex
+	records  map[string]*models.VerificationRecord
+	order    []string
+	watchers map[string][]chan struct{}
+}
+
+func newVerificationRecords() *verificationRecords {
+	return &verificationRecords{
+		records:  make(map[string]*models.VerificationRecord),
+		watchers: make(map[string][]chan struct{}),
+	}
+}
+
+// watch returns a channel signalled, without blocking the update, after
+// each change to the record of verificationID, and the func that stops
+// watching. Changes made before the last signal was received are coalesced.
+func (r *verificationRecords) watch(verificationID string) (<-chan struct{}, func()) {
+	changed := make(chan struct{}, 1)
+	r.mu.Lock()
+	r.watchers[verificationID] = append(r.watchers[verificationID], changed)
+	r.mu.Unlock()
+
+	return changed, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		watchers := r.watchers[verificationID]
+		for i, watcher := range watchers {
+			if watcher == changed {
+				watchers = append(watchers[:i], watchers[i+1:]...)
+				break
+			}
+		}
+		if len(watchers) == 0 {
+			delete(r.watchers, verificationID)
+		} else {
+			r.watchers[verificationID] = watchers
+		}
+	}
+}
+
+// notify signals the watchers of verificationID; r.mu must be held.
+func (r *verificationRecords) notify(verificationID string) {
+	for _, changed := range r.watchers[verificationID] {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// begin registers a new verification as pending.
+func (r *verificationRecords) begin(verificationID, tenantID, userID, sessionID string) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.records[verificationID]; !exists {
+		r.order = append(r.order, verificationID)
+	}
+	r.records[verificationID] = &models.VerificationRecord{
+		ID:        verificationID,
+		Tenant:    tenantID,
+		UserID:    userID,
+		SessionID: sessionID,
+		Status:    models.StatusPending,
+		Stage:     models.StageUploadReceived,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.notify(verificationID)
+
+	for len(r.order) > maxRecentResults {
+		delete(r.records, r.order[0])
+		r.notify(r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+func (r *verificationRecords) setStatus(verificationID string, status models.VerificationStatus, errorMessage string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[verificationID]
+	if !ok {
+		return
+	}
+	record.Status = status
+	record.ErrorMessage = errorMessage
+	record.UpdatedAt = time.Now()
+	r.notify(verificationID)
+}
+
+// setStage records that a verification passed a pipeline stage.
+func (r *verificationRecords) setStage(verificationID string, stage models.VerificationStage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[verificationID]
+	if !ok {
+		return
+	}
+	record.Stage = stage
+	record.UpdatedAt = time.Now()
+	r.notify(verificationID)
+}
+
+// complete attaches the final result, registering the record first for
+// results produced outside VerifyVideo (e.g. two-phase continuations).
+func (r *verificationRecords) complete(result *models.VerificationResult) {
+	r.mu.RLock()
+	_, exists := r.records[result.VerificationID]
+	r.mu.RUnlock()
+	if !exists {
+		r.begin(result.VerificationID, result.Tenant, result.UserID, "")
+	}
+
+	status := models.StatusCompleted
+	if result.Error != "" {
+		status = models.StatusFailed
+	} else if result.Review != nil && result.Review.Status == models.ReviewPending {
+		status = models.StatusNeedsReview
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[result.VerificationID]; ok {
+		record.Status = status
+		record.Result = result
+		record.ErrorMessage = result.Error
+		record.UpdatedAt = time.Now()
+		r.notify(result.VerificationID)
+	}
+}
+
+// restore re-registers a record persisted before a restart.
+func (r *verificationRecords) restore(record models.VerificationRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.records[record.ID]; !exists {
+		r.order = append(r.order, record.ID)
+	}
+	r.records[record.ID] = &record
+	r.notify(record.ID)
+}
+
+// eraseUser drops every record of the user with key userKey and returns
+// their IDs.
+func (r *verificationRecords) eraseUser(userKey string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var erased []string
+	order := r.order[:0]
+	for _, id := range r.order {
+		record := r.records[id]
+		if record.UserID != "" && tenant.UserKey(record.Tenant, record.UserID) == userKey {
+			erased = append(erased, id)
+			delete(r.records, id)
+			r.notify(id)
+			continue
+		}
+		order = append(order, id)
+	}
+	r.order = order
+	return erased
+}
+
+// get returns a copy of the record so callers never race with updates.
+func (r *verificationRecords) get(verificationID string) (models.VerificationRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.records[verificationID]
+	if !ok {
+		return models.VerificationRecord{}, false
+	}
+	return *record, true
+}
+
+// GetVerificationRecord returns the lifecycle record of a verification.
+func (s *FaceVerificationService) GetVerificationRecord(verificationID string) (models.VerificationRecord, bool) {
+	return s.records.get(verificationID)
+}
+
+// WatchVerification returns a channel signalled after each change to the
+// record of verificationID, including its eviction or erasure, and the
+// func that stops watching. Read the record with GetVerificationRecord
+// once the channel is signalled; a watch started before the first read
+// misses no change.
+func (s *FaceVerificationService) WatchVerification(verificationID string) (<-chan struct{}, func()) {
+	return s.records.watch(verificationID)
+}
+
+func (s *FaceVerificationService) GetVerificationResult(verificationID string) (*models.VerificationResult, bool) {
+	return s.recentResults.get(verificationID)
+}
+
+// GetUserHistory returns a redacted, newest-first page of a user's
+// verifications along with the total number of entries. userID is the
+// user's key; see tenant.UserKey.
+func (s *FaceVerificationService) GetUserHistory(userID string, page, pageSize int) ([]models.HistoryEntry, int) {
+	results := s.recentResults.forUser(userID)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+
+	total := len(results)
+	start := (page - 1) * pageSize
+	if start >= total {
+		return []models.HistoryEntry{}, total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	entries := make([]models.HistoryEntry, 0, end-start)
+	for _, result := range results[start:end] {
+		entries = append(entries, models.HistoryEntry{
+			VerificationID: result.VerificationID,
+			Timestamp:      result.Timestamp,
+			Verified:       result.Verified,
+			Reason:         result.Reason,
+			Device:         result.Device,
+		})
+	}
+	return entries, total
+}
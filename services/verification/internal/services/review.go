@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review case not found")
+	ErrReviewDecided  = errors.New("review case already decided")
+)
+
+// Triggers of a manual review
+const (
+	ReviewTriggerConfidence = "confidence"
+	ReviewTriggerLiveness   = "liveness"
+	ReviewTriggerRisk       = "risk"
+)
+
+// reviewCases is the manual review queue. Cases live in memory like the
+// verification records they belong to; oldest entries are evicted first.
+type reviewCases struct {
+	mu    sync.RWMutex
+	cases map[string]*reviewCase
+	order []string
+}
+
+type reviewCase struct {
+	models.ReviewCase
+	result   *models.VerificationResult
+	liveness *models.LivenessResult
+	// JPEG of the matched frame, dropped once the case is decided
+	frame []byte
+}
+
+func newReviewCases() *reviewCases {
+	return &reviewCases{cases: make(map[string]*reviewCase)}
+}
+
+func (r *reviewCases) add(c *reviewCase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.cases[c.VerificationID]; !exists {
+		r.order = append(r.order, c.VerificationID)
+	}
+	r.cases[c.VerificationID] = c
+
+	for len(r.order) > maxRecentResults {
+		delete(r.cases, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// list returns the cases of tenantID with status, all of them when status
+// is empty, oldest first.
+func (r *reviewCases) list(tenantID string, status models.ReviewStatus) []models.ReviewCase {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cases := []models.ReviewCase{}
+	for _, id := range r.order {
+		c := r.cases[id]
+		if c.Tenant == tenantID && (status == "" || c.Status == status) {
+			cases = append(cases, c.snapshot())
+		}
+	}
+	return cases
+}
+
+func (r *reviewCases) evidence(tenantID, verificationID string) (models.ReviewEvidence, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.cases[verificationID]
+	if !ok || c.Tenant != tenantID {
+		return models.ReviewEvidence{}, false
+	}
+	return models.ReviewEvidence{
+		Case:     c.snapshot(),
+		Result:   c.result,
+		Liveness: c.liveness,
+		Frame:    c.frame,
+	}, true
+}
+
+// decide closes the pending case of tenantID with review and returns the
+// result it held.
+func (r *reviewCases) decide(tenantID, verificationID string, review models.Review) (*models.VerificationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.cases[verificationID]
+	if !ok || c.Tenant != tenantID {
+		return nil, ErrReviewNotFound
+	}
+	if c.Status != models.ReviewPending {
+		return nil, ErrReviewDecided
+	}
+	review.Triggers = c.Triggers
+	c.Review = review
+	c.frame = nil
+	return c.result, nil
+}
+
+// settle replaces the result of a decided case with its outcome.
+func (r *reviewCases) settle(result *models.VerificationResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.cases[result.VerificationID]; ok {
+		c.result = result
+	}
+}
+
+// eraseUser drops every case of the user with key userKey and returns
+// their verification IDs.
+func (r *reviewCases) eraseUser(userKey string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var erased []string
+	order := r.order[:0]
+	for _, id := range r.order {
+		c := r.cases[id]
+		if c.UserID != "" && tenant.UserKey(c.Tenant, c.UserID) == userKey {
+			erased = append(erased, id)
+			delete(r.cases, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	r.order = order
+	return erased
+}
+
+func (c *reviewCase) snapshot() models.ReviewCase {
+	snapshot := c.ReviewCase
+	snapshot.Triggers = append([]string(nil), c.Triggers...)
+	return snapshot
+}
+
+// inGrayZone reports whether score lies in [min, max]; a zero max disables
+// the zone.
+func inGrayZone(score, min, max float64) bool {
+	return max > 0 && score >= min && score <= max
+}
+
+// holdForReview puts a verification whose raw confidence or liveness score
+// fell in its gray zone, or that the risk engine wants reviewed, into the
+// review queue, with the matched frame, if any, as evidence. The result is reported as NEEDS_REVIEW and not verified until a
+// reviewer decides. Captures that failed for any other reason than their
+// scores are never held.
+func (s *FaceVerificationService) holdForReview(result *models.VerificationResult, frame image.Image, liveness *models.LivenessResult) {
+	if result.Synthetic || result.Error != "" {
+		return
+	}
+
+	var triggers []string
+	switch result.Reason {
+	case "", models.ReasonLowSimilarity:
+		if result.UserID != "" && inGrayZone(result.RawConfidence, s.config.ReviewConfidenceMin, s.config.ReviewConfidenceMax) {
+			triggers = append(triggers, ReviewTriggerConfidence)
+		}
+		if result.Verified && result.Risk != nil && result.Risk.Decision == models.RiskReview {
+			triggers = append(triggers, ReviewTriggerRisk)
+		}
+	case models.ReasonLivenessFailed:
+	default:
+		return
+	}
+	if inGrayZone(result.LivenessScore, s.config.ReviewLivenessMin, s.config.ReviewLivenessMax) {
+		triggers = append(triggers, ReviewTriggerLiveness)
+	}
+	if len(triggers) == 0 {
+		return
+	}
+
+	result.Verified = false
+	result.Reason = models.ReasonNeedsReview
+	result.Review = &models.Review{Status: models.ReviewPending, Triggers: triggers}
+
+	s.reviews.add(&reviewCase{
+		ReviewCase: models.ReviewCase{
+			VerificationID: result.VerificationID,
+			Tenant:         result.Tenant,
+			UserID:         result.UserID,
+			CreatedAt:      time.Now().UTC(),
+			Review:         *result.Review,
+		},
+		result:   result,
+		liveness: liveness,
+		frame:    s.encodeEvidence(frame),
+	})
+	metrics.ReviewsQueued.Add(1)
+	s.logger.Info("Verification held for review",
+		zap.String("verification_id", result.VerificationID),
+		zap.Strings("triggers", triggers))
+}
+
+// mayHoldForReview reports whether any verification can be held for
+// review: a gray zone or a risk policy is configured.
+func (s *FaceVerificationService) mayHoldForReview() bool {
+	s.riskMutex.RLock()
+	engine := s.riskEngine
+	s.riskMutex.RUnlock()
+	return s.config.ReviewConfidenceMax > 0 || s.config.ReviewLivenessMax > 0 || engine != nil
+}
+
+// encodeEvidence is frame as the JPEG reviewers are shown, or nil when
+// there is no frame.
+func (s *FaceVerificationService) encodeEvidence(frame image.Image) []byte {
+	if frame == nil {
+		return nil
+	}
+	var evidence bytes.Buffer
+	if err := jpeg.Encode(&evidence, frame, &jpeg.Options{Quality: 90}); err != nil {
+		s.logger.Warn("Failed to encode review evidence", zap.Error(err))
+	}
+	return evidence.Bytes()
+}
+
+// decodeEvidence is the frame encodeEvidence encoded, or nil.
+func decodeEvidence(data []byte) image.Image {
+	if len(data) == 0 {
+		return nil
+	}
+	frame, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return frame
+}
+
+// ReviewCases lists the review cases of tenantID, optionally only those
+// with status, oldest first.
+func (s *FaceVerificationService) ReviewCases(tenantID string, status models.ReviewStatus) []models.ReviewCase {
+	return s.reviews.list(tenantID, status)
+}
+
+// ReviewEvidence returns a review case of tenantID with what it is decided
+// on.
+func (s *FaceVerificationService) ReviewEvidence(tenantID, verificationID string) (models.ReviewEvidence, error) {
+	evidence, ok := s.reviews.evidence(tenantID, verificationID)
+	if !ok {
+		return models.ReviewEvidence{}, ErrReviewNotFound
+	}
+	return evidence, nil
+}
+
+// DecideReview approves or rejects a pending review case of tenantID. The
+// verification is completed with the reviewer's outcome, which updates its
+// record and is delivered to webhooks and Kafka like any other result.
+func (s *FaceVerificationService) DecideReview(tenantID, verificationID string, approve bool, reviewer, note string) (*models.VerificationResult, error) {
+	decidedAt := time.Now().UTC()
+	review := models.Review{
+		Status:    models.ReviewRejected,
+		Reviewer:  reviewer,
+		Note:      note,
+		DecidedAt: &decidedAt,
+	}
+	if approve {
+		review.Status = models.ReviewApproved
+	}
+
+	held, err := s.reviews.decide(tenantID, verificationID, review)
+	if err != nil {
+		return nil, err
+	}
+
+	// The held result may still be read elsewhere; the outcome is a copy
+	decided := *held
+	review.Triggers = held.Review.Triggers
+	decided.Review = &review
+	decided.Verified = approve
+	decided.Reason = models.ReasonReviewRejected
+	if approve {
+		decided.Reason = ""
+	}
+	decided.ReasonMessage = ""
+	decided.Attestation = ""
+
+	s.recordResult(&decided)
+	s.reviews.settle(&decided)
+
+	s.logger.Info("Review decided",
+		zap.String("verification_id", verificationID),
+		zap.String("status", string(review.Status)),
+		zap.String("reviewer", reviewer))
+	return &decided, nil
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// Risk signals, as named in policies and in RiskAssessment.Signals
+const (
+	RiskSignalLiveness     = "liveness"
+	RiskSignalMatch        = "match"
+	RiskSignalDevice       = "device"
+	RiskSignalIPReputation = "ip_reputation"
+	RiskSignalVelocity     = "velocity"
+	RiskSignalWatchlist    = "watchlist"
+)
+
+// RiskInput is what is known about a verification attempt once its capture
+// has been analysed.
+type RiskInput struct {
+	Tenant        string
+	UserID        string
+	LivenessScore float64
+	// Raw similarity to the claimed user; only meaningful when Matched
+	RawConfidence float64
+	Matched       bool
+	Device        string
+	// Device has not been seen before for a user who has used others
+	NewDevice bool
+	ClientIP  string
+	// Attempts in the policy's velocity window, this one included
+	UserAttempts   int
+	DeviceAttempts int
+	IPAttempts     int
+	// The face, device or IP was used with more accounts than
+	// VELOCITY_LIMITS allows
+	VelocityExceeded bool
+	WatchlistHit     bool
+}
+
+// RiskEngine turns the signals of an attempt into a risk score and
+// decision.
+type RiskEngine interface {
+	Assess(input RiskInput) models.RiskAssessment
+}
+
+// RiskPolicy configures the policy risk engine. Every signal is scored from
+// 0 (no risk) to 1; the risk score is the weighted sum of the signals,
+// capped at 1.
+type RiskPolicy struct {
+	Weights         map[string]float64 `json:"weights"`
+	ReviewThreshold float64            `json:"review_threshold"`
+	DenyThreshold   float64            `json:"deny_threshold"`
+	// Devices containing any of these strings score a device risk of 1
+	BlockedDevices []string `json:"blocked_devices"`
+	// Reputation of address ranges; the most specific range wins
+	IPReputation []IPReputation `json:"ip_reputation"`
+	Velocity     VelocityPolicy `json:"velocity"`
+}
+
+// IPReputation scores the addresses of a CIDR range from 0 (trusted) to 1.
+type IPReputation struct {
+	Network string  `json:"network"`
+	Score   float64 `json:"score"`
+}
+
+// VelocityPolicy limits attempts per user, device and client IP within a
+// window; the velocity signal is the highest share of a limit used.
+type VelocityPolicy struct {
+	WindowSeconds int `json:"window_seconds"`
+	MaxPerUser    int `json:"max_per_user"`
+	MaxPerDevice  int `json:"max_per_device"`
+	MaxPerIP      int `json:"max_per_ip"`
+}
+
+// DefaultRiskPolicy lets a watchlist hit deny on its own and spreads the
+// rest over the capture, the device and the client.
+func DefaultRiskPolicy() RiskPolicy {
+	return RiskPolicy{
+		Weights: map[string]float64{
+			RiskSignalLiveness:     0.3,
+			RiskSignalMatch:        0.3,
+			RiskSignalDevice:       0.1,
+			RiskSignalIPReputation: 0.15,
+			RiskSignalVelocity:     0.15,
+			RiskSignalWatchlist:    1,
+		},
+		ReviewThreshold: 0.5,
+		DenyThreshold:   0.8,
+		Velocity: VelocityPolicy{
+			WindowSeconds: 3600,
+			MaxPerUser:    10,
+			MaxPerDevice:  20,
+			MaxPerIP:      30,
+		},
+	}
+}
+
+// LoadRiskPolicy reads a JSON policy from path. Fields it leaves out keep
+// their DefaultRiskPolicy values; weights are merged signal by signal.
+func LoadRiskPolicy(path string) (RiskPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RiskPolicy{}, fmt.Errorf("reading risk policy: %w", err)
+	}
+
+	// Weights unmarshal into the default map, replacing only those given
+	policy := DefaultRiskPolicy()
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return RiskPolicy{}, fmt.Errorf("parsing risk policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// policyRiskEngine scores attempts with a RiskPolicy.
+type policyRiskEngine struct {
+	policy   RiskPolicy
+	networks []reputedNetwork
+}
+
+type reputedNetwork struct {
+	network *net.IPNet
+	score   float64
+}
+
+// NewPolicyRiskEngine validates policy and returns the engine applying it.
+func NewPolicyRiskEngine(policy RiskPolicy) (RiskEngine, error) {
+	for signal, weight := range policy.Weights {
+		if !knownRiskSignal(signal) {
+			return nil, fmt.Errorf("unknown risk signal %q", signal)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight of %s must not be negative", signal)
+		}
+	}
+	if policy.ReviewThreshold <= 0 || policy.DenyThreshold < policy.ReviewThreshold {
+		return nil, fmt.Errorf("risk thresholds must satisfy 0 < review_threshold <= deny_threshold")
+	}
+
+	engine := &policyRiskEngine{policy: policy}
+	for _, reputation := range policy.IPReputation {
+		_, network, err := net.ParseCIDR(reputation.Network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip_reputation network %q: %w", reputation.Network, err)
+		}
+		if reputation.Score < 0 || reputation.Score > 1 {
+			return nil, fmt.Errorf("reputation of %s must be between 0 and 1", reputation.Network)
+		}
+		engine.networks = append(engine.networks, reputedNetwork{network: network, score: reputation.Score})
+	}
+	return engine, nil
+}
+
+func knownRiskSignal(signal string) bool {
+	switch signal {
+	case RiskSignalLiveness, RiskSignalMatch, RiskSignalDevice, RiskSignalIPReputation, RiskSignalVelocity, RiskSignalWatchlist:
+		return true
+	}
+	return false
+}
+
+func (e *policyRiskEngine) Assess(input RiskInput) models.RiskAssessment {
+	signals := map[string]float64{
+		RiskSignalLiveness:     clamp01(1 - input.LivenessScore),
+		RiskSignalDevice:       e.deviceRisk(input),
+		RiskSignalIPReputation: e.ipRisk(input.ClientIP),
+		RiskSignalVelocity:     e.velocityRisk(input),
+	}
+	// Identifications have no claimed user to be more or less similar to
+	if input.Matched {
+		signals[RiskSignalMatch] = clamp01(1 - input.RawConfidence)
+	}
+	if input.WatchlistHit {
+		signals[RiskSignalWatchlist] = 1
+	}
+
+	assessment := models.RiskAssessment{Signals: make(map[string]float64)}
+	score := 0.0
+	for signal, value := range signals {
+		contribution := e.policy.Weights[signal] * value
+		if contribution == 0 {
+			continue
+		}
+		assessment.Signals[signal] = roundScore(contribution)
+		score += contribution
+	}
+	assessment.Score = roundScore(math.Min(score, 1))
+
+	switch {
+	case assessment.Score >= e.policy.DenyThreshold:
+		assessment.Decision = models.RiskDeny
+	case assessment.Score >= e.policy.ReviewThreshold:
+		assessment.Decision = models.RiskReview
+	default:
+		assessment.Decision = models.RiskApprove
+	}
+	return assessment
+}
+
+func (e *policyRiskEngine) deviceRisk(input RiskInput) float64 {
+	device := strings.ToLower(input.Device)
+	for _, blocked := range e.policy.BlockedDevices {
+		if blocked != "" && strings.Contains(device, strings.ToLower(blocked)) {
+			return 1
+		}
+	}
+	if input.Device == "" || input.NewDevice {
+		return 0.5
+	}
+	return 0
+}
+
+func (e *policyRiskEngine) ipRisk(clientIP string) float64 {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return 0
+	}
+	score, longest := 0.0, -1
+	for _, reputed := range e.networks {
+		if !reputed.network.Contains(ip) {
+			continue
+		}
+		if ones, _ := reputed.network.Mask.Size(); ones > longest {
+			score, longest = reputed.score, ones
+		}
+	}
+	return score
+}
+
+func (e *policyRiskEngine) velocityRisk(input RiskInput) float64 {
+	if input.VelocityExceeded {
+		return 1
+	}
+	velocity := e.policy.Velocity
+	risk := 0.0
+	for _, usage := range []struct{ attempts, limit int }{
+		{input.UserAttempts, velocity.MaxPerUser},
+		{input.DeviceAttempts, velocity.MaxPerDevice},
+		{input.IPAttempts, velocity.MaxPerIP},
+	} {
+		if usage.limit > 0 {
+			risk = math.Max(risk, float64(usage.attempts)/float64(usage.limit))
+		}
+	}
+	return clamp01(risk)
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// riskHistory remembers recent attempts per user, device and client IP for
+// the velocity signal, and the devices each user verified from.
+type riskHistory struct {
+	mu       sync.Mutex
+	window   time.Duration
+	attempts map[string][]time.Time
+	devices  map[string][]string
+}
+
+// Devices remembered per user; older ones count as new again
+const maxRiskDevices = 8
+
+func newRiskHistory(window time.Duration) *riskHistory {
+	return &riskHistory{
+		window:   window,
+		attempts: make(map[string][]time.Time),
+		devices:  make(map[string][]string),
+	}
+}
+
+// record counts an attempt under key and returns the attempts under key
+// within the window, this one included. An empty key counts nothing.
+func (h *riskHistory) record(key string, now time.Time) int {
+	if key == "" {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-h.window)
+	recent := h.attempts[key][:0]
+	for _, at := range h.attempts[key] {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	h.attempts[key] = append(recent, now)
+
+	// Keys of clients that went quiet are swept once there are many
+	if len(h.attempts) > maxRecentResults {
+		for k, times := range h.attempts {
+			if !times[len(times)-1].After(cutoff) {
+				delete(h.attempts, k)
+			}
+		}
+	}
+	return len(h.attempts[key])
+}
+
+// seenDevice remembers device for the user with key userKey and reports
+// whether it is new to a user who has used other devices.
+func (h *riskHistory) seenDevice(userKey, device string) bool {
+	if device == "" {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	known := h.devices[userKey]
+	for _, d := range known {
+		if d == device {
+			return false
+		}
+	}
+	known = append(known, device)
+	if len(known) > maxRiskDevices {
+		known = known[len(known)-maxRiskDevices:]
+	}
+	h.devices[userKey] = known
+	return len(known) > 1
+}
+
+// eraseUser forgets the attempts and devices of the user with key userKey.
+func (h *riskHistory) eraseUser(userKey string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.attempts, "user:"+userKey)
+	delete(h.devices, userKey)
+}
+
+func newRiskEngine(logger *zap.Logger, cfg *config.Config) (RiskEngine, time.Duration, error) {
+	if cfg.RiskPolicyPath == "" {
+		return nil, time.Hour, nil
+	}
+	policy, err := LoadRiskPolicy(cfg.RiskPolicyPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	engine, err := NewPolicyRiskEngine(policy)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid risk policy %s: %w", cfg.RiskPolicyPath, err)
+	}
+	window := time.Duration(policy.Velocity.WindowSeconds) * time.Second
+	if window <= 0 {
+		window = time.Hour
+	}
+	logger.Info("Risk scoring enabled", zap.String("policy", cfg.RiskPolicyPath))
+	return engine, window, nil
+}
+
+// SetRiskEngine replaces the engine scoring every verification; nil
+// disables risk scoring.
+func (s *FaceVerificationService) SetRiskEngine(engine RiskEngine) {
+	s.riskMutex.Lock()
+	s.riskEngine = engine
+	s.riskMutex.Unlock()
+}
+
+// assessRisk scores a completed verification with the risk engine. A deny
+// decision overturns a successful verification with reason RISK_DENIED; a
+// review decision is left to holdForReview. Failed captures are scored too,
+// so the attempt still counts towards velocity.
+func (s *FaceVerificationService) assessRisk(result *models.VerificationResult, clientIP string) {
+	s.riskMutex.RLock()
+	engine := s.riskEngine
+	s.riskMutex.RUnlock()
+	if engine == nil || result.Synthetic || result.Error != "" {
+		return
+	}
+
+	now := time.Now()
+	userKey := ""
+	if result.UserID != "" {
+		userKey = tenant.UserKey(result.Tenant, result.UserID)
+	}
+	input := RiskInput{
+		Tenant:        result.Tenant,
+		UserID:        result.UserID,
+		LivenessScore: result.LivenessScore,
+		RawConfidence: result.RawConfidence,
+		// A capture that failed liveness was never matched
+		Matched:          result.UserID != "" && result.Reason != models.ReasonLivenessFailed,
+		Device:           result.Device,
+		ClientIP:         clientIP,
+		WatchlistHit:     hasWarning(result, WarningWatchlistHit),
+		VelocityExceeded: hasWarning(result, WarningVelocityExceeded),
+	}
+	if userKey != "" {
+		input.UserAttempts = s.riskHistory.record("user:"+userKey, now)
+		input.NewDevice = s.riskHistory.seenDevice(userKey, result.Device)
+	}
+	if result.Device != "" {
+		input.DeviceAttempts = s.riskHistory.record("device:"+tenant.UserKey(result.Tenant, result.Device), now)
+	}
+	if clientIP != "" {
+		input.IPAttempts = s.riskHistory.record("ip:"+tenant.UserKey(result.Tenant, clientIP), now)
+	}
+
+	assessment := engine.Assess(input)
+	result.Risk = &assessment
+	metrics.RiskDecisions.Add(string(assessment.Decision), 1)
+
+	if assessment.Decision == models.RiskDeny && result.Verified {
+		result.Verified = false
+		result.Reason = models.ReasonRiskDenied
+		s.logger.Warn("Verification denied by risk policy",
+			zap.String("verification_id", result.VerificationID),
+			zap.Float64("risk_score", assessment.Score))
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrSelfBenchForbidden = errors.New("self-benchmark is disabled in production")
+var ErrSelfBenchRateLimited = errors.New("self-benchmark ran too recently or is already running")
+var ErrSelfBenchInvalid = errors.New("invalid self-benchmark parameters")
+
+const maxSelfBenchConcurrency = 32
+
+// SelfBenchOptions sizes a self-benchmark run.
+type SelfBenchOptions struct {
+	Requests    int `json:"requests"`
+	Concurrency int `json:"concurrency"`
+}
+
+// SelfBenchReport summarizes a run; latencies are in milliseconds.
+type SelfBenchReport struct {
+	Requests        int     `json:"requests"`
+	Concurrency     int     `json:"concurrency"`
+	Errors          int     `json:"errors"`
+	ErrorRate       float64 `json:"error_rate"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Throughput      float64 `json:"throughput_rps"`
+	LatencyMinMs    float64 `json:"latency_min_ms"`
+	LatencyP50Ms    float64 `json:"latency_p50_ms"`
+	LatencyP95Ms    float64 `json:"latency_p95_ms"`
+	LatencyP99Ms    float64 `json:"latency_p99_ms"`
+	LatencyMaxMs    float64 `json:"latency_max_ms"`
+}
+
+// selfBenchGuard allows one run at a time and enforces a cooldown between
+// runs so the endpoint can't be used to saturate the instance.
+type selfBenchGuard struct {
+	mu       sync.Mutex
+	running  bool
+	lastDone time.Time
+}
+
+func (g *selfBenchGuard) acquire(cooldown time.Duration) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.running || (!g.lastDone.IsZero() && time.Since(g.lastDone) < cooldown) {
+		return false
+	}
+	g.running = true
+	return true
+}
+
+func (g *selfBenchGuard) release() {
+	g.mu.Lock()
+	g.running = false
+	g.lastDone = time.Now()
+	g.mu.Unlock()
+}
+
+// SyntheticFrame renders the gradient test pattern also used by the package
+// benchmarks.
+func SyntheticFrame(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r := uint8((x * 255) / width)
+			g := uint8((y * 255) / height)
+			img.SetRGBA(x, y, color.RGBA{r, g, 128, 255})
+		}
+	}
+	return img
+}
+
+// SyntheticCapture encodes a SyntheticFrame as the capture payload.
+func SyntheticCapture(width, height int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, SyntheticFrame(width, height), &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RunSelfBench runs opts.Requests synthetic verifications at opts.Concurrency
+// through the full pipeline and reports throughput and latency percentiles.
+// Synthetic results are not recorded, exported or sent to webhooks.
+func (s *FaceVerificationService) RunSelfBench(ctx context.Context, opts SelfBenchOptions) (*SelfBenchReport, error) {
+	if s.config.Environment == "production" && !s.config.SelfBenchAllowProduction {
+		return nil, ErrSelfBenchForbidden
+	}
+
+	maxRequests := s.config.SelfBenchMaxRequests
+	if maxRequests <= 0 {
+		maxRequests = 500
+	}
+	if opts.Requests < 1 || opts.Requests > maxRequests ||
+		opts.Concurrency < 1 || opts.Concurrency > maxSelfBenchConcurrency {
+		return nil, ErrSelfBenchInvalid
+	}
+	if opts.Concurrency > opts.Requests {
+		opts.Concurrency = opts.Requests
+	}
+
+	if !s.selfBench.acquire(time.Duration(s.config.SelfBenchCooldown) * time.Second) {
+		return nil, ErrSelfBenchRateLimited
+	}
+	defer s.selfBench.release()
+
+	capture, err := SyntheticCapture(640, 480)
+	if err != nil {
+		return nil, err
+	}
+
+	latencies := make([]time.Duration, 0, opts.Requests)
+	errorCount := 0
+	var mu sync.Mutex
+
+	jobs := make(chan struct{})
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				begin := time.Now()
+				_, err := s.VerifyVideoContext(ctx, &models.VerificationRequest{
+					VideoData: capture,
+					SessionID: "selfbench",
+					Synthetic: true,
+				})
+				elapsed := time.Since(begin)
+
+				mu.Lock()
+				if err != nil {
+					errorCount++
+				} else {
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	submitted := 0
+submit:
+	for ; submitted < opts.Requests; submitted++ {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break submit
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	duration := time.Since(start)
+	report := &SelfBenchReport{
+		Requests:        submitted,
+		Concurrency:     opts.Concurrency,
+		Errors:          errorCount,
+		DurationSeconds: duration.Seconds(),
+	}
+	if submitted > 0 {
+		report.ErrorRate = float64(errorCount) / float64(submitted)
+	}
+	if duration > 0 {
+		report.Throughput = float64(len(latencies)) / duration.Seconds()
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.LatencyMinMs = durationMs(latencies[0])
+		report.LatencyP50Ms = durationMs(percentile(latencies, 0.50))
+		report.LatencyP95Ms = durationMs(percentile(latencies, 0.95))
+		report.LatencyP99Ms = durationMs(percentile(latencies, 0.99))
+		report.LatencyMaxMs = durationMs(latencies[len(latencies)-1])
+	}
+
+	s.logger.Info("Self-benchmark completed",
+		zap.Int("requests", report.Requests),
+		zap.Int("concurrency", report.Concurrency),
+		zap.Float64("throughput_rps", report.Throughput),
+		zap.Float64("p95_ms", report.LatencyP95Ms),
+		zap.Float64("error_rate", report.ErrorRate))
+
+	return report, ctx.Err()
+}
+
+// percentile uses the nearest-rank method on sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
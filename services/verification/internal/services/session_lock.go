@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrSessionInUse = errors.New("session is already in use")
+
+// sessionLocks tracks sessions with a verification in flight. Entries carry
+// an expiry so a lock leaked by a stuck request cannot block a session forever.
+type sessionLocks struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	locks map[string]time.Time
+}
+
+func newSessionLocks(ttl time.Duration) *sessionLocks {
+	return &sessionLocks{
+		ttl:   ttl,
+		locks: make(map[string]time.Time),
+	}
+}
+
+func (l *sessionLocks) acquire(sessionID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if expiry, held := l.locks[sessionID]; held && now.Before(expiry) {
+		return false
+	}
+
+	l.locks[sessionID] = now.Add(l.ttl)
+	return true
+}
+
+func (l *sessionLocks) release(sessionID string) {
+	l.mu.Lock()
+	delete(l.locks, sessionID)
+	l.mu.Unlock()
+}
+
+// AcquireSession reserves a session ID for the duration of a verification.
+// The returned release func must be called once processing finishes. When
+// unique sessions are not enforced it always succeeds.
+func (s *FaceVerificationService) AcquireSession(sessionID string) (func(), error) {
+	if !s.config.EnforceUniqueSessions || sessionID == "" {
+		return func() {}, nil
+	}
+
+	if !s.sessionLocks.acquire(sessionID) {
+		return nil, ErrSessionInUse
+	}
+
+	return func() { s.sessionLocks.release(sessionID) }, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// backgroundWork counts work that outlives the request that started it,
+// queued async jobs and webhook deliveries, so shutdown can wait for it.
+type backgroundWork struct {
+	mu      sync.Mutex
+	active  int
+	waiters []chan struct{}
+}
+
+func (w *backgroundWork) add() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.active++
+}
+
+func (w *backgroundWork) done() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.active--
+	if w.active == 0 {
+		for _, waiter := range w.waiters {
+			close(waiter)
+		}
+		w.waiters = nil
+	}
+}
+
+func (w *backgroundWork) outstanding() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.active
+}
+
+// wait blocks until no work is outstanding or ctx is done.
+func (w *backgroundWork) wait(ctx context.Context) error {
+	w.mu.Lock()
+	if w.active == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	w.waiters = append(w.waiters, idle)
+	w.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownDrainTimeout is how long Drain may wait for background work,
+// SHUTDOWN_DRAIN_TIMEOUT seconds.
+func ShutdownDrainTimeout(cfg *config.Config) time.Duration {
+	if cfg.ShutdownDrainTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(cfg.ShutdownDrainTimeout) * time.Second
+}
+
+// ShutdownDelay is how long readiness reports the service draining before
+// it stops listening, SHUTDOWN_DELAY seconds, so load balancers stop
+// routing to it first.
+func ShutdownDelay(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.ShutdownDelay) * time.Second
+}
+
+// BeginDrain stops the service accepting background work: new async jobs
+// are refused with ErrShuttingDown and readiness fails from now on. Work
+// already accepted carries on.
+func (s *FaceVerificationService) BeginDrain() {
+	if s.draining.CompareAndSwap(false, true) {
+		s.logger.Info("Draining, no longer accepting background work",
+			zap.Int("outstanding", s.background.outstanding()))
+	}
+}
+
+// Draining reports whether BeginDrain was called.
+func (s *FaceVerificationService) Draining() bool {
+	return s.draining.Load()
+}
+
+// Drain begins draining and waits until queued async jobs, webhook
+// deliveries and template refreshes have finished or ctx is done, in which case the work left is
+// abandoned and ctx's error returned. Close the service afterwards.
+func (s *FaceVerificationService) Drain(ctx context.Context) error {
+	s.BeginDrain()
+	start := time.Now()
+	if err := s.background.wait(ctx); err != nil {
+		s.logger.Warn("Drain deadline reached, abandoning background work",
+			zap.Int("outstanding", s.background.outstanding()),
+			zap.Duration("waited", time.Since(start)))
+		return err
+	}
+	s.logger.Info("Background work drained", zap.Duration("waited", time.Since(start)))
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"math"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// dot is the dot product of two vectors of the same length. It sums in four
+// independent lanes, so successive multiply-adds do not wait on each other
+// and the compiler keeps the lanes in registers.
+func dot(a, b []float32) float64 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float64
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += float64(a[i]) * float64(b[i])
+		s1 += float64(a[i+1]) * float64(b[i+1])
+		s2 += float64(a[i+2]) * float64(b[i+2])
+		s3 += float64(a[i+3]) * float64(b[i+3])
+	}
+	for ; i < len(a); i++ {
+		s0 += float64(a[i]) * float64(b[i])
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// unitTemplates normalizes templates once, when the gallery is loaded, so
+// matching a capture against them is a dot product per template rather
+// than a cosine that recomputes both norms every time. The unit vectors
+// share one backing array, in the order of templates, and are scanned
+// front to back. A template of zero norm stays all zeros and scores 0.
+func unitTemplates(templates []models.FaceVector) [][]float32 {
+	size := 0
+	for _, template := range templates {
+		size += len(template.Vector)
+	}
+	backing := make([]float32, size)
+	units := make([][]float32, len(templates))
+	for i, template := range templates {
+		unit := backing[:len(template.Vector):len(template.Vector)]
+		backing = backing[len(template.Vector):]
+		normalizeInto(unit, template.Vector)
+		units[i] = unit
+	}
+	return units
+}
+
+// unitGallery is unitTemplates for every user of gallery.
+func unitGallery(gallery map[string][]models.FaceVector) map[string][][]float32 {
+	units := make(map[string][][]float32, len(gallery))
+	for userKey, templates := range gallery {
+		units[userKey] = unitTemplates(templates)
+	}
+	return units
+}
+
+// normalizeInto writes v scaled to unit length to dst, of the same length,
+// or zeros when v has no length.
+func normalizeInto(dst, v []float32) {
+	norm := math.Sqrt(dot(v, v))
+	if norm == 0 {
+		for i := range dst {
+			dst[i] = 0
+		}
+		return
+	}
+	for i, f := range v {
+		dst[i] = float32(float64(f) / norm)
+	}
+}
+
+// unitSimilarity is the cosine similarity of two unit vectors. Vectors of
+// different lengths score 0, as cosineSimilarity has them.
+func unitSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0.0
+	}
+	return dot(a, b)
+}
+
+// unitSimilarities is the cosine similarity of the unit vector query to
+// each of units, scored in one pass over their contiguous backing array.
+func unitSimilarities(query []float32, units [][]float32) []float64 {
+	out := make([]float64, len(units))
+	for i, unit := range units {
+		out[i] = unitSimilarity(query, unit)
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"image"
+	"image/jpeg"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrInvalidFrame = errors.New("invalid frame")
+
+var jpegMagic = []byte{0xFF, 0xD8, 0xFF}
+
+// IsJPEG reports whether data starts with the JPEG SOI marker.
+func IsJPEG(data []byte) bool {
+	return bytes.HasPrefix(data, jpegMagic)
+}
+
+// decodeSubmittedFrames decodes client pre-extracted JPEG frames. All frames
+// must share the first frame's dimensions so motion analysis compares like
+// with like.
+func decodeSubmittedFrames(data [][]byte) ([]image.Image, error) {
+	frames := make([]image.Image, 0, len(data))
+	for i, frameData := range data {
+		frame, err := decodeSubmittedFrame(frames, i, frameData)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// decodeSubmittedFrame decodes frame i of a submission, checking it against
+// the frames decoded before it.
+func decodeSubmittedFrame(previous []image.Image, i int, frameData []byte) (image.Image, error) {
+	if !IsJPEG(frameData) {
+		return nil, fmt.Errorf("%w: frame %d is not a JPEG", ErrInvalidFrame, i)
+	}
+	frame, err := jpeg.Decode(bytes.NewReader(frameData))
+	if err != nil {
+		return nil, fmt.Errorf("%w: frame %d: %v", ErrInvalidFrame, i, err)
+	}
+	frame = orientImage(frame, jpegOrientation(frameData))
+	if len(previous) > 0 && frame.Bounds().Size() != previous[0].Bounds().Size() {
+		return nil, fmt.Errorf("%w: frame %d dimensions differ from frame 0", ErrInvalidFrame, i)
+	}
+	return frame, nil
+}
+
+// framesForRequest returns the frames to analyze: the client's pre-extracted
+// frames when present, otherwise frames extracted from the video. They are
+// at full size; the caller downscales them.
+func (s *FaceVerificationService) framesForRequest(ctx context.Context, req *models.VerificationRequest) ([]image.Image, error) {
+	if len(req.FrameData) > 0 {
+		return decodeSubmittedFrames(req.FrameData)
+	}
+	return s.decodeCapture(ctx, requestVideo(req))
+}
+
+// RequestContentKey is ContentKey extended to pre-extracted frames, each
+// length-prefixed so frame boundaries are part of the identity. The tenant
+// is part of the key as well, so no decision is shared across tenants.
+func RequestContentKey(req *models.VerificationRequest) string {
+	key := payloadContentKey(req)
+	// Every liveness session is its own attempt, even with identical content
+	if req.LivenessSession != "" {
+		key += ":" + req.LivenessSession
+	}
+	return key
+}
+
+func payloadContentKey(req *models.VerificationRequest) string {
+	if len(req.FrameData) == 0 {
+		return contentKeyFromDigest(requestVideo(req).Digest(), tenant.UserKey(req.Tenant, req.UserID))
+	}
+
+	hash := sha256.New()
+	hashFrames(hash, req.FrameData)
+	hash.Write([]byte{0})
+	hash.Write([]byte(tenant.UserKey(req.Tenant, req.UserID)))
+	return "frames:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// payloadDigest is the hex SHA-256 of req's capture, or of its frames each
+// length-prefixed.
+func payloadDigest(req *models.VerificationRequest) string {
+	if len(req.FrameData) == 0 {
+		return requestVideo(req).Digest()
+	}
+	hash := sha256.New()
+	hashFrames(hash, req.FrameData)
+	return "frames:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func hashFrames(h hash.Hash, frames [][]byte) {
+	var length [8]byte
+	for _, frame := range frames {
+		binary.BigEndian.PutUint64(length[:], uint64(len(frame)))
+		h.Write(length[:])
+		h.Write(frame)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// Compact face template layout (all multi-byte fields little-endian):
+//
+//	byte 0      format version (TemplateVersion)
+//	byte 1      encoding: 0 = float32, 1 = int8 quantized
+//	bytes 2-3   uint16 number of dimensions N
+//	float32:    N * 4 bytes of IEEE-754 float32 values
+//	int8:       bytes 4-7 float32 scale, then N signed bytes; value = q * scale
+const (
+	TemplateVersion = 1
+
+	templateEncodingFloat32 = 0
+	templateEncodingInt8    = 1
+)
+
+var ErrInvalidTemplate = errors.New("invalid face template")
+
+// EncodeTemplate serializes a descriptor into the compact binary layout.
+func EncodeTemplate(vector []float32, quantize bool) []byte {
+	if !quantize {
+		buf := make([]byte, 4+4*len(vector))
+		buf[0] = TemplateVersion
+		buf[1] = templateEncodingFloat32
+		binary.LittleEndian.PutUint16(buf[2:4], uint16(len(vector)))
+		for i, v := range vector {
+			binary.LittleEndian.PutUint32(buf[4+4*i:], math.Float32bits(v))
+		}
+		return buf
+	}
+
+	maxAbs := float32(0)
+	for _, v := range vector {
+		if abs := float32(math.Abs(float64(v))); abs > maxAbs {
+			maxAbs = abs
+		}
+	}
+	scale := maxAbs / 127
+	if scale == 0 {
+		scale = 1
+	}
+
+	buf := make([]byte, 8+len(vector))
+	buf[0] = TemplateVersion
+	buf[1] = templateEncodingInt8
+	binary.LittleEndian.PutUint16(buf[2:4], uint16(len(vector)))
+	binary.LittleEndian.PutUint32(buf[4:8], math.Float32bits(scale))
+	for i, v := range vector {
+		q := math.Round(float64(v / scale))
+		buf[8+i] = byte(int8(math.Max(-127, math.Min(127, q))))
+	}
+	return buf
+}
+
+// DecodeTemplate parses a compact template back into a descriptor.
+func DecodeTemplate(data []byte) ([]float32, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidTemplate
+	}
+	if data[0] != TemplateVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidTemplate, data[0])
+	}
+
+	dims := int(binary.LittleEndian.Uint16(data[2:4]))
+	if dims == 0 {
+		return nil, ErrInvalidTemplate
+	}
+
+	vector := make([]float32, dims)
+	switch data[1] {
+	case templateEncodingFloat32:
+		if len(data) != 4+4*dims {
+			return nil, fmt.Errorf("%w: length mismatch", ErrInvalidTemplate)
+		}
+		for i := range vector {
+			vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4+4*i:]))
+		}
+	case templateEncodingInt8:
+		if len(data) != 8+dims {
+			return nil, fmt.Errorf("%w: length mismatch", ErrInvalidTemplate)
+		}
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(data[4:8]))
+		for i := range vector {
+			vector[i] = float32(int8(data[8+i])) * scale
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown encoding %d", ErrInvalidTemplate, data[1])
+	}
+
+	for _, v := range vector {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil, fmt.Errorf("%w: non-finite value", ErrInvalidTemplate)
+		}
+	}
+
+	return vector, nil
+}
+
+var ErrNotLive = errors.New("liveness check failed")
+var ErrNotEnrolled = errors.New("user has no enrolled face")
+
+// ExtractTemplate returns the face descriptor of a live in-memory capture.
+func (s *FaceVerificationService) ExtractTemplate(videoData []byte) ([]float32, error) {
+	return s.ExtractTemplateVideo(BytesVideo(videoData))
+}
+
+// ExtractTemplateVideo returns the face descriptor of a live capture.
+func (s *FaceVerificationService) ExtractTemplateVideo(video models.VideoSource) ([]float32, error) {
+	frames, err := s.extractFramesFromVideo(context.Background(), video)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames extracted")
+	}
+
+	liveness, err := s.detectLiveness(tenant.Default, frames)
+	if err != nil {
+		return nil, err
+	}
+	if !liveness.IsLive {
+		return nil, ErrNotLive
+	}
+
+	return s.captureFaceVector(frames, nil)
+}
+
+// MatchTemplate is MatchTemplateFrom a client of unknown IP.
+func (s *FaceVerificationService) MatchTemplate(userID string, vector []float32) (float64, bool, error) {
+	return s.MatchTemplateFrom(userID, vector, "")
+}
+
+// MatchTemplateFrom compares a descriptor held by the client at clientIP
+// against a user's enrolled gallery, returning the calibrated confidence
+// and the match decision. Like a verification it is refused while the user
+// or client is locked out, and counts towards their lockouts, so it cannot
+// probe a gallery without limit. userID is the user's key; see
+// tenant.UserKey.
+func (s *FaceVerificationService) MatchTemplateFrom(userID string, vector []float32, clientIP string) (float64, bool, error) {
+	tenantID, bareUserID := tenant.SplitUserKey(userID)
+	if err := s.CheckLockout(tenantID, bareUserID, clientIP); err != nil {
+		return 0.0, false, err
+	}
+
+	s.storageMutex.RLock()
+	enrolled := len(s.faceVectors[userID]) > 0
+	s.storageMutex.RUnlock()
+
+	if !enrolled && !s.readThrough(userID) {
+		return 0.0, false, ErrNotEnrolled
+	}
+
+	similarity, err := s.checkForDuplicates(userID, vector)
+	if err != nil {
+		return 0.0, false, err
+	}
+
+	matched := similarity >= s.similarityThreshold(tenantID)
+	s.countAttempt(&models.VerificationResult{Tenant: tenantID, UserID: bareUserID, Verified: matched}, clientIP)
+	return s.calibration.Apply(similarity), matched, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrCapturesMismatch = errors.New("enrollment captures do not show the same face")
+
+// MaxEnrollmentCaptures is the most captures one registration can enroll.
+const MaxEnrollmentCaptures = 5
+
+// Template fusion strategies for ENROLLMENT_FUSION
+const (
+	// FusionAll keeps every enrolled descriptor as a template
+	FusionAll = "all"
+	// FusionAverage keeps one template, the mean of all descriptors
+	FusionAverage = "average"
+	// FusionDiverse keeps up to ENROLLMENT_MAX_TEMPLATES templates, the
+	// newest and those least alike
+	FusionDiverse = "diverse"
+)
+
+// ValidTemplateFusion reports whether fusion names a fusion strategy.
+func ValidTemplateFusion(fusion string) bool {
+	switch fusion {
+	case "", FusionAll, FusionAverage, FusionDiverse:
+		return true
+	}
+	return false
+}
+
+func maxTemplates(maxConfigured int) int {
+	if maxConfigured > 0 {
+		return maxConfigured
+	}
+	return 5
+}
+
+// storeTemplates adds the templates enrolled for userID to the vector store
+// and the gallery. Unless every descriptor is kept, they are fused with the
+// user's stored templates. Stale templates of other recognizer models are
+// dropped.
+func (s *FaceVerificationService) storeTemplates(userID string, added []models.FaceVector) error {
+	if fusion := s.config.EnrollmentFusion; (fusion == "" || fusion == FusionAll) && s.StaleTemplateCount(userID) == 0 {
+		for _, vector := range added {
+			if err := s.vectorStore.Save(vector); err != nil {
+				return err
+			}
+		}
+		s.addUserTemplates(userID, added)
+		return nil
+	}
+	_, err := s.updateTemplates(userID, func(stored []models.FaceVector) []models.FaceVector {
+		return append(stored, added...)
+	})
+	return err
+}
+
+// updateTemplates replaces userID's stored templates, and those in the
+// gallery, with what change makes of those of the current recognizer
+// models, oldest first, fused as ENROLLMENT_FUSION says. A nil change
+// leaves them as they are. It reports whether anything was rewritten.
+func (s *FaceVerificationService) updateTemplates(userID string, change func(stored []models.FaceVector) []models.FaceVector) (bool, error) {
+	// Reads and rewrites all of the user's templates
+	s.templateMutex.Lock()
+	defer s.templateMutex.Unlock()
+
+	stored, err := s.vectorStore.Load(userID)
+	if err != nil {
+		return false, err
+	}
+	templates := change(s.currentTemplates(stored))
+	if templates == nil {
+		return false, nil
+	}
+	if len(templates) > 0 {
+		templates = s.fuseTemplates(s.config.EnrollmentFusion, templates, maxTemplates(s.config.EnrollmentMaxTemplates), time.Now())
+	}
+
+	if err := s.vectorStore.Delete(userID); err != nil {
+		return false, err
+	}
+	for _, vector := range templates {
+		if err := s.vectorStore.Save(vector); err != nil {
+			return false, fmt.Errorf("failed to store templates: %w", err)
+		}
+	}
+	s.setUserTemplates(userID, templates)
+	return true, nil
+}
+
+// fuseTemplates fuses a user's templates, oldest first, with fusion; "all"
+// keeps them as they are.
+func (s *FaceVerificationService) fuseTemplates(fusion string, templates []models.FaceVector, limit int, now time.Time) []models.FaceVector {
+	newest := templates[len(templates)-1]
+	switch fusion {
+	case FusionAverage:
+		// Unit vectors weigh every capture the same
+		mean := make([]float32, len(newest.Vector))
+		quality := 0.0
+		for _, template := range templates {
+			if len(template.Vector) != len(mean) {
+				continue
+			}
+			for i, f := range normalizeVector(template.Vector) {
+				mean[i] += f
+			}
+			quality += template.Quality
+		}
+		// The mean is as young as its newest capture for MIN_ENROLLMENT_AGE
+		return []models.FaceVector{{
+			UserID:    newest.UserID,
+			Vector:    normalizeVector(mean),
+			CreatedAt: now,
+			Version:   newest.Version,
+			Quality:   quality / float64(len(templates)),
+			Source:    newest.Source,
+		}}
+
+	case FusionDiverse:
+		if len(templates) <= limit {
+			return templates
+		}
+		// Greedily add the template least like those kept, starting from
+		// the newest capture
+		kept := make([]bool, len(templates))
+		kept[len(templates)-1] = true
+		closest := make([]float64, len(templates))
+		for i := range templates {
+			closest[i] = s.cosineSimilarity(templates[i].Vector, newest.Vector)
+		}
+		for n := 1; n < limit; n++ {
+			next := -1
+			for i := range templates {
+				if !kept[i] && (next < 0 || closest[i] < closest[next]) {
+					next = i
+				}
+			}
+			kept[next] = true
+			for i := range templates {
+				if similarity := s.cosineSimilarity(templates[i].Vector, templates[next].Vector); similarity > closest[i] {
+					closest[i] = similarity
+				}
+			}
+		}
+		fused := make([]models.FaceVector, 0, limit)
+		for i, template := range templates {
+			if kept[i] {
+				fused = append(fused, template)
+			}
+		}
+		return fused
+	}
+	return templates
+}
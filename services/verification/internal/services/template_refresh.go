@@ -0,0 +1,164 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// Why a verified capture refreshed a user's templates
+const (
+	TemplateRefreshAged    = "aged"
+	TemplateRefreshQuality = "quality"
+)
+
+// templateRefreshPolicy decides when a verified capture becomes a template.
+type templateRefreshPolicy struct {
+	// Templates older than this are replaced, 0 never
+	maxAge        time.Duration
+	qualityMargin float64
+	minSimilarity float64
+}
+
+func newTemplateRefreshPolicy(cfg *config.Config) templateRefreshPolicy {
+	policy := templateRefreshPolicy{
+		maxAge:        time.Duration(cfg.TemplateMaxAge) * 24 * time.Hour,
+		qualityMargin: cfg.TemplateQualityMargin,
+		minSimilarity: cfg.TemplateRefreshMinSimilarity,
+	}
+	if policy.qualityMargin <= 0 {
+		policy.qualityMargin = 0.2
+	}
+	if policy.minSimilarity <= 0 {
+		policy.minSimilarity = 0.85
+	}
+	return policy
+}
+
+// reason returns why a capture of quality should refresh templates, or ""
+// if it should not. Aged templates come first. Templates of unmeasured
+// quality cannot be outdone on quality.
+func (p templateRefreshPolicy) reason(templates []models.FaceVector, quality float64, now time.Time) string {
+	best := 0.0
+	for _, template := range templates {
+		if p.aged(template, now) {
+			return TemplateRefreshAged
+		}
+		best = math.Max(best, template.Quality)
+	}
+	if best > 0 && quality >= best+p.qualityMargin {
+		return TemplateRefreshQuality
+	}
+	return ""
+}
+
+func (p templateRefreshPolicy) aged(template models.FaceVector, now time.Time) bool {
+	return p.maxAge > 0 && now.Sub(template.CreatedAt) > p.maxAge
+}
+
+// maybeRefreshTemplates stores the descriptor of a verified capture as a
+// template of userKey when TEMPLATE_REFRESH_ENABLED is set and the user's
+// templates have aged or are of clearly lower quality. Only strong matches
+// with assessed liveness that were not held for review qualify, so a
+// capture cannot drift a user's templates towards another face. The store
+// is updated in the background.
+func (s *FaceVerificationService) maybeRefreshTemplates(result *models.VerificationResult, userKey string, faceVector []float32, quality float64) {
+	if !s.config.TemplateRefreshEnabled || userKey == "" || !result.Verified || result.Synthetic ||
+		result.Reason == models.ReasonNeedsReview || result.LivenessMethod == models.LivenessMethodNone {
+		return
+	}
+	policy := newTemplateRefreshPolicy(s.config)
+	if result.RawConfidence < math.Max(policy.minSimilarity, s.similarityThreshold(result.Tenant)) {
+		return
+	}
+
+	s.storageMutex.RLock()
+	reason := policy.reason(s.faceVectors[userKey], quality, time.Now())
+	s.storageMutex.RUnlock()
+	if reason == "" || s.Draining() {
+		return
+	}
+
+	s.background.add()
+	go func() {
+		defer s.background.done()
+		s.refreshTemplates(result.VerificationID, userKey, faceVector, quality, policy)
+	}()
+}
+
+// refreshTemplates adds the capture as a template of userKey in place of
+// the aged ones, checking again against the stored templates since another
+// capture may have refreshed them meanwhile. The refresh is audited and
+// reported as a face.template_refreshed event.
+func (s *FaceVerificationService) refreshTemplates(verificationID, userKey string, faceVector []float32, quality float64, policy templateRefreshPolicy) {
+	var reason string
+	replaced := 0
+	refreshed, err := s.updateTemplates(userKey, func(stored []models.FaceVector) []models.FaceVector {
+		now := time.Now()
+		if reason = policy.reason(stored, quality, now); reason == "" {
+			return nil
+		}
+		kept := make([]models.FaceVector, 0, len(stored)+1)
+		for _, template := range stored {
+			if policy.aged(template, now) {
+				replaced++
+				continue
+			}
+			kept = append(kept, template)
+		}
+		return append(kept, models.FaceVector{
+			UserID:    userKey,
+			Vector:    faceVector,
+			CreatedAt: now,
+			Version:   s.descriptorVersion,
+			Quality:   quality,
+			Source:    models.TemplateSourceRefresh,
+		})
+	})
+
+	tenantID, userID := tenant.SplitUserKey(userKey)
+	audit := models.AuditEvent{
+		Operation:      models.AuditTemplateRefresh,
+		Tenant:         tenantID,
+		UserID:         userID,
+		VerificationID: verificationID,
+		Result:         models.AuditSuccess,
+		Transport:      "internal",
+	}
+	if err == nil && refreshed {
+		err = s.galleryChanged(userKey)
+	}
+	if err != nil {
+		s.logger.Error("Template refresh failed",
+			zap.String("verification_id", verificationID),
+			zap.Error(err))
+		audit.Result = models.AuditError
+		s.RecordAudit(audit)
+		return
+	}
+	if !refreshed {
+		return
+	}
+
+	templates := s.TemplateCount(userKey)
+	metrics.TemplateRefreshes.Add(1)
+	s.logger.Info("Refreshed templates from a verified capture",
+		zap.String("verification_id", verificationID),
+		zap.String("tenant", tenantID),
+		zap.String("reason", reason),
+		zap.Int("replaced", replaced),
+		zap.Int("templates", templates))
+	s.RecordAudit(audit)
+	s.publishEvent(models.EventTemplateRefreshed, userKey, verificationID, models.TemplateRefreshEvent{
+		Reason:    reason,
+		Replaced:  replaced,
+		Templates: templates,
+		Quality:   quality,
+	})
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrInvalidTenantConfig = errors.New("invalid tenant configuration")
+
+// TenantConfig returns the overrides an admin has set for tenantID.
+func (s *FaceVerificationService) TenantConfig(tenantID string) (models.TenantConfig, bool) {
+	return s.tenantConfigs.Get(tenantID)
+}
+
+// SetTenantConfig replaces the overrides of tenantID with cfg. Detectors
+// must be part of the liveness pipeline.
+func (s *FaceVerificationService) SetTenantConfig(tenantID string, cfg models.TenantConfig) (models.TenantConfig, error) {
+	for name, threshold := range map[string]*float64{
+		"liveness_threshold":   cfg.LivenessThreshold,
+		"similarity_threshold": cfg.SimilarityThreshold,
+	} {
+		if threshold != nil && (*threshold < 0 || *threshold > 1) {
+			return models.TenantConfig{}, fmt.Errorf("%w: %s must be between 0 and 1", ErrInvalidTenantConfig, name)
+		}
+	}
+	if cfg.MaxUploadSize != nil && *cfg.MaxUploadSize <= 0 {
+		return models.TenantConfig{}, fmt.Errorf("%w: max_upload_size must be positive", ErrInvalidTenantConfig)
+	}
+
+	weighted := make(map[string]bool)
+	for _, w := range s.currentTuning().livenessWeights {
+		if w.Weight > 0 {
+			weighted[w.Name] = true
+		}
+	}
+	detectors := make([]string, 0, len(cfg.LivenessDetectors))
+	seen := make(map[string]bool)
+	for _, name := range cfg.LivenessDetectors {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !weighted[name] {
+			return models.TenantConfig{}, fmt.Errorf("%w: liveness detector %q is not in the pipeline", ErrInvalidTenantConfig, name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			detectors = append(detectors, name)
+		}
+	}
+	cfg.LivenessDetectors = nil
+	if len(detectors) > 0 {
+		cfg.LivenessDetectors = detectors
+	}
+
+	cfg.UpdatedAt = time.Now().UTC()
+	if err := s.tenantConfigs.Put(tenantID, cfg); err != nil {
+		return models.TenantConfig{}, fmt.Errorf("failed to save tenant configuration: %w", err)
+	}
+	s.logger.Info("Tenant configuration updated", zap.String("tenant", tenantID))
+	return cfg, nil
+}
+
+// DeleteTenantConfig drops the overrides of tenantID, so it falls back to
+// the configured settings. It reports whether there were any.
+func (s *FaceVerificationService) DeleteTenantConfig(tenantID string) (bool, error) {
+	deleted, err := s.tenantConfigs.Delete(tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to save tenant configuration: %w", err)
+	}
+	return deleted, nil
+}
+
+// TenantSettings returns the settings in effect for tenantID.
+func (s *FaceVerificationService) TenantSettings(tenantID string) models.TenantSettings {
+	settings := models.TenantSettings{
+		LivenessThreshold:   s.livenessThreshold(tenantID),
+		SimilarityThreshold: s.similarityThreshold(tenantID),
+		MaxUploadSize:       s.MaxUploadSize(tenantID),
+	}
+	enabled := s.enabledLivenessDetectors(tenantID)
+	for _, w := range s.currentTuning().livenessWeights {
+		if w.Weight > 0 && (enabled == nil || enabled[w.Name]) {
+			settings.LivenessDetectors = append(settings.LivenessDetectors, w.Name)
+		}
+	}
+	return settings
+}
+
+// MaxUploadSize is the largest capture tenantID may upload, 0 when neither
+// the tenant nor MAX_UPLOAD_SIZE sets one.
+func (s *FaceVerificationService) MaxUploadSize(tenantID string) int64 {
+	if override, ok := s.tenantConfigs.Get(tenantID); ok && override.MaxUploadSize != nil {
+		return *override.MaxUploadSize
+	}
+	return s.config.MaxUploadSize
+}
+
+// enabledLivenessDetectors is the set of detectors tenantID's captures are
+// scored with, nil for the whole pipeline.
+func (s *FaceVerificationService) enabledLivenessDetectors(tenantID string) map[string]bool {
+	override, ok := s.tenantConfigs.Get(tenantID)
+	if !ok || len(override.LivenessDetectors) == 0 {
+		return nil
+	}
+	enabled := make(map[string]bool, len(override.LivenessDetectors))
+	for _, name := range override.LivenessDetectors {
+		enabled[name] = true
+	}
+	return enabled
+}
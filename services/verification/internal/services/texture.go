@@ -0,0 +1,86 @@
+package services
+
+import (
+	"image"
+	"sync"
+)
+
+// integralPool recycles the summed-area tables texture scoring builds, two
+// per frame, which are as large as the frame itself.
+var integralPool sync.Pool
+
+// integralImages are the summed-area tables of a frame's luminance and of
+// its square: entry (x, y) holds the sum over the pixels above and to the
+// left of it, so the sum over any rectangle takes four lookups. Row and
+// column 0 are zeros. The sums are kept modulo 2^32, which does not matter
+// since a box sum taken from them is exact as long as it fits in 32 bits.
+type integralImages struct {
+	sum, sumSq []uint32
+	stride     int
+}
+
+// luma is the luminance of an 8-bit RGB sample, weighted as
+// color.GrayModel weighs it. A grey sample is its own luminance.
+func luma(r, g, b uint8) uint32 {
+	return (19595*uint32(r) + 38470*uint32(g) + 7471*uint32(b) + 1<<15) >> 16
+}
+
+// luminanceIntegrals builds the summed-area tables of rgba's luminance in
+// recycled buffers, which release hands back.
+func luminanceIntegrals(rgba *image.RGBA) *integralImages {
+	bounds := rgba.Rect
+	stride, rows := bounds.Dx()+1, bounds.Dy()+1
+	n := stride * rows
+
+	ii, _ := integralPool.Get().(*integralImages)
+	if ii == nil || cap(ii.sum) < n {
+		ii = &integralImages{sum: make([]uint32, n), sumSq: make([]uint32, n)}
+	}
+	ii.sum, ii.sumSq, ii.stride = ii.sum[:n], ii.sumSq[:n], stride
+	sum, sumSq := ii.sum, ii.sumSq
+
+	for x := 0; x < stride; x++ {
+		sum[x], sumSq[x] = 0, 0
+	}
+	for y := 1; y < rows; y++ {
+		pix := rgba.Pix[rgba.PixOffset(bounds.Min.X, bounds.Min.Y+y-1):]
+		above, row := sum[(y-1)*stride:y*stride], sum[y*stride:(y+1)*stride]
+		aboveSq, rowSq := sumSq[(y-1)*stride:y*stride], sumSq[y*stride:(y+1)*stride]
+		row[0], rowSq[0] = 0, 0
+
+		var lineSum, lineSq uint32
+		for x := 1; x < stride; x++ {
+			p := pix[4*(x-1) : 4*(x-1)+3]
+			l := luma(p[0], p[1], p[2])
+			lineSum += l
+			lineSq += l * l
+			row[x] = above[x] + lineSum
+			rowSq[x] = aboveSq[x] + lineSq
+		}
+	}
+	return ii
+}
+
+func (ii *integralImages) release() {
+	integralPool.Put(ii)
+}
+
+// box returns the sums of luminance and of its square over the pixels
+// [x0, x1) x [y0, y1), relative to the frame's origin.
+func (ii *integralImages) box(x0, y0, x1, y1 int) (sum, sumSq uint32) {
+	a, b := y0*ii.stride, y1*ii.stride
+	sum = ii.sum[b+x1] - ii.sum[a+x1] - ii.sum[b+x0] + ii.sum[a+x0]
+	sumSq = ii.sumSq[b+x1] - ii.sumSq[a+x1] - ii.sumSq[b+x0] + ii.sumSq[a+x0]
+	return sum, sumSq
+}
+
+// neighborVariance is the sum of squared luminance differences between
+// the pixel at (x, y), relative to the frame's origin, of luminance c and
+// its eight neighbours. Over the 3x3 window around it, of sums s and ss,
+// that is (ss - c²) - 2c(s - c) + 8c², so it takes two box sums rather
+// than eight differences.
+func (ii *integralImages) neighborVariance(x, y int, c uint32) int64 {
+	s, ss := ii.box(x-1, y-1, x+2, y+2)
+	center := int64(c)
+	return int64(ss) - 2*center*int64(s) + 9*center*center
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"time"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+// ProcessingTimeout bounds a whole verification or enrollment request,
+// queueing included.
+func ProcessingTimeout(cfg *config.Config) time.Duration {
+	if cfg.ProcessingTimeout > 0 {
+		return time.Duration(cfg.ProcessingTimeout) * time.Second
+	}
+	return 30 * time.Second
+}
+
+func frameExtractionTimeout(cfg *config.Config) time.Duration {
+	if cfg.FrameExtractionTimeoutMs > 0 {
+		return time.Duration(cfg.FrameExtractionTimeoutMs) * time.Millisecond
+	}
+	return 2 * time.Second
+}
+
+// analysisTimeout bounds liveness detection and face vector generation,
+// which run in parallel once frames are extracted.
+func analysisTimeout(cfg *config.Config) time.Duration {
+	if cfg.AnalysisTimeoutMs > 0 {
+		return time.Duration(cfg.AnalysisTimeoutMs) * time.Millisecond
+	}
+	return time.Second
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+var ErrInvalidTuning = errors.New("configuration not reloaded")
+
+// tuning is the part of the configuration that can change without a
+// restart. A reload swaps it as a whole, so a verification never sees the
+// thresholds of one reload with the weights of another.
+type tuning struct {
+	livenessThreshold   float64
+	similarityThreshold float64
+	livenessWeights     []LivenessWeight
+	rateLimitPerMinute  int
+	rateLimitBurst      int
+	reloadedAt          time.Time
+}
+
+func newTuning(cfg *config.Config, weights []LivenessWeight) *tuning {
+	return &tuning{
+		livenessThreshold:   cfg.LivenessThreshold,
+		similarityThreshold: cfg.SimilarityThreshold,
+		livenessWeights:     weights,
+		rateLimitPerMinute:  cfg.RateLimitPerMinute,
+		rateLimitBurst:      cfg.RateLimitBurst,
+	}
+}
+
+func (s *FaceVerificationService) currentTuning() *tuning {
+	return s.tuning.Load()
+}
+
+// Tuning returns the tunable settings in effect.
+func (s *FaceVerificationService) Tuning() models.Tuning {
+	current := s.currentTuning()
+	tuned := models.Tuning{
+		LivenessThreshold:   current.livenessThreshold,
+		SimilarityThreshold: current.similarityThreshold,
+		LivenessWeights:     make(map[string]float64, len(current.livenessWeights)),
+		RateLimitPerMinute:  current.rateLimitPerMinute,
+		RateLimitBurst:      current.rateLimitBurst,
+	}
+	for _, w := range current.livenessWeights {
+		tuned.LivenessWeights[w.Name] = w.Weight
+	}
+	if !current.reloadedAt.IsZero() {
+		reloadedAt := current.reloadedAt
+		tuned.ReloadedAt = &reloadedAt
+	}
+	return tuned
+}
+
+// RateLimits returns the default per-client rate limit in effect, for the
+// rate limiting middleware to follow reloads.
+func (s *FaceVerificationService) RateLimits() (requestsPerMinute, burst int) {
+	current := s.currentTuning()
+	return current.rateLimitPerMinute, current.rateLimitBurst
+}
+
+// ReloadConfig reads the configuration again, as at startup, and applies
+// its tunable settings: LIVENESS_THRESHOLD, SIMILARITY_THRESHOLD, the
+// liveness detector weights and the default rate limit. Other settings
+// still need a restart. An invalid configuration changes nothing.
+func (s *FaceVerificationService) ReloadConfig() (models.Tuning, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return models.Tuning{}, fmt.Errorf("%w: %v", ErrInvalidTuning, err)
+	}
+	return s.ReloadTuning(cfg)
+}
+
+// ReloadTuning applies the tunable settings of cfg, which must have been
+// validated.
+func (s *FaceVerificationService) ReloadTuning(cfg *config.Config) (models.Tuning, error) {
+	weights, err := s.livenessWeightsFor(cfg)
+	if err != nil {
+		return models.Tuning{}, fmt.Errorf("%w: %v", ErrInvalidTuning, err)
+	}
+	next := newTuning(cfg, weights)
+	next.reloadedAt = time.Now().UTC()
+	previous := s.tuning.Swap(next)
+
+	s.logger.Info("Tunable settings reloaded",
+		zap.Float64("liveness_threshold", next.livenessThreshold),
+		zap.Float64("previous_liveness_threshold", previous.livenessThreshold),
+		zap.Float64("similarity_threshold", next.similarityThreshold),
+		zap.Float64("previous_similarity_threshold", previous.similarityThreshold),
+		zap.Int("rate_limit_per_minute", next.rateLimitPerMinute),
+		zap.Int("rate_limit_burst", next.rateLimitBurst))
+	return s.Tuning(), nil
+}
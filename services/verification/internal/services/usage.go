@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+var ErrQuotaExceeded = errors.New("monthly quota exceeded")
+
+// UsageMonthFormat is the layout of UsageRecord.Month.
+const UsageMonthFormat = "2006-01"
+
+// usageMeter meters verifications and registrations per API key and month
+// against the configured quotas.
+type usageMeter struct {
+	meter *storage.FileUsageMeter
+	// quotas maps the fingerprint of an API key to its monthly limit, so
+	// the keys themselves are not held
+	quotas       map[string]int64
+	defaultQuota int64
+}
+
+func newUsageMeter(cfg *config.Config) (*usageMeter, error) {
+	path := cfg.UsagePath
+	if path == "" {
+		path = filepath.Join(cfg.StoragePath, "usage.json")
+	}
+	meter, err := storage.NewFileUsageMeter(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &usageMeter{meter: meter, quotas: make(map[string]int64)}
+	for _, entry := range strings.Split(cfg.UsageQuotas, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// API keys may contain colons; the limit follows the last one
+		sep := strings.LastIndex(entry, ":")
+		if sep <= 0 {
+			return nil, fmt.Errorf("invalid usage quota %q, expected key:limit", entry)
+		}
+		key := strings.TrimSpace(entry[:sep])
+		limit, err := strconv.ParseInt(strings.TrimSpace(entry[sep+1:]), 10, 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid usage quota limit in %q", entry)
+		}
+		if key == "*" {
+			m.defaultQuota = limit
+			continue
+		}
+		m.quotas[ClientKeyFingerprint(key)] = limit
+	}
+	return m, nil
+}
+
+// quota is the monthly limit of the API key with fingerprint clientKey, 0
+// when unlimited.
+func (m *usageMeter) quota(clientKey string) int64 {
+	if limit, ok := m.quotas[clientKey]; ok && clientKey != "" {
+		return limit
+	}
+	return m.defaultQuota
+}
+
+// ReserveUsage counts operation against the monthly quota of apiKey,
+// failing with ErrQuotaExceeded once it is spent. Callers without a key
+// share one meter. The returned release takes the operation back when it
+// does not go through.
+func (s *FaceVerificationService) ReserveUsage(apiKey, tenantID string, operation models.UsageOperation) (release func(), err error) {
+	month := time.Now().UTC().Format(UsageMonthFormat)
+	clientKey := ClientKeyFingerprint(apiKey)
+
+	reserved, err := s.usage.meter.Reserve(month, clientKey, tenantID, operation, s.usage.quota(clientKey))
+	if err != nil {
+		s.logger.Warn("Failed to persist usage", zap.Error(err))
+	}
+	if !reserved {
+		metrics.QuotaRejections.Add(1)
+		return nil, ErrQuotaExceeded
+	}
+
+	return func() {
+		if err := s.usage.meter.Release(month, clientKey, operation); err != nil {
+			s.logger.Warn("Failed to persist usage", zap.Error(err))
+		}
+	}, nil
+}
+
+// Usage reports the metered operations of month, optionally only those of
+// the API key with the given fingerprint, with each key's quota.
+func (s *FaceVerificationService) Usage(month, clientKey string) []models.UsageRecord {
+	records := s.usage.meter.Query(month, clientKey)
+	for i := range records {
+		records[i].Quota = s.usage.quota(records[i].ClientKey)
+	}
+	return records
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+var ErrKeyRotationUnsupported = errors.New("vector store does not support key rotation")
+
+// NewVectorStore builds the enrollment backend selected by STORAGE_TYPE.
+// New backends are added here without touching the verification pipeline.
+// The admin commands use it to work on the store without loading models.
+func NewVectorStore(logger *zap.Logger, cfg *config.Config) (storage.VectorStore, error) {
+	keys, err := encryptionKeys(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newVectorStore(cfg, keys)
+}
+
+// newVectorStore builds the store selected by STORAGE_TYPE on keys, which
+// the service shares with the watchlist.
+func newVectorStore(cfg *config.Config, keys storage.KeyRing) (storage.VectorStore, error) {
+	switch cfg.StorageType {
+	case "", "encrypted_file":
+		store, err := storage.NewEncryptedFileVectorStoreWithKeys(cfg.StoragePath, keys, storageLockTimeout(cfg))
+		if err != nil {
+			return nil, err
+		}
+		store.SetPerUserKeys(cfg.PerUserKeys)
+		store.SetLogCompactionSize(int64(cfg.VectorLogCompactionSize))
+		return store, nil
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis vector store")
+		}
+		return storage.NewRedisVectorStore(cfg.RedisURL, keys)
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", cfg.StorageType)
+	}
+}
+
+// encryptionKeys is the key from STORAGE_KEY_PROVIDER under
+// ENCRYPTION_KEY_ID, plus the retired keys of ENCRYPTION_PREVIOUS_KEYS
+// still needed to read older data. It is built once per service, so a
+// key provider is asked for the key only once.
+func encryptionKeys(logger *zap.Logger, cfg *config.Config) (storage.KeyRing, error) {
+	keyID := cfg.EncryptionKeyID
+	if keyID == "" {
+		keyID = storage.DefaultKeyID
+	}
+	previous, err := storage.ParseEncryptionKeys(cfg.EncryptionPreviousKeys)
+	if err != nil {
+		return storage.KeyRing{}, fmt.Errorf("invalid ENCRYPTION_PREVIOUS_KEYS: %w", err)
+	}
+
+	current := storage.EncryptionKey{ID: keyID, Secret: cfg.EncryptionKey}
+	provider, err := newKeyProvider(cfg)
+	if err != nil {
+		return storage.KeyRing{}, err
+	}
+	if provider != nil {
+		cached := storage.NewCachingKeyProvider(provider, keyCacheTTL(cfg), func(err error) {
+			logger.Warn("Encryption key refresh failed, using cached key",
+				zap.String("provider", cfg.StorageKeyProvider),
+				zap.Error(err))
+		})
+
+		// Fail at startup rather than on the first enrollment
+		ctx, cancel := context.WithTimeout(context.Background(), keyProviderTimeout)
+		defer cancel()
+		if _, err := cached.Key(ctx); err != nil {
+			return storage.KeyRing{}, fmt.Errorf("failed to fetch encryption key from %s: %w", cfg.StorageKeyProvider, err)
+		}
+		current = storage.EncryptionKey{ID: keyID, Provider: cached}
+	}
+
+	return storage.KeyRing{Current: current, Previous: previous}, nil
+}
+
+const keyProviderTimeout = 10 * time.Second
+
+// newKeyProvider builds the KMS client selected by STORAGE_KEY_PROVIDER,
+// or nil when ENCRYPTION_KEY is used as is.
+func newKeyProvider(cfg *config.Config) (storage.KeyProvider, error) {
+	client := &http.Client{Timeout: keyProviderTimeout}
+	switch cfg.StorageKeyProvider {
+	case "", "env":
+		return nil, nil
+	case "aws-kms":
+		return storage.NewAWSKMSKeyProvider(cfg.KMSEndpoint, cfg.AWSRegion, storage.AWSCredentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}, cfg.EncryptionKeyCiphertext, client)
+	case "gcp-kms":
+		return storage.NewGCPKMSKeyProvider(cfg.KMSEndpoint, cfg.KMSKeyName, cfg.EncryptionKeyCiphertext, cfg.GCPAccessToken, client)
+	case "vault":
+		return storage.NewVaultKeyProvider(cfg.VaultAddr, cfg.VaultToken, cfg.VaultTransitMount, cfg.KMSKeyName, cfg.EncryptionKeyCiphertext, client)
+	default:
+		return nil, fmt.Errorf("unknown storage key provider %q", cfg.StorageKeyProvider)
+	}
+}
+
+func keyCacheTTL(cfg *config.Config) time.Duration {
+	if cfg.StorageKeyCacheTTL <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(cfg.StorageKeyCacheTTL) * time.Second
+}
+
+// RotateEncryptionKey re-encrypts stored enrollments under the current
+// ENCRYPTION_KEY, after which ENCRYPTION_PREVIOUS_KEYS can be retired.
+func (s *FaceVerificationService) RotateEncryptionKey() (*models.KeyRotation, error) {
+	rotator, ok := s.vectorStore.(storage.KeyRotator)
+	if !ok {
+		return nil, ErrKeyRotationUnsupported
+	}
+
+	rotation, err := rotator.RotateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.watchlist.RotateKey(); err != nil {
+		return nil, fmt.Errorf("re-encrypting watchlist: %w", err)
+	}
+	rotation.RotatedAt = time.Now().UTC()
+
+	s.logger.Info("Stored enrollments re-encrypted",
+		zap.String("key_id", rotation.KeyID),
+		zap.String("previous_key_id", rotation.PreviousKeyID),
+		zap.Int("enrollments", rotation.Enrollments))
+	return &rotation, nil
+}
+
+func storageLockTimeout(cfg *config.Config) time.Duration {
+	if cfg.StorageLockTimeout > 0 {
+		return time.Duration(cfg.StorageLockTimeout) * time.Second
+	}
+	return 10 * time.Second
+}
+
+// SetVectorStore replaces the enrollment backend and reloads the in-memory
+// gallery from it.
+func (s *FaceVerificationService) SetVectorStore(store storage.VectorStore) error {
+	s.vectorStore = store
+	return s.loadFaceVectors()
+}
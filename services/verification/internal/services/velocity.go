@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// VelocityLimit caps the distinct accounts one face, device or client IP
+// may verify as within Window.
+type VelocityLimit struct {
+	Window   time.Duration
+	Accounts int
+}
+
+// ParseVelocityLimits parses a limit list of the form "window:accounts,..."
+// such as "1h:3,24h:5", windows being Go durations. An empty spec returns
+// nil.
+func ParseVelocityLimits(spec string) ([]VelocityLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var limits []VelocityLimit
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid velocity limit %q", pair)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(parts[0]))
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid window in %q", pair)
+		}
+		accounts, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || accounts < 1 {
+			return nil, fmt.Errorf("invalid account count in %q", pair)
+		}
+		limits = append(limits, VelocityLimit{Window: window, Accounts: accounts})
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Window < limits[j].Window })
+	return limits, nil
+}
+
+func newVelocityStore(cfg *config.Config) (storage.VelocityStore, error) {
+	switch cfg.VelocityStore {
+	case "", "memory":
+		return storage.NewMemoryVelocityStore(), nil
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis velocity store")
+		}
+		return storage.NewRedisVelocityStore(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unknown velocity store %q", cfg.VelocityStore)
+	}
+}
+
+func velocityFaceThreshold(cfg *config.Config) float64 {
+	if cfg.VelocityFaceThreshold > 0 {
+		return cfg.VelocityFaceThreshold
+	}
+	return 0.9
+}
+
+// velocityChecks counts the accounts each face, device and client IP
+// verified as. Faces are told apart by this instance, so unlike devices and
+// IPs their counts are not shared with other replicas.
+type velocityChecks struct {
+	limits    []VelocityLimit
+	store     storage.VelocityStore
+	faces     *faceClusters
+	threshold float64
+}
+
+func newVelocityChecks(cfg *config.Config) (*velocityChecks, error) {
+	limits, err := ParseVelocityLimits(cfg.VelocityLimits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VELOCITY_LIMITS: %w", err)
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	store, err := newVelocityStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &velocityChecks{
+		limits:    limits,
+		store:     store,
+		faces:     newFaceClusters(),
+		threshold: velocityFaceThreshold(cfg),
+	}, nil
+}
+
+// retain is how long attempts are remembered: the longest window.
+func (v *velocityChecks) retain() time.Duration {
+	return v.limits[len(v.limits)-1].Window
+}
+
+// Faces remembered for velocity checks; the least recently seen go first
+const maxVelocityFaces = 2048
+
+// faceClusters gives captures of the same face the same ID, by matching
+// each against the faces seen recently.
+type faceClusters struct {
+	mu    sync.Mutex
+	faces []clusteredFace
+}
+
+type clusteredFace struct {
+	id       string
+	tenant   string
+	vector   []float32
+	lastSeen time.Time
+}
+
+func newFaceClusters() *faceClusters {
+	return &faceClusters{}
+}
+
+// assign returns the ID of the closest face of tenantID reaching threshold
+// under similarity, or of a new one for vector.
+func (f *faceClusters) assign(tenantID string, vector []float32, threshold float64, similarity func(a, b []float32) float64, now time.Time) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	best, closest := -1, threshold
+	for i, face := range f.faces {
+		if face.tenant != tenantID {
+			continue
+		}
+		if s := similarity(vector, face.vector); s >= closest {
+			best, closest = i, s
+		}
+	}
+	if best >= 0 {
+		f.faces[best].lastSeen = now
+		return f.faces[best].id
+	}
+
+	if len(f.faces) >= maxVelocityFaces {
+		oldest := 0
+		for i, face := range f.faces {
+			if face.lastSeen.Before(f.faces[oldest].lastSeen) {
+				oldest = i
+			}
+		}
+		f.faces = append(f.faces[:oldest], f.faces[oldest+1:]...)
+	}
+	id := uuid.New().String()
+	f.faces = append(f.faces, clusteredFace{
+		id:       id,
+		tenant:   tenantID,
+		vector:   append([]float32(nil), vector...),
+		lastSeen: now,
+	})
+	return id
+}
+
+// velocityKey hashes what is counted, so the store never holds user IDs,
+// devices or addresses.
+func velocityKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// checkVelocity counts the claimed account against the capture's face,
+// device and client IP and flags the result with a VELOCITY_EXCEEDED
+// warning, whether or not warnings are enabled, when any of them was used
+// with more accounts than a VELOCITY_LIMITS window allows. The decision is
+// left to the caller, or to the risk policy. The check fails open: a store
+// error is logged and the capture goes unflagged.
+func (s *FaceVerificationService) checkVelocity(ctx context.Context, result *models.VerificationResult, faceVector []float32, clientIP string) {
+	v := s.velocity
+	if v == nil || result.Synthetic || result.UserID == "" {
+		return
+	}
+
+	now := time.Now()
+	member := velocityKey(tenant.UserKey(result.Tenant, result.UserID))
+	subjects := []struct{ kind, label, value string }{
+		{"face", "face", v.faces.assign(result.Tenant, faceVector, v.threshold, s.cosineSimilarity, now)},
+		{"device", "device", result.Device},
+		{"ip", "IP address", clientIP},
+	}
+
+	flagged := false
+	for _, subject := range subjects {
+		if subject.value == "" {
+			continue
+		}
+		key := velocityKey(subject.kind, result.Tenant, subject.value)
+		if err := v.store.Observe(ctx, key, member, now, v.retain()); err != nil {
+			s.logger.Warn("Velocity check failed", zap.String("subject", subject.kind), zap.Error(err))
+			return
+		}
+		if flagged {
+			continue
+		}
+		for _, limit := range v.limits {
+			accounts, err := v.store.Distinct(ctx, key, now.Add(-limit.Window))
+			if err != nil {
+				s.logger.Warn("Velocity check failed", zap.String("subject", subject.kind), zap.Error(err))
+				return
+			}
+			if accounts <= limit.Accounts {
+				continue
+			}
+
+			flagged = true
+			metrics.VelocityExceeded.Add(1)
+			addWarning(result, WarningVelocityExceeded,
+				fmt.Sprintf("This %s was used with %d accounts within %s", subject.label, accounts, limit.Window))
+			s.logger.Warn("Velocity exceeded",
+				zap.String("verification_id", result.VerificationID),
+				zap.String("tenant", result.Tenant),
+				zap.String("subject", subject.kind),
+				zap.Int("accounts", accounts),
+				zap.Duration("window", limit.Window))
+			break
+		}
+	}
+}
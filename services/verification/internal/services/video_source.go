@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// BytesVideo is an in-memory capture, for callers that already hold one.
+type BytesVideo []byte
+
+func (v BytesVideo) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(v)), nil
+}
+
+func (v BytesVideo) Size() int64 {
+	return int64(len(v))
+}
+
+func (v BytesVideo) Digest() string {
+	sum := sha256.Sum256(v)
+	return hex.EncodeToString(sum[:])
+}
+
+// StreamedVideo is a capture read through open, typically a multipart upload
+// the HTTP server spilled to disk. Size and digest are measured by streaming
+// it once, so the capture is never held in memory whole.
+type StreamedVideo struct {
+	open   func() (io.ReadCloser, error)
+	size   int64
+	digest string
+}
+
+// NewStreamedVideo streams the capture once to measure it.
+func NewStreamedVideo(open func() (io.ReadCloser, error)) (*StreamedVideo, error) {
+	r, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video: %w", err)
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read video: %w", err)
+	}
+	return &StreamedVideo{
+		open:   open,
+		size:   size,
+		digest: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func (v *StreamedVideo) Open() (io.ReadCloser, error) { return v.open() }
+func (v *StreamedVideo) Size() int64                  { return v.size }
+func (v *StreamedVideo) Digest() string               { return v.digest }
+
+// SpooledVideo is a private temp-file copy of a capture, for work that
+// outlives the request the capture came with, such as async verification.
+type SpooledVideo struct {
+	*StreamedVideo
+	path       string
+	removeOnce sync.Once
+}
+
+// SpoolVideo copies video into a temp file in dir (the system default when
+// empty). Remove deletes the copy.
+func SpoolVideo(video models.VideoSource, dir string) (*SpooledVideo, error) {
+	src, err := video.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video: %w", err)
+	}
+	defer src.Close()
+
+	file, err := os.CreateTemp(dir, "capture-*.video")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	path := file.Name()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), src)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to spool video: %w", err)
+	}
+
+	return &SpooledVideo{
+		StreamedVideo: &StreamedVideo{
+			open:   func() (io.ReadCloser, error) { return os.Open(path) },
+			size:   size,
+			digest: hex.EncodeToString(hash.Sum(nil)),
+		},
+		path: path,
+	}, nil
+}
+
+func (v *SpooledVideo) Remove() {
+	v.removeOnce.Do(func() { os.Remove(v.path) })
+}
+
+// requestVideo is the capture of a request: the streamed source when set,
+// otherwise the in-memory bytes.
+func requestVideo(req *models.VerificationRequest) models.VideoSource {
+	if req.Video != nil {
+		return req.Video
+	}
+	return BytesVideo(req.VideoData)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+)
+
+// warmupTimeout bounds the startup warmup, WARMUP_TIMEOUT seconds.
+func warmupTimeout(cfg *config.Config) time.Duration {
+	if cfg.WarmupTimeout > 0 {
+		return time.Duration(cfg.WarmupTimeout) * time.Second
+	}
+	return time.Minute
+}
+
+// StartWarmup gets the service ready for its first request in the
+// background: it loads every recognizer of the pool, then runs
+// WARMUP_REQUESTS synthetic verifications end to end, one per recognizer
+// at a time, so first-use initialization in dlib and the pipeline's
+// buffers are paid for here rather than by a caller. Readiness fails from
+// now until the returned channel is closed. A warmup that fails or runs
+// out of time is logged and ends all the same: readiness checks the
+// recognizer by itself. With WARMUP_ENABLED off it does nothing.
+func (s *FaceVerificationService) StartWarmup(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if !s.config.WarmupEnabled {
+		close(done)
+		return done
+	}
+
+	s.warmingUp.Store(true)
+	go func() {
+		defer close(done)
+		defer s.warmingUp.Store(false)
+
+		ctx, cancel := context.WithTimeout(ctx, warmupTimeout(s.config))
+		defer cancel()
+		s.warmup(ctx)
+	}()
+	return done
+}
+
+// WarmingUp reports whether the startup warmup is still running.
+func (s *FaceVerificationService) WarmingUp() bool {
+	return s.warmingUp.Load()
+}
+
+func (s *FaceVerificationService) warmup(ctx context.Context) {
+	start := time.Now()
+	defer func() { metrics.WarmupSeconds.Set(time.Since(start).Seconds()) }()
+
+	if err := s.recognizers.preload(); err != nil {
+		s.logger.Warn("Failed to preload face recognizers", zap.Error(err))
+	}
+
+	capture, err := SyntheticCapture(640, 480)
+	if err != nil {
+		s.logger.Warn("Failed to render warmup capture", zap.Error(err))
+		return
+	}
+
+	requests := s.config.WarmupRequests
+	if requests <= 0 {
+		requests = s.recognizers.size
+	}
+	concurrency := s.recognizers.size
+	if concurrency > requests {
+		concurrency = requests
+	}
+
+	jobs := make(chan struct{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failures := 0
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				_, err := s.VerifyVideoContext(ctx, &models.VerificationRequest{
+					VideoData: capture,
+					SessionID: "warmup",
+					Synthetic: true,
+				})
+				if err != nil {
+					mu.Lock()
+					failures++
+					mu.Unlock()
+					s.logger.Warn("Warmup verification failed", zap.Error(err))
+				}
+			}
+		}()
+	}
+
+	submitted := 0
+submit:
+	for ; submitted < requests; submitted++ {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break submit
+		case <-s.stopCh:
+			break submit
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	stats := s.recognizers.stats()
+	s.logger.Info("Warmup completed",
+		zap.Int("verifications", submitted),
+		zap.Int("failures", failures),
+		zap.Int("recognizers_loaded", stats.Loaded),
+		zap.Duration("duration", time.Since(start)))
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"image"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Stable codes for non-fatal advisories attached to a verification result.
+const (
+	WarningLowLight             = "LOW_LIGHT"
+	WarningFewFrames            = "FEW_FRAMES"
+	WarningBorderlineLiveness   = "BORDERLINE_LIVENESS"
+	WarningBorderlineSimilarity = "BORDERLINE_SIMILARITY"
+	WarningWatchlistHit         = "WATCHLIST_HIT"
+	WarningVelocityExceeded     = "VELOCITY_EXCEEDED"
+)
+
+// minCleanFrames is the fewest frames that give liveness analysis enough
+// motion history to be trusted without a caveat.
+const minCleanFrames = 3
+
+func addWarning(result *models.VerificationResult, code, message string) {
+	result.Warnings = append(result.Warnings, models.VerificationWarning{Code: code, Message: message})
+}
+
+func hasWarning(result *models.VerificationResult, code string) bool {
+	for _, warning := range result.Warnings {
+		if warning.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func warningMargin(cfg *config.Config) float64 {
+	if cfg.WarningMargin > 0 {
+		return cfg.WarningMargin
+	}
+	return 0.05
+}
+
+// addCaptureWarnings flags captures that were usable but of marginal quality.
+func (s *FaceVerificationService) addCaptureWarnings(result *models.VerificationResult, frames []image.Image) {
+	if !s.config.WarningsEnabled || len(frames) == 0 {
+		return
+	}
+
+	// Single-image verifications never run liveness analysis
+	if len(frames) < minCleanFrames && result.LivenessMethod != models.LivenessMethodNone {
+		addWarning(result, WarningFewFrames, "Only a few frames were available for liveness analysis")
+	}
+
+	minBrightness := s.config.WarningMinBrightness
+	if minBrightness <= 0 {
+		minBrightness = 0.2
+	}
+	if brightness, _ := s.calculateLuminanceStats(frames[0]); brightness < minBrightness {
+		addWarning(result, WarningLowLight, "Capture is dim; better lighting improves accuracy")
+	}
+}
+
+// addDecisionWarnings flags scores that cleared their thresholds by less
+// than WARNING_MARGIN.
+func (s *FaceVerificationService) addDecisionWarnings(result *models.VerificationResult, userID string) {
+	if !s.config.WarningsEnabled || !result.Verified {
+		return
+	}
+
+	margin := warningMargin(s.config)
+	if result.LivenessScore-s.livenessThreshold(result.Tenant) < margin {
+		addWarning(result, WarningBorderlineLiveness, "Liveness score was close to the threshold")
+	}
+	if userID != "" && result.RawConfidence-s.similarityThreshold(result.Tenant) < margin {
+		addWarning(result, WarningBorderlineSimilarity, "Face match was close to the threshold")
+	}
+}
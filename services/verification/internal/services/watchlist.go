@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+var ErrWatchlistEntryNotFound = errors.New("watchlist entry not found")
+
+// WatchlistRequest describes a face to put on a tenant's watchlist, given
+// either as a descriptor or as the user whose latest enrollment it is.
+type WatchlistRequest struct {
+	Vector []float32
+	UserID string
+	Label  string
+	Reason string
+}
+
+func newWatchlist(cfg *config.Config, keys storage.KeyRing) (*storage.FileWatchlist, error) {
+	path := cfg.WatchlistPath
+	if path == "" {
+		path = filepath.Join(cfg.StoragePath, "watchlist.enc")
+	}
+	return storage.NewFileWatchlist(path, keys)
+}
+
+func watchlistThreshold(cfg *config.Config) float64 {
+	if cfg.WatchlistThreshold > 0 {
+		return cfg.WatchlistThreshold
+	}
+	return 0.9
+}
+
+// AddToWatchlist puts a face on the watchlist of tenantID. A user's face
+// is copied from their latest enrollment, failing with ErrNotEnrolled when
+// they have none.
+func (s *FaceVerificationService) AddToWatchlist(tenantID string, req WatchlistRequest) (*models.WatchlistEntry, error) {
+	vector := req.Vector
+	if req.UserID != "" {
+		s.storageMutex.RLock()
+		enrollments := s.faceVectors[tenant.UserKey(tenantID, req.UserID)]
+		if len(enrollments) > 0 {
+			vector = enrollments[len(enrollments)-1].Vector
+		}
+		s.storageMutex.RUnlock()
+		if len(enrollments) == 0 {
+			return nil, ErrNotEnrolled
+		}
+	}
+
+	face := storage.WatchlistFace{
+		WatchlistEntry: models.WatchlistEntry{
+			ID:        "wl_" + uuid.New().String(),
+			Tenant:    tenantID,
+			Label:     req.Label,
+			Reason:    req.Reason,
+			CreatedAt: time.Now().UTC(),
+		},
+		Vector: append([]float32(nil), vector...),
+	}
+	if err := s.watchlist.Add(face); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Face added to watchlist",
+		zap.String("entry_id", face.ID),
+		zap.String("tenant", tenantID),
+		zap.String("label", face.Label))
+	return &face.WatchlistEntry, nil
+}
+
+// RemoveFromWatchlist takes an entry off the watchlist of tenantID.
+func (s *FaceVerificationService) RemoveFromWatchlist(tenantID, id string) error {
+	removed, err := s.watchlist.Delete(tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrWatchlistEntryNotFound
+	}
+	s.logger.Info("Face removed from watchlist", zap.String("entry_id", id), zap.String("tenant", tenantID))
+	return nil
+}
+
+// Watchlist returns the entries on the watchlist of tenantID, oldest first.
+func (s *FaceVerificationService) Watchlist(tenantID string) []models.WatchlistEntry {
+	faces := s.watchlist.List(tenantID)
+	entries := make([]models.WatchlistEntry, len(faces))
+	for i, face := range faces {
+		entries[i] = face.WatchlistEntry
+	}
+	return entries
+}
+
+// screenWatchlist matches a capture's face against the watchlist of the
+// result's tenant. The closest entry reaching WATCHLIST_THRESHOLD is
+// flagged with a WATCHLIST_HIT warning, whether or not warnings are
+// enabled, and reported to the fraud team as an event; the decision itself
+// is left to the caller.
+func (s *FaceVerificationService) screenWatchlist(result *models.VerificationResult, faceVector []float32) {
+	threshold := watchlistThreshold(s.config)
+
+	var hit *storage.WatchlistFace
+	best := 0.0
+	faces := s.watchlist.List(result.Tenant)
+	for i := range faces {
+		if similarity := s.cosineSimilarity(faceVector, faces[i].Vector); similarity >= threshold && similarity > best {
+			hit, best = &faces[i], similarity
+		}
+	}
+	if hit == nil {
+		return
+	}
+
+	metrics.WatchlistHits.Add(1)
+	addWarning(result, WarningWatchlistHit, "Face matches an entry on the watchlist")
+	s.logger.Warn("Watchlist hit",
+		zap.String("verification_id", result.VerificationID),
+		zap.String("tenant", result.Tenant),
+		zap.String("entry_id", hit.ID),
+		zap.Float64("similarity", best))
+
+	s.publishEvent(models.EventWatchlistHit, tenant.UserKey(result.Tenant, result.UserID), result.VerificationID, models.WatchlistHitEvent{
+		EntryID:    hit.ID,
+		Label:      hit.Label,
+		Similarity: best,
+	})
+}
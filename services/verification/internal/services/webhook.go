@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+const maxWebhookDeliveries = 10000
+
+var ErrWebhooksDisabled = errors.New("webhooks are not configured")
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+var ErrDeliveryInProgress = errors.New("webhook delivery is still in progress")
+
+// WebhookSignatureHeader carries "sha256=<hex HMAC of the body>" so
+// receivers can authenticate callbacks with WEBHOOK_SECRET.
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+type webhookPayload struct {
+	Event      string                     `json:"event"`
+	DeliveryID string                     `json:"delivery_id"`
+	Data       *models.VerificationResult `json:"data"`
+}
+
+// webhookDispatcher posts verification results to the configured URL and
+// records the outcome of every delivery so operators can inspect and retry.
+type webhookDispatcher struct {
+	logger      *zap.Logger
+	url         string
+	secret      string
+	maxAttempts int
+	backoff     time.Duration
+	client      *http.Client
+	stopCh      <-chan struct{}
+	work        *backgroundWork
+
+	mu         sync.RWMutex
+	deliveries map[string]*models.WebhookDelivery
+	bodies     map[string][]byte
+	order      []string
+}
+
+func newWebhookDispatcher(logger *zap.Logger, cfg *config.Config, stopCh <-chan struct{}, work *backgroundWork) *webhookDispatcher {
+	maxAttempts := cfg.WebhookMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	backoff := time.Duration(cfg.WebhookRetryBackoffMs) * time.Millisecond
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	timeout := time.Duration(cfg.WebhookTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &webhookDispatcher{
+		logger:      logger,
+		url:         cfg.WebhookURL,
+		secret:      cfg.WebhookSecret,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		client:      &http.Client{Timeout: timeout},
+		stopCh:      stopCh,
+		work:        work,
+		deliveries:  make(map[string]*models.WebhookDelivery),
+		bodies:      make(map[string][]byte),
+	}
+}
+
+// enqueue records a delivery for result and starts sending it. The payload is
+// serialized up front so later changes to result are not sent.
+func (d *webhookDispatcher) enqueue(result *models.VerificationResult) {
+	deliveryID := fmt.Sprintf("whd_%d", time.Now().UnixNano())
+	body, err := json.Marshal(webhookPayload{
+		Event:      "verification.completed",
+		DeliveryID: deliveryID,
+		Data:       result,
+	})
+	if err != nil {
+		d.logger.Error("Failed to encode webhook payload", zap.Error(err))
+		return
+	}
+
+	d.mu.Lock()
+	d.deliveries[deliveryID] = &models.WebhookDelivery{
+		ID:             deliveryID,
+		VerificationID: result.VerificationID,
+		URL:            d.url,
+		Status:         models.WebhookPending,
+		CreatedAt:      time.Now(),
+	}
+	d.bodies[deliveryID] = body
+	d.order = append(d.order, deliveryID)
+	for len(d.order) > maxWebhookDeliveries {
+		delete(d.deliveries, d.order[0])
+		delete(d.bodies, d.order[0])
+		d.order = d.order[1:]
+	}
+	d.mu.Unlock()
+
+	d.work.add()
+	go d.deliver(deliveryID)
+}
+
+// deliver attempts a delivery up to maxAttempts times with exponential
+// backoff, updating its tracked status after every attempt.
+func (d *webhookDispatcher) deliver(deliveryID string) {
+	defer d.work.done()
+
+	d.mu.RLock()
+	body := d.bodies[deliveryID]
+	d.mu.RUnlock()
+
+	backoff := d.backoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		statusCode, err := d.post(body)
+		metrics.WebhookAttempts.Add(1)
+
+		if d.recordAttempt(deliveryID, statusCode, err) {
+			return
+		}
+
+		if attempt == d.maxAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-d.stopCh:
+			return
+		}
+	}
+
+	d.mu.Lock()
+	if delivery, ok := d.deliveries[deliveryID]; ok {
+		delivery.Status = models.WebhookFailed
+	}
+	d.mu.Unlock()
+
+	metrics.WebhookFailures.Add(1)
+	d.logger.Warn("Webhook delivery failed",
+		zap.String("delivery_id", deliveryID),
+		zap.Int("max_attempts", d.maxAttempts))
+}
+
+// recordAttempt stores the outcome of one attempt and reports success.
+func (d *webhookDispatcher) recordAttempt(deliveryID string, statusCode int, err error) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delivery, ok := d.deliveries[deliveryID]
+	if !ok {
+		return true // evicted; nothing left to track
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+
+	switch {
+	case err != nil:
+		delivery.LastError = err.Error()
+		return false
+	case statusCode < 200 || statusCode >= 300:
+		delivery.LastError = fmt.Sprintf("receiver responded with status %d", statusCode)
+		return false
+	}
+
+	now := time.Now()
+	delivery.Status = models.WebhookDelivered
+	delivery.DeliveredAt = &now
+	delivery.LastError = ""
+	return true
+}
+
+func (d *webhookDispatcher) post(body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.secret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+func (d *webhookDispatcher) list(status models.WebhookDeliveryStatus, verificationID string) []models.WebhookDelivery {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for i := len(d.order) - 1; i >= 0; i-- {
+		delivery := d.deliveries[d.order[i]]
+		if status != "" && delivery.Status != status {
+			continue
+		}
+		if verificationID != "" && delivery.VerificationID != verificationID {
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries
+}
+
+func (d *webhookDispatcher) redeliver(deliveryID string) (models.WebhookDelivery, error) {
+	d.mu.Lock()
+	delivery, ok := d.deliveries[deliveryID]
+	if !ok {
+		d.mu.Unlock()
+		return models.WebhookDelivery{}, ErrDeliveryNotFound
+	}
+	if delivery.Status == models.WebhookPending {
+		d.mu.Unlock()
+		return models.WebhookDelivery{}, ErrDeliveryInProgress
+	}
+	delivery.Status = models.WebhookPending
+	snapshot := *delivery
+	d.mu.Unlock()
+
+	d.work.add()
+	go d.deliver(deliveryID)
+	return snapshot, nil
+}
+
+// erase drops the deliveries, and so the payloads, of the given
+// verifications. Deliveries still retrying stop after the current attempt.
+func (d *webhookDispatcher) erase(verificationIDs map[string]bool) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	erased := 0
+	order := d.order[:0]
+	for _, id := range d.order {
+		if verificationIDs[d.deliveries[id].VerificationID] {
+			delete(d.deliveries, id)
+			delete(d.bodies, id)
+			erased++
+			continue
+		}
+		order = append(order, id)
+	}
+	d.order = order
+	return erased
+}
+
+// recordResult signs and stores a final verification result and notifies the
+// webhook and Kafka.
+func (s *FaceVerificationService) recordResult(result *models.VerificationResult) {
+	if result.Synthetic {
+		return
+	}
+	s.attest(result)
+	s.recentResults.put(result)
+	s.records.complete(result)
+	if s.webhooks != nil {
+		s.webhooks.enqueue(result)
+	}
+	s.publishEvent(models.EventVerificationCompleted, tenant.UserKey(result.Tenant, result.UserID), result.VerificationID, result)
+}
+
+// WebhookDeliveries lists tracked deliveries, newest first, optionally
+// filtered by status and verification ID.
+func (s *FaceVerificationService) WebhookDeliveries(status models.WebhookDeliveryStatus, verificationID string) ([]models.WebhookDelivery, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+	return s.webhooks.list(status, verificationID), nil
+}
+
+// RedeliverWebhook re-sends a delivery that is not currently in flight. The
+// attempt counter keeps accumulating across redeliveries.
+func (s *FaceVerificationService) RedeliverWebhook(deliveryID string) (models.WebhookDelivery, error) {
+	if s.webhooks == nil {
+		return models.WebhookDelivery{}, ErrWebhooksDisabled
+	}
+	return s.webhooks.redeliver(deliveryID)
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// AuditLog is an append-only record of biometric operations. Events are
+// never updated or removed through it.
+type AuditLog interface {
+	Append(event models.AuditEvent) error
+	// Query returns up to q.Limit matching events, newest first.
+	Query(q AuditQuery) ([]models.AuditEvent, error)
+}
+
+// AuditQuery selects audit events; zero fields match everything. From is
+// inclusive and To exclusive.
+type AuditQuery struct {
+	UserID    string
+	Operation models.AuditOperation
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+func (q AuditQuery) matches(event models.AuditEvent) bool {
+	if q.UserID != "" && event.UserID != q.UserID {
+		return false
+	}
+	if q.Operation != "" && event.Operation != q.Operation {
+		return false
+	}
+	if !q.From.IsZero() && event.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !event.Timestamp.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// FileAuditLog writes one JSON event per line to a file opened for append
+// only. Replicas sharing the file serialize appends with an advisory lock.
+type FileAuditLog struct {
+	path        string
+	lockTimeout time.Duration
+}
+
+func NewFileAuditLog(path string, lockTimeout time.Duration) (*FileAuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &FileAuditLog{path: path, lockTimeout: lockTimeout}, nil
+}
+
+func (f *FileAuditLog) Append(event models.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	unlock, err := lockFile(f.path, true, f.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *FileAuditLog) Query(q AuditQuery) ([]models.AuditEvent, error) {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return []models.AuditEvent{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Events are appended in time order, so the last matches are the newest
+	var matched []models.AuditEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event models.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A torn final line from a crash mid-append is skipped
+			continue
+		}
+		if !q.matches(event) {
+			continue
+		}
+		matched = append(matched, event)
+		if q.Limit > 0 && len(matched) > q.Limit {
+			matched = matched[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	events := make([]models.AuditEvent, 0, len(matched))
+	for i := len(matched) - 1; i >= 0; i-- {
+		events = append(events, matched[i])
+	}
+	return events, nil
+}
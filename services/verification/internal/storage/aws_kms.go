@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are static credentials, as in AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSKMSKeyProvider unwraps the data key with AWS KMS. Requests are signed
+// with Signature Version 4.
+type AWSKMSKeyProvider struct {
+	endpoint    string
+	region      string
+	credentials AWSCredentials
+	ciphertext  []byte
+	client      *http.Client
+}
+
+// NewAWSKMSKeyProvider unwraps the base64 ciphertext blob returned by
+// GenerateDataKey. endpoint defaults to the regional KMS endpoint.
+func NewAWSKMSKeyProvider(endpoint, region string, credentials AWSCredentials, ciphertext string, client *http.Client) (*AWSKMSKeyProvider, error) {
+	if region == "" || credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws kms key provider needs a region and credentials")
+	}
+	blob, err := decodeCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		endpoint = "https://kms." + region + ".amazonaws.com"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &AWSKMSKeyProvider{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		region:      region,
+		credentials: credentials,
+		ciphertext:  blob,
+		client:      client,
+	}, nil
+}
+
+func (a *AWSKMSKeyProvider) Key(ctx context.Context) (string, error) {
+	payload, err := json.Marshal(map[string][]byte{"CiphertextBlob": a.ciphertext})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	signAWSRequest(req, payload, a.credentials, a.region, "kms", time.Now().UTC())
+
+	var response struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	if err := doJSON(a.client, req, &response); err != nil {
+		return "", fmt.Errorf("aws kms: %w", err)
+	}
+	return plaintextKey(response.Plaintext)
+}
+
+// signAWSRequest adds a Signature Version 4 Authorization header covering
+// the host, the x-amz-* headers, the content type and the payload.
+func signAWSRequest(req *http.Request, payload []byte, credentials AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	names := []string{"host"}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := req.URL.Host
+		if name != "host" {
+			value = strings.TrimSpace(req.Header.Get(name))
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := awsSigningKey(credentials.SecretAccessKey, date, region, service)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(signingKey, stringToSign))))
+}
+
+func awsSigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
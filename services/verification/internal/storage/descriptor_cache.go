@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// CachedAnalysis is what the pipeline found in a capture before matching:
+// its liveness, its face descriptor and the capture details reported with
+// the result. Identical re-submissions reuse it instead of decoding and
+// scoring the capture again.
+type CachedAnalysis struct {
+	Liveness   *models.LivenessResult `json:"liveness"`
+	FaceVector []float32              `json:"face_vector"`
+	// Per-detector liveness scores, which LivenessResult does not serialize
+	LivenessFeatures map[string]float64 `json:"liveness_features,omitempty"`
+	// Face quality, for template refresh, when it was assessed
+	Quality         float64 `json:"quality,omitempty"`
+	QualityAssessed bool    `json:"quality_assessed,omitempty"`
+	// First frame as JPEG, kept when verifications may be held for manual
+	// review so a cached capture still has evidence to show
+	Frame []byte `json:"frame,omitempty"`
+
+	OriginalResolution  *models.Resolution           `json:"original_resolution,omitempty"`
+	ProcessedResolution *models.Resolution           `json:"processed_resolution,omitempty"`
+	InjectionRisk       *float64                     `json:"injection_risk,omitempty"`
+	InjectionSignals    []string                     `json:"injection_signals,omitempty"`
+	Warnings            []models.VerificationWarning `json:"warnings,omitempty"`
+}
+
+// DescriptorCache keeps capture analyses by payload hash for a short while.
+type DescriptorCache interface {
+	// Get returns the analysis stored for key, or nil when there is none.
+	Get(ctx context.Context, key string) (*CachedAnalysis, error)
+	// Put stores analysis for key for ttl.
+	Put(ctx context.Context, key string, analysis *CachedAnalysis, ttl time.Duration) error
+}
+
+// MemoryDescriptorCache keeps analyses in process, for a single instance,
+// dropping the least recently used once it holds its capacity.
+type MemoryDescriptorCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // of *descriptorEntry, most recently used first
+	entries  map[string]*list.Element
+}
+
+type descriptorEntry struct {
+	key       string
+	analysis  *CachedAnalysis
+	expiresAt time.Time
+}
+
+// NewMemoryDescriptorCache returns a cache of up to capacity analyses,
+// 1024 when capacity is not positive.
+func NewMemoryDescriptorCache(capacity int) *MemoryDescriptorCache {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &MemoryDescriptorCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryDescriptorCache) Get(ctx context.Context, key string) (*CachedAnalysis, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := element.Value.(*descriptorEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return nil, nil
+	}
+	m.order.MoveToFront(element)
+	return entry.analysis, nil
+}
+
+func (m *MemoryDescriptorCache) Put(ctx context.Context, key string, analysis *CachedAnalysis, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &descriptorEntry{key: key, analysis: analysis, expiresAt: time.Now().Add(ttl)}
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(entry)
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*descriptorEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of analyses held, expired ones included until
+// they are looked up or pushed out.
+func (m *MemoryDescriptorCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+const faceVectorsFile = "face_vectors.enc"
+
+// EncryptedFileVectorStore keeps every enrollment in one AES-GCM encrypted
+// JSON file, with new enrollments appended to a log beside it until the
+// next compaction (see vector_log.go). The file is checksummed and replaced
+// atomically, with a backup of its last version (see snapshot.go). Writers
+// on a shared volume are serialized with an advisory file lock and always
+// re-read the file first, so none of them drops enrollments persisted by
+// another. The file records the ID of the key it
+// is encrypted under, so it stays readable while keys are rotated. With
+// per-user keys each user's enrollments are additionally sealed under a key
+// of their own (see user_keys.go).
+type EncryptedFileVectorStore struct {
+	path              string
+	keys              *keyRing
+	lockTimeout       time.Duration
+	perUserKeys       bool
+	logCompactionSize int64
+}
+
+// NewEncryptedFileVectorStore encrypts under a single key with DefaultKeyID.
+func NewEncryptedFileVectorStore(dir, encryptionKey string, lockTimeout time.Duration) (*EncryptedFileVectorStore, error) {
+	return NewEncryptedFileVectorStoreWithKeys(dir, KeyRing{
+		Current: EncryptionKey{ID: DefaultKeyID, Secret: encryptionKey},
+	}, lockTimeout)
+}
+
+// NewEncryptedFileVectorStoreWithKeys encrypts under keys.Current and also
+// decrypts files still encrypted under one of keys.Previous.
+func NewEncryptedFileVectorStoreWithKeys(dir string, keys KeyRing, lockTimeout time.Duration) (*EncryptedFileVectorStore, error) {
+	ring, err := newKeyRing(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedFileVectorStore{
+		path:        filepath.Join(dir, faceVectorsFile),
+		keys:        ring,
+		lockTimeout: lockTimeout,
+	}, nil
+}
+
+// Save appends the enrollment to the log, compacting the log once it has
+// grown past its compaction size.
+func (f *EncryptedFileVectorStore) Save(vector models.FaceVector) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(f.path, true, f.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	size, err := f.appendRecord(vector)
+	if err != nil {
+		return err
+	}
+	limit := f.logCompactionSize
+	if limit <= 0 {
+		limit = DefaultLogCompactionSize
+	}
+	if size < limit {
+		return nil
+	}
+	return f.compact(func(*vectorFile) {})
+}
+
+func (f *EncryptedFileVectorStore) Load(userID string) ([]models.FaceVector, error) {
+	vectors, err := f.List()
+	if err != nil {
+		return nil, err
+	}
+	return vectors[userID], nil
+}
+
+func (f *EncryptedFileVectorStore) List() (map[string][]models.FaceVector, error) {
+	if _, err := os.Stat(f.path); os.IsNotExist(err) {
+		if _, err := os.Stat(f.logPath()); os.IsNotExist(err) {
+			return make(map[string][]models.FaceVector), nil
+		}
+	}
+
+	unlock, err := lockFile(f.path, false, f.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return f.read()
+}
+
+func (f *EncryptedFileVectorStore) Delete(userID string) error {
+	return f.update(func(vectors map[string][]models.FaceVector) {
+		delete(vectors, userID)
+	})
+}
+
+func (f *EncryptedFileVectorStore) Query(q VectorQuery) ([]models.FaceVector, error) {
+	vectors, err := f.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []models.FaceVector
+	for _, gallery := range vectors {
+		for _, vector := range gallery {
+			if q.matches(vector) {
+				matched = append(matched, vector)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// RotateKey re-encrypts the file under the current key. Once it has run on
+// every storage path, previous keys can be retired.
+func (f *EncryptedFileVectorStore) RotateKey() (models.KeyRotation, error) {
+	rotation := models.KeyRotation{KeyID: f.keys.current.ID}
+	err := f.rewrite(func(file *vectorFile) {
+		rotation.PreviousKeyID = file.keyID
+		for _, gallery := range file.vectors {
+			rotation.Enrollments += len(gallery)
+		}
+	})
+	return rotation, err
+}
+
+// Migrate rewrites the file in the current format: checksummed, under the
+// current key, sealed per user when per-user keys are enabled and with the
+// log folded in.
+func (f *EncryptedFileVectorStore) Migrate() (models.StorageMigration, error) {
+	var migration models.StorageMigration
+	err := f.rewrite(func(file *vectorFile) {
+		migration.Users = len(file.vectors)
+		for _, gallery := range file.vectors {
+			migration.Enrollments += len(gallery)
+		}
+	})
+	return migration, err
+}
+
+// CheckHealth checks that the storage directory is writable and the
+// current key is available.
+func (f *EncryptedFileVectorStore) CheckHealth(ctx context.Context) error {
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return fmt.Errorf("storage not writable: %w", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	if provider := f.keys.current.Provider; provider != nil {
+		if _, err := provider.Key(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShredUser deletes every enrollment of userID and reports whether they
+// were sealed under a per-user key, which is destroyed along with them.
+func (f *EncryptedFileVectorStore) ShredUser(userID string) (bool, error) {
+	shredded := false
+	err := f.rewrite(func(file *vectorFile) {
+		shredded = file.sealed[userID]
+		delete(file.vectors, userID)
+	})
+	return shredded, err
+}
+
+// update applies change to the current file contents under an exclusive lock.
+func (f *EncryptedFileVectorStore) update(change func(map[string][]models.FaceVector)) error {
+	return f.rewrite(func(file *vectorFile) {
+		change(file.vectors)
+	})
+}
+
+// vectorFile is the decoded content of the vector file.
+type vectorFile struct {
+	vectors map[string][]models.FaceVector
+	// ID of the key the file was read with or, before the log was first
+	// compacted, its first record; "" when nothing was saved yet
+	keyID string
+	// Users whose enrollments are sealed under a per-user key
+	sealed map[string]bool
+	// The vector file as read, nil when it was damaged and the backup was
+	// read instead
+	snapshot []byte
+}
+
+// rewrite reads the file, lets change modify it and writes it back under
+// the current key, all under an exclusive lock.
+func (f *EncryptedFileVectorStore) rewrite(change func(*vectorFile)) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+
+	// Serialize writers across processes sharing the same storage path
+	unlock, err := lockFile(f.path, true, f.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return f.compact(change)
+}
+
+// compact is rewrite for a caller already holding the exclusive lock. The
+// log is folded into the rewritten file.
+func (f *EncryptedFileVectorStore) compact(change func(*vectorFile)) error {
+	file, err := f.readFile()
+	if err != nil {
+		return err
+	}
+	before := enrollmentCounts(file.vectors)
+	change(file)
+
+	data, err := f.encodeGalleries(file.vectors)
+	if err != nil {
+		return err
+	}
+
+	encryptedData, err := f.keys.encrypt(data)
+	if err != nil {
+		return err
+	}
+
+	// The file being replaced becomes the backup unless it was damaged,
+	// holds enrollments that are being erased or is encrypted under a key
+	// that is being retired
+	backup := file.snapshot
+	if len(backup) == 0 || file.keyID != f.keys.current.ID || erases(before, enrollmentCounts(file.vectors)) {
+		backup = nil
+	}
+	return f.writeSnapshot(encryptedData, backup)
+}
+
+func enrollmentCounts(vectors map[string][]models.FaceVector) map[string]int {
+	counts := make(map[string]int, len(vectors))
+	for userID, gallery := range vectors {
+		counts[userID] = len(gallery)
+	}
+	return counts
+}
+
+// erases reports whether any user has fewer enrollments after than before.
+func erases(before, after map[string]int) bool {
+	for userID, count := range before {
+		if after[userID] < count {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *EncryptedFileVectorStore) read() (map[string][]models.FaceVector, error) {
+	file, err := f.readFile()
+	if err != nil {
+		return nil, err
+	}
+	return file.vectors, nil
+}
+
+func (f *EncryptedFileVectorStore) readFile() (*vectorFile, error) {
+	file := &vectorFile{
+		vectors: make(map[string][]models.FaceVector),
+		sealed:  make(map[string]bool),
+	}
+
+	snapshot, encryptedData, recovered, err := f.readSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	generations := [][]byte{generationOf(snapshot)}
+	if recovered {
+		// The log may extend the damaged file, whose checksum is intact;
+		// enrollments are only ever appended between two backups, so it
+		// applies to the backup as well
+		current, err := f.generation()
+		if err != nil {
+			return nil, err
+		}
+		generations = append(generations, current)
+	} else {
+		file.snapshot = snapshot
+	}
+
+	if len(encryptedData) > 0 {
+		decryptedData, keyID, err := f.keys.decrypt(encryptedData)
+		if err != nil {
+			return nil, err
+		}
+		file.keyID = keyID
+
+		if err := f.decodeGalleries(decryptedData, file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.replayLog(file, generations...); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrStorageLockTimeout = errors.New("timed out waiting for storage lock")
+
+// Without flock only writers inside this process can be serialized.
+var processFileLock sync.RWMutex
+
+func lockFile(path string, exclusive bool, timeout time.Duration) (func(), error) {
+	if exclusive {
+		processFileLock.Lock()
+		return processFileLock.Unlock, nil
+	}
+	processFileLock.RLock()
+	return processFileLock.RUnlock, nil
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+var ErrStorageLockTimeout = errors.New("timed out waiting for storage lock")
+
+// lockFile takes an advisory flock on path+".lock", retrying until timeout.
+// Shared locks are used for reads and exclusive locks for writes.
+func lockFile(path string, exclusive bool, timeout time.Duration) (func(), error) {
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			file.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, ErrStorageLockTimeout
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GalleryNotifier tells the replicas sharing a vector store whose
+// enrollments changed, so each can refresh its in-memory gallery.
+type GalleryNotifier interface {
+	// Publish announces that userID's enrollments changed.
+	Publish(ctx context.Context, userID string) error
+	// Subscribe calls changed with every user ID announced, by any replica,
+	// until the subscription fails or ctx is done. subscribed is called once
+	// it is established: announcements made before then are not received.
+	Subscribe(ctx context.Context, subscribed func(), changed func(userID string)) error
+}
+
+// RedisGalleryNotifier announces gallery changes over Redis pub/sub.
+type RedisGalleryNotifier struct {
+	client  *redisClient
+	channel string
+}
+
+func NewRedisGalleryNotifier(redisURL string) (*RedisGalleryNotifier, error) {
+	client, err := newRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisGalleryNotifier{client: client, channel: "verification:gallery:changed"}, nil
+}
+
+func (r *RedisGalleryNotifier) Publish(ctx context.Context, userID string) error {
+	_, err := r.client.do(ctx, "PUBLISH", r.channel, userID)
+	return err
+}
+
+// Subscribe holds a connection of its own in subscribed mode.
+func (r *RedisGalleryNotifier) Subscribe(ctx context.Context, subscribed func(), changed func(userID string)) error {
+	conn, err := r.client.conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.conn.Close()
+
+	// Unblock the read below once ctx is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.conn.Close()
+		case <-stop:
+		}
+	}()
+
+	conn.conn.SetDeadline(time.Now().Add(redisDialTimeout))
+	if _, err := conn.command("SUBSCRIBE", r.channel); err != nil {
+		return err
+	}
+	conn.conn.SetDeadline(time.Time{})
+	subscribed()
+
+	for {
+		reply, err := conn.reply()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		message, ok := reply.([]interface{})
+		if !ok || len(message) != 3 {
+			return fmt.Errorf("redis: unexpected pub/sub reply %v", reply)
+		}
+		if kind, _ := message[0].(string); kind == "message" {
+			userID, _ := message[2].(string)
+			changed(userID)
+		}
+	}
+}
+
+// CheckHealth pings the server.
+func (r *RedisGalleryNotifier) CheckHealth(ctx context.Context) error {
+	_, err := r.client.do(ctx, "PING")
+	return err
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrIdempotencyKeyInUse = errors.New("idempotency key is held by a request in progress")
+
+// IdempotentResponse is the first response given for an idempotency key,
+// replayed to retries of the request.
+type IdempotentResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"body"`
+}
+
+// IdempotencyStore keeps the responses to idempotent requests.
+type IdempotencyStore interface {
+	// Reserve claims key for a request about to run, for up to lockTTL. It
+	// returns the stored response instead when the key has one, and
+	// ErrIdempotencyKeyInUse while another request holds the key.
+	Reserve(ctx context.Context, key string, lockTTL time.Duration) (*IdempotentResponse, error)
+	// Complete stores the response for a reserved key for ttl.
+	Complete(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error
+	// Release gives up a reservation without storing a response, so the
+	// next retry runs the request again.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore keeps responses in process, for a single instance.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	response  *IdempotentResponse // nil while reserved
+	expiresAt time.Time
+}
+
+// idempotencySweepInterval is how often expired entries are dropped.
+const idempotencySweepInterval = time.Minute
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry)}
+}
+
+func (m *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, lockTTL time.Duration) (*IdempotentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastSweep) >= idempotencySweepInterval {
+		for k, entry := range m.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	if entry, ok := m.entries[key]; ok && now.Before(entry.expiresAt) {
+		if entry.response == nil {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return entry.response, nil
+	}
+	m.entries[key] = idempotencyEntry{expiresAt: now.Add(lockTTL)}
+	return nil, nil
+}
+
+func (m *MemoryIdempotencyStore) Complete(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	m.mu.Lock()
+	m.entries[key] = idempotencyEntry{response: response, expiresAt: time.Now().Add(ttl)}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+	return nil
+}
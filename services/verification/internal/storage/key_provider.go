@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrKeyUnavailable = errors.New("encryption key unavailable")
+
+// KeyProvider supplies the secret data keys are derived from. Providers
+// backed by a KMS unwrap a data-encryption key that is only configured in
+// encrypted form (envelope encryption), so the plaintext key is never part
+// of the environment.
+type KeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// StaticKeyProvider is a key taken directly from configuration.
+type StaticKeyProvider string
+
+func (s StaticKeyProvider) Key(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// CachingKeyProvider keeps an unwrapped key for ttl before asking the
+// underlying provider again. When a refresh fails the previous key is kept,
+// so a brief KMS outage does not stop storage; onRefreshError is told.
+type CachingKeyProvider struct {
+	provider       KeyProvider
+	ttl            time.Duration
+	onRefreshError func(error)
+
+	mu      sync.Mutex
+	key     string
+	fetched time.Time
+}
+
+func NewCachingKeyProvider(provider KeyProvider, ttl time.Duration, onRefreshError func(error)) *CachingKeyProvider {
+	return &CachingKeyProvider{provider: provider, ttl: ttl, onRefreshError: onRefreshError}
+}
+
+func (c *CachingKeyProvider) Key(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.key != "" && time.Since(c.fetched) < c.ttl {
+		return c.key, nil
+	}
+
+	key, err := c.provider.Key(ctx)
+	if err != nil {
+		if c.key == "" {
+			return "", err
+		}
+		if c.onRefreshError != nil {
+			c.onRefreshError(err)
+		}
+		return c.key, nil
+	}
+	c.key = key
+	c.fetched = time.Now()
+	return key, nil
+}
+
+// VaultKeyProvider unwraps the data key with a HashiCorp Vault transit key.
+type VaultKeyProvider struct {
+	address    string
+	token      string
+	mount      string
+	keyName    string
+	ciphertext string
+	client     *http.Client
+}
+
+// NewVaultKeyProvider unwraps ciphertext ("vault:v1:...") with the transit
+// key keyName mounted at mount.
+func NewVaultKeyProvider(address, token, mount, keyName, ciphertext string, client *http.Client) (*VaultKeyProvider, error) {
+	if address == "" || token == "" || keyName == "" || ciphertext == "" {
+		return nil, fmt.Errorf("vault key provider needs an address, token, transit key and key ciphertext")
+	}
+	if mount == "" {
+		mount = "transit"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &VaultKeyProvider{
+		address:    strings.TrimRight(address, "/"),
+		token:      token,
+		mount:      strings.Trim(mount, "/"),
+		keyName:    keyName,
+		ciphertext: ciphertext,
+		client:     client,
+	}, nil
+}
+
+func (v *VaultKeyProvider) Key(ctx context.Context) (string, error) {
+	var response struct {
+		Data struct {
+			Plaintext []byte `json:"plaintext"`
+		} `json:"data"`
+	}
+	err := postJSON(ctx, v.client, v.address+"/v1/"+v.mount+"/decrypt/"+v.keyName,
+		map[string]string{"X-Vault-Token": v.token},
+		map[string]string{"ciphertext": v.ciphertext}, &response)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	return plaintextKey(response.Data.Plaintext)
+}
+
+// gcpMetadataTokenURL serves access tokens for the instance service account.
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPKMSKeyProvider unwraps the data key with a Google Cloud KMS key.
+type GCPKMSKeyProvider struct {
+	endpoint    string
+	keyName     string
+	ciphertext  []byte
+	accessToken string
+	client      *http.Client
+}
+
+// NewGCPKMSKeyProvider unwraps the base64 ciphertext with keyName
+// ("projects/.../cryptoKeys/..."). Without an accessToken the instance
+// service account token is fetched from the metadata server.
+func NewGCPKMSKeyProvider(endpoint, keyName, ciphertext, accessToken string, client *http.Client) (*GCPKMSKeyProvider, error) {
+	if keyName == "" {
+		return nil, fmt.Errorf("gcp kms key provider needs a key name")
+	}
+	blob, err := decodeCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		endpoint = "https://cloudkms.googleapis.com"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &GCPKMSKeyProvider{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		keyName:     keyName,
+		ciphertext:  blob,
+		accessToken: accessToken,
+		client:      client,
+	}, nil
+}
+
+func (g *GCPKMSKeyProvider) Key(ctx context.Context) (string, error) {
+	token := g.accessToken
+	if token == "" {
+		var err error
+		if token, err = g.metadataToken(ctx); err != nil {
+			return "", fmt.Errorf("gcp kms: %w", err)
+		}
+	}
+
+	var response struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	err := postJSON(ctx, g.client, g.endpoint+"/v1/"+g.keyName+":decrypt",
+		map[string]string{"Authorization": "Bearer " + token},
+		map[string][]byte{"ciphertext": g.ciphertext}, &response)
+	if err != nil {
+		return "", fmt.Errorf("gcp kms: %w", err)
+	}
+	return plaintextKey(response.Plaintext)
+}
+
+func (g *GCPKMSKeyProvider) metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(g.client, req, &token); err != nil {
+		return "", fmt.Errorf("metadata token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+func decodeCiphertext(ciphertext string) ([]byte, error) {
+	if ciphertext == "" {
+		return nil, fmt.Errorf("key ciphertext is required")
+	}
+	blob, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("key ciphertext is not valid base64: %w", err)
+	}
+	return blob, nil
+}
+
+func plaintextKey(plaintext []byte) (string, error) {
+	if len(plaintext) == 0 {
+		return "", fmt.Errorf("%w: empty plaintext", ErrKeyUnavailable)
+	}
+	return string(plaintext), nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return doJSON(client, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrKeyUnavailable, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
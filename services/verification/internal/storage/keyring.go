@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// DefaultKeyID names ENCRYPTION_KEY when no ENCRYPTION_KEY_ID is set.
+const DefaultKeyID = "1"
+
+// LegacyKeyID reports data written before key IDs were recorded, which is
+// encrypted under a key derived with the old fixed salt.
+const LegacyKeyID = "legacy"
+
+var ErrUnknownKeyID = errors.New("data is encrypted under an unknown key")
+
+// Encrypted data starts with keyMagic, the key ID and the salt the data key
+// was derived with. The header is authenticated along with the ciphertext.
+var keyMagic = []byte("CHVK\x01")
+
+const keySaltSize = 16
+
+// legacySalt is the fixed salt data without a key header was encrypted with.
+var legacySalt = []byte("connect-hub-face-verification-salt")
+
+// EncryptionKey is a secret together with the ID recorded next to the data
+// it encrypts. With a Provider the secret is fetched from it on use instead.
+type EncryptionKey struct {
+	ID       string
+	Secret   string
+	Provider KeyProvider
+}
+
+func (k EncryptionKey) secret() (string, error) {
+	if k.Provider == nil {
+		return k.Secret, nil
+	}
+	return k.Provider.Key(context.Background())
+}
+
+// KeyRing is the key new data is encrypted under plus retired keys that are
+// still accepted for decryption while data is being rotated.
+type KeyRing struct {
+	Current  EncryptionKey
+	Previous []EncryptionKey
+}
+
+// ParseEncryptionKeys parses "id:secret,id:secret" as used by
+// ENCRYPTION_PREVIOUS_KEYS. Secrets may contain ':' but not ','.
+func ParseEncryptionKeys(spec string) ([]EncryptionKey, error) {
+	var keys []EncryptionKey
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(id) == "" || secret == "" {
+			return nil, fmt.Errorf("invalid key %q, expected id:secret", entry)
+		}
+		keys = append(keys, EncryptionKey{ID: strings.TrimSpace(id), Secret: secret})
+	}
+	return keys, nil
+}
+
+// keyRing derives and caches data keys. Derivation is deliberately slow
+// (scrypt), so the current key is derived when the ring is built, writes
+// reuse one salt per key and any other (key, salt) pair is derived once, on
+// first use.
+type keyRing struct {
+	current EncryptionKey
+	byID    map[string]EncryptionKey
+	ordered []EncryptionKey
+	salt    []byte
+	mu      sync.Mutex
+	derived map[string]derivedKey
+}
+
+// derivedKey is a data key with the secret it was derived from, so a key
+// whose provider hands out new material is derived again.
+type derivedKey struct {
+	secret string
+	key    []byte
+}
+
+// scrypt cost parameters of data key derivation
+const (
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+)
+
+func newKeyRing(keys KeyRing) (*keyRing, error) {
+	ring := &keyRing{
+		current: keys.Current,
+		byID:    make(map[string]EncryptionKey),
+		salt:    make([]byte, keySaltSize),
+		derived: make(map[string]derivedKey),
+	}
+	if _, err := io.ReadFull(rand.Reader, ring.salt); err != nil {
+		return nil, err
+	}
+
+	for i, key := range append([]EncryptionKey{keys.Current}, keys.Previous...) {
+		if key.ID == "" || len(key.ID) > 255 || key.ID == LegacyKeyID {
+			return nil, fmt.Errorf("invalid encryption key ID %q", key.ID)
+		}
+		// A retired key without its secret decrypts nothing; an unset
+		// ENCRYPTION_KEY keeps working as it did before keys had IDs
+		if key.Secret == "" && i > 0 {
+			return nil, fmt.Errorf("encryption key %q has no secret", key.ID)
+		}
+		if _, dup := ring.byID[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate encryption key ID %q", key.ID)
+		}
+		ring.byID[key.ID] = key
+		ring.ordered = append(ring.ordered, key)
+	}
+
+	// Saves then never pay for a derivation
+	if _, err := ring.dataKey(ring.current, ring.salt); err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key %q: %w", ring.current.ID, err)
+	}
+	return ring, nil
+}
+
+func (r *keyRing) dataKey(key EncryptionKey, salt []byte) ([]byte, error) {
+	secret, err := key.secret()
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := key.ID + "\x00" + string(salt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// A provider may hand out new key material under the same ID; the
+	// entry for the old material is replaced rather than kept around
+	if cached, ok := r.derived[cacheKey]; ok && cached.secret == secret {
+		return cached.key, nil
+	}
+	derived, err := scrypt.Key([]byte(secret), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	r.derived[cacheKey] = derivedKey{secret: secret, key: derived}
+	return derived, nil
+}
+
+// encrypt seals data under the current key behind a key header.
+func (r *keyRing) encrypt(data []byte) ([]byte, error) {
+	header := append([]byte{}, keyMagic...)
+	header = append(header, byte(len(r.current.ID)))
+	header = append(header, r.current.ID...)
+	header = append(header, r.salt...)
+
+	key, err := r.dataKey(r.current, r.salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, 0, len(header)+len(nonce)+len(data)+gcm.Overhead())
+	sealed = append(sealed, header...)
+	sealed = append(sealed, nonce...)
+	return gcm.Seal(sealed, nonce, data, header), nil
+}
+
+// decrypt opens data sealed by encrypt under any key in the ring, or legacy
+// data without a header, and reports the ID of the key that opened it.
+func (r *keyRing) decrypt(data []byte) ([]byte, string, error) {
+	if !bytes.HasPrefix(data, keyMagic) {
+		return r.decryptLegacy(data)
+	}
+
+	rest := data[len(keyMagic):]
+	if len(rest) == 0 || len(rest) < 1+int(rest[0])+keySaltSize {
+		return nil, "", fmt.Errorf("ciphertext too short")
+	}
+	idLen := int(rest[0])
+	keyID := string(rest[1 : 1+idLen])
+	salt := rest[1+idLen : 1+idLen+keySaltSize]
+	header := data[:len(keyMagic)+1+idLen+keySaltSize]
+
+	key, ok := r.byID[keyID]
+	if !ok {
+		return nil, "", fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+	plaintext, err := r.open(key, salt, data[len(header):], header)
+	if err != nil {
+		return nil, "", err
+	}
+	return plaintext, keyID, nil
+}
+
+// decryptLegacy tries every key with the old fixed salt, newest first.
+func (r *keyRing) decryptLegacy(data []byte) ([]byte, string, error) {
+	var lastErr error
+	for _, key := range r.ordered {
+		plaintext, err := r.open(key, legacySalt, data, nil)
+		if err == nil {
+			return plaintext, LegacyKeyID, nil
+		}
+		lastErr = err
+	}
+	return nil, "", lastErr
+}
+
+func (r *keyRing) open(key EncryptionKey, salt, sealed, additionalData []byte) ([]byte, error) {
+	dataKey, err := r.dataKey(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
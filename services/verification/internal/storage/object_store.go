@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+var ErrObjectTooLarge = errors.New("object exceeds size limit")
+
+// ObjectStore fetches client-uploaded captures referenced by key.
+type ObjectStore interface {
+	Get(ctx context.Context, key string, maxSize int64) ([]byte, error)
+}
+
+// ObjectDeleter is implemented by object stores that can remove captures
+// once they have been verified.
+type ObjectDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// UploadSigner is implemented by object stores that can issue pre-signed
+// URLs, so clients upload captures without going through the service.
+type UploadSigner interface {
+	// PresignPut returns a URL that accepts a PUT of key with the given
+	// Content-Type until expires has passed.
+	PresignPut(key, contentType string, expires time.Duration) (string, error)
+}
+
+// FileObjectStore serves objects from a local or mounted directory.
+type FileObjectStore struct {
+	root string
+}
+
+func NewFileObjectStore(root string) *FileObjectStore {
+	return &FileObjectStore{root: root}
+}
+
+func (f *FileObjectStore) Get(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	file, err := os.Open(filepath.Join(f.root, filepath.FromSlash(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	return readLimited(file, maxSize)
+}
+
+// Delete removes the object; a missing object is not an error.
+func (f *FileObjectStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(filepath.Join(f.root, filepath.FromSlash(key)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// CheckHealth checks that the root directory is there.
+func (f *FileObjectStore) CheckHealth(ctx context.Context) error {
+	info, err := os.Stat(f.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", f.root)
+	}
+	return nil
+}
+
+// HTTPObjectStore fetches objects from an HTTP(S) endpoint such as an S3 or
+// GCS bucket URL, appending the key to the base URL.
+type HTTPObjectStore struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPObjectStore(baseURL string, client *http.Client) *HTTPObjectStore {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPObjectStore{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+func (h *HTTPObjectStore) Get(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+"/"+escapeKey(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrObjectNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("object store returned status %d", resp.StatusCode)
+	}
+
+	return readLimited(resp.Body, maxSize)
+}
+
+// CheckHealth checks that the endpoint answers. Any response short of a
+// server error will do, since buckets commonly refuse to list their root.
+func (h *HTTPObjectStore) CheckHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, h.baseURL+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("object store returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// escapeKey escapes each segment of an object key for use in a URL path.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrObjectTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// EventOutbox holds events until they have been published, so a broker
+// outage delays events instead of losing them.
+type EventOutbox interface {
+	Add(event models.OutboxEvent) error
+	// Drain passes up to limit unpublished events, oldest first, to publish
+	// and drops them once it returns nil. Events are published at least
+	// once: a crash after publishing sends them again.
+	Drain(limit int, publish func([]models.OutboxEvent) error) (int, error)
+	// Erase drops unpublished events about the user with key userKey (see
+	// tenant.UserKey) or any of verificationIDs.
+	Erase(userKey string, verificationIDs map[string]bool) (int, error)
+}
+
+// FileEventOutbox appends one JSON event per line and keeps the offset of
+// the first unpublished event in a cursor file beside it. Replicas sharing
+// the file serialize appends with the storage lock, and only one of them
+// relays at a time. The file is truncated once everything is published.
+type FileEventOutbox struct {
+	path        string
+	lockTimeout time.Duration
+}
+
+func NewFileEventOutbox(path string, lockTimeout time.Duration) (*FileEventOutbox, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &FileEventOutbox{path: path, lockTimeout: lockTimeout}, nil
+}
+
+func (f *FileEventOutbox) Add(event models.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	unlock, err := lockFile(f.path, true, f.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *FileEventOutbox) Drain(limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	unlockRelay, err := lockFile(f.path+".relay", true, f.lockTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer unlockRelay()
+
+	cursor, err := f.readCursor()
+	if err != nil {
+		return 0, err
+	}
+	events, next, err := f.read(cursor, limit)
+	if err != nil || next == cursor {
+		return 0, err
+	}
+	if len(events) > 0 {
+		if err := publish(events); err != nil {
+			return 0, err
+		}
+	}
+	if err := f.writeCursor(next); err != nil {
+		return 0, err
+	}
+	return len(events), f.compact(next)
+}
+
+// read returns up to limit events from offset on and the offset after the
+// last line read. A final line without a newline is still being appended
+// and is left for the next read.
+func (f *FileEventOutbox) read(offset int64, limit int) ([]models.OutboxEvent, int64, error) {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, offset, err
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err != nil {
+		return nil, offset, err
+	} else if info.Size() < offset {
+		// The file was replaced under a stale cursor
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+
+	var events []models.OutboxEvent
+	reader := bufio.NewReader(file)
+	for limit <= 0 || len(events) < limit {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, offset, err
+		}
+		offset += int64(len(line))
+
+		var event models.OutboxEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			// A line torn by a crash mid-append is skipped
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, offset, nil
+}
+
+// compact truncates the outbox once the cursor has reached its end.
+func (f *FileEventOutbox) compact(cursor int64) error {
+	unlock, err := lockFile(f.path, true, f.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil || info.Size() != cursor {
+		return nil
+	}
+	if err := os.Truncate(f.path, 0); err != nil {
+		return err
+	}
+	return f.writeCursor(0)
+}
+
+func (f *FileEventOutbox) Erase(userKey string, verificationIDs map[string]bool) (int, error) {
+	unlockRelay, err := lockFile(f.path+".relay", true, f.lockTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer unlockRelay()
+	unlock, err := lockFile(f.path, true, f.lockTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	cursor, err := f.readCursor()
+	if err != nil {
+		return 0, err
+	}
+	events, _, err := f.read(cursor, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	// Rewrite only the events still to publish; published ones go as well
+	var kept bytes.Buffer
+	erased := 0
+	for _, event := range events {
+		if (event.UserID != "" && tenant.UserKey(event.Tenant, event.UserID) == userKey) || verificationIDs[event.VerificationID] {
+			erased++
+			continue
+		}
+		line, err := json.Marshal(event)
+		if err != nil {
+			return 0, err
+		}
+		kept.Write(line)
+		kept.WriteByte('\n')
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, kept.Bytes(), 0600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return erased, f.writeCursor(0)
+}
+
+func (f *FileEventOutbox) readCursor() (int64, error) {
+	data, err := os.ReadFile(f.path + ".cursor")
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return cursor, nil
+}
+
+func (f *FileEventOutbox) writeCursor(cursor int64) error {
+	tmp := f.path + ".cursor.tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(cursor, 10)), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path+".cursor")
+}
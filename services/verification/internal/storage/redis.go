@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisDialTimeout = 5 * time.Second
+	redisMaxIdle     = 8
+	// redisPending marks a reserved key; stored responses are JSON objects
+	redisPending = "pending"
+)
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisClient speaks just enough RESP for the commands the stores here
+// use, over a small pool of connections.
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newRedisClient parses a redis://[:password@]host:port[/db] URL.
+func newRedisClient(rawURL string) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q", rawURL)
+	}
+	client := &redisClient{addr: u.Host, idle: make(chan *redisConn, redisMaxIdle)}
+	if u.Port() == "" {
+		client.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		client.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if client.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+	return client, nil
+}
+
+// do runs one command and returns its reply: a string, an int64, a
+// []interface{} of replies, nil for a null reply, or a redisError.
+func (r *redisClient) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.conn.SetDeadline(deadline)
+	} else {
+		conn.conn.SetDeadline(time.Now().Add(redisDialTimeout))
+	}
+
+	reply, err := conn.command(args...)
+	if err != nil {
+		var replyErr redisError
+		if !errors.As(err, &replyErr) {
+			// The connection is in an unknown state
+			conn.conn.Close()
+			return nil, err
+		}
+	}
+	select {
+	case r.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+	return reply, err
+}
+
+func (r *redisClient) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+	netConn.SetDeadline(time.Now().Add(redisDialTimeout))
+	if r.password != "" {
+		if _, err := conn.command("AUTH", r.password); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.command("SELECT", strconv.Itoa(r.db)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *redisConn) command(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.reply()
+}
+
+func (c *redisConn) reply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		elements := make([]interface{}, count)
+		for i := range elements {
+			if elements[i], err = c.reply(); err != nil {
+				return nil, err
+			}
+		}
+		return elements, nil
+	default:
+		return nil, fmt.Errorf("redis: unsupported reply %q", line)
+	}
+}
+
+// RedisIdempotencyStore keeps responses in Redis, shared by every replica.
+// Keys expire in Redis, so nothing needs sweeping.
+type RedisIdempotencyStore struct {
+	client *redisClient
+	prefix string
+}
+
+func NewRedisIdempotencyStore(redisURL string) (*RedisIdempotencyStore, error) {
+	client, err := newRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisIdempotencyStore{client: client, prefix: "verification:idempotency:"}, nil
+}
+
+func (r *RedisIdempotencyStore) Reserve(ctx context.Context, key string, lockTTL time.Duration) (*IdempotentResponse, error) {
+	// A key that expires between SET and GET is reserved on the next try
+	for attempt := 0; attempt < 2; attempt++ {
+		reply, err := r.client.do(ctx, "SET", r.prefix+key, redisPending, "NX", "PX", strconv.FormatInt(lockTTL.Milliseconds(), 10))
+		if err != nil {
+			return nil, err
+		}
+		if reply != nil {
+			return nil, nil
+		}
+
+		reply, err = r.client.do(ctx, "GET", r.prefix+key)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := reply.(string)
+		if !ok {
+			continue
+		}
+		if value == redisPending {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		var response IdempotentResponse
+		if err := json.Unmarshal([]byte(value), &response); err != nil {
+			return nil, fmt.Errorf("stored idempotent response: %w", err)
+		}
+		return &response, nil
+	}
+	return nil, ErrIdempotencyKeyInUse
+}
+
+func (r *RedisIdempotencyStore) Complete(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.do(ctx, "SET", r.prefix+key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := r.client.do(ctx, "DEL", r.prefix+key)
+	return err
+}
+
+// CheckHealth pings the server.
+func (r *RedisIdempotencyStore) CheckHealth(ctx context.Context) error {
+	_, err := r.client.do(ctx, "PING")
+	return err
+}
+
+// RedisVelocityStore keeps velocity counters in Redis, shared by every
+// replica: one sorted set per key, scored by when each member was last
+// seen, that expires with its retention.
+type RedisVelocityStore struct {
+	client *redisClient
+	prefix string
+}
+
+func NewRedisVelocityStore(redisURL string) (*RedisVelocityStore, error) {
+	client, err := newRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisVelocityStore{client: client, prefix: "verification:velocity:"}, nil
+}
+
+func (r *RedisVelocityStore) Observe(ctx context.Context, key, member string, at time.Time, retain time.Duration) error {
+	if _, err := r.client.do(ctx, "ZADD", r.prefix+key, strconv.FormatInt(at.UnixMilli(), 10), member); err != nil {
+		return err
+	}
+	cutoff := "(" + strconv.FormatInt(at.Add(-retain).UnixMilli(), 10)
+	if _, err := r.client.do(ctx, "ZREMRANGEBYSCORE", r.prefix+key, "-inf", cutoff); err != nil {
+		return err
+	}
+	_, err := r.client.do(ctx, "PEXPIRE", r.prefix+key, strconv.FormatInt(retain.Milliseconds(), 10))
+	return err
+}
+
+func (r *RedisVelocityStore) Distinct(ctx context.Context, key string, since time.Time) (int, error) {
+	reply, err := r.client.do(ctx, "ZCOUNT", r.prefix+key, strconv.FormatInt(since.UnixMilli(), 10), "+inf")
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected ZCOUNT reply %v", reply)
+	}
+	return int(count), nil
+}
+
+// CheckHealth pings the server.
+func (r *RedisVelocityStore) CheckHealth(ctx context.Context) error {
+	_, err := r.client.do(ctx, "PING")
+	return err
+}
+
+// RedisDescriptorCache keeps capture analyses in Redis, shared by every
+// replica, so a retry landing on another instance is answered too. Keys
+// expire in Redis.
+type RedisDescriptorCache struct {
+	client *redisClient
+	prefix string
+}
+
+func NewRedisDescriptorCache(redisURL string) (*RedisDescriptorCache, error) {
+	client, err := newRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisDescriptorCache{client: client, prefix: "verification:descriptor:"}, nil
+}
+
+func (r *RedisDescriptorCache) Get(ctx context.Context, key string) (*CachedAnalysis, error) {
+	reply, err := r.client.do(ctx, "GET", r.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, nil
+	}
+	var analysis CachedAnalysis
+	if err := json.Unmarshal([]byte(value), &analysis); err != nil {
+		return nil, fmt.Errorf("cached capture analysis: %w", err)
+	}
+	return &analysis, nil
+}
+
+func (r *RedisDescriptorCache) Put(ctx context.Context, key string, analysis *CachedAnalysis, ttl time.Duration) error {
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.do(ctx, "SET", r.prefix+key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// CheckHealth pings the server.
+func (r *RedisDescriptorCache) CheckHealth(ctx context.Context) error {
+	_, err := r.client.do(ctx, "PING")
+	return err
+}
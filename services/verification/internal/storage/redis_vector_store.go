@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// redisStoreTimeout bounds each command of the Redis vector store, whose
+// interface carries no context.
+const redisStoreTimeout = 10 * time.Second
+
+// RedisVectorStore keeps enrollments in Redis, so every replica reads and
+// writes the same gallery. Each user's enrollments are a hash of one field
+// per enrollment, so an enrollment is added with a single HSET and
+// concurrent enrollments from different replicas never overwrite each
+// other; a set lists the users that have any. Every enrollment is
+// AES-GCM encrypted under the key ring, like the file store's data.
+type RedisVectorStore struct {
+	client *redisClient
+	keys   *keyRing
+	prefix string
+}
+
+func NewRedisVectorStore(redisURL string, keys KeyRing) (*RedisVectorStore, error) {
+	client, err := newRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := newKeyRing(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisVectorStore{client: client, keys: ring, prefix: "verification:gallery:"}, nil
+}
+
+func (r *RedisVectorStore) usersKey() string {
+	return r.prefix + "users"
+}
+
+func (r *RedisVectorStore) userKey(userID string) string {
+	return r.prefix + "user:" + userID
+}
+
+func (r *RedisVectorStore) Save(vector models.FaceVector) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return err
+	}
+	sealed, err := r.keys.encrypt(data)
+	if err != nil {
+		return err
+	}
+	// Fields sort by creation time, the random suffix keeps enrollments of
+	// the same instant apart
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	field := fmt.Sprintf("%020d-%s", vector.CreatedAt.UnixNano(), hex.EncodeToString(suffix))
+
+	if _, err := r.client.do(ctx, "HSET", r.userKey(vector.UserID), field, string(sealed)); err != nil {
+		return err
+	}
+	_, err = r.client.do(ctx, "SADD", r.usersKey(), vector.UserID)
+	return err
+}
+
+// Load returns userID's enrollments, oldest first.
+func (r *RedisVectorStore) Load(userID string) ([]models.FaceVector, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	reply, err := r.client.do(ctx, "HGETALL", r.userKey(userID))
+	if err != nil {
+		return nil, err
+	}
+	pairs, _ := reply.([]interface{})
+
+	type field struct {
+		name   string
+		vector models.FaceVector
+	}
+	fields := make([]field, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		name, _ := pairs[i].(string)
+		sealed, _ := pairs[i+1].(string)
+		data, _, err := r.keys.decrypt([]byte(sealed))
+		if err != nil {
+			return nil, fmt.Errorf("enrollment of %s: %w", userID, err)
+		}
+		var vector models.FaceVector
+		if err := json.Unmarshal(data, &vector); err != nil {
+			return nil, fmt.Errorf("enrollment of %s: %w", userID, err)
+		}
+		fields = append(fields, field{name: name, vector: vector})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+
+	vectors := make([]models.FaceVector, len(fields))
+	for i, f := range fields {
+		vectors[i] = f.vector
+	}
+	return vectors, nil
+}
+
+// List loads every user's enrollments, one round trip per user.
+func (r *RedisVectorStore) List() (map[string][]models.FaceVector, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	reply, err := r.client.do(ctx, "SMEMBERS", r.usersKey())
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	members, _ := reply.([]interface{})
+
+	gallery := make(map[string][]models.FaceVector, len(members))
+	for _, member := range members {
+		userID, _ := member.(string)
+		vectors, err := r.Load(userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) > 0 {
+			gallery[userID] = vectors
+		}
+	}
+	return gallery, nil
+}
+
+func (r *RedisVectorStore) Delete(userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisStoreTimeout)
+	defer cancel()
+
+	if _, err := r.client.do(ctx, "DEL", r.userKey(userID)); err != nil {
+		return err
+	}
+	_, err := r.client.do(ctx, "SREM", r.usersKey(), userID)
+	return err
+}
+
+func (r *RedisVectorStore) Query(q VectorQuery) ([]models.FaceVector, error) {
+	var gallery map[string][]models.FaceVector
+	if q.UserID != "" {
+		vectors, err := r.Load(q.UserID)
+		if err != nil {
+			return nil, err
+		}
+		gallery = map[string][]models.FaceVector{q.UserID: vectors}
+	} else {
+		var err error
+		if gallery, err = r.List(); err != nil {
+			return nil, err
+		}
+	}
+
+	var matched []models.FaceVector
+	for _, vectors := range gallery {
+		for _, vector := range vectors {
+			if q.matches(vector) {
+				matched = append(matched, vector)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// CheckHealth pings the server and checks the current key is available.
+func (r *RedisVectorStore) CheckHealth(ctx context.Context) error {
+	if _, err := r.client.do(ctx, "PING"); err != nil {
+		return err
+	}
+	if provider := r.keys.current.Provider; provider != nil {
+		if _, err := provider.Key(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body, sent as
+// X-Amz-Content-Sha256 on GET, HEAD and DELETE requests.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3ObjectStore reads, deletes and issues pre-signed uploads for objects in
+// one bucket of a store speaking the S3 API, signing requests with
+// Signature Version 4. GCS buckets are served through their XML API with
+// HMAC keys, which accepts the same signatures; see NewGCSObjectStore.
+type S3ObjectStore struct {
+	// bucketURL is the URL objects are addressed under: virtual-hosted for
+	// AWS, path-style for a custom endpoint
+	bucketURL   string
+	bucket      string
+	region      string
+	credentials AWSCredentials
+	client      *http.Client
+}
+
+// NewS3ObjectStore returns a store for bucket. endpoint defaults to the
+// regional AWS endpoint; set it for S3-compatible stores such as MinIO.
+func NewS3ObjectStore(endpoint, bucket, region string, credentials AWSCredentials, client *http.Client) (*S3ObjectStore, error) {
+	if !validBucketName(bucket) {
+		return nil, fmt.Errorf("invalid bucket name %q", bucket)
+	}
+	if region == "" || credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 object store needs a region and credentials")
+	}
+	bucketURL := "https://" + bucket + ".s3." + region + ".amazonaws.com"
+	if endpoint != "" {
+		bucketURL = strings.TrimRight(endpoint, "/") + "/" + bucket
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3ObjectStore{
+		bucketURL:   bucketURL,
+		bucket:      bucket,
+		region:      region,
+		credentials: credentials,
+		client:      client,
+	}, nil
+}
+
+// NewGCSObjectStore returns a store for a GCS bucket, authenticated with an
+// HMAC key of a service account. endpoint defaults to
+// https://storage.googleapis.com.
+func NewGCSObjectStore(endpoint, bucket, accessID, secret string, client *http.Client) (*S3ObjectStore, error) {
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	if accessID == "" || secret == "" {
+		return nil, fmt.Errorf("gcs object store needs an HMAC key")
+	}
+	return NewS3ObjectStore(endpoint, bucket, "auto", AWSCredentials{AccessKeyID: accessID, SecretAccessKey: secret}, client)
+}
+
+// Bucket returns the name of the store's bucket.
+func (s *S3ObjectStore) Bucket() string {
+	return s.bucket
+}
+
+func (s *S3ObjectStore) Get(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.bucketURL+"/"+awsEscapePath(key))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrObjectNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("object store returned status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, ErrObjectTooLarge
+	}
+
+	return readLimited(resp.Body, maxSize)
+}
+
+// Delete removes the object; a missing object is not an error.
+func (s *S3ObjectStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.bucketURL+"/"+awsEscapePath(key))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("object store returned status %d", resp.StatusCode)
+}
+
+// CheckHealth checks that the bucket exists. Credentials allowed only to
+// read and write objects are refused a HEAD of the bucket, so a 403 passes.
+func (s *S3ObjectStore) CheckHealth(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, s.bucketURL+"/")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("bucket %s does not exist", s.bucket)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("object store returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *S3ObjectStore) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	signAWSRequest(req, nil, s.credentials, s.region, "s3", time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// PresignPut signs a PUT of key with query parameters. The client must send
+// the same Content-Type; the body itself is not signed.
+func (s *S3ObjectStore) PresignPut(key, contentType string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodPut, key, contentType, expires, time.Now().UTC())
+}
+
+func (s *S3ObjectStore) presign(method, key, contentType string, expires time.Duration, now time.Time) (string, error) {
+	seconds := int64(expires / time.Second)
+	if seconds < 1 || seconds > 7*24*3600 {
+		return "", fmt.Errorf("pre-signed URLs must expire within 1 second to 7 days, not %s", expires)
+	}
+
+	objectURL := s.bucketURL + "/" + awsEscapePath(key)
+	req, err := http.NewRequest(method, objectURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+
+	signedHeaders := "host"
+	canonicalHeaders := "host:" + req.URL.Host + "\n"
+	if contentType != "" {
+		signedHeaders = "content-type;host"
+		canonicalHeaders = "content-type:" + contentType + "\n" + canonicalHeaders
+	}
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.credentials.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.FormatInt(seconds, 10),
+		"X-Amz-SignedHeaders": signedHeaders,
+	}
+	if s.credentials.SessionToken != "" {
+		query["X-Amz-Security-Token"] = s.credentials.SessionToken
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = awsEscape(name) + "=" + awsEscape(query[name])
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		req.URL.EscapedPath(),
+		canonicalQuery,
+		canonicalHeaders,
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+	signature := hmacSHA256(awsSigningKey(s.credentials.SecretAccessKey, date, s.region, "s3"), stringToSign)
+
+	return objectURL + "?" + canonicalQuery + "&X-Amz-Signature=" + hex.EncodeToString(signature), nil
+}
+
+// awsEscape percent-encodes every byte except the unreserved characters, as
+// Signature Version 4 requires.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// awsEscapePath escapes each segment of an object key with awsEscape.
+func awsEscapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// validBucketName accepts the names both S3 and GCS allow: 3-63 lowercase
+// letters, digits, dots and hyphens.
+func validBucketName(name string) bool {
+	if len(name) < 3 || len(name) > 63 {
+		return false
+	}
+	for _, char := range name {
+		if !((char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '.' || char == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+
+	"connect-hub/verification-service/internal/atomicfile"
+	"connect-hub/verification-service/internal/metrics"
+)
+
+var ErrVectorFileCorrupted = errors.New("vector file failed its integrity check")
+
+// The vector file starts with snapshotMagic and a SHA-256 checksum of the
+// encrypted data that follows, so a damaged file is told apart from one
+// encrypted under an unknown key. Files written before the checksum was
+// added start straight with the encrypted data and are read as they are.
+//
+// Each rewrite also keeps a backup of the file it replaces next to it, which
+// is read instead when the file fails its checksum. A rewrite that erases
+// enrollments or moves to a new key backs up the new file instead, so
+// erased data does not outlive the erasure there and the backup stays
+// readable once previous keys are retired.
+var snapshotMagic = []byte("CHVS\x01")
+
+const (
+	snapshotHeaderSize = 5 + sha256.Size
+	vectorBackupSuffix = ".bak"
+)
+
+func (f *EncryptedFileVectorStore) backupPath() string {
+	return f.path + vectorBackupSuffix
+}
+
+// sealSnapshot prepends the header to encrypted vector data.
+func sealSnapshot(encryptedData []byte) []byte {
+	sum := sha256.Sum256(encryptedData)
+	snapshot := make([]byte, 0, snapshotHeaderSize+len(encryptedData))
+	snapshot = append(snapshot, snapshotMagic...)
+	snapshot = append(snapshot, sum[:]...)
+	return append(snapshot, encryptedData...)
+}
+
+// openSnapshot verifies a vector file and returns its encrypted data.
+func openSnapshot(snapshot []byte) ([]byte, error) {
+	if !bytes.HasPrefix(snapshot, snapshotMagic) {
+		return snapshot, nil
+	}
+	if len(snapshot) < snapshotHeaderSize {
+		return nil, ErrVectorFileCorrupted
+	}
+	encryptedData := snapshot[snapshotHeaderSize:]
+	sum := sha256.Sum256(encryptedData)
+	if !bytes.Equal(sum[:], snapshot[len(snapshotMagic):snapshotHeaderSize]) {
+		return nil, ErrVectorFileCorrupted
+	}
+	return encryptedData, nil
+}
+
+// readSnapshot reads and verifies the vector file, falling back to the
+// backup when it is damaged. It returns the file's content, the encrypted
+// data in it and whether that came from the backup.
+func (f *EncryptedFileVectorStore) readSnapshot() (snapshot, encryptedData []byte, recovered bool, err error) {
+	snapshot, err = os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, false, err
+	}
+	encryptedData, err = openSnapshot(snapshot)
+	if err == nil {
+		return snapshot, encryptedData, false, nil
+	}
+
+	backup, backupErr := os.ReadFile(f.backupPath())
+	if backupErr != nil {
+		return nil, nil, false, err
+	}
+	backupData, backupErr := openSnapshot(backup)
+	if backupErr != nil || len(backupData) == 0 {
+		return nil, nil, false, err
+	}
+	metrics.VectorFileRecoveries.Add(1)
+	return backup, backupData, true, nil
+}
+
+// writeSnapshot replaces the vector file with encrypted data and drops the
+// log, whose records the new file now holds. backup is written first as the
+// new backup, or the new file itself when nil.
+func (f *EncryptedFileVectorStore) writeSnapshot(encryptedData, backup []byte) error {
+	snapshot := sealSnapshot(encryptedData)
+	if backup == nil {
+		backup = snapshot
+	}
+	if err := atomicfile.Write(f.backupPath(), backup); err != nil {
+		return fmt.Errorf("vector file backup: %w", err)
+	}
+	if err := atomicfile.Write(f.path, snapshot); err != nil {
+		return err
+	}
+	if err := os.Remove(f.logPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"connect-hub/verification-service/internal/atomicfile"
+	"connect-hub/verification-service/internal/models"
+)
+
+// FileUsageMeter counts metered operations per API key and month. Counts
+// are held in memory and the whole set is rewritten to a JSON file on every
+// change, so each instance keeps its own meter.
+type FileUsageMeter struct {
+	mu      sync.Mutex
+	path    string
+	records map[usageKey]*models.UsageRecord
+	// loadErr is why the persisted counts could not be read; the meter
+	// then counts in memory and leaves the file alone
+	loadErr error
+}
+
+type usageKey struct {
+	month     string
+	clientKey string
+}
+
+// NewFileUsageMeter loads the counts persisted at path, if any. A file that
+// cannot be read does not fail the service: the meter starts from zero and
+// reports the error from CheckHealth, and from every write.
+func NewFileUsageMeter(path string) (*FileUsageMeter, error) {
+	m := &FileUsageMeter{path: path, records: make(map[usageKey]*models.UsageRecord)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		m.loadErr = fmt.Errorf("reading usage: %w", err)
+		return m, nil
+	}
+	var records []models.UsageRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parsing usage %s: %w", path, err)
+	}
+	for i := range records {
+		record := records[i]
+		m.records[usageKey{record.Month, record.ClientKey}] = &record
+	}
+	return m, nil
+}
+
+// Reserve counts one operation of clientKey in month unless the key has
+// already used quota operations that month; a quota of 0 is unlimited. It
+// reports whether the operation was counted. The count is kept even when
+// it cannot be persisted.
+func (m *FileUsageMeter) Reserve(month, clientKey, tenantID string, operation models.UsageOperation, quota int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := usageKey{month, clientKey}
+	record, ok := m.records[key]
+	if !ok {
+		record = &models.UsageRecord{Month: month, ClientKey: clientKey}
+		m.records[key] = record
+	}
+	if quota > 0 && record.Verifications+record.Registrations >= quota {
+		return false, nil
+	}
+
+	record.Tenant = tenantID
+	switch operation {
+	case models.UsageVerification:
+		record.Verifications++
+	case models.UsageRegistration:
+		record.Registrations++
+	}
+	return true, m.writeLocked()
+}
+
+// Release takes back a reserved operation that did not go through.
+func (m *FileUsageMeter) Release(month, clientKey string, operation models.UsageOperation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[usageKey{month, clientKey}]
+	if !ok {
+		return nil
+	}
+	switch {
+	case operation == models.UsageVerification && record.Verifications > 0:
+		record.Verifications--
+	case operation == models.UsageRegistration && record.Registrations > 0:
+		record.Registrations--
+	}
+	return m.writeLocked()
+}
+
+// Query returns the records of month, all of them or only clientKey's,
+// ordered by client key.
+func (m *FileUsageMeter) Query(month, clientKey string) []models.UsageRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := []models.UsageRecord{}
+	for key, record := range m.records {
+		if key.month == month && (clientKey == "" || key.clientKey == clientKey) {
+			records = append(records, *record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ClientKey < records[j].ClientKey
+	})
+	return records
+}
+
+// CheckHealth reports whether the persisted counts were loaded.
+func (m *FileUsageMeter) CheckHealth(ctx context.Context) error {
+	return m.loadErr
+}
+
+func (m *FileUsageMeter) writeLocked() error {
+	if m.loadErr != nil {
+		// Writing would replace counts that were never read
+		return m.loadErr
+	}
+	records := make([]models.UsageRecord, 0, len(m.records))
+	for _, record := range m.records {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Month != records[j].Month {
+			return records[i].Month < records[j].Month
+		}
+		return records[i].ClientKey < records[j].ClientKey
+	})
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	return atomicfile.Write(m.path, data)
+}
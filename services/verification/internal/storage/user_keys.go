@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"connect-hub/verification-service/internal/atomicfile"
+	"connect-hub/verification-service/internal/models"
+)
+
+const userKeysFile = "user_keys.enc"
+
+const userKeySize = 32
+
+// SetPerUserKeys seals each user's enrollments under a random key of their
+// own. The keys are kept in a separate file, so deleting a user's key
+// crypto-shreds their enrollments everywhere the vector file was copied,
+// backups included. Existing enrollments are sealed on the next write.
+func (f *EncryptedFileVectorStore) SetPerUserKeys(enabled bool) {
+	f.perUserKeys = enabled
+}
+
+func (f *EncryptedFileVectorStore) userKeysPath() string {
+	return filepath.Join(filepath.Dir(f.path), userKeysFile)
+}
+
+// decodeGalleries fills file from the decrypted vector file. A user's entry
+// is either their enrollments or, with per-user keys, a sealed blob of
+// them. Blobs whose key has been shredded are dropped.
+func (f *EncryptedFileVectorStore) decodeGalleries(data []byte, file *vectorFile) error {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	var userKeys map[string][]byte
+	for userID, entry := range entries {
+		if len(entry) == 0 || entry[0] != '"' {
+			var gallery []models.FaceVector
+			if err := json.Unmarshal(entry, &gallery); err != nil {
+				return err
+			}
+			file.vectors[userID] = gallery
+			continue
+		}
+
+		var sealed []byte
+		if err := json.Unmarshal(entry, &sealed); err != nil {
+			return err
+		}
+		if userKeys == nil {
+			var err error
+			if userKeys, err = f.readUserKeys(); err != nil {
+				return err
+			}
+		}
+		key, ok := userKeys[userID]
+		if !ok {
+			continue
+		}
+		gallery, err := openGallery(key, userID, sealed)
+		if err != nil {
+			return fmt.Errorf("enrollments of %s: %w", userID, err)
+		}
+		file.vectors[userID] = gallery
+		file.sealed[userID] = true
+	}
+	return nil
+}
+
+// encodeGalleries serializes vectors for the vector file. With per-user
+// keys it first persists a key for every user and drops the keys of users
+// that are gone, which shreds whatever is left of their enrollments.
+func (f *EncryptedFileVectorStore) encodeGalleries(vectors map[string][]models.FaceVector) ([]byte, error) {
+	if !f.perUserKeys {
+		if err := f.writeUserKeys(nil); err != nil {
+			return nil, err
+		}
+		return json.Marshal(vectors)
+	}
+
+	existing, err := f.readUserKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	userKeys := make(map[string][]byte, len(vectors))
+	entries := make(map[string][]byte, len(vectors))
+	for userID, gallery := range vectors {
+		key, ok := existing[userID]
+		if !ok {
+			key = make([]byte, userKeySize)
+			if _, err := io.ReadFull(rand.Reader, key); err != nil {
+				return nil, err
+			}
+		}
+		userKeys[userID] = key
+
+		sealed, err := sealGallery(key, userID, gallery)
+		if err != nil {
+			return nil, err
+		}
+		entries[userID] = sealed
+	}
+
+	if err := f.writeUserKeys(userKeys); err != nil {
+		return nil, err
+	}
+	return json.Marshal(entries)
+}
+
+func (f *EncryptedFileVectorStore) readUserKeys() (map[string][]byte, error) {
+	userKeys := make(map[string][]byte)
+
+	encryptedData, err := os.ReadFile(f.userKeysPath())
+	if os.IsNotExist(err) {
+		return userKeys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedData, _, err := f.keys.decrypt(encryptedData)
+	if err != nil {
+		return nil, fmt.Errorf("user keys: %w", err)
+	}
+	if err := json.Unmarshal(decryptedData, &userKeys); err != nil {
+		return nil, err
+	}
+	return userKeys, nil
+}
+
+// writeUserKeys replaces the key file, removing it when no keys are left.
+func (f *EncryptedFileVectorStore) writeUserKeys(userKeys map[string][]byte) error {
+	if len(userKeys) == 0 {
+		if err := os.Remove(f.userKeysPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(userKeys)
+	if err != nil {
+		return err
+	}
+	encryptedData, err := f.keys.encrypt(data)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(f.userKeysPath(), encryptedData)
+}
+
+// sealGallery encrypts a user's enrollments under their key, bound to the
+// user ID so blobs cannot be swapped between users.
+func sealGallery(key []byte, userID string, gallery []models.FaceVector) ([]byte, error) {
+	data, err := json.Marshal(gallery)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, []byte(userID)), nil
+}
+
+func openGallery(key []byte, userID string, sealed []byte) ([]models.FaceVector, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, ciphertext, []byte(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	var gallery []models.FaceVector
+	if err := json.Unmarshal(data, &gallery); err != nil {
+		return nil, err
+	}
+	return gallery, nil
+}
@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// Enrollments are appended to a log next to the vector file, so saving one
+// costs the same however many users are enrolled. Deletions, key rotation
+// and erasure rewrite the vector file with the log folded in (compaction),
+// as does a save that grows the log past its compaction size.
+//
+// The log starts with logMagic and the generation of the vector file it
+// extends: the file's checksum, which covers its random nonce, so every
+// rewrite starts a new generation. A log left over from before a
+// rewrite, because the process died between writing the vector file and
+// removing the log, is ignored and replaced on the next save. Each record
+// is an encrypted logRecord between two copies of its length, which lets a
+// save spot a record torn by a crash at the end of the log and cut it off.
+const vectorLogSuffix = ".log"
+
+var logMagic = []byte("CHVL\x01")
+
+const (
+	generationSize       = sha256.Size
+	logHeaderSize        = 5 + generationSize
+	generationPrefixSize = 512
+)
+
+// DefaultLogCompactionSize is the log size in bytes beyond which a save
+// compacts it into the vector file.
+const DefaultLogCompactionSize = 8 << 20
+
+// logRecord is one appended enrollment, sealed under the user's key when
+// per-user keys are enabled.
+type logRecord struct {
+	UserID string             `json:"user_id"`
+	Vector *models.FaceVector `json:"vector,omitempty"`
+	Sealed []byte             `json:"sealed,omitempty"`
+}
+
+// SetLogCompactionSize sets the log size in bytes beyond which a save
+// compacts it; zero or less uses DefaultLogCompactionSize.
+func (f *EncryptedFileVectorStore) SetLogCompactionSize(size int64) {
+	f.logCompactionSize = size
+}
+
+func (f *EncryptedFileVectorStore) logPath() string {
+	return f.path + vectorLogSuffix
+}
+
+// generation identifies the current vector file; see logMagic.
+func (f *EncryptedFileVectorStore) generation() ([]byte, error) {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return generationOf(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	prefix := make([]byte, generationPrefixSize)
+	n, err := io.ReadFull(file, prefix)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return generationOf(prefix[:n]), nil
+}
+
+// generationOf returns the generation of the vector file starting with
+// snapshot: its checksum, or a hash of its first bytes for a file written
+// before it carried one.
+func generationOf(snapshot []byte) []byte {
+	if bytes.HasPrefix(snapshot, snapshotMagic) && len(snapshot) >= snapshotHeaderSize {
+		return append([]byte(nil), snapshot[len(snapshotMagic):snapshotHeaderSize]...)
+	}
+	if len(snapshot) > generationPrefixSize {
+		snapshot = snapshot[:generationPrefixSize]
+	}
+	sum := sha256.Sum256(snapshot)
+	return sum[:]
+}
+
+func logHeader(generation []byte) []byte {
+	return append(append(make([]byte, 0, logHeaderSize), logMagic...), generation...)
+}
+
+// appendRecord appends an enrollment to the log, starting a new log when
+// there is none for the current vector file, and returns the log's size.
+// The caller holds the exclusive lock.
+func (f *EncryptedFileVectorStore) appendRecord(vector models.FaceVector) (int64, error) {
+	record, err := f.logRecord(vector)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	sealed, err := f.keys.encrypt(data)
+	if err != nil {
+		return 0, err
+	}
+
+	generation, err := f.generation()
+	if err != nil {
+		return 0, err
+	}
+	log, err := os.OpenFile(f.logPath(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer log.Close()
+
+	end, err := logEnd(log, generation)
+	if err != nil {
+		return 0, err
+	}
+	if end == 0 {
+		// No log for this vector file yet, or a stale one
+		if _, err := log.WriteAt(logHeader(generation), 0); err != nil {
+			return 0, err
+		}
+		end = logHeaderSize
+	}
+	if err := log.Truncate(end); err != nil {
+		return 0, err
+	}
+
+	frame := make([]byte, 0, len(sealed)+8)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(sealed)))
+	frame = append(frame, sealed...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(sealed)))
+	if _, err := log.WriteAt(frame, end); err != nil {
+		log.Truncate(end)
+		return 0, err
+	}
+	if err := log.Sync(); err != nil {
+		return 0, err
+	}
+	return end + int64(len(frame)), nil
+}
+
+// logEnd returns where the next record goes: the end of the last complete
+// record, or 0 when the log is empty or belongs to another generation.
+func logEnd(log *os.File, generation []byte) (int64, error) {
+	info, err := log.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size < logHeaderSize {
+		return 0, nil
+	}
+
+	header := make([]byte, logHeaderSize)
+	if _, err := log.ReadAt(header, 0); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(header, logHeader(generation)) {
+		return 0, nil
+	}
+	if size == logHeaderSize {
+		return size, nil
+	}
+
+	// The common case: the last record is whole
+	var trailer, leading [4]byte
+	if _, err := log.ReadAt(trailer[:], size-4); err != nil {
+		return 0, err
+	}
+	start := size - 8 - int64(binary.BigEndian.Uint32(trailer[:]))
+	if start >= logHeaderSize {
+		if _, err := log.ReadAt(leading[:], start); err != nil {
+			return 0, err
+		}
+		if leading == trailer {
+			return size, nil
+		}
+	}
+
+	// A torn record: walk the log to the end of the last whole one
+	end := int64(logHeaderSize)
+	for end+8 <= size {
+		if _, err := log.ReadAt(leading[:], end); err != nil {
+			return 0, err
+		}
+		next := end + 8 + int64(binary.BigEndian.Uint32(leading[:]))
+		if next > size {
+			break
+		}
+		if _, err := log.ReadAt(trailer[:], next-4); err != nil {
+			return 0, err
+		}
+		if trailer != leading {
+			break
+		}
+		end = next
+	}
+	return end, nil
+}
+
+// logRecord builds the log entry of vector, sealing it under the user's
+// key, created if need be, when per-user keys are enabled.
+func (f *EncryptedFileVectorStore) logRecord(vector models.FaceVector) (logRecord, error) {
+	if !f.perUserKeys {
+		return logRecord{UserID: vector.UserID, Vector: &vector}, nil
+	}
+
+	userKeys, err := f.readUserKeys()
+	if err != nil {
+		return logRecord{}, err
+	}
+	key, ok := userKeys[vector.UserID]
+	if !ok {
+		key = make([]byte, userKeySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return logRecord{}, err
+		}
+		userKeys[vector.UserID] = key
+		if err := f.writeUserKeys(userKeys); err != nil {
+			return logRecord{}, err
+		}
+	}
+
+	sealed, err := sealGallery(key, vector.UserID, []models.FaceVector{vector})
+	if err != nil {
+		return logRecord{}, err
+	}
+	return logRecord{UserID: vector.UserID, Sealed: sealed}, nil
+}
+
+// replayLog applies the log to file when it extends a vector file of one of
+// the given generations. A torn final record is skipped.
+func (f *EncryptedFileVectorStore) replayLog(file *vectorFile, generations ...[]byte) error {
+	data, err := os.ReadFile(f.logPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(data) < logHeaderSize {
+		return nil
+	}
+	current := false
+	for _, generation := range generations {
+		current = current || bytes.Equal(data[:logHeaderSize], logHeader(generation))
+	}
+	if !current {
+		return nil
+	}
+
+	var userKeys map[string][]byte
+	for rest := data[logHeaderSize:]; len(rest) >= 8; {
+		length := int(binary.BigEndian.Uint32(rest))
+		if len(rest) < length+8 {
+			break
+		}
+		sealedRecord := rest[4 : 4+length]
+		rest = rest[length+8:]
+
+		plaintext, keyID, err := f.keys.decrypt(sealedRecord)
+		if err != nil {
+			return fmt.Errorf("vector log: %w", err)
+		}
+		if file.keyID == "" {
+			// Everything is still in the log
+			file.keyID = keyID
+		}
+		var record logRecord
+		if err := json.Unmarshal(plaintext, &record); err != nil {
+			return fmt.Errorf("vector log: %w", err)
+		}
+
+		if record.Vector != nil {
+			file.vectors[record.UserID] = append(file.vectors[record.UserID], *record.Vector)
+			continue
+		}
+		if userKeys == nil {
+			if userKeys, err = f.readUserKeys(); err != nil {
+				return err
+			}
+		}
+		key, ok := userKeys[record.UserID]
+		if !ok {
+			// Shredded
+			continue
+		}
+		gallery, err := openGallery(key, record.UserID, record.Sealed)
+		if err != nil {
+			return fmt.Errorf("enrollments of %s: %w", record.UserID, err)
+		}
+		file.vectors[record.UserID] = append(file.vectors[record.UserID], gallery...)
+		file.sealed[record.UserID] = true
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// VectorStore persists enrolled face vectors. The service keeps its own
+// in-memory copy for matching, so backends only need to be durable and
+// safe to share between replicas.
+type VectorStore interface {
+	// Save appends an enrollment for vector.UserID.
+	Save(vector models.FaceVector) error
+	// Load returns every enrollment of userID, empty if there are none.
+	Load(userID string) ([]models.FaceVector, error)
+	// List returns all enrollments keyed by user ID.
+	List() (map[string][]models.FaceVector, error)
+	// Delete removes every enrollment of userID.
+	Delete(userID string) error
+	// Query returns the enrollments accepted by q.
+	Query(q VectorQuery) ([]models.FaceVector, error)
+}
+
+// KeyRotator is implemented by stores that encrypt at rest. RotateKey
+// re-encrypts everything stored under the current key.
+type KeyRotator interface {
+	RotateKey() (models.KeyRotation, error)
+}
+
+// Migrator is implemented by stores with an on-disk format. Migrate
+// rewrites everything stored in the current format.
+type Migrator interface {
+	Migrate() (models.StorageMigration, error)
+}
+
+// UserShredder is implemented by stores that can seal each user's
+// enrollments under a key of their own. ShredUser deletes a user's
+// enrollments together with that key and reports whether a key was
+// destroyed.
+type UserShredder interface {
+	ShredUser(userID string) (bool, error)
+}
+
+// HealthChecker is implemented by stores that can check they are usable
+// without touching stored data, for readiness probes.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// VectorQuery selects stored enrollments; zero fields match everything.
+type VectorQuery struct {
+	UserID        string
+	Version       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+func (q VectorQuery) matches(vector models.FaceVector) bool {
+	if q.UserID != "" && vector.UserID != q.UserID {
+		return false
+	}
+	if q.Version != "" && vector.Version != q.Version {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && !vector.CreatedAt.After(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !vector.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// VelocityStore remembers, per key, the distinct members seen recently and
+// when each was last seen, for sliding-window velocity checks.
+type VelocityStore interface {
+	// Observe records member under key at, forgetting members of key not
+	// seen within retain of it.
+	Observe(ctx context.Context, key, member string, at time.Time, retain time.Duration) error
+	// Distinct returns how many members of key were seen at or after since.
+	Distinct(ctx context.Context, key string, since time.Time) (int, error)
+}
+
+// MemoryVelocityStore keeps velocity counters in process, for a single
+// instance.
+type MemoryVelocityStore struct {
+	mu        sync.Mutex
+	keys      map[string]map[string]velocityMember
+	lastSweep time.Time
+}
+
+type velocityMember struct {
+	seenAt    time.Time
+	expiresAt time.Time
+}
+
+// velocitySweepInterval is how often forgotten keys are dropped.
+const velocitySweepInterval = time.Minute
+
+func NewMemoryVelocityStore() *MemoryVelocityStore {
+	return &MemoryVelocityStore{keys: make(map[string]map[string]velocityMember)}
+}
+
+func (m *MemoryVelocityStore) Observe(ctx context.Context, key, member string, at time.Time, retain time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if at.Sub(m.lastSweep) >= velocitySweepInterval {
+		for k, members := range m.keys {
+			for name, seen := range members {
+				if !at.Before(seen.expiresAt) {
+					delete(members, name)
+				}
+			}
+			if len(members) == 0 {
+				delete(m.keys, k)
+			}
+		}
+		m.lastSweep = at
+	}
+
+	members := m.keys[key]
+	if members == nil {
+		members = make(map[string]velocityMember)
+		m.keys[key] = members
+	}
+	members[member] = velocityMember{seenAt: at, expiresAt: at.Add(retain)}
+	return nil
+}
+
+func (m *MemoryVelocityStore) Distinct(ctx context.Context, key string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, seen := range m.keys[key] {
+		if !seen.seenAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
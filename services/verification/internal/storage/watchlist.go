@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"connect-hub/verification-service/internal/atomicfile"
+	"connect-hub/verification-service/internal/models"
+)
+
+// WatchlistFace is a watchlist entry together with its face descriptor.
+type WatchlistFace struct {
+	models.WatchlistEntry
+	Vector []float32 `json:"vector"`
+}
+
+// FileWatchlist keeps the watchlist in memory and mirrors it to one AES-GCM
+// encrypted JSON file, rewritten on every change. It shares its keys with
+// the vector store, but derives its own data key from them, and only once
+// there is a watchlist to read or write, so instances without one skip the
+// scrypt derivation.
+type FileWatchlist struct {
+	mu    sync.RWMutex
+	path  string
+	keys  KeyRing
+	ring  *keyRing
+	faces []WatchlistFace
+}
+
+// NewFileWatchlist loads the watchlist persisted at path, if any, encrypted
+// under keys.Current or one of keys.Previous.
+func NewFileWatchlist(path string, keys KeyRing) (*FileWatchlist, error) {
+	w := &FileWatchlist{path: path, keys: keys}
+
+	encrypted, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading watchlist: %w", err)
+	}
+	ring, err := w.keyRingLocked()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := ring.decrypt(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting watchlist %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &w.faces); err != nil {
+		return nil, fmt.Errorf("parsing watchlist %s: %w", path, err)
+	}
+	return w, nil
+}
+
+// Add puts face on the watchlist.
+func (w *FileWatchlist) Add(face WatchlistFace) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.faces = append(w.faces, face)
+	if err := w.writeLocked(); err != nil {
+		w.faces = w.faces[:len(w.faces)-1]
+		return err
+	}
+	return nil
+}
+
+// Delete takes entry id of tenantID off the watchlist, reporting whether
+// it was on it.
+func (w *FileWatchlist) Delete(tenantID, id string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, face := range w.faces {
+		if face.ID != id || face.Tenant != tenantID {
+			continue
+		}
+		previous := w.faces
+		w.faces = append(append([]WatchlistFace{}, w.faces[:i]...), w.faces[i+1:]...)
+		if err := w.writeLocked(); err != nil {
+			w.faces = previous
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// List returns the watchlist of tenantID, oldest entry first.
+func (w *FileWatchlist) List(tenantID string) []WatchlistFace {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	faces := []WatchlistFace{}
+	for _, face := range w.faces {
+		if face.Tenant == tenantID {
+			faces = append(faces, face)
+		}
+	}
+	sort.SliceStable(faces, func(i, j int) bool {
+		return faces[i].CreatedAt.Before(faces[j].CreatedAt)
+	})
+	return faces
+}
+
+// RotateKey re-encrypts the watchlist under the current key.
+func (w *FileWatchlist) RotateKey() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Without a ring there was never a file to read or write
+	if w.ring == nil {
+		return nil
+	}
+	return w.writeLocked()
+}
+
+func (w *FileWatchlist) keyRingLocked() (*keyRing, error) {
+	if w.ring == nil {
+		ring, err := newKeyRing(w.keys)
+		if err != nil {
+			return nil, err
+		}
+		w.ring = ring
+	}
+	return w.ring, nil
+}
+
+func (w *FileWatchlist) writeLocked() error {
+	ring, err := w.keyRingLocked()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(w.faces)
+	if err != nil {
+		return err
+	}
+	encrypted, err := ring.encrypt(data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+	return atomicfile.Write(w.path, encrypted)
+}
@@ -0,0 +1,100 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"connect-hub/verification-service/internal/atomicfile"
+	"connect-hub/verification-service/internal/models"
+)
+
+// Store holds the per-tenant configuration overrides set through the admin
+// API. They are mirrored to a JSON file so they survive a restart; with no
+// path they only live in memory.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	configs map[string]models.TenantConfig
+}
+
+// OpenStore loads the overrides persisted at path, if any.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, configs: make(map[string]models.TenantConfig)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading tenant configuration: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.configs); err != nil {
+		return nil, fmt.Errorf("parsing tenant configuration %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the overrides of tenantID.
+func (s *Store) Get(tenantID string) (models.TenantConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cfg, ok := s.configs[tenantID]
+	return cfg, ok
+}
+
+// Put replaces the overrides of tenantID.
+func (s *Store) Put(tenantID string, cfg models.TenantConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.configs[tenantID]
+	s.configs[tenantID] = cfg
+	if err := s.writeLocked(); err != nil {
+		if existed {
+			s.configs[tenantID] = previous
+		} else {
+			delete(s.configs, tenantID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete drops the overrides of tenantID, reporting whether it had any.
+func (s *Store) Delete(tenantID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.configs[tenantID]
+	if !existed {
+		return false, nil
+	}
+	delete(s.configs, tenantID)
+	if err := s.writeLocked(); err != nil {
+		s.configs[tenantID] = previous
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Store) writeLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.configs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return atomicfile.Write(s.path, data)
+}
@@ -0,0 +1,272 @@
+// Package tenant separates the products sharing one deployment. Each tenant
+// is identified by its API keys and has its own gallery, thresholds and rate
+// limit.
+//
+// Enrollments are stored under a user key that prefixes the user ID with
+// the tenant, so a lookup for one tenant can never reach another tenant's
+// gallery. The default tenant, "", keeps bare user IDs; data written before
+// tenancy was enabled belongs to it.
+package tenant
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"connect-hub/verification-service/internal/rbac"
+)
+
+// Default is the tenant of callers without a tenant API key, such as admins
+// and every caller of a deployment without tenancy.
+const Default = ""
+
+// separator cannot appear in a tenant or user ID.
+const separator = "/"
+
+// ValidID reports whether id can name a tenant: alphanumerics, hyphens and
+// underscores, 1-64 characters, like user IDs.
+func ValidID(id string) bool {
+	if len(id) < 1 || len(id) > 64 {
+		return false
+	}
+	for _, char := range id {
+		if !((char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') || char == '-' || char == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// UserKey returns the key userID of tenantID is stored under.
+func UserKey(tenantID, userID string) string {
+	if tenantID == Default {
+		return userID
+	}
+	return tenantID + separator + userID
+}
+
+// SplitUserKey is the inverse of UserKey.
+func SplitUserKey(key string) (tenantID, userID string) {
+	if tenantID, userID, ok := strings.Cut(key, separator); ok {
+		return tenantID, userID
+	}
+	return Default, key
+}
+
+// Registry maps API keys to tenants and holds the per-tenant settings. A
+// nil Registry has tenancy disabled.
+type Registry struct {
+	// keys maps the SHA-256 of each API key to its tenant, so the keys
+	// themselves are not held
+	keys map[[sha256.Size]byte]string
+	// roles holds the roles of the keys that name theirs
+	roles                map[[sha256.Size]byte]rbac.Roles
+	tenants              map[string]bool
+	similarityThresholds map[string]float64
+	livenessThresholds   map[string]float64
+	rateLimits           map[string]int
+}
+
+// Config is the textual configuration of a Registry. Every field is a
+// comma-separated list of tenant:value pairs.
+type Config struct {
+	// APIKeys assigns API keys to tenants; a tenant may have several. A key
+	// may be followed by ":" and the roles it is limited to, such as
+	// "acme:key:reviewer"; keys without roles get rbac.Default
+	APIKeys string
+	// SimilarityThresholds and LivenessThresholds override the global
+	// thresholds
+	SimilarityThresholds string
+	LivenessThresholds   string
+	// RateLimits gives a tenant one shared bucket of requests per minute
+	// across all of its API keys
+	RateLimits string
+}
+
+// NewRegistry parses cfg. It returns nil, with tenancy disabled, when no API
+// keys are configured. Settings may only name tenants that have API keys.
+func NewRegistry(cfg Config) (*Registry, error) {
+	keys, err := parsePairs(cfg.APIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("tenant API keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	r := &Registry{
+		keys:                 make(map[[sha256.Size]byte]string),
+		roles:                make(map[[sha256.Size]byte]rbac.Roles),
+		tenants:              make(map[string]bool),
+		similarityThresholds: make(map[string]float64),
+		livenessThresholds:   make(map[string]float64),
+		rateLimits:           make(map[string]int),
+	}
+	for _, pair := range keys {
+		key, roles, err := splitKeyRoles(pair.value)
+		if err != nil {
+			return nil, fmt.Errorf("tenant API keys: %w for %s", err, pair.tenant)
+		}
+		digest := sha256.Sum256([]byte(key))
+		if owner, exists := r.keys[digest]; exists && owner != pair.tenant {
+			return nil, fmt.Errorf("tenant API keys: key assigned to both %s and %s", owner, pair.tenant)
+		}
+		r.keys[digest] = pair.tenant
+		r.tenants[pair.tenant] = true
+		if roles != 0 {
+			r.roles[digest] = roles
+		}
+	}
+
+	if err := r.parseThresholds(cfg.SimilarityThresholds, r.similarityThresholds); err != nil {
+		return nil, fmt.Errorf("tenant similarity thresholds: %w", err)
+	}
+	if err := r.parseThresholds(cfg.LivenessThresholds, r.livenessThresholds); err != nil {
+		return nil, fmt.Errorf("tenant liveness thresholds: %w", err)
+	}
+
+	limits, err := parsePairs(cfg.RateLimits)
+	if err != nil {
+		return nil, fmt.Errorf("tenant rate limits: %w", err)
+	}
+	for _, pair := range limits {
+		if !r.tenants[pair.tenant] {
+			return nil, fmt.Errorf("tenant rate limits: unknown tenant %s", pair.tenant)
+		}
+		limit, err := strconv.Atoi(pair.value)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("tenant rate limits: invalid limit %q for %s", pair.value, pair.tenant)
+		}
+		r.rateLimits[pair.tenant] = limit
+	}
+	return r, nil
+}
+
+// splitKeyRoles separates the roles from an API key. Text after the last
+// ":" is only taken for roles when it names roles, so keys that contain a
+// colon keep working.
+func splitKeyRoles(value string) (string, rbac.Roles, error) {
+	i := strings.LastIndex(value, ":")
+	if i <= 0 {
+		return value, 0, nil
+	}
+	roles, err := rbac.Parse(value[i+1:])
+	if err != nil {
+		return value, 0, nil
+	}
+	if roles.Has(rbac.Admin) {
+		return "", 0, errors.New("the admin role spans tenants and cannot be given to an API key")
+	}
+	return value[:i], roles, nil
+}
+
+func (r *Registry) parseThresholds(spec string, into map[string]float64) error {
+	pairs, err := parsePairs(spec)
+	if err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		if !r.tenants[pair.tenant] {
+			return fmt.Errorf("unknown tenant %s", pair.tenant)
+		}
+		threshold, err := strconv.ParseFloat(pair.value, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return fmt.Errorf("invalid threshold %q for %s", pair.value, pair.tenant)
+		}
+		into[pair.tenant] = threshold
+	}
+	return nil
+}
+
+type pair struct {
+	tenant string
+	value  string
+}
+
+func parsePairs(spec string) ([]pair, error) {
+	var pairs []pair
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(entry, ":")
+		id, value = strings.TrimSpace(id), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected tenant:value", entry)
+		}
+		if !ValidID(id) {
+			return nil, fmt.Errorf("invalid tenant ID %q", id)
+		}
+		pairs = append(pairs, pair{tenant: id, value: value})
+	}
+	return pairs, nil
+}
+
+// Enabled reports whether callers must identify their tenant.
+func (r *Registry) Enabled() bool {
+	return r != nil
+}
+
+// Resolve returns the tenant apiKey belongs to.
+func (r *Registry) Resolve(apiKey string) (string, bool) {
+	if r == nil || apiKey == "" {
+		return "", false
+	}
+	tenantID, ok := r.keys[sha256.Sum256([]byte(apiKey))]
+	return tenantID, ok
+}
+
+// Roles returns the roles of apiKey, which must resolve to a tenant.
+func (r *Registry) Roles(apiKey string) rbac.Roles {
+	if r == nil {
+		return rbac.Default
+	}
+	if roles, ok := r.roles[sha256.Sum256([]byte(apiKey))]; ok {
+		return roles
+	}
+	return rbac.Default
+}
+
+// Known reports whether tenantID has API keys. The default tenant is always
+// known.
+func (r *Registry) Known(tenantID string) bool {
+	if tenantID == Default {
+		return true
+	}
+	return r != nil && r.tenants[tenantID]
+}
+
+// SimilarityThreshold returns the tenant's match threshold, or fallback
+// when it has none.
+func (r *Registry) SimilarityThreshold(tenantID string, fallback float64) float64 {
+	if r != nil {
+		if threshold, ok := r.similarityThresholds[tenantID]; ok {
+			return threshold
+		}
+	}
+	return fallback
+}
+
+// LivenessThreshold returns the tenant's liveness threshold, or fallback
+// when it has none.
+func (r *Registry) LivenessThreshold(tenantID string, fallback float64) float64 {
+	if r != nil {
+		if threshold, ok := r.livenessThresholds[tenantID]; ok {
+			return threshold
+		}
+	}
+	return fallback
+}
+
+// RateLimit returns the tenant's requests per minute, or 0 when its keys
+// are limited individually.
+func (r *Registry) RateLimit(tenantID string) int {
+	if r == nil {
+		return 0
+	}
+	return r.rateLimits[tenantID]
+}
@@ -0,0 +1,105 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// ClientRole is what a client certificate identity may do: act as an admin,
+// as X-Admin-Key does, or on behalf of a tenant, as its API keys do.
+type ClientRole struct {
+	Admin  bool
+	Tenant string
+}
+
+// ClientIdentities maps client certificate identities, a URI or DNS name
+// from the subject alternative names or the subject common name, to roles.
+type ClientIdentities map[string]ClientRole
+
+// ParseClientIdentities parses a list of the form "identity=role,..." where
+// role is admin or tenant:<id>, such as
+// "spiffe://connect-hub/billing=tenant:billing,ops.internal=admin".
+func ParseClientIdentities(spec string) (ClientIdentities, error) {
+	identities := make(ClientIdentities)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		identity, role, ok := strings.Cut(entry, "=")
+		identity, role = strings.TrimSpace(identity), strings.TrimSpace(role)
+		if !ok || identity == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected identity=role", entry)
+		}
+		if _, ok := identities[identity]; ok {
+			return nil, fmt.Errorf("duplicate client identity %q", identity)
+		}
+
+		if role == "admin" {
+			identities[identity] = ClientRole{Admin: true}
+			continue
+		}
+		tenantID, ok := strings.CutPrefix(role, "tenant:")
+		if !ok {
+			return nil, fmt.Errorf("invalid role %q of %q, expected admin or tenant:<id>", role, identity)
+		}
+		if !tenant.ValidID(tenantID) {
+			return nil, fmt.Errorf("invalid tenant ID %q of %q", tenantID, identity)
+		}
+		identities[identity] = ClientRole{Tenant: tenantID}
+	}
+	return identities, nil
+}
+
+// Resolve returns the listed identity of the verified client certificate
+// of a connection, and its role. URI names are tried first, then DNS
+// names, then the common name. Connections without a verified certificate
+// have no identity.
+func (ids ClientIdentities) Resolve(state *tls.ConnectionState) (string, ClientRole, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", ClientRole{}, false
+	}
+	cert := state.VerifiedChains[0][0]
+
+	var names []string
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.Subject.CommonName)
+	for _, name := range names {
+		if role, ok := ids[name]; ok && name != "" {
+			return name, role, true
+		}
+	}
+	return "", ClientRole{}, false
+}
+
+// clientAuth configures tlsConfig to verify client certificates against
+// TLS_CLIENT_CA_FILE, requiring one with TLS_CLIENT_AUTH=require.
+func clientAuth(tlsConfig *tls.Config, cfg *config.Config) error {
+	if cfg.TLSClientCAFile == "" {
+		return nil
+	}
+	bundle, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return fmt.Errorf("no certificates in client CA bundle %s", cfg.TLSClientCAFile)
+	}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if cfg.TLSClientAuth == "require" {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package tlsconfig sets up HTTPS for the HTTP server: the server
+// certificate, reloaded from disk or obtained from Let's Encrypt, and the
+// optional verification of client certificates.
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+
+	"connect-hub/verification-service/internal/config"
+)
+
+// New returns the TLS configuration of the HTTP server, or nil when cfg
+// serves plain HTTP. With TLS_CERT_FILE and TLS_KEY_FILE the returned
+// CertReloader serves the pair and must be reloaded, or watched, to pick up
+// renewals. With TLS_AUTOCERT_DOMAINS certificates come from Let's Encrypt
+// over the TLS-ALPN-01 challenge and are renewed in the background, and no
+// CertReloader is returned. Either way TLS_CLIENT_CA_FILE turns on client
+// certificate verification.
+func New(cfg *config.Config, logger *zap.Logger) (*tls.Config, *CertReloader, error) {
+	switch {
+	case cfg.TLSCertFile != "":
+		certs, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig := &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		if err := clientAuth(tlsConfig, cfg); err != nil {
+			return nil, nil, err
+		}
+		return tlsConfig, certs, nil
+
+	case cfg.TLSAutocertDomains != "":
+		var domains []string
+		for _, domain := range strings.Split(cfg.TLSAutocertDomains, ",") {
+			if domain = strings.TrimSpace(domain); domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCache),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		if err := clientAuth(tlsConfig, cfg); err != nil {
+			return nil, nil, err
+		}
+		logger.Info("Serving certificates from Let's Encrypt", zap.Strings("domains", domains))
+		return tlsConfig, nil, nil
+	}
+	return nil, nil, nil
+}
+
+// CertReloader serves a certificate and key pair from disk, swapping in the
+// files' new contents on Reload. Handshakes in progress keep the
+// certificate they started with.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *zap.Logger
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewCertReloader loads the pair, failing when it cannot.
+func NewCertReloader(certFile, keyFile string, logger *zap.Logger) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the pair, reporting whether it differs from the one in use.
+func (r *CertReloader) load() (bool, error) {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return false, fmt.Errorf("failed to parse TLS certificate: %w", err)
+	}
+	if current := r.cert.Load(); current != nil && bytes.Equal(current.Certificate[0], cert.Certificate[0]) {
+		return false, nil
+	}
+	r.cert.Store(&cert)
+	return true, nil
+}
+
+// Reload reads the pair again. When it cannot be loaded, say because only
+// one of the files has been replaced so far, the previous certificate stays
+// in use and the error is returned.
+func (r *CertReloader) Reload() error {
+	changed, err := r.load()
+	if err != nil || !changed {
+		return err
+	}
+	leaf := r.Certificate()
+	r.logger.Info("TLS certificate reloaded",
+		zap.Strings("names", leaf.DNSNames),
+		zap.String("serial", leaf.SerialNumber.String()),
+		zap.Time("not_after", leaf.NotAfter))
+	return nil
+}
+
+// Certificate returns the certificate in use.
+func (r *CertReloader) Certificate() *x509.Certificate {
+	return r.cert.Load().Leaf
+}
+
+// GetCertificate serves the current certificate, for tls.Config.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Watch reloads the pair whenever the directories holding it change, until
+// stop is closed. Directories rather than files are watched so that
+// renewals written by replacing the file, or by swapping the symlink of a
+// Kubernetes secret mount, are noticed.
+func (r *CertReloader) Watch(stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch TLS certificate: %w", err)
+	}
+	dirs := map[string]bool{filepath.Dir(r.certFile): true, filepath.Dir(r.keyFile): true}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch TLS certificate: %w", err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				// A renewal writes several files, so early events may find
+				// a mismatched pair; the last one loads it
+				if err := r.Reload(); err != nil {
+					r.logger.Debug("TLS certificate not reloaded", zap.String("event", event.String()), zap.Error(err))
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Warn("TLS certificate watch failed", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
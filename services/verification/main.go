@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,35 +12,38 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 
+	"connect-hub/verification-service/internal/cli"
 	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/grpcapi"
 	"connect-hub/verification-service/internal/handlers"
-	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/logging"
 	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/tlsconfig"
 )
 
 func main() {
-	// Initialize logger
-	logger, err := zap.NewProduction()
-	if err != nil {
-		log.Fatal("Failed to create logger:", err)
-	}
-	defer logger.Sync()
-
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		logger.Fatal("Failed to load configuration", zap.Error(err))
-	}
+	os.Exit(cli.Run(os.Args[1:], serve, os.Stdout, os.Stderr))
+}
 
+// serve runs the HTTP API, and the gRPC API when enabled, until SIGINT or
+// SIGTERM.
+func serve(logger *zap.Logger, cfg *config.Config) error {
 	// Initialize services
 	faceService, err := services.NewFaceVerificationService(logger, cfg)
 	if err != nil {
-		logger.Fatal("Failed to initialize face verification service", zap.Error(err))
+		return fmt.Errorf("failed to initialize face verification service: %w", err)
 	}
 	defer faceService.Close()
 
+	// Roles of client certificate identities, as an alternative to API keys
+	clientIdentities, err := tlsconfig.ParseClientIdentities(cfg.TLSClientIdentities)
+	if err != nil {
+		return fmt.Errorf("invalid TLS_CLIENT_IDENTITIES: %w", err)
+	}
+
 	// Initialize handlers
 	verificationHandler := handlers.NewVerificationHandler(faceService, logger)
 
@@ -52,43 +55,101 @@ func main() {
 	router := gin.New()
 
 	// Global middleware
-	router.Use(middleware.Logger(logger))
-	router.Use(middleware.CORS())
+	redactor, err := logging.NewRedactor(cfg)
+	if err != nil {
+		return err
+	}
+	if redactor != nil {
+		router.Use(middleware.RedactedLogger(redactor))
+	} else {
+		router.Use(middleware.Logger(logger))
+	}
+	router.Use(middleware.CORS(middleware.NewCORSConfig(cfg)))
 	router.Use(middleware.Recovery(logger))
-	router.Use(middleware.RateLimit())
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "healthy",
-			"timestamp": time.Now().UTC(),
-		})
-	})
-
-	// API routes
-	v1 := router.Group("/api/v1")
-	{
-		v1.POST("/verify", verificationHandler.VerifyVideo)
-		v1.GET("/status/:id", verificationHandler.GetVerificationStatus)
-		v1.POST("/register", verificationHandler.RegisterFace)
+	router.Use(middleware.ClientCertificate(clientIdentities))
+	router.Use(middleware.RateLimit(middleware.RateLimitConfig{
+		RequestsPerMinute: cfg.RateLimitPerMinute,
+		Burst:             cfg.RateLimitBurst,
+		MaxClients:        cfg.RateLimitMaxClients,
+		Tenants:           faceService.Tenants(),
+		Limits:            faceService.RateLimits,
+	}))
+
+	handlers.RegisterRoutes(router, verificationHandler, cfg)
+
+	// HTTPS when a certificate or Let's Encrypt domains are configured
+	tlsConfig, certs, err := tlsconfig.New(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	if certs != nil {
+		stopWatch := make(chan struct{})
+		defer close(stopWatch)
+		if err := certs.Watch(stopWatch); err != nil {
+			logger.Warn("Certificate renewals need SIGHUP to be picked up", zap.Error(err))
+		}
 	}
 
+	// Readiness fails until every recognizer is loaded and a synthetic
+	// verification ran through the pipeline
+	faceService.StartWarmup(context.Background())
+
 	// Start server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      router,
+		TLSConfig:    tlsConfig,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
 	// Start server in goroutine
 	go func() {
-		logger.Info("Starting verification service", zap.Int("port", cfg.Port))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Info("Starting verification service", zap.Int("port", cfg.Port), zap.Bool("tls", tlsConfig != nil))
+		var err error
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Failed to start server", zap.Error(err))
 		}
 	}()
 
+	// gRPC API for service-to-service calls
+	var grpcServer *grpc.Server
+	if cfg.GRPCEnabled {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		grpcServer = grpcapi.NewGRPCServer(faceService, logger)
+		go func() {
+			logger.Info("Starting gRPC server", zap.Int("port", cfg.GRPCPort))
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
+	// SIGHUP reloads the tunable settings and the TLS certificate
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	go func() {
+		for range reload {
+			if _, err := faceService.ReloadConfig(); err != nil {
+				logger.Error("Failed to reload configuration", zap.Error(err))
+			}
+			if certs != nil {
+				if err := certs.Reload(); err != nil {
+					logger.Error("Failed to reload TLS certificate", zap.Error(err))
+				}
+			}
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -96,13 +157,39 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	// Give outstanding requests 30 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Fail readiness and refuse new background work first, giving load
+	// balancers SHUTDOWN_DELAY to stop routing here
+	faceService.BeginDrain()
+	time.Sleep(services.ShutdownDelay(cfg))
+
+	// In-flight requests and background work share the drain deadline
+	ctx, cancel := context.WithTimeout(context.Background(), services.ShutdownDrainTimeout(cfg))
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+
+	// Requests finishing now may still queue webhook deliveries, so the
+	// service drains after the server
+	shutdownErr := srv.Shutdown(ctx)
+	drainErr := faceService.Drain(ctx)
+	if shutdownErr != nil {
+		return fmt.Errorf("server forced to shutdown: %w", shutdownErr)
+	}
+	if drainErr != nil {
+		return fmt.Errorf("background work abandoned: %w", drainErr)
 	}
 
 	logger.Info("Server exited")
-}
\ No newline at end of file
+	return nil
+}
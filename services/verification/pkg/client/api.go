@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultPollInterval is how often WaitForStatus polls unless told otherwise.
+const defaultPollInterval = time.Second
+
+// Verify verifies a capture and waits for the decision. A rejected capture
+// is not an error: check Verified and Reason on the result.
+func (c *Client) Verify(ctx context.Context, req VerifyRequest) (*VerificationResult, error) {
+	var response struct {
+		Data VerificationResult `json:"data"`
+	}
+	if err := c.verify(ctx, req, "", &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// VerifyAsync queues a verification and returns at once; follow it with
+// Status or WaitForStatus. The service needs ASYNC_ENABLED.
+func (c *Client) VerifyAsync(ctx context.Context, req VerifyRequest) (*AsyncVerification, error) {
+	var response struct {
+		Data AsyncVerification `json:"data"`
+	}
+	if err := c.verify(ctx, req, "async", &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+func (c *Client) verify(ctx context.Context, req VerifyRequest, mode string, out interface{}) error {
+	if req.Video == nil {
+		return errors.New("client: a video is required")
+	}
+	body, replayable := multipartBody(map[string]string{
+		"user_id":          req.UserID,
+		"session_id":       req.SessionID,
+		"action":           req.Action,
+		"region":           req.Region,
+		"liveness_session": req.LivenessSession,
+		"liveness_nonce":   req.LivenessNonce,
+		"mode":             mode,
+	}, req.Video, req.ContentType)
+
+	_, err := c.do(ctx, request{
+		method:         http.MethodPost,
+		path:           "/api/v1/verify",
+		body:           body,
+		idempotencyKey: idempotencyKey(req.IdempotencyKey),
+		retryable:      replayable,
+	}, out)
+	return err
+}
+
+// Register enrolls the face in a capture. Captures the enrollment quality
+// gate turns away fail with an Error whose Code names the problem, such as
+// FACE_TOO_SMALL.
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*Registration, error) {
+	if req.Video == nil {
+		return nil, errors.New("client: a video is required")
+	}
+	if req.UserID == "" {
+		return nil, errors.New("client: a user ID is required")
+	}
+	body, replayable := multipartBody(map[string]string{
+		"user_id":         req.UserID,
+		"duplicate_check": req.DuplicateCheck,
+	}, req.Video, req.ContentType)
+
+	var registration Registration
+	_, err := c.do(ctx, request{
+		method:         http.MethodPost,
+		path:           "/api/v1/register",
+		body:           body,
+		idempotencyKey: idempotencyKey(req.IdempotencyKey),
+		retryable:      replayable,
+	}, &registration)
+	if err != nil {
+		return nil, err
+	}
+	return &registration, nil
+}
+
+// Identify returns the enrolled users closest to the face in a capture,
+// best first. Nothing is stored, so failed attempts are retried as reads.
+func (c *Client) Identify(ctx context.Context, req IdentifyRequest) ([]GalleryMatch, error) {
+	if req.Video == nil {
+		return nil, errors.New("client: a video is required")
+	}
+	fields := map[string]string{}
+	if req.MaxResults > 0 {
+		fields["max_results"] = strconv.Itoa(req.MaxResults)
+	}
+	body, replayable := multipartBody(fields, req.Video, req.ContentType)
+
+	var response struct {
+		Data struct {
+			Matches []GalleryMatch `json:"matches"`
+		} `json:"data"`
+	}
+	_, err := c.do(ctx, request{
+		method:    http.MethodPost,
+		path:      "/api/v1/identify",
+		body:      body,
+		retryable: replayable,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Data.Matches, nil
+}
+
+// Status returns where a verification is. Unknown IDs, and those of other
+// tenants, fail with VERIFICATION_NOT_FOUND.
+func (c *Client) Status(ctx context.Context, verificationID string) (*Status, error) {
+	var status Status
+	_, err := c.do(ctx, request{
+		method:    http.MethodGet,
+		path:      "/api/v1/status/" + url.PathEscape(verificationID),
+		retryable: true,
+	}, &status)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// WaitForStatus polls Status every interval (a second when 0) until the
+// verification is done or ctx ends.
+func (c *Client) WaitForStatus(ctx context.Context, verificationID string, interval time.Duration) (*Status, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		status, err := c.Status(ctx, verificationID)
+		if err != nil {
+			return nil, err
+		}
+		if status.Done() {
+			return status, nil
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// StartLivenessSession issues an active liveness challenge for the next
+// capture; pass its token and nonce in VerifyRequest.
+func (c *Client) StartLivenessSession(ctx context.Context) (*LivenessSession, error) {
+	var response struct {
+		Data LivenessSession `json:"data"`
+	}
+	_, err := c.do(ctx, request{
+		method:    http.MethodPost,
+		path:      "/api/v1/liveness/session",
+		retryable: true,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+func idempotencyKey(key string) string {
+	if key != "" {
+		return key
+	}
+	return uuid.New().String()
+}
+
+// multipartBody streams fields and a capture as a multipart form, so the
+// capture is never buffered whole. It reports whether the body can be sent
+// again: captures that are io.Seekers are rewound for every attempt.
+func multipartBody(fields map[string]string, video io.Reader, contentType string) (func() (io.Reader, string, error), bool) {
+	if contentType == "" {
+		contentType = "video/webm"
+	}
+	seeker, replayable := video.(io.Seeker)
+	var start int64
+	if replayable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			replayable = false
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name, value := range fields {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var previous *io.PipeReader
+	var written chan struct{}
+	return func() (io.Reader, string, error) {
+		if previous != nil {
+			if !replayable {
+				return nil, "", errors.New("client: the capture cannot be sent again")
+			}
+			// The transport may still hold the last attempt's body; stop its
+			// writer before rewinding the capture under it
+			previous.Close()
+			<-written
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, "", fmt.Errorf("client: rewinding the capture: %w", err)
+			}
+		}
+
+		pr, pw := io.Pipe()
+		form := multipart.NewWriter(pw)
+		previous, written = pr, make(chan struct{})
+		go func(written chan struct{}) {
+			defer close(written)
+			pw.CloseWithError(writeForm(form, names, fields, video, contentType))
+		}(written)
+		return pr, form.FormDataContentType(), nil
+	}, replayable
+}
+
+func writeForm(form *multipart.Writer, names []string, fields map[string]string, video io.Reader, contentType string) error {
+	for _, name := range names {
+		if err := form.WriteField(name, fields[name]); err != nil {
+			return err
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="video"; filename="capture"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, video); err != nil {
+		return err
+	}
+	return form.Close()
+}
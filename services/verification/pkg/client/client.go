@@ -0,0 +1,305 @@
+// Package client is a typed Go client for the verification service's REST
+// API, for connect-hub services that verify or enroll faces.
+//
+// Calls take a context and retry transient failures (rate limiting, a busy
+// server, unreachable replicas) with backoff, honoring Retry-After.
+// Verifications and registrations carry an Idempotency-Key that stays the
+// same across retries, so a retried call never runs twice when the service
+// has IDEMPOTENCY_ENABLED set.
+//
+//	c, err := client.New("http://verification:8080", client.WithAPIKey(key))
+//	result, err := c.Verify(ctx, client.VerifyRequest{
+//		UserID: "user123",
+//		Video:  bytes.NewReader(capture),
+//	})
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for the retry policy, see WithRetries
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 200 * time.Millisecond
+	maxRetryDelay      = 10 * time.Second
+)
+
+// Client calls one verification service deployment. It is safe for
+// concurrent use.
+type Client struct {
+	baseURL     *url.URL
+	httpClient  *http.Client
+	apiKey      string
+	adminKey    string
+	tenant      string
+	language    string
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey authenticates calls with a tenant API key (X-API-Key).
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithAdminKey sends the admin key (X-Admin-Key), which discloses scores in
+// Status and lets WithTenant pick the tenant.
+func WithAdminKey(key string) Option {
+	return func(c *Client) { c.adminKey = key }
+}
+
+// WithTenant acts on behalf of a tenant (X-Tenant-ID); it needs the admin
+// key.
+func WithTenant(tenantID string) Option {
+	return func(c *Client) { c.tenant = tenantID }
+}
+
+// WithLanguage asks for reason messages in a language (Accept-Language).
+func WithLanguage(language string) Option {
+	return func(c *Client) { c.language = language }
+}
+
+// WithHTTPClient replaces http.DefaultClient, e.g. for timeouts or TLS.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetries sets how many times a call is attempted in all and the delay
+// before the first retry, which doubles on every further one. One attempt
+// disables retries.
+func WithRetries(maxAttempts int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxAttempts = maxAttempts
+		c.baseDelay = baseDelay
+	}
+}
+
+// New returns a client for the service at baseURL, such as
+// "http://verification:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:     u,
+		httpClient:  http.DefaultClient,
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxAttempts < 1 {
+		c.maxAttempts = 1
+	}
+	return c, nil
+}
+
+// Error is an error answered by the service. Code is its machine-readable
+// code, such as SERVER_BUSY or USER_NOT_ENROLLED. StatusCode is 0 for
+// errors sent on a live stream.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	// How long the service asked to wait before retrying (Retry-After)
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("verification service: HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("verification service: %s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the service error code in err's chain, or "".
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// temporary reports whether the same request may succeed when retried:
+// the service was busy or rate limiting, a replica was unreachable, or an
+// earlier attempt with the same Idempotency-Key is still running. Monthly
+// quotas are not retried.
+func (e *Error) temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return e.Code != "QUOTA_EXCEEDED"
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return e.Code == "IDEMPOTENCY_KEY_IN_USE"
+	}
+	return false
+}
+
+// request is one API call. body returns a fresh request body and its
+// content type for every attempt; nil sends none.
+type request struct {
+	method         string
+	path           string
+	query          url.Values
+	body           func() (io.Reader, string, error)
+	idempotencyKey string
+	// Whether a failed attempt may be repeated: reads, and writes made
+	// safe by an idempotency key
+	retryable bool
+}
+
+// do sends req, retrying transient failures, and decodes the JSON response
+// into out, which may be nil.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.retryDelay(attempt, lastErr)); err != nil {
+				return 0, err
+			}
+		}
+
+		status, temporary, err := c.attempt(ctx, req, out)
+		if err == nil {
+			return status, nil
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if !temporary || !req.retryable {
+			return status, err
+		}
+		lastErr = err
+	}
+	return 0, lastErr
+}
+
+// attempt sends req once and reports whether a failure was temporary: the
+// service could not be reached or answered with a temporary Error.
+func (c *Client) attempt(ctx context.Context, req request, out interface{}) (int, bool, error) {
+	var body io.Reader
+	var contentType string
+	if req.body != nil {
+		var err error
+		if body, contentType, err = req.body(); err != nil {
+			return 0, false, err
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return 0, false, err
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if req.idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", req.idempotencyKey)
+	}
+	c.authorize(httpReq.Header)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := decodeError(resp)
+		return resp.StatusCode, apiErr.temporary(), apiErr
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, false, fmt.Errorf("client: decoding response: %w", err)
+	}
+	return resp.StatusCode, false, nil
+}
+
+// authorize sets the credentials and preferences every call carries.
+func (c *Client) authorize(header http.Header) {
+	if c.apiKey != "" {
+		header.Set("X-API-Key", c.apiKey)
+	}
+	if c.adminKey != "" {
+		header.Set("X-Admin-Key", c.adminKey)
+	}
+	if c.tenant != "" {
+		header.Set("X-Tenant-ID", c.tenant)
+	}
+	if c.language != "" {
+		header.Set("Accept-Language", c.language)
+	}
+}
+
+func decodeError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Code != "" {
+		apiErr.Code, apiErr.Message = body.Code, body.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// retryDelay is the backoff before attempt, with jitter, or the service's
+// Retry-After when it asked for longer.
+func (c *Client) retryDelay(attempt int, lastErr error) time.Duration {
+	delay := c.baseDelay << (attempt - 1)
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	var apiErr *Error
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+	return delay
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
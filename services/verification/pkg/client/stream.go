@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/websocket"
+)
+
+// LiveRequest is a verification of frames streamed with VerifyLive. The
+// fields mean what they do in VerifyRequest.
+type LiveRequest struct {
+	UserID          string
+	SessionID       string
+	Action          string
+	Region          string
+	LivenessSession string
+	LivenessNonce   string
+}
+
+// liveEvent is a message the service sends on /verify/live.
+type liveEvent struct {
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+	Error string          `json:"error"`
+	Code  string          `json:"code"`
+}
+
+// VerifyLive streams JPEG frames to /verify/live as they arrive on frames,
+// calling progress, when given, with the running liveness of each, and
+// returns the decision once frames is closed. The service needs
+// LIVE_VERIFICATION_ENABLED. A stream cannot be replayed, so it is never
+// retried.
+func (c *Client) VerifyLive(ctx context.Context, req LiveRequest, frames <-chan []byte, progress func(LiveProgress)) (*VerificationResult, error) {
+	u := *c.baseURL
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	u.Path += "/api/v1/verify/live"
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"user_id":          req.UserID,
+		"session_id":       req.SessionID,
+		"action":           req.Action,
+		"region":           req.Region,
+		"liveness_session": req.LivenessSession,
+		"liveness_nonce":   req.LivenessNonce,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	header := make(http.Header)
+	c.authorize(header)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		// Requests the service refuses are answered before the upgrade
+		if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		}
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Canceling ctx unblocks reads and writes by closing the connection
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"finish"}`)); err != nil {
+					return nil, liveError(ctx, err)
+				}
+				return readLiveResult(ctx, conn, progress)
+			}
+			if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+				return nil, liveError(ctx, err)
+			}
+			result, done, err := readLiveEvent(conn, progress)
+			if err != nil || done {
+				return result, liveError(ctx, err)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// readLiveResult reads events until the decision or an error arrives.
+func readLiveResult(ctx context.Context, conn *websocket.Conn, progress func(LiveProgress)) (*VerificationResult, error) {
+	for {
+		result, done, err := readLiveEvent(conn, progress)
+		if err != nil || done {
+			return result, liveError(ctx, err)
+		}
+	}
+}
+
+// readLiveEvent reads one event, reporting whether it ended the stream. The
+// service stops reading once it holds enough frames, answering the last
+// with the decision.
+func readLiveEvent(conn *websocket.Conn, progress func(LiveProgress)) (*VerificationResult, bool, error) {
+	var event liveEvent
+	if err := conn.ReadJSON(&event); err != nil {
+		return nil, true, err
+	}
+	switch event.Type {
+	case "progress":
+		if progress != nil {
+			var p LiveProgress
+			if err := json.Unmarshal(event.Data, &p); err != nil {
+				return nil, true, fmt.Errorf("client: decoding progress: %w", err)
+			}
+			progress(p)
+		}
+		return nil, false, nil
+	case "result":
+		var result VerificationResult
+		if err := json.Unmarshal(event.Data, &result); err != nil {
+			return nil, true, fmt.Errorf("client: decoding result: %w", err)
+		}
+		return &result, true, nil
+	case "error":
+		return nil, true, &Error{Code: event.Code, Message: event.Error}
+	default:
+		return nil, true, fmt.Errorf("client: unexpected live event %q", event.Type)
+	}
+}
+
+// liveError reports a connection closed by canceling ctx as ctx's error.
+func liveError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"io"
+	"time"
+
+	"connect-hub/verification-service/internal/models"
+)
+
+// Responses share the service's own models, so they never drift from what
+// it sends.
+type (
+	VerificationResult  = models.VerificationResult
+	VerificationWarning = models.VerificationWarning
+	VerificationStatus  = models.VerificationStatus
+	LivenessSession     = models.LivenessSession
+	LiveProgress        = models.LiveProgress
+	GalleryMatch        = models.GalleryMatch
+	RiskAssessment      = models.RiskAssessment
+	Review              = models.Review
+)
+
+// Statuses of a verification, as reported by Status
+const (
+	StatusPending    = models.StatusPending
+	StatusProcessing = models.StatusProcessing
+	StatusCompleted  = models.StatusCompleted
+	StatusFailed     = models.StatusFailed
+)
+
+// VerifyRequest is a 1:1 verification of a capture against UserID's
+// enrollment.
+type VerifyRequest struct {
+	// The capture. Readers that are also io.Seekers, such as *os.File and
+	// *bytes.Reader, are rewound for retries; others are sent only once.
+	Video io.Reader
+	// MIME type of Video; video/webm when empty
+	ContentType string
+
+	UserID    string
+	SessionID string
+	// Declared head motion the capture must show, e.g. "turn_left"
+	Action string
+	// Region the capture was taken in, for residency rules
+	Region string
+	// Liveness session from StartLivenessSession the capture answers
+	LivenessSession string
+	LivenessNonce   string
+	// Idempotency-Key sent with every attempt; a random one when empty
+	IdempotencyKey string
+}
+
+// RegisterRequest enrolls the face in a capture as UserID.
+type RegisterRequest struct {
+	Video       io.Reader
+	ContentType string
+
+	UserID string
+	// Cross-user duplicate check for this enrollment: off, flag or reject
+	DuplicateCheck string
+	IdempotencyKey string
+}
+
+// Registration is the outcome of a successful Register.
+type Registration struct {
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+	// The face was already enrolled under another user and the duplicate
+	// check only flagged it
+	DuplicateIdentity bool `json:"duplicate_identity,omitempty"`
+}
+
+// IdentifyRequest is a 1:N search of the tenant's gallery.
+type IdentifyRequest struct {
+	Video       io.Reader
+	ContentType string
+
+	// How many users to return, best first; the service default when 0
+	MaxResults int
+}
+
+// AsyncVerification is a verification queued with VerifyAsync.
+type AsyncVerification struct {
+	VerificationID string             `json:"verification_id"`
+	Status         VerificationStatus `json:"status"`
+	StatusURL      string             `json:"status_url"`
+}
+
+// Status is where a verification is, as reported by /status/:id. Result
+// carries the scores only for clients with the admin key.
+type Status struct {
+	VerificationID string              `json:"verification_id"`
+	Status         VerificationStatus  `json:"status"`
+	Verified       *bool               `json:"verified,omitempty"`
+	ErrorMessage   string              `json:"error_message,omitempty"`
+	Timestamp      time.Time           `json:"timestamp"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Result         *VerificationResult `json:"result,omitempty"`
+}
+
+// Done reports whether the verification has finished, successfully or not.
+func (s *Status) Done() bool {
+	return s.Status == StatusCompleted || s.Status == StatusFailed
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestDeclaredActionConsistency(t *testing.T) {
+	rightward := createPanningFrames(5, 10, 0)
+	leftward := createPanningFrames(5, -10, 0)
+	static := createPanningFrames(5, 0, 0)
+
+	t.Run("measures rightward motion", func(t *testing.T) {
+		dx, dy := services.MeasureMotion(rightward)
+
+		assert.Greater(t, dx, 0.05)
+		assert.InDelta(t, 0.0, dy, 0.01)
+	})
+
+	t.Run("turn left challenge with rightward motion is a mismatch", func(t *testing.T) {
+		err := services.CheckDeclaredAction(services.ActionTurnLeft, rightward, 0.02)
+		assert.ErrorIs(t, err, services.ErrActionMismatch)
+	})
+
+	t.Run("matching direction passes", func(t *testing.T) {
+		assert.NoError(t, services.CheckDeclaredAction(services.ActionTurnRight, rightward, 0.02))
+		assert.NoError(t, services.CheckDeclaredAction(services.ActionTurnLeft, leftward, 0.02))
+	})
+
+	t.Run("no motion does not support a turn", func(t *testing.T) {
+		err := services.CheckDeclaredAction(services.ActionTurnLeft, static, 0.02)
+		assert.ErrorIs(t, err, services.ErrActionMismatch)
+	})
+
+	t.Run("vertical motion does not satisfy a horizontal action", func(t *testing.T) {
+		downward := createPanningFrames(5, 0, 10)
+
+		assert.ErrorIs(t, services.CheckDeclaredAction(services.ActionTurnRight, downward, 0.02), services.ErrActionMismatch)
+		assert.NoError(t, services.CheckDeclaredAction(services.ActionLookDown, downward, 0.02))
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		err := services.CheckDeclaredAction("wave", rightward, 0.02)
+		assert.ErrorIs(t, err, services.ErrUnknownAction)
+		assert.False(t, services.ValidAction("wave"))
+	})
+}
+
+func TestFaceVerificationService_ActionMismatch(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, enabled bool) *services.FaceVerificationService {
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			ActionCheckEnabled:  enabled,
+			ActionMinMotion:     0.02,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	t.Run("static capture claiming a turn is rejected", func(t *testing.T) {
+		service := newService(t, true)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "test-session-action",
+			Action:    services.ActionTurnLeft,
+		})
+
+		require.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Equal(t, models.ReasonActionMismatch, result.Reason)
+	})
+
+	t.Run("no declared action skips the check", func(t *testing.T) {
+		service := newService(t, true)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "test-session-action",
+		})
+
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+	})
+
+	t.Run("disabled check ignores the declared action", func(t *testing.T) {
+		service := newService(t, false)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "test-session-action",
+			Action:    services.ActionTurnLeft,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+	})
+}
+
+// createPanningFrames renders a fixed random texture moved by (stepX, stepY)
+// pixels between consecutive frames.
+func createPanningFrames(count, stepX, stepY int) []image.Image {
+	const width, height, block = 320, 240, 8
+
+	rng := rand.New(rand.NewSource(42))
+	cols, rows := (width+200)/block, (height+200)/block
+	texture := make([]uint8, cols*rows)
+	for i := range texture {
+		texture[i] = uint8(rng.Intn(256))
+	}
+
+	frames := make([]image.Image, 0, count)
+	for i := 0; i < count; i++ {
+		frame := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				tx := x - i*stepX + 100
+				ty := y - i*stepY + 100
+				frame.SetGray(x, y, color.Gray{Y: texture[(ty/block)*cols+tx/block]})
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// gateDecoder blocks every capture until the gate opens, keeping the
+// verification holding its admission slot.
+type gateDecoder struct {
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (d *gateDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	d.started <- struct{}{}
+	<-d.gate
+	return nil, io.ErrUnexpectedEOF
+}
+
+func TestAdmissionQueue(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:     0.5,
+		SimilarityThreshold:   0.75,
+		StoragePath:           t.TempDir(),
+		EncryptionKey:         "test-encryption-key-for-testing-only",
+		MaxConcurrentRequests: 1,
+		RequestQueueDepth:     1,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	decoder := &gateDecoder{started: make(chan struct{}, 2), gate: make(chan struct{})}
+	service.SetFrameDecoder(decoder)
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	var wg sync.WaitGroup
+	verify := func(sessionID string) {
+		defer wg.Done()
+		service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: sessionID,
+		})
+	}
+
+	// One verification holds the only slot, a second waits for it
+	queuedBefore := metrics.RequestsQueued.Value()
+	wg.Add(2)
+	go verify("admission-running")
+	<-decoder.started
+	go verify("admission-queued")
+	require.Eventually(t, func() bool {
+		return metrics.RequestsQueued.Value() == queuedBefore+1
+	}, time.Second, 10*time.Millisecond)
+
+	t.Run("requests beyond the queue are shed with 503", func(t *testing.T) {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video": createTestVideoFile(),
+		})
+		require.NoError(t, err)
+
+		start := time.Now()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		assert.Less(t, time.Since(start), time.Second, "shedding must not wait for a slot")
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "SERVER_BUSY", response["code"])
+	})
+
+	t.Run("queued requests run once a slot frees up", func(t *testing.T) {
+		close(decoder.gate)
+		<-decoder.started
+		wg.Wait()
+
+		_, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "admission-after",
+		})
+		assert.NotErrorIs(t, err, services.ErrServerBusy)
+	})
+}
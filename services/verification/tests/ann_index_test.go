@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func randomVector(rng *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = float32(rng.NormFloat64())
+	}
+	return v
+}
+
+// noisyCopy perturbs v slightly, like a second capture of the same face.
+func noisyCopy(rng *rand.Rand, v []float32) []float32 {
+	out := make([]float32, len(v))
+	for i := range v {
+		out[i] = v[i] + float32(rng.NormFloat64()*0.1)
+	}
+	return out
+}
+
+func TestGalleryANNIndex(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	rng := rand.New(rand.NewSource(42))
+
+	const users, dim = 2000, 128
+	store := &memoryVectorStore{vectors: make(map[string][]models.FaceVector)}
+	enrolled := make(map[string][]float32, users)
+	created := time.Now().Add(-time.Hour)
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("ann-user-%d", i)
+		enrolled[userID] = randomVector(rng, dim)
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    userID,
+			Vector:    enrolled[userID],
+			CreatedAt: created,
+			Version:   "1.0",
+		}))
+	}
+
+	newService := func(annEnabled bool) *services.FaceVerificationService {
+		service, err := services.NewFaceVerificationService(logger, &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			AnnIndexEnabled:     annEnabled,
+		})
+		require.NoError(t, err)
+		require.NoError(t, service.SetVectorStore(store))
+		return service
+	}
+
+	indexed := newService(true)
+	defer indexed.Close()
+	exact := newService(false)
+	defer exact.Close()
+
+	t.Run("index matches the exact scan", func(t *testing.T) {
+		hits := 0
+		const queries = 100
+		for i := 0; i < queries; i++ {
+			userID := fmt.Sprintf("ann-user-%d", rng.Intn(users))
+			query := noisyCopy(rng, enrolled[userID])
+
+			want := exact.SearchGallery(query, 1)
+			require.Len(t, want, 1)
+			assert.Equal(t, userID, want[0].UserID)
+
+			got := indexed.SearchGallery(query, 5)
+			require.NotEmpty(t, got)
+			if got[0].UserID == userID {
+				hits++
+				assert.InDelta(t, want[0].Similarity, got[0].Similarity, 1e-6)
+			}
+			for j := 1; j < len(got); j++ {
+				assert.GreaterOrEqual(t, got[j-1].Similarity, got[j].Similarity)
+			}
+		}
+		assert.GreaterOrEqual(t, hits, queries*95/100, "recall@1 too low")
+	})
+
+	lateVector := randomVector(rng, dim)
+
+	t.Run("new enrollments are indexed on reload", func(t *testing.T) {
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    "ann-late-user",
+			Vector:    lateVector,
+			CreatedAt: created,
+			Version:   "1.0",
+		}))
+		require.NoError(t, indexed.SetVectorStore(store))
+
+		got := indexed.SearchGallery(noisyCopy(rng, lateVector), 1)
+		require.Len(t, got, 1)
+		assert.Equal(t, "ann-late-user", got[0].UserID)
+	})
+
+	t.Run("deleted enrollments are not returned", func(t *testing.T) {
+		require.NoError(t, store.Delete("ann-late-user"))
+		require.NoError(t, indexed.SetVectorStore(store))
+
+		for _, match := range indexed.SearchGallery(lateVector, 10) {
+			assert.NotEqual(t, "ann-late-user", match.UserID)
+		}
+	})
+
+	t.Run("one match per user", func(t *testing.T) {
+		userID := "ann-user-7"
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    userID,
+			Vector:    noisyCopy(rng, enrolled[userID]),
+			CreatedAt: created.Add(time.Minute),
+			Version:   "1.0",
+		}))
+		require.NoError(t, indexed.SetVectorStore(store))
+
+		seen := make(map[string]bool)
+		for _, match := range indexed.SearchGallery(enrolled[userID], 10) {
+			assert.False(t, seen[match.UserID], "duplicate user %s", match.UserID)
+			seen[match.UserID] = true
+		}
+		assert.True(t, seen[userID])
+	})
+
+	t.Run("erasure updates the index without a reload", func(t *testing.T) {
+		_, err := indexed.EraseUser("ann-user-7")
+		require.NoError(t, err)
+
+		for _, match := range indexed.SearchGallery(enrolled["ann-user-7"], 10) {
+			assert.NotEqual(t, "ann-user-7", match.UserID)
+		}
+		got := indexed.SearchGallery(noisyCopy(rng, enrolled["ann-user-8"]), 1)
+		require.Len(t, got, 1)
+		assert.Equal(t, "ann-user-8", got[0].UserID)
+	})
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestAsyncVerification(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	storageDir := t.TempDir()
+	statePath := filepath.Join(storageDir, "async_jobs.json")
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         storageDir,
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AsyncWorkers:        2,
+		AsyncQueueSize:      10,
+		AsyncJobStatePath:   statePath,
+	}
+
+	newRouter := func(t *testing.T, cfg *config.Config) (*services.FaceVerificationService, *gin.Engine) {
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return service, router
+	}
+
+	submit := func(router *gin.Engine, mode string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video": createTestVideoFile(),
+			"mode":  mode,
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	status := func(router *gin.Engine, verificationID string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/status/"+verificationID, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	service, router := newRouter(t, cfg)
+	var completedID string
+
+	t.Run("async request returns 202 and completes in the background", func(t *testing.T) {
+		w, response := submit(router, "async")
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		data := response["data"].(map[string]interface{})
+		completedID = data["verification_id"].(string)
+		assert.Equal(t, "pending", data["status"])
+		assert.Equal(t, "/api/v1/status/"+completedID, data["status_url"])
+		assert.Equal(t, "/api/v1/status/"+completedID, w.Header().Get("Location"))
+
+		require.Eventually(t, func() bool {
+			return status(router, completedID)["status"] == "completed"
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, true, status(router, completedID)["verified"])
+	})
+
+	t.Run("job state is persisted", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			data, err := os.ReadFile(statePath)
+			if err != nil {
+				return false
+			}
+			var records []models.VerificationRecord
+			if json.Unmarshal(data, &records) != nil || len(records) != 1 {
+				return false
+			}
+			return records[0].ID == completedID && records[0].Status == models.StatusCompleted
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("sync remains the default", func(t *testing.T) {
+		w, response := submit(router, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, response["success"])
+
+		w, response = submit(router, "batch")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_MODE", response["code"])
+	})
+
+	t.Run("restart keeps finished jobs and fails interrupted ones", func(t *testing.T) {
+		service.Close()
+
+		// Simulate a job that was still queued when the process died
+		data, err := os.ReadFile(statePath)
+		require.NoError(t, err)
+		var records []models.VerificationRecord
+		require.NoError(t, json.Unmarshal(data, &records))
+		records = append(records, models.VerificationRecord{
+			ID:        "ver_interruptedJob01",
+			Status:    models.StatusPending,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		data, err = json.Marshal(records)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(statePath, data, 0600))
+
+		restarted, router := newRouter(t, cfg)
+		defer restarted.Close()
+
+		assert.Equal(t, "completed", status(router, completedID)["status"])
+
+		interrupted := status(router, "ver_interruptedJob01")
+		assert.Equal(t, "failed", interrupted["status"])
+		assert.Equal(t, "interrupted by service restart", interrupted["error_message"])
+	})
+
+	t.Run("disabled async mode returns 501", func(t *testing.T) {
+		disabled := *cfg
+		disabled.AsyncWorkers = 0
+		disabled.AsyncJobStatePath = ""
+
+		service, router := newRouter(t, &disabled)
+		defer service.Close()
+
+		w, response := submit(router, "async")
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Equal(t, "ASYNC_DISABLED", response["code"])
+	})
+}
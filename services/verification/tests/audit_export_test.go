@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestAuditExport(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "test-admin-key",
+		Region:              "eu-west-1",
+		AuditSigningKey:     "test-audit-signing-key",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	// Seed three records
+	start := time.Now().UTC().Add(-time.Second)
+	var seeded []string
+	for _, userID := range []string{"audit-user-1", "audit-user-2", "audit-user-3"} {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": userID,
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		seeded = append(seeded, response["data"].(map[string]interface{})["verification_id"].(string))
+	}
+	end := time.Now().UTC().Add(time.Second)
+
+	export := func(query url.Values, adminKey string) (*httptest.ResponseRecorder, *models.AuditExport, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/admin/audit/export?"+query.Encode(), nil)
+		if adminKey != "" {
+			req.Header.Set("X-Admin-Key", adminKey)
+		}
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			var errBody map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errBody))
+			return w, nil, errBody
+		}
+		var response struct {
+			Data models.AuditExport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, &response.Data, nil
+	}
+
+	inRange := url.Values{
+		"from": {start.Format(time.RFC3339)},
+		"to":   {end.Format(time.RFC3339)},
+	}
+
+	t.Run("csv export has the expected columns and rows", func(t *testing.T) {
+		w, result, _ := export(inRange, "test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		rows, err := csv.NewReader(strings.NewReader(result.Content)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Equal(t, services.DefaultAuditFields, rows[0])
+
+		for i, row := range rows[1:] {
+			assert.Equal(t, seeded[i], row[0])
+			assert.Equal(t, "eu-west-1", row[5])
+		}
+		assert.NotContains(t, rows[0], "confidence")
+		assert.NotContains(t, rows[0], "liveness_score")
+
+		assert.Equal(t, "csv", result.Manifest.Format)
+		assert.Equal(t, 3, result.Manifest.RecordCount)
+	})
+
+	t.Run("manifest hash and signature verify", func(t *testing.T) {
+		_, result, _ := export(inRange, "test-admin-key")
+
+		sum := sha256.Sum256([]byte(result.Content))
+		assert.Equal(t, hex.EncodeToString(sum[:]), result.Manifest.ContentSHA256)
+		assert.NotEmpty(t, result.Manifest.Signature)
+		assert.True(t, services.VerifyAuditExport(result, "test-audit-signing-key"))
+		assert.False(t, services.VerifyAuditExport(result, "wrong-key"))
+
+		// Attribute the first record to another user
+		rows, err := csv.NewReader(strings.NewReader(result.Content)).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, "user_id", rows[0][2])
+		rows[1][2] = "someone-else"
+		var edited strings.Builder
+		require.NoError(t, csv.NewWriter(&edited).WriteAll(rows))
+		tampered := *result
+		tampered.Content = edited.String()
+		require.NotEqual(t, result.Content, tampered.Content)
+		assert.False(t, services.VerifyAuditExport(&tampered, "test-audit-signing-key"))
+
+		tampered = *result
+		tampered.Manifest.RecordCount = 2
+		assert.False(t, services.VerifyAuditExport(&tampered, "test-audit-signing-key"))
+	})
+
+	t.Run("json export with selected fields", func(t *testing.T) {
+		query := url.Values{"format": {"json"}, "fields": {"verification_id,user_id"}}
+		w, result, _ := export(query, "test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var rows []map[string]string
+		require.NoError(t, json.Unmarshal([]byte(result.Content), &rows))
+		require.Len(t, rows, 3)
+		for i, row := range rows {
+			assert.Len(t, row, 2)
+			assert.Equal(t, seeded[i], row["verification_id"])
+		}
+		assert.Equal(t, []string{"verification_id", "user_id"}, result.Manifest.Fields)
+	})
+
+	t.Run("date range outside the records is empty", func(t *testing.T) {
+		query := url.Values{
+			"from": {start.Add(-48 * time.Hour).Format(time.RFC3339)},
+			"to":   {start.Add(-24 * time.Hour).Format(time.RFC3339)},
+		}
+		w, result, _ := export(query, "test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, 0, result.Manifest.RecordCount)
+		assert.Equal(t, strings.Join(services.DefaultAuditFields, ",")+"\n", result.Content)
+	})
+
+	t.Run("biometric fields are rejected", func(t *testing.T) {
+		w, _, body := export(url.Values{"fields": {"verification_id,liveness_score"}}, "test-admin-key")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_FIELDS", body["code"])
+	})
+
+	t.Run("invalid range and format are rejected", func(t *testing.T) {
+		w, _, body := export(url.Values{"from": {end.Format(time.RFC3339)}, "to": {start.Format(time.RFC3339)}}, "test-admin-key")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_DATE_RANGE", body["code"])
+
+		w, _, body = export(url.Values{"format": {"xml"}}, "test-admin-key")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_FORMAT", body["code"])
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		w, _, _ := export(inRange, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
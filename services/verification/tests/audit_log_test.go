@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestFileAuditLog(t *testing.T) {
+	log, err := storage.NewFileAuditLog(filepath.Join(t.TempDir(), "audit.log"), time.Second)
+	require.NoError(t, err)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, operation := range []models.AuditOperation{models.AuditRegister, models.AuditVerify, models.AuditVerify, models.AuditDelete} {
+		require.NoError(t, log.Append(models.AuditEvent{
+			ID:        string(rune('a' + i)),
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+			Operation: operation,
+			UserID:    "alice",
+			Result:    models.AuditSuccess,
+		}))
+	}
+
+	t.Run("newest first", func(t *testing.T) {
+		events, err := log.Query(storage.AuditQuery{})
+		require.NoError(t, err)
+		require.Len(t, events, 4)
+		assert.Equal(t, "d", events[0].ID)
+	})
+
+	t.Run("time range is inclusive from and exclusive to", func(t *testing.T) {
+		events, err := log.Query(storage.AuditQuery{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "c", events[0].ID)
+		assert.Equal(t, "b", events[1].ID)
+	})
+
+	t.Run("operation filter and limit", func(t *testing.T) {
+		events, err := log.Query(storage.AuditQuery{Operation: models.AuditVerify, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "c", events[0].ID)
+	})
+}
+
+func TestVerificationHandler_AuditLog(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "test-admin-key",
+		AuditLogEnabled:     true,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	post := func(path string, fields map[string]interface{}) {
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", path, body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-API-Key", "client-api-key")
+		router.ServeHTTP(w, req)
+	}
+
+	query := func(t *testing.T, params string) []models.AuditEvent {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/admin/audit"+params, nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Items []models.AuditEvent `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Items
+	}
+
+	post("/api/v1/register", map[string]interface{}{"video": createTestVideoFile(), "user_id": "audited-user"})
+	post("/api/v1/verify", map[string]interface{}{"video": createTestVideoFile(), "user_id": "audited-user"})
+	post("/api/v1/verify", map[string]interface{}{"video": createTestVideoFile(), "user_id": "other-user"})
+
+	t.Run("biometric operations are recorded per user", func(t *testing.T) {
+		events := query(t, "?user_id=audited-user")
+		require.Len(t, events, 2)
+
+		verify, register := events[0], events[1]
+		assert.Equal(t, models.AuditVerify, verify.Operation)
+		assert.NotEmpty(t, verify.VerificationID)
+		assert.Contains(t, []string{models.AuditVerified, models.AuditNotVerified}, verify.Result)
+		assert.Equal(t, models.AuditRegister, register.Operation)
+		assert.Equal(t, models.AuditSuccess, register.Result)
+
+		assert.Equal(t, services.ClientKeyFingerprint("client-api-key"), verify.ClientKey)
+		assert.NotContains(t, verify.ClientKey, "client-api-key")
+		assert.NotEmpty(t, verify.ClientIP)
+		assert.Equal(t, "http", verify.Transport)
+	})
+
+	t.Run("erasures are recorded and keep the trail", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("DELETE", "/api/v1/faces/audited-user", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		events := query(t, "?user_id=audited-user")
+		require.Len(t, events, 3)
+		assert.Equal(t, models.AuditDelete, events[0].Operation)
+		assert.True(t, events[0].Admin)
+	})
+
+	t.Run("filters by operation and time", func(t *testing.T) {
+		assert.Len(t, query(t, "?operation=verify"), 2)
+		assert.Empty(t, query(t, "?from="+time.Now().Add(time.Hour).UTC().Format(time.RFC3339)))
+	})
+
+	t.Run("invalid parameters are rejected", func(t *testing.T) {
+		for _, params := range []string{"?from=yesterday", "?operation=export", "?limit=0"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/admin/audit"+params, nil)
+			req.Header.Set("X-Admin-Key", "test-admin-key")
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, params)
+		}
+	})
+}
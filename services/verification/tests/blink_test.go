@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"image"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// scriptedLandmarks returns 68-point shapes whose eye openness follows
+// openness, one entry per frame, cycling for every capture.
+type scriptedLandmarks struct {
+	mu       sync.Mutex
+	openness []int
+	err      error
+	calls    int
+}
+
+func (d *scriptedLandmarks) Landmarks(frame image.Image) ([]image.Point, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	lid := d.openness[d.calls%len(d.openness)]
+	d.calls++
+
+	points := make([]image.Point, 68)
+	for _, base := range []int{36, 42} {
+		x := base * 2
+		points[base+0] = image.Pt(x, 100)
+		points[base+1] = image.Pt(x+10, 100-lid)
+		points[base+2] = image.Pt(x+20, 100-lid)
+		points[base+3] = image.Pt(x+30, 100)
+		points[base+4] = image.Pt(x+20, 100+lid)
+		points[base+5] = image.Pt(x+10, 100+lid)
+	}
+	return points, nil
+}
+
+func TestBlinkLivenessComponent(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	service, err := services.NewFaceVerificationService(logger, &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		BlinkWeight:         0.5,
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	livenessScore := func(t *testing.T, detector services.LandmarkDetector) float64 {
+		service.SetLandmarkDetector(detector)
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "blink-session",
+		})
+		require.NoError(t, err)
+		return result.LivenessScore
+	}
+
+	passive := livenessScore(t, nil)
+
+	t.Run("blink raises the score by its weight", func(t *testing.T) {
+		// Open (EAR 0.33), closed (0.07) mid-capture, open again
+		score := livenessScore(t, &scriptedLandmarks{openness: []int{5, 5, 1, 5, 5}})
+		assert.InDelta(t, passive*0.5+0.5, score, 1e-9)
+	})
+
+	t.Run("eyes that never close score no blink", func(t *testing.T) {
+		score := livenessScore(t, &scriptedLandmarks{openness: []int{5, 5, 5, 5, 5}})
+		assert.InDelta(t, passive*0.5, score, 1e-9)
+	})
+
+	t.Run("eyes closed until the end are not a blink", func(t *testing.T) {
+		score := livenessScore(t, &scriptedLandmarks{openness: []int{5, 5, 1, 1, 1}})
+		assert.InDelta(t, passive*0.5, score, 1e-9)
+	})
+
+	t.Run("models without eye points leave the passive score", func(t *testing.T) {
+		score := livenessScore(t, &scriptedLandmarks{err: services.ErrNoEyeLandmarks})
+		assert.InDelta(t, passive, score, 1e-9)
+	})
+}
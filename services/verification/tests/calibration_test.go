@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestCalibrationMap(t *testing.T) {
+	calibration, err := services.ParseCalibrationMap("0.5:0.1, 0.7:0.5, 0.9:0.95")
+	require.NoError(t, err)
+	require.NotNil(t, calibration)
+
+	t.Run("knots map exactly", func(t *testing.T) {
+		assert.InDelta(t, 0.1, calibration.Apply(0.5), 1e-9)
+		assert.InDelta(t, 0.5, calibration.Apply(0.7), 1e-9)
+		assert.InDelta(t, 0.95, calibration.Apply(0.9), 1e-9)
+	})
+
+	t.Run("interpolates between knots", func(t *testing.T) {
+		assert.InDelta(t, 0.3, calibration.Apply(0.6), 1e-9)
+		assert.InDelta(t, 0.725, calibration.Apply(0.8), 1e-9)
+	})
+
+	t.Run("clamps outside range", func(t *testing.T) {
+		assert.InDelta(t, 0.1, calibration.Apply(0.0), 1e-9)
+		assert.InDelta(t, 0.95, calibration.Apply(1.0), 1e-9)
+	})
+
+	t.Run("unset map is bypassed", func(t *testing.T) {
+		unset, err := services.ParseCalibrationMap("")
+		require.NoError(t, err)
+		assert.Nil(t, unset)
+		assert.Equal(t, 0.83, unset.Apply(0.83))
+	})
+
+	t.Run("rejects non-monotonic curve", func(t *testing.T) {
+		_, err := services.ParseCalibrationMap("0.5:0.6,0.7:0.4")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects malformed points", func(t *testing.T) {
+		_, err := services.ParseCalibrationMap("0.5-0.6,0.7:0.4")
+		assert.Error(t, err)
+
+		_, err = services.ParseCalibrationMap("0.5:0.6")
+		assert.Error(t, err)
+	})
+}
+
+func TestFaceVerificationService_ConfidenceCalibration(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	videoData := createTestVideoData()
+
+	verifyEnrolled := func(t *testing.T, curve string) *models.VerificationResult {
+		cfg := &config.Config{
+			LivenessThreshold:     0.5,
+			SimilarityThreshold:   0.75,
+			StoragePath:           t.TempDir(),
+			EncryptionKey:         "test-encryption-key-for-testing-only",
+			ConfidenceCalibration: curve,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		require.NoError(t, service.RegisterFace("test-user-calibration", videoData))
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: videoData,
+			UserID:    "test-user-calibration",
+			SessionID: "test-session-calibration",
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("bypassed when unset", func(t *testing.T) {
+		result := verifyEnrolled(t, "")
+
+		assert.Equal(t, result.RawConfidence, result.Confidence)
+	})
+
+	t.Run("calibrated confidence with raw score retained", func(t *testing.T) {
+		result := verifyEnrolled(t, "0.0:0.0,1.0:0.5")
+
+		assert.Greater(t, result.RawConfidence, 0.0)
+		assert.InDelta(t, result.RawConfidence*0.5, result.Confidence, 1e-9)
+	})
+
+	t.Run("invalid curve fails service startup", func(t *testing.T) {
+		cfg := &config.Config{ConfidenceCalibration: "0.9:0.1,0.5:0.8"}
+
+		_, err := services.NewFaceVerificationService(logger, cfg)
+		assert.Error(t, err)
+	})
+}
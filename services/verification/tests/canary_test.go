@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// rejectingCanary is a candidate pipeline that rejects everything.
+type rejectingCanary struct{}
+
+func (rejectingCanary) Name() string { return "rejecting" }
+
+func (rejectingCanary) Evaluate(input services.CanaryInput) (bool, error) {
+	return false, nil
+}
+
+func TestFaceVerificationService_Canary(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, fraction float64) *services.FaceVerificationService {
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			CanaryFraction:      fraction,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		service.SetCanaryPipeline(rejectingCanary{})
+		return service
+	}
+
+	verify := func(t *testing.T, service *services.FaceVerificationService, n int) []*models.VerificationResult {
+		results := make([]*models.VerificationResult, 0, n)
+		for i := 0; i < n; i++ {
+			result, err := service.VerifyVideo(&models.VerificationRequest{
+				VideoData: createTestVideoData(),
+				SessionID: "test-session-canary",
+			})
+			require.NoError(t, err)
+			results = append(results, result)
+		}
+		return results
+	}
+
+	t.Run("disagreements recorded without changing the decision", func(t *testing.T) {
+		service := newService(t, 1.0)
+
+		results := verify(t, service, 5)
+
+		for _, result := range results {
+			assert.True(t, result.Verified, "stable pipeline decision must win")
+		}
+		assert.Eventually(t, func() bool {
+			return service.CanaryStats().Runs == 5
+		}, 5*time.Second, 10*time.Millisecond)
+		stats := service.CanaryStats()
+		assert.Equal(t, int64(5), stats.Disagreements)
+		assert.Equal(t, int64(0), stats.Agreements)
+	})
+
+	t.Run("disabled at zero fraction", func(t *testing.T) {
+		service := newService(t, 0)
+
+		verify(t, service, 5)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int64(0), service.CanaryStats().Runs)
+	})
+
+	t.Run("runs for the sampled fraction", func(t *testing.T) {
+		service := newService(t, 0.5)
+
+		verify(t, service, 40)
+
+		time.Sleep(100 * time.Millisecond)
+		runs := service.CanaryStats().Runs
+		assert.GreaterOrEqual(t, runs, int64(8))
+		assert.LessOrEqual(t, runs, int64(32))
+	})
+
+	t.Run("threshold candidate from config", func(t *testing.T) {
+		cfg := &config.Config{
+			LivenessThreshold:       0.5,
+			SimilarityThreshold:     0.75,
+			StoragePath:             t.TempDir(),
+			EncryptionKey:           "test-encryption-key-for-testing-only",
+			CanaryFraction:          1.0,
+			CanaryLivenessThreshold: 0.99,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		results := verify(t, service, 2)
+
+		assert.True(t, results[0].Verified)
+		assert.Eventually(t, func() bool {
+			return service.CanaryStats().Disagreements == 2
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("score deltas of a scoring candidate", func(t *testing.T) {
+		service := newService(t, 1.0)
+		service.SetCanaryPipeline(scoringCanary{})
+
+		verify(t, service, 3)
+
+		assert.Eventually(t, func() bool {
+			return service.CanaryStats().Agreements == 3
+		}, 5*time.Second, 10*time.Millisecond)
+		stats := service.CanaryStats()
+		assert.Equal(t, int64(3), stats.LivenessDeltas)
+		assert.InDelta(t, -0.1, stats.MeanLivenessDelta, 1e-9)
+		assert.Zero(t, stats.SimilarityDeltas)
+	})
+
+	t.Run("candidate recognizer compared on pairs of captures", func(t *testing.T) {
+		// The current models as candidate must agree with themselves
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			CanaryFraction:      1.0,
+			CanaryFaceModelPath: ".",
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		videoData := createTestVideoFile().data
+		require.NoError(t, service.RegisterFace("canary-user", videoData))
+		for i := 0; i < 2; i++ {
+			result, err := service.VerifyVideo(&models.VerificationRequest{
+				VideoData: videoData,
+				UserID:    "canary-user",
+			})
+			require.NoError(t, err)
+			require.True(t, result.Verified)
+			// The second capture pairs with the first once that is scored;
+			// the enrollment was scored too
+			require.Eventually(t, func() bool {
+				stats := service.CanaryStats()
+				return stats.Agreements+stats.Disagreements == int64(i+2)
+			}, 5*time.Second, 10*time.Millisecond)
+		}
+
+		stats := service.CanaryStats()
+		assert.Zero(t, stats.Errors)
+		assert.Equal(t, int64(1), stats.SimilarityDeltas)
+		assert.InDelta(t, 0, stats.MeanSimilarityDelta, 1e-6)
+	})
+}
+
+// scoringCanary is a candidate model that scores liveness a little lower
+// and decides like the stable pipeline.
+type scoringCanary struct{}
+
+func (scoringCanary) Name() string { return "scoring" }
+
+func (c scoringCanary) Evaluate(input services.CanaryInput) (bool, error) {
+	scores, err := c.Score(input)
+	return scores.Verified, err
+}
+
+func (scoringCanary) Score(input services.CanaryInput) (services.CanaryScores, error) {
+	return services.CanaryScores{
+		Verified:       input.Verified,
+		LivenessDelta:  -0.1,
+		LivenessScored: true,
+	}, nil
+}
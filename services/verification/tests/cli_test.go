@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/cli"
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestCLI(t *testing.T) {
+	const key = "test-encryption-key-for-testing-only"
+
+	// A storage path with enrollments, configured through the environment
+	// as an operator would
+	setup := func(t *testing.T, vectors ...models.FaceVector) string {
+		dir := t.TempDir()
+		t.Setenv("STORAGE_PATH", dir)
+		t.Setenv("ENCRYPTION_KEY", key)
+		t.Setenv("ENCRYPTION_KEY_ID", "1")
+		t.Setenv("ENCRYPTION_PREVIOUS_KEYS", "")
+		t.Setenv("FACE_MODEL_PATH", t.TempDir())
+
+		store, err := storage.NewEncryptedFileVectorStore(dir, key, 10*time.Second)
+		require.NoError(t, err)
+		for _, vector := range vectors {
+			require.NoError(t, store.Save(vector))
+		}
+		return dir
+	}
+	enrollment := func(userID string, vector ...float32) models.FaceVector {
+		return models.FaceVector{UserID: userID, Vector: vector, CreatedAt: time.Now().UTC(), Version: "1.0"}
+	}
+
+	noServe := func(logger *zap.Logger, cfg *config.Config) error {
+		t.Fatal("serve should not run")
+		return nil
+	}
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := cli.Run(args, noServe, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("serves by default", func(t *testing.T) {
+		setup(t)
+		served := false
+		code := cli.Run(nil, func(logger *zap.Logger, cfg *config.Config) error {
+			served = true
+			assert.Equal(t, key, cfg.EncryptionKey)
+			return nil
+		}, &bytes.Buffer{}, &bytes.Buffer{})
+		assert.Equal(t, 0, code)
+		assert.True(t, served)
+	})
+
+	t.Run("unknown commands print the usage", func(t *testing.T) {
+		code, _, stderr := run("frobnicate")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "rotate-key")
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		dir := setup(t, enrollment("alice", 1, 0), enrollment("alice", 0, 1), enrollment("bob", 1, 1))
+
+		code, stdout, stderr := run("migrate")
+		require.Equal(t, 0, code, stderr)
+
+		var migration models.StorageMigration
+		require.NoError(t, json.Unmarshal([]byte(stdout), &migration))
+		assert.Equal(t, 2, migration.Users)
+		assert.Equal(t, 3, migration.Enrollments)
+		assert.NoFileExists(t, filepath.Join(dir, "face_vectors.enc.log"))
+	})
+
+	t.Run("reindex", func(t *testing.T) {
+		setup(t, enrollment("alice", 1, 0, 0), enrollment("bob", 0, 1, 0))
+
+		code, stdout, stderr := run("reindex")
+		require.Equal(t, 0, code, stderr)
+
+		var rebuild models.IndexRebuild
+		require.NoError(t, json.Unmarshal([]byte(stdout), &rebuild))
+		assert.Equal(t, 2, rebuild.Users)
+		assert.Equal(t, 2, rebuild.Enrollments)
+		assert.Equal(t, 3, rebuild.Dimensions)
+		assert.Empty(t, rebuild.Skipped)
+	})
+
+	t.Run("reindex fails on enrollments it cannot index", func(t *testing.T) {
+		setup(t,
+			enrollment("alice", 1, 0, 0),
+			enrollment("bob", 0, 1, 0),
+			enrollment("carol", 0, 1),
+			enrollment("dave", 0, 0, 0))
+
+		code, stdout, _ := run("reindex")
+		assert.Equal(t, 1, code)
+
+		var rebuild models.IndexRebuild
+		require.NoError(t, json.Unmarshal([]byte(stdout), &rebuild))
+		assert.Equal(t, 2, rebuild.Enrollments)
+		require.Len(t, rebuild.Skipped, 2)
+		assert.Contains(t, rebuild.Skipped[0], "carol@")
+		assert.Contains(t, rebuild.Skipped[1], "dave@")
+	})
+
+	t.Run("export", func(t *testing.T) {
+		setup(t, enrollment("alice", 1, 0), enrollment("bob", 1, 1))
+
+		code, stdout, stderr := run("export", "--user", "alice")
+		require.Equal(t, 0, code, stderr)
+
+		var export models.EnrollmentExport
+		require.NoError(t, json.Unmarshal([]byte(stdout), &export))
+		assert.Equal(t, "alice", export.UserID)
+		require.Len(t, export.Enrollments, 1)
+		assert.Equal(t, []float32{1, 0}, export.Enrollments[0].Vector)
+	})
+
+	t.Run("export to a file", func(t *testing.T) {
+		setup(t, enrollment("alice", 1, 0))
+		output := filepath.Join(t.TempDir(), "alice.json")
+
+		code, stdout, stderr := run("export", "--user", "alice", "--output", output)
+		require.Equal(t, 0, code, stderr)
+		assert.Empty(t, stdout)
+
+		info, err := os.Stat(output)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("export needs a user with enrollments", func(t *testing.T) {
+		setup(t, enrollment("alice", 1, 0))
+
+		code, _, _ := run("export")
+		assert.Equal(t, 2, code)
+
+		code, _, stderr := run("export", "--user", "nobody")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, cli.ErrNoEnrollments.Error())
+	})
+
+	t.Run("rotate-key", func(t *testing.T) {
+		dir := setup(t, enrollment("alice", 1, 0))
+		t.Setenv("ENCRYPTION_KEY", "a-new-encryption-key")
+		t.Setenv("ENCRYPTION_KEY_ID", "2")
+		t.Setenv("ENCRYPTION_PREVIOUS_KEYS", "1:"+key)
+
+		code, stdout, stderr := run("rotate-key")
+		require.Equal(t, 0, code, stderr)
+
+		var rotation models.KeyRotation
+		require.NoError(t, json.Unmarshal([]byte(stdout), &rotation))
+		assert.Equal(t, "2", rotation.KeyID)
+		assert.Equal(t, "1", rotation.PreviousKeyID)
+		assert.Equal(t, 1, rotation.Enrollments)
+
+		// Readable under the new key alone
+		rotated, err := storage.NewEncryptedFileVectorStoreWithKeys(dir, storage.KeyRing{
+			Current: storage.EncryptionKey{ID: "2", Secret: "a-new-encryption-key"},
+		}, 10*time.Second)
+		require.NoError(t, err)
+		alice, err := rotated.Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, alice, 1)
+	})
+}
@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/tenant"
+	"connect-hub/verification-service/internal/tlsconfig"
+)
+
+// testCA issues certificates for the client certificate tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "connect-hub test CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue signs a certificate completing template, returned as a TLS key pair.
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) string {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestClientCertificates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+	dir := t.TempDir()
+
+	ca := newTestCA(t)
+	server := ca.issue(t, &x509.Certificate{
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	serverKey, err := x509.MarshalPKCS8PrivateKey(server.PrivateKey)
+	require.NoError(t, err)
+	cfg := &config.Config{
+		TLSCertFile:     writePEM(t, filepath.Join(dir, "tls.crt"), "CERTIFICATE", server.Certificate[0]),
+		TLSKeyFile:      writePEM(t, filepath.Join(dir, "tls.key"), "PRIVATE KEY", serverKey),
+		TLSClientCAFile: writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", ca.cert.Raw),
+		TLSClientAuth:   "optional",
+	}
+
+	identities, err := tlsconfig.ParseClientIdentities("spiffe://connect-hub/billing=tenant:billing, ops.connect-hub.internal=admin")
+	require.NoError(t, err)
+	registry, err := tenant.NewRegistry(tenant.Config{APIKeys: "billing:billing-key,acme:acme-key"})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware.ClientCertificate(identities))
+	api := router.Group("/api", middleware.Tenant(registry, "admin-key"))
+	api.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"tenant":   middleware.TenantOf(c),
+			"identity": c.GetString(middleware.ClientIdentityContextKey),
+		})
+	})
+	api.GET("/admin", middleware.RequireAdmin("admin-key"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// serve starts an HTTPS server for cfg
+	serve := func(t *testing.T, cfg *config.Config) string {
+		tlsConfig, _, err := tlsconfig.New(cfg, logger)
+		require.NoError(t, err)
+		return serveTLS(t, router, tlsConfig)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	request := func(serverURL, path string, clientCert *tls.Certificate, headers map[string]string) (*http.Response, error) {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if clientCert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*clientCert}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		req, err := http.NewRequest("GET", serverURL+path, nil)
+		require.NoError(t, err)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			t.Cleanup(func() { resp.Body.Close() })
+		}
+		return resp, err
+	}
+	clientCert := func(template *x509.Certificate) *tls.Certificate {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		cert := ca.issue(t, template)
+		return &cert
+	}
+
+	billingURI, err := url.Parse("spiffe://connect-hub/billing")
+	require.NoError(t, err)
+	billing := clientCert(&x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, URIs: []*url.URL{billingURI}})
+	ops := clientCert(&x509.Certificate{Subject: pkix.Name{CommonName: "ops"}, DNSNames: []string{"ops.connect-hub.internal"}})
+	unlisted := clientCert(&x509.Certificate{Subject: pkix.Name{CommonName: "reporting"}})
+
+	serverURL := serve(t, cfg)
+
+	t.Run("a tenant certificate stands in for the tenant's API key", func(t *testing.T) {
+		resp, err := request(serverURL, "/api/whoami", billing, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"tenant": "billing", "identity": "spiffe://connect-hub/billing"}, body)
+
+		resp, err = request(serverURL, "/api/admin", billing, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("an admin certificate stands in for the admin key", func(t *testing.T) {
+		resp, err := request(serverURL, "/api/admin", ops, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, err = request(serverURL, "/api/whoami", ops, map[string]string{"X-Tenant-ID": "acme"})
+		require.NoError(t, err)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "acme", body["tenant"])
+	})
+
+	t.Run("API keys still work and take precedence", func(t *testing.T) {
+		resp, err := request(serverURL, "/api/whoami", nil, map[string]string{"X-API-Key": "acme-key"})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = request(serverURL, "/api/whoami", billing, map[string]string{"X-API-Key": "acme-key"})
+		require.NoError(t, err)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "acme", body["tenant"])
+	})
+
+	t.Run("callers without a listed identity get nothing", func(t *testing.T) {
+		for _, cert := range []*tls.Certificate{nil, unlisted} {
+			resp, err := request(serverURL, "/api/whoami", cert, nil)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("certificates from other CAs are refused", func(t *testing.T) {
+		other := newTestCA(t).issue(t, &x509.Certificate{
+			URIs:        []*url.URL{billingURI},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		_, err := request(serverURL, "/api/whoami", &other, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("require refuses callers without a certificate", func(t *testing.T) {
+		required := *cfg
+		required.TLSClientAuth = "require"
+		serverURL := serve(t, &required)
+
+		_, err := request(serverURL, "/api/whoami", nil, map[string]string{"X-API-Key": "acme-key"})
+		assert.Error(t, err)
+		resp, err := request(serverURL, "/api/whoami", billing, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("identities must map to known roles", func(t *testing.T) {
+		for _, spec := range []string{
+			"ops.connect-hub.internal",
+			"ops.connect-hub.internal=root",
+			"ops.connect-hub.internal=tenant:not valid",
+			"ops.connect-hub.internal=admin,ops.connect-hub.internal=tenant:acme",
+		} {
+			_, err := tlsconfig.ParseClientIdentities(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
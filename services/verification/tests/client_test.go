@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/pkg/client"
+)
+
+// clientAttempt is what a fake service saw of one request.
+type clientAttempt struct {
+	idempotencyKey string
+	apiKey         string
+	userID         string
+	video          []byte
+}
+
+func TestClientRetries(t *testing.T) {
+	var mu sync.Mutex
+	var attempts []clientAttempt
+	busy := 2
+	// reset forgets earlier attempts and fails the next busy ones
+	reset := func(n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts, busy = nil, n
+	}
+	seen := func() []clientAttempt {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		file, _, err := r.FormFile("video")
+		require.NoError(t, err)
+		video, _ := io.ReadAll(file)
+
+		mu.Lock()
+		attempts = append(attempts, clientAttempt{
+			idempotencyKey: r.Header.Get("Idempotency-Key"),
+			apiKey:         r.Header.Get("X-API-Key"),
+			userID:         r.FormValue("user_id"),
+			video:          video,
+		})
+		fail := len(attempts) <= busy
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"Too many verifications in progress, retry later","code":"SERVER_BUSY"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"verified":true,"user_id":"alice","confidence":0.93}}`))
+	}))
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithAPIKey("key-a"), client.WithRetries(3, time.Millisecond))
+	require.NoError(t, err)
+	capture := createTestVideoData()
+
+	t.Run("transient failures are retried with the same key", func(t *testing.T) {
+		result, err := c.Verify(context.Background(), client.VerifyRequest{
+			Video:  bytes.NewReader(capture),
+			UserID: "alice",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+		assert.Equal(t, "alice", result.UserID)
+
+		attempts := seen()
+		require.Len(t, attempts, 3)
+		assert.NotEmpty(t, attempts[0].idempotencyKey)
+		for _, attempt := range attempts {
+			assert.Equal(t, attempts[0].idempotencyKey, attempt.idempotencyKey)
+			assert.Equal(t, "key-a", attempt.apiKey)
+			assert.Equal(t, "alice", attempt.userID)
+			// The capture is rewound for every attempt
+			assert.Equal(t, capture, attempt.video)
+		}
+	})
+
+	t.Run("calls get their own keys", func(t *testing.T) {
+		reset(0)
+		for i := 0; i < 2; i++ {
+			_, err := c.Verify(context.Background(), client.VerifyRequest{Video: bytes.NewReader(capture), UserID: "alice"})
+			require.NoError(t, err)
+		}
+		attempts := seen()
+		require.Len(t, attempts, 2)
+		assert.NotEqual(t, attempts[0].idempotencyKey, attempts[1].idempotencyKey)
+
+		reset(0)
+		_, err := c.Verify(context.Background(), client.VerifyRequest{
+			Video:          bytes.NewReader(capture),
+			UserID:         "alice",
+			IdempotencyKey: "checkout-42",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "checkout-42", seen()[0].idempotencyKey)
+	})
+
+	t.Run("captures that cannot be rewound are sent once", func(t *testing.T) {
+		reset(2)
+		_, err := c.Verify(context.Background(), client.VerifyRequest{
+			Video:  io.MultiReader(bytes.NewReader(capture)),
+			UserID: "alice",
+		})
+		require.Error(t, err)
+		assert.Equal(t, "SERVER_BUSY", client.ErrorCode(err))
+		assert.Len(t, seen(), 1)
+	})
+
+	t.Run("retries stop with the context", func(t *testing.T) {
+		reset(10)
+		slow, err := client.New(server.URL, client.WithRetries(5, time.Hour))
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = slow.Verify(ctx, client.VerifyRequest{Video: bytes.NewReader(capture), UserID: "alice"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Len(t, seen(), 1)
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/api/v1/liveness/session":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"Monthly quota of verifications and registrations exceeded","code":"QUOTA_EXCEEDED"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream unavailable"))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(server.URL, client.WithRetries(3, time.Millisecond))
+	require.NoError(t, err)
+
+	// Quotas do not reset on retry
+	_, err = c.StartLivenessSession(context.Background())
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, "QUOTA_EXCEEDED", apiErr.Code)
+	assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Bodies that are not service errors keep their text
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.Status(context.Background(), "v-1")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Empty(t, client.ErrorCode(err))
+	assert.Equal(t, "upstream unavailable", apiErr.Message)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	_, err = client.New("verification:8080")
+	assert.Error(t, err)
+}
+
+func TestClientWaitForStatus(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/status/v-1", r.URL.Path)
+		status := map[string]interface{}{"verification_id": "v-1", "status": "processing"}
+		if atomic.AddInt32(&polls, 1) == 3 {
+			status["status"] = "completed"
+			status["verified"] = true
+		}
+		json.NewEncoder(w).Encode(status)
+	}))
+	defer server.Close()
+
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	status, err := c.WaitForStatus(context.Background(), "v-1", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+	assert.Equal(t, client.StatusCompleted, status.Status)
+	require.NotNil(t, status.Verified)
+	assert.True(t, *status.Verified)
+}
+
+func TestClientAgainstService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		StoragePath:        t.TempDir(),
+		EncryptionKey:      "test-encryption-key-for-testing-only",
+		EnrollmentDisabled: true,
+	}
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, zaptest.NewLogger(t)), cfg)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.Register(ctx, client.RegisterRequest{Video: bytes.NewReader(createTestVideoData()), UserID: "alice"})
+	assert.Equal(t, "ENROLLMENT_DISABLED", client.ErrorCode(err))
+
+	_, err = c.Identify(ctx, client.IdentifyRequest{Video: bytes.NewReader(createTestVideoData()), MaxResults: 500})
+	assert.Equal(t, "INVALID_MAX_RESULTS", client.ErrorCode(err))
+
+	_, err = c.Status(ctx, "ver_0000000000")
+	assert.Equal(t, "VERIFICATION_NOT_FOUND", client.ErrorCode(err))
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestVerificationHandler_CompareFaces(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:      0.5,
+		SimilarityThreshold:    0.75,
+		StoragePath:            t.TempDir(),
+		EncryptionKey:          "test-encryption-key-for-testing-only",
+		CompareMaxDocumentSize: 64 * 1024,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	compare := func(fields map[string]interface{}) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/compare", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	photo := encodeJPEGFrames(t, createPanningFrames(1, 0, 0))[0]
+	document := &fileData{filename: "id.jpg", contentType: "image/jpeg", data: photo}
+
+	t.Run("compares a selfie with a document photo", func(t *testing.T) {
+		w := compare(map[string]interface{}{"video": createTestVideoFile(), "document": document})
+		if w.Code == http.StatusUnprocessableEntity {
+			// The synthetic images may hold no detectable face
+			assert.Contains(t, []string{"NO_FACE_IN_VIDEO", "NO_FACE_IN_DOCUMENT"}, errorCode(t, w))
+			return
+		}
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data models.FaceComparison `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		comparison := response.Data
+		if comparison.Match {
+			assert.True(t, comparison.IsLive)
+			assert.GreaterOrEqual(t, comparison.Similarity, 0.75)
+			assert.Empty(t, comparison.Reason)
+		} else {
+			assert.Contains(t, []string{models.ReasonLivenessFailed, models.ReasonLowSimilarity}, comparison.Reason)
+		}
+	})
+
+	t.Run("requires both uploads", func(t *testing.T) {
+		w := compare(map[string]interface{}{"video": createTestVideoFile()})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "MISSING_DOCUMENT", errorCode(t, w))
+
+		w = compare(map[string]interface{}{"document": document})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "MISSING_VIDEO_FILE", errorCode(t, w))
+	})
+
+	t.Run("rejects documents that are not images", func(t *testing.T) {
+		w := compare(map[string]interface{}{
+			"video":    createTestVideoFile(),
+			"document": &fileData{filename: "id.pdf", contentType: "application/pdf", data: []byte("%PDF-1.7 not an image")},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_DOCUMENT", errorCode(t, w))
+	})
+
+	t.Run("rejects oversized documents", func(t *testing.T) {
+		w := compare(map[string]interface{}{
+			"video":    createTestVideoFile(),
+			"document": &fileData{filename: "id.jpg", contentType: "image/jpeg", data: make([]byte, 65*1024)},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "DOCUMENT_TOO_LARGE", errorCode(t, w))
+	})
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Code
+}
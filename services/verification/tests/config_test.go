@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"connect-hub/verification-service/internal/cli"
+	"connect-hub/verification-service/internal/config"
+)
+
+func TestConfigLoad(t *testing.T) {
+	// A valid environment, which each case then breaks or overrides
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		t.Setenv("FACE_MODEL_PATH", dir)
+		t.Setenv("STORAGE_PATH", dir)
+		t.Setenv("ENCRYPTION_KEY", "test-encryption-key-for-testing-only")
+		t.Setenv(config.ConfigFileEnv, "")
+		return dir
+	}
+	writeFile := func(t *testing.T, dir, name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		t.Setenv(config.ConfigFileEnv, path)
+		return path
+	}
+	problems := func(t *testing.T, err error) []string {
+		var validation *config.ValidationError
+		require.ErrorAs(t, err, &validation)
+		return validation.Problems
+	}
+
+	t.Run("reads YAML and JSON files, with the environment taking precedence", func(t *testing.T) {
+		dir := setup(t)
+		writeFile(t, dir, "config.yaml", "liveness_threshold: 0.6\nSIMILARITY_THRESHOLD: 0.7\n")
+		t.Setenv("SIMILARITY_THRESHOLD", "0.8")
+
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, 0.6, cfg.LivenessThreshold)
+		assert.Equal(t, 0.8, cfg.SimilarityThreshold)
+		assert.Equal(t, 8080, cfg.Port)
+
+		writeFile(t, dir, "config.json", `{"port": 9000, "max_frame_dimension": 720}`)
+		cfg, err = config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.Port)
+		assert.Equal(t, 720, cfg.MaxFrameDimension)
+		// Nothing is left over from the previous file
+		assert.Equal(t, 0.85, cfg.LivenessThreshold)
+	})
+
+	t.Run("a named file must exist", func(t *testing.T) {
+		dir := setup(t)
+		t.Setenv(config.ConfigFileEnv, filepath.Join(dir, "missing.yaml"))
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		dir := setup(t)
+		writeFile(t, dir, "config.yaml", "liveness_threshold: 1.5\nsimilarty_threshold: 0.7\nport: 70000\n")
+		t.Setenv("ENCRYPTION_KEY", "")
+		t.Setenv("FACE_MODEL_PATH", filepath.Join(dir, "models"))
+
+		_, err := config.Load()
+		found := problems(t, err)
+		require.Len(t, found, 5, err.Error())
+		assert.Contains(t, found[0], `unknown setting "similarty_threshold"`)
+		assert.Contains(t, err.Error(), "PORT must be a port between 1 and 65535, got 70000")
+		assert.Contains(t, err.Error(), "LIVENESS_THRESHOLD must be between 0 and 1, got 1.5")
+		assert.Contains(t, err.Error(), "ENCRYPTION_KEY is required")
+		assert.Contains(t, err.Error(), "FACE_MODEL_PATH")
+	})
+
+	t.Run("encryption keys must be strong enough for their provider", func(t *testing.T) {
+		setup(t)
+		t.Setenv("ENCRYPTION_KEY", "short")
+		_, err := config.Load()
+		assert.Equal(t, []string{"ENCRYPTION_KEY must be at least 16 characters, got 5"}, problems(t, err))
+
+		t.Setenv("STORAGE_KEY_PROVIDER", "vault")
+		_, err = config.Load()
+		assert.Equal(t, []string{"ENCRYPTION_KEY_CIPHERTEXT is required when STORAGE_KEY_PROVIDER is vault"}, problems(t, err))
+	})
+
+	t.Run("the remote embedder needs a server and a model", func(t *testing.T) {
+		setup(t)
+		t.Setenv("EMBEDDER_BACKEND", "remote")
+		_, err := config.Load()
+		assert.Equal(t, []string{"EMBEDDER_ADDR and EMBEDDER_MODEL are required when EMBEDDER_BACKEND is remote"}, problems(t, err))
+
+		t.Setenv("EMBEDDER_BACKEND", "onnx")
+		_, err = config.Load()
+		assert.Equal(t, []string{`EMBEDDER_BACKEND must be dlib or remote, got "onnx"`}, problems(t, err))
+	})
+
+	t.Run("a TLS certificate needs its key and excludes Let's Encrypt", func(t *testing.T) {
+		setup(t)
+		t.Setenv("TLS_CERT_FILE", "/etc/tls/tls.crt")
+		_, err := config.Load()
+		assert.Equal(t, []string{"TLS_CERT_FILE and TLS_KEY_FILE must be set together"}, problems(t, err))
+
+		t.Setenv("TLS_KEY_FILE", "/etc/tls/tls.key")
+		t.Setenv("TLS_AUTOCERT_DOMAINS", "verify.example.com")
+		_, err = config.Load()
+		assert.Equal(t, []string{"TLS_CERT_FILE and TLS_KEY_FILE are mutually exclusive with TLS_AUTOCERT_DOMAINS"}, problems(t, err))
+	})
+
+	t.Run("client certificates need TLS", func(t *testing.T) {
+		setup(t)
+		t.Setenv("TLS_CLIENT_IDENTITIES", "ops.connect-hub.internal=admin")
+		_, err := config.Load()
+		assert.Equal(t, []string{"TLS_CLIENT_IDENTITIES requires TLS_CLIENT_CA_FILE"}, problems(t, err))
+
+		t.Setenv("TLS_CLIENT_CA_FILE", "/etc/tls/ca.crt")
+		_, err = config.Load()
+		assert.Equal(t, []string{"TLS_CLIENT_CA_FILE requires TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS"}, problems(t, err))
+
+		t.Setenv("TLS_AUTOCERT_DOMAINS", "verify.example.com")
+		t.Setenv("TLS_CLIENT_AUTH", "require")
+		_, err = config.Load()
+		assert.Equal(t, []string{"TLS_CLIENT_AUTH=require cannot be used with TLS_AUTOCERT_DOMAINS"}, problems(t, err))
+	})
+
+	t.Run("credentials cannot be sent to any origin", func(t *testing.T) {
+		setup(t)
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, *")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+		_, err := config.Load()
+		assert.Equal(t, []string{"CORS_ALLOWED_ORIGINS cannot be * with CORS_ALLOW_CREDENTIALS, list the allowed origins"}, problems(t, err))
+
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+		_, err = config.Load()
+		assert.NoError(t, err)
+	})
+
+	t.Run("the model path may come up later when initialization retries", func(t *testing.T) {
+		dir := setup(t)
+		t.Setenv("FACE_MODEL_PATH", filepath.Join(dir, "models"))
+		t.Setenv("RECOGNIZER_INIT_ATTEMPTS", "5")
+		_, err := config.Load()
+		assert.NoError(t, err)
+	})
+
+	t.Run("the binary exits listing the problems", func(t *testing.T) {
+		setup(t)
+		t.Setenv("LIVENESS_THRESHOLD", "2")
+		var stderr bytes.Buffer
+		code := cli.Run(nil, func(logger *zap.Logger, cfg *config.Config) error {
+			t.Fatal("serve should not run")
+			return nil
+		}, &bytes.Buffer{}, &stderr)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "  - LIVENESS_THRESHOLD must be between 0 and 1, got 2")
+	})
+}
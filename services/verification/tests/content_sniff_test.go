@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// recordingDecoder keeps what it was asked to decode and yields frames.
+type recordingDecoder struct {
+	frames []image.Image
+	opened [][]byte
+}
+
+func (d *recordingDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	data, err := io.ReadAll(video)
+	if err != nil {
+		return nil, err
+	}
+	d.opened = append(d.opened, data)
+	return &sliceFrames{frames: d.frames}, nil
+}
+
+func TestSniffContentType(t *testing.T) {
+	png := new(bytes.Buffer)
+	png.Write([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'})
+
+	for name, tc := range map[string]struct {
+		data     []byte
+		expected string
+	}{
+		"webm":             {createTestVideoFile().data, services.ContentTypeWebM},
+		"mp4":              {mp4WithFrameDelta(1000), services.ContentTypeMP4},
+		"quicktime":        {mp4Box("ftyp", []byte("qt  \x00\x00\x02\x00qt  ")), services.ContentTypeQuickTime},
+		"legacy quicktime": {mp4Box("moov", make([]byte, 16)), services.ContentTypeQuickTime},
+		"avi":              {append([]byte("RIFF\x00\x10\x00\x00AVI LIST"), make([]byte, 16)...), services.ContentTypeAVI},
+		"jpeg":             {encodeJPEGFrames(t, createTestFrames(1))[0], services.ContentTypeJPEG},
+		"png":              {png.Bytes(), services.ContentTypePNG},
+		"unknown":          {[]byte("test-video-data-placeholder"), ""},
+		"empty":            {nil, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, services.SniffContentType(tc.data))
+		})
+	}
+
+	assert.True(t, services.ContentTypeMatches("video/mov", services.ContentTypeQuickTime))
+	assert.True(t, services.ContentTypeMatches("video/quicktime", services.ContentTypeMP4))
+	assert.False(t, services.ContentTypeMatches("video/webm", services.ContentTypeJPEG))
+	assert.False(t, services.ContentTypeMatches("video/webm", ""))
+}
+
+func TestUploadContentSniffing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	verify := func(video *fileData) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(map[string]interface{}{"video": video})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	message := func(w *httptest.ResponseRecorder) string {
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response["error"].(string)
+	}
+
+	t.Run("content must match the declared type", func(t *testing.T) {
+		photo := encodeJPEGFrames(t, createTestFrames(1))[0]
+		photo = append(photo, make([]byte, 1024)...)
+		w := verify(&fileData{filename: "capture.webm", contentType: "video/webm", data: photo})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_VIDEO_FILE", errorCode(t, w))
+		assert.Contains(t, message(w), "image/jpeg")
+	})
+
+	t.Run("unknown content is rejected", func(t *testing.T) {
+		w := verify(&fileData{filename: "capture.webm", contentType: "video/webm", data: bytes.Repeat([]byte("x"), 2048)})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, message(w), "unrecognized file format")
+	})
+
+	t.Run("the sniffed type picks the decoder", func(t *testing.T) {
+		fallback := &recordingDecoder{frames: createTestFrames(5)}
+		mp4 := &recordingDecoder{frames: createTestFrames(5)}
+		service.SetFrameDecoder(fallback)
+		service.SetFormatDecoder(services.ContentTypeMP4, mp4)
+
+		capture := append(mp4WithFrameDelta(1000), make([]byte, 1024)...)
+		// QuickTime and MP4 share a container, so the label is accepted
+		w := verify(&fileData{filename: "capture.mov", contentType: "video/quicktime", data: capture})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, mp4.opened, 1)
+		// The decoder reads the capture from its first byte
+		assert.Equal(t, capture, mp4.opened[0])
+		assert.Empty(t, fallback.opened)
+
+		_, err := service.VerifyVideo(&models.VerificationRequest{VideoData: createTestVideoFile().data, SessionID: "sniff-webm"})
+		require.NoError(t, err)
+		assert.Len(t, fallback.opened, 1)
+		assert.Len(t, mp4.opened, 1)
+	})
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(cfg middleware.CORSConfig) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.CORS(cfg))
+		router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+		return router
+	}
+	request := func(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/ping", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	restricted := middleware.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.connect-hub.io"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	}
+
+	t.Run("origin matching", func(t *testing.T) {
+		for origin, allowed := range map[string]bool{
+			"https://app.example.com":          true,
+			"https://APP.example.com":          true,
+			"https://eu.connect-hub.io":        true,
+			"https://a.b.connect-hub.io":       true,
+			"https://connect-hub.io":           false,
+			"http://eu.connect-hub.io":         false,
+			"https://evil.com/.connect-hub.io": false,
+			"https://evilconnect-hub.io":       false,
+			"https://app.example.com.evil.com": false,
+		} {
+			assert.Equal(t, allowed, restricted.AllowsOrigin(origin), origin)
+		}
+	})
+
+	t.Run("allowed origins are echoed", func(t *testing.T) {
+		w := request(newRouter(restricted), "GET", "https://eu.connect-hub.io")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://eu.connect-hub.io", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("other origins get no CORS headers", func(t *testing.T) {
+		router := newRouter(restricted)
+
+		w := request(router, "GET", "https://evil.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = request(router, "OPTIONS", "https://evil.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request(router, "OPTIONS", "https://app.example.com")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("credentials echo the origin", func(t *testing.T) {
+		w := request(newRouter(middleware.CORSConfig{
+			AllowedOrigins:   []string{"https://app.example.com"},
+			AllowCredentials: true,
+		}), "GET", "https://app.example.com")
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("no origin is allowed by default", func(t *testing.T) {
+		for _, environment := range []string{"development", "production"} {
+			defaults := middleware.NewCORSConfig(&config.Config{Environment: environment})
+			assert.False(t, defaults.AllowsOrigin("https://anywhere.example"), environment)
+		}
+
+		open := middleware.NewCORSConfig(&config.Config{CORSAllowedOrigins: "*"})
+		w := request(newRouter(open), "GET", "https://anywhere.example")
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "ETag, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Verification-Id",
+			w.Header().Get("Access-Control-Expose-Headers"))
+
+		listed := middleware.NewCORSConfig(&config.Config{
+			Environment:        "production",
+			CORSAllowedOrigins: " https://app.example.com , https://*.connect-hub.io",
+		})
+		assert.Equal(t, []string{"https://app.example.com", "https://*.connect-hub.io"}, listed.AllowedOrigins)
+	})
+}
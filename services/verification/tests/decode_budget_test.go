@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// slowDecoder yields frames instantly except for frame slowFrame, which
+// takes delay to decode, like a crafted clip stalling the decoder.
+type slowDecoder struct {
+	frames    int
+	slowFrame int
+	delay     time.Duration
+	closed    atomic.Bool
+}
+
+type slowFrames struct {
+	decoder *slowDecoder
+	index   int
+}
+
+func (d *slowDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	return &slowFrames{decoder: d}, nil
+}
+
+func (f *slowFrames) Next() (image.Image, error) {
+	if f.index >= f.decoder.frames {
+		return nil, io.EOF
+	}
+	if f.index == f.decoder.slowFrame {
+		time.Sleep(f.decoder.delay)
+	}
+	f.index++
+	return createTestImage(640, 480), nil
+}
+
+func (f *slowFrames) Close() error {
+	f.decoder.closed.Store(true)
+	return nil
+}
+
+func TestFrameDecodeBudget(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		FrameDecodeBudgetMs: 100,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	t.Run("slow frame aborts extraction promptly", func(t *testing.T) {
+		decoder := &slowDecoder{frames: 5, slowFrame: 2, delay: 5 * time.Second}
+		service.SetFrameDecoder(decoder)
+
+		start := time.Now()
+		_, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "decode-budget-session",
+		})
+		elapsed := time.Since(start)
+
+		assert.ErrorIs(t, err, services.ErrDecodeBudgetExceeded)
+		assert.Less(t, elapsed, time.Second)
+		assert.True(t, decoder.closed.Load())
+	})
+
+	t.Run("frames within budget decode normally", func(t *testing.T) {
+		service.SetFrameDecoder(&slowDecoder{frames: 5, slowFrame: 2, delay: 20 * time.Millisecond})
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "decode-budget-session-ok",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.Error)
+	})
+
+	t.Run("handler reports DECODE_BUDGET_EXCEEDED", func(t *testing.T) {
+		service.SetFrameDecoder(&slowDecoder{frames: 5, slowFrame: 0, delay: 5 * time.Second})
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+		body, contentType, err := createMultipartForm(map[string]interface{}{"video": createTestVideoFile()})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "DECODE_BUDGET_EXCEEDED", response["code"])
+	})
+}
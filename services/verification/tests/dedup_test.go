@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"bytes"
+	"image/jpeg"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestFaceVerificationService_Deduplication(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, window int, key string) *services.FaceVerificationService {
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			DedupWindow:         window,
+			DedupKey:            key,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	// verifyConcurrently releases all requests at once and returns the
+	// results, how many were shared and how many times frames were decoded.
+	verifyConcurrently := func(t *testing.T, service *services.FaceVerificationService, requests ...*models.VerificationRequest) ([]*models.VerificationResult, int, int64) {
+		decodesBefore := metrics.VideoDecodes.Value()
+
+		results := make([]*models.VerificationResult, len(requests))
+		sharedFlags := make([]bool, len(requests))
+		errs := make([]error, len(requests))
+
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, req := range requests {
+			wg.Add(1)
+			go func(i int, req *models.VerificationRequest) {
+				defer wg.Done()
+				<-start
+				results[i], sharedFlags[i], errs[i] = service.VerifyVideoDeduplicated(req)
+			}(i, req)
+		}
+		close(start)
+		wg.Wait()
+
+		shared := 0
+		for i := range requests {
+			require.NoError(t, errs[i])
+			if sharedFlags[i] {
+				shared++
+			}
+		}
+		return results, shared, metrics.VideoDecodes.Value() - decodesBefore
+	}
+
+	request := func(userID string, video []byte) *models.VerificationRequest {
+		return &models.VerificationRequest{VideoData: video, UserID: userID, SessionID: "test-session-dedup"}
+	}
+
+	t.Run("simultaneous identical requests run the pipeline once", func(t *testing.T) {
+		service := newService(t, 2, services.DedupKeyVideo)
+		video := createTestVideoData()
+
+		results, shared, decodes := verifyConcurrently(t, service, request("", video), request("", video))
+
+		assert.Equal(t, int64(1), decodes)
+		assert.Equal(t, 1, shared)
+		assert.Equal(t, results[0].VerificationID, results[1].VerificationID)
+	})
+
+	t.Run("different captures are not merged by video key", func(t *testing.T) {
+		service := newService(t, 2, services.DedupKeyVideo)
+
+		_, shared, decodes := verifyConcurrently(t, service,
+			request("", createTestVideoData()),
+			request("", createPanningFrameData(t)))
+
+		assert.Equal(t, int64(2), decodes)
+		assert.Equal(t, 0, shared)
+	})
+
+	t.Run("user key merges any request for the same user", func(t *testing.T) {
+		service := newService(t, 2, services.DedupKeyUser)
+
+		_, shared, decodes := verifyConcurrently(t, service,
+			request("dedup-user", createTestVideoData()),
+			request("dedup-user", createPanningFrameData(t)))
+
+		assert.Equal(t, int64(1), decodes)
+		assert.Equal(t, 1, shared)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		service := newService(t, 0, "")
+		video := createTestVideoData()
+
+		_, shared, decodes := verifyConcurrently(t, service, request("", video), request("", video))
+
+		assert.Equal(t, int64(2), decodes)
+		assert.Equal(t, 0, shared)
+	})
+
+	t.Run("result is not shared after the window", func(t *testing.T) {
+		service := newService(t, 1, services.DedupKeyVideo)
+		video := createTestVideoData()
+
+		first, _, err := service.VerifyVideoDeduplicated(request("", video))
+		require.NoError(t, err)
+
+		time.Sleep(1100 * time.Millisecond)
+
+		second, shared, err := service.VerifyVideoDeduplicated(request("", video))
+		require.NoError(t, err)
+		assert.False(t, shared)
+		assert.NotEqual(t, first.VerificationID, second.VerificationID)
+	})
+}
+
+func createPanningFrameData(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, createPanningFrames(1, 0, 0)[0], &jpeg.Options{Quality: 90}))
+	return buf.Bytes()
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestDescriptorCache(t *testing.T) {
+	newService := func(t *testing.T, ttl int) *services.FaceVerificationService {
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			DescriptorCacheTTL:  ttl,
+		})
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	// verify returns the result and how many times frames were decoded
+	verify := func(t *testing.T, service *services.FaceVerificationService, video []byte) (*models.VerificationResult, int64) {
+		decodesBefore := metrics.VideoDecodes.Value()
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: video,
+			SessionID: "descriptor-cache-session",
+		})
+		require.NoError(t, err)
+		return result, metrics.VideoDecodes.Value() - decodesBefore
+	}
+
+	t.Run("identical payload reuses the analysis", func(t *testing.T) {
+		service := newService(t, 60)
+		video := createTestVideoData()
+
+		first, decodes := verify(t, service, video)
+		assert.Equal(t, int64(1), decodes)
+		assert.False(t, first.AnalysisCached)
+
+		second, decodes := verify(t, service, video)
+		assert.Equal(t, int64(0), decodes, "a cached payload must not be decoded again")
+		assert.True(t, second.AnalysisCached)
+		assert.NotEqual(t, first.VerificationID, second.VerificationID)
+		assert.Equal(t, first.LivenessScore, second.LivenessScore)
+		assert.Equal(t, first.Verified, second.Verified)
+		assert.Equal(t, first.ProcessedResolution, second.ProcessedResolution)
+	})
+
+	t.Run("other payloads are analyzed", func(t *testing.T) {
+		service := newService(t, 60)
+		video := createTestVideoData()
+		verify(t, service, video)
+
+		other := append(append([]byte(nil), video...), 0)
+		result, decodes := verify(t, service, other)
+		assert.Equal(t, int64(1), decodes)
+		assert.False(t, result.AnalysisCached)
+	})
+
+	t.Run("disabled without a TTL", func(t *testing.T) {
+		service := newService(t, 0)
+		video := createTestVideoData()
+		verify(t, service, video)
+
+		result, decodes := verify(t, service, video)
+		assert.Equal(t, int64(1), decodes)
+		assert.False(t, result.AnalysisCached)
+	})
+}
+
+func TestMemoryDescriptorCache(t *testing.T) {
+	ctx := context.Background()
+	analysis := func(score float64) *storage.CachedAnalysis {
+		return &storage.CachedAnalysis{Liveness: &models.LivenessResult{Score: score}}
+	}
+
+	t.Run("evicts the least recently used", func(t *testing.T) {
+		cache := storage.NewMemoryDescriptorCache(2)
+		require.NoError(t, cache.Put(ctx, "a", analysis(0.1), time.Minute))
+		require.NoError(t, cache.Put(ctx, "b", analysis(0.2), time.Minute))
+
+		// Reading a makes b the least recently used
+		got, err := cache.Get(ctx, "a")
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		require.NoError(t, cache.Put(ctx, "c", analysis(0.3), time.Minute))
+
+		assert.Equal(t, 2, cache.Len())
+		got, _ = cache.Get(ctx, "b")
+		assert.Nil(t, got)
+		got, _ = cache.Get(ctx, "a")
+		assert.NotNil(t, got)
+		got, _ = cache.Get(ctx, "c")
+		assert.NotNil(t, got)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		cache := storage.NewMemoryDescriptorCache(0)
+		require.NoError(t, cache.Put(ctx, "a", analysis(0.1), time.Millisecond))
+		time.Sleep(5 * time.Millisecond)
+
+		got, err := cache.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.Nil(t, got)
+		assert.Equal(t, 0, cache.Len())
+	})
+}
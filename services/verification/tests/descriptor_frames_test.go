@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestDescriptorFrames(t *testing.T) {
+	videoData := createTestVideoFile().data
+	newConfig := func(t *testing.T) *config.Config {
+		return &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			DescriptorFrames:    3,
+		}
+	}
+
+	t.Run("captures are described over several frames", func(t *testing.T) {
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), newConfig(t))
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		require.NoError(t, service.RegisterFace("batched-user", videoData))
+		result, err := service.VerifyVideo(&models.VerificationRequest{VideoData: videoData, UserID: "batched-user"})
+		require.NoError(t, err)
+		assert.True(t, result.Verified, result.Reason)
+	})
+
+	t.Run("the remote embedder sends the frames as one batch", func(t *testing.T) {
+		fake, addr := startInferenceServer(t, []float32{0, 1, 0, 0})
+		cfg := newConfig(t)
+		cfg.EmbedderBackend = services.EmbedderRemote
+		cfg.EmbedderAddr = addr
+		cfg.EmbedderModel = "arcface"
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		template, err := service.ExtractTemplate(videoData)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0, 1, 0, 0}, template)
+		assert.Equal(t, 1, fake.requestCount())
+		assert.Equal(t, []int64{3, 3, 112, 112}, fake.lastRequest().shape)
+	})
+
+	t.Run("GPU detection needs a CUDA build", func(t *testing.T) {
+		cfg := newConfig(t)
+		cfg.RecognizerGPU = true
+		_, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+		assert.ErrorIs(t, err, services.ErrGPUUnavailable)
+	})
+}
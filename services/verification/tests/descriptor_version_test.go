@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestMigrateDescriptors(t *testing.T) {
+	const modelVersion = "dlib:0123456789abcdef"
+	newStore := func(t *testing.T) storage.VectorStore {
+		store, err := storage.NewEncryptedFileVectorStore(t.TempDir(), "test-encryption-key-for-testing-only", time.Second)
+		require.NoError(t, err)
+		template := func(userID, version string) models.FaceVector {
+			return models.FaceVector{UserID: userID, Vector: []float32{1, 0}, CreatedAt: time.Now(), Version: version}
+		}
+		require.NoError(t, store.Save(template("legacy-user", models.LegacyDescriptorVersion)))
+		require.NoError(t, store.Save(template("current-user", modelVersion)))
+		require.NoError(t, store.Save(template("stale-user", "dlib:fedcba9876543210")))
+		require.NoError(t, store.Save(template("stale-user", "dlib:fedcba9876543210")))
+		return store
+	}
+
+	t.Run("legacy templates are stamped with the current models", func(t *testing.T) {
+		store := newStore(t)
+		migration, err := services.MigrateDescriptors(store, modelVersion, services.LegacyDescriptorsCurrent)
+		require.NoError(t, err)
+		assert.Equal(t, modelVersion, migration.ModelVersion)
+		assert.Equal(t, 3, migration.Users)
+		assert.Equal(t, 1, migration.Stamped)
+		assert.Equal(t, 2, migration.StaleTemplates)
+		assert.Equal(t, []string{"stale-user"}, migration.ReenrollmentRequired)
+
+		templates, err := store.Load("legacy-user")
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		assert.Equal(t, modelVersion, templates[0].Version)
+
+		// Stale templates are kept until their user enrolls again
+		templates, err = store.Load("stale-user")
+		require.NoError(t, err)
+		assert.Len(t, templates, 2)
+	})
+
+	t.Run("stale legacy templates flag their users", func(t *testing.T) {
+		store := newStore(t)
+		migration, err := services.MigrateDescriptors(store, modelVersion, services.LegacyDescriptorsStale)
+		require.NoError(t, err)
+		assert.Zero(t, migration.Stamped)
+		assert.Equal(t, 3, migration.StaleTemplates)
+		assert.Equal(t, []string{"legacy-user", "stale-user"}, migration.ReenrollmentRequired)
+
+		templates, err := store.Load("legacy-user")
+		require.NoError(t, err)
+		assert.Equal(t, models.LegacyDescriptorVersion, templates[0].Version)
+	})
+}
+
+func TestDescriptorVersioning(t *testing.T) {
+	const key = "test-encryption-key-for-testing-only"
+	logger := zaptest.NewLogger(t)
+	dir := t.TempDir()
+	videoData := createTestVideoFile().data
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         dir,
+		EncryptionKey:       key,
+	}
+
+	// Enroll, then pretend the template came from other models
+	enrolling, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	require.NoError(t, enrolling.RegisterFace("upgraded-user", videoData))
+	enrolling.Close()
+
+	modelVersion, err := services.DescriptorModelVersion(cfg)
+	require.NoError(t, err)
+	store, err := storage.NewEncryptedFileVectorStore(dir, key, time.Second)
+	require.NoError(t, err)
+	templates, err := store.Load("upgraded-user")
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, modelVersion, templates[0].Version)
+	stale := templates[0]
+	stale.Version = "dlib:0000000000000000"
+	require.NoError(t, store.Delete("upgraded-user"))
+	require.NoError(t, store.Save(stale))
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+	verify := func(t *testing.T) *models.VerificationResult {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: videoData,
+			UserID:    "upgraded-user",
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("templates of other models are not compared", func(t *testing.T) {
+		result := verify(t)
+		assert.False(t, result.Verified)
+		assert.Equal(t, models.ReasonReenrollmentRequired, result.Reason)
+		assert.Zero(t, service.TemplateCount("upgraded-user"))
+		assert.Equal(t, 1, service.StaleTemplateCount("upgraded-user"))
+		assert.Empty(t, service.SearchGallery(stale.Vector, 1))
+	})
+
+	t.Run("the migration flags the user", func(t *testing.T) {
+		migration, err := service.MigrateDescriptors()
+		require.NoError(t, err)
+		assert.Equal(t, modelVersion, migration.ModelVersion)
+		assert.Equal(t, []string{"upgraded-user"}, migration.ReenrollmentRequired)
+	})
+
+	t.Run("enrolling again replaces the stale templates", func(t *testing.T) {
+		require.NoError(t, service.RegisterFace("upgraded-user", videoData))
+		assert.Equal(t, 1, service.TemplateCount("upgraded-user"))
+		assert.Zero(t, service.StaleTemplateCount("upgraded-user"))
+		assert.True(t, verify(t).Verified)
+	})
+}
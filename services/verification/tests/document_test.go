@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/document"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// ICAO 9303 specimen documents
+var (
+	specimenPassportMRZ = []string{
+		"P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<",
+		"L898902C36UTO7408122F1204159ZE184226B<<<<<10",
+	}
+	specimenIDCardMRZ = []string{
+		"I<UTOD231458907<<<<<<<<<<<<<<<",
+		"7408122F1204159UTO<<<<<<<<<<<6",
+		"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+	}
+)
+
+func TestParseMRZ(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("passport", func(t *testing.T) {
+		text := append([]string{"PASSPORT", "Utopia"}, specimenPassportMRZ...)
+		fields, err := document.ParseMRZ(text, now)
+		require.NoError(t, err)
+
+		assert.Equal(t, document.FormatTD3, fields.Format)
+		assert.Equal(t, "P", fields.DocumentType)
+		assert.Equal(t, "UTO", fields.IssuingCountry)
+		assert.Equal(t, "ERIKSSON", fields.Surname)
+		assert.Equal(t, "ANNA MARIA", fields.GivenNames)
+		assert.Equal(t, "L898902C3", fields.DocumentNumber)
+		assert.Equal(t, "UTO", fields.Nationality)
+		assert.Equal(t, "1974-08-12", fields.DateOfBirth)
+		assert.Equal(t, "F", fields.Sex)
+		assert.Equal(t, "2012-04-15", fields.ExpiryDate)
+		assert.Equal(t, "ZE184226B", fields.OptionalData)
+		assert.True(t, fields.ChecksValid)
+		assert.Equal(t, specimenPassportMRZ, fields.MRZ)
+	})
+
+	t.Run("ID card", func(t *testing.T) {
+		fields, err := document.ParseMRZ(specimenIDCardMRZ, now)
+		require.NoError(t, err)
+
+		assert.Equal(t, document.FormatTD1, fields.Format)
+		assert.Equal(t, "I", fields.DocumentType)
+		assert.Equal(t, "D23145890", fields.DocumentNumber)
+		assert.Equal(t, "ERIKSSON", fields.Surname)
+		assert.Equal(t, "ANNA MARIA", fields.GivenNames)
+		assert.Equal(t, "1974-08-12", fields.DateOfBirth)
+		assert.Equal(t, "2012-04-15", fields.ExpiryDate)
+		assert.True(t, fields.ChecksValid)
+	})
+
+	t.Run("tolerates OCR spacing and letter-for-digit misreads", func(t *testing.T) {
+		text := []string{
+			"P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<",
+			"L898902C36UTO74O8122F12O4159ZE184226B<<<<< 10",
+		}
+		fields, err := document.ParseMRZ(text, now)
+		require.NoError(t, err)
+		assert.Equal(t, "1974-08-12", fields.DateOfBirth)
+		assert.Equal(t, "2012-04-15", fields.ExpiryDate)
+		assert.True(t, fields.ChecksValid)
+	})
+
+	t.Run("flags a failed check digit", func(t *testing.T) {
+		text := []string{specimenPassportMRZ[0], "L898902C36UTO7408122F1204159ZE184226B<<<<<11"}
+		fields, err := document.ParseMRZ(text, now)
+		require.NoError(t, err)
+		assert.False(t, fields.ChecksValid)
+	})
+
+	t.Run("no machine-readable zone", func(t *testing.T) {
+		_, err := document.ParseMRZ([]string{"DRIVING LICENCE", "ERIKSSON ANNA"}, now)
+		assert.True(t, errors.Is(err, document.ErrMRZNotFound))
+	})
+}
+
+type stubOCR struct {
+	text []string
+	err  error
+}
+
+func (s *stubOCR) Recognize(ctx context.Context, img image.Image) ([]string, error) {
+	return s.text, s.err
+}
+
+func TestVerificationHandler_VerifyDocument(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:      0.5,
+		SimilarityThreshold:    0.75,
+		StoragePath:            t.TempDir(),
+		EncryptionKey:          "test-encryption-key-for-testing-only",
+		CompareMaxDocumentSize: 64 * 1024,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	verify := func(fields map[string]interface{}) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify/document", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	photo := encodeJPEGFrames(t, createPanningFrames(1, 0, 0))[0]
+	passport := &fileData{filename: "passport.jpg", contentType: "image/jpeg", data: photo}
+
+	t.Run("returns the portrait and MRZ fields", func(t *testing.T) {
+		service.SetOCREngine(&stubOCR{text: append([]string{"PASSPORT"}, specimenPassportMRZ...)})
+
+		w := verify(map[string]interface{}{"video": createTestVideoFile(), "document": passport})
+		if w.Code == http.StatusUnprocessableEntity {
+			// The synthetic images may hold no detectable face
+			assert.Contains(t, []string{"NO_FACE_IN_VIDEO", "NO_FACE_IN_DOCUMENT"}, errorCode(t, w))
+			return
+		}
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data models.DocumentVerification `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		result := response.Data
+
+		require.NotNil(t, result.Portrait)
+		assert.NotEmpty(t, result.Portrait.Image)
+		require.NotNil(t, result.Fields)
+		assert.Equal(t, "L898902C3", result.Fields.DocumentNumber)
+		assert.True(t, result.Fields.ChecksValid)
+		// The specimen expired in 2012
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, services.WarningDocumentExpired, result.Warnings[0].Code)
+	})
+
+	t.Run("an unreadable document is a warning", func(t *testing.T) {
+		service.SetOCREngine(&stubOCR{err: errors.New("tesseract not installed")})
+
+		w := verify(map[string]interface{}{"video": createTestVideoFile(), "document": passport})
+		if w.Code == http.StatusUnprocessableEntity {
+			return
+		}
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data models.DocumentVerification `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Nil(t, response.Data.Fields)
+		require.Len(t, response.Data.Warnings, 1)
+		assert.Equal(t, services.WarningOCRFailed, response.Data.Warnings[0].Code)
+	})
+
+	t.Run("requires both uploads", func(t *testing.T) {
+		w := verify(map[string]interface{}{"video": createTestVideoFile()})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "MISSING_DOCUMENT", errorCode(t, w))
+	})
+
+	t.Run("rejects documents that are not images", func(t *testing.T) {
+		w := verify(map[string]interface{}{
+			"video":    createTestVideoFile(),
+			"document": &fileData{filename: "id.pdf", contentType: "application/pdf", data: []byte("%PDF-1.7 not an image")},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_DOCUMENT", errorCode(t, w))
+	})
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// stripedFrame alternates black and white one-pixel columns.
+func stripedFrame(width, height int) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{0, 0, 0, 255}
+			if x%2 == 1 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			frame.SetRGBA(x, y, c)
+		}
+	}
+	return frame
+}
+
+func TestFrameDownscaling(t *testing.T) {
+	newService := func(t *testing.T, maxDimension int) (*services.FaceVerificationService, *frameDetector) {
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			MaxFrameDimension:   maxDimension,
+		})
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		detector := &frameDetector{}
+		service.SetChallengeDetector(detector)
+		return service, detector
+	}
+	// Verifies frames of the given size, answering a fresh liveness session
+	// so the detector sees the frames analyzed
+	verify := func(t *testing.T, service *services.FaceVerificationService, width, height int) *models.VerificationResult {
+		service.SetFrameDecoder(&recordingDecoder{frames: []image.Image{stripedFrame(width, height), stripedFrame(width, height)}})
+		session, err := service.StartLivenessSession()
+		require.NoError(t, err)
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData:       createTestVideoFile().data,
+			SessionID:       "downscale-" + session.SessionToken,
+			LivenessSession: session.SessionToken,
+			LivenessNonce:   session.Nonce,
+		})
+		// The synthetic frames hold no face to match
+		if err != nil {
+			require.ErrorIs(t, err, services.ErrNoFaceDetected)
+		}
+		return result
+	}
+
+	t.Run("large frames are downscaled keeping the aspect ratio", func(t *testing.T) {
+		service, detector := newService(t, 32)
+
+		result := verify(t, service, 128, 72)
+		assert.Equal(t, &models.Resolution{Width: 128, Height: 72}, result.OriginalResolution)
+		assert.Equal(t, &models.Resolution{Width: 32, Height: 18}, result.ProcessedResolution)
+		assert.Equal(t, image.Pt(32, 18), detector.frame.Bounds().Size())
+		// Four stripes average to mid grey rather than aliasing
+		r, g, b, _ := detector.frame.At(5, 5).RGBA()
+		assert.InDelta(t, 0x8080, r, 0x200)
+		assert.Equal(t, r, g)
+		assert.Equal(t, r, b)
+
+		result = verify(t, service, 72, 128)
+		assert.Equal(t, &models.Resolution{Width: 18, Height: 32}, result.ProcessedResolution)
+	})
+
+	t.Run("small frames are left alone", func(t *testing.T) {
+		service, detector := newService(t, 32)
+
+		result := verify(t, service, 24, 16)
+		assert.Equal(t, result.OriginalResolution, result.ProcessedResolution)
+		assert.Equal(t, image.Pt(24, 16), detector.frame.Bounds().Size())
+	})
+
+	t.Run("zero disables downscaling", func(t *testing.T) {
+		service, detector := newService(t, 0)
+
+		result := verify(t, service, 128, 72)
+		assert.Equal(t, &models.Resolution{Width: 128, Height: 72}, result.ProcessedResolution)
+		assert.Equal(t, image.Pt(128, 72), detector.frame.Bounds().Size())
+	})
+}
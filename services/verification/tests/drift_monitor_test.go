@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestDriftMonitor(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		DriftBaselineConfidence: 0.90,
+		DriftBaselineLiveness:   0.92,
+		DriftTolerance:          0.05,
+		DriftWindowSize:         20,
+	}
+
+	t.Run("stable stream does not alert", func(t *testing.T) {
+		monitor := services.NewDriftMonitor(logger, cfg)
+
+		for i := 0; i < 100; i++ {
+			monitor.Observe(0.89+float64(i%3)*0.01, 0.92)
+			assert.Empty(t, monitor.Check())
+		}
+	})
+
+	t.Run("no alert before the window fills", func(t *testing.T) {
+		monitor := services.NewDriftMonitor(logger, cfg)
+
+		for i := 0; i < cfg.DriftWindowSize-1; i++ {
+			monitor.Observe(0.40, 0.40)
+		}
+		assert.Empty(t, monitor.Check())
+	})
+
+	t.Run("drifting stream alerts past the bound", func(t *testing.T) {
+		monitor := services.NewDriftMonitor(logger, cfg)
+
+		var alerts []services.DriftAlert
+		confidence := 0.90
+		for i := 0; i < 200 && len(alerts) == 0; i++ {
+			monitor.Observe(confidence, 0.92)
+			alerts = monitor.Check()
+
+			// Slow downward drift in match confidence
+			confidence -= 0.002
+		}
+
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "confidence", alerts[0].Signal)
+		assert.Less(t, alerts[0].Delta, -cfg.DriftTolerance)
+		assert.InDelta(t, 0.90, alerts[0].Baseline, 1e-9)
+	})
+
+	t.Run("alert fires once per excursion", func(t *testing.T) {
+		monitor := services.NewDriftMonitor(logger, cfg)
+
+		fired := 0
+		for i := 0; i < 60; i++ {
+			monitor.Observe(0.70, 0.92)
+			fired += len(monitor.Check())
+		}
+		assert.Equal(t, 1, fired)
+
+		// Recover, then drift again
+		for i := 0; i < cfg.DriftWindowSize; i++ {
+			monitor.Observe(0.90, 0.92)
+			fired += len(monitor.Check())
+		}
+		for i := 0; i < cfg.DriftWindowSize; i++ {
+			monitor.Observe(0.90, 0.70)
+			fired += len(monitor.Check())
+		}
+		assert.Equal(t, 2, fired)
+	})
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestDuplicateIdentity(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newRouter := func(t *testing.T, check string) (*services.FaceVerificationService, *gin.Engine) {
+		cfg := &config.Config{
+			LivenessThreshold:      0.5,
+			SimilarityThreshold:    0.75,
+			StoragePath:            t.TempDir(),
+			EncryptionKey:          "test-encryption-key-for-testing-only",
+			DuplicateIdentityCheck: check,
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return service, router
+	}
+
+	// Every test capture shows the same face
+	register := func(t *testing.T, router *gin.Engine, userID, duplicateCheck string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		fields := map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": userID,
+		}
+		if duplicateCheck != "" {
+			fields["duplicate_check"] = duplicateCheck
+		}
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/register", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("duplicates are not checked by default", func(t *testing.T) {
+		_, router := newRouter(t, "")
+		w, _ := register(t, router, "alice", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w, response := register(t, router, "bob", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, response, "duplicate_identity")
+	})
+
+	t.Run("flagged duplicates are enrolled", func(t *testing.T) {
+		service, router := newRouter(t, services.DuplicateCheckFlag)
+		w, response := register(t, router, "alice", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, response, "duplicate_identity")
+
+		w, response = register(t, router, "bob", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, true, response["duplicate_identity"])
+		assert.Equal(t, 1, service.TemplateCount("bob"))
+
+		// A request cannot loosen the configured check
+		w, response = register(t, router, "carol", services.DuplicateCheckOff)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, true, response["duplicate_identity"])
+	})
+
+	t.Run("requests can reject duplicates", func(t *testing.T) {
+		service, router := newRouter(t, services.DuplicateCheckOff)
+		w, _ := register(t, router, "alice", services.DuplicateCheckReject)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w, response := register(t, router, "mallory", services.DuplicateCheckReject)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "DUPLICATE_IDENTITY", response["code"])
+		assert.Zero(t, service.TemplateCount("mallory"))
+
+		w, response = register(t, router, "mallory", "sometimes")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_REQUEST", response["code"])
+	})
+
+	t.Run("unknown configured checks are rejected", func(t *testing.T) {
+		_, err := services.NewFaceVerificationService(logger, &config.Config{
+			StoragePath:            t.TempDir(),
+			EncryptionKey:          "test-encryption-key-for-testing-only",
+			DuplicateIdentityCheck: "block",
+		})
+		assert.Error(t, err)
+	})
+}
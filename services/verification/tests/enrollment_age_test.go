@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestMinEnrollmentAge(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		MinEnrollmentAge:    1,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	videoData := createTestVideoFile().data
+	require.NoError(t, service.RegisterFace("settling-user", videoData))
+
+	verify := func() *models.VerificationResult {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: videoData,
+			UserID:    "settling-user",
+			SessionID: "enrollment-age-session",
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("fresh enrollment is excluded", func(t *testing.T) {
+		result := verify()
+		assert.False(t, result.Verified)
+		assert.Equal(t, models.ReasonEnrollmentNotYetActive, result.Reason)
+
+		template, err := service.ExtractTemplate(videoData)
+		require.NoError(t, err)
+		_, _, err = service.MatchTemplate("settling-user", template)
+		assert.ErrorIs(t, err, services.ErrEnrollmentNotYetActive)
+	})
+
+	t.Run("unknown users are unaffected", func(t *testing.T) {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: videoData,
+			SessionID: "enrollment-age-new-user",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+	})
+
+	t.Run("enrollment is used once the age threshold passes", func(t *testing.T) {
+		time.Sleep(1100 * time.Millisecond)
+
+		result := verify()
+		assert.True(t, result.Verified)
+		assert.Empty(t, result.Reason)
+	})
+}
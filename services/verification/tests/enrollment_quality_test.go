@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"connect-hub/verification-service/internal/i18n"
+	"connect-hub/verification-service/internal/services"
+)
+
+// checkerboardFrame is a 400x400 frame of 8px squares alternating between
+// the two grey levels, which gives a sharp, evenly lit "face".
+func checkerboardFrame(dark, light uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 400, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			level := dark
+			if (x/8+y/8)%2 == 0 {
+				level = light
+			}
+			img.SetGray(x, y, color.Gray{Y: level})
+		}
+	}
+	return img
+}
+
+// fivePointLandmarks places dlib's 5-point shape: two corners per eye, then
+// the nose base.
+func fivePointLandmarks(leftEyeY, rightEyeY, noseX int) []image.Point {
+	return []image.Point{
+		{X: 150, Y: leftEyeY}, {X: 180, Y: leftEyeY},
+		{X: 220, Y: rightEyeY}, {X: 250, Y: rightEyeY},
+		{X: noseX, Y: 240},
+	}
+}
+
+func TestEnrollmentQualityGate(t *testing.T) {
+	thresholds := services.QualityThresholds{
+		MinFaceSize:   0.2,
+		MinSharpness:  50,
+		MinBrightness: 0.2,
+		MaxBrightness: 0.9,
+		MaxPoseAngle:  30,
+	}
+	faceRect := image.Rect(100, 100, 300, 300)
+	frontal := fivePointLandmarks(180, 180, 200)
+
+	code := func(err error) string {
+		var qualityErr *services.QualityError
+		if errors.As(err, &qualityErr) {
+			return qualityErr.Code
+		}
+		return ""
+	}
+
+	t.Run("sharp, well lit, frontal face passes", func(t *testing.T) {
+		quality := services.AssessFaceQuality(checkerboardFrame(60, 190), faceRect, frontal)
+		assert.InDelta(t, 0.5, quality.FaceSize, 0.001)
+		assert.InDelta(t, 0.49, quality.Brightness, 0.02)
+		assert.Greater(t, quality.Sharpness, 50.0)
+		assert.InDelta(t, 0, quality.PoseAngle, 0.001)
+		assert.NoError(t, thresholds.Check(quality))
+	})
+
+	t.Run("tiny face", func(t *testing.T) {
+		quality := services.AssessFaceQuality(checkerboardFrame(60, 190), image.Rect(180, 180, 220, 220), frontal)
+		assert.Equal(t, services.QualityFaceTooSmall, code(thresholds.Check(quality)))
+	})
+
+	t.Run("dark and overexposed faces", func(t *testing.T) {
+		dark := services.AssessFaceQuality(checkerboardFrame(5, 40), faceRect, frontal)
+		assert.Equal(t, services.QualityImageTooDark, code(thresholds.Check(dark)))
+
+		bright := services.AssessFaceQuality(checkerboardFrame(240, 255), faceRect, frontal)
+		assert.Equal(t, services.QualityImageTooBright, code(thresholds.Check(bright)))
+	})
+
+	t.Run("blurry face", func(t *testing.T) {
+		quality := services.AssessFaceQuality(checkerboardFrame(128, 128), faceRect, frontal)
+		assert.InDelta(t, 0, quality.Sharpness, 0.001)
+
+		err := thresholds.Check(quality)
+		require.Error(t, err)
+		assert.Equal(t, services.QualityImageTooBlurry, code(err))
+		var qualityErr *services.QualityError
+		require.True(t, errors.As(err, &qualityErr))
+		assert.Equal(t, quality, qualityErr.Quality)
+	})
+
+	t.Run("turned and tilted heads", func(t *testing.T) {
+		turned := services.AssessFaceQuality(checkerboardFrame(60, 190), faceRect, fivePointLandmarks(180, 180, 235))
+		assert.Greater(t, turned.PoseAngle, 30.0)
+		assert.Equal(t, services.QualityFaceNotFrontal, code(thresholds.Check(turned)))
+
+		tilted := services.AssessFaceQuality(checkerboardFrame(60, 190), faceRect, fivePointLandmarks(160, 230, 200))
+		assert.InDelta(t, 45, tilted.PoseAngle, 1)
+	})
+
+	t.Run("every code has guidance in every locale", func(t *testing.T) {
+		codes := []string{
+			services.QualityFaceTooSmall,
+			services.QualityImageTooDark,
+			services.QualityImageTooBright,
+			services.QualityImageTooBlurry,
+			services.QualityFaceNotFrontal,
+		}
+		for _, locale := range []string{"en", "es", "pt"} {
+			for _, c := range codes {
+				assert.NotEmpty(t, i18n.Message(locale, c), "%s/%s", locale, c)
+			}
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestEnrollmentWindow(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "test-admin-key",
+		EnrollmentDisabled:  true,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	register := func(userID string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": userID,
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/register", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	verify := func(t *testing.T) {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "enrollment-window-session",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+	}
+
+	setEnrollment := func(enabled bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]bool{"enabled": enabled})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/api/v1/admin/enrollment", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("registration is blocked while disabled", func(t *testing.T) {
+		assert.False(t, service.EnrollmentEnabled())
+
+		w, response := register("window-user-1")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "ENROLLMENT_DISABLED", response["code"])
+		assert.Equal(t, 0, service.TemplateCount("window-user-1"))
+
+		err := service.RegisterFace("window-user-1", createTestVideoData())
+		assert.ErrorIs(t, err, services.ErrEnrollmentDisabled)
+	})
+
+	t.Run("verification is unaffected while disabled", verify)
+
+	t.Run("admin toggle opens the window", func(t *testing.T) {
+		w := setEnrollment(true)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"enabled": true}`, w.Body.String())
+
+		w, response := register("window-user-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, response["success"])
+		assert.Equal(t, 1, service.TemplateCount("window-user-1"))
+	})
+
+	t.Run("verification is unaffected while enabled", verify)
+
+	t.Run("closing the window blocks registration again", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setEnrollment(false).Code)
+
+		w, response := register("window-user-2")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "ENROLLMENT_DISABLED", response["code"])
+	})
+
+	t.Run("toggle requires admin key and a value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/enrollment", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/api/v1/admin/enrollment", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestFirstEnrollment(t *testing.T) {
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	}
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	t.Run("a first enrollment needs no gallery match", func(t *testing.T) {
+		require.NoError(t, service.RegisterFace("first-user", createTestVideoData()))
+		assert.Equal(t, 1, service.TemplateCount("first-user"))
+	})
+
+	t.Run("a re-enrollment matches the gallery", func(t *testing.T) {
+		require.NoError(t, service.RegisterFace("first-user", createTestVideoData()))
+		assert.Equal(t, 2, service.TemplateCount("first-user"))
+	})
+
+	t.Run("a user ID is required", func(t *testing.T) {
+		assert.Error(t, service.RegisterFace("", createTestVideoData()))
+	})
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestPerUserKeyShredding(t *testing.T) {
+	const key = "test-encryption-key-for-testing-only"
+	dir := t.TempDir()
+	store, err := storage.NewEncryptedFileVectorStore(dir, key, time.Second)
+	require.NoError(t, err)
+	store.SetPerUserKeys(true)
+
+	now := time.Now()
+	require.NoError(t, store.Save(models.FaceVector{UserID: "alice", Vector: []float32{1, 0}, CreatedAt: now}))
+	require.NoError(t, store.Save(models.FaceVector{UserID: "bob", Vector: []float32{0, 1}, CreatedAt: now}))
+
+	// A backup of the vector file and its log taken before the erasure
+	backup := make(map[string][]byte)
+	for _, name := range []string{"face_vectors.enc", "face_vectors.enc.log"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if !os.IsNotExist(err) {
+			require.NoError(t, err)
+		}
+		backup[name] = data
+	}
+
+	shredded, err := store.ShredUser("alice")
+	require.NoError(t, err)
+	assert.True(t, shredded)
+
+	alice, err := store.Load("alice")
+	require.NoError(t, err)
+	assert.Empty(t, alice)
+
+	t.Run("restored backups no longer hold the erased user", func(t *testing.T) {
+		for name, data := range backup {
+			path := filepath.Join(dir, name)
+			if data == nil {
+				require.NoError(t, os.RemoveAll(path))
+				continue
+			}
+			require.NoError(t, os.WriteFile(path, data, 0600))
+		}
+
+		all, err := store.List()
+		require.NoError(t, err)
+		assert.NotContains(t, all, "alice")
+		assert.Len(t, all["bob"], 1)
+	})
+
+	t.Run("without per-user keys nothing is shredded", func(t *testing.T) {
+		plain, err := storage.NewEncryptedFileVectorStore(t.TempDir(), key, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, plain.Save(models.FaceVector{UserID: "carol", Vector: []float32{1, 1}, CreatedAt: now}))
+
+		shredded, err := plain.ShredUser("carol")
+		require.NoError(t, err)
+		assert.False(t, shredded)
+	})
+}
+
+func TestVerificationHandler_EraseUser(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "test-admin-key",
+		PerUserKeys:         true,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	require.NoError(t, service.RegisterFace("erased-user", createTestVideoData()))
+	result, err := service.VerifyVideo(&models.VerificationRequest{
+		VideoData: createTestVideoData(),
+		UserID:    "erased-user",
+		SessionID: "erasure-session",
+	})
+	require.NoError(t, err)
+
+	erase := func(adminKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("DELETE", "/api/v1/faces/erased-user", nil)
+		req.Header.Set("X-Admin-Key", adminKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requires the admin key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, erase("wrong-key").Code)
+	})
+
+	t.Run("erases enrollments and records and returns a receipt", func(t *testing.T) {
+		w := erase("test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data models.ErasureReceipt `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		receipt := response.Data
+		assert.Equal(t, "erased-user", receipt.UserID)
+		assert.False(t, receipt.ErasedAt.IsZero())
+		assert.Equal(t, 1, receipt.Enrollments)
+		assert.Equal(t, 1, receipt.Verifications)
+		assert.Equal(t, 2, receipt.Records)
+		assert.True(t, receipt.CryptoShredded)
+
+		assert.Zero(t, service.TemplateCount("erased-user"))
+		_, ok := service.GetVerificationRecord(result.VerificationID)
+		assert.False(t, ok)
+		_, ok = service.GetVerificationResult(result.VerificationID)
+		assert.False(t, ok)
+	})
+
+	t.Run("erasing again returns an empty receipt", func(t *testing.T) {
+		w := erase("test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data models.ErasureReceipt `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Zero(t, response.Data.Records)
+	})
+}
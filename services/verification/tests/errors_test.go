@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	apperrors "connect-hub/verification-service/internal/errors"
+	"connect-hub/verification-service/internal/middleware"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	t.Run("variants match their catalogue error", func(t *testing.T) {
+		err := apperrors.ErrInvalidVideo.WithMessage("invalid file type: text/plain")
+		assert.True(t, errors.Is(err, apperrors.ErrInvalidVideo))
+		assert.False(t, errors.Is(err, apperrors.ErrMissingVideo))
+		assert.Equal(t, http.StatusBadRequest, err.Status)
+
+		// The catalogue error itself is left alone
+		assert.Equal(t, "Invalid video file", apperrors.ErrInvalidVideo.Message)
+	})
+
+	t.Run("wrapped causes stay out of the body", func(t *testing.T) {
+		cause := errors.New("open /var/lib/faces/face_vectors.enc: permission denied")
+		err := fmt.Errorf("register: %w", apperrors.ErrRegistrationFailed.Wrap(cause))
+
+		apiErr := apperrors.From(err)
+		assert.Equal(t, "REGISTRATION_FAILED", apiErr.Code)
+		assert.ErrorIs(t, err, cause)
+		assert.Equal(t, map[string]interface{}{
+			"error": "Face registration failed",
+			"code":  "REGISTRATION_FAILED",
+		}, apiErr.Body())
+	})
+
+	t.Run("unknown errors are internal", func(t *testing.T) {
+		apiErr := apperrors.From(errors.New("boom"))
+		assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+		assert.Equal(t, "INTERNAL_ERROR", apiErr.Code)
+		assert.NotContains(t, apiErr.Body()["error"], "boom")
+	})
+
+	t.Run("panics answer with the internal error", func(t *testing.T) {
+		router := gin.New()
+		router.Use(middleware.Recovery(zap.NewNop()))
+		router.GET("/panic", func(c *gin.Context) { panic("secret state") })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "INTERNAL_ERROR", body["code"])
+		assert.NotContains(t, w.Body.String(), "secret state")
+	})
+}
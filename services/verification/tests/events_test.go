@@ -0,0 +1,351 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/kafka"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestKafkaProducer(t *testing.T) {
+	broker := newFakeKafka(t, 3)
+	producer := kafka.NewProducer([]string{broker.addr}, "test", time.Second)
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, producer.Produce(ctx, "events", []kafka.Message{
+		{Key: []byte("alice"), Value: []byte("1"), Time: now},
+		{Key: []byte("bob"), Value: []byte("2"), Time: now},
+		{Key: []byte("alice"), Value: []byte("3"), Time: now.Add(time.Millisecond)},
+	}))
+
+	records := broker.records()
+	require.Len(t, records, 3)
+	partitions := make(map[string]int32)
+	var alice []string
+	for _, record := range records {
+		if previous, ok := partitions[record.key]; ok {
+			assert.Equal(t, previous, record.partition, "one key, one partition")
+		}
+		partitions[record.key] = record.partition
+		if record.key == "alice" {
+			alice = append(alice, record.value)
+		}
+	}
+	assert.Equal(t, []string{"1", "3"}, alice)
+
+	broker.failing.Store(true)
+	err := producer.Produce(ctx, "events", []kafka.Message{{Key: []byte("alice"), Value: []byte("4"), Time: now}})
+	assert.Error(t, err)
+	assert.Error(t, producer.Produce(ctx, "missing", []kafka.Message{{Value: []byte("5"), Time: now}}))
+}
+
+func TestFileEventOutbox(t *testing.T) {
+	outbox, err := storage.NewFileEventOutbox(filepath.Join(t.TempDir(), "outbox.log"), time.Second)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, outbox.Add(models.OutboxEvent{
+			ID:             strconv.Itoa(i),
+			Type:           models.EventVerificationCompleted,
+			UserID:         []string{"alice", "bob"}[i%2],
+			VerificationID: "v" + strconv.Itoa(i),
+		}))
+	}
+
+	// A failed publish keeps the events for the next drain
+	_, err = outbox.Drain(2, func([]models.OutboxEvent) error { return errors.New("broker down") })
+	require.Error(t, err)
+
+	var published []string
+	collect := func(events []models.OutboxEvent) error {
+		for _, event := range events {
+			published = append(published, event.ID)
+		}
+		return nil
+	}
+	n, err := outbox.Drain(2, collect)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"0", "1"}, published)
+
+	// Erasure drops the user's pending events only
+	erased, err := outbox.Erase("bob", map[string]bool{"v4": true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, erased)
+
+	n, err = outbox.Drain(10, collect)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"0", "1", "2"}, published)
+
+	// Fully drained, the outbox starts over empty
+	n, err = outbox.Drain(10, collect)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestEventPublishing(t *testing.T) {
+	broker := newFakeKafka(t, 1)
+	cfg := &config.Config{
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		KafkaBrokers:        broker.addr,
+		KafkaTopic:          "events",
+		KafkaPollIntervalMs: 20,
+	}
+
+	// Events queued while the broker is down are published once it is back
+	broker.failing.Store(true)
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	receipt, err := service.EraseUser("alice")
+	require.NoError(t, err)
+	assert.Zero(t, receipt.OutboxEvents)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, broker.records())
+
+	broker.failing.Store(false)
+	require.Eventually(t, func() bool { return len(broker.records()) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	record := broker.records()[0]
+	assert.Equal(t, "alice", record.key)
+	var event models.OutboxEvent
+	require.NoError(t, json.Unmarshal([]byte(record.value), &event))
+	assert.Equal(t, models.EventUserErased, event.Type)
+	assert.Equal(t, "alice", event.UserID)
+	assert.NotEmpty(t, event.ID)
+}
+
+type kafkaRecord struct {
+	topic     string
+	partition int32
+	key       string
+	value     string
+}
+
+// fakeKafka is a single broker answering Metadata v1 and Produce v3 for
+// topics "events" with the given number of partitions.
+type fakeKafka struct {
+	addr       string
+	partitions int32
+	failing    atomic.Bool
+
+	mu       sync.Mutex
+	received []kafkaRecord
+}
+
+func newFakeKafka(t *testing.T, partitions int32) *fakeKafka {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	broker := &fakeKafka{addr: listener.Addr().String(), partitions: partitions}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go broker.serve(t, conn)
+		}
+	}()
+	return broker
+}
+
+func (b *fakeKafka) records() []kafkaRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]kafkaRecord(nil), b.received...)
+}
+
+func (b *fakeKafka) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return
+		}
+		req := &wire{buf: frame}
+		apiKey := req.int16()
+		req.int16() // version
+		correlationID := req.int32()
+		req.str() // client ID
+
+		resp := &wire{}
+		resp.putInt32(correlationID)
+		switch apiKey {
+		case 3:
+			b.metadata(req, resp)
+		case 0:
+			b.produce(t, req, resp)
+		}
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(resp.buf)))
+		conn.Write(append(out, resp.buf...))
+	}
+}
+
+func (b *fakeKafka) metadata(req, resp *wire) {
+	host, portStr, _ := net.SplitHostPort(b.addr)
+	port, _ := strconv.Atoi(portStr)
+
+	resp.putInt32(1)
+	resp.putInt32(0)
+	resp.putStr(host)
+	resp.putInt32(int32(port))
+	resp.putInt16(-1) // rack
+	resp.putInt32(0)  // controller
+
+	count := req.int32()
+	resp.putInt32(count)
+	for i := int32(0); i < count; i++ {
+		topic := req.str()
+		if topic != "events" {
+			resp.putInt16(3) // UNKNOWN_TOPIC_OR_PARTITION
+			resp.putStr(topic)
+			resp.buf = append(resp.buf, 0)
+			resp.putInt32(0)
+			continue
+		}
+		resp.putInt16(0)
+		resp.putStr(topic)
+		resp.buf = append(resp.buf, 0)
+		resp.putInt32(b.partitions)
+		for p := int32(0); p < b.partitions; p++ {
+			resp.putInt16(0)
+			resp.putInt32(p)
+			resp.putInt32(0) // leader
+			resp.putInt32(1)
+			resp.putInt32(0) // replicas
+			resp.putInt32(1)
+			resp.putInt32(0) // in-sync replicas
+		}
+	}
+}
+
+func (b *fakeKafka) produce(t *testing.T, req, resp *wire) {
+	req.int16() // transactional ID
+	assert.Equal(t, int16(-1), req.int16(), "acks from all replicas")
+	req.int32() // timeout
+
+	errorCode := int16(0)
+	if b.failing.Load() {
+		errorCode = 6 // NOT_LEADER_OR_FOLLOWER
+	}
+
+	topics := req.int32()
+	resp.putInt32(topics)
+	for i := int32(0); i < topics; i++ {
+		topic := req.str()
+		resp.putStr(topic)
+		partitions := req.int32()
+		resp.putInt32(partitions)
+		for j := int32(0); j < partitions; j++ {
+			partition := req.int32()
+			batch := req.take(int(req.int32()))
+			if errorCode == 0 {
+				b.mu.Lock()
+				b.received = append(b.received, decodeBatch(t, topic, partition, batch)...)
+				b.mu.Unlock()
+			}
+			resp.putInt32(partition)
+			resp.putInt16(errorCode)
+			resp.putInt64(0)
+			resp.putInt64(-1)
+		}
+	}
+	resp.putInt32(0) // throttle time
+}
+
+func decodeBatch(t *testing.T, topic string, partition int32, batch []byte) []kafkaRecord {
+	require.GreaterOrEqual(t, len(batch), 61)
+	assert.Equal(t, byte(2), batch[16], "magic")
+	assert.Equal(t, binary.BigEndian.Uint32(batch[17:21]), crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)))
+	assert.Equal(t, int(binary.BigEndian.Uint32(batch[8:12])), len(batch)-12, "batch length")
+
+	count := int(binary.BigEndian.Uint32(batch[57:61]))
+	data := batch[61:]
+	varint := func() int64 {
+		v, n := binary.Varint(data)
+		data = data[n:]
+		return v
+	}
+	bytesField := func() string {
+		n := varint()
+		if n < 0 {
+			return ""
+		}
+		s := string(data[:n])
+		data = data[n:]
+		return s
+	}
+
+	var records []kafkaRecord
+	for i := 0; i < count; i++ {
+		varint()        // length
+		data = data[1:] // attributes
+		varint()        // timestamp delta
+		varint()        // offset delta
+		key := bytesField()
+		value := bytesField()
+		varint() // headers
+		records = append(records, kafkaRecord{topic: topic, partition: partition, key: key, value: value})
+	}
+	return records
+}
+
+// wire reads and writes big-endian Kafka protocol fields.
+type wire struct {
+	buf []byte
+}
+
+func (w *wire) take(n int) []byte {
+	b := w.buf[:n]
+	w.buf = w.buf[n:]
+	return b
+}
+
+func (w *wire) int16() int16 { return int16(binary.BigEndian.Uint16(w.take(2))) }
+func (w *wire) int32() int32 { return int32(binary.BigEndian.Uint32(w.take(4))) }
+
+func (w *wire) str() string {
+	n := w.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(w.take(int(n)))
+}
+
+func (w *wire) putInt16(v int16) { w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(v)) }
+func (w *wire) putInt32(v int32) { w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v)) }
+func (w *wire) putInt64(v int64) { w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v)) }
+
+func (w *wire) putStr(s string) {
+	w.putInt16(int16(len(s)))
+	w.buf = append(w.buf, s...)
+}
@@ -1,8 +1,17 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,10 +27,10 @@ import (
 func TestFaceVerificationService_VerifyVideo(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	cfg := &config.Config{
-		LivenessThreshold:  0.85,
+		LivenessThreshold:   0.85,
 		SimilarityThreshold: 0.75,
-		StoragePath:        "/tmp/test_storage",
-		EncryptionKey:      "test-encryption-key-for-testing-only",
+		StoragePath:         "/tmp/test_storage",
+		EncryptionKey:       "test-encryption-key-for-testing-only",
 	}
 
 	service, err := services.NewFaceVerificationService(logger, cfg)
@@ -83,10 +92,10 @@ func TestFaceVerificationService_VerifyVideo(t *testing.T) {
 func TestFaceVerificationService_RegisterFace(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	cfg := &config.Config{
-		LivenessThreshold:  0.85,
+		LivenessThreshold:   0.85,
 		SimilarityThreshold: 0.75,
-		StoragePath:        "/tmp/test_storage",
-		EncryptionKey:      "test-encryption-key-for-testing-only",
+		StoragePath:         "/tmp/test_storage",
+		EncryptionKey:       "test-encryption-key-for-testing-only",
 	}
 
 	service, err := services.NewFaceVerificationService(logger, cfg)
@@ -227,6 +236,197 @@ func TestFaceVerificationService_CosineSimilarity(t *testing.T) {
 	})
 }
 
+func TestFaceVerificationService_DatasetExport(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	exportPath := filepath.Join(t.TempDir(), "dataset.jsonl")
+	cfg := &config.Config{
+		LivenessThreshold:    0.85,
+		SimilarityThreshold:  0.75,
+		StoragePath:          t.TempDir(),
+		EncryptionKey:        "test-encryption-key-for-testing-only",
+		DatasetExportEnabled: true,
+		DatasetExportPath:    exportPath,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+
+	_, err = service.VerifyVideo(&models.VerificationRequest{
+		VideoData: createTestVideoData(),
+		UserID:    "test-user-dataset",
+		SessionID: "test-session-dataset",
+	})
+	require.NoError(t, err)
+	service.Close()
+
+	data, err := os.ReadFile(exportPath)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	t.Run("only scalar features and labels", func(t *testing.T) {
+		var row map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+
+		keys := make([]string, 0, len(row))
+		for key := range row {
+			keys = append(keys, key)
+		}
+		assert.ElementsMatch(t, []string{"features", "is_live", "verified"}, keys)
+
+		features, ok := row["features"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Contains(t, features, "motion_score")
+		assert.Contains(t, features, "texture_score")
+		assert.Contains(t, features, "color_score")
+		for name, value := range features {
+			_, isScalar := value.(float64)
+			assert.True(t, isScalar, "feature %s is not a scalar", name)
+		}
+	})
+
+	t.Run("no identifiers, images or vectors", func(t *testing.T) {
+		for _, forbidden := range []string{"user_id", "session_id", "vector", "descriptor", "video_data", "image"} {
+			assert.NotContains(t, lines[0], forbidden)
+		}
+	})
+}
+
+func TestFaceVerificationService_DatasetExportDisabled(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	exportPath := filepath.Join(t.TempDir(), "dataset.jsonl")
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		DatasetExportPath:   exportPath,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	_, err = service.VerifyVideo(&models.VerificationRequest{
+		VideoData: createTestVideoData(),
+		SessionID: "test-session-dataset-off",
+	})
+	require.NoError(t, err)
+
+	_, err = os.Stat(exportPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFaceVerificationService_CameraBlocked(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		CameraCheckEnabled:  true,
+		CameraMinBrightness: 0.04,
+		CameraMinVariance:   0.0001,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	encode := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}))
+		return buf.Bytes()
+	}
+
+	t.Run("all-black frame", func(t *testing.T) {
+		black := image.NewRGBA(image.Rect(0, 0, 320, 240))
+		draw.Draw(black, black.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: encode(black),
+			SessionID: "test-session-black",
+		})
+
+		require.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Equal(t, models.ReasonCameraBlocked, result.Reason)
+		assert.NotEqual(t, models.ReasonLivenessFailed, result.Reason)
+		assert.Contains(t, result.Error, "Camera")
+	})
+
+	t.Run("flat gray frame", func(t *testing.T) {
+		gray := image.NewRGBA(image.Rect(0, 0, 320, 240))
+		draw.Draw(gray, gray.Bounds(), image.NewUniform(color.Gray{Y: 128}), image.Point{}, draw.Src)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: encode(gray),
+			SessionID: "test-session-gray",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ReasonCameraBlocked, result.Reason)
+	})
+
+	t.Run("normal frame is not flagged", func(t *testing.T) {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: encode(createTestImage(320, 240)),
+			SessionID: "test-session-normal",
+		})
+
+		require.NoError(t, err)
+		assert.NotEqual(t, models.ReasonCameraBlocked, result.Reason)
+	})
+}
+
+func TestFaceVerificationService_ConcurrentSavers(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	storagePath := t.TempDir()
+
+	newConfig := func() *config.Config {
+		return &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         storagePath,
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			StorageLockTimeout:  30,
+		}
+	}
+
+	// Independent service instances sharing one storage path, as in the
+	// concurrent benchmark or multiple replicas on a shared volume
+	const savers = 6
+	var wg sync.WaitGroup
+	errs := make(chan error, savers)
+	for i := 0; i < savers; i++ {
+		service, err := services.NewFaceVerificationService(logger, newConfig())
+		require.NoError(t, err)
+		defer service.Close()
+
+		wg.Add(1)
+		go func(i int, service *services.FaceVerificationService) {
+			defer wg.Done()
+			errs <- service.RegisterFace(fmt.Sprintf("concurrent-user-%d", i), createTestVideoData())
+		}(i, service)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// A fresh instance must decrypt the file and see every enrollment
+	reloaded, err := services.NewFaceVerificationService(logger, newConfig())
+	require.NoError(t, err)
+	defer reloaded.Close()
+
+	for i := 0; i < savers; i++ {
+		assert.Equal(t, 1, reloaded.TemplateCount(fmt.Sprintf("concurrent-user-%d", i)), "enrollment %d was lost", i)
+	}
+}
+
 // Helper functions
 
 func createTestVideoData() []byte {
@@ -267,10 +467,10 @@ func createTestFrames(count int) []image.Image {
 func BenchmarkFaceVerificationService_VerifyVideo(b *testing.B) {
 	logger := zaptest.NewLogger(b)
 	cfg := &config.Config{
-		LivenessThreshold:  0.85,
+		LivenessThreshold:   0.85,
 		SimilarityThreshold: 0.75,
-		StoragePath:        "/tmp/benchmark_storage",
-		EncryptionKey:      "benchmark-encryption-key",
+		StoragePath:         "/tmp/benchmark_storage",
+		EncryptionKey:       "benchmark-encryption-key",
 	}
 
 	service, err := services.NewFaceVerificationService(logger, cfg)
@@ -312,4 +512,4 @@ func BenchmarkFaceVerificationService_LivenessDetection(b *testing.B) {
 			b.Fatal(err)
 		}
 	}
-}
\ No newline at end of file
+}
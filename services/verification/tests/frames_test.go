@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestVerificationHandler_VerifyFrames(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newRouter := func(t *testing.T, cfg *config.Config) *gin.Engine {
+		cfg.LivenessThreshold = 0.5
+		cfg.SimilarityThreshold = 0.75
+		cfg.StoragePath = t.TempDir()
+		cfg.EncryptionKey = "test-encryption-key-for-testing-only"
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return router
+	}
+
+	enabled := func() *config.Config {
+		return &config.Config{
+			FrameSubmissionEnabled: true,
+			MinSubmittedFrames:     2,
+			MaxSubmittedFrames:     10,
+			MaxFrameSize:           512 * 1024,
+		}
+	}
+
+	submit := func(t *testing.T, router *gin.Engine, frames [][]byte) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, contentType := createFramesForm(t, frames)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify/frames", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("pre-extracted frames run the pipeline without video decoding", func(t *testing.T) {
+		router := newRouter(t, enabled())
+		frames := encodeJPEGFrames(t, createPanningFrames(4, 4, 0))
+
+		decodesBefore := metrics.VideoDecodes.Value()
+		w, response := submit(t, router, frames)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, decodesBefore, metrics.VideoDecodes.Value())
+
+		data := response["data"].(map[string]interface{})
+		assert.NotEmpty(t, data["verification_id"])
+		assert.Greater(t, data["liveness_score"].(float64), 0.0)
+	})
+
+	t.Run("frame count is validated", func(t *testing.T) {
+		router := newRouter(t, enabled())
+		all := encodeJPEGFrames(t, createPanningFrames(11, 1, 0))
+
+		w, response := submit(t, router, all[:1])
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_FRAME_COUNT", response["code"])
+
+		w, response = submit(t, router, all)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_FRAME_COUNT", response["code"])
+	})
+
+	t.Run("per-frame size is validated", func(t *testing.T) {
+		cfg := enabled()
+		cfg.MaxFrameSize = 1024
+		router := newRouter(t, cfg)
+
+		w, response := submit(t, router, encodeJPEGFrames(t, createPanningFrames(3, 4, 0)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "FRAME_TOO_LARGE", response["code"])
+	})
+
+	t.Run("frames must be JPEG", func(t *testing.T) {
+		router := newRouter(t, enabled())
+
+		var pngFrame bytes.Buffer
+		require.NoError(t, png.Encode(&pngFrame, createPanningFrames(1, 0, 0)[0]))
+		frames := encodeJPEGFrames(t, createPanningFrames(2, 4, 0))
+
+		w, response := submit(t, router, append(frames, pngFrame.Bytes()))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_FRAME", response["code"])
+	})
+
+	t.Run("frames must share dimensions", func(t *testing.T) {
+		router := newRouter(t, enabled())
+		frames := encodeJPEGFrames(t, createPanningFrames(2, 4, 0))
+		frames = append(frames, encodeJPEGFrames(t, []image.Image{createTestImage(64, 48)})...)
+
+		w, response := submit(t, router, frames)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_FRAME", response["code"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		router := newRouter(t, &config.Config{})
+
+		w, response := submit(t, router, encodeJPEGFrames(t, createPanningFrames(3, 4, 0)))
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Equal(t, "FRAME_SUBMISSION_DISABLED", response["code"])
+	})
+}
+
+func encodeJPEGFrames(t *testing.T, frames []image.Image) [][]byte {
+	encoded := make([][]byte, 0, len(frames))
+	for _, frame := range frames {
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, frame, &jpeg.Options{Quality: 90}))
+		encoded = append(encoded, buf.Bytes())
+	}
+	return encoded
+}
+
+// createFramesForm builds a multipart body with one "frame" part per frame.
+func createFramesForm(t *testing.T, frames [][]byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for i, frame := range frames {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="frame"; filename="frame%d.jpg"`, i))
+		header.Set("Content-Type", "image/jpeg")
+		part, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = part.Write(frame)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestRedisVectorStore(t *testing.T) {
+	store, err := storage.NewRedisVectorStore("redis://"+newFakeRedis(t), storage.KeyRing{
+		Current: storage.EncryptionKey{ID: storage.DefaultKeyID, Secret: "test-encryption-key-for-testing-only"},
+	})
+	require.NoError(t, err)
+
+	created := time.Now().Add(-time.Hour)
+	for i, userID := range []string{"alice", "bob", "alice"} {
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    userID,
+			Vector:    []float32{float32(i), 1, 2},
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+			Version:   "1.0",
+		}))
+	}
+
+	vectors, err := store.Load("alice")
+	require.NoError(t, err)
+	require.Len(t, vectors, 2)
+	assert.Equal(t, []float32{0, 1, 2}, vectors[0].Vector, "oldest first")
+	assert.Equal(t, []float32{2, 1, 2}, vectors[1].Vector)
+
+	gallery, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, gallery, 2)
+	assert.Len(t, gallery["bob"], 1)
+
+	require.NoError(t, store.Delete("alice"))
+	vectors, err = store.Load("alice")
+	require.NoError(t, err)
+	assert.Empty(t, vectors)
+	gallery, err = store.List()
+	require.NoError(t, err)
+	assert.Len(t, gallery, 1)
+
+	assert.NoError(t, store.CheckHealth(context.Background()))
+}
+
+// TestGallerySync runs two replicas over one Redis and checks each picks
+// up the other's writes.
+func TestGallerySync(t *testing.T) {
+	redisURL := "redis://" + newFakeRedis(t)
+	store, err := storage.NewRedisVectorStore(redisURL, storage.KeyRing{
+		Current: storage.EncryptionKey{ID: storage.DefaultKeyID, Secret: "test-encryption-key-for-testing-only"},
+	})
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(11))
+	enroll := func(userID string) []float32 {
+		vector := randomVector(rng, 128)
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    userID,
+			Vector:    vector,
+			CreatedAt: time.Now().Add(-time.Hour),
+			Version:   "1.0",
+		}))
+		return vector
+	}
+	alice := enroll("alice")
+
+	newReplica := func() *services.FaceVerificationService {
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StorageType:         "redis",
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			RedisURL:            redisURL,
+			GallerySync:         "redis",
+		})
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+	first, second := newReplica(), newReplica()
+
+	// Both are subscribed once an announcement reaches them both
+	notifier, err := storage.NewRedisGalleryNotifier(redisURL)
+	require.NoError(t, err)
+	before := metrics.GalleryInvalidations.Value()
+	require.Eventually(t, func() bool {
+		require.NoError(t, notifier.Publish(context.Background(), "nobody"))
+		return metrics.GalleryInvalidations.Value()-before >= 2
+	}, 5*time.Second, 20*time.Millisecond)
+
+	for _, service := range []*services.FaceVerificationService{first, second} {
+		_, matched, err := service.MatchTemplate("alice", alice)
+		require.NoError(t, err)
+		assert.True(t, matched)
+	}
+
+	t.Run("unannounced enrollments are read through", func(t *testing.T) {
+		bob := enroll("bob")
+		_, matched, err := second.MatchTemplate("bob", bob)
+		require.NoError(t, err)
+		assert.True(t, matched)
+	})
+
+	t.Run("erasure reaches the other replica", func(t *testing.T) {
+		_, err := first.EraseUser("alice")
+		require.NoError(t, err)
+		_, _, err = first.MatchTemplate("alice", alice)
+		assert.ErrorIs(t, err, services.ErrNotEnrolled)
+		assert.Eventually(t, func() bool {
+			_, _, err := second.MatchTemplate("alice", alice)
+			return errors.Is(err, services.ErrNotEnrolled)
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("readiness checks the subscription", func(t *testing.T) {
+		readiness := first.Readiness(context.Background())
+		var names []string
+		for _, check := range readiness.Checks {
+			names = append(names, check.Name)
+		}
+		assert.Contains(t, names, "gallery_sync")
+	})
+}
+
+// stalledListStore holds List until released, handing out the gallery as it
+// was when List was called, like a slow reload of a large store.
+type stalledListStore struct {
+	*memoryVectorStore
+	listing chan struct{}
+	release chan struct{}
+}
+
+func (s *stalledListStore) List() (map[string][]models.FaceVector, error) {
+	gallery, err := s.memoryVectorStore.List()
+	close(s.listing)
+	<-s.release
+	return gallery, err
+}
+
+// TestGalleryReloadOrdering checks a reload of the whole gallery keeps the
+// users updated while it listed the store.
+func TestGalleryReloadOrdering(t *testing.T) {
+	store := &memoryVectorStore{vectors: make(map[string][]models.FaceVector)}
+	vector := randomVector(rand.New(rand.NewSource(5)), 128)
+	require.NoError(t, store.Save(models.FaceVector{
+		UserID:    "alice",
+		Vector:    vector,
+		CreatedAt: time.Now().Add(-time.Hour),
+		Version:   "1.0",
+	}))
+
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AnnIndexEnabled:     true,
+	})
+	require.NoError(t, err)
+	defer service.Close()
+	require.NoError(t, service.SetVectorStore(store))
+
+	stalled := &stalledListStore{
+		memoryVectorStore: store,
+		listing:           make(chan struct{}),
+		release:           make(chan struct{}),
+	}
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- service.SetVectorStore(stalled)
+	}()
+	<-stalled.listing
+
+	// Erased after the reload listed the store, so its snapshot still has alice
+	_, err = service.EraseUser("alice")
+	require.NoError(t, err)
+	close(stalled.release)
+	require.NoError(t, <-reloaded)
+
+	_, _, err = service.MatchTemplate("alice", vector)
+	assert.ErrorIs(t, err, services.ErrNotEnrolled)
+	assert.Empty(t, service.SearchGallery(vector, 1))
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/grpcapi"
+	"connect-hub/verification-service/internal/grpcapi/verificationpb"
+	"connect-hub/verification-service/internal/services"
+)
+
+// errorReason extracts the REST-style code from a gRPC error status.
+func errorReason(t *testing.T, err error) (codes.Code, string) {
+	st, ok := status.FromError(err)
+	require.True(t, ok, "not a status error: %v", err)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info.Reason
+		}
+	}
+	return st.Code(), ""
+}
+
+func TestGRPCServer(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	service, err := services.NewFaceVerificationService(logger, &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "admin-secret",
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpcapi.NewGRPCServer(service, logger)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := verificationpb.NewVerificationServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("verify and look up the status", func(t *testing.T) {
+		verified, err := client.Verify(ctx, &verificationpb.VerifyRequest{
+			Video:     createTestVideoData(),
+			SessionId: "grpc-session",
+		})
+		require.NoError(t, err)
+		result := verified.GetResult()
+		require.NotEmpty(t, result.GetVerificationId())
+		assert.NotNil(t, result.GetTimestamp())
+
+		statusResponse, err := client.GetStatus(ctx, &verificationpb.GetStatusRequest{
+			VerificationId: result.GetVerificationId(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "completed", statusResponse.GetStatus())
+		assert.Equal(t, result.GetVerified(), statusResponse.GetVerified())
+		assert.Nil(t, statusResponse.GetResult(), "scores need the admin key")
+
+		adminCtx := metadata.AppendToOutgoingContext(ctx, "x-admin-key", "admin-secret")
+		statusResponse, err = client.GetStatus(adminCtx, &verificationpb.GetStatusRequest{
+			VerificationId: result.GetVerificationId(),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, statusResponse.GetResult())
+		assert.Equal(t, result.GetLivenessScore(), statusResponse.GetResult().GetLivenessScore())
+	})
+
+	t.Run("verify needs a capture", func(t *testing.T) {
+		_, err := client.Verify(ctx, &verificationpb.VerifyRequest{UserId: "user-1"})
+		code, reason := errorReason(t, err)
+		assert.Equal(t, codes.InvalidArgument, code)
+		assert.Equal(t, "MISSING_VIDEO_FILE", reason)
+	})
+
+	t.Run("frames need frame submission enabled", func(t *testing.T) {
+		_, err := client.Verify(ctx, &verificationpb.VerifyRequest{
+			Frames: encodeJPEGFrames(t, createPanningFrames(3, 2, 0)),
+		})
+		code, reason := errorReason(t, err)
+		assert.Equal(t, codes.Unimplemented, code)
+		assert.Equal(t, "FRAME_SUBMISSION_DISABLED", reason)
+	})
+
+	t.Run("register validates the user ID", func(t *testing.T) {
+		_, err := client.Register(ctx, &verificationpb.RegisterRequest{
+			UserId: "not a valid id!",
+			Video:  createTestVideoData(),
+		})
+		code, reason := errorReason(t, err)
+		assert.Equal(t, codes.InvalidArgument, code)
+		assert.Equal(t, "INVALID_USER_ID", reason)
+	})
+
+	t.Run("unknown verification is not found", func(t *testing.T) {
+		_, err := client.GetStatus(ctx, &verificationpb.GetStatusRequest{VerificationId: "ver_0000000000"})
+		code, reason := errorReason(t, err)
+		assert.Equal(t, codes.NotFound, code)
+		assert.Equal(t, "VERIFICATION_NOT_FOUND", reason)
+	})
+}
+
+func TestGRPCRoles(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	service, err := services.NewFaceVerificationService(logger, &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		TenantAPIKeys:       "acme:acme-verify-key:verify,acme:acme-review-key:reviewer",
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpcapi.NewGRPCServer(service, logger)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := verificationpb.NewVerificationServiceClient(conn)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+
+	t.Run("verify keys cannot register", func(t *testing.T) {
+		_, err := client.Register(withKey("acme-verify-key"), &verificationpb.RegisterRequest{
+			UserId: "user-1",
+			Video:  createTestVideoData(),
+		})
+		code, reason := errorReason(t, err)
+		assert.Equal(t, codes.PermissionDenied, code)
+		assert.Equal(t, "ROLE_REQUIRED", reason)
+	})
+
+	t.Run("reviewer keys cannot look up verifications", func(t *testing.T) {
+		request := &verificationpb.GetStatusRequest{VerificationId: "ver_0000000000"}
+		_, err := client.GetStatus(withKey("acme-review-key"), request)
+		code, reason := errorReason(t, err)
+		assert.Equal(t, codes.PermissionDenied, code)
+		assert.Equal(t, "ROLE_REQUIRED", reason)
+
+		// Past the role check, the unknown ID is what fails
+		_, err = client.GetStatus(withKey("acme-verify-key"), request)
+		code, _ = errorReason(t, err)
+		assert.Equal(t, codes.NotFound, code)
+	})
+}
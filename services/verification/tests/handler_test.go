@@ -2,10 +2,12 @@ package tests
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"strings"
 	"testing"
 
@@ -16,6 +18,8 @@ import (
 
 	"connect-hub/verification-service/internal/config"
 	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
 	"connect-hub/verification-service/internal/services"
 )
 
@@ -206,10 +210,16 @@ func TestVerificationHandler_GetVerificationStatus(t *testing.T) {
 
 	handler := handlers.NewVerificationHandler(service, logger)
 
+	result, err := service.VerifyVideo(&models.VerificationRequest{
+		VideoData: createTestVideoData(),
+		SessionID: "test-session-status",
+	})
+	require.NoError(t, err)
+
 	t.Run("valid verification ID", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
-		c.Params = gin.Params{{Key: "id", Value: "ver_1234567890"}}
+		c.Params = gin.Params{{Key: "id", Value: result.VerificationID}}
 
 		handler.GetVerificationStatus(c)
 
@@ -219,8 +229,37 @@ func TestVerificationHandler_GetVerificationStatus(t *testing.T) {
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		assert.Equal(t, "ver_1234567890", response["verification_id"])
+		assert.Equal(t, result.VerificationID, response["verification_id"])
 		assert.Equal(t, "completed", response["status"])
+		assert.Equal(t, result.Verified, response["verified"])
+	})
+
+	t.Run("unknown verification ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "ver_1234567890"}}
+
+		handler.GetVerificationStatus(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "VERIFICATION_NOT_FOUND", response["code"])
+	})
+
+	t.Run("pipeline errors are recorded as failed", func(t *testing.T) {
+		failed, err := service.VerifyVideo(&models.VerificationRequest{
+			FrameData: [][]byte{[]byte("not a jpeg")},
+			SessionID: "test-session-status-failed",
+		})
+		require.ErrorIs(t, err, services.ErrInvalidFrame)
+
+		record, ok := service.GetVerificationRecord(failed.VerificationID)
+		require.True(t, ok)
+		assert.Equal(t, models.StatusFailed, record.Status)
+		assert.Equal(t, "test-session-status-failed", record.SessionID)
+		assert.NotEmpty(t, record.ErrorMessage)
 	})
 
 	t.Run("missing verification ID", func(t *testing.T) {
@@ -257,6 +296,150 @@ func TestVerificationHandler_GetVerificationStatus(t *testing.T) {
 	})
 }
 
+func TestVerificationHandler_StatusRedaction(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "test-admin-key",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	result, err := service.VerifyVideo(&models.VerificationRequest{
+		VideoData: createTestVideoData(),
+		SessionID: "test-session-redaction",
+	})
+	require.NoError(t, err)
+
+	handler := handlers.NewVerificationHandler(service, logger)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/status/:id", middleware.IdentifyAdmin(cfg.AdminAPIKey), handler.GetVerificationStatus)
+
+	getStatus := func(adminKey string) map[string]interface{} {
+		req := httptest.NewRequest("GET", "/api/v1/status/"+result.VerificationID, nil)
+		if adminKey != "" {
+			req.Header.Set("X-Admin-Key", adminKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("unauthenticated status omits scores", func(t *testing.T) {
+		response := getStatus("")
+
+		assert.Equal(t, result.VerificationID, response["verification_id"])
+		assert.Contains(t, response, "status")
+		assert.Contains(t, response, "verified")
+		assert.Contains(t, response, "timestamp")
+		assert.NotContains(t, response, "result")
+		assert.NotContains(t, toJSON(response), "confidence")
+		assert.NotContains(t, toJSON(response), "liveness_score")
+	})
+
+	t.Run("wrong admin key is treated as unauthenticated", func(t *testing.T) {
+		response := getStatus("not-the-admin-key")
+
+		assert.NotContains(t, response, "result")
+	})
+
+	t.Run("admin status includes scores", func(t *testing.T) {
+		response := getStatus("test-admin-key")
+
+		full, ok := response["result"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Contains(t, full, "confidence")
+		assert.Contains(t, full, "liveness_score")
+	})
+}
+
+func toJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestVerificationHandler_ETagCaching(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		ETagCachingEnabled:  true,
+		ResultCacheTTL:      60,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	verify := func(video *fileData, ifNoneMatch string) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video": video,
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/verify", body)
+		c.Request.Header.Set("Content-Type", contentType)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		handler.VerifyVideo(c)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	first := verify(createTestVideoFile(), "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	var firstResponse map[string]interface{}
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &firstResponse))
+	firstID := firstResponse["data"].(map[string]interface{})["verification_id"]
+
+	t.Run("repeat with matching If-None-Match gets 304", func(t *testing.T) {
+		w := verify(createTestVideoFile(), etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Equal(t, firstID, w.Header().Get("X-Verification-Id"))
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("new capture gets a fresh 200", func(t *testing.T) {
+		video := createTestVideoFile()
+		video.data[len(video.data)-1] ^= 0xFF
+
+		w := verify(video, etag)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("repeat without If-None-Match is reprocessed", func(t *testing.T) {
+		w := verify(createTestVideoFile(), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+}
+
 // Helper types and functions
 
 type fileData struct {
@@ -272,11 +455,16 @@ func createMultipartForm(fields map[string]interface{}) (*bytes.Buffer, string,
 	for key, value := range fields {
 		switch v := value.(type) {
 		case *fileData:
-			part, err := writer.CreateFormFile(key, v.filename)
-			if err != nil {
+			if err := writeFilePart(writer, key, v); err != nil {
 				return nil, "", err
 			}
-			part.Write(v.data)
+		case []*fileData:
+			// Repeats the field once per file
+			for _, file := range v {
+				if err := writeFilePart(writer, key, file); err != nil {
+					return nil, "", err
+				}
+			}
 		case string:
 			writer.WriteField(key, v)
 		}
@@ -286,12 +474,26 @@ func createMultipartForm(fields map[string]interface{}) (*bytes.Buffer, string,
 	return body, writer.FormDataContentType(), nil
 }
 
+func writeFilePart(writer *multipart.Writer, key string, file *fileData) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, key, file.filename))
+	header.Set("Content-Type", file.contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(file.data)
+	return err
+}
+
 func createTestVideoFile() *fileData {
-	// Create a small test video file (actually just test data)
+	// Create a small test video file (actually just test data behind a
+	// WebM EBML header, which is all upload sniffing looks at)
 	data := make([]byte, 1024)
 	for i := range data {
 		data[i] = byte(i % 256)
 	}
+	copy(data, []byte{0x1A, 0x45, 0xDF, 0xA3})
 
 	return &fileData{
 		filename:    "test.webm",
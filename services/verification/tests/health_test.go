@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestHealthProbes(t *testing.T) {
+	newRouter := func(t *testing.T, storagePath string) (*gin.Engine, *services.FaceVerificationService) {
+		logger := zaptest.NewLogger(t)
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         storagePath,
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return router, service
+	}
+	get := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	readiness := func(t *testing.T, w *httptest.ResponseRecorder) map[string]models.DependencyCheck {
+		var response models.Readiness
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		checks := make(map[string]models.DependencyCheck)
+		for _, check := range response.Checks {
+			checks[check.Name] = check
+		}
+		return checks
+	}
+
+	t.Run("liveness", func(t *testing.T) {
+		router, _ := newRouter(t, t.TempDir())
+		for _, path := range []string{"/healthz", "/health"} {
+			w := get(router, path)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+		}
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		router, _ := newRouter(t, t.TempDir())
+		w := get(router, "/readyz")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		checks := readiness(t, w)
+		assert.True(t, checks["recognizer"].Healthy)
+		assert.True(t, checks["vector_store"].Healthy)
+		assert.NotContains(t, checks, "object_store")
+	})
+
+	t.Run("unwritable storage", func(t *testing.T) {
+		// A path below a regular file can never be created
+		file := filepath.Join(t.TempDir(), "not-a-directory")
+		require.NoError(t, os.WriteFile(file, nil, 0600))
+
+		router, _ := newRouter(t, filepath.Join(file, "storage"))
+		w := get(router, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		checks := readiness(t, w)
+		assert.False(t, checks["vector_store"].Healthy)
+		assert.NotEmpty(t, checks["vector_store"].Error)
+		assert.True(t, checks["recognizer"].Healthy)
+	})
+
+	t.Run("missing object store", func(t *testing.T) {
+		router, service := newRouter(t, t.TempDir())
+		service.SetObjectStore(storage.NewFileObjectStore(filepath.Join(t.TempDir(), "missing")))
+
+		w := get(router, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.False(t, readiness(t, w)["object_store"].Healthy)
+	})
+
+	t.Run("shut down", func(t *testing.T) {
+		router, service := newRouter(t, t.TempDir())
+		service.Close()
+
+		w := get(router, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.False(t, readiness(t, w)["recognizer"].Healthy)
+		assert.Equal(t, http.StatusOK, get(router, "/healthz").Code)
+	})
+}
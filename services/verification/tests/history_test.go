@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestVerificationHandler_UserHistory(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		JWTSecret:           "test-jwt-secret",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	for _, req := range []*models.VerificationRequest{
+		{UserID: "history-user-a", SessionID: "s1", Device: "iPhone 15"},
+		{UserID: "history-user-a", SessionID: "s2", Device: "Pixel 8"},
+		{UserID: "history-user-b", SessionID: "s3", Device: "Galaxy S24"},
+	} {
+		req.VideoData = createTestVideoData()
+		_, err := service.VerifyVideo(req)
+		require.NoError(t, err)
+	}
+
+	handler := handlers.NewVerificationHandler(service, logger)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/users/:id/history", middleware.JWTAuth(cfg.JWTSecret), handler.GetUserHistory)
+
+	getHistory := func(userID, token, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/users/"+userID+"/history"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tokenA := signTestJWT(t, cfg.JWTSecret, "history-user-a", time.Now().Add(time.Hour))
+
+	t.Run("user sees only their own redacted history", func(t *testing.T) {
+		w := getHistory("history-user-a", tokenA, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Total int                      `json:"total"`
+			Items []map[string]interface{} `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, 2, response.Total)
+		require.Len(t, response.Items, 2)
+		assert.Equal(t, "Pixel 8", response.Items[0]["device"])
+		assert.Equal(t, "iPhone 15", response.Items[1]["device"])
+		for _, item := range response.Items {
+			assert.NotContains(t, item, "confidence")
+			assert.NotContains(t, item, "liveness_score")
+			assert.NotContains(t, item, "user_id")
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		w := getHistory("history-user-a", tokenA, "?page=2&page_size=1")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Total int                      `json:"total"`
+			Items []map[string]interface{} `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, 2, response.Total)
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, "iPhone 15", response.Items[0]["device"])
+	})
+
+	t.Run("denied another user's history", func(t *testing.T) {
+		w := getHistory("history-user-b", tokenA, "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "Galaxy S24")
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		w := getHistory("history-user-a", "", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token signed with another secret", func(t *testing.T) {
+		forged := signTestJWT(t, "some-other-secret", "history-user-a", time.Now().Add(time.Hour))
+
+		w := getHistory("history-user-a", forged, "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := signTestJWT(t, cfg.JWTSecret, "history-user-a", time.Now().Add(-time.Minute))
+
+		w := getHistory("history-user-a", expired, "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func signTestJWT(t *testing.T, secret, subject string, expiresAt time.Time) string {
+	t.Helper()
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"sub": subject,
+		"exp": expiresAt.Unix(),
+	})
+	require.NoError(t, err)
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	return header + "." + payload + "." + signature
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/i18n"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestReasonLocalization(t *testing.T) {
+	reasons := []string{
+		models.ReasonCameraBlocked,
+		models.ReasonLivenessFailed,
+		models.ReasonLowSimilarity,
+		models.ReasonActionMismatch,
+		models.ReasonEnrollmentNotYetActive,
+		models.ReasonChallengeFailed,
+		models.ReasonNeedsReview,
+		models.ReasonReviewRejected,
+		models.ReasonRiskDenied,
+		models.ReasonInjectionSuspected,
+		models.ReasonNonceNotBound,
+	}
+
+	t.Run("every reason has guidance in every locale", func(t *testing.T) {
+		for _, locale := range []string{"en", "es", "pt"} {
+			for _, reason := range reasons {
+				assert.NotEmpty(t, i18n.Message(locale, reason), "%s/%s", locale, reason)
+			}
+		}
+	})
+
+	t.Run("guidance is translated per locale", func(t *testing.T) {
+		expected := map[string]map[string]string{
+			models.ReasonLivenessFailed: {
+				"en": "We couldn't confirm a live person. Face the camera in good light, move naturally and try again.",
+				"pt": "Não foi possível confirmar uma pessoa real. Olhe para a câmera com boa luz, mova-se naturalmente e tente novamente.",
+			},
+			models.ReasonCameraBlocked: {
+				"en": "Your camera seems to be covered or not sending a picture. Uncover it, improve the lighting and try again.",
+				"es": "Parece que tu cámara está tapada o no envía imagen. Destápala, mejora la iluminación e inténtalo de nuevo.",
+			},
+			models.ReasonLowSimilarity: {
+				"en": "Your face didn't match the registered profile. Remove glasses or hats, face the camera directly and try again.",
+				"es": "Tu rostro no coincide con el perfil registrado. Quítate gafas o gorros, mira directamente a la cámara e inténtalo de nuevo.",
+			},
+		}
+
+		for reason, byLocale := range expected {
+			for locale, message := range byLocale {
+				assert.Equal(t, message, i18n.Message(locale, reason), "%s/%s", locale, reason)
+			}
+		}
+	})
+
+	t.Run("unsupported locale falls back to English", func(t *testing.T) {
+		assert.Equal(t, i18n.Message("en", models.ReasonLowSimilarity), i18n.Message("de", models.ReasonLowSimilarity))
+	})
+
+	t.Run("Accept-Language negotiation", func(t *testing.T) {
+		assert.Equal(t, "pt", i18n.ResolveLocale("pt-BR,pt;q=0.9,en;q=0.8", "en"))
+		assert.Equal(t, "es", i18n.ResolveLocale("de-DE, es;q=0.7", "en"))
+		assert.Equal(t, "en", i18n.ResolveLocale("de-DE", "en"))
+		assert.Equal(t, "pt", i18n.ResolveLocale("", "pt"))
+		assert.Equal(t, "en", i18n.ResolveLocale("", "xx"))
+	})
+}
+
+func TestVerificationHandler_LocalizedReason(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		DefaultLocale:       "en",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	verify := func(acceptLanguage string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video": createTestVideoFile(),
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/verify", body)
+		c.Request.Header.Set("Content-Type", contentType)
+		c.Request.Header.Set("Accept-Language", acceptLanguage)
+
+		handler.VerifyVideo(c)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response["data"].(map[string]interface{})
+	}
+
+	for _, tc := range []struct {
+		acceptLanguage string
+		locale         string
+	}{
+		{"en-US,en;q=0.9", "en"},
+		{"pt-BR,pt;q=0.9", "pt"},
+		{"es", "es"},
+	} {
+		t.Run(tc.locale, func(t *testing.T) {
+			w, data := verify(tc.acceptLanguage)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, models.ReasonLivenessFailed, data["reason"])
+			assert.Equal(t, i18n.Message(tc.locale, models.ReasonLivenessFailed), data["reason_message"])
+			assert.Equal(t, tc.locale, w.Header().Get("Content-Language"))
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		StoragePath:        t.TempDir(),
+		EncryptionKey:      "test-encryption-key-for-testing-only",
+		EnrollmentDisabled: true,
+		IdempotencyEnabled: true,
+	}
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, zaptest.NewLogger(t)), cfg)
+
+	register := func(idempotencyKey, apiKey string) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(map[string]interface{}{"user_id": "alice"})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/api/v1/register", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := register("attempt-1", "client-a")
+	require.Equal(t, http.StatusForbidden, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// Once enrollment opens, a retry still gets the first answer
+	service.SetEnrollmentEnabled(true)
+	retry := register("attempt-1", "client-a")
+	assert.Equal(t, http.StatusForbidden, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+
+	// New keys and other clients run the request
+	w := register("attempt-2", "client-a")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "MISSING_VIDEO_FILE", errorCode(t, w))
+	w = register("attempt-1", "client-b")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = register(strings.Repeat("k", 256), "client-a")
+	assert.Equal(t, "INVALID_IDEMPOTENCY_KEY", errorCode(t, w))
+}
+
+func TestIdempotencyStores(t *testing.T) {
+	redis := newFakeRedis(t)
+	redisStore, err := storage.NewRedisIdempotencyStore("redis://" + redis)
+	require.NoError(t, err)
+
+	for name, store := range map[string]storage.IdempotencyStore{
+		"memory": storage.NewMemoryIdempotencyStore(),
+		"redis":  redisStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			response := &storage.IdempotentResponse{
+				Status: http.StatusOK,
+				Header: map[string]string{"Content-Type": "application/json"},
+				Body:   []byte(`{"success":true}`),
+			}
+
+			stored, err := store.Reserve(ctx, "key", time.Minute)
+			require.NoError(t, err)
+			assert.Nil(t, stored)
+
+			_, err = store.Reserve(ctx, "key", time.Minute)
+			assert.ErrorIs(t, err, storage.ErrIdempotencyKeyInUse)
+
+			require.NoError(t, store.Complete(ctx, "key", response, time.Minute))
+			stored, err = store.Reserve(ctx, "key", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, response, stored)
+
+			// Released and lapsed reservations can be taken again
+			_, err = store.Reserve(ctx, "released", time.Minute)
+			require.NoError(t, err)
+			require.NoError(t, store.Release(ctx, "released"))
+			stored, err = store.Reserve(ctx, "released", time.Minute)
+			assert.NoError(t, err)
+			assert.Nil(t, stored)
+
+			_, err = store.Reserve(ctx, "lapsed", 10*time.Millisecond)
+			require.NoError(t, err)
+			time.Sleep(20 * time.Millisecond)
+			_, err = store.Reserve(ctx, "lapsed", time.Minute)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// newFakeRedis serves the commands the Redis stores use from memory and
+// returns its address.
+func newFakeRedis(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	values := make(map[string]string)
+	expiry := make(map[string]time.Time)
+	get := func(key string) (string, bool) {
+		if at, ok := expiry[key]; ok && time.Now().After(at) {
+			delete(values, key)
+			delete(expiry, key)
+		}
+		value, ok := values[key]
+		return value, ok
+	}
+	sets := make(map[string]map[string]int64)
+	// bound parses a score range bound: a number, "(" and a number for an
+	// exclusive one, or ±inf
+	bound := func(spec string) (int64, bool) {
+		switch spec {
+		case "-inf":
+			return math.MinInt64, false
+		case "+inf":
+			return math.MaxInt64, false
+		}
+		exclusive := strings.HasPrefix(spec, "(")
+		n, _ := strconv.ParseInt(strings.TrimPrefix(spec, "("), 10, 64)
+		return n, exclusive
+	}
+	inRange := func(v int64, min, max string) bool {
+		lo, loExclusive := bound(min)
+		hi, hiExclusive := bound(max)
+		return (v > lo || (!loExclusive && v == lo)) && (v < hi || (!hiExclusive && v == hi))
+	}
+	hashes := make(map[string]map[string]string)
+	members := make(map[string]map[string]bool)
+	// bulks encodes an array of bulk strings
+	bulks := func(items []string) string {
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(items))
+		for _, item := range items {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(item), item)
+		}
+		return b.String()
+	}
+	subscribers := make(map[string]map[net.Conn]bool)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		defer func() {
+			mu.Lock()
+			for _, conns := range subscribers {
+				delete(conns, conn)
+			}
+			mu.Unlock()
+		}()
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			args := make([]string, count)
+			for i := range args {
+				// Bulk strings are read by length, sealed values may hold newlines
+				header, _ := reader.ReadString('\n')
+				size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+				arg := make([]byte, size+2)
+				io.ReadFull(reader, arg)
+				args[i] = string(arg[:size])
+			}
+
+			mu.Lock()
+			reply := "+OK\r\n"
+			switch strings.ToUpper(args[0]) {
+			case "PING":
+				reply = "+PONG\r\n"
+			case "GET":
+				if value, ok := get(args[1]); ok {
+					reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+				} else {
+					reply = "$-1\r\n"
+				}
+			case "DEL":
+				delete(values, args[1])
+				delete(expiry, args[1])
+				delete(hashes, args[1])
+				delete(members, args[1])
+				reply = ":1\r\n"
+			case "SET":
+				_, exists := get(args[1])
+				if len(args) > 3 && args[3] == "NX" && exists {
+					reply = "$-1\r\n"
+					break
+				}
+				values[args[1]] = args[2]
+				ms, _ := strconv.Atoi(args[len(args)-1])
+				expiry[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			case "ZADD":
+				if sets[args[1]] == nil {
+					sets[args[1]] = make(map[string]int64)
+				}
+				sets[args[1]][args[3]], _ = strconv.ParseInt(args[2], 10, 64)
+				reply = ":1\r\n"
+			case "ZREMRANGEBYSCORE", "ZCOUNT":
+				count := 0
+				for member, v := range sets[args[1]] {
+					if inRange(v, args[2], args[3]) {
+						count++
+						if args[0] == "ZREMRANGEBYSCORE" {
+							delete(sets[args[1]], member)
+						}
+					}
+				}
+				reply = fmt.Sprintf(":%d\r\n", count)
+			case "PEXPIRE":
+				reply = ":1\r\n"
+			case "HSET":
+				if hashes[args[1]] == nil {
+					hashes[args[1]] = make(map[string]string)
+				}
+				hashes[args[1]][args[2]] = args[3]
+				reply = ":1\r\n"
+			case "HGETALL":
+				var items []string
+				for field, value := range hashes[args[1]] {
+					items = append(items, field, value)
+				}
+				reply = bulks(items)
+			case "SADD":
+				if members[args[1]] == nil {
+					members[args[1]] = make(map[string]bool)
+				}
+				members[args[1]][args[2]] = true
+				reply = ":1\r\n"
+			case "SREM":
+				delete(members[args[1]], args[2])
+				reply = ":1\r\n"
+			case "SMEMBERS":
+				var items []string
+				for member := range members[args[1]] {
+					items = append(items, member)
+				}
+				reply = bulks(items)
+			case "PUBLISH":
+				for subscriber := range subscribers[args[1]] {
+					subscriber.Write([]byte(bulks([]string{"message", args[1], args[2]})))
+				}
+				reply = fmt.Sprintf(":%d\r\n", len(subscribers[args[1]]))
+			case "SUBSCRIBE":
+				// Replied under the lock, so no message overtakes it
+				if subscribers[args[1]] == nil {
+					subscribers[args[1]] = make(map[net.Conn]bool)
+				}
+				subscribers[args[1]][conn] = true
+				conn.Write([]byte(fmt.Sprintf("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])))
+				reply = ""
+			}
+			mu.Unlock()
+			conn.Write([]byte(reply))
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestVerificationHandler_VerifyImage(t *testing.T) {
+	newRouter := func(t *testing.T, cfg *config.Config) *gin.Engine {
+		cfg.LivenessThreshold = 0.5
+		cfg.SimilarityThreshold = 0.75
+		cfg.StoragePath = t.TempDir()
+		cfg.EncryptionKey = "test-encryption-key-for-testing-only"
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, zaptest.NewLogger(t)), cfg)
+		return router
+	}
+	verifyImage := func(router *gin.Engine, fields map[string]interface{}) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify/image", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	selfie := &fileData{filename: "selfie.jpg", contentType: "image/jpeg", data: encodeJPEGFrames(t, createPanningFrames(1, 0, 0))[0]}
+	router := newRouter(t, &config.Config{ImageVerificationEnabled: true, MaxImageSize: 64 * 1024})
+
+	t.Run("matches without liveness", func(t *testing.T) {
+		w := verifyImage(router, map[string]interface{}{"image": selfie, "user_id": "alice"})
+		if w.Code == http.StatusUnprocessableEntity {
+			// The synthetic image may hold no detectable face
+			assert.Equal(t, "NO_FACE_IN_VIDEO", errorCode(t, w))
+			return
+		}
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data models.VerificationResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		result := response.Data
+		assert.Equal(t, models.LivenessMethodNone, result.LivenessMethod)
+		assert.Zero(t, result.LivenessScore)
+		assert.Nil(t, result.Risk)
+		assert.Nil(t, result.Review)
+		for _, warning := range result.Warnings {
+			assert.NotEqual(t, services.WarningFewFrames, warning.Code)
+		}
+		// alice never enrolled
+		assert.False(t, result.Verified)
+	})
+
+	t.Run("validates the upload", func(t *testing.T) {
+		w := verifyImage(router, map[string]interface{}{"user_id": "alice"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "MISSING_IMAGE", errorCode(t, w))
+
+		w = verifyImage(router, map[string]interface{}{"image": selfie})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "MISSING_USER_ID", errorCode(t, w))
+
+		w = verifyImage(router, map[string]interface{}{
+			"image":   &fileData{filename: "selfie.gif", contentType: "image/gif", data: []byte("GIF89a not decodable")},
+			"user_id": "alice",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_IMAGE", errorCode(t, w))
+
+		w = verifyImage(router, map[string]interface{}{
+			"image":   &fileData{filename: "selfie.jpg", contentType: "image/jpeg", data: make([]byte, 65*1024)},
+			"user_id": "alice",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "IMAGE_TOO_LARGE", errorCode(t, w))
+	})
+
+	t.Run("is disabled by default", func(t *testing.T) {
+		w := verifyImage(newRouter(t, &config.Config{}), map[string]interface{}{"image": selfie, "user_id": "alice"})
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Equal(t, "IMAGE_VERIFICATION_DISABLED", errorCode(t, w))
+	})
+
+	t.Run("is refused when active liveness is required", func(t *testing.T) {
+		required := newRouter(t, &config.Config{ImageVerificationEnabled: true, LivenessChallengeRequired: true})
+		w := verifyImage(required, map[string]interface{}{"image": selfie, "user_id": "alice"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "LIVENESS_SESSION_REQUIRED", errorCode(t, w))
+	})
+}
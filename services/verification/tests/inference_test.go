@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/inference"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// rawCodec hands the fake server's messages over undecoded.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error)      { return *v.(*[]byte), nil }
+func (rawCodec) Unmarshal(data []byte, v any) error { *v.(*[]byte) = data; return nil }
+func (rawCodec) Name() string                       { return "proto" }
+
+// inferRequest is what the fake server reads from a ModelInferRequest.
+type inferRequest struct {
+	model, version string
+	input          string
+	shape          []int64
+	output         string
+	raw            []byte
+}
+
+// fakeInferenceServer answers ModelInfer with embedding as the requested
+// output, or with failure when set.
+type fakeInferenceServer struct {
+	mu        sync.Mutex
+	embedding []float32
+	failure   error
+	requests  []inferRequest
+}
+
+func startInferenceServer(t *testing.T, embedding []float32) (*fakeInferenceServer, string) {
+	fake := &fakeInferenceServer{embedding: embedding}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(fake.handle))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return fake, listener.Addr().String()
+}
+
+func (f *fakeInferenceServer) handle(_ any, stream grpc.ServerStream) error {
+	if method, _ := grpc.MethodFromServerStream(stream); method != "/inference.GRPCInferenceService/ModelInfer" {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	var b []byte
+	if err := stream.RecvMsg(&b); err != nil {
+		return err
+	}
+	req := parseInferRequest(b)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	failure, embedding := f.failure, f.embedding
+	f.mu.Unlock()
+	if failure != nil {
+		return failure
+	}
+
+	// The same embedding for every image of the batch
+	batch := 1
+	if len(req.shape) > 0 {
+		batch = int(req.shape[0])
+	}
+	var values []float32
+	for i := 0; i < batch; i++ {
+		values = append(values, embedding...)
+	}
+
+	var tensor []byte
+	tensor = protowire.AppendTag(tensor, 1, protowire.BytesType)
+	tensor = protowire.AppendString(tensor, req.output)
+	tensor = protowire.AppendTag(tensor, 2, protowire.BytesType)
+	tensor = protowire.AppendString(tensor, "FP32")
+	tensor = protowire.AppendTag(tensor, 3, protowire.BytesType)
+	tensor = protowire.AppendBytes(tensor, protowire.AppendVarint(protowire.AppendVarint(nil, uint64(batch)), uint64(len(embedding))))
+	var resp []byte
+	resp = protowire.AppendTag(resp, 5, protowire.BytesType)
+	resp = protowire.AppendBytes(resp, tensor)
+	resp = protowire.AppendTag(resp, 6, protowire.BytesType)
+	resp = protowire.AppendBytes(resp, inference.FP32(values))
+	return stream.SendMsg(&resp)
+}
+
+func (f *fakeInferenceServer) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func (f *fakeInferenceServer) lastRequest() inferRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1]
+}
+
+// parseInferRequest reads the fields the client sets, ignoring errors: a
+// malformed request fails the test's assertions instead.
+func parseInferRequest(b []byte) inferRequest {
+	var req inferRequest
+	eachField := func(b []byte, fn func(num protowire.Number, value []byte)) {
+		for len(b) > 0 {
+			num, _, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				return
+			}
+			value, m := protowire.ConsumeBytes(b[n:])
+			if m < 0 {
+				return
+			}
+			fn(num, value)
+			b = b[n+m:]
+		}
+	}
+	eachField(b, func(num protowire.Number, value []byte) {
+		switch num {
+		case 1:
+			req.model = string(value)
+		case 2:
+			req.version = string(value)
+		case 5:
+			eachField(value, func(num protowire.Number, value []byte) {
+				switch num {
+				case 1:
+					req.input = string(value)
+				case 3:
+					for len(value) > 0 {
+						dim, n := protowire.ConsumeVarint(value)
+						if n < 0 {
+							return
+						}
+						req.shape = append(req.shape, int64(dim))
+						value = value[n:]
+					}
+				}
+			})
+		case 6:
+			eachField(value, func(num protowire.Number, value []byte) {
+				if num == 1 {
+					req.output = string(value)
+				}
+			})
+		case 7:
+			req.raw = value
+		}
+	})
+	return req
+}
+
+func TestInferenceClient(t *testing.T) {
+	fake, addr := startInferenceServer(t, []float32{0.5, -0.25, 1, 0})
+	client, err := inference.Dial(addr, false)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	input := inference.Tensor{
+		Name:     "pixels",
+		Datatype: "FP32",
+		Shape:    []int64{1, 3, 2, 2},
+		Data:     inference.FP32(make([]float32, 12)),
+	}
+
+	t.Run("sends the input and decodes the output", func(t *testing.T) {
+		output, err := client.Infer(ctx, "arcface", "3", input, "features")
+		require.NoError(t, err)
+		assert.Equal(t, "features", output.Name)
+		assert.Equal(t, []int64{1, 4}, output.Shape)
+		values, err := output.Float32s()
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.5, -0.25, 1, 0}, values)
+
+		req := fake.lastRequest()
+		assert.Equal(t, "arcface", req.model)
+		assert.Equal(t, "3", req.version)
+		assert.Equal(t, "pixels", req.input)
+		assert.Equal(t, []int64{1, 3, 2, 2}, req.shape)
+		assert.Equal(t, "features", req.output)
+		assert.Len(t, req.raw, 12*4)
+	})
+
+	t.Run("server errors are returned", func(t *testing.T) {
+		fake.mu.Lock()
+		fake.failure = status.Error(codes.NotFound, "unknown model")
+		fake.mu.Unlock()
+		t.Cleanup(func() {
+			fake.mu.Lock()
+			fake.failure = nil
+			fake.mu.Unlock()
+		})
+
+		_, err := client.Infer(ctx, "missing", "", input, "features")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestRemoteEmbedder(t *testing.T) {
+	fake, addr := startInferenceServer(t, []float32{0.6, 0.8, 0, 0})
+	cfg := &config.Config{
+		LivenessThreshold:    0.5,
+		SimilarityThreshold:  0.75,
+		StoragePath:          t.TempDir(),
+		EncryptionKey:        "test-encryption-key-for-testing-only",
+		EmbedderBackend:      services.EmbedderRemote,
+		EmbedderAddr:         addr,
+		EmbedderModel:        "arcface",
+		EmbedderModelVersion: "2",
+		EmbedderInputSize:    64,
+	}
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+	videoData := createTestVideoFile().data
+
+	t.Run("enrollment and verification use the served model", func(t *testing.T) {
+		modelVersion, err := services.DescriptorModelVersion(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "remote:arcface@2", modelVersion)
+
+		template, err := service.ExtractTemplate(videoData)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.6, 0.8, 0, 0}, template)
+
+		require.NoError(t, service.RegisterFace("remote-user", videoData))
+		assert.Equal(t, 1, service.TemplateCount("remote-user"))
+
+		req := fake.lastRequest()
+		assert.Equal(t, "arcface", req.model)
+		assert.Equal(t, "2", req.version)
+		assert.Equal(t, "input", req.input)
+		assert.Equal(t, []int64{1, 3, 64, 64}, req.shape)
+		assert.Equal(t, "embedding", req.output)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{VideoData: videoData, UserID: "remote-user"})
+		require.NoError(t, err)
+		assert.True(t, result.Verified, result.Reason)
+	})
+
+	t.Run("an unavailable server fails the capture", func(t *testing.T) {
+		fake.mu.Lock()
+		fake.failure = fmt.Errorf("model unavailable")
+		fake.mu.Unlock()
+
+		err := service.RegisterFace("unserved-user", videoData)
+		assert.Error(t, err)
+		assert.Zero(t, service.TemplateCount("unserved-user"))
+	})
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// noisyFrame is createTestImage with fresh sensor-like noise in every pixel.
+func noisyFrame(rng *rand.Rand) image.Image {
+	base := createTestImage(160, 120)
+	frame := image.NewRGBA(base.Bounds())
+	for y := 0; y < 120; y++ {
+		for x := 0; x < 160; x++ {
+			r, g, b, _ := base.At(x, y).RGBA()
+			noise := int(rng.NormFloat64() * 4)
+			frame.Set(x, y, color.RGBA{
+				R: clampByte(int(r>>8) + noise),
+				G: clampByte(int(g>>8) + noise),
+				B: clampByte(int(b>>8) + noise),
+				A: 255,
+			})
+		}
+	}
+	return frame
+}
+
+func clampByte(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// mp4Box builds an ISO BMFF box from its type and payload.
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], boxType)
+	return append(box, body...)
+}
+
+// mp4WithFrameDelta builds an MP4 whose video track has 30 frames of delta
+// at a timescale of 30000.
+func mp4WithFrameDelta(delta uint32) []byte {
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], "vide")
+	mdhd := make([]byte, 24)
+	binary.BigEndian.PutUint32(mdhd[12:], 30000)
+	stts := make([]byte, 16)
+	binary.BigEndian.PutUint32(stts[4:], 1)
+	binary.BigEndian.PutUint32(stts[8:], 30)
+	binary.BigEndian.PutUint32(stts[12:], delta)
+
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		mp4Box("moov", mp4Box("trak", mp4Box("mdia",
+			mp4Box("mdhd", mdhd),
+			mp4Box("hdlr", hdlr),
+			mp4Box("minf", mp4Box("stbl", mp4Box("stts", stts))),
+		))),
+		mp4Box("mdat", make([]byte, 256)),
+	}, nil)
+}
+
+func TestInjectionRisk(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var camera []image.Image
+	for i := 0; i < 5; i++ {
+		camera = append(camera, noisyFrame(rng))
+	}
+	encoders := []string{"Lavf", "OBS Virtual Camera"}
+
+	t.Run("camera capture", func(t *testing.T) {
+		risk, signals := services.InjectionRisk(camera, bytes.NewReader(mp4WithFrameDelta(1000)), encoders)
+		assert.Less(t, risk, 0.5)
+		assert.Empty(t, signals)
+	})
+
+	t.Run("still image", func(t *testing.T) {
+		still := createTestImage(160, 120)
+		risk, signals := services.InjectionRisk([]image.Image{still, still, still}, nil, encoders)
+		assert.Equal(t, 1.0, risk)
+		assert.ElementsMatch(t, []string{services.InjectionSignalSensorNoise, services.InjectionSignalStaticNoise}, signals)
+	})
+
+	t.Run("noise frozen between frames", func(t *testing.T) {
+		frames := []image.Image{camera[0], camera[0], camera[0]}
+		risk, signals := services.InjectionRisk(frames, nil, encoders)
+		assert.Equal(t, 1.0, risk)
+		assert.Equal(t, []string{services.InjectionSignalStaticNoise}, signals)
+	})
+
+	t.Run("encoder metadata", func(t *testing.T) {
+		capture := append(mp4WithFrameDelta(1000), mp4Box("free", []byte("Lavf60.16.100"))...)
+		_, signals := services.InjectionRisk(camera, bytes.NewReader(capture), encoders)
+		assert.Equal(t, []string{services.InjectionSignalEncoder}, signals)
+
+		_, signals = services.InjectionRisk(camera, bytes.NewReader([]byte("\x1aE\xdf\xa3webm...lavf")), encoders)
+		assert.Empty(t, signals, "markers are case-sensitive")
+	})
+
+	t.Run("frame timing", func(t *testing.T) {
+		for _, delta := range []uint32{0, 100} {
+			risk, signals := services.InjectionRisk(camera, bytes.NewReader(mp4WithFrameDelta(delta)), encoders)
+			assert.Equal(t, 1.0, risk, delta)
+			assert.Equal(t, []string{services.InjectionSignalFrameTiming}, signals, delta)
+		}
+	})
+}
+
+func TestInjectionDetection(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:         0.5,
+		SimilarityThreshold:       0.75,
+		StoragePath:               t.TempDir(),
+		EncryptionKey:             "test-encryption-key-for-testing-only",
+		InjectionDetectionEnabled: true,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+	require.NoError(t, service.RegisterFace("user123", createTestVideoData()))
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	// Test captures decode to brightness-shifted copies of one image, as a
+	// looped still fed to a virtual camera would
+	body, contentType, err := createMultipartForm(map[string]interface{}{
+		"video":   createTestVideoFile(),
+		"user_id": "user123",
+	})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/verify", body)
+	req.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response struct {
+		Data models.VerificationResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Data.Verified)
+	assert.Equal(t, models.ReasonInjectionSuspected, response.Data.Reason)
+	require.NotNil(t, response.Data.InjectionRisk)
+	assert.GreaterOrEqual(t, *response.Data.InjectionRisk, 0.8)
+	assert.Contains(t, response.Data.InjectionSignals, services.InjectionSignalStaticNoise)
+}
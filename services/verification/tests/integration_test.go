@@ -38,7 +38,7 @@ func TestIntegration_FullVerificationFlow(t *testing.T) {
 
 	// Add middleware
 	router.Use(middleware.Logger(logger))
-	router.Use(middleware.CORS())
+	router.Use(middleware.CORS(middleware.NewCORSConfig(cfg)))
 	router.Use(middleware.Recovery(logger))
 
 	// Add handlers
@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// testJWKS serves the public keys of its signers as a JWKS endpoint.
+type testJWKS struct {
+	mu      sync.Mutex
+	keys    map[string]crypto.Signer
+	fetches int
+}
+
+func (s *testJWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	var keys []map[string]string
+	for kid, signer := range s.keys {
+		switch key := signer.Public().(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "EC", "kid": kid, "use": "sig", "crv": "P-256",
+				"x": encode(key.X.FillBytes(make([]byte, 32))), "y": encode(key.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys}) //nolint:errcheck
+}
+
+func (s *testJWKS) set(kid string, key crypto.Signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = map[string]crypto.Signer{kid: key}
+}
+
+// signAsymmetricJWT signs claims with an RSA (RS256) or P-256 (ES256) key.
+func signAsymmetricJWT(t *testing.T, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestBearerTokensFromJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := &testJWKS{keys: map[string]crypto.Signer{"rsa-1": rsaKey, "ec-1": ecKey}}
+	jwksServer := httptest.NewServer(jwks)
+	defer jwksServer.Close()
+
+	verifier := middleware.NewJWTVerifier(middleware.JWTConfig{
+		Secret:      "test-jwt-secret",
+		JWKSURL:     jwksServer.URL,
+		JWKSRefresh: time.Hour,
+		Issuer:      "https://auth.connect-hub.test",
+		Audience:    "verification",
+		TenantClaim: "tenant",
+	})
+	registry, err := tenant.NewRegistry(tenant.Config{APIKeys: "acme:acme-key,globex:globex-key"})
+	require.NoError(t, err)
+
+	router := gin.New()
+	api := router.Group("/api", middleware.IdentifyBearer(verifier), middleware.Tenant(registry, "admin-key"))
+	api.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"tenant":  middleware.TenantOf(c),
+			"subject": c.GetString(middleware.SubjectContextKey),
+		})
+	})
+	api.GET("/me", middleware.RequireBearer(verifier), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func(path, token string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{
+			"sub":    "user-42",
+			"iss":    "https://auth.connect-hub.test",
+			"aud":    "verification",
+			"tenant": "acme",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range overrides {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	t.Run("gateway tokens identify the user and tenant", func(t *testing.T) {
+		for kid, key := range map[string]crypto.Signer{"rsa-1": rsaKey, "ec-1": ecKey} {
+			w := request("/api/whoami", signAsymmetricJWT(t, kid, key, claims(nil)), nil)
+			require.Equal(t, http.StatusOK, w.Code, kid)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, map[string]string{"tenant": "acme", "subject": "user-42"}, body, kid)
+		}
+
+		aud := claims(map[string]interface{}{"aud": []string{"billing", "verification"}})
+		assert.Equal(t, http.StatusNoContent, request("/api/me", signAsymmetricJWT(t, "rsa-1", rsaKey, aud), nil).Code)
+	})
+
+	t.Run("API keys take precedence over the tenant claim", func(t *testing.T) {
+		w := request("/api/whoami", signAsymmetricJWT(t, "rsa-1", rsaKey, claims(nil)), map[string]string{"X-API-Key": "globex-key"})
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "globex", body["tenant"])
+	})
+
+	t.Run("invalid tokens are refused", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		for name, token := range map[string]string{
+			"wrong issuer":     signAsymmetricJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"iss": "https://evil.test"})),
+			"wrong audience":   signAsymmetricJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"aud": "billing"})),
+			"expired":          signAsymmetricJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()})),
+			"no expiry":        signAsymmetricJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"exp": nil})),
+			"unpublished key":  signAsymmetricJWT(t, "rsa-1", otherKey, claims(nil)),
+			"key type of kid":  signAsymmetricJWT(t, "rsa-1", ecKey, claims(nil)),
+			"missing subject":  signAsymmetricJWT(t, "rsa-1", rsaKey, claims(map[string]interface{}{"sub": ""})),
+			"not a JWT at all": "opaque-token",
+		} {
+			w := request("/api/whoami", token, map[string]string{"X-API-Key": "acme-key"})
+			assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+			assert.Equal(t, "UNAUTHORIZED", errorCode(t, w), name)
+		}
+	})
+
+	t.Run("a token without a known tenant needs an API key", func(t *testing.T) {
+		for _, tenantClaim := range []interface{}{nil, "initech"} {
+			token := signAsymmetricJWT(t, "ec-1", ecKey, claims(map[string]interface{}{"tenant": tenantClaim}))
+			assert.Equal(t, http.StatusUnauthorized, request("/api/whoami", token, nil).Code)
+			assert.Equal(t, http.StatusOK, request("/api/whoami", token, map[string]string{"X-API-Key": "acme-key"}).Code)
+		}
+	})
+
+	t.Run("HS256 tokens still need the shared secret", func(t *testing.T) {
+		token := signTestJWT(t, "test-jwt-secret", "user-7", time.Now().Add(time.Hour))
+		assert.Equal(t, http.StatusNoContent, request("/api/me", token, map[string]string{"X-API-Key": "acme-key"}).Code)
+
+		jwksOnly := middleware.NewJWTVerifier(middleware.JWTConfig{JWKSURL: jwksServer.URL})
+		_, err := jwksOnly.Verify(token, time.Now())
+		assert.Error(t, err)
+	})
+
+	t.Run("rotated keys are fetched when first seen", func(t *testing.T) {
+		rotated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		jwks.set("ec-2", rotated)
+		token := signAsymmetricJWT(t, "ec-2", rotated, claims(map[string]interface{}{
+			"exp": time.Now().Add(2 * time.Hour).Unix(),
+		}))
+
+		jwks.mu.Lock()
+		fetches := jwks.fetches
+		jwks.mu.Unlock()
+
+		// Unknown key IDs refetch the set at most every 10 seconds
+		_, err = verifier.Verify(token, time.Now())
+		assert.Error(t, err)
+		verified, err := verifier.Verify(token, time.Now().Add(11*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, "user-42", verified.Subject)
+
+		jwks.mu.Lock()
+		defer jwks.mu.Unlock()
+		assert.Equal(t, fetches+1, jwks.fetches)
+	})
+}
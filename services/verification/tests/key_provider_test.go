@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+const dataKey = "unwrapped-data-encryption-key"
+
+// fakeVault answers transit decrypt calls for one key and token.
+func fakeVault(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/v1/transit/decrypt/face-vectors" || r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["ciphertext"] != "vault:v1:wrapped" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]string{"plaintext": base64.StdEncoding.EncodeToString([]byte(dataKey))},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type flakyKeyProvider struct {
+	calls atomic.Int32
+	fail  atomic.Bool
+}
+
+func (f *flakyKeyProvider) Key(ctx context.Context) (string, error) {
+	f.calls.Add(1)
+	if f.fail.Load() {
+		return "", errors.New("kms unavailable")
+	}
+	return dataKey, nil
+}
+
+func TestKeyProviders(t *testing.T) {
+	t.Run("vault transit unwraps the data key", func(t *testing.T) {
+		var calls atomic.Int32
+		server := fakeVault(t, &calls)
+
+		provider, err := storage.NewVaultKeyProvider(server.URL, "vault-token", "", "face-vectors", "vault:v1:wrapped", nil)
+		require.NoError(t, err)
+		key, err := provider.Key(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, key)
+
+		wrong, err := storage.NewVaultKeyProvider(server.URL, "other-token", "", "face-vectors", "vault:v1:wrapped", nil)
+		require.NoError(t, err)
+		_, err = wrong.Key(context.Background())
+		assert.ErrorIs(t, err, storage.ErrKeyUnavailable)
+	})
+
+	t.Run("aws kms requests are signed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "TrentService.Decrypt", r.Header.Get("X-Amz-Target"))
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+				"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), r.Header.Get("Authorization"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+			assert.Equal(t, "session-token", r.Header.Get("X-Amz-Security-Token"))
+
+			var body struct {
+				CiphertextBlob []byte
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "wrapped", string(body.CiphertextBlob))
+			json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": []byte(dataKey)})
+		}))
+		defer server.Close()
+
+		provider, err := storage.NewAWSKMSKeyProvider(server.URL, "eu-west-1", storage.AWSCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			SessionToken:    "session-token",
+		}, base64.StdEncoding.EncodeToString([]byte("wrapped")), nil)
+		require.NoError(t, err)
+		key, err := provider.Key(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, key)
+	})
+
+	t.Run("gcp kms decrypts with the configured key", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/projects/p/locations/global/keyRings/r/cryptoKeys/k:decrypt", r.URL.Path)
+			assert.Equal(t, "Bearer gcp-token", r.Header.Get("Authorization"))
+			json.NewEncoder(w).Encode(map[string][]byte{"plaintext": []byte(dataKey)})
+		}))
+		defer server.Close()
+
+		provider, err := storage.NewGCPKMSKeyProvider(server.URL, "projects/p/locations/global/keyRings/r/cryptoKeys/k",
+			base64.StdEncoding.EncodeToString([]byte("wrapped")), "gcp-token", nil)
+		require.NoError(t, err)
+		key, err := provider.Key(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, key)
+	})
+
+	t.Run("cached key survives a failed refresh", func(t *testing.T) {
+		flaky := &flakyKeyProvider{}
+		var refreshErrors atomic.Int32
+		cached := storage.NewCachingKeyProvider(flaky, 20*time.Millisecond, func(error) { refreshErrors.Add(1) })
+
+		for i := 0; i < 3; i++ {
+			key, err := cached.Key(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, dataKey, key)
+		}
+		assert.Equal(t, int32(1), flaky.calls.Load())
+
+		flaky.fail.Store(true)
+		time.Sleep(30 * time.Millisecond)
+		key, err := cached.Key(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, key)
+		assert.Equal(t, int32(1), refreshErrors.Load())
+	})
+
+	t.Run("vector store encrypts under the unwrapped key", func(t *testing.T) {
+		var calls atomic.Int32
+		server := fakeVault(t, &calls)
+		dir := t.TempDir()
+
+		logger := zaptest.NewLogger(t)
+		cfg := &config.Config{
+			LivenessThreshold:       0.5,
+			SimilarityThreshold:     0.75,
+			StoragePath:             dir,
+			StorageKeyProvider:      "vault",
+			EncryptionKeyCiphertext: "vault:v1:wrapped",
+			KMSKeyName:              "face-vectors",
+			VaultAddr:               server.URL,
+			VaultToken:              "vault-token",
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		err = service.RegisterFace("kms-user", createTestVideoData())
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), calls.Load(), "the key is fetched once and cached")
+
+		// The file is readable with the plaintext data key alone
+		store, err := storage.NewEncryptedFileVectorStore(dir, dataKey, time.Second)
+		require.NoError(t, err)
+		vectors, err := store.Load("kms-user")
+		require.NoError(t, err)
+		assert.NotEmpty(t, vectors)
+	})
+
+	t.Run("an unreachable provider fails at startup", func(t *testing.T) {
+		cfg := &config.Config{
+			StoragePath:             t.TempDir(),
+			StorageKeyProvider:      "vault",
+			EncryptionKeyCiphertext: "vault:v1:wrapped",
+			KMSKeyName:              "face-vectors",
+			VaultAddr:               "http://127.0.0.1:1",
+			VaultToken:              "vault-token",
+		}
+		_, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+		assert.Error(t, err)
+	})
+}
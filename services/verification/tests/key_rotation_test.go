@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/crypto/scrypt"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// writeLegacyVectorFile writes vectors the way the store did before key IDs
+// were recorded: a fixed salt and no header.
+func writeLegacyVectorFile(t *testing.T, dir, secret string, vectors map[string][]models.FaceVector) {
+	key, err := scrypt.Key([]byte(secret), []byte("connect-hub-face-verification-salt"), 32768, 8, 1, 32)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(vectors)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "face_vectors.enc"), gcm.Seal(nonce, nonce, data, nil), 0600))
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	vector := models.FaceVector{UserID: "alice", Vector: []float32{1, 0}, CreatedAt: time.Now(), Version: "1.0"}
+	oldKey := storage.EncryptionKey{ID: "1", Secret: "old-encryption-key-for-testing"}
+	newKey := storage.EncryptionKey{ID: "2", Secret: "new-encryption-key-for-testing"}
+
+	t.Run("previous keys still decrypt until the data is rotated", func(t *testing.T) {
+		dir := t.TempDir()
+		old, err := storage.NewEncryptedFileVectorStoreWithKeys(dir, storage.KeyRing{Current: oldKey}, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, old.Save(vector))
+
+		// Without the old key the file cannot be read
+		newOnly, err := storage.NewEncryptedFileVectorStoreWithKeys(dir, storage.KeyRing{Current: newKey}, time.Second)
+		require.NoError(t, err)
+		_, err = newOnly.Load("alice")
+		assert.ErrorIs(t, err, storage.ErrUnknownKeyID)
+
+		rotating, err := storage.NewEncryptedFileVectorStoreWithKeys(dir, storage.KeyRing{
+			Current:  newKey,
+			Previous: []storage.EncryptionKey{oldKey},
+		}, time.Second)
+		require.NoError(t, err)
+		loaded, err := rotating.Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, loaded, 1)
+
+		rotation, err := rotating.RotateKey()
+		require.NoError(t, err)
+		assert.Equal(t, "2", rotation.KeyID)
+		assert.Equal(t, "1", rotation.PreviousKeyID)
+		assert.Equal(t, 1, rotation.Enrollments)
+
+		loaded, err = newOnly.Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, loaded, 1)
+	})
+
+	t.Run("files written before key IDs are rotated from legacy", func(t *testing.T) {
+		dir := t.TempDir()
+		writeLegacyVectorFile(t, dir, oldKey.Secret, map[string][]models.FaceVector{"alice": {vector}})
+
+		store, err := storage.NewEncryptedFileVectorStoreWithKeys(dir, storage.KeyRing{
+			Current:  newKey,
+			Previous: []storage.EncryptionKey{oldKey},
+		}, time.Second)
+		require.NoError(t, err)
+
+		rotation, err := store.RotateKey()
+		require.NoError(t, err)
+		assert.Equal(t, storage.LegacyKeyID, rotation.PreviousKeyID)
+
+		rotation, err = store.RotateKey()
+		require.NoError(t, err)
+		assert.Equal(t, "2", rotation.PreviousKeyID)
+	})
+
+	t.Run("previous keys are parsed from config", func(t *testing.T) {
+		keys, err := storage.ParseEncryptionKeys("1:secret-one, 2:secret:two")
+		require.NoError(t, err)
+		assert.Equal(t, []storage.EncryptionKey{{ID: "1", Secret: "secret-one"}, {ID: "2", Secret: "secret:two"}}, keys)
+
+		_, err = storage.ParseEncryptionKeys("missing-secret")
+		assert.Error(t, err)
+	})
+
+	t.Run("admin endpoint rotates the configured store", func(t *testing.T) {
+		dir := t.TempDir()
+		old, err := storage.NewEncryptedFileVectorStoreWithKeys(dir, storage.KeyRing{Current: oldKey}, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, old.Save(vector))
+
+		logger := zaptest.NewLogger(t)
+		cfg := &config.Config{
+			LivenessThreshold:      0.5,
+			SimilarityThreshold:    0.75,
+			StoragePath:            dir,
+			EncryptionKey:          newKey.Secret,
+			EncryptionKeyID:        newKey.ID,
+			EncryptionPreviousKeys: "1:" + oldKey.Secret,
+			AdminAPIKey:            "test-admin-key",
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/admin/keys/rotate", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data models.KeyRotation `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2", response.Data.KeyID)
+		assert.Equal(t, "1", response.Data.PreviousKeyID)
+		assert.Equal(t, 1, response.Data.Enrollments)
+	})
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+type liveEvent struct {
+	Type  string                 `json:"type"`
+	Data  map[string]interface{} `json:"data"`
+	Error string                 `json:"error"`
+	Code  string                 `json:"code"`
+}
+
+func TestVerificationHandler_VerifyLive(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newServer := func(t *testing.T, cfg *config.Config) *httptest.Server {
+		cfg.LivenessThreshold = 0.5
+		cfg.SimilarityThreshold = 0.75
+		cfg.StoragePath = t.TempDir()
+		cfg.EncryptionKey = "test-encryption-key-for-testing-only"
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		server := httptest.NewServer(router)
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	dial := func(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/verify/live" + query
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	read := func(t *testing.T, conn *websocket.Conn) liveEvent {
+		var event liveEvent
+		require.NoError(t, conn.ReadJSON(&event))
+		return event
+	}
+
+	t.Run("streams progress per frame and the final result", func(t *testing.T) {
+		server := newServer(t, &config.Config{LiveVerificationEnabled: true})
+		conn := dial(t, server, "?session_id=live-session")
+
+		frames := encodeJPEGFrames(t, createPanningFrames(4, 4, 0))
+		for i, frame := range frames {
+			require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, frame))
+			event := read(t, conn)
+			require.Equal(t, "progress", event.Type, event.Error)
+			assert.Equal(t, float64(i+1), event.Data["frames"])
+		}
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"finish"}`)))
+		event := read(t, conn)
+		require.Equal(t, "result", event.Type, event.Error)
+		assert.NotEmpty(t, event.Data["verification_id"])
+		assert.Greater(t, event.Data["liveness_score"].(float64), 0.0)
+	})
+
+	t.Run("reaching the frame limit finishes the capture", func(t *testing.T) {
+		server := newServer(t, &config.Config{LiveVerificationEnabled: true, LiveMaxFrames: 3})
+		conn := dial(t, server, "")
+
+		for _, frame := range encodeJPEGFrames(t, createPanningFrames(3, 4, 0)) {
+			require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, frame))
+			assert.Equal(t, "progress", read(t, conn).Type)
+		}
+		assert.Equal(t, "result", read(t, conn).Type)
+	})
+
+	t.Run("invalid frames end the connection with an error event", func(t *testing.T) {
+		server := newServer(t, &config.Config{LiveVerificationEnabled: true})
+		conn := dial(t, server, "")
+
+		require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("not a jpeg")))
+		event := read(t, conn)
+		assert.Equal(t, "error", event.Type)
+		assert.Equal(t, "INVALID_FRAME", event.Code)
+	})
+
+	t.Run("finishing too early is rejected", func(t *testing.T) {
+		server := newServer(t, &config.Config{LiveVerificationEnabled: true})
+		conn := dial(t, server, "")
+
+		require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, encodeJPEGFrames(t, createPanningFrames(1, 0, 0))[0]))
+		read(t, conn)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"finish"}`)))
+		assert.Equal(t, "INVALID_FRAME_COUNT", read(t, conn).Code)
+	})
+
+	t.Run("disabled endpoint does not upgrade", func(t *testing.T) {
+		server := newServer(t, &config.Config{})
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/verify/live"
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// stubChallengeDetector reports every challenge as performed or not.
+type stubChallengeDetector struct {
+	performed bool
+	seen      []string
+}
+
+func (d *stubChallengeDetector) Performed(challenge string, frames []image.Image) bool {
+	d.seen = append(d.seen, challenge)
+	return d.performed
+}
+
+func TestLivenessChallengeSessions(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newRouter := func(t *testing.T, required bool) (*services.FaceVerificationService, *gin.Engine) {
+		cfg := &config.Config{
+			LivenessThreshold:         0.5,
+			SimilarityThreshold:       0.75,
+			StoragePath:               t.TempDir(),
+			EncryptionKey:             "test-encryption-key-for-testing-only",
+			LivenessChallengeRequired: required,
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return service, router
+	}
+
+	startSession := func(t *testing.T, router *gin.Engine) models.LivenessSession {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/liveness/session", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data models.LivenessSession `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	verify := func(t *testing.T, router *gin.Engine, token, nonce string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		fields := map[string]interface{}{"video": createTestVideoFile()}
+		if token != "" {
+			fields["liveness_session"] = token
+			fields["liveness_nonce"] = nonce
+		}
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("session issues a known challenge", func(t *testing.T) {
+		_, router := newRouter(t, false)
+		session := startSession(t, router)
+
+		assert.NotEmpty(t, session.SessionToken)
+		assert.NotEmpty(t, session.Nonce)
+		assert.Contains(t, []string{"blink", "smile", "turn_left", "turn_right"}, session.Challenge)
+		assert.False(t, session.ExpiresAt.IsZero())
+	})
+
+	t.Run("performed challenge verifies and the session is single use", func(t *testing.T) {
+		service, router := newRouter(t, false)
+		detector := &stubChallengeDetector{performed: true}
+		service.SetChallengeDetector(detector)
+
+		session := startSession(t, router)
+		w, response := verify(t, router, session.SessionToken, session.Nonce)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, true, data["verified"])
+		assert.Equal(t, []string{session.Challenge}, detector.seen)
+
+		w, response = verify(t, router, session.SessionToken, session.Nonce)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "LIVENESS_SESSION_INVALID", response["code"])
+	})
+
+	t.Run("challenge not performed is rejected", func(t *testing.T) {
+		service, router := newRouter(t, false)
+		service.SetChallengeDetector(&stubChallengeDetector{performed: false})
+
+		session := startSession(t, router)
+		w, response := verify(t, router, session.SessionToken, session.Nonce)
+		require.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, false, data["verified"])
+		assert.Equal(t, models.ReasonChallengeFailed, data["reason"])
+	})
+
+	t.Run("wrong nonce burns the session", func(t *testing.T) {
+		service, router := newRouter(t, false)
+		service.SetChallengeDetector(&stubChallengeDetector{performed: true})
+
+		session := startSession(t, router)
+		w, response := verify(t, router, session.SessionToken, "wrong-nonce")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "LIVENESS_SESSION_INVALID", response["code"])
+
+		w, _ = verify(t, router, session.SessionToken, session.Nonce)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("required mode refuses captures without a session", func(t *testing.T) {
+		service, router := newRouter(t, true)
+		service.SetChallengeDetector(&stubChallengeDetector{performed: true})
+
+		w, response := verify(t, router, "", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "LIVENESS_SESSION_REQUIRED", response["code"])
+
+		session := startSession(t, router)
+		w, _ = verify(t, router, session.SessionToken, session.Nonce)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+// createBandFrames builds flat grey frames; frames listed in changed get a
+// darker horizontal band between top and bottom (fractions of the height).
+func createBandFrames(count int, top, bottom float64, changed ...int) []image.Image {
+	const width, height = 160, 120
+	dark := make(map[int]bool)
+	for _, i := range changed {
+		dark[i] = true
+	}
+
+	frames := make([]image.Image, 0, count)
+	for i := 0; i < count; i++ {
+		frame := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			level := uint8(160)
+			if dark[i] && y >= int(top*height) && y < int(bottom*height) {
+				level = 60
+			}
+			for x := 0; x < width; x++ {
+				frame.SetGray(x, y, color.Gray{Y: level})
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestDefaultChallengeDetector(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	service, err := services.NewFaceVerificationService(logger, &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	// Challenges are random, so draw sessions until the wanted one comes up
+	sessionFor := func(t *testing.T, challenge string) *models.LivenessSession {
+		for i := 0; i < 200; i++ {
+			session, err := service.StartLivenessSession()
+			require.NoError(t, err)
+			if session.Challenge == challenge {
+				return session
+			}
+		}
+		t.Fatalf("no %s challenge issued", challenge)
+		return nil
+	}
+
+	reasonFor := func(t *testing.T, challenge string, frames []image.Image) string {
+		session := sessionFor(t, challenge)
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			FrameData:       encodeJPEGFrames(t, frames),
+			SessionID:       "challenge-" + challenge,
+			LivenessSession: session.SessionToken,
+			LivenessNonce:   session.Nonce,
+		})
+		require.NoError(t, err)
+		return result.Reason
+	}
+
+	cases := []struct {
+		name      string
+		challenge string
+		frames    []image.Image
+		performed bool
+	}{
+		{"blink in the eye band", services.ChallengeBlink, createBandFrames(5, 0.25, 0.45, 2), true},
+		{"no blink", services.ChallengeBlink, createBandFrames(5, 0.25, 0.45), false},
+		{"eyes closed to the end is not a blink", services.ChallengeBlink, createBandFrames(5, 0.25, 0.45, 2, 3, 4), false},
+		{"smile in the mouth band", services.ChallengeSmile, createBandFrames(5, 0.6, 0.85, 3, 4), true},
+		{"no smile", services.ChallengeSmile, createBandFrames(5, 0.6, 0.85), false},
+		{"turn left", services.ChallengeTurnLeft, createPanningFrames(4, -4, 0), true},
+		{"turn right instead of left", services.ChallengeTurnLeft, createPanningFrames(4, 4, 0), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := reasonFor(t, tc.challenge, tc.frames)
+			if tc.performed {
+				assert.NotEqual(t, models.ReasonChallengeFailed, reason)
+			} else {
+				assert.Equal(t, models.ReasonChallengeFailed, reason)
+			}
+		})
+	}
+}
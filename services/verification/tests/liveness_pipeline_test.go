@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// fixedDetector scores every capture the same, or abstains.
+func fixedDetector(score float64, ok bool) services.LivenessDetector {
+	return services.LivenessDetectorFunc(func(frames []image.Image) (float64, map[string]float64, bool) {
+		return score, nil, ok
+	})
+}
+
+func TestParseLivenessDetectors(t *testing.T) {
+	weights, err := services.ParseLivenessDetectors("motion:0.3, texture:0.3,Blink:0.4")
+	require.NoError(t, err)
+	assert.Equal(t, []services.LivenessWeight{
+		{Name: "motion", Weight: 0.3},
+		{Name: "texture", Weight: 0.3},
+		{Name: "blink", Weight: 0.4},
+	}, weights)
+
+	unset, err := services.ParseLivenessDetectors("")
+	require.NoError(t, err)
+	assert.Nil(t, unset)
+
+	for _, spec := range []string{"motion", "motion:x", "motion:-1", "motion:1,motion:2"} {
+		_, err := services.ParseLivenessDetectors(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestLivenessPipeline(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, detectors string) (*services.FaceVerificationService, error) {
+		service, err := services.NewFaceVerificationService(logger, &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			LivenessDetectors:   detectors,
+		})
+		if err == nil {
+			t.Cleanup(service.Close)
+		}
+		return service, err
+	}
+
+	livenessScore := func(t *testing.T, service *services.FaceVerificationService) float64 {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "pipeline-session",
+		})
+		require.NoError(t, err)
+		return result.LivenessScore
+	}
+
+	t.Run("configured weights mix detector scores", func(t *testing.T) {
+		service, err := newService(t, "motion:0.3,texture:0.3,blink:0.4")
+		require.NoError(t, err)
+		service.SetLivenessDetector(services.DetectorMotion, fixedDetector(1, true))
+		service.SetLivenessDetector(services.DetectorTexture, fixedDetector(0.5, true))
+		service.SetLivenessDetector(services.DetectorBlink, fixedDetector(0, true))
+
+		assert.InDelta(t, 0.3+0.15, livenessScore(t, service), 1e-9)
+	})
+
+	t.Run("abstaining detectors are renormalized away", func(t *testing.T) {
+		service, err := newService(t, "motion:0.3,texture:0.3,blink:0.4")
+		require.NoError(t, err)
+		service.SetLivenessDetector(services.DetectorMotion, fixedDetector(1, true))
+		service.SetLivenessDetector(services.DetectorTexture, fixedDetector(0.5, true))
+		service.SetLivenessDetector(services.DetectorBlink, fixedDetector(0, false))
+
+		assert.InDelta(t, 0.75, livenessScore(t, service), 1e-9)
+	})
+
+	t.Run("detectors not listed do not score", func(t *testing.T) {
+		service, err := newService(t, "motion:1")
+		require.NoError(t, err)
+		service.SetLivenessDetector(services.DetectorMotion, fixedDetector(0.6, true))
+		service.SetLivenessDetector(services.DetectorTexture, fixedDetector(0, true))
+
+		assert.InDelta(t, 0.6, livenessScore(t, service), 1e-9)
+	})
+
+	t.Run("unknown detectors are rejected at startup", func(t *testing.T) {
+		_, err := newService(t, "motion:0.5,neural:0.5")
+		assert.Error(t, err)
+	})
+}
+
+func TestONNXLivenessFallback(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	livenessScore := func(t *testing.T, cfg *config.Config) float64 {
+		cfg.LivenessThreshold = 0.5
+		cfg.SimilarityThreshold = 0.75
+		cfg.StoragePath = t.TempDir()
+		cfg.EncryptionKey = "test-encryption-key-for-testing-only"
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "onnx-session",
+		})
+		require.NoError(t, err)
+		return result.LivenessScore
+	}
+
+	heuristic := livenessScore(t, &config.Config{})
+	missing := t.TempDir() + "/antispoof.onnx"
+
+	t.Run("missing model falls back to the heuristic detectors", func(t *testing.T) {
+		score := livenessScore(t, &config.Config{
+			ONNXLivenessModelPath: missing,
+			ONNXLivenessWeight:    0.5,
+		})
+		assert.InDelta(t, heuristic, score, 1e-9)
+	})
+
+	t.Run("an unloaded onnx detector abstains from a configured ensemble", func(t *testing.T) {
+		motionOnly := livenessScore(t, &config.Config{LivenessDetectors: "motion:1"})
+		score := livenessScore(t, &config.Config{
+			LivenessDetectors:     "motion:0.5,onnx:0.5",
+			ONNXLivenessModelPath: missing,
+		})
+		assert.InDelta(t, motionOnly, score, 1e-9)
+	})
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestLockout(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:      0.85,
+		SimilarityThreshold:    0.75,
+		StoragePath:            t.TempDir(),
+		EncryptionKey:          "test-encryption-key-for-testing-only",
+		CameraCheckEnabled:     true,
+		CameraMinBrightness:    0.04,
+		CameraMinVariance:      0.0001,
+		LockoutThreshold:       2,
+		LockoutClientThreshold: 3,
+		LockoutCooldown:        600,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+
+	// A black frame fails the camera check without running the pipeline
+	var black bytes.Buffer
+	require.NoError(t, jpeg.Encode(&black, image.NewRGBA(image.Rect(0, 0, 320, 240)), nil))
+	verify := func(userID, clientIP string) (*models.VerificationResult, error) {
+		return service.VerifyVideo(&models.VerificationRequest{
+			VideoData: black.Bytes(),
+			UserID:    userID,
+			ClientIP:  clientIP,
+		})
+	}
+
+	t.Run("a user is locked out after repeated failures", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			result, err := verify("alice", "")
+			require.NoError(t, err)
+			assert.False(t, result.Verified)
+		}
+
+		_, err := verify("alice", "")
+		require.ErrorIs(t, err, services.ErrLockedOut)
+		var lockout *services.LockedOutError
+		require.True(t, errors.As(err, &lockout))
+		assert.Equal(t, services.LockoutSubjectUser, lockout.Subject)
+		assert.InDelta(t, 600, lockout.RetryAfter.Seconds(), 5)
+
+		_, err = verify("bob", "")
+		assert.NoError(t, err, "other users are unaffected")
+	})
+
+	t.Run("a client is locked out across users", func(t *testing.T) {
+		for _, user := range []string{"carol", "dave", "erin"} {
+			_, err := verify(user, "198.51.100.7")
+			require.NoError(t, err)
+		}
+
+		var lockout *services.LockedOutError
+		_, err := verify("frank", "198.51.100.7")
+		require.True(t, errors.As(err, &lockout))
+		assert.Equal(t, services.LockoutSubjectClient, lockout.Subject)
+
+		_, err = verify("frank", "198.51.100.8")
+		assert.NoError(t, err)
+	})
+
+	t.Run("locked out requests get 423 with Retry-After", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": "alice",
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusLocked, w.Code)
+		assert.Equal(t, "LOCKED", errorCode(t, w))
+		retryAfter, err := time.ParseDuration(w.Header().Get("Retry-After") + "s")
+		require.NoError(t, err)
+		assert.InDelta(t, 600, retryAfter.Seconds(), 5)
+	})
+
+	t.Run("template matches count towards the lockout", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(3))
+		store := &memoryVectorStore{vectors: make(map[string][]models.FaceVector)}
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    "gina",
+			Vector:    randomVector(rng, 128),
+			CreatedAt: time.Now().Add(-time.Hour),
+			Version:   "1.0",
+		}))
+		require.NoError(t, service.SetVectorStore(store))
+
+		for i := 0; i < 2; i++ {
+			_, matched, err := service.MatchTemplateFrom("gina", randomVector(rng, 128), "203.0.113.9")
+			require.NoError(t, err)
+			assert.False(t, matched)
+		}
+		_, _, err := service.MatchTemplateFrom("gina", randomVector(rng, 128), "203.0.113.10")
+		var lockout *services.LockedOutError
+		require.True(t, errors.As(err, &lockout))
+		assert.Equal(t, services.LockoutSubjectUser, lockout.Subject)
+	})
+}
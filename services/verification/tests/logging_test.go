@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/logging"
+	"connect-hub/verification-service/internal/middleware"
+)
+
+func TestLogRedaction(t *testing.T) {
+	redactor, err := logging.NewRedactor(&config.Config{
+		LogRedaction:          "on",
+		LogRedactionKey:       "test-redaction-key",
+		LogRedactionAllowlist: "device",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, redactor)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).WithOptions(zap.WrapCore(redactor.Wrap))
+
+	t.Run("personal data is hashed or masked", func(t *testing.T) {
+		logger.With(zap.String("session_id", "session-123")).Info("Verification completed",
+			zap.String("user_id", "alice"),
+			zap.String("filename", "alice-selfie.webm"),
+			zap.Strings("labels", []string{"Alice Smith"}),
+			zap.String("verification_id", "3b9f2c1e-verification"),
+			zap.String("tenant", "acme"),
+			zap.String("device", "Pixel 8"),
+			zap.Float64("confidence", 0.93),
+			zap.Bool("verified", true),
+			zap.Duration("processing_time", 1500*time.Millisecond),
+			zap.Error(errors.New("decode failed")),
+		)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+
+		assert.Equal(t, redactor.Hash("alice"), fields["user_id"])
+		assert.Equal(t, redactor.Hash("session-123"), fields["session_id"])
+		assert.Equal(t, redactor.Hash("alice-selfie.webm"), fields["filename"])
+		assert.Equal(t, logging.Masked, fields["labels"])
+		assert.NotContains(t, fields["user_id"], "alice")
+
+		assert.Equal(t, "3b9f2c1e-verification", fields["verification_id"])
+		assert.Equal(t, "acme", fields["tenant"])
+		assert.Equal(t, "Pixel 8", fields["device"], "extra allowlisted field")
+		assert.Equal(t, 0.93, fields["confidence"])
+		assert.Equal(t, true, fields["verified"])
+		assert.Equal(t, 1500*time.Millisecond, fields["processing_time"])
+		assert.Equal(t, "decode failed", fields["error"])
+	})
+
+	t.Run("hashes are stable per key", func(t *testing.T) {
+		assert.Equal(t, redactor.Hash("alice"), redactor.Hash("alice"))
+		assert.NotEqual(t, redactor.Hash("alice"), redactor.Hash("bob"))
+
+		other, err := logging.NewRedactor(&config.Config{LogRedaction: "on", LogRedactionKey: "other-key"})
+		require.NoError(t, err)
+		assert.NotEqual(t, redactor.Hash("alice"), other.Hash("alice"))
+	})
+
+	t.Run("off leaves logging alone", func(t *testing.T) {
+		for _, setting := range []string{"", "off"} {
+			off, err := logging.NewRedactor(&config.Config{LogRedaction: setting})
+			require.NoError(t, err)
+			assert.Nil(t, off)
+		}
+	})
+
+	t.Run("the access log names the route, not the path", func(t *testing.T) {
+		// The access log writes to gin.DefaultWriter as it was when created
+		var output bytes.Buffer
+		previous := gin.DefaultWriter
+		gin.DefaultWriter = &output
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(middleware.RedactedLogger(redactor))
+		gin.DefaultWriter = previous
+		router.GET("/api/v1/status/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/status/alice-verification", nil)
+		req.RemoteAddr = "203.0.113.7:4711"
+		router.ServeHTTP(w, req)
+		logged := output.String()
+
+		assert.Contains(t, logged, "/api/v1/status/:id")
+		assert.NotContains(t, logged, "alice")
+		assert.NotContains(t, logged, "203.0.113.7")
+		assert.Contains(t, logged, redactor.Hash("203.0.113.7"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// createFlashFrames builds perColor frames of a scene lit by each screen
+// color in turn; an empty color leaves the scene unlit.
+func createFlashFrames(colors []string, perColor int) []image.Image {
+	const width, height = 160, 120
+	var frames []image.Image
+	for _, flash := range colors {
+		for i := 0; i < perColor; i++ {
+			frame := image.NewRGBA(image.Rect(0, 0, width, height))
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					px := color.RGBA{R: uint8(100 + x/4), G: uint8(90 + y/4), B: 80, A: 255}
+					switch flash {
+					case services.ScreenColorRed:
+						px.R += 30
+					case services.ScreenColorGreen:
+						px.G += 30
+					case services.ScreenColorBlue:
+						px.B += 30
+					}
+					frame.Set(x, y, px)
+				}
+			}
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+func TestColorSequenceShown(t *testing.T) {
+	sequence := []string{services.ScreenColorRed, services.ScreenColorGreen, services.ScreenColorBlue, services.ScreenColorRed}
+
+	assert.True(t, services.ColorSequenceShown(sequence, createFlashFrames(sequence, 2)))
+	assert.True(t, services.ColorSequenceShown(sequence, append(createFlashFrames([]string{""}, 2), createFlashFrames(sequence, 3)...)),
+		"unlit frames before the first flash are skipped")
+
+	assert.False(t, services.ColorSequenceShown(sequence, createFlashFrames([]string{"red", "blue", "green", "red"}, 2)), "wrong order")
+	assert.False(t, services.ColorSequenceShown(sequence, createFlashFrames(sequence[:3], 2)), "flash missing")
+	assert.False(t, services.ColorSequenceShown(sequence, createFlashFrames([]string{"", "", ""}, 2)), "no flashes")
+	assert.False(t, services.ColorSequenceShown(sequence, nil))
+}
+
+func TestNonceBinding(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		NonceBindingEnabled: true,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+	service.SetChallengeDetector(&stubChallengeDetector{performed: true})
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	startSession := func(t *testing.T) models.LivenessSession {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/liveness/session", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data models.LivenessSession `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	t.Run("sessions carry a color sequence", func(t *testing.T) {
+		session := startSession(t)
+		require.Len(t, session.ColorSequence, 4)
+		for i, flash := range session.ColorSequence {
+			assert.Contains(t, []string{"red", "green", "blue"}, flash)
+			if i > 0 {
+				assert.NotEqual(t, session.ColorSequence[i-1], flash, "no color is flashed twice in a row")
+			}
+		}
+	})
+
+	t.Run("captures without a session are refused", func(t *testing.T) {
+		body, contentType, err := createMultipartForm(map[string]interface{}{"video": createTestVideoFile()})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "LIVENESS_SESSION_REQUIRED")
+	})
+
+	verify := func(t *testing.T, session models.LivenessSession, frames []image.Image) string {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			FrameData:       encodeJPEGFrames(t, frames),
+			SessionID:       "nonce-" + session.SessionToken,
+			LivenessSession: session.SessionToken,
+			LivenessNonce:   session.Nonce,
+		})
+		require.NoError(t, err)
+		return result.Reason
+	}
+
+	t.Run("capture showing the sequence is bound", func(t *testing.T) {
+		session := startSession(t)
+		assert.NotEqual(t, models.ReasonNonceNotBound, verify(t, session, createFlashFrames(session.ColorSequence, 2)))
+	})
+
+	t.Run("capture recorded for another session is rejected", func(t *testing.T) {
+		earlier, session := startSession(t), startSession(t)
+		for assert.ObjectsAreEqual(earlier.ColorSequence, session.ColorSequence) {
+			session = startSession(t)
+		}
+		assert.Equal(t, models.ReasonNonceNotBound, verify(t, session, createFlashFrames(earlier.ColorSequence, 2)))
+	})
+
+	t.Run("capture without flashes is rejected", func(t *testing.T) {
+		session := startSession(t)
+		assert.Equal(t, models.ReasonNonceNotBound, verify(t, session, createFlashFrames([]string{"", ""}, 3)))
+	})
+}
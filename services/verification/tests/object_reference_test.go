@@ -0,0 +1,390 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// stubObjectStore serves objects from memory and records fetched keys.
+type stubObjectStore struct {
+	objects map[string][]byte
+	fetched []string
+}
+
+func (s *stubObjectStore) Get(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	s.fetched = append(s.fetched, key)
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, storage.ErrObjectNotFound
+	}
+	if int64(len(data)) > maxSize {
+		return nil, storage.ErrObjectTooLarge
+	}
+	return data, nil
+}
+
+func TestVerificationHandler_VerifyReference(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		ObjectKeyPrefix:     "uploads/",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	store := &stubObjectStore{objects: map[string][]byte{
+		"uploads/user-1/capture.webm": createTestVideoData(),
+		"private/other/capture.webm":  createTestVideoData(),
+	}}
+	service.SetObjectStore(store)
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	verifyRef := func(body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/verify/ref", bytes.NewReader(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.VerifyReference(c)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("referenced object is verified", func(t *testing.T) {
+		w, response := verifyRef(map[string]interface{}{
+			"object_key": "uploads/user-1/capture.webm",
+			"session_id": "test-session-ref",
+		})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, response["success"].(bool))
+		assert.Contains(t, store.fetched, "uploads/user-1/capture.webm")
+	})
+
+	t.Run("out-of-scope key is rejected without fetching", func(t *testing.T) {
+		store.fetched = nil
+
+		w, response := verifyRef(map[string]interface{}{
+			"object_key": "private/other/capture.webm",
+		})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "OBJECT_KEY_FORBIDDEN", response["code"])
+		assert.Empty(t, store.fetched)
+	})
+
+	t.Run("path traversal is rejected", func(t *testing.T) {
+		store.fetched = nil
+
+		for _, key := range []string{
+			"uploads/../private/other/capture.webm",
+			"/uploads/user-1/capture.webm",
+			"uploads//capture.webm",
+			"uploads/user-1/capture.webm?x=1",
+			"",
+		} {
+			w, response := verifyRef(map[string]interface{}{"object_key": key})
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, key)
+			assert.Equal(t, "INVALID_OBJECT_KEY", response["code"], key)
+		}
+		assert.Empty(t, store.fetched)
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		w, response := verifyRef(map[string]interface{}{
+			"object_key": "uploads/user-1/missing.webm",
+		})
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "OBJECT_NOT_FOUND", response["code"])
+	})
+}
+
+// deletingObjectStore is a stubObjectStore that can delete objects.
+type deletingObjectStore struct {
+	stubObjectStore
+	deleted []string
+}
+
+func (s *deletingObjectStore) Delete(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	delete(s.objects, key)
+	return nil
+}
+
+func TestVerificationHandler_VerifyObjectURL(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		ObjectKeyPrefix:     "uploads/",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	// Stand in for an s3 store on the captures bucket
+	cfg.ObjectStoreType = "s3"
+	cfg.ObjectStoreBucket = "captures"
+	store := &deletingObjectStore{stubObjectStore: stubObjectStore{objects: map[string][]byte{
+		"uploads/user-1/capture.webm": createTestVideoData(),
+		"uploads/user-2/capture.webm": createTestVideoData(),
+	}}}
+	service.SetObjectStore(store)
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	verify := func(body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/verify", bytes.NewReader(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.VerifyVideo(c)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("JSON body on /verify fetches the URL", func(t *testing.T) {
+		w, response := verify(map[string]interface{}{
+			"url":        "s3://captures/uploads/user-1/capture.webm",
+			"session_id": "test-session-url",
+		})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, response["success"].(bool))
+		assert.Contains(t, store.fetched, "uploads/user-1/capture.webm")
+		assert.Empty(t, store.deleted)
+	})
+
+	t.Run("object is deleted once verified", func(t *testing.T) {
+		w, _ := verify(map[string]interface{}{
+			"url":           "s3://captures/uploads/user-2/capture.webm",
+			"session_id":    "test-session-delete",
+			"delete_object": true,
+		})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"uploads/user-2/capture.webm"}, store.deleted)
+	})
+
+	t.Run("URLs outside the bucket are forbidden", func(t *testing.T) {
+		store.fetched = nil
+
+		for _, objectURL := range []string{
+			"s3://other-bucket/uploads/user-1/capture.webm",
+			"gs://captures/uploads/user-1/capture.webm",
+			"s3://captures/private/capture.webm",
+		} {
+			w, response := verify(map[string]interface{}{"url": objectURL})
+
+			assert.Equal(t, http.StatusForbidden, w.Code, objectURL)
+			assert.Equal(t, "OBJECT_KEY_FORBIDDEN", response["code"], objectURL)
+		}
+		assert.Empty(t, store.fetched)
+	})
+
+	t.Run("malformed URLs are rejected", func(t *testing.T) {
+		for _, objectURL := range []string{
+			"https://captures.s3.amazonaws.com/uploads/user-1/capture.webm",
+			"s3:///uploads/user-1/capture.webm",
+			"s3://captures/uploads/user-1/capture.webm?versionId=1",
+		} {
+			w, response := verify(map[string]interface{}{"url": objectURL})
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, objectURL)
+			assert.Equal(t, "INVALID_OBJECT_URL", response["code"], objectURL)
+		}
+
+		w, response := verify(map[string]interface{}{
+			"url":        "s3://captures/uploads/user-1/capture.webm",
+			"object_key": "uploads/user-1/capture.webm",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_REQUEST", response["code"])
+	})
+
+	t.Run("deletion needs a store that can delete", func(t *testing.T) {
+		service.SetObjectStore(&stubObjectStore{objects: map[string][]byte{
+			"uploads/user-1/capture.webm": createTestVideoData(),
+		}})
+		defer service.SetObjectStore(store)
+
+		w, response := verify(map[string]interface{}{
+			"url":           "s3://captures/uploads/user-1/capture.webm",
+			"delete_object": true,
+		})
+
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Equal(t, "OBJECT_DELETE_UNSUPPORTED", response["code"])
+	})
+}
+
+func TestVerificationHandler_CreateUpload(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		StoragePath:        t.TempDir(),
+		EncryptionKey:      "test-encryption-key-for-testing-only",
+		ObjectKeyPrefix:    "uploads/",
+		ObjectStoreType:    "s3",
+		ObjectStoreBucket:  "captures",
+		AWSRegion:          "eu-west-1",
+		AWSAccessKeyID:     "AKIDEXAMPLE",
+		AWSSecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		ObjectUploadTTL:    600,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	createUpload := func(contentType string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/uploads", strings.NewReader(`{"content_type":"`+contentType+`"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateUpload(c)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("issues a pre-signed PUT under the prefix", func(t *testing.T) {
+		w, response := createUpload("video/webm")
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		data := response["data"].(map[string]interface{})
+		key := data["object_key"].(string)
+		assert.True(t, strings.HasPrefix(key, "uploads/"), key)
+		assert.True(t, strings.HasSuffix(key, ".webm"), key)
+		assert.Equal(t, "s3://captures/"+key, data["url"])
+		assert.Equal(t, "PUT", data["method"])
+		assert.Equal(t, map[string]interface{}{"Content-Type": "video/webm"}, data["headers"])
+
+		uploadURL, err := url.Parse(data["upload_url"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, "captures.s3.eu-west-1.amazonaws.com", uploadURL.Host)
+		assert.Equal(t, "/"+key, uploadURL.Path)
+		assert.Equal(t, "600", uploadURL.Query().Get("X-Amz-Expires"))
+		assert.Equal(t, "content-type;host", uploadURL.Query().Get("X-Amz-SignedHeaders"))
+		assert.Len(t, uploadURL.Query().Get("X-Amz-Signature"), 64)
+
+		// The issued URL is accepted for verification
+		objectKey, err := service.ObjectKeyFromURL(data["url"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, key, objectKey)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		w, response := createUpload("text/html")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_CONTENT_TYPE", response["code"])
+	})
+
+	t.Run("stores without pre-signing", func(t *testing.T) {
+		service.SetObjectStore(&stubObjectStore{})
+
+		w, response := createUpload("video/mp4")
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Equal(t, "UPLOADS_UNSUPPORTED", response["code"])
+	})
+}
+
+func TestS3ObjectStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{"/captures/uploads/a.webm": []byte("capture")}
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		data, ok := objects[r.URL.Path]
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/captures/":
+			w.WriteHeader(http.StatusForbidden)
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			w.Write(data)
+		case r.Method == http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := storage.NewGCSObjectStore(server.URL, "captures", "GOOGTESTACCESSID", "test-secret", server.Client())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	data, err := store.Get(ctx, "uploads/a.webm", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("capture"), data)
+
+	_, err = store.Get(ctx, "uploads/a.webm", 3)
+	assert.ErrorIs(t, err, storage.ErrObjectTooLarge)
+
+	require.NoError(t, store.Delete(ctx, "uploads/a.webm"))
+	_, err = store.Get(ctx, "uploads/a.webm", 1024)
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	assert.NoError(t, store.Delete(ctx, "uploads/a.webm"))
+
+	// Objects-only credentials are refused the bucket HEAD; that is healthy
+	assert.NoError(t, store.CheckHealth(ctx))
+
+	mu.Lock()
+	for _, authorization := range authorizations {
+		assert.Contains(t, authorization, "Credential=GOOGTESTACCESSID/")
+		assert.Contains(t, authorization, "/auto/s3/aws4_request")
+	}
+	mu.Unlock()
+
+	_, err = storage.NewS3ObjectStore("", "Bad_Bucket", "eu-west-1", storage.AWSCredentials{AccessKeyID: "a", SecretAccessKey: "b"}, nil)
+	assert.Error(t, err)
+}
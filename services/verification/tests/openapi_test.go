@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/openapi"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		OpenAPIEnabled:      true,
+		// Enables the optional self-service routes so every route is checked
+		JWTSecret: "test-jwt-secret",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	paths := spec["paths"].(map[string]interface{})
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	t.Run("document is a valid OpenAPI 3 document", func(t *testing.T) {
+		assert.Equal(t, openapi.Version, spec["openapi"])
+		info := spec["info"].(map[string]interface{})
+		assert.NotEmpty(t, info["title"])
+		assert.NotEmpty(t, info["version"])
+
+		methods := map[string]bool{"get": true, "put": true, "post": true, "delete": true, "patch": true, "head": true, "options": true}
+		operationIDs := map[string]bool{}
+		templateParam := regexp.MustCompile(`\{([^}]+)\}`)
+
+		for path, item := range paths {
+			assert.True(t, strings.HasPrefix(path, "/"), path)
+
+			for method, op := range item.(map[string]interface{}) {
+				require.True(t, methods[method], "%s: invalid method %q", path, method)
+				operation := op.(map[string]interface{})
+
+				id, _ := operation["operationId"].(string)
+				assert.NotEmpty(t, id, "%s %s", method, path)
+				assert.False(t, operationIDs[id], "duplicate operationId %s", id)
+				operationIDs[id] = true
+
+				responses, _ := operation["responses"].(map[string]interface{})
+				assert.NotEmpty(t, responses, "%s %s has no responses", method, path)
+				for status := range responses {
+					assert.Regexp(t, `^[1-5][0-9][0-9]$`, status)
+				}
+
+				// Every templated segment needs a matching path parameter
+				declared := map[string]bool{}
+				params, _ := operation["parameters"].([]interface{})
+				for _, p := range params {
+					param := p.(map[string]interface{})
+					assert.Contains(t, []string{"path", "query", "header"}, param["in"])
+					if param["in"] == "path" {
+						assert.Equal(t, true, param["required"])
+						declared[param["name"].(string)] = true
+					}
+				}
+				for _, match := range templateParam.FindAllStringSubmatch(path, -1) {
+					assert.True(t, declared[match[1]], "%s %s: undeclared path parameter %s", method, path, match[1])
+				}
+			}
+		}
+
+		// Every $ref resolves to a component schema
+		var walk func(node interface{})
+		walk = func(node interface{}) {
+			switch v := node.(type) {
+			case map[string]interface{}:
+				if target, ok := v["$ref"].(string); ok {
+					name := strings.TrimPrefix(target, "#/components/schemas/")
+					assert.NotEqual(t, target, name, "unsupported $ref %s", target)
+					assert.Contains(t, schemas, name, "unresolved $ref %s", target)
+				}
+				for _, child := range v {
+					walk(child)
+				}
+			case []interface{}:
+				for _, child := range v {
+					walk(child)
+				}
+			}
+		}
+		walk(spec)
+	})
+
+	t.Run("documents the response and error schemas", func(t *testing.T) {
+		for _, name := range []string{"VerificationResult", "Error", "PrecheckResult", "HistoryEntry"} {
+			assert.Contains(t, schemas, name)
+		}
+
+		errorProps := schemas["Error"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Contains(t, errorProps, "error")
+		assert.Contains(t, errorProps, "code")
+
+		verify := paths["/api/v1/verify"].(map[string]interface{})["post"].(map[string]interface{})
+		form := verify["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["multipart/form-data"].(map[string]interface{})
+		fields := form["schema"].(map[string]interface{})["properties"].(map[string]interface{})
+		for _, field := range []string{"video", "user_id", "session_id", "device", "action"} {
+			assert.Contains(t, fields, field)
+		}
+	})
+
+	t.Run("lists exactly the registered routes", func(t *testing.T) {
+		ginParam := regexp.MustCompile(`:([^/]+)`)
+
+		registered := map[string]bool{}
+		for _, route := range router.Routes() {
+			path := ginParam.ReplaceAllString(route.Path, "{$1}")
+			key := strings.ToLower(route.Method) + " " + path
+			registered[key] = true
+
+			item, ok := paths[path].(map[string]interface{})
+			if assert.True(t, ok, "route %s %s missing from spec", route.Method, route.Path) {
+				assert.Contains(t, item, strings.ToLower(route.Method), "route %s %s missing from spec", route.Method, route.Path)
+			}
+		}
+
+		for path, item := range paths {
+			for method := range item.(map[string]interface{}) {
+				assert.True(t, registered[method+" "+path], "spec lists unregistered route %s %s", method, path)
+			}
+		}
+	})
+}
+
+func TestOpenAPISpec_Disabled(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
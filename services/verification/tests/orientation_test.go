@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// frameDetector passes every challenge, keeping the first frame it saw.
+type frameDetector struct {
+	frame image.Image
+}
+
+func (d *frameDetector) Performed(challenge string, frames []image.Image) bool {
+	d.frame = frames[0]
+	return true
+}
+
+// leftRedFrame is a grey frame whose left quarter is red, to tell which
+// way it was turned.
+func leftRedFrame(width, height int) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{128, 128, 128, 255}
+			if x < width/4 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			frame.SetRGBA(x, y, c)
+		}
+	}
+	return frame
+}
+
+// mp4WithRotation builds an MP4 whose video track header carries the
+// transformation matrix of a clockwise rotation by degrees.
+func mp4WithRotation(degrees int) []byte {
+	const one = 1 << 16
+	a, b, c, d := int32(one), int32(0), int32(0), int32(one)
+	switch degrees {
+	case 90:
+		a, b, c, d = 0, one, -one, 0
+	case 180:
+		a, d = -one, -one
+	case 270:
+		a, b, c, d = 0, -one, one, 0
+	}
+	tkhd := make([]byte, 84)
+	for i, value := range []int32{a, b, 0, c, d, 0, 0, 0, 1 << 30} {
+		binary.BigEndian.PutUint32(tkhd[40+i*4:], uint32(value))
+	}
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], "vide")
+
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("qt  \x00\x00\x02\x00qt  ")),
+		mp4Box("moov", mp4Box("trak", mp4Box("tkhd", tkhd), mp4Box("mdia", mp4Box("hdlr", hdlr)))),
+		mp4Box("mdat", make([]byte, 1024)),
+	}, nil)
+}
+
+// jpegWithOrientation encodes img as a JPEG carrying an EXIF orientation.
+func jpegWithOrientation(t *testing.T, img image.Image, orientation uint16) []byte {
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 95}))
+
+	// Little-endian TIFF with one IFD entry: Orientation, SHORT, count 1
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(app1)+2))
+	data := encoded.Bytes()
+	return bytes.Join([][]byte{data[:2], segment, app1, data[2:]}, nil)
+}
+
+func TestCaptureOrientation(t *testing.T) {
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	detector := &frameDetector{}
+	service.SetChallengeDetector(detector)
+	// The frame the detector saw, for a capture answering a fresh session
+	firstFrame := func(t *testing.T, capture []byte) image.Image {
+		session, err := service.StartLivenessSession()
+		require.NoError(t, err)
+		_, err = service.VerifyVideo(&models.VerificationRequest{
+			VideoData:       capture,
+			SessionID:       "orientation-" + session.SessionToken,
+			LivenessSession: session.SessionToken,
+			LivenessNonce:   session.Nonce,
+		})
+		// The synthetic frames hold no face to match
+		if err != nil {
+			require.ErrorIs(t, err, services.ErrNoFaceDetected)
+		}
+		require.NotNil(t, detector.frame)
+		return detector.frame
+	}
+	isRed := func(c color.Color) bool {
+		r, g, _, _ := c.RGBA()
+		return r > 0xC000 && g < 0x4000
+	}
+
+	t.Run("rotated videos are turned upright", func(t *testing.T) {
+		service.SetFrameDecoder(&recordingDecoder{frames: []image.Image{leftRedFrame(64, 48), leftRedFrame(64, 48)}})
+
+		frame := firstFrame(t, mp4WithRotation(90))
+		assert.Equal(t, image.Pt(48, 64), frame.Bounds().Size())
+		// The left edge ends up on top
+		assert.True(t, isRed(frame.At(24, 2)))
+		assert.False(t, isRed(frame.At(24, 61)))
+
+		frame = firstFrame(t, mp4WithRotation(270))
+		assert.Equal(t, image.Pt(48, 64), frame.Bounds().Size())
+		assert.True(t, isRed(frame.At(24, 61)))
+
+		frame = firstFrame(t, mp4WithRotation(180))
+		assert.Equal(t, image.Pt(64, 48), frame.Bounds().Size())
+		assert.True(t, isRed(frame.At(61, 24)))
+
+		frame = firstFrame(t, mp4WithRotation(0))
+		assert.Equal(t, image.Pt(64, 48), frame.Bounds().Size())
+		assert.True(t, isRed(frame.At(2, 24)))
+	})
+
+	t.Run("EXIF orientation of a photo is applied", func(t *testing.T) {
+		frame := firstFrame(t, jpegWithOrientation(t, leftRedFrame(64, 48), 6))
+		assert.Equal(t, image.Pt(48, 64), frame.Bounds().Size())
+		assert.True(t, isRed(frame.At(24, 2)))
+
+		frame = firstFrame(t, jpegWithOrientation(t, leftRedFrame(64, 48), 1))
+		assert.Equal(t, image.Pt(64, 48), frame.Bounds().Size())
+	})
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"image"
+	"image/draw"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// sliceDecoder yields a fixed set of frames for any capture.
+type sliceDecoder struct {
+	frames []image.Image
+}
+
+type sliceFrames struct {
+	frames []image.Image
+}
+
+func (d *sliceDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	return &sliceFrames{frames: d.frames}, nil
+}
+
+func (f *sliceFrames) Next() (image.Image, error) {
+	if len(f.frames) == 0 {
+		return nil, io.EOF
+	}
+	frame := f.frames[0]
+	f.frames = f.frames[1:]
+	return frame, nil
+}
+
+func (f *sliceFrames) Close() error {
+	return nil
+}
+
+// TestLivenessScoringImageLayouts checks that scoring from raw pixel
+// buffers does not depend on how the frames are laid out in memory.
+func TestLivenessScoringImageLayouts(t *testing.T) {
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	}
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	grey := createPanningFrames(5, 3, 1)
+
+	packed := make([]image.Image, len(grey))
+	for i, frame := range grey {
+		rgba := image.NewRGBA(frame.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), frame, image.Point{}, draw.Src)
+		packed[i] = rgba
+	}
+
+	// Each frame a window into a larger canvas: a non-zero origin and a
+	// stride wider than the frame
+	windowed := make([]image.Image, len(grey))
+	for i, frame := range grey {
+		canvas := image.NewRGBA(image.Rect(0, 0, 400, 300))
+		window := image.Rect(40, 30, 40+frame.Bounds().Dx(), 30+frame.Bounds().Dy())
+		draw.Draw(canvas, window, frame, image.Point{}, draw.Src)
+		windowed[i] = canvas.SubImage(window)
+	}
+
+	score := func(frames []image.Image) float64 {
+		service.SetFrameDecoder(&sliceDecoder{frames: frames})
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "pixel-layout-session",
+		})
+		require.NoError(t, err)
+		return result.LivenessScore
+	}
+
+	expected := score(grey)
+	assert.Greater(t, expected, 0.0)
+	assert.InDelta(t, expected, score(packed), 1e-9)
+	assert.InDelta(t, expected, score(windowed), 1e-9)
+}
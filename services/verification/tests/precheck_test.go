@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/metrics"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestFaceVerificationService_LivenessPrecheck(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, enabled bool, ttl int) *services.FaceVerificationService {
+		cfg := &config.Config{
+			LivenessThreshold:       0.5,
+			SimilarityThreshold:     0.75,
+			StoragePath:             t.TempDir(),
+			EncryptionKey:           "test-encryption-key-for-testing-only",
+			LivenessPrecheckEnabled: enabled,
+			ContinuationTTL:         ttl,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	precheck := func(t *testing.T, service *services.FaceVerificationService) *models.PrecheckResult {
+		result, err := service.PrecheckLiveness(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "test-session-precheck",
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("phase two reuses the cached frames", func(t *testing.T) {
+		service := newService(t, true, 60)
+		require.NoError(t, service.RegisterFace("precheck-user", createTestVideoData()))
+
+		phaseOne := precheck(t, service)
+		require.True(t, phaseOne.IsLive)
+		require.NotEmpty(t, phaseOne.ContinuationToken)
+		require.NotNil(t, phaseOne.ExpiresAt)
+
+		decodesBefore := metrics.VideoDecodes.Value()
+
+		result, err := service.ContinueVerification(phaseOne.ContinuationToken, "", "precheck-user")
+		require.NoError(t, err)
+
+		assert.Equal(t, decodesBefore, metrics.VideoDecodes.Value(), "phase two must not decode the capture again")
+		assert.True(t, result.Verified)
+		assert.Equal(t, phaseOne.VerificationID, result.VerificationID)
+		assert.Equal(t, phaseOne.LivenessScore, result.LivenessScore)
+		assert.Greater(t, result.RawConfidence, 0.75)
+	})
+
+	t.Run("token is single use", func(t *testing.T) {
+		service := newService(t, true, 60)
+
+		phaseOne := precheck(t, service)
+		_, err := service.ContinueVerification(phaseOne.ContinuationToken, "", "")
+		require.NoError(t, err)
+
+		_, err = service.ContinueVerification(phaseOne.ContinuationToken, "", "")
+		assert.ErrorIs(t, err, services.ErrContinuationExpired)
+	})
+
+	t.Run("token expires", func(t *testing.T) {
+		service := newService(t, true, 1)
+
+		phaseOne := precheck(t, service)
+		time.Sleep(1100 * time.Millisecond)
+
+		_, err := service.ContinueVerification(phaseOne.ContinuationToken, "", "")
+		assert.ErrorIs(t, err, services.ErrContinuationExpired)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		service := newService(t, true, 60)
+
+		_, err := service.ContinueVerification("not-a-token", "", "")
+		assert.ErrorIs(t, err, services.ErrContinuationExpired)
+	})
+
+	t.Run("non-live capture gets no token", func(t *testing.T) {
+		cfg := &config.Config{
+			LivenessThreshold:       0.85,
+			SimilarityThreshold:     0.75,
+			StoragePath:             t.TempDir(),
+			EncryptionKey:           "test-encryption-key-for-testing-only",
+			LivenessPrecheckEnabled: true,
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		result := precheck(t, service)
+
+		assert.False(t, result.IsLive)
+		assert.Equal(t, models.ReasonLivenessFailed, result.Reason)
+		assert.Empty(t, result.ContinuationToken)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		service := newService(t, false, 60)
+
+		_, err := service.PrecheckLiveness(&models.VerificationRequest{VideoData: createTestVideoData()})
+		assert.ErrorIs(t, err, services.ErrPrecheckDisabled)
+	})
+}
@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/queue"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestQueueWorker(t *testing.T) {
+	server := newFakeJetStream(t)
+	cfg := &config.Config{
+		StoragePath:       t.TempDir(),
+		EncryptionKey:     "test-encryption-key-for-testing-only",
+		AuditLogEnabled:   true,
+		ObjectStoreType:   "file",
+		ObjectStorePath:   t.TempDir(),
+		ObjectKeyPrefix:   "uploads/",
+		NATSURL:           "nats://" + server.addr,
+		NATSStream:        "JOBS",
+		NATSConsumer:      "workers",
+		NATSResultSubject: "verification.results",
+		WorkerConcurrency: 2,
+	}
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	worker, err := queue.NewWorker(service, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- worker.Run(ctx) }()
+
+	server.addJob(`{"job_id":"missing","object_key":"uploads/missing.mp4","reply_subject":"verification.results.web"}`)
+	server.addJob(`{"job_id":"bad-user","object_key":"uploads/a.mp4","user_id":"not a user"}`)
+	server.addJob(`{"job_id":"outside","object_key":"private/a.mp4"}`)
+	server.addJob(`not json`)
+
+	require.Eventually(t, func() bool { return len(server.acks()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	for _, ack := range server.acks() {
+		assert.Equal(t, "+ACK", ack)
+	}
+
+	results := make(map[string]queue.Result)
+	subjects := make(map[string]string)
+	for _, published := range server.published() {
+		var result queue.Result
+		require.NoError(t, json.Unmarshal(published.data, &result))
+		results[result.JobID] = result
+		subjects[result.JobID] = published.subject
+	}
+	require.Len(t, results, 4)
+	assert.Equal(t, "OBJECT_NOT_FOUND", results["missing"].Code)
+	assert.Equal(t, "verification.results.web", subjects["missing"])
+	assert.Equal(t, "INVALID_USER_ID", results["bad-user"].Code)
+	assert.Equal(t, "verification.results", subjects["bad-user"])
+	assert.Equal(t, "OBJECT_KEY_FORBIDDEN", results["outside"].Code)
+	assert.Equal(t, "INVALID_REQUEST", results[""].Code)
+	for _, result := range results {
+		assert.False(t, result.Success)
+	}
+
+	events, err := service.QueryAudit(storage.AuditQuery{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for _, event := range events {
+		assert.Equal(t, "queue", event.Transport)
+		assert.Equal(t, models.AuditVerify, event.Operation)
+		assert.Equal(t, models.AuditRejected, event.Result)
+	}
+
+	cancel()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop")
+	}
+
+	t.Run("requires NATS and an object store", func(t *testing.T) {
+		cfg.ObjectStoreType = ""
+		_, err := queue.NewWorker(service, zaptest.NewLogger(t))
+		assert.Error(t, err)
+
+		cfg.NATSURL = ""
+		_, err = queue.NewWorker(service, zaptest.NewLogger(t))
+		assert.Error(t, err)
+	})
+}
+
+type natsPublish struct {
+	subject string
+	data    []byte
+}
+
+// fakeJetStream is a NATS server holding a queue of jobs for pull
+// consumers. Pull requests wait until a job is added.
+type fakeJetStream struct {
+	addr  string
+	jobs  chan string
+	pulls chan func(job string, ackID int)
+
+	mu        sync.Mutex
+	publishes []natsPublish
+	acked     []string
+}
+
+func newFakeJetStream(t *testing.T) *fakeJetStream {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeJetStream{
+		addr:  listener.Addr().String(),
+		jobs:  make(chan string, 16),
+		pulls: make(chan func(string, int), 64),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	// Hand each job to the next pull request
+	go func() {
+		ackID := 0
+		for job := range server.jobs {
+			deliver := <-server.pulls
+			ackID++
+			deliver(job, ackID)
+		}
+	}()
+	return server
+}
+
+func (s *fakeJetStream) addJob(job string) {
+	s.jobs <- job
+}
+
+func (s *fakeJetStream) published() []natsPublish {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]natsPublish(nil), s.publishes...)
+}
+
+func (s *fakeJetStream) acks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.acked...)
+}
+
+func (s *fakeJetStream) serve(conn net.Conn) {
+	defer conn.Close()
+	var writeMu sync.Mutex
+	write := func(format string, args ...interface{}) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		fmt.Fprintf(conn, format, args...)
+	}
+	write("INFO {\"server_id\":\"fake\",\"headers\":true,\"max_payload\":1048576}\r\n")
+
+	subs := make(map[string]string)
+	var subsMu sync.Mutex
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "PING":
+			write("PONG\r\n")
+		case "SUB":
+			subsMu.Lock()
+			subs[fields[1]] = fields[2]
+			subsMu.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			payload = payload[:size]
+			subject := fields[1]
+
+			switch {
+			case strings.HasPrefix(subject, "$JS.API.CONSUMER.MSG.NEXT.JOBS.workers"):
+				inbox := fields[2]
+				s.pulls <- func(job string, ackID int) {
+					subsMu.Lock()
+					sid := subs[inbox]
+					subsMu.Unlock()
+					write("MSG %s %s $JS.ACK.JOBS.workers.1.%d.%d.0.0 %d\r\n%s\r\n", inbox, sid, ackID, ackID, len(job), job)
+				}
+			case strings.HasPrefix(subject, "$JS.ACK."):
+				if string(payload) != "+WPI" {
+					s.mu.Lock()
+					s.acked = append(s.acked, string(payload))
+					s.mu.Unlock()
+				}
+			default:
+				s.mu.Lock()
+				s.publishes = append(s.publishes, natsPublish{subject: subject, data: payload})
+				s.mu.Unlock()
+			}
+		}
+	}
+}
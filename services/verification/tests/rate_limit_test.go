@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"connect-hub/verification-service/internal/middleware"
+)
+
+func TestPerClientRateLimit(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.RateLimit(middleware.RateLimitConfig{
+		RequestsPerMinute: 60,
+		Burst:             3,
+		MaxClients:        2,
+	}))
+	router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	request := func(apiKey, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/ping", nil)
+		req.RemoteAddr = ip + ":1234"
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("burst is enforced per client with headers", func(t *testing.T) {
+		for i, remaining := range []string{"2", "1", "0"} {
+			w := request("noisy", "10.0.0.1")
+			assert.Equal(t, http.StatusOK, w.Code, "request %d", i)
+			assert.Equal(t, "60", w.Header().Get("X-RateLimit-Limit"))
+			assert.Equal(t, remaining, w.Header().Get("X-RateLimit-Remaining"))
+			assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+		}
+
+		w := request("noisy", "10.0.0.1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+	})
+
+	t.Run("other clients are unaffected", func(t *testing.T) {
+		// Same IP, different key
+		assert.Equal(t, http.StatusOK, request("quiet", "10.0.0.1").Code)
+		// No key falls back to the client IP
+		assert.Equal(t, http.StatusOK, request("", "10.0.0.2").Code)
+	})
+
+	t.Run("least recently seen client is evicted", func(t *testing.T) {
+		// "noisy" was pushed out by the two newer clients and starts afresh
+		assert.Equal(t, http.StatusOK, request("noisy", "10.0.0.1").Code)
+	})
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/rbac"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+// signClaimsJWT signs arbitrary claims with HS256.
+func signClaimsJWT(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestRoles(t *testing.T) {
+	t.Run("role lists", func(t *testing.T) {
+		roles, err := rbac.Parse("verify|reviewer")
+		require.NoError(t, err)
+		assert.True(t, roles.Has(rbac.Verify))
+		assert.True(t, roles.Has(rbac.Reviewer))
+		assert.False(t, roles.Has(rbac.Enroll))
+		assert.Equal(t, "verify|reviewer", roles.String())
+		assert.True(t, rbac.Admin.Has(rbac.Enroll), "admin implies every role")
+
+		_, err = rbac.Parse("verify|root")
+		assert.Error(t, err)
+
+		assert.Equal(t, rbac.Reviewer, rbac.FromScopes([]string{"openid", "reviewer"}))
+		assert.Zero(t, rbac.FromScopes([]string{"openid", "profile"}), "tokens naming no role hold none")
+	})
+
+	t.Run("API keys may be limited to roles", func(t *testing.T) {
+		registry, err := tenant.NewRegistry(tenant.Config{
+			APIKeys: "acme:plain-key, acme:review-key:reviewer, acme:app-key:verify|enroll, beta:colon:key",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, rbac.Default, registry.Roles("plain-key"))
+		assert.Equal(t, rbac.Reviewer, registry.Roles("review-key"))
+		assert.Equal(t, rbac.Verify|rbac.Enroll, registry.Roles("app-key"))
+		_, ok := registry.Resolve("review-key:reviewer")
+		assert.False(t, ok)
+
+		// A suffix that names no roles is part of the key
+		tenantID, ok := registry.Resolve("colon:key")
+		assert.True(t, ok)
+		assert.Equal(t, "beta", tenantID)
+
+		_, err = tenant.NewRegistry(tenant.Config{APIKeys: "acme:key:admin"})
+		assert.Error(t, err, "the admin role spans tenants")
+	})
+}
+
+func TestRoleEnforcement(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.85,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "admin-key",
+		TenantAPIKeys:       "acme:acme-key,acme:acme-review-key:reviewer,acme:acme-verify-key:verify",
+		JWTSecret:           "test-jwt-secret",
+		JWTTenantClaim:      "tenant",
+		JWTRolesClaim:       "roles",
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	call := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	apiKey := func(key string) map[string]string { return map[string]string{"X-API-Key": key} }
+	bearerFor := func(tenantID string, roles interface{}) map[string]string {
+		claims := map[string]interface{}{
+			"sub":   "operator",
+			"roles": roles,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		if tenantID != "" {
+			claims["tenant"] = tenantID
+		}
+		return map[string]string{"Authorization": "Bearer " + signClaimsJWT(t, cfg.JWTSecret, claims)}
+	}
+	bearer := func(roles interface{}) map[string]string { return bearerFor("acme", roles) }
+
+	t.Run("ordinary keys cannot reach the review queue or admin endpoints", func(t *testing.T) {
+		w := call("GET", "/api/v1/admin/reviews", apiKey("acme-key"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "ROLE_REQUIRED", errorCode(t, w))
+
+		w = call("GET", "/api/v1/admin/config", apiKey("acme-key"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "ADMIN_REQUIRED", errorCode(t, w))
+
+		// Past the role check, the missing video is what fails
+		w = call("POST", "/api/v1/verify", apiKey("acme-key"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("reviewer keys reach only the review queue", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/admin/reviews", apiKey("acme-review-key")).Code)
+		assert.Equal(t, http.StatusUnauthorized, call("GET", "/api/v1/admin/config", apiKey("acme-review-key")).Code)
+
+		for _, path := range []string{"/api/v1/verify", "/api/v1/uploads"} {
+			w := call("POST", path, apiKey("acme-review-key"))
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+			assert.Equal(t, "ROLE_REQUIRED", errorCode(t, w), path)
+		}
+	})
+
+	t.Run("verify keys cannot enroll", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/register", "/api/v1/template"} {
+			w := call("POST", path, apiKey("acme-verify-key"))
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+		}
+		assert.Equal(t, http.StatusBadRequest, call("POST", "/api/v1/verify", apiKey("acme-verify-key")).Code)
+	})
+
+	t.Run("bearer tokens carry their roles", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/admin/reviews", bearer("openid reviewer")).Code)
+		assert.Equal(t, http.StatusForbidden, call("GET", "/api/v1/admin/reviews", bearer([]string{"verify"})).Code)
+		assert.Equal(t, http.StatusForbidden, call("GET", "/api/v1/admin/reviews", bearer(nil)).Code)
+
+		assert.Equal(t, http.StatusUnauthorized, call("GET", "/api/v1/admin/config", bearer("reviewer")).Code)
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/admin/config", bearerFor("", "admin")).Code)
+	})
+
+	t.Run("tokens naming no role hold none", func(t *testing.T) {
+		w := call("POST", "/api/v1/verify", bearer("openid profile"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "ROLE_REQUIRED", errorCode(t, w))
+		assert.Equal(t, http.StatusBadRequest, call("POST", "/api/v1/verify", bearer("verify")).Code)
+	})
+
+	t.Run("admin tokens cannot be scoped to a tenant", func(t *testing.T) {
+		w := call("GET", "/api/v1/admin/config", bearer("admin"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "UNAUTHORIZED", errorCode(t, w))
+	})
+
+	t.Run("the admin key holds every role", func(t *testing.T) {
+		admin := map[string]string{"X-Admin-Key": "admin-key"}
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/admin/reviews", admin).Code)
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/admin/config", admin).Code)
+	})
+}
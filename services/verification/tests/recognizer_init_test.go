@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/services"
+)
+
+// modelSourceDir is where the dlib models used by the other tests live.
+func modelSourceDir(t *testing.T) string {
+	dir := os.Getenv("FACE_MODEL_PATH")
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	require.NoError(t, err)
+	return abs
+}
+
+func TestRecognizerInitRetry(t *testing.T) {
+	const retryLog = "Face recognizer initialization failed, retrying"
+
+	t.Run("succeeds once the model mount appears", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		mountPath := filepath.Join(t.TempDir(), "models")
+
+		cfg := &config.Config{
+			FaceModelPath:              mountPath,
+			RecognizerInitAttempts:     5,
+			RecognizerInitRetryDelayMs: 100,
+			LivenessThreshold:          0.85,
+			SimilarityThreshold:        0.75,
+			StoragePath:                t.TempDir(),
+			EncryptionKey:              "test-encryption-key-for-testing-only",
+		}
+
+		// Bring the mount up after the second failed attempt, so the
+		// third attempt is the first that can load the models.
+		go func() {
+			deadline := time.Now().Add(5 * time.Second)
+			for time.Now().Before(deadline) {
+				if logs.FilterMessage(retryLog).Len() >= 2 {
+					os.Symlink(modelSourceDir(t), mountPath)
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		service, err := services.NewFaceVerificationService(zap.New(core), cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		assert.Equal(t, 2, logs.FilterMessage(retryLog).Len())
+		assert.Equal(t, 1, logs.FilterMessage("Face recognizer initialized after retry").Len())
+	})
+
+	t.Run("gives up after the configured attempts", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		cfg := &config.Config{
+			FaceModelPath:              filepath.Join(t.TempDir(), "missing"),
+			RecognizerInitAttempts:     3,
+			RecognizerInitRetryDelayMs: 10,
+			StoragePath:                t.TempDir(),
+			EncryptionKey:              "test-encryption-key-for-testing-only",
+		}
+
+		_, err := services.NewFaceVerificationService(zap.New(core), cfg)
+		assert.Error(t, err)
+		assert.Equal(t, 2, logs.FilterMessage(retryLog).Len())
+	})
+
+	t.Run("single attempt by default", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		cfg := &config.Config{
+			FaceModelPath: filepath.Join(t.TempDir(), "missing"),
+			StoragePath:   t.TempDir(),
+			EncryptionKey: "test-encryption-key-for-testing-only",
+		}
+
+		_, err := services.NewFaceVerificationService(zap.New(core), cfg)
+		assert.Error(t, err)
+		assert.Equal(t, 0, logs.FilterMessage(retryLog).Len())
+	})
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestRecognizerPool(t *testing.T) {
+	newService := func(t *testing.T, poolSize int) *services.FaceVerificationService {
+		cfg := &config.Config{
+			FaceModelPath:       modelSourceDir(t),
+			RecognizerPoolSize:  poolSize,
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+		}
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), cfg)
+		require.NoError(t, err)
+		return service
+	}
+
+	document := encodeJPEGFrames(t, createPanningFrames(1, 0, 0))[0]
+	compare := func(service *services.FaceVerificationService) error {
+		_, err := service.CompareFaces(context.Background(), services.BytesVideo(createTestVideoData()), document)
+		if errors.Is(err, services.ErrNoFaceInCapture) || errors.Is(err, services.ErrNoFaceInDocument) {
+			// The synthetic images may hold no detectable face
+			return nil
+		}
+		return err
+	}
+
+	t.Run("loads one recognizer up front", func(t *testing.T) {
+		service := newService(t, 4)
+		defer service.Close()
+
+		stats := service.RecognizerPoolStats()
+		assert.Equal(t, services.RecognizerPoolStats{Size: 4, Loaded: 1, InUse: 0}, stats)
+	})
+
+	t.Run("parallel requests load recognizers on demand", func(t *testing.T) {
+		service := newService(t, 3)
+		defer service.Close()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- compare(service)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		stats := service.RecognizerPoolStats()
+		assert.Equal(t, 3, stats.Size)
+		assert.GreaterOrEqual(t, stats.Loaded, 1)
+		assert.LessOrEqual(t, stats.Loaded, 3)
+		assert.Zero(t, stats.InUse)
+	})
+
+	t.Run("defaults to a single recognizer", func(t *testing.T) {
+		service := newService(t, 0)
+		defer service.Close()
+
+		assert.Equal(t, 1, service.RecognizerPoolStats().Size)
+		assert.NoError(t, compare(service))
+	})
+
+	t.Run("checkouts fail once closed", func(t *testing.T) {
+		service := newService(t, 2)
+		service.Close()
+
+		err := compare(service)
+		assert.True(t, errors.Is(err, services.ErrRecognizerPoolClosed), "got %v", err)
+	})
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestVerificationRegionTagging(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "test-admin-key",
+		Region:              "eu-west-1",
+		AllowedRegions:      "eu-west-1,eu-central-1,us-east-1",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	verify := func(region string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		fields := map[string]interface{}{"video": createTestVideoFile()}
+		if region != "" {
+			fields["region"] = region
+		}
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	list := func(query, adminKey string) (*httptest.ResponseRecorder, []models.VerificationResult) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/admin/verifications"+query, nil)
+		if adminKey != "" {
+			req.Header.Set("X-Admin-Key", adminKey)
+		}
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Items []models.VerificationResult `json:"items"`
+			Count int                         `json:"count"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, len(response.Items), response.Count)
+		}
+		return w, response.Items
+	}
+
+	// Seed records from two client regions
+	recordIDs := map[string][]string{}
+	for _, region := range []string{"eu-central-1", "us-east-1", "eu-central-1", "US-EAST-1"} {
+		w, response := verify(region)
+		require.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		recordIDs[services.NormalizeRegion(region)] = append(recordIDs[services.NormalizeRegion(region)], data["verification_id"].(string))
+	}
+
+	t.Run("records carry processing and client region", func(t *testing.T) {
+		result, ok := service.GetVerificationResult(recordIDs["us-east-1"][0])
+		require.True(t, ok)
+
+		assert.Equal(t, "eu-west-1", result.ProcessingRegion)
+		assert.Equal(t, "us-east-1", result.ClientRegion)
+	})
+
+	t.Run("admin filter returns only matching records", func(t *testing.T) {
+		w, items := list("?client_region=us-east-1", "test-admin-key")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, items, 2)
+		for _, item := range items {
+			assert.Equal(t, "us-east-1", item.ClientRegion)
+			assert.Contains(t, recordIDs["us-east-1"], item.VerificationID)
+		}
+
+		w, items = list("?client_region=eu-central-1&processing_region=eu-west-1", "test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, items, 2)
+
+		w, items = list("?processing_region=us-east-1", "test-admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, items)
+	})
+
+	t.Run("filter requires admin key", func(t *testing.T) {
+		w, _ := list("?client_region=us-east-1", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w, _ = list("?client_region=us-east-1", "wrong-key")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("region outside the allowed set is rejected", func(t *testing.T) {
+		w, response := verify("ap-south-1")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "REGION_NOT_ALLOWED", response["code"])
+	})
+
+	t.Run("malformed region is rejected", func(t *testing.T) {
+		w, response := verify("eu west/1")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_REGION", response["code"])
+	})
+}
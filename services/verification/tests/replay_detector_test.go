@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// createSceneFrames renders count 320x240 frames of a bright oval "face" on
+// a darker background with fixed sensor noise, plus whatever overlay adds
+// for frame i at (x, y).
+func createSceneFrames(count int, overlay func(i, x, y int) float64) []image.Image {
+	const width, height = 320, 240
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]float64, width*height)
+	for i := range noise {
+		noise[i] = rng.Float64() * 30
+	}
+
+	frames := make([]image.Image, 0, count)
+	for i := 0; i < count; i++ {
+		frame := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				dx, dy := float64(x-width/2)/70, float64(y-height/2)/90
+				level := 90.0
+				if dx*dx+dy*dy < 1 {
+					level = 170
+				}
+				level += noise[y*width+x] + overlay(i, x, y)
+				frame.SetGray(x, y, color.Gray{Y: uint8(math.Max(0, math.Min(level, 255)))})
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func noOverlay(i, x, y int) float64 { return 0 }
+
+// moireOverlay is an oblique grating like the beat between a screen's pixel
+// grid and the camera sensor.
+func moireOverlay(i, x, y int) float64 {
+	return 40 * math.Sin(2*math.Pi*(float64(x)*0.23+float64(y)*0.17))
+}
+
+// refreshOverlay is horizontal banding that rolls between frames, as a
+// display refreshing out of step with the camera leaves behind.
+func refreshOverlay(i, x, y int) float64 {
+	return 25 * math.Sin(2*math.Pi*(float64(y)/12+float64(i)*0.3))
+}
+
+func TestReplayScore(t *testing.T) {
+	t.Run("live capture scores no replay", func(t *testing.T) {
+		assert.Zero(t, services.ReplayScore(createSceneFrames(5, noOverlay)))
+	})
+
+	t.Run("moiré is detected", func(t *testing.T) {
+		assert.Greater(t, services.ReplayScore(createSceneFrames(5, moireOverlay)), 0.5)
+	})
+
+	t.Run("rolling refresh bands are detected", func(t *testing.T) {
+		assert.Greater(t, services.ReplayScore(createSceneFrames(5, refreshOverlay)), 0.5)
+	})
+
+	t.Run("frames too small to crop score no replay", func(t *testing.T) {
+		assert.Zero(t, services.ReplayScore([]image.Image{image.NewGray(image.Rect(0, 0, 32, 32))}))
+	})
+}
+
+func TestReplayLivenessComponent(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	service, err := services.NewFaceVerificationService(logger, &config.Config{
+		LivenessThreshold:      0.5,
+		SimilarityThreshold:    0.75,
+		StoragePath:            t.TempDir(),
+		EncryptionKey:          "test-encryption-key-for-testing-only",
+		ReplayDetectionEnabled: true,
+		ReplayWeight:           0.2,
+		ReplayThreshold:        0.5,
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	verify := func(t *testing.T, frames []image.Image) *models.VerificationResult {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			FrameData: encodeJPEGFrames(t, frames),
+			SessionID: "replay-session",
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	live := verify(t, createSceneFrames(5, noOverlay))
+	replay := verify(t, createSceneFrames(5, moireOverlay))
+
+	assert.Equal(t, models.ReasonLivenessFailed, replay.Reason)
+	assert.Less(t, replay.LivenessScore, live.LivenessScore)
+}
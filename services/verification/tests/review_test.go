@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestManualReview(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AdminAPIKey:         "admin-key",
+		// Every test capture matches its own enrollment, so every 1:1
+		// verification lands in the gray zone
+		ReviewConfidenceMin: 0.5,
+		ReviewConfidenceMax: 1.0,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	require.NoError(t, service.RegisterFace("alice", createTestVideoData()))
+
+	verify := func(t *testing.T) map[string]interface{} {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": "alice",
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	admin := func(method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "admin-key")
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("gray zone verifications are held and approved", func(t *testing.T) {
+		result := verify(t)
+		assert.Equal(t, false, result["verified"])
+		assert.Equal(t, models.ReasonNeedsReview, result["reason"])
+		require.Contains(t, result, "review")
+		assert.Equal(t, "pending", result["review"].(map[string]interface{})["status"])
+		id := result["verification_id"].(string)
+
+		record, ok := service.GetVerificationRecord(id)
+		require.True(t, ok)
+		assert.Equal(t, models.StatusNeedsReview, record.Status)
+
+		w, response := admin("GET", "/api/v1/admin/reviews", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(1), response["count"])
+
+		w, evidence := admin("GET", "/api/v1/admin/reviews/"+id, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		frame, err := base64.StdEncoding.DecodeString(evidence["frame"].(string))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(frame, []byte{0xFF, 0xD8, 0xFF}), "evidence frame is a JPEG")
+		assert.Equal(t, []interface{}{services.ReviewTriggerConfidence}, evidence["case"].(map[string]interface{})["triggers"])
+
+		w, _ = admin("POST", "/api/v1/admin/reviews/"+id+"/approve", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, response = admin("POST", "/api/v1/admin/reviews/"+id+"/approve", `{"reviewer": "rita", "note": "same person"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decided := response["data"].(map[string]interface{})
+		assert.Equal(t, true, decided["verified"])
+		assert.NotContains(t, decided, "reason")
+		assert.Equal(t, "approved", decided["review"].(map[string]interface{})["status"])
+
+		record, _ = service.GetVerificationRecord(id)
+		assert.Equal(t, models.StatusCompleted, record.Status)
+		assert.True(t, record.Result.Verified)
+
+		// Evidence is dropped once the case is decided
+		_, evidence = admin("GET", "/api/v1/admin/reviews/"+id, "")
+		assert.NotContains(t, evidence, "frame")
+
+		w, response = admin("POST", "/api/v1/admin/reviews/"+id+"/reject", `{"reviewer": "rita"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "REVIEW_ALREADY_DECIDED", response["code"])
+	})
+
+	t.Run("held verifications can be rejected", func(t *testing.T) {
+		id := verify(t)["verification_id"].(string)
+
+		w, response := admin("POST", "/api/v1/admin/reviews/"+id+"/reject", `{"reviewer": "rita"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decided := response["data"].(map[string]interface{})
+		assert.Equal(t, false, decided["verified"])
+		assert.Equal(t, models.ReasonReviewRejected, decided["reason"])
+
+		_, response = admin("GET", "/api/v1/admin/reviews", "")
+		assert.Equal(t, float64(0), response["count"])
+		_, response = admin("GET", "/api/v1/admin/reviews?status=all", "")
+		assert.Equal(t, float64(2), response["count"])
+		_, response = admin("GET", "/api/v1/admin/reviews?status=rejected", "")
+		assert.Equal(t, float64(1), response["count"])
+	})
+
+	t.Run("unknown cases and statuses", func(t *testing.T) {
+		w, response := admin("GET", "/api/v1/admin/reviews/ver_0000000000", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "REVIEW_NOT_FOUND", response["code"])
+
+		w, _ = admin("GET", "/api/v1/admin/reviews?status=open", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
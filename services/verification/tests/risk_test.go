@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestRiskPolicyEngine(t *testing.T) {
+	policy := services.DefaultRiskPolicy()
+	policy.BlockedDevices = []string{"headlesschrome"}
+	policy.IPReputation = []services.IPReputation{
+		{Network: "203.0.113.0/24", Score: 0.4},
+		{Network: "203.0.113.128/25", Score: 1},
+	}
+	engine, err := services.NewPolicyRiskEngine(policy)
+	require.NoError(t, err)
+
+	clean := services.RiskInput{
+		UserID:        "alice",
+		LivenessScore: 0.95,
+		RawConfidence: 0.9,
+		Matched:       true,
+		Device:        "Mozilla/5.0",
+		ClientIP:      "198.51.100.7",
+		UserAttempts:  1,
+	}
+
+	t.Run("a clean attempt is approved", func(t *testing.T) {
+		assessment := engine.Assess(clean)
+		assert.Equal(t, models.RiskApprove, assessment.Decision)
+		assert.InDelta(t, 0.3*0.05+0.3*0.1+0.15*0.1, assessment.Score, 1e-4)
+		assert.NotContains(t, assessment.Signals, services.RiskSignalDevice)
+	})
+
+	t.Run("a watchlist hit denies on its own", func(t *testing.T) {
+		input := clean
+		input.WatchlistHit = true
+		assessment := engine.Assess(input)
+		assert.Equal(t, models.RiskDeny, assessment.Decision)
+		assert.Equal(t, 1.0, assessment.Score)
+	})
+
+	t.Run("the most specific network sets the reputation", func(t *testing.T) {
+		input := clean
+		input.ClientIP = "203.0.113.200"
+		assert.Equal(t, 0.15, engine.Assess(input).Signals[services.RiskSignalIPReputation])
+		input.ClientIP = "203.0.113.5"
+		assert.Equal(t, 0.06, engine.Assess(input).Signals[services.RiskSignalIPReputation])
+	})
+
+	t.Run("blocked devices and velocity add up", func(t *testing.T) {
+		input := clean
+		input.Device = "Mozilla/5.0 HeadlessChrome/120"
+		input.IPAttempts = 60
+		assessment := engine.Assess(input)
+		assert.Equal(t, 0.1, assessment.Signals[services.RiskSignalDevice])
+		assert.Equal(t, 0.15, assessment.Signals[services.RiskSignalVelocity])
+	})
+
+	t.Run("invalid policies are refused", func(t *testing.T) {
+		bad := services.DefaultRiskPolicy()
+		bad.Weights = map[string]float64{"shoe_size": 1}
+		_, err := services.NewPolicyRiskEngine(bad)
+		assert.Error(t, err)
+
+		bad = services.DefaultRiskPolicy()
+		bad.DenyThreshold = 0.2
+		_, err = services.NewPolicyRiskEngine(bad)
+		assert.Error(t, err)
+
+		bad = services.DefaultRiskPolicy()
+		bad.IPReputation = []services.IPReputation{{Network: "203.0.113.0", Score: 1}}
+		_, err = services.NewPolicyRiskEngine(bad)
+		assert.Error(t, err)
+	})
+}
+
+func TestRiskScoring(t *testing.T) {
+	// Only the device signal counts, so outcomes don't depend on the
+	// test captures' scores
+	policyPath := filepath.Join(t.TempDir(), "risk.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{
+		"weights": {"liveness": 0, "match": 0, "device": 1, "ip_reputation": 0, "velocity": 0, "watchlist": 0},
+		"blocked_devices": ["HeadlessChrome"]
+	}`), 0600))
+
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		RiskPolicyPath:      policyPath,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	require.NoError(t, service.RegisterFace("alice", createTestVideoData()))
+
+	verify := func(t *testing.T, device string) map[string]interface{} {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": "alice",
+			"device":  device,
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Contains(t, response.Data, "risk")
+		return response.Data
+	}
+
+	t.Run("blocked devices are denied", func(t *testing.T) {
+		result := verify(t, "Mozilla/5.0 HeadlessChrome/120")
+		assert.Equal(t, false, result["verified"])
+		assert.Equal(t, models.ReasonRiskDenied, result["reason"])
+		risk := result["risk"].(map[string]interface{})
+		assert.Equal(t, "deny", risk["decision"])
+		assert.Equal(t, 1.0, risk["score"])
+	})
+
+	t.Run("new devices are held for review", func(t *testing.T) {
+		result := verify(t, "Mozilla/5.0 (iPhone)")
+		assert.Equal(t, false, result["verified"])
+		assert.Equal(t, models.ReasonNeedsReview, result["reason"])
+		assert.Equal(t, "review", result["risk"].(map[string]interface{})["decision"])
+		assert.Equal(t, []interface{}{services.ReviewTriggerRisk}, result["review"].(map[string]interface{})["triggers"])
+
+		// Once seen, the device no longer raises the score
+		result = verify(t, "Mozilla/5.0 (iPhone)")
+		assert.Equal(t, true, result["verified"])
+		assert.Equal(t, "approve", result["risk"].(map[string]interface{})["decision"])
+	})
+}
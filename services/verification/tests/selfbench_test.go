@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestSelfBench(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newRouter := func(t *testing.T, cfg *config.Config) (*gin.Engine, *services.FaceVerificationService) {
+		cfg.LivenessThreshold = 0.85
+		cfg.SimilarityThreshold = 0.75
+		cfg.StoragePath = t.TempDir()
+		cfg.EncryptionKey = "test-encryption-key-for-testing-only"
+		cfg.AdminAPIKey = "test-admin-key"
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return router, service
+	}
+
+	selfBench := func(router *gin.Engine, body map[string]int) (*httptest.ResponseRecorder, map[string]interface{}) {
+		payload, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/admin/selfbench", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("small run reports sane percentiles", func(t *testing.T) {
+		router, service := newRouter(t, &config.Config{SelfBenchCooldown: 60})
+
+		w, response := selfBench(router, map[string]int{"requests": 12, "concurrency": 3})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(12), data["requests"])
+		assert.Equal(t, float64(3), data["concurrency"])
+		assert.Equal(t, float64(0), data["errors"])
+		assert.Equal(t, float64(0), data["error_rate"])
+		assert.Greater(t, data["throughput_rps"].(float64), 0.0)
+
+		minMs := data["latency_min_ms"].(float64)
+		p50 := data["latency_p50_ms"].(float64)
+		p95 := data["latency_p95_ms"].(float64)
+		p99 := data["latency_p99_ms"].(float64)
+		maxMs := data["latency_max_ms"].(float64)
+		assert.Greater(t, minMs, 0.0)
+		assert.LessOrEqual(t, minMs, p50)
+		assert.LessOrEqual(t, p50, p95)
+		assert.LessOrEqual(t, p95, p99)
+		assert.LessOrEqual(t, p99, maxMs)
+		assert.Less(t, maxMs, 1000*data["duration_seconds"].(float64)+1)
+
+		// Synthetic runs leave no trace in the result store
+		assert.Empty(t, service.FindVerifications(services.RegionFilter{}, 100))
+
+		// A second run inside the cooldown is refused
+		w, response = selfBench(router, map[string]int{"requests": 1, "concurrency": 1})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "SELFBENCH_RATE_LIMITED", response["code"])
+	})
+
+	t.Run("refused in production unless allowed", func(t *testing.T) {
+		router, _ := newRouter(t, &config.Config{Environment: "production"})
+
+		w, response := selfBench(router, map[string]int{"requests": 1, "concurrency": 1})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "SELFBENCH_FORBIDDEN", response["code"])
+
+		_, service := newRouter(t, &config.Config{Environment: "production", SelfBenchAllowProduction: true})
+		report, err := service.RunSelfBench(context.Background(), services.SelfBenchOptions{Requests: 2, Concurrency: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Requests)
+	})
+
+	t.Run("out of range parameters", func(t *testing.T) {
+		router, _ := newRouter(t, &config.Config{SelfBenchMaxRequests: 10})
+
+		w, _ := selfBench(router, map[string]int{"requests": 11, "concurrency": 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = selfBench(router, map[string]int{"requests": 5, "concurrency": 100})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
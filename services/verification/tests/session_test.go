@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestFaceVerificationService_AcquireSession(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	t.Run("enforced", func(t *testing.T) {
+		cfg := &config.Config{EnforceUniqueSessions: true, SessionLockTTL: 60}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		release, err := service.AcquireSession("session-lock-1")
+		require.NoError(t, err)
+
+		_, err = service.AcquireSession("session-lock-1")
+		assert.ErrorIs(t, err, services.ErrSessionInUse)
+
+		// Other sessions are unaffected
+		releaseOther, err := service.AcquireSession("session-lock-2")
+		require.NoError(t, err)
+		releaseOther()
+
+		release()
+		release, err = service.AcquireSession("session-lock-1")
+		assert.NoError(t, err)
+		release()
+	})
+
+	t.Run("not enforced", func(t *testing.T) {
+		cfg := &config.Config{}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		defer service.Close()
+
+		release, err := service.AcquireSession("session-lock-1")
+		require.NoError(t, err)
+		defer release()
+
+		_, err = service.AcquireSession("session-lock-1")
+		assert.NoError(t, err)
+	})
+}
+
+func TestVerificationHandler_ConcurrentSessionReuse(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:     0.85,
+		SimilarityThreshold:   0.75,
+		StoragePath:           t.TempDir(),
+		EncryptionKey:         "test-encryption-key-for-testing-only",
+		EnforceUniqueSessions: true,
+		SessionLockTTL:        60,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	const attempts = 2
+	codes := make([]string, attempts)
+	statuses := make([]int, attempts)
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":      createTestVideoFile(),
+			"session_id": "shared-session-id",
+		})
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/v1/verify", body)
+			c.Request.Header.Set("Content-Type", contentType)
+
+			<-start
+			handler.VerifyVideo(c)
+
+			statuses[i] = w.Code
+			var response map[string]interface{}
+			if json.Unmarshal(w.Body.Bytes(), &response) == nil {
+				codes[i], _ = response["code"].(string)
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	rejected := 0
+	for i := 0; i < attempts; i++ {
+		if statuses[i] == http.StatusConflict {
+			assert.Equal(t, "SESSION_IN_USE", codes[i])
+			rejected++
+		}
+	}
+	assert.Equal(t, 1, rejected, "exactly one concurrent request should be rejected")
+}
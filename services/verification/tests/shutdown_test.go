@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// gatedDecoder yields black frames once released, signalling when a
+// capture starts decoding.
+type gatedDecoder struct {
+	opened  chan struct{}
+	release chan struct{}
+}
+
+func (d *gatedDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	d.opened <- struct{}{}
+	<-d.release
+	black := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	return &sliceFrames{frames: []image.Image{black, black}}, nil
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	delivered := make(chan struct{})
+	receiverRelease := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-receiverRelease
+		w.WriteHeader(http.StatusOK)
+		close(delivered)
+	}))
+	defer receiver.Close()
+
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AsyncWorkers:        1,
+		AsyncQueueSize:      10,
+		WebhookURL:          receiver.URL,
+		// Black frames end the job as CAMERA_BLOCKED, whose result is sent
+		// to the webhook
+		CameraCheckEnabled:  true,
+		CameraMinBrightness: 0.1,
+		CameraMinVariance:   0.01,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+	decoder := &gatedDecoder{opened: make(chan struct{}, 1), release: make(chan struct{})}
+	service.SetFrameDecoder(decoder)
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	submitAsync := func() *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video": createTestVideoFile(),
+			"mode":  "async",
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	drainWithin := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return service.Drain(ctx)
+	}
+
+	w := submitAsync()
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	<-decoder.opened
+
+	service.BeginDrain()
+
+	t.Run("readiness fails while draining", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var readiness models.Readiness
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &readiness))
+		assert.False(t, readiness.Ready)
+		assert.True(t, readiness.Draining)
+	})
+
+	t.Run("new async jobs are refused", func(t *testing.T) {
+		w := submitAsync()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "SHUTTING_DOWN", errorCode(t, w))
+	})
+
+	t.Run("drain waits for the job and its webhook delivery", func(t *testing.T) {
+		assert.ErrorIs(t, drainWithin(20*time.Millisecond), context.DeadlineExceeded)
+
+		// The job finishes, but its result is still being delivered
+		close(decoder.release)
+		assert.ErrorIs(t, drainWithin(100*time.Millisecond), context.DeadlineExceeded)
+
+		close(receiverRelease)
+		require.NoError(t, drainWithin(5*time.Second))
+		<-delivered
+
+		deliveries, err := service.WebhookDeliveries(models.WebhookDelivered, "")
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+	})
+
+	t.Run("an idle service drains at once", func(t *testing.T) {
+		assert.NoError(t, drainWithin(time.Millisecond))
+	})
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// TestPrecomputedNormsMatchCosine checks that matching against templates
+// normalized at load time scores what a cosine over the stored vectors
+// does, whatever their scale or dimension.
+func TestPrecomputedNormsMatchCosine(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	const users, dim = 200, 131 // not a multiple of the dot product's lanes
+
+	store := &memoryVectorStore{vectors: make(map[string][]models.FaceVector)}
+	enrolled := make(map[string][][]float32, users)
+	created := time.Now().Add(-time.Hour)
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("norm-user-%d", i)
+		for j := 0; j < 2; j++ {
+			vector := randomVector(rng, dim)
+			// Stored descriptors need not be unit length
+			scale := float32(0.1 + rng.Float64()*10)
+			for k := range vector {
+				vector[k] *= scale
+			}
+			enrolled[userID] = append(enrolled[userID], vector)
+			require.NoError(t, store.Save(models.FaceVector{
+				UserID:    userID,
+				Vector:    vector,
+				CreatedAt: created,
+				Version:   "1.0",
+			}))
+		}
+	}
+
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	})
+	require.NoError(t, err)
+	defer service.Close()
+	require.NoError(t, service.SetVectorStore(store))
+
+	t.Run("1:1 match", func(t *testing.T) {
+		userID := "norm-user-3"
+		query := noisyCopy(rng, enrolled[userID][1])
+
+		similarity, _, err := service.MatchTemplate(userID, query)
+		require.NoError(t, err)
+		want := math.Max(cosine(query, enrolled[userID][0]), cosine(query, enrolled[userID][1]))
+		assert.InDelta(t, want, similarity, 1e-6)
+	})
+
+	t.Run("1:N scan", func(t *testing.T) {
+		query := noisyCopy(rng, enrolled["norm-user-42"][0])
+		matches := service.SearchGallery(query, 3)
+		require.Len(t, matches, 3)
+		assert.Equal(t, "norm-user-42", matches[0].UserID)
+		for _, match := range matches {
+			want := 0.0
+			for _, vector := range enrolled[match.UserID] {
+				want = math.Max(want, cosine(query, vector))
+			}
+			assert.InDelta(t, want, match.Similarity, 1e-6, match.UserID)
+		}
+	})
+
+	t.Run("zero and mismatched vectors score 0", func(t *testing.T) {
+		similarity, _, err := service.MatchTemplate("norm-user-0", make([]float32, dim))
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, similarity)
+
+		similarity, _, err = service.MatchTemplate("norm-user-0", randomVector(rng, dim-1))
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, similarity)
+	})
+}
+
+// BenchmarkGalleryScan measures an exact 1:N search over 20,000 templates.
+func BenchmarkGalleryScan(b *testing.B) {
+	rng := rand.New(rand.NewSource(7))
+	const users, dim = 20000, 128
+
+	store := &memoryVectorStore{vectors: make(map[string][]models.FaceVector)}
+	created := time.Now().Add(-time.Hour)
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("scan-user-%d", i)
+		if err := store.Save(models.FaceVector{UserID: userID, Vector: randomVector(rng, dim), CreatedAt: created, Version: "1.0"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(b), &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         b.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer service.Close()
+	if err := service.SetVectorStore(store); err != nil {
+		b.Fatal(err)
+	}
+	query := randomVector(rng, dim)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.SearchGallery(query, services.DefaultIdentifyResults)
+	}
+}
@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestTemplateFusion(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, fusion string, maxTemplates int) (*services.FaceVerificationService, *config.Config) {
+		cfg := &config.Config{
+			LivenessThreshold:      0.5,
+			SimilarityThreshold:    0.75,
+			StoragePath:            t.TempDir(),
+			EncryptionKey:          "test-encryption-key-for-testing-only",
+			EnrollmentFusion:       fusion,
+			EnrollmentMaxTemplates: maxTemplates,
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service, cfg
+	}
+
+	// Every test capture shows the same face
+	captures := func(n int) []models.VideoSource {
+		videos := make([]models.VideoSource, n)
+		for i := range videos {
+			videos[i] = services.BytesVideo(createTestVideoFile().data)
+		}
+		return videos
+	}
+
+	t.Run("several videos are enrolled in one call", func(t *testing.T) {
+		service, cfg := newService(t, services.FusionAll, 0)
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   []*fileData{createTestVideoFile(), createTestVideoFile(), createTestVideoFile()},
+			"user_id": "multi-angle-user",
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/register", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var registered map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &registered))
+		assert.Equal(t, float64(3), registered["templates"])
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/faces/multi-angle-user", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var templates struct {
+			Data struct {
+				Templates int `json:"templates"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &templates))
+		assert.Equal(t, 3, templates.Data.Templates)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/faces/unknown-user", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "USER_NOT_ENROLLED", errorCode(t, w))
+	})
+
+	t.Run("too many videos are refused", func(t *testing.T) {
+		service, cfg := newService(t, services.FusionAll, 0)
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+		videos := make([]*fileData, services.MaxEnrollmentCaptures+1)
+		for i := range videos {
+			videos[i] = createTestVideoFile()
+		}
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   videos,
+			"user_id": "greedy-user",
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/register", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, service.TemplateCount("greedy-user"))
+	})
+
+	t.Run("average fuses every enrollment into one template", func(t *testing.T) {
+		service, _ := newService(t, services.FusionAverage, 0)
+
+		registration, err := service.RegisterFaceVideos(context.Background(), "averaged-user", captures(3), services.RegistrationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, registration.Templates)
+
+		// Sequential enrollments are folded into the same template
+		registration, err = service.RegisterFaceVideos(context.Background(), "averaged-user", captures(1), services.RegistrationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, registration.Templates)
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoFile().data,
+			UserID:    "averaged-user",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+	})
+
+	t.Run("diverse keeps at most ENROLLMENT_MAX_TEMPLATES", func(t *testing.T) {
+		service, _ := newService(t, services.FusionDiverse, 2)
+
+		registration, err := service.RegisterFaceVideos(context.Background(), "diverse-user", captures(3), services.RegistrationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, registration.Templates)
+		assert.Equal(t, 2, service.TemplateCount("diverse-user"))
+	})
+
+	t.Run("unknown strategies are rejected", func(t *testing.T) {
+		_, err := services.NewFaceVerificationService(logger, &config.Config{
+			StoragePath:      t.TempDir(),
+			EncryptionKey:    "test-encryption-key-for-testing-only",
+			EnrollmentFusion: "median",
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestTemplateRefresh(t *testing.T) {
+	const key = "test-encryption-key-for-testing-only"
+	logger := zaptest.NewLogger(t)
+	dir := t.TempDir()
+	videoData := createTestVideoFile().data
+
+	newService := func(t *testing.T, refresh bool) *services.FaceVerificationService {
+		service, err := services.NewFaceVerificationService(logger, &config.Config{
+			LivenessThreshold:      0.5,
+			SimilarityThreshold:    0.75,
+			StoragePath:            dir,
+			EncryptionKey:          key,
+			AuditLogEnabled:        true,
+			TemplateRefreshEnabled: refresh,
+			TemplateMaxAge:         365,
+		})
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	// Enroll, then backdate the template past TEMPLATE_MAX_AGE
+	enrolling := newService(t, false)
+	require.NoError(t, enrolling.RegisterFace("aging-user", videoData))
+	enrolling.Close()
+
+	store, err := storage.NewEncryptedFileVectorStore(dir, key, time.Second)
+	require.NoError(t, err)
+	templates, err := store.Load("aging-user")
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	aged := templates[0]
+	aged.CreatedAt = time.Now().AddDate(-2, 0, 0)
+	require.NoError(t, store.Delete("aging-user"))
+	require.NoError(t, store.Save(aged))
+
+	service := newService(t, true)
+	verify := func(t *testing.T) {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: videoData,
+			UserID:    "aging-user",
+		})
+		require.NoError(t, err)
+		require.True(t, result.Verified)
+	}
+	refreshes := func(t *testing.T) []models.AuditEvent {
+		events, err := service.QueryAudit(storage.AuditQuery{Operation: models.AuditTemplateRefresh, Limit: 10})
+		require.NoError(t, err)
+		return events
+	}
+
+	t.Run("a verified capture replaces an aged template", func(t *testing.T) {
+		verify(t)
+
+		// Refreshes run in the background
+		require.Eventually(t, func() bool { return len(refreshes(t)) > 0 }, 5*time.Second, 20*time.Millisecond)
+
+		templates, err := store.Load("aging-user")
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		assert.Equal(t, models.TemplateSourceRefresh, templates[0].Source)
+		assert.WithinDuration(t, time.Now(), templates[0].CreatedAt, time.Minute)
+
+		events := refreshes(t)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.AuditSuccess, events[0].Result)
+		assert.Equal(t, "aging-user", events[0].UserID)
+	})
+
+	t.Run("fresh templates are left alone", func(t *testing.T) {
+		// The refresh is decided before the verification returns
+		verify(t)
+		assert.Len(t, refreshes(t), 1)
+		assert.Equal(t, 1, service.TemplateCount("aging-user"))
+	})
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestTemplateEncoding(t *testing.T) {
+	vector := createTestDescriptor(128)
+
+	t.Run("float32 round trip is exact", func(t *testing.T) {
+		encoded := services.EncodeTemplate(vector, false)
+
+		assert.Equal(t, byte(services.TemplateVersion), encoded[0])
+		assert.Len(t, encoded, 4+128*4)
+
+		decoded, err := services.DecodeTemplate(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, vector, decoded)
+	})
+
+	t.Run("quantized round trip is close and ~4x smaller", func(t *testing.T) {
+		encoded := services.EncodeTemplate(vector, true)
+
+		assert.Len(t, encoded, 8+128)
+		assert.Less(t, len(encoded)*3, len(services.EncodeTemplate(vector, false)))
+
+		decoded, err := services.DecodeTemplate(encoded)
+		require.NoError(t, err)
+		require.Len(t, decoded, len(vector))
+		for i := range vector {
+			assert.InDelta(t, vector[i], decoded[i], 0.01)
+		}
+	})
+
+	t.Run("rejects corrupt templates", func(t *testing.T) {
+		encoded := services.EncodeTemplate(vector, true)
+
+		_, err := services.DecodeTemplate(encoded[:len(encoded)-1])
+		assert.ErrorIs(t, err, services.ErrInvalidTemplate)
+
+		badVersion := append([]byte{}, encoded...)
+		badVersion[0] = 99
+		_, err = services.DecodeTemplate(badVersion)
+		assert.ErrorIs(t, err, services.ErrInvalidTemplate)
+
+		_, err = services.DecodeTemplate([]byte{1})
+		assert.ErrorIs(t, err, services.ErrInvalidTemplate)
+	})
+}
+
+func TestVerificationHandler_TemplateRoundTrip(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:    0.5,
+		SimilarityThreshold:  0.75,
+		StoragePath:          t.TempDir(),
+		EncryptionKey:        "test-encryption-key-for-testing-only",
+		TemplateQuantization: true,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	require.NoError(t, service.RegisterFace("template-user", createTestVideoFile().data))
+
+	handler := handlers.NewVerificationHandler(service, logger)
+
+	// Extract a compact template from a fresh capture
+	body, contentType, err := createMultipartForm(map[string]interface{}{
+		"video": createTestVideoFile(),
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/template", body)
+	c.Request.Header.Set("Content-Type", contentType)
+	handler.ExtractTemplate(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var extracted map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &extracted))
+	template := extracted["template"].(string)
+
+	raw, err := base64.StdEncoding.DecodeString(template)
+	require.NoError(t, err)
+	assert.Len(t, raw, 8+128)
+
+	match := func(userID, template string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		payload, err := json.Marshal(map[string]string{"user_id": userID, "template": template})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/match", bytes.NewReader(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		handler.MatchTemplate(c)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("compact template matches the enrollment", func(t *testing.T) {
+		w, response := match("template-user", template)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, response["matched"].(bool))
+		assert.Greater(t, response["confidence"].(float64), 0.75)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		w, response := match("template-nobody", template)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "USER_NOT_ENROLLED", response["code"])
+	})
+
+	t.Run("malformed template", func(t *testing.T) {
+		w, response := match("template-user", base64.StdEncoding.EncodeToString([]byte{1, 1, 0}))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_TEMPLATE", response["code"])
+	})
+}
+
+func createTestDescriptor(size int) []float32 {
+	vector := make([]float32, size)
+	for i := range vector {
+		vector[i] = float32(i%17)/17.0 - 0.5
+	}
+	return vector
+}
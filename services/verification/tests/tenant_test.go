@@ -0,0 +1,389 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/tenant"
+)
+
+func TestTenantRegistry(t *testing.T) {
+	registry, err := tenant.NewRegistry(tenant.Config{
+		APIKeys:              "acme:acme-key-1, acme:acme-key-2, beta:beta-key",
+		SimilarityThresholds: "acme:0.9",
+		LivenessThresholds:   "beta:0.6",
+		RateLimits:           "acme:120",
+	})
+	require.NoError(t, err)
+	assert.True(t, registry.Enabled())
+
+	t.Run("API keys resolve to their tenant", func(t *testing.T) {
+		for key, want := range map[string]string{"acme-key-1": "acme", "acme-key-2": "acme", "beta-key": "beta"} {
+			tenantID, ok := registry.Resolve(key)
+			assert.True(t, ok, key)
+			assert.Equal(t, want, tenantID, key)
+		}
+		_, ok := registry.Resolve("unknown-key")
+		assert.False(t, ok)
+		_, ok = registry.Resolve("")
+		assert.False(t, ok)
+	})
+
+	t.Run("settings override the global ones per tenant", func(t *testing.T) {
+		assert.Equal(t, 0.9, registry.SimilarityThreshold("acme", 0.75))
+		assert.Equal(t, 0.75, registry.SimilarityThreshold("beta", 0.75))
+		assert.Equal(t, 0.6, registry.LivenessThreshold("beta", 0.85))
+		assert.Equal(t, 0.85, registry.LivenessThreshold("acme", 0.85))
+		assert.Equal(t, 120, registry.RateLimit("acme"))
+		assert.Zero(t, registry.RateLimit("beta"))
+		assert.True(t, registry.Known("beta"))
+		assert.True(t, registry.Known(tenant.Default))
+		assert.False(t, registry.Known("gamma"))
+	})
+
+	t.Run("no API keys disables tenancy", func(t *testing.T) {
+		disabled, err := tenant.NewRegistry(tenant.Config{})
+		require.NoError(t, err)
+		assert.False(t, disabled.Enabled())
+		assert.Equal(t, 0.75, disabled.SimilarityThreshold("acme", 0.75))
+		assert.True(t, disabled.Known(tenant.Default))
+		assert.False(t, disabled.Known("acme"))
+	})
+
+	t.Run("invalid configuration is rejected", func(t *testing.T) {
+		for _, cfg := range []tenant.Config{
+			{APIKeys: "acme"},
+			{APIKeys: "ac/me:key"},
+			{APIKeys: "acme:key,beta:key"},
+			{APIKeys: "acme:key", SimilarityThresholds: "beta:0.9"},
+			{APIKeys: "acme:key", LivenessThresholds: "acme:1.5"},
+			{APIKeys: "acme:key", RateLimits: "acme:0"},
+		} {
+			_, err := tenant.NewRegistry(cfg)
+			assert.Error(t, err, "%+v", cfg)
+		}
+	})
+
+	t.Run("user keys round-trip", func(t *testing.T) {
+		assert.Equal(t, "alice", tenant.UserKey(tenant.Default, "alice"))
+		assert.Equal(t, "acme/alice", tenant.UserKey("acme", "alice"))
+		tenantID, userID := tenant.SplitUserKey("acme/alice")
+		assert.Equal(t, "acme", tenantID)
+		assert.Equal(t, "alice", userID)
+		tenantID, userID = tenant.SplitUserKey("alice")
+		assert.Equal(t, tenant.Default, tenantID)
+		assert.Equal(t, "alice", userID)
+	})
+}
+
+func TestTenantGalleryIsolation(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	rng := rand.New(rand.NewSource(7))
+
+	// Every tenant enrolls an "alice" with the same face
+	face := randomVector(rng, 128)
+	store := &memoryVectorStore{vectors: make(map[string][]models.FaceVector)}
+	created := time.Now().Add(-time.Hour)
+	for _, userKey := range []string{"alice", "acme/alice", "beta/alice", "acme/bob"} {
+		require.NoError(t, store.Save(models.FaceVector{
+			UserID:    userKey,
+			Vector:    face,
+			CreatedAt: created,
+			Version:   "1.0",
+		}))
+	}
+
+	for _, annEnabled := range []bool{false, true} {
+		service, err := services.NewFaceVerificationService(logger, &config.Config{
+			LivenessThreshold:          0.5,
+			SimilarityThreshold:        0.75,
+			StoragePath:                t.TempDir(),
+			EncryptionKey:              "test-encryption-key-for-testing-only",
+			AnnIndexEnabled:            annEnabled,
+			TenantAPIKeys:              "acme:acme-key,beta:beta-key",
+			TenantSimilarityThresholds: "beta:0.99",
+		})
+		require.NoError(t, err)
+		require.NoError(t, service.SetVectorStore(store))
+
+		matches := service.SearchTenantGallery("acme", face, 10)
+		require.Len(t, matches, 2, "ann=%v", annEnabled)
+		for _, match := range matches {
+			assert.Contains(t, []string{"alice", "bob"}, match.UserID)
+		}
+
+		matches = service.SearchTenantGallery("beta", face, 10)
+		require.Len(t, matches, 1, "ann=%v", annEnabled)
+		assert.Equal(t, "alice", matches[0].UserID)
+
+		matches = service.SearchGallery(face, 10)
+		require.Len(t, matches, 1, "ann=%v", annEnabled)
+		assert.Equal(t, "alice", matches[0].UserID)
+
+		assert.Empty(t, service.SearchTenantGallery("gamma", face, 10))
+
+		// bob exists only in acme's gallery
+		_, _, err = service.MatchTemplate(tenant.UserKey("beta", "bob"), face)
+		assert.ErrorIs(t, err, services.ErrNotEnrolled)
+		_, matched, err := service.MatchTemplate(tenant.UserKey("acme", "bob"), face)
+		require.NoError(t, err)
+		assert.True(t, matched)
+
+		// Another capture of alice matches at about 0.89: enough for the
+		// global threshold acme uses, not for beta's own
+		probe := make([]float32, len(face))
+		for i := range face {
+			probe[i] = face[i] + float32(rng.NormFloat64()*0.5)
+		}
+		_, matched, err = service.MatchTemplate(tenant.UserKey("acme", "alice"), probe)
+		require.NoError(t, err)
+		assert.True(t, matched)
+		_, matched, err = service.MatchTemplate(tenant.UserKey("beta", "alice"), probe)
+		require.NoError(t, err)
+		assert.False(t, matched)
+
+		assert.Equal(t, 1, service.TemplateCount(tenant.UserKey("acme", "bob")))
+		assert.Zero(t, service.TemplateCount("bob"))
+
+		service.Close()
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	registry, err := tenant.NewRegistry(tenant.Config{APIKeys: "acme:acme-key,beta:beta-key"})
+	require.NoError(t, err)
+
+	newRouter := func(registry *tenant.Registry) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.Tenant(registry, "admin-key"))
+		router.GET("/whoami", func(c *gin.Context) {
+			c.String(http.StatusOK, "tenant=%s", middleware.TenantOf(c))
+		})
+		return router
+	}
+
+	request := func(router *gin.Engine, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/whoami", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	router := newRouter(registry)
+
+	t.Run("API key selects the tenant", func(t *testing.T) {
+		w := request(router, map[string]string{"X-API-Key": "beta-key"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "tenant=beta", w.Body.String())
+
+		// A tenant caller cannot switch tenants
+		w = request(router, map[string]string{"X-API-Key": "beta-key", "X-Tenant-ID": "acme"})
+		assert.Equal(t, "tenant=beta", w.Body.String())
+	})
+
+	t.Run("callers without a tenant key are rejected", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			{},
+			{"X-API-Key": "unknown-key"},
+			{"X-Tenant-ID": "acme"},
+			{"X-Admin-Key": "wrong", "X-Tenant-ID": "acme"},
+		} {
+			w := request(router, headers)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "%v", headers)
+			assert.Contains(t, w.Body.String(), "TENANT_REQUIRED")
+		}
+	})
+
+	t.Run("admins choose the tenant", func(t *testing.T) {
+		w := request(router, map[string]string{"X-Admin-Key": "admin-key", "X-Tenant-ID": "acme"})
+		assert.Equal(t, "tenant=acme", w.Body.String())
+
+		w = request(router, map[string]string{"X-Admin-Key": "admin-key"})
+		assert.Equal(t, "tenant=", w.Body.String())
+
+		w = request(router, map[string]string{"X-Admin-Key": "admin-key", "X-Tenant-ID": "gamma"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "UNKNOWN_TENANT")
+	})
+
+	t.Run("without tenancy every caller is in the default tenant", func(t *testing.T) {
+		w := request(newRouter(nil), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "tenant=", w.Body.String())
+	})
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	registry, err := tenant.NewRegistry(tenant.Config{
+		APIKeys:    "acme:acme-key-1,acme:acme-key-2,beta:beta-key",
+		RateLimits: "acme:2",
+	})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware.RateLimit(middleware.RateLimitConfig{
+		RequestsPerMinute: 60,
+		Burst:             5,
+		Tenants:           registry,
+	}))
+	router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/ping", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// acme's keys share one bucket of 2
+	w := request("acme-key-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusOK, request("acme-key-2").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("acme-key-1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("acme-key-2").Code)
+
+	// beta has no limit of its own and keeps the per-key default
+	w = request("beta-key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "60", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestTenantConfigOverrides(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, configPath string) (*services.FaceVerificationService, *gin.Engine) {
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			MaxUploadSize:       4 * 1024 * 1024,
+			AdminAPIKey:         "admin-key",
+			TenantAPIKeys:       "acme:acme-key,beta:beta-key",
+			TenantConfigPath:    configPath,
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return service, router
+	}
+
+	call := func(router *gin.Engine, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "admin-key")
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	configPath := filepath.Join(t.TempDir(), "tenant_config.json")
+	service, router := newService(t, configPath)
+
+	t.Run("tenants start on the global settings", func(t *testing.T) {
+		w, response := call(router, "GET", "/api/v1/admin/tenants/acme/config", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Nil(t, response["overrides"])
+		effective := response["effective"].(map[string]interface{})
+		assert.Equal(t, 0.5, effective["liveness_threshold"])
+		assert.Equal(t, 0.75, effective["similarity_threshold"])
+		assert.EqualValues(t, 4*1024*1024, effective["max_upload_size"])
+	})
+
+	t.Run("overrides apply to their tenant only", func(t *testing.T) {
+		w, response := call(router, "PUT", "/api/v1/admin/tenants/acme/config",
+			`{"similarity_threshold": 0.9, "max_upload_size": 1024, "liveness_detectors": ["Motion", "texture"]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		overrides := response["overrides"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"motion", "texture"}, overrides["liveness_detectors"])
+		assert.NotEmpty(t, overrides["updated_at"])
+
+		acme := service.TenantSettings("acme")
+		assert.Equal(t, 0.9, acme.SimilarityThreshold)
+		assert.Equal(t, 0.5, acme.LivenessThreshold)
+		assert.EqualValues(t, 1024, acme.MaxUploadSize)
+		assert.Equal(t, []string{"motion", "texture"}, acme.LivenessDetectors)
+
+		beta := service.TenantSettings("beta")
+		assert.Equal(t, 0.75, beta.SimilarityThreshold)
+		assert.EqualValues(t, 4*1024*1024, beta.MaxUploadSize)
+		assert.Contains(t, beta.LivenessDetectors, "color")
+	})
+
+	t.Run("uploads are limited per tenant", func(t *testing.T) {
+		upload := func(apiKey string) *httptest.ResponseRecorder {
+			video := createTestVideoFile()
+			video.data = make([]byte, 2*1024*1024)
+			body, contentType, err := createMultipartForm(map[string]interface{}{"video": video})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/verify", body)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("X-API-Key", apiKey)
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := upload("acme-key")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		assert.NotEqual(t, http.StatusRequestEntityTooLarge, upload("beta-key").Code)
+	})
+
+	t.Run("overrides survive a restart", func(t *testing.T) {
+		restarted, _ := newService(t, configPath)
+		assert.Equal(t, 0.9, restarted.TenantSettings("acme").SimilarityThreshold)
+	})
+
+	t.Run("invalid overrides are rejected", func(t *testing.T) {
+		for _, body := range []string{
+			`{"similarity_threshold": 1.5}`,
+			`{"liveness_threshold": -0.1}`,
+			`{"max_upload_size": 0}`,
+			`{"liveness_detectors": ["unknown"]}`,
+		} {
+			w, response := call(router, "PUT", "/api/v1/admin/tenants/acme/config", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+			assert.Equal(t, "INVALID_TENANT_CONFIG", response["code"], body)
+		}
+		assert.Equal(t, 0.9, service.TenantSettings("acme").SimilarityThreshold)
+
+		w, response := call(router, "GET", "/api/v1/admin/tenants/gamma/config", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "UNKNOWN_TENANT", response["code"])
+	})
+
+	t.Run("deleting the overrides restores the global settings", func(t *testing.T) {
+		w, response := call(router, "DELETE", "/api/v1/admin/tenants/acme/config", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Nil(t, response["overrides"])
+		assert.Equal(t, 0.75, service.TenantSettings("acme").SimilarityThreshold)
+	})
+}
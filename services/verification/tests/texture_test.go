@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// noiseFrame is a grey frame of uniform noise in [0, amplitude).
+func noiseFrame(rng *rand.Rand, amplitude int) *image.Gray {
+	frame := image.NewGray(image.Rect(0, 0, 320, 240))
+	for i := range frame.Pix {
+		if amplitude > 0 {
+			frame.Pix[i] = uint8(rng.Intn(amplitude))
+		}
+	}
+	return frame
+}
+
+// neighborTexture is the texture score as computed before summed-area
+// tables: squared RGB differences to the eight neighbours of every other
+// pixel, which for a grey frame is three times the squared difference.
+func neighborTexture(frame *image.Gray) float64 {
+	bounds := frame.Bounds()
+	total, count := 0, 0
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y += 2 {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x += 2 {
+			center := int(frame.GrayAt(x, y).Y)
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					d := center - int(frame.GrayAt(x+dx, y+dy).Y)
+					total += 3 * d * d
+				}
+			}
+			count++
+		}
+	}
+	return float64(total) / 8 * 257 * 257 / float64(count) / 1e10
+}
+
+// TestTextureConsistencyIntegralImages checks that the texture detector,
+// which works from summed-area tables, scores grey frames exactly as the
+// per-neighbour differences did.
+func TestTextureConsistencyIntegralImages(t *testing.T) {
+	service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		LivenessDetectors:   "texture:1",
+	})
+	require.NoError(t, err)
+	defer service.Close()
+
+	rng := rand.New(rand.NewSource(3))
+	grey := []*image.Gray{noiseFrame(rng, 0), noiseFrame(rng, 128), noiseFrame(rng, 96)}
+	// A flat square in an otherwise noisy frame
+	patched := noiseFrame(rng, 128)
+	for y := 60; y < 180; y++ {
+		for x := 80; x < 240; x++ {
+			patched.SetGray(x, y, color.Gray{Y: 40})
+		}
+	}
+	grey = append(grey, patched)
+
+	frames := make([]image.Image, len(grey))
+	scores := make([]float64, len(grey))
+	mean := 0.0
+	for i, frame := range grey {
+		frames[i] = frame
+		scores[i] = neighborTexture(frame)
+		mean += scores[i] / float64(len(grey))
+	}
+	variance := 0.0
+	for _, score := range scores {
+		variance += (score - mean) * (score - mean) / float64(len(scores))
+	}
+	expected := 1 - math.Min(variance*100, 1)
+	require.Greater(t, expected, 0.0)
+	require.Less(t, expected, 1.0)
+
+	service.SetFrameDecoder(&sliceDecoder{frames: frames})
+	result, err := service.VerifyVideo(&models.VerificationRequest{
+		VideoData: createTestVideoData(),
+		SessionID: "texture-session",
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, expected, result.LivenessScore, 1e-9)
+}
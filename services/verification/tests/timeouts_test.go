@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestPipelineTimeouts(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:        0.5,
+		SimilarityThreshold:      0.75,
+		StoragePath:              t.TempDir(),
+		EncryptionKey:            "test-encryption-key-for-testing-only",
+		FrameDecodeBudgetMs:      10000,
+		FrameExtractionTimeoutMs: 100,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	t.Run("frame extraction deadline comes from config", func(t *testing.T) {
+		service.SetFrameDecoder(&slowDecoder{frames: 5, slowFrame: 1, delay: 2 * time.Second})
+
+		start := time.Now()
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "extraction-timeout-session",
+		})
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, "Frame extraction timeout", result.Error)
+	})
+
+	t.Run("canceling the context stops the pipeline", func(t *testing.T) {
+		cfg.FrameExtractionTimeoutMs = 5000
+		defer func() { cfg.FrameExtractionTimeoutMs = 100 }()
+		decoder := &slowDecoder{frames: 5, slowFrame: 1, delay: 2 * time.Second}
+		service.SetFrameDecoder(decoder)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		result, err := service.VerifyVideoContext(ctx, &models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "canceled-session",
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, "Verification canceled", result.Error)
+
+		// Decoding is abandoned rather than left running
+		assert.Eventually(t, decoder.closed.Load, time.Second, 10*time.Millisecond)
+
+		record, ok := service.GetVerificationRecord(result.VerificationID)
+		require.True(t, ok)
+		assert.Equal(t, models.StatusFailed, record.Status)
+	})
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/tlsconfig"
+)
+
+// writeCertificate writes a self-signed certificate for localhost with the
+// given serial number, and its key, as PEM files into dir.
+func writeCertificate(t *testing.T, dir string, serial int64) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// serveTLS serves handler over HTTPS with tlsConfig as is, returning the
+// server's URL; httptest's StartTLS would add a certificate of its own.
+func serveTLS(t *testing.T, handler http.Handler, tlsConfig *tls.Config) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(listener) //nolint:errcheck
+	t.Cleanup(func() { srv.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, 1)
+
+	tlsConfig, certs, err := tlsconfig.New(&config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile}, logger)
+	require.NoError(t, err)
+	require.NotNil(t, certs)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+
+	serverURL := serveTLS(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), tlsConfig)
+
+	// The serial number of the certificate the server presents
+	servedSerial := func(t *testing.T) int64 {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		}}
+		resp, err := client.Get(serverURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	t.Run("serves the configured certificate", func(t *testing.T) {
+		assert.Equal(t, int64(1), servedSerial(t))
+	})
+
+	t.Run("reload picks up a renewed certificate", func(t *testing.T) {
+		writeCertificate(t, dir, 2)
+		require.NoError(t, certs.Reload())
+		assert.Equal(t, int64(2), servedSerial(t))
+	})
+
+	t.Run("a broken pair keeps the previous certificate", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+		assert.Error(t, certs.Reload())
+		assert.Equal(t, int64(2), servedSerial(t))
+	})
+
+	t.Run("watching picks up renewals without a reload", func(t *testing.T) {
+		stop := make(chan struct{})
+		defer close(stop)
+		require.NoError(t, certs.Watch(stop))
+
+		writeCertificate(t, dir, 3)
+		assert.Eventually(t, func() bool {
+			return certs.Certificate().SerialNumber.Int64() == 3
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(3), servedSerial(t))
+	})
+
+	t.Run("an unreadable pair fails at startup", func(t *testing.T) {
+		_, _, err := tlsconfig.New(&config.Config{TLSCertFile: filepath.Join(dir, "missing.crt"), TLSKeyFile: keyFile}, logger)
+		assert.Error(t, err)
+	})
+
+	t.Run("plain HTTP without a certificate", func(t *testing.T) {
+		tlsConfig, certs, err := tlsconfig.New(&config.Config{}, logger)
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+		assert.Nil(t, certs)
+	})
+}
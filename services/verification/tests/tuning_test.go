@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/middleware"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestTuningReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	dir := t.TempDir()
+	t.Setenv("FACE_MODEL_PATH", dir)
+	t.Setenv("STORAGE_PATH", dir)
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key-for-testing-only")
+	t.Setenv("ADMIN_API_KEY", "admin-key")
+	configFile := filepath.Join(dir, "config.yaml")
+	t.Setenv(config.ConfigFileEnv, configFile)
+	writeConfig := func(t *testing.T, content string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+	}
+
+	writeConfig(t, "liveness_threshold: 0.5\nsimilarity_threshold: 0.7\nrate_limit_per_minute: 60\nrate_limit_burst: 2\n")
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	limited := gin.New()
+	limited.Use(middleware.RateLimit(middleware.RateLimitConfig{
+		RequestsPerMinute: cfg.RateLimitPerMinute,
+		Burst:             cfg.RateLimitBurst,
+		Limits:            service.RateLimits,
+	}))
+	limited.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	ping := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+		return w
+	}
+
+	admin := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Admin-Key", "admin-key")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	tuningOf := func(t *testing.T, w *httptest.ResponseRecorder) models.Tuning {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var tuned models.Tuning
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tuned))
+		return tuned
+	}
+
+	t.Run("reports the startup settings", func(t *testing.T) {
+		tuned := tuningOf(t, admin("GET", "/api/v1/admin/config"))
+		assert.Equal(t, 0.5, tuned.LivenessThreshold)
+		assert.Equal(t, 0.7, tuned.SimilarityThreshold)
+		assert.Equal(t, 60, tuned.RateLimitPerMinute)
+		assert.Equal(t, 2, tuned.RateLimitBurst)
+		assert.NotEmpty(t, tuned.LivenessWeights)
+		assert.Nil(t, tuned.ReloadedAt)
+	})
+
+	t.Run("reload applies thresholds, weights and rate limits", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, ping().Code)
+		assert.Equal(t, http.StatusOK, ping().Code)
+		w := ping()
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("X-RateLimit-Limit"))
+
+		writeConfig(t, "liveness_threshold: 0.6\nsimilarity_threshold: 0.8\nliveness_detectors: motion:1,texture:3\nrate_limit_per_minute: 6000\nrate_limit_burst: 10\n")
+		tuned, err := service.ReloadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 0.6, tuned.LivenessThreshold)
+		assert.Equal(t, 0.8, tuned.SimilarityThreshold)
+		assert.Equal(t, map[string]float64{"motion": 1, "texture": 3}, tuned.LivenessWeights)
+		assert.Equal(t, 6000, tuned.RateLimitPerMinute)
+		assert.NotNil(t, tuned.ReloadedAt)
+
+		// The existing bucket refills at the new rate, 100 tokens a second
+		assert.Equal(t, "6000", ping().Header().Get("X-RateLimit-Limit"))
+		assert.Eventually(t, func() bool { return ping().Code == http.StatusOK }, time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, 0.8, service.TenantSettings("").SimilarityThreshold)
+		assert.Equal(t, []string{"motion", "texture"}, service.TenantSettings("").LivenessDetectors)
+	})
+
+	t.Run("an invalid configuration changes nothing", func(t *testing.T) {
+		writeConfig(t, "liveness_threshold: 1.5\nsimilarity_threshold: 0.9\nrate_limit_burst: 10\n")
+		w := admin("POST", "/api/v1/admin/config/reload")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "INVALID_CONFIG", errorCode(t, w))
+		assert.Contains(t, w.Body.String(), "LIVENESS_THRESHOLD must be between 0 and 1")
+
+		writeConfig(t, "liveness_detectors: motion:1,unknown:1\nrate_limit_burst: 10\n")
+		w = admin("POST", "/api/v1/admin/config/reload")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		tuned := tuningOf(t, admin("GET", "/api/v1/admin/config"))
+		assert.Equal(t, 0.6, tuned.LivenessThreshold)
+		assert.Equal(t, 0.8, tuned.SimilarityThreshold)
+	})
+
+	t.Run("reload over the endpoint", func(t *testing.T) {
+		writeConfig(t, "liveness_threshold: 0.7\nrate_limit_burst: 10\n")
+		tuned := tuningOf(t, admin("POST", "/api/v1/admin/config/reload"))
+		assert.Equal(t, 0.7, tuned.LivenessThreshold)
+		assert.Equal(t, 0.75, tuned.SimilarityThreshold, "unset settings fall back to their defaults")
+	})
+
+	t.Run("requires the admin key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/config/reload", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestStreamedUpload(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newRouter := func(t *testing.T, cfg *config.Config) *gin.Engine {
+		cfg.LivenessThreshold = 0.5
+		cfg.SimilarityThreshold = 0.75
+		cfg.StoragePath = t.TempDir()
+		cfg.EncryptionKey = "test-encryption-key-for-testing-only"
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return router
+	}
+
+	upload := func(t *testing.T, router *gin.Engine, video *fileData) *httptest.ResponseRecorder {
+		body, contentType, err := createMultipartForm(map[string]interface{}{"video": video})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("captures within the limit are verified", func(t *testing.T) {
+		router := newRouter(t, &config.Config{MaxUploadSize: 4 * 1024 * 1024})
+		w := upload(t, router, createTestVideoFile())
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("oversized bodies are rejected while streaming", func(t *testing.T) {
+		router := newRouter(t, &config.Config{MaxUploadSize: 1024})
+		video := createTestVideoFile()
+		video.data = make([]byte, 4*1024*1024)
+		w := upload(t, router, video)
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "UPLOAD_TOO_LARGE", response["code"])
+	})
+}
+
+func TestVideoSources(t *testing.T) {
+	data := createTestVideoData()
+	inMemory := services.BytesVideo(data)
+
+	streamed, err := services.NewStreamedVideo(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, inMemory.Size(), streamed.Size())
+	assert.Equal(t, inMemory.Digest(), streamed.Digest())
+
+	spooled, err := services.SpoolVideo(streamed, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, inMemory.Digest(), spooled.Digest())
+
+	var source models.VideoSource = spooled
+	r, err := source.Open()
+	require.NoError(t, err)
+	copied, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, data, copied)
+
+	spooled.Remove()
+	_, err = spooled.Open()
+	assert.True(t, os.IsNotExist(err), "spool file should be removed")
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestUsageMetering(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	storagePath := t.TempDir()
+
+	newRouter := func(t *testing.T) *gin.Engine {
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         storagePath,
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			AdminAPIKey:         "admin-key",
+			UsageQuotas:         "*:2, team-b:key:1",
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return router
+	}
+
+	verify := func(router *gin.Engine, apiKey string, video *fileData) *httptest.ResponseRecorder {
+		fields := map[string]interface{}{}
+		if video != nil {
+			fields["video"] = video
+		}
+		body, contentType, err := createMultipartForm(fields)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	usage := func(router *gin.Engine, query string) (int, map[string]map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/admin/usage"+query, nil)
+		req.Header.Set("X-Admin-Key", "admin-key")
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Items []map[string]interface{} `json:"items"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		byKey := make(map[string]map[string]interface{})
+		for _, item := range response.Items {
+			byKey[item["client_key"].(string)] = item
+		}
+		return w.Code, byKey
+	}
+
+	router := newRouter(t)
+
+	t.Run("quotas cap verifications per key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, verify(router, "team-a", createTestVideoFile()).Code)
+		// Failed requests are not counted
+		assert.Equal(t, http.StatusBadRequest, verify(router, "team-a", nil).Code)
+		assert.Equal(t, http.StatusOK, verify(router, "team-a", createTestVideoFile()).Code)
+
+		w := verify(router, "team-a", createTestVideoFile())
+		require.Equal(t, http.StatusTooManyRequests, w.Code, w.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "QUOTA_EXCEEDED", response["code"])
+
+		assert.Equal(t, http.StatusOK, verify(router, "team-b:key", createTestVideoFile()).Code)
+		assert.Equal(t, http.StatusTooManyRequests, verify(router, "team-b:key", createTestVideoFile()).Code)
+	})
+
+	t.Run("usage is reported per key and month", func(t *testing.T) {
+		code, byKey := usage(router, "")
+		require.Equal(t, http.StatusOK, code)
+
+		teamA := byKey[services.ClientKeyFingerprint("team-a")]
+		require.NotNil(t, teamA)
+		assert.Equal(t, time.Now().UTC().Format(services.UsageMonthFormat), teamA["month"])
+		assert.EqualValues(t, 2, teamA["verifications"])
+		assert.EqualValues(t, 0, teamA["registrations"])
+		assert.EqualValues(t, 2, teamA["quota"])
+
+		teamB := byKey[services.ClientKeyFingerprint("team-b:key")]
+		require.NotNil(t, teamB)
+		assert.EqualValues(t, 1, teamB["verifications"])
+		assert.EqualValues(t, 1, teamB["quota"])
+
+		_, byKey = usage(router, "?client_key="+services.ClientKeyFingerprint("team-a"))
+		assert.Len(t, byKey, 1)
+
+		_, byKey = usage(router, "?month=2001-01")
+		assert.Empty(t, byKey)
+
+		code, _ = usage(router, "?month=january")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("usage survives a restart", func(t *testing.T) {
+		restarted := newRouter(t)
+		assert.Equal(t, http.StatusTooManyRequests, verify(restarted, "team-a", createTestVideoFile()).Code)
+
+		_, byKey := usage(restarted, "")
+		assert.EqualValues(t, 2, byKey[services.ClientKeyFingerprint("team-a")]["verifications"])
+	})
+}
@@ -0,0 +1,362 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/crypto/scrypt"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+// memoryVectorStore is a minimal alternative backend.
+type memoryVectorStore struct {
+	mu      sync.Mutex
+	vectors map[string][]models.FaceVector
+	saves   int
+}
+
+func (m *memoryVectorStore) Save(vector models.FaceVector) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vectors[vector.UserID] = append(m.vectors[vector.UserID], vector)
+	m.saves++
+	return nil
+}
+
+func (m *memoryVectorStore) Load(userID string) ([]models.FaceVector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.vectors[userID], nil
+}
+
+func (m *memoryVectorStore) List() (map[string][]models.FaceVector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := make(map[string][]models.FaceVector, len(m.vectors))
+	for userID, vectors := range m.vectors {
+		copied[userID] = append([]models.FaceVector(nil), vectors...)
+	}
+	return copied, nil
+}
+
+func (m *memoryVectorStore) Delete(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vectors, userID)
+	return nil
+}
+
+func (m *memoryVectorStore) Query(q storage.VectorQuery) ([]models.FaceVector, error) {
+	vectors, _ := m.Load(q.UserID)
+	return vectors, nil
+}
+
+func TestEncryptedFileVectorStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewEncryptedFileVectorStore(dir, "test-encryption-key-for-testing-only", 10*time.Second)
+	require.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour)
+	require.NoError(t, store.Save(models.FaceVector{UserID: "alice", Vector: []float32{1, 0}, CreatedAt: base, Version: "1.0"}))
+	require.NoError(t, store.Save(models.FaceVector{UserID: "alice", Vector: []float32{0, 1}, CreatedAt: base.Add(time.Minute), Version: "2.0"}))
+	require.NoError(t, store.Save(models.FaceVector{UserID: "bob", Vector: []float32{1, 1}, CreatedAt: base, Version: "1.0"}))
+
+	t.Run("load and list", func(t *testing.T) {
+		alice, err := store.Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, alice, 2)
+
+		all, err := store.List()
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		missing, err := store.Load("nobody")
+		require.NoError(t, err)
+		assert.Empty(t, missing)
+	})
+
+	t.Run("query filters", func(t *testing.T) {
+		v1, err := store.Query(storage.VectorQuery{Version: "1.0"})
+		require.NoError(t, err)
+		assert.Len(t, v1, 2)
+
+		recent, err := store.Query(storage.VectorQuery{UserID: "alice", CreatedAfter: base})
+		require.NoError(t, err)
+		require.Len(t, recent, 1)
+		assert.Equal(t, "2.0", recent[0].Version)
+	})
+
+	t.Run("contents survive reopening with the same key", func(t *testing.T) {
+		reopened, err := storage.NewEncryptedFileVectorStore(dir, "test-encryption-key-for-testing-only", 10*time.Second)
+		require.NoError(t, err)
+		all, err := reopened.List()
+		require.NoError(t, err)
+		assert.Len(t, all["alice"], 2)
+
+		wrongKey, err := storage.NewEncryptedFileVectorStore(dir, "another-key", 10*time.Second)
+		require.NoError(t, err)
+		_, err = wrongKey.List()
+		assert.Error(t, err)
+	})
+
+	t.Run("delete removes a user's enrollments", func(t *testing.T) {
+		require.NoError(t, store.Delete("bob"))
+		bob, err := store.Load("bob")
+		require.NoError(t, err)
+		assert.Empty(t, bob)
+
+		alice, err := store.Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, alice, 2)
+	})
+}
+
+func TestFaceVerificationService_CustomVectorStore(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	store := &memoryVectorStore{vectors: map[string][]models.FaceVector{
+		"preloaded-user": {{UserID: "preloaded-user", Vector: []float32{1, 0}, CreatedAt: time.Now(), Version: "1.0"}},
+	}}
+	require.NoError(t, service.SetVectorStore(store))
+	assert.Equal(t, 1, service.TemplateCount("preloaded-user"))
+
+	require.NoError(t, service.RegisterFace("custom-store-user", createTestVideoData()))
+	assert.Equal(t, 1, store.saves)
+	assert.Equal(t, 1, service.TemplateCount("custom-store-user"))
+
+	t.Run("unknown storage type is rejected", func(t *testing.T) {
+		_, err := services.NewFaceVerificationService(logger, &config.Config{
+			StoragePath:   t.TempDir(),
+			EncryptionKey: "test-encryption-key-for-testing-only",
+			StorageType:   "tape",
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptedFileVectorStore_Log(t *testing.T) {
+	const key = "test-encryption-key-for-testing-only"
+	vector := func(userID string, i int) models.FaceVector {
+		return models.FaceVector{UserID: userID, Vector: []float32{float32(i), 1}, CreatedAt: time.Now(), Version: "1.0"}
+	}
+	open := func(t *testing.T, dir string) *storage.EncryptedFileVectorStore {
+		store, err := storage.NewEncryptedFileVectorStore(dir, key, 10*time.Second)
+		require.NoError(t, err)
+		return store
+	}
+
+	t.Run("saves append to the log", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice", 0)))
+		require.NoError(t, store.Save(vector("alice", 1)))
+
+		assert.NoFileExists(t, filepath.Join(dir, "face_vectors.enc"))
+		assert.FileExists(t, filepath.Join(dir, "face_vectors.enc.log"))
+
+		alice, err := open(t, dir).Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, alice, 2)
+	})
+
+	t.Run("a full log is compacted into the vector file", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		store.SetLogCompactionSize(1)
+		require.NoError(t, store.Save(vector("alice", 0)))
+		require.NoError(t, store.Save(vector("bob", 0)))
+
+		assert.FileExists(t, filepath.Join(dir, "face_vectors.enc"))
+		assert.NoFileExists(t, filepath.Join(dir, "face_vectors.enc.log"))
+
+		all, err := open(t, dir).List()
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+	})
+
+	t.Run("deletion compacts the log", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice", 0)))
+		require.NoError(t, store.Save(vector("bob", 0)))
+		require.NoError(t, store.Delete("alice"))
+
+		assert.NoFileExists(t, filepath.Join(dir, "face_vectors.enc.log"))
+		all, err := store.List()
+		require.NoError(t, err)
+		assert.Contains(t, all, "bob")
+		assert.NotContains(t, all, "alice")
+	})
+
+	t.Run("a log left over from before a rewrite is ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice", 0)))
+		logPath := filepath.Join(dir, "face_vectors.enc.log")
+		stale, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+
+		// The process dies after rewriting the vector file but before
+		// removing the log
+		require.NoError(t, store.Delete("alice"))
+		require.NoError(t, os.WriteFile(logPath, stale, 0600))
+
+		alice, err := store.Load("alice")
+		require.NoError(t, err)
+		assert.Empty(t, alice)
+
+		require.NoError(t, store.Save(vector("bob", 0)))
+		all, err := store.List()
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+		assert.Contains(t, all, "bob")
+	})
+
+	t.Run("a torn record is cut off", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice", 0)))
+		require.NoError(t, store.Save(vector("alice", 1)))
+
+		// A crash midway through the second record
+		logPath := filepath.Join(dir, "face_vectors.enc.log")
+		info, err := os.Stat(logPath)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(logPath, info.Size()-10))
+
+		alice, err := store.Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, alice, 1)
+
+		require.NoError(t, store.Save(vector("alice", 2)))
+		alice, err = open(t, dir).Load("alice")
+		require.NoError(t, err)
+		assert.Len(t, alice, 2)
+	})
+}
+
+func TestEncryptedFileVectorStore_Integrity(t *testing.T) {
+	const key = "test-encryption-key-for-testing-only"
+	vector := func(userID string) models.FaceVector {
+		return models.FaceVector{UserID: userID, Vector: []float32{1, 0}, CreatedAt: time.Now(), Version: "1.0"}
+	}
+	open := func(t *testing.T, dir string) *storage.EncryptedFileVectorStore {
+		store, err := storage.NewEncryptedFileVectorStore(dir, key, 10*time.Second)
+		require.NoError(t, err)
+		store.SetLogCompactionSize(1)
+		return store
+	}
+	corrupt := func(t *testing.T, path string) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		data[len(data)-1] ^= 0xff
+		require.NoError(t, os.WriteFile(path, data, 0600))
+	}
+
+	t.Run("a damaged file is recovered from its backup", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice")))
+		require.NoError(t, store.Save(vector("bob")))
+		corrupt(t, filepath.Join(dir, "face_vectors.enc"))
+
+		all, err := store.List()
+		require.NoError(t, err)
+		assert.Contains(t, all, "alice")
+
+		// The next rewrite replaces the damaged file
+		require.NoError(t, store.Save(vector("carol")))
+		all, err = open(t, dir).List()
+		require.NoError(t, err)
+		assert.Contains(t, all, "alice")
+		assert.Contains(t, all, "carol")
+	})
+
+	t.Run("a damaged file without a backup is an error", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice")))
+		require.NoError(t, os.Remove(filepath.Join(dir, "face_vectors.enc.bak")))
+		corrupt(t, filepath.Join(dir, "face_vectors.enc"))
+
+		_, err := store.List()
+		assert.True(t, errors.Is(err, storage.ErrVectorFileCorrupted), "got %v", err)
+	})
+
+	t.Run("the backup does not keep deleted users", func(t *testing.T) {
+		dir := t.TempDir()
+		store := open(t, dir)
+		require.NoError(t, store.Save(vector("alice")))
+		require.NoError(t, store.Save(vector("bob")))
+		require.NoError(t, store.Delete("alice"))
+
+		backup, err := os.ReadFile(filepath.Join(dir, "face_vectors.enc.bak"))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "face_vectors.enc"), backup, 0600))
+
+		all, err := store.List()
+		require.NoError(t, err)
+		assert.NotContains(t, all, "alice")
+		assert.Contains(t, all, "bob")
+	})
+}
+
+// BenchmarkEncryptedFileVectorStore measures storage round trips over a
+// gallery of 100 users. The data key is derived once, when the store is
+// built; derive is what every operation cost when it was derived per call.
+func BenchmarkEncryptedFileVectorStore(b *testing.B) {
+	store, err := storage.NewEncryptedFileVectorStore(b.TempDir(), "benchmark-encryption-key", 10*time.Second)
+	require.NoError(b, err)
+	for i := 0; i < 100; i++ {
+		vector := models.FaceVector{UserID: fmt.Sprintf("user-%d", i), Vector: make([]float32, 128), CreatedAt: time.Now(), Version: "1.0"}
+		require.NoError(b, store.Save(vector))
+	}
+
+	b.Run("list", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.List(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// Decrypts and re-encrypts the whole file, like a save
+	b.Run("rewrite", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.RotateKey(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("derive", func(b *testing.B) {
+		salt := make([]byte, 16)
+		for i := 0; i < b.N; i++ {
+			if _, err := scrypt.Key([]byte("benchmark-encryption-key"), salt, 32768, 8, 1, 32); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+	"connect-hub/verification-service/internal/storage"
+)
+
+func TestParseVelocityLimits(t *testing.T) {
+	limits, err := services.ParseVelocityLimits("24h:5, 1h:3")
+	require.NoError(t, err)
+	assert.Equal(t, []services.VelocityLimit{
+		{Window: time.Hour, Accounts: 3},
+		{Window: 24 * time.Hour, Accounts: 5},
+	}, limits)
+
+	limits, err = services.ParseVelocityLimits("")
+	assert.NoError(t, err)
+	assert.Nil(t, limits)
+
+	for _, spec := range []string{"1h", "1h:0", "soon:3", "-1h:3", "1h:many"} {
+		_, err := services.ParseVelocityLimits(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestVelocityStores(t *testing.T) {
+	redisStore, err := storage.NewRedisVelocityStore("redis://" + newFakeRedis(t))
+	require.NoError(t, err)
+
+	for name, store := range map[string]storage.VelocityStore{
+		"memory": storage.NewMemoryVelocityStore(),
+		"redis":  redisStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			require.NoError(t, store.Observe(ctx, "face", "alice", now.Add(-2*time.Hour), 24*time.Hour))
+			require.NoError(t, store.Observe(ctx, "face", "bob", now.Add(-time.Minute), 24*time.Hour))
+			require.NoError(t, store.Observe(ctx, "face", "bob", now, 24*time.Hour))
+
+			count, err := store.Distinct(ctx, "face", now.Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+			count, err = store.Distinct(ctx, "face", now.Add(-24*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			count, err = store.Distinct(ctx, "device", now.Add(-24*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+	}
+}
+
+func TestVelocityChecks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		WarningsEnabled:     false,
+		VelocityLimits:      "1h:2",
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+
+	// Every test capture shows the same face
+	for _, user := range []string{"alice", "bob", "carol"} {
+		require.NoError(t, service.RegisterFace(user, createTestVideoData()))
+	}
+
+	warnings := func(t *testing.T, userID, device string) []string {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": userID,
+			"device":  device,
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data struct {
+				Warnings []struct {
+					Code string `json:"code"`
+				} `json:"warnings"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var codes []string
+		for _, warning := range response.Data.Warnings {
+			codes = append(codes, warning.Code)
+		}
+		return codes
+	}
+
+	assert.NotContains(t, warnings(t, "alice", "phone-1"), services.WarningVelocityExceeded)
+	assert.NotContains(t, warnings(t, "alice", "phone-2"), services.WarningVelocityExceeded, "retries by one account never count")
+	assert.NotContains(t, warnings(t, "bob", "phone-3"), services.WarningVelocityExceeded)
+	assert.Contains(t, warnings(t, "carol", "phone-4"), services.WarningVelocityExceeded, "a third account from the same face and address")
+}
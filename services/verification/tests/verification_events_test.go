@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+type streamedEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+// readEvents reads a Server-Sent Events stream until it ends.
+func readEvents(t *testing.T, url string) []streamedEvent {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var events []streamedEvent
+	var current streamedEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			current.name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &current.data))
+		case line == "" && current.name != "":
+			events = append(events, current)
+			current = streamedEvent{}
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestVerificationEvents(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:   0.5,
+		SimilarityThreshold: 0.75,
+		StoragePath:         t.TempDir(),
+		EncryptionKey:       "test-encryption-key-for-testing-only",
+		AsyncWorkers:        1,
+		AsyncQueueSize:      10,
+	}
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	t.Cleanup(service.Close)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	body, contentType, err := createMultipartForm(map[string]interface{}{
+		"video": createTestVideoFile(),
+		"mode":  "async",
+	})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/verify", body)
+	req.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var accepted struct {
+		Data struct {
+			VerificationID string `json:"verification_id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &accepted))
+	verificationID := accepted.Data.VerificationID
+
+	stages := map[string]int{
+		string(models.StageUploadReceived):  0,
+		string(models.StageFramesExtracted): 1,
+		string(models.StageLivenessDone):    2,
+		string(models.StageMatched):         3,
+	}
+
+	t.Run("progress is streamed until the outcome", func(t *testing.T) {
+		events := readEvents(t, server.URL+"/api/v1/verify/"+verificationID+"/events")
+		require.NotEmpty(t, events)
+
+		last := events[len(events)-1]
+		assert.Equal(t, "result", last.name)
+		assert.Contains(t, []interface{}{"completed", "failed"}, last.data["status"])
+
+		// However much of the job the stream caught, stages only move forward
+		previous := -1
+		for _, event := range events {
+			assert.Equal(t, verificationID, event.data["verification_id"])
+			stage, ok := event.data["stage"].(string)
+			require.True(t, ok, "every event names a stage")
+			assert.GreaterOrEqual(t, stages[stage], previous, stage)
+			previous = stages[stage]
+		}
+	})
+
+	t.Run("a finished verification streams its result at once", func(t *testing.T) {
+		events := readEvents(t, server.URL+"/api/v1/verify/"+verificationID+"/events")
+		require.Len(t, events, 1)
+		assert.Equal(t, "result", events[0].name)
+	})
+
+	t.Run("unknown verifications are not streamed", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/v1/verify/ver_1234567890/events")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/services"
+)
+
+// heldDecoder holds every capture until gate is closed.
+type heldDecoder struct {
+	sliceDecoder
+	gate chan struct{}
+}
+
+func (d *heldDecoder) Open(video io.Reader) (services.FrameIterator, error) {
+	<-d.gate
+	return d.sliceDecoder.Open(video)
+}
+
+func TestStartupWarmup(t *testing.T) {
+	newService := func(t *testing.T, enabled bool) *services.FaceVerificationService {
+		service, err := services.NewFaceVerificationService(zaptest.NewLogger(t), &config.Config{
+			FaceModelPath:       modelSourceDir(t),
+			RecognizerPoolSize:  2,
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         t.TempDir(),
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			WarmupEnabled:       enabled,
+			WarmupRequests:      3,
+		})
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	t.Run("not ready until the warmup ran", func(t *testing.T) {
+		service := newService(t, true)
+		// The synthetic capture is a JPEG
+		decoder := &heldDecoder{sliceDecoder: sliceDecoder{frames: createPanningFrames(3, 1, 0)}, gate: make(chan struct{})}
+		service.SetFormatDecoder(services.ContentTypeJPEG, decoder)
+
+		done := service.StartWarmup(context.Background())
+		readiness := service.Readiness(context.Background())
+		assert.False(t, readiness.Ready)
+		assert.True(t, readiness.WarmingUp)
+		assert.Empty(t, readiness.Checks)
+
+		close(decoder.gate)
+		select {
+		case <-done:
+		case <-time.After(30 * time.Second):
+			t.Fatal("warmup did not finish")
+		}
+
+		assert.False(t, service.WarmingUp())
+		assert.Equal(t, 2, service.RecognizerPoolStats().Loaded, "every recognizer is preloaded")
+		readiness = service.Readiness(context.Background())
+		assert.True(t, readiness.Ready)
+		assert.False(t, readiness.WarmingUp)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service := newService(t, false)
+
+		select {
+		case <-service.StartWarmup(context.Background()):
+		default:
+			t.Fatal("a disabled warmup must be done at once")
+		}
+		assert.False(t, service.WarmingUp())
+		assert.Equal(t, 1, service.RecognizerPoolStats().Loaded)
+	})
+}
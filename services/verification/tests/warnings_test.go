@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// createDimJPEG encodes a gradient scaled down to a dim but not blocked
+// brightness level.
+func createDimJPEG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 60 / 320), uint8(y * 60 / 240), 30, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}))
+	return buf.Bytes()
+}
+
+func warningCodes(result *models.VerificationResult) []string {
+	codes := []string{}
+	for _, warning := range result.Warnings {
+		codes = append(codes, warning.Code)
+	}
+	return codes
+}
+
+func TestVerificationWarnings(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		LivenessThreshold:    0.0,
+		SimilarityThreshold:  0.75,
+		StoragePath:          t.TempDir(),
+		EncryptionKey:        "test-encryption-key-for-testing-only",
+		WarningsEnabled:      true,
+		WarningMargin:        0.05,
+		WarningMinBrightness: 0.2,
+	}
+
+	service, err := services.NewFaceVerificationService(logger, cfg)
+	require.NoError(t, err)
+	defer service.Close()
+
+	t.Run("clean verification has no warnings", func(t *testing.T) {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "warnings-clean",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, result.Verified)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("borderline capture succeeds with warnings", func(t *testing.T) {
+		frame := createDimJPEG(t)
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			FrameData: [][]byte{frame, frame},
+			SessionID: "warnings-borderline",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, result.Verified)
+		codes := warningCodes(result)
+		assert.Contains(t, codes, services.WarningLowLight)
+		assert.Contains(t, codes, services.WarningFewFrames)
+		for _, warning := range result.Warnings {
+			assert.NotEmpty(t, warning.Message, warning.Code)
+		}
+	})
+
+	t.Run("borderline similarity is flagged", func(t *testing.T) {
+		videoData := createTestVideoFile().data
+		require.NoError(t, service.RegisterFace("warnings-user", videoData))
+
+		// An identical capture matches perfectly, so only a threshold right
+		// below the score makes the match borderline.
+		cfg.SimilarityThreshold = 0.99
+		defer func() { cfg.SimilarityThreshold = 0.75 }()
+
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: videoData,
+			UserID:    "warnings-user",
+			SessionID: "warnings-similarity",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, result.Verified)
+		assert.Contains(t, warningCodes(result), services.WarningBorderlineSimilarity)
+	})
+
+	t.Run("disabled warnings are never attached", func(t *testing.T) {
+		cfg.WarningsEnabled = false
+		defer func() { cfg.WarningsEnabled = true }()
+
+		frame := createDimJPEG(t)
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			FrameData: [][]byte{frame, frame},
+			SessionID: "warnings-disabled",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.Warnings)
+	})
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/services"
+)
+
+func TestWatchlist(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	storagePath := t.TempDir()
+
+	newRouter := func(t *testing.T) *gin.Engine {
+		cfg := &config.Config{
+			LivenessThreshold:   0.5,
+			SimilarityThreshold: 0.75,
+			StoragePath:         storagePath,
+			EncryptionKey:       "test-encryption-key-for-testing-only",
+			AdminAPIKey:         "admin-key",
+		}
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), cfg)
+		return router
+	}
+
+	admin := func(router *gin.Engine, method, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		var payload bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&payload).Encode(body))
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, &payload)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Key", "admin-key")
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	// Every test capture shows the same face
+	warnings := func(router *gin.Engine) []string {
+		body, contentType, err := createMultipartForm(map[string]interface{}{
+			"video":   createTestVideoFile(),
+			"user_id": "alice",
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/verify", body)
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data struct {
+				Warnings []struct {
+					Code string `json:"code"`
+				} `json:"warnings"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var codes []string
+		for _, warning := range response.Data.Warnings {
+			codes = append(codes, warning.Code)
+		}
+		return codes
+	}
+
+	router := newRouter(t)
+	registerBody, contentType, err := createMultipartForm(map[string]interface{}{
+		"video":   createTestVideoFile(),
+		"user_id": "alice",
+	})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/register", registerBody)
+	req.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var entryID string
+
+	t.Run("verifications are screened against the watchlist", func(t *testing.T) {
+		assert.NotContains(t, warnings(router), services.WarningWatchlistHit)
+
+		w, entry := admin(router, "POST", "/api/v1/admin/watchlist", map[string]interface{}{
+			"user_id": "alice",
+			"label":   "case-1042",
+			"reason":  "chargeback abuse",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		entryID, _ = entry["id"].(string)
+		assert.NotEmpty(t, entryID)
+		assert.Equal(t, "case-1042", entry["label"])
+		assert.NotContains(t, entry, "vector")
+
+		assert.Contains(t, warnings(router), services.WarningWatchlistHit)
+	})
+
+	t.Run("the watchlist survives a restart encrypted", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(storagePath, "watchlist.enc"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "case-1042")
+
+		router = newRouter(t)
+		w, response := admin(router, "GET", "/api/v1/admin/watchlist", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(1), response["count"])
+		assert.Contains(t, warnings(router), services.WarningWatchlistHit)
+	})
+
+	t.Run("entries can be added from templates and removed", func(t *testing.T) {
+		template := services.EncodeTemplate([]float32{0.1, 0.2, 0.3}, false)
+		w, _ := admin(router, "POST", "/api/v1/admin/watchlist", map[string]interface{}{
+			"template": base64.StdEncoding.EncodeToString(template),
+			"label":    "case-1043",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		_, response := admin(router, "GET", "/api/v1/admin/watchlist", nil)
+		assert.Equal(t, float64(2), response["count"])
+
+		w, _ = admin(router, "DELETE", "/api/v1/admin/watchlist/"+entryID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, warnings(router), services.WarningWatchlistHit)
+
+		w, response = admin(router, "DELETE", "/api/v1/admin/watchlist/"+entryID, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "WATCHLIST_ENTRY_NOT_FOUND", response["code"])
+	})
+
+	t.Run("invalid entries are rejected", func(t *testing.T) {
+		for name, body := range map[string]map[string]interface{}{
+			"no label":     {"user_id": "alice"},
+			"no face":      {"label": "case-1044"},
+			"both faces":   {"label": "case-1044", "user_id": "alice", "template": "AQAAAA=="},
+			"bad template": {"label": "case-1044", "template": "not base64"},
+			"not enrolled": {"label": "case-1044", "user_id": "bob"},
+		} {
+			w, _ := admin(router, "POST", "/api/v1/admin/watchlist", body)
+			if name == "not enrolled" {
+				assert.Equal(t, http.StatusNotFound, w.Code, name)
+				continue
+			}
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/watchlist", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"connect-hub/verification-service/internal/config"
+	"connect-hub/verification-service/internal/handlers"
+	"connect-hub/verification-service/internal/models"
+	"connect-hub/verification-service/internal/services"
+)
+
+// webhookReceiver fails the first `failures` requests (or all while
+// `failing` is set) and records correctly signed verification IDs.
+type webhookReceiver struct {
+	server   *httptest.Server
+	failures atomic.Int32
+	failing  atomic.Bool
+	received atomic.Int32
+	badSigs  atomic.Int32
+}
+
+func newWebhookReceiver(t *testing.T, secret string) *webhookReceiver {
+	receiver := &webhookReceiver{}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if r.Header.Get(services.WebhookSignatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			receiver.badSigs.Add(1)
+		}
+
+		if receiver.failing.Load() || receiver.failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		receiver.received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.server.Close)
+	return receiver
+}
+
+func TestWebhookDeliveryTracking(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	newService := func(t *testing.T, url string, maxAttempts int) *services.FaceVerificationService {
+		cfg := &config.Config{
+			LivenessThreshold:     0.5,
+			SimilarityThreshold:   0.75,
+			StoragePath:           t.TempDir(),
+			EncryptionKey:         "test-encryption-key-for-testing-only",
+			AdminAPIKey:           "test-admin-key",
+			WebhookURL:            url,
+			WebhookSecret:         "test-webhook-secret",
+			WebhookMaxAttempts:    maxAttempts,
+			WebhookRetryBackoffMs: 10,
+			WebhookTimeout:        5,
+		}
+
+		service, err := services.NewFaceVerificationService(logger, cfg)
+		require.NoError(t, err)
+		t.Cleanup(service.Close)
+		return service
+	}
+
+	verify := func(t *testing.T, service *services.FaceVerificationService) *models.VerificationResult {
+		result, err := service.VerifyVideo(&models.VerificationRequest{
+			VideoData: createTestVideoData(),
+			SessionID: "test-session-webhook",
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	waitForStatus := func(t *testing.T, service *services.FaceVerificationService, verificationID string, status models.WebhookDeliveryStatus) models.WebhookDelivery {
+		var delivery models.WebhookDelivery
+		require.Eventually(t, func() bool {
+			deliveries, err := service.WebhookDeliveries(status, verificationID)
+			require.NoError(t, err)
+			if len(deliveries) == 0 {
+				return false
+			}
+			delivery = deliveries[0]
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+		return delivery
+	}
+
+	t.Run("failing then succeeding receiver", func(t *testing.T) {
+		receiver := newWebhookReceiver(t, "test-webhook-secret")
+		receiver.failures.Store(2)
+		service := newService(t, receiver.server.URL, 5)
+
+		result := verify(t, service)
+		delivery := waitForStatus(t, service, result.VerificationID, models.WebhookDelivered)
+
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+		assert.Empty(t, delivery.LastError)
+		require.NotNil(t, delivery.DeliveredAt)
+		assert.False(t, delivery.DeliveredAt.Before(delivery.CreatedAt))
+		assert.Equal(t, receiver.server.URL, delivery.URL)
+		assert.Equal(t, int32(1), receiver.received.Load())
+		assert.Equal(t, int32(0), receiver.badSigs.Load())
+	})
+
+	t.Run("exhausted attempts are marked failed and can be re-delivered", func(t *testing.T) {
+		receiver := newWebhookReceiver(t, "test-webhook-secret")
+		receiver.failing.Store(true)
+		service := newService(t, receiver.server.URL, 2)
+
+		router := gin.New()
+		handlers.RegisterRoutes(router, handlers.NewVerificationHandler(service, logger), service.Config())
+
+		result := verify(t, service)
+		failed := waitForStatus(t, service, result.VerificationID, models.WebhookFailed)
+
+		assert.Equal(t, 2, failed.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, failed.LastStatusCode)
+		assert.Contains(t, failed.LastError, "503")
+		assert.Nil(t, failed.DeliveredAt)
+
+		// The receiver recovers; an operator re-delivers through the admin API
+		receiver.failing.Store(false)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/admin/webhooks/"+failed.ID+"/redeliver", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		delivered := waitForStatus(t, service, result.VerificationID, models.WebhookDelivered)
+		assert.Equal(t, failed.ID, delivered.ID)
+		assert.Equal(t, 3, delivered.Attempts)
+		assert.Equal(t, http.StatusOK, delivered.LastStatusCode)
+		assert.NotNil(t, delivered.DeliveredAt)
+		assert.Equal(t, int32(1), receiver.received.Load())
+
+		// The admin listing reflects the tracked status
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/api/v1/admin/webhooks?status=delivered&verification_id="+result.VerificationID, nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var listing struct {
+			Items []models.WebhookDelivery `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+		require.Len(t, listing.Items, 1)
+		assert.Equal(t, 3, listing.Items[0].Attempts)
+	})
+
+	t.Run("unknown delivery", func(t *testing.T) {
+		receiver := newWebhookReceiver(t, "test-webhook-secret")
+		service := newService(t, receiver.server.URL, 1)
+
+		_, err := service.RedeliverWebhook("whd_missing")
+		assert.ErrorIs(t, err, services.ErrDeliveryNotFound)
+	})
+
+	t.Run("disabled without a URL", func(t *testing.T) {
+		service := newService(t, "", 1)
+
+		_, err := service.WebhookDeliveries("", "")
+		assert.ErrorIs(t, err, services.ErrWebhooksDisabled)
+		_, err = service.RedeliverWebhook("whd_missing")
+		assert.ErrorIs(t, err, services.ErrWebhooksDisabled)
+	})
+}